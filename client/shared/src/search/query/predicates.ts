@@ -24,6 +24,7 @@ export const PREDICATES: Access[] = [
                         name: 'commit',
                         fields: [{ name: 'after' }],
                     },
+                    { name: 'symbol' },
                 ],
             },
         ],
@@ -33,7 +34,7 @@ export const PREDICATES: Access[] = [
         fields: [
             {
                 name: 'contains',
-                fields: [{ name: 'content' }],
+                fields: [{ name: 'content' }, { name: 'symbol' }],
             },
         ],
     },
@@ -36,6 +36,8 @@ export interface ContentMatch {
     branches?: string[]
     version?: string
     lineMatches: LineMatch[]
+    /** The ranges of the matches in lineMatches. A match spanning multiple lines has a single range. */
+    matchRanges?: Range[]
 }
 
 interface LineMatch {
@@ -45,6 +47,19 @@ interface LineMatch {
     aggregableBadges?: AggregableBadge[]
 }
 
+/** The range of a match, from start up to but excluding end. */
+interface Range {
+    start: Location
+    end: Location
+}
+
+/** A position in a file. line and column are 0-based, column counts characters. */
+interface Location {
+    offset: number
+    line: number
+    column: number
+}
+
 export interface SymbolMatch {
     type: 'symbol'
     name: string
@@ -5,18 +5,27 @@ import BitbucketIcon from 'mdi-react/BitbucketIcon'
 import GithubIcon from 'mdi-react/GithubIcon'
 import GitIcon from 'mdi-react/GitIcon'
 import GitLabIcon from 'mdi-react/GitlabIcon'
+import LanguageGoIcon from 'mdi-react/LanguageGoIcon'
 import LanguageJavaIcon from 'mdi-react/LanguageJavaIcon'
+import MicrosoftAzureDevopsIcon from 'mdi-react/MicrosoftAzureDevopsIcon'
+import NpmIcon from 'mdi-react/NpmIcon'
+import SourceRepositoryIcon from 'mdi-react/SourceRepositoryIcon'
 import React from 'react'
 
 import { PhabricatorIcon } from '@sourcegraph/shared/src/components/icons'
 
 import awsCodeCommitSchemaJSON from '../../../../../schema/aws_codecommit.schema.json'
+import azureDevOpsSchemaJSON from '../../../../../schema/azuredevops.schema.json'
 import bitbucketCloudSchemaJSON from '../../../../../schema/bitbucket_cloud.schema.json'
 import bitbucketServerSchemaJSON from '../../../../../schema/bitbucket_server.schema.json'
+import giteaSchemaJSON from '../../../../../schema/gitea.schema.json'
 import githubSchemaJSON from '../../../../../schema/github.schema.json'
 import gitlabSchemaJSON from '../../../../../schema/gitlab.schema.json'
 import gitoliteSchemaJSON from '../../../../../schema/gitolite.schema.json'
+import goModulesSchemaJSON from '../../../../../schema/go-modules.schema.json'
 import jvmPackagesSchemaJSON from '../../../../../schema/jvm-packages.schema.json'
+import mercurialSchemaJSON from '../../../../../schema/mercurial.schema.json'
+import npmPackagesSchemaJSON from '../../../../../schema/npm-packages.schema.json'
 import otherExternalServiceSchemaJSON from '../../../../../schema/other_external_service.schema.json'
 import perforceSchemaJSON from '../../../../../schema/perforce.schema.json'
 import phabricatorSchemaJSON from '../../../../../schema/phabricator.schema.json'
@@ -1013,6 +1022,145 @@ const GITOLITE: AddExternalServiceOptions = {
         },
     ],
 }
+const GITEA: AddExternalServiceOptions = {
+    kind: ExternalServiceKind.GITEA,
+    title: 'Gitea',
+    icon: GitIcon,
+    jsonSchema: giteaSchemaJSON,
+    defaultDisplayName: 'Gitea',
+    defaultConfig: `{
+  "url": "https://gitea.example.com",
+  "token": "<access token>",
+  "repositoryQuery": ["affiliated"]
+}`,
+    instructions: (
+        <div>
+            <ol>
+                <li>
+                    In the configuration below, set <Field>url</Field> to the URL of your Gitea (or Forgejo) instance.
+                </li>
+                <li>
+                    Create a Gitea access token in <strong>Settings &gt; Applications</strong> and set it as the{' '}
+                    <Field>token</Field>. To enforce repository permissions, the token must belong to a site admin.
+                </li>
+                <li>
+                    Use <Field>repositoryQuery</Field>, <Field>orgs</Field> or <Field>repos</Field> to select the
+                    repositories to sync.
+                </li>
+            </ol>
+            <p>
+                See{' '}
+                <a
+                    rel="noopener noreferrer"
+                    target="_blank"
+                    href="https://docs.sourcegraph.com/admin/external_service/gitea#configuration"
+                >
+                    the docs for more advanced options
+                </a>
+                , or try one of the buttons below.
+            </p>
+        </div>
+    ),
+    editorActions: [
+        {
+            id: 'addOrgRepos',
+            label: 'Add repositories in an organization',
+            run: (config: string) => {
+                const value = '<organization name>'
+                const edits = setProperty(config, ['orgs', -1], value, defaultFormattingOptions)
+                return { edits, selectText: value }
+            },
+        },
+        {
+            id: 'addSingleRepo',
+            label: 'Add single repository',
+            run: (config: string) => {
+                const value = '<owner>/<repository>'
+                const edits = setProperty(config, ['repos', -1], value, defaultFormattingOptions)
+                return { edits, selectText: value }
+            },
+        },
+        {
+            id: 'enforcePermissions',
+            label: 'Enforce permissions',
+            run: (config: string) => {
+                const value = { identityProvider: { type: 'username' } }
+                const edits = setProperty(config, ['authorization'], value, defaultFormattingOptions)
+                return { edits, selectText: '"authorization"' }
+            },
+        },
+    ],
+}
+const AZURE_DEVOPS: AddExternalServiceOptions = {
+    kind: ExternalServiceKind.AZUREDEVOPS,
+    title: 'Azure DevOps',
+    icon: MicrosoftAzureDevopsIcon,
+    jsonSchema: azureDevOpsSchemaJSON,
+    defaultDisplayName: 'Azure DevOps',
+    defaultConfig: `{
+  "url": "https://dev.azure.com",
+  "token": "<personal access token>",
+  "orgs": []
+}`,
+    instructions: (
+        <div>
+            <ol>
+                <li>
+                    In the configuration below, set <Field>url</Field> to <code>https://dev.azure.com</code>, or to the
+                    URL of your Azure DevOps Server collection.
+                </li>
+                <li>
+                    Create a personal access token with the <strong>Code (Read)</strong> scope and set it as the{' '}
+                    <Field>token</Field>. Batch changes also require the <strong>Code (Read &amp; write)</strong> and{' '}
+                    <strong>Code (Status)</strong> scopes.
+                </li>
+                <li>
+                    Use <Field>orgs</Field> or <Field>projects</Field> to select the repositories to sync.
+                </li>
+            </ol>
+            <p>
+                See{' '}
+                <a
+                    rel="noopener noreferrer"
+                    target="_blank"
+                    href="https://docs.sourcegraph.com/admin/external_service/azuredevops#configuration"
+                >
+                    the docs for more advanced options
+                </a>
+                , or try one of the buttons below.
+            </p>
+        </div>
+    ),
+    editorActions: [
+        {
+            id: 'addOrgRepos',
+            label: 'Add repositories in an organization',
+            run: (config: string) => {
+                const value = '<organization name>'
+                const edits = setProperty(config, ['orgs', -1], value, defaultFormattingOptions)
+                return { edits, selectText: value }
+            },
+        },
+        {
+            id: 'addProjectRepos',
+            label: 'Add repositories in a project',
+            run: (config: string) => {
+                const value = '<organization name>/<project name>'
+                const edits = setProperty(config, ['projects', -1], value, defaultFormattingOptions)
+                return { edits, selectText: value }
+            },
+        },
+        {
+            id: 'excludeRepo',
+            label: 'Exclude a repository',
+            run: (config: string) => {
+                const value = { name: '<organization name>/<project name>/<repository name>' }
+                const edits = setProperty(config, ['exclude', -1], value, defaultFormattingOptions)
+                return { edits, selectText: '<organization name>/<project name>/<repository name>' }
+            },
+        },
+    ],
+}
 const PHABRICATOR_SERVICE: AddExternalServiceOptions = {
     kind: ExternalServiceKind.PHABRICATOR,
     title: 'Phabricator connection',
@@ -1118,6 +1266,72 @@ const GENERIC_GIT: AddExternalServiceOptions = {
         },
     ],
 }
+const MERCURIAL: AddExternalServiceOptions = {
+    kind: ExternalServiceKind.MERCURIAL,
+    title: 'Mercurial',
+    icon: SourceRepositoryIcon,
+    jsonSchema: mercurialSchemaJSON,
+    defaultDisplayName: 'Mercurial',
+    defaultConfig: `{
+  "url": "https://hg.example.com",
+  "repos": []
+}`,
+    instructions: (
+        <div>
+            <ol>
+                <li>
+                    In the configuration below, set <Field>url</Field> to the URL your Mercurial repositories are
+                    served from.
+                </li>
+                <li>
+                    Add the paths of the repositories you wish to index to the <Field>repos</Field> field. These will be
+                    appended to the URL to obtain the repository clone URLs. The repositories are converted to Git
+                    repositories when they are cloned.
+                </li>
+            </ol>
+            <p>
+                See{' '}
+                <a
+                    rel="noopener noreferrer"
+                    target="_blank"
+                    href="https://docs.sourcegraph.com/admin/external_service/mercurial#configuration"
+                >
+                    the docs for more options
+                </a>
+                , or try one of the buttons below.
+            </p>
+        </div>
+    ),
+    editorActions: [
+        {
+            id: 'addRepo',
+            label: 'Add a repository',
+            run: (config: string) => {
+                const value = 'path/to/repository'
+                const edits = setProperty(config, ['repos', -1], value, defaultFormattingOptions)
+                return { edits, selectText: value }
+            },
+        },
+        {
+            id: 'setUsername',
+            label: 'Set HTTP(S) username',
+            run: (config: string) => {
+                const value = '<username>'
+                const edits = setProperty(config, ['username'], value, defaultFormattingOptions)
+                return { edits, selectText: value }
+            },
+        },
+        {
+            id: 'setPassword',
+            label: 'Set HTTP(S) password',
+            run: (config: string) => {
+                const value = '<password>'
+                const edits = setProperty(config, ['password'], value, defaultFormattingOptions)
+                return { edits, selectText: value }
+            },
+        },
+    ],
+}
 const PERFORCE: AddExternalServiceOptions = {
     kind: ExternalServiceKind.PERFORCE,
     title: 'Perforce',
@@ -1208,6 +1422,62 @@ const JVM_PACKAGES: AddExternalServiceOptions = {
     ),
     editorActions: [],
 }
+const NPM_PACKAGES: AddExternalServiceOptions = {
+    kind: ExternalServiceKind.NPMPACKAGES,
+    title: 'npm Dependencies',
+    icon: NpmIcon,
+    jsonSchema: npmPackagesSchemaJSON,
+    defaultDisplayName: 'npm Dependencies',
+    defaultConfig: `{
+  "registry": "https://registry.npmjs.org",
+  "dependencies": []
+}`,
+    instructions: (
+        <div>
+            <ol>
+                <li>
+                    In the configuration below, set <Field>registry</Field> to the URL of the npm registry. For example,
+                    <code>"https://registry.npmjs.org"</code>.
+                </li>
+                <li>
+                    In the configuration below, set <Field>dependencies</Field> to the list of packages that you want to
+                    manually add. For example,
+                    <code>"lodash@4.17.21"</code> or
+                    <code>"@types/node@16.4.0"</code>.
+                </li>
+            </ol>
+        </div>
+    ),
+    editorActions: [],
+}
+const GO_MODULES: AddExternalServiceOptions = {
+    kind: ExternalServiceKind.GOMODULES,
+    title: 'Go Dependencies',
+    icon: LanguageGoIcon,
+    jsonSchema: goModulesSchemaJSON,
+    defaultDisplayName: 'Go Dependencies',
+    defaultConfig: `{
+  "urls": ["https://proxy.golang.org"],
+  "dependencies": []
+}`,
+    instructions: (
+        <div>
+            <ol>
+                <li>
+                    In the configuration below, set <Field>urls</Field> to the list of Go module proxies. For example,
+                    <code>"https://proxy.golang.org"</code>.
+                </li>
+                <li>
+                    In the configuration below, set <Field>dependencies</Field> to the list of modules that you want to
+                    manually add. For example,
+                    <code>"golang.org/x/net@v0.0.0-20210813160813-60bc85c4be6d"</code> or
+                    <code>"github.com/pkg/errors"</code> for all its versions.
+                </li>
+            </ol>
+        </div>
+    ),
+    editorActions: [],
+}
 
 export const codeHostExternalServices: Record<string, AddExternalServiceOptions> = {
     github: GITHUB_DOTCOM,
@@ -1219,9 +1489,14 @@ export const codeHostExternalServices: Record<string, AddExternalServiceOptions>
     aws_codecommit: AWS_CODE_COMMIT,
     srcservegit: SRC_SERVE_GIT,
     gitolite: GITOLITE,
+    gitea: GITEA,
+    azuredevops: AZURE_DEVOPS,
+    mercurial: MERCURIAL,
     git: GENERIC_GIT,
     ...(window.context?.experimentalFeatures?.perforce === 'enabled' ? { perforce: PERFORCE } : {}),
     ...(window.context?.experimentalFeatures?.jvmPackages === 'enabled' ? { jvmPackages: JVM_PACKAGES } : {}),
+    ...(window.context?.experimentalFeatures?.npmPackages === 'enabled' ? { npmPackages: NPM_PACKAGES } : {}),
+    ...(window.context?.experimentalFeatures?.goModules === 'enabled' ? { goModules: GO_MODULES } : {}),
 }
 
 export const nonCodeHostExternalServices: Record<string, AddExternalServiceOptions> = {
@@ -1239,9 +1514,14 @@ export const defaultExternalServices: Record<ExternalServiceKind, AddExternalSer
     [ExternalServiceKind.BITBUCKETSERVER]: BITBUCKET_SERVER,
     [ExternalServiceKind.GITLAB]: GITLAB_DOTCOM,
     [ExternalServiceKind.GITOLITE]: GITOLITE,
+    [ExternalServiceKind.GITEA]: GITEA,
+    [ExternalServiceKind.AZUREDEVOPS]: AZURE_DEVOPS,
     [ExternalServiceKind.PHABRICATOR]: PHABRICATOR_SERVICE,
     [ExternalServiceKind.OTHER]: GENERIC_GIT,
     [ExternalServiceKind.AWSCODECOMMIT]: AWS_CODE_COMMIT,
+    [ExternalServiceKind.MERCURIAL]: MERCURIAL,
     [ExternalServiceKind.PERFORCE]: PERFORCE,
     [ExternalServiceKind.JVMPACKAGES]: JVM_PACKAGES,
+    [ExternalServiceKind.NPMPACKAGES]: NPM_PACKAGES,
+    [ExternalServiceKind.GOMODULES]: GO_MODULES,
 }
@@ -118,3 +118,35 @@ add('Bitbucket Server', () => (
         )}
     </EnterpriseWebStory>
 ))
+
+add('Bitbucket Cloud', () => (
+    <EnterpriseWebStory>
+        {props => (
+            <AddCredentialModal
+                {...props}
+                userID="user-id-1"
+                externalServiceKind={ExternalServiceKind.BITBUCKETCLOUD}
+                externalServiceURL="https://bitbucket.org/"
+                requiresSSH={false}
+                afterCreate={noop}
+                onCancel={noop}
+            />
+        )}
+    </EnterpriseWebStory>
+))
+
+add('AWS CodeCommit', () => (
+    <EnterpriseWebStory>
+        {props => (
+            <AddCredentialModal
+                {...props}
+                userID="user-id-1"
+                externalServiceKind={ExternalServiceKind.AWSCODECOMMIT}
+                externalServiceURL="arn:aws:codecommit:us-west-1:123456789012:"
+                requiresSSH={false}
+                afterCreate={noop}
+                onCancel={noop}
+            />
+        )}
+    </EnterpriseWebStory>
+))
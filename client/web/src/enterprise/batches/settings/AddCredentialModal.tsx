@@ -56,17 +56,51 @@ const helpTexts: Record<ExternalServiceKind, JSX.Element> = {
             with <code>write</code> permissions on the project and repository level.
         </>
     ),
+    [ExternalServiceKind.AZUREDEVOPS]: (
+        <>
+            <a href={HELP_TEXT_LINK_URL} rel="noreferrer noopener" target="_blank">
+                Create a new personal access token
+            </a>{' '}
+            with <code>Code (Read &amp; write)</code> and <code>Code (Status)</code> scopes.
+        </>
+    ),
+    [ExternalServiceKind.BITBUCKETCLOUD]: (
+        <>
+            <a href={HELP_TEXT_LINK_URL} rel="noreferrer noopener" target="_blank">
+                Create a new app password
+            </a>{' '}
+            with <code>Account (Read)</code>, <code>Repositories (Write)</code>, and{' '}
+            <code>Pull requests (Write)</code> permissions.
+        </>
+    ),
+    [ExternalServiceKind.AWSCODECOMMIT]: (
+        <>
+            <a href={HELP_TEXT_LINK_URL} rel="noreferrer noopener" target="_blank">
+                Generate HTTPS Git credentials
+            </a>{' '}
+            for an IAM user that can push to the repositories.
+        </>
+    ),
 
     // These are just for type completeness and serve as placeholders for a bright future.
-    [ExternalServiceKind.BITBUCKETCLOUD]: <span>Unsupported</span>,
+    [ExternalServiceKind.GITEA]: <span>Unsupported</span>,
     [ExternalServiceKind.GITOLITE]: <span>Unsupported</span>,
+    [ExternalServiceKind.GOMODULES]: <span>Unsupported</span>,
     [ExternalServiceKind.JVMPACKAGES]: <span>Unsupported</span>,
+    [ExternalServiceKind.MERCURIAL]: <span>Unsupported</span>,
+    [ExternalServiceKind.NPMPACKAGES]: <span>Unsupported</span>,
     [ExternalServiceKind.PERFORCE]: <span>Unsupported</span>,
     [ExternalServiceKind.PHABRICATOR]: <span>Unsupported</span>,
-    [ExternalServiceKind.AWSCODECOMMIT]: <span>Unsupported</span>,
     [ExternalServiceKind.OTHER]: <span>Unsupported</span>,
 }
 
+/**
+ * Code hosts that authenticate with a username and password pair rather than
+ * with a token alone.
+ */
+const requiresUsername = (kind: ExternalServiceKind): boolean =>
+    kind === ExternalServiceKind.BITBUCKETCLOUD || kind === ExternalServiceKind.AWSCODECOMMIT
+
 type Step = 'add-token' | 'get-ssh-key'
 
 export const AddCredentialModal: React.FunctionComponent<AddCredentialModalProps> = ({
@@ -81,10 +115,15 @@ export const AddCredentialModal: React.FunctionComponent<AddCredentialModalProps
 }) => {
     const labelId = 'addCredential'
     const [isLoading, setIsLoading] = useState<boolean | Error>(false)
+    const [username, setUsername] = useState<string>('')
     const [credential, setCredential] = useState<string>('')
     const [sshPublicKey, setSSHPublicKey] = useState<string>()
     const [step, setStep] = useState<Step>(initialStep)
 
+    const onChangeUsername = useCallback<React.ChangeEventHandler<HTMLInputElement>>(event => {
+        setUsername(event.target.value)
+    }, [])
+
     const onChangeCredential = useCallback<React.ChangeEventHandler<HTMLInputElement>>(event => {
         setCredential(event.target.value)
     }, [])
@@ -96,6 +135,7 @@ export const AddCredentialModal: React.FunctionComponent<AddCredentialModalProps
             try {
                 const createdCredential = await createBatchChangesCredential({
                     user: userID,
+                    username: requiresUsername(externalServiceKind) ? username : null,
                     credential,
                     externalServiceKind,
                     externalServiceURL,
@@ -113,6 +153,7 @@ export const AddCredentialModal: React.FunctionComponent<AddCredentialModalProps
         [
             afterCreate,
             userID,
+            username,
             credential,
             externalServiceKind,
             externalServiceURL,
@@ -164,8 +205,26 @@ export const AddCredentialModal: React.FunctionComponent<AddCredentialModalProps
                     <>
                         {isErrorLike(isLoading) && <ErrorAlert error={isLoading} />}
                         <Form onSubmit={onSubmit}>
+                            {requiresUsername(externalServiceKind) && (
+                                <div className="form-group">
+                                    <label htmlFor="username">Username</label>
+                                    <input
+                                        id="username"
+                                        name="username"
+                                        type="text"
+                                        className="form-control test-add-credential-modal-username"
+                                        required={true}
+                                        spellCheck="false"
+                                        minLength={1}
+                                        value={username}
+                                        onChange={onChangeUsername}
+                                    />
+                                </div>
+                            )}
                             <div className="form-group">
-                                <label htmlFor="token">Personal access token</label>
+                                <label htmlFor="token">
+                                    {requiresUsername(externalServiceKind) ? 'Password' : 'Personal access token'}
+                                </label>
                                 <input
                                     id="token"
                                     name="token"
@@ -190,7 +249,11 @@ export const AddCredentialModal: React.FunctionComponent<AddCredentialModalProps
                                 </button>
                                 <button
                                     type="submit"
-                                    disabled={isLoading === true || credential.length === 0}
+                                    disabled={
+                                        isLoading === true ||
+                                        credential.length === 0 ||
+                                        (requiresUsername(externalServiceKind) && username.length === 0)
+                                    }
                                     className="btn btn-primary test-add-credential-modal-submit"
                                 >
                                     {isLoading === true && <LoadingSpinner className="icon-inline" />}
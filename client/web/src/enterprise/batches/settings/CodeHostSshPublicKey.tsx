@@ -11,10 +11,16 @@ const configInstructionLinks: Record<ExternalServiceKind, string> = {
     [ExternalServiceKind.GITLAB]: 'https://docs.gitlab.com/ee/ssh/#add-an-ssh-key-to-your-gitlab-account',
     [ExternalServiceKind.BITBUCKETSERVER]:
         'https://confluence.atlassian.com/bitbucketserver/ssh-user-keys-for-personal-use-776639793.html',
+    [ExternalServiceKind.AZUREDEVOPS]:
+        'https://docs.microsoft.com/en-us/azure/devops/repos/git/use-ssh-keys-to-authenticate',
     [ExternalServiceKind.AWSCODECOMMIT]: 'unsupported',
-    [ExternalServiceKind.BITBUCKETCLOUD]: 'unsupported',
+    [ExternalServiceKind.BITBUCKETCLOUD]: 'https://support.atlassian.com/bitbucket-cloud/docs/set-up-an-ssh-key/',
+    [ExternalServiceKind.GITEA]: 'unsupported',
     [ExternalServiceKind.GITOLITE]: 'unsupported',
+    [ExternalServiceKind.GOMODULES]: 'unsupported',
     [ExternalServiceKind.JVMPACKAGES]: 'unsupported',
+    [ExternalServiceKind.MERCURIAL]: 'unsupported',
+    [ExternalServiceKind.NPMPACKAGES]: 'unsupported',
     [ExternalServiceKind.OTHER]: 'unsupported',
     [ExternalServiceKind.PERFORCE]: 'unsupported',
     [ExternalServiceKind.PHABRICATOR]: 'unsupported',
@@ -0,0 +1,273 @@
+package graphqlbackend
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/cockroachdb/errors"
+
+	"github.com/sourcegraph/sourcegraph/internal/search"
+	"github.com/sourcegraph/sourcegraph/internal/search/query"
+	"github.com/sourcegraph/sourcegraph/internal/search/result"
+)
+
+// Backends a search is sent to, as reported by explainSearchQuery.
+const (
+	backendRepo     = "repo"
+	backendZoekt    = "zoekt"
+	backendSearcher = "searcher"
+	backendSymbols  = "symbols"
+	backendCommit   = "commit"
+)
+
+// queryExplanation describes how a search query is evaluated without running
+// it. It is returned as JSON by explainSearchQuery, so its shape is part of
+// the API.
+type queryExplanation struct {
+	Query       string              `json:"query"`
+	PatternType string              `json:"patternType"`
+	ParseTree   []interface{}       `json:"parseTree"`
+	Plan        []*stageExplanation `json:"plan"`
+}
+
+// stageExplanation describes a query of the plan, which is evaluated on its
+// own and whose results are unioned with those of the other queries.
+type stageExplanation struct {
+	Query     string        `json:"query"`
+	ParseTree []interface{} `json:"parseTree"`
+
+	// Predicates are the predicates (e.g. repo:contains.file(...)) of the
+	// query. They are expanded by running subqueries when the query is
+	// evaluated, so Searches is empty if there are any.
+	Predicates []string `json:"predicates,omitempty"`
+
+	// Operator is "and" or "or" if the pattern of the query is an expression
+	// whose operands are searched for separately.
+	Operator string               `json:"operator,omitempty"`
+	Searches []*searchExplanation `json:"searches,omitempty"`
+}
+
+// searchExplanation describes a single search operation, as performed by
+// evaluateLeaf.
+type searchExplanation struct {
+	Query       string      `json:"query"`
+	ResultTypes []string    `json:"resultTypes"`
+	Mode        string      `json:"mode"`
+	Backends    []string    `json:"backends"`
+	Repos       *repoCounts `json:"repos"`
+}
+
+// repoCounts are the number of repositories a search resolved to.
+type repoCounts struct {
+	Resolved         int `json:"resolved"`
+	Missing          int `json:"missing"`
+	ExcludedForks    int `json:"excludedForks"`
+	ExcludedArchived int `json:"excludedArchived"`
+}
+
+func (r *schemaResolver) ExplainSearchQuery(ctx context.Context, args *SearchArgs) (*JSONValue, error) {
+	impl, err := NewSearchImplementer(ctx, r.db, args)
+	if err != nil {
+		return nil, err
+	}
+	sr, ok := impl.(*searchResolver)
+	if !ok {
+		// The query is invalid, NewSearchImplementer returns an alert
+		// describing why.
+		if a, ok := impl.(*alertSearchImplementer); ok {
+			return nil, errors.New(a.alert.description)
+		}
+		return nil, errors.Errorf("unexpected search implementer %T", impl)
+	}
+
+	explanation, err := sr.explain(ctx)
+	if err != nil {
+		return nil, err
+	}
+	b, err := json.Marshal(explanation)
+	if err != nil {
+		return nil, err
+	}
+	return &JSONValue{Value: string(b)}, nil
+}
+
+// explain describes how r evaluates its plan. It mirrors resultsRecursive,
+// evaluate and doResults, but only resolves repositories instead of
+// searching them.
+func (r *searchResolver) explain(ctx context.Context) (*queryExplanation, error) {
+	nodes, err := query.Parse(r.OriginalQuery, r.PatternType)
+	if err != nil {
+		return nil, err
+	}
+
+	e := &queryExplanation{
+		Query:       r.OriginalQuery,
+		PatternType: r.PatternType.String(),
+		ParseTree:   toJSONs(nodes),
+		Plan:        make([]*stageExplanation, 0, len(r.Plan)),
+	}
+	for _, q := range r.Plan {
+		stage, err := r.explainStage(ctx, q)
+		if err != nil {
+			return nil, err
+		}
+		e.Plan = append(e.Plan, stage)
+	}
+	return e, nil
+}
+
+func (r *searchResolver) explainStage(ctx context.Context, q query.Basic) (*stageExplanation, error) {
+	stage := &stageExplanation{
+		Query:     q.String(),
+		ParseTree: toJSONs(q.ToParseTree()),
+	}
+
+	query.VisitParameter(q.ToParseTree(), func(field, value string, negated bool, ann query.Annotation) {
+		if ann.Labels.IsSet(query.IsPredicate) {
+			p := query.Parameter{Field: field, Value: value, Negated: negated}
+			stage.Predicates = append(stage.Predicates, query.StringHuman([]query.Node{p}))
+		}
+	})
+	if len(stage.Predicates) > 0 {
+		return stage, nil
+	}
+
+	// See evaluate and evaluatePatternExpression.
+	var leaves []query.Q
+	switch term := q.Pattern.(type) {
+	case nil:
+		leaves = []query.Q{query.ToNodes(q.Parameters)}
+	case query.Operator:
+		switch term.Kind {
+		case query.And:
+			stage.Operator = "and"
+		case query.Or:
+			stage.Operator = "or"
+		}
+		if stage.Operator == "" {
+			leaves = []query.Q{q.ToParseTree()}
+			break
+		}
+		for _, operand := range term.Operands {
+			leaves = append(leaves, q.MapPattern(operand).ToParseTree())
+		}
+	default:
+		leaves = []query.Q{q.ToParseTree()}
+	}
+
+	for _, leaf := range leaves {
+		s, err := r.explainSearch(ctx, leaf)
+		if err != nil {
+			return nil, err
+		}
+		stage.Searches = append(stage.Searches, s)
+	}
+	return stage, nil
+}
+
+func (r *searchResolver) explainSearch(ctx context.Context, q query.Q) (*searchExplanation, error) {
+	args, _, err := r.textParameters(q)
+	if err != nil {
+		return nil, err
+	}
+
+	// Every search may resolve to different repositories, so neither use nor
+	// fill the repo cache of r.
+	opts := r.toRepoOptions(q, resolveRepositoriesOpts{})
+	opts.CacheLookup = false
+	resolved, err := r.resolveRepositories(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	return &searchExplanation{
+		Query:       query.StringHuman(q),
+		ResultTypes: resultTypeNames(args.ResultTypes),
+		Mode:        args.Mode.String(),
+		Backends:    searchBackends(args),
+		Repos: &repoCounts{
+			Resolved:         len(resolved.RepoRevs),
+			Missing:          len(resolved.MissingRepoRevs),
+			ExcludedForks:    resolved.ExcludedRepos.Forks,
+			ExcludedArchived: resolved.ExcludedRepos.Archived,
+		},
+	}, nil
+}
+
+// searchBackends returns the backends doResults sends a search with args to.
+func searchBackends(args *search.TextParameters) []string {
+	var backends []string
+	add := func(backend string) {
+		for _, b := range backends {
+			if b == backend {
+				return
+			}
+		}
+		backends = append(backends, backend)
+	}
+
+	jobs := planSearchJobs(args)
+
+	if jobs.repo {
+		add(backendRepo)
+	}
+
+	if jobs.symbol {
+		if args.PatternInfo.Index != query.No {
+			add(backendZoekt)
+		}
+		if args.PatternInfo.Index != query.Only {
+			add(backendSymbols)
+		}
+	}
+
+	if jobs.globalZoekt {
+		add(backendZoekt)
+	}
+
+	if jobs.filePath {
+		switch {
+		case jobs.globalZoekt:
+			// Only the unindexed repos are left to search.
+			add(backendSearcher)
+		case args.PatternInfo.IsStructuralPat:
+			// Zoekt narrows down the files searcher runs comby on.
+			if args.PatternInfo.Index != query.No {
+				add(backendZoekt)
+			}
+			add(backendSearcher)
+		default:
+			if args.PatternInfo.Index != query.No {
+				add(backendZoekt)
+			}
+			if args.PatternInfo.Index != query.Only {
+				add(backendSearcher)
+			}
+		}
+	}
+
+	if jobs.diff || jobs.commit {
+		add(backendCommit)
+	}
+
+	return backends
+}
+
+// resultTypeNames returns the names of types, in a stable order.
+func resultTypeNames(types result.Types) []string {
+	var names []string
+	for _, t := range []result.Types{result.TypeRepo, result.TypeSymbol, result.TypeFile, result.TypePath, result.TypeDiff, result.TypeCommit} {
+		if types.Has(t) {
+			names = append(names, t.String())
+		}
+	}
+	return names
+}
+
+func toJSONs(nodes []query.Node) []interface{} {
+	jsons := make([]interface{}, 0, len(nodes))
+	for _, node := range nodes {
+		jsons = append(jsons, toJSON(node))
+	}
+	return jsons
+}
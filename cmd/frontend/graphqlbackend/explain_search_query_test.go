@@ -0,0 +1,173 @@
+package graphqlbackend
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/sourcegraph/sourcegraph/internal/search"
+	"github.com/sourcegraph/sourcegraph/internal/search/query"
+	searchrepos "github.com/sourcegraph/sourcegraph/internal/search/repos"
+	"github.com/sourcegraph/sourcegraph/internal/search/run"
+	"github.com/sourcegraph/sourcegraph/internal/types"
+	"github.com/sourcegraph/sourcegraph/schema"
+)
+
+func TestExplain(t *testing.T) {
+	mockResolveRepositories = func() (searchrepos.Resolved, error) {
+		return searchrepos.Resolved{
+			RepoRevs: []*search.RepositoryRevisions{
+				{Repo: types.RepoName{ID: 1, Name: "a"}},
+				{Repo: types.RepoName{ID: 2, Name: "b"}},
+			},
+			ExcludedRepos: searchrepos.ExcludedRepos{Forks: 3},
+		}, nil
+	}
+	defer func() { mockResolveRepositories = nil }()
+
+	explain := func(t *testing.T, q string) *queryExplanation {
+		t.Helper()
+		plan, err := query.Pipeline(query.InitLiteral(q))
+		if err != nil {
+			t.Fatal(err)
+		}
+		r := &searchResolver{
+			SearchInputs: &run.SearchInputs{
+				Plan:          plan,
+				Query:         plan.ToParseTree(),
+				OriginalQuery: q,
+				UserSettings:  &schema.Settings{},
+				PatternType:   query.SearchTypeLiteral,
+			},
+			reposMu:  &sync.Mutex{},
+			resolved: &searchrepos.Resolved{},
+		}
+		e, err := r.explain(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		return e
+	}
+
+	t.Run("global search", func(t *testing.T) {
+		e := explain(t, "foo")
+		if len(e.Plan) != 1 || len(e.Plan[0].Searches) != 1 {
+			t.Fatalf("expected a single search, got %+v", e.Plan)
+		}
+		want := &searchExplanation{
+			Query:       `foo`,
+			ResultTypes: []string{"repo", "file", "path"},
+			Mode:        "ZoektGlobalSearch",
+			Backends:    []string{"repo", "zoekt", "searcher"},
+			Repos:       &repoCounts{Resolved: 2, ExcludedForks: 3},
+		}
+		if diff := cmp.Diff(want, e.Plan[0].Searches[0]); diff != "" {
+			t.Errorf("unexpected search (-want +got):\n%s", diff)
+		}
+	})
+
+	t.Run("backends", func(t *testing.T) {
+		cases := []struct {
+			query string
+			want  []string
+		}{
+			{query: "repo:a foo", want: []string{"repo", "zoekt", "searcher"}},
+			{query: "repo:a foo index:only", want: []string{"repo", "zoekt"}},
+			{query: "repo:a foo index:no", want: []string{"repo", "searcher"}},
+			{query: "repo:a type:symbol foo", want: []string{"zoekt", "symbols"}},
+			{query: "repo:a type:commit foo", want: []string{"commit"}},
+			{query: "repo:a type:diff foo", want: []string{"commit"}},
+			{query: "repo:a", want: []string{"repo"}},
+		}
+		for _, tc := range cases {
+			t.Run(tc.query, func(t *testing.T) {
+				e := explain(t, tc.query)
+				if len(e.Plan) != 1 || len(e.Plan[0].Searches) != 1 {
+					t.Fatalf("expected a single search, got %+v", e.Plan)
+				}
+				if diff := cmp.Diff(tc.want, e.Plan[0].Searches[0].Backends); diff != "" {
+					t.Errorf("unexpected backends (-want +got):\n%s", diff)
+				}
+			})
+		}
+	})
+
+	t.Run("plan", func(t *testing.T) {
+		e := explain(t, "(repo:a foo) or (repo:b bar)")
+		if len(e.Plan) != 2 {
+			t.Fatalf("expected 2 stages, got %d", len(e.Plan))
+		}
+		for _, stage := range e.Plan {
+			if len(stage.Searches) != 1 {
+				t.Errorf("expected a single search for %q, got %d", stage.Query, len(stage.Searches))
+			}
+		}
+	})
+
+	t.Run("operator", func(t *testing.T) {
+		e := explain(t, "repo:a foo and bar")
+		if len(e.Plan) != 1 {
+			t.Fatalf("expected 1 stage, got %d", len(e.Plan))
+		}
+		stage := e.Plan[0]
+		if stage.Operator != "and" {
+			t.Errorf("expected operator and, got %q", stage.Operator)
+		}
+		var got []string
+		for _, s := range stage.Searches {
+			got = append(got, s.Query)
+		}
+		if diff := cmp.Diff([]string{"repo:a foo", "repo:a bar"}, got); diff != "" {
+			t.Errorf("unexpected searches (-want +got):\n%s", diff)
+		}
+	})
+
+	t.Run("predicate", func(t *testing.T) {
+		e := explain(t, "repo:contains.file(README) foo")
+		if len(e.Plan) != 1 {
+			t.Fatalf("expected 1 stage, got %d", len(e.Plan))
+		}
+		stage := e.Plan[0]
+		if diff := cmp.Diff([]string{"repo:contains.file(README)"}, stage.Predicates); diff != "" {
+			t.Errorf("unexpected predicates (-want +got):\n%s", diff)
+		}
+		if len(stage.Searches) != 0 {
+			t.Errorf("expected no searches, got %d", len(stage.Searches))
+		}
+	})
+}
+
+func TestExplainKeepsResolver(t *testing.T) {
+	mockResolveRepositories = func() (searchrepos.Resolved, error) {
+		return searchrepos.Resolved{RepoRevs: []*search.RepositoryRevisions{{Repo: types.RepoName{ID: 1, Name: "a"}}}}, nil
+	}
+	defer func() { mockResolveRepositories = nil }()
+
+	// An empty structural pattern falls back to literal search.
+	plan, err := query.Pipeline(query.InitStructural("repo:a"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := &searchResolver{
+		SearchInputs: &run.SearchInputs{
+			Plan:          plan,
+			Query:         plan.ToParseTree(),
+			OriginalQuery: "repo:a",
+			UserSettings:  &schema.Settings{},
+			PatternType:   query.SearchTypeStructural,
+		},
+		reposMu:  &sync.Mutex{},
+		resolved: &searchrepos.Resolved{},
+	}
+	if _, err := r.explain(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if r.PatternType != query.SearchTypeStructural {
+		t.Errorf("expected pattern type to stay structural, got %s", r.PatternType)
+	}
+	if r.invalidateRepoCache {
+		t.Error("expected repo cache to stay valid")
+	}
+}
@@ -175,6 +175,17 @@ func (r *updateScheduleResolver) Total() int32 {
 	return int32(r.schedule.Total)
 }
 
+func (r *updateScheduleResolver) LastUpdatedAt() *DateTime {
+	return DateTimeOrNil(r.schedule.LastUpdatedAt)
+}
+
+func (r *updateScheduleResolver) LastError() *string {
+	if r.schedule.LastError == "" {
+		return nil
+	}
+	return &r.schedule.LastError
+}
+
 func (r *repositoryMirrorInfoResolver) UpdateQueue(ctx context.Context) (*updateQueueResolver, error) {
 	info, err := r.repoUpdateSchedulerInfo(ctx)
 	if err != nil {
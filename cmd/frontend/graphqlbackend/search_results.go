@@ -41,6 +41,7 @@ import (
 	"github.com/sourcegraph/sourcegraph/internal/search/run"
 	"github.com/sourcegraph/sourcegraph/internal/search/searchcontexts"
 	"github.com/sourcegraph/sourcegraph/internal/search/streaming"
+	"github.com/sourcegraph/sourcegraph/internal/search/symbol"
 	"github.com/sourcegraph/sourcegraph/internal/trace"
 	"github.com/sourcegraph/sourcegraph/internal/trace/ot"
 	"github.com/sourcegraph/sourcegraph/internal/types"
@@ -561,21 +562,34 @@ func withMode(args search.TextParameters, st query.SearchType, versionContext *s
 }
 
 func (r *searchResolver) toTextParameters(q query.Q) (*search.TextParameters, error) {
+	args, patternType, err := r.textParameters(q)
+	if err != nil {
+		return nil, err
+	}
+	r.PatternType = patternType
+	return args, nil
+}
+
+// textParameters returns the parameters of a search for q and the pattern type
+// it is run with, which may differ from r.PatternType. Unlike
+// toTextParameters, it doesn't modify r.
+func (r *searchResolver) textParameters(q query.Q) (*search.TextParameters, query.SearchType, error) {
+	patternType := r.PatternType
 	forceResultTypes := result.TypeEmpty
-	if r.PatternType == query.SearchTypeStructural {
+	if patternType == query.SearchTypeStructural {
 		forceResultTypes = result.TypeFile
 	}
 
 	b, err := query.ToBasicQuery(q)
 	if err != nil {
-		return nil, err
+		return nil, patternType, err
 	}
 	p := search.ToTextPatternInfo(b, r.protocol(), query.Identity)
 
 	// Fallback to literal search for searching repos and files if
 	// the structural search pattern is empty.
-	if r.PatternType == query.SearchTypeStructural && p.Pattern == "" {
-		r.PatternType = query.SearchTypeLiteral
+	if patternType == query.SearchTypeStructural && p.Pattern == "" {
+		patternType = query.SearchTypeLiteral
 		p.IsStructuralPat = false
 		forceResultTypes = result.Types(0)
 	}
@@ -593,8 +607,8 @@ func (r *searchResolver) toTextParameters(q query.Q) (*search.TextParameters, er
 		RepoPromise:  &search.RepoPromise{},
 	}
 	args = withResultTypes(args, forceResultTypes)
-	args = withMode(args, r.PatternType, r.VersionContext)
-	return &args, nil
+	args = withMode(args, patternType, r.VersionContext)
+	return &args, patternType, nil
 }
 
 // evaluateLeaf performs a single search operation and corresponds to the
@@ -952,6 +966,11 @@ func (r *searchResolver) resultsStreaming(ctx context.Context) (*SearchResultsRe
 		// Ensure downstream events sent on the stream are processed by `select:`.
 		selectPath, _ := filter.SelectPathFromString(sp) // Invariant: error already checked
 		r.stream = streaming.WithSelect(r.stream, selectPath)
+		if selectPath.Root() == filter.Symbol {
+			// Content matches need their enclosing symbols before they
+			// can be selected.
+			r.stream = symbol.WithEnclosingSymbols(ctx, r.stream)
+		}
 	}
 	sr, err := r.resultsRecursive(ctx, r.Plan)
 	srr := r.resultsToResolver(sr)
@@ -1046,6 +1065,11 @@ func (r *searchResolver) resultsRecursive(ctx context.Context, plan query.Plan)
 		}
 
 		if newResult != nil {
+			// Streamed results already got their enclosing symbols from
+			// symbol.WithEnclosingSymbols.
+			if r.stream == nil && selectsSymbols(q) {
+				symbol.ResolveEnclosingSymbols(ctx, newResult.Matches)
+			}
 			newResult.Matches = result.Select(newResult.Matches, q)
 			sr = union(sr, newResult)
 			if len(sr.Matches) > wantCount {
@@ -1061,19 +1085,38 @@ func (r *searchResolver) resultsRecursive(ctx context.Context, plan query.Plan)
 	return sr, err
 }
 
+// selectsSymbols returns whether q selects symbols, e.g. with select:symbol.function.
+func selectsSymbols(q query.Basic) bool {
+	v, _ := q.ToParseTree().StringValue(query.FieldSelect)
+	sp, _ := filter.SelectPathFromString(v) // Invariant: select already validated
+	return sp.Root() == filter.Symbol
+}
+
 // searchResultsToRepoNodes converts a set of search results into repository nodes
-// such that they can be used to replace a repository predicate
+// such that they can be used to replace a repository predicate. File matches,
+// as returned by symbol predicates, contribute their repository.
 func searchResultsToRepoNodes(matches []result.Match) ([]query.Node, error) {
 	nodes := make([]query.Node, 0, len(matches))
+	seen := make(map[api.RepoName]struct{}, len(matches))
 	for _, match := range matches {
-		repoMatch, ok := match.(*result.RepoMatch)
-		if !ok {
+		var repoName api.RepoName
+		switch m := match.(type) {
+		case *result.RepoMatch:
+			repoName = m.Name
+		case *result.FileMatch:
+			repoName = m.Repo.Name
+		default:
 			return nil, errors.Errorf("expected type %T, but got %T", &result.RepoMatch{}, match)
 		}
 
+		if _, ok := seen[repoName]; ok {
+			continue
+		}
+		seen[repoName] = struct{}{}
+
 		nodes = append(nodes, query.Parameter{
 			Field: query.FieldRepo,
-			Value: "^" + regexp.QuoteMeta(string(repoMatch.Name)) + "$",
+			Value: "^" + regexp.QuoteMeta(string(repoName)) + "$",
 		})
 	}
 
@@ -1440,10 +1483,11 @@ func (r *searchResolver) doResults(ctx context.Context, args *search.TextParamet
 	}()
 
 	args.RepoOptions = r.toRepoOptions(args.Query, resolveRepositoriesOpts{})
+	jobs := planSearchJobs(args)
 
 	// performance optimization: call zoekt early, resolve repos concurrently, filter
 	// search results with resolved repos.
-	if args.Mode == search.ZoektGlobalSearch {
+	if jobs.globalZoekt {
 		argsIndexed := *args
 
 		// Get all private repos that are accessible by the current actor.
@@ -1510,7 +1554,7 @@ func (r *searchResolver) doResults(ctx context.Context, args *search.TextParamet
 	// results before the above reporting.
 	args.RepoPromise.Resolve(resolved.RepoRevs)
 
-	if args.ResultTypes.Has(result.TypeRepo) {
+	if jobs.repo {
 		wg := waitGroup(true)
 		wg.Add(1)
 		goroutine.Go(func() {
@@ -1520,7 +1564,7 @@ func (r *searchResolver) doResults(ctx context.Context, args *search.TextParamet
 
 	}
 
-	if args.ResultTypes.Has(result.TypeSymbol) && args.PatternInfo.Pattern != "" {
+	if jobs.symbol {
 		wg := waitGroup(args.ResultTypes.Without(result.TypeSymbol) == 0)
 		wg.Add(1)
 		goroutine.Go(func() {
@@ -1529,18 +1573,16 @@ func (r *searchResolver) doResults(ctx context.Context, args *search.TextParamet
 		})
 	}
 
-	if args.ResultTypes.Has(result.TypeFile | result.TypePath) {
-		if args.Mode != search.SkipContentAndPathSearch {
-			wg := waitGroup(true)
-			wg.Add(1)
-			goroutine.Go(func() {
-				defer wg.Done()
-				_ = agg.DoFilePathSearch(ctx, args)
-			})
-		}
+	if jobs.filePath {
+		wg := waitGroup(true)
+		wg.Add(1)
+		goroutine.Go(func() {
+			defer wg.Done()
+			_ = agg.DoFilePathSearch(ctx, args)
+		})
 	}
 
-	if args.ResultTypes.Has(result.TypeDiff) {
+	if jobs.diff {
 		wg := waitGroup(args.ResultTypes.Without(result.TypeDiff) == 0)
 		wg.Add(1)
 		goroutine.Go(func() {
@@ -1549,7 +1591,7 @@ func (r *searchResolver) doResults(ctx context.Context, args *search.TextParamet
 		})
 	}
 
-	if args.ResultTypes.Has(result.TypeCommit) {
+	if jobs.commit {
 		wg := waitGroup(args.ResultTypes.Without(result.TypeCommit) == 0)
 		wg.Add(1)
 		goroutine.Go(func() {
@@ -1599,6 +1641,42 @@ func (r *searchResolver) doResults(ctx context.Context, args *search.TextParamet
 	}, err
 }
 
+// searchJobs are the searches doResults runs.
+type searchJobs struct {
+	repo   bool
+	symbol bool
+
+	// globalZoekt is a search of files and paths in all indexed repos,
+	// which starts before repos are resolved.
+	globalZoekt bool
+
+	// filePath is a search of files and paths in the resolved repos. For
+	// global searches, it only searches the unindexed ones.
+	filePath bool
+
+	diff   bool
+	commit bool
+}
+
+// planSearchJobs returns the searches doResults runs for args.
+func planSearchJobs(args *search.TextParameters) searchJobs {
+	jobs := searchJobs{
+		repo:        args.ResultTypes.Has(result.TypeRepo),
+		symbol:      args.ResultTypes.Has(result.TypeSymbol) && args.PatternInfo.Pattern != "",
+		globalZoekt: args.Mode == search.ZoektGlobalSearch,
+		filePath:    args.ResultTypes.Has(result.TypeFile|result.TypePath) && args.Mode != search.SkipContentAndPathSearch,
+		diff:        args.ResultTypes.Has(result.TypeDiff),
+		commit:      args.ResultTypes.Has(result.TypeCommit),
+	}
+	if jobs.globalZoekt && envvar.SourcegraphDotComMode() {
+		// On sourcegraph.com and for unscoped queries, determineRepos returns
+		// the subset of indexed default searchrepos, so there are no
+		// unindexed repos to search.
+		jobs.filePath = false
+	}
+	return jobs
+}
+
 // isContextError returns true if ctx.Err() is not nil or if err
 // is an error caused by context cancelation or timeout.
 func isContextError(ctx context.Context, err error) bool {
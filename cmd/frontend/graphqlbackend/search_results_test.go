@@ -1078,3 +1078,49 @@ func TestZeroElapsedMilliseconds(t *testing.T) {
 		t.Fatalf("got %d, want %d", got, 0)
 	}
 }
+
+func TestSearchResultsToRepoNodes(t *testing.T) {
+	fileMatch := func(repo api.RepoName, path string) *result.FileMatch {
+		return &result.FileMatch{File: result.File{Repo: types.RepoName{Name: repo}, Path: path}}
+	}
+	repoNode := func(value string) query.Node {
+		return query.Parameter{Field: query.FieldRepo, Value: value}
+	}
+
+	cases := []struct {
+		name    string
+		matches []result.Match
+		want    []query.Node
+	}{
+		{
+			name:    "repo matches",
+			matches: []result.Match{&result.RepoMatch{Name: "a.b/c"}, &result.RepoMatch{Name: "d"}},
+			want:    []query.Node{repoNode(`^a\.b/c$`), repoNode(`^d$`)},
+		},
+		{
+			name:    "file matches in the same repo",
+			matches: []result.Match{fileMatch("a", "x.go"), fileMatch("a", "y.go"), fileMatch("b", "x.go"), fileMatch("a", "z.go")},
+			want:    []query.Node{repoNode(`^a$`), repoNode(`^b$`)},
+		},
+		{
+			name:    "repo and file matches",
+			matches: []result.Match{&result.RepoMatch{Name: "a"}, fileMatch("a", "x.go")},
+			want:    []query.Node{repoNode(`^a$`)},
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := searchResultsToRepoNodes(tc.matches)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("unexpected nodes (-want +got):\n%s", diff)
+			}
+		})
+	}
+
+	if _, err := searchResultsToRepoNodes([]result.Match{&result.CommitMatch{}}); err == nil {
+		t.Error("expected an error for a commit match")
+	}
+}
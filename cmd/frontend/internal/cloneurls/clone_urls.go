@@ -30,6 +30,8 @@ func ReposourceCloneURLToRepoName(ctx context.Context, db dbutil.DB, cloneURL st
 		Kinds: []string{
 			extsvc.KindGitHub,
 			extsvc.KindGitLab,
+			extsvc.KindGitea,
+			extsvc.KindAzureDevOps,
 			extsvc.KindBitbucketServer,
 			extsvc.KindAWSCodeCommit,
 			extsvc.KindGitolite,
@@ -65,6 +67,12 @@ func ReposourceCloneURLToRepoName(ctx context.Context, db dbutil.DB, cloneURL st
 			case *schema.GitLabConnection:
 				rs = reposource.GitLab{GitLabConnection: c}
 				host = c.Url
+			case *schema.GiteaConnection:
+				rs = reposource.Gitea{GiteaConnection: c}
+				host = c.Url
+			case *schema.AzureDevOpsConnection:
+				rs = reposource.AzureDevOps{AzureDevOpsConnection: c}
+				host = c.Url
 			case *schema.BitbucketServerConnection:
 				rs = reposource.BitbucketServer{BitbucketServerConnection: c}
 				host = c.Url
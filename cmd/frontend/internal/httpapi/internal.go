@@ -5,10 +5,12 @@ import (
 	"context"
 	"encoding/json"
 	"io"
+	"net"
 	"net/http"
 	"net/url"
 	"path"
 	"strconv"
+	"time"
 
 	"github.com/cockroachdb/errors"
 	"github.com/gorilla/mux"
@@ -601,10 +603,10 @@ func serveGitExec(w http.ResponseWriter, r *http.Request) error {
 		return errors.Wrap(err, "Encode")
 	}
 
-	// Find the correct shard to query
-	addr := gitserver.DefaultClient.AddrForRepo(repo.Name)
+	// Find the shards which keep a copy of the repo, in the order to query them
+	addrs := gitserver.DefaultClient.AddrsForRepo(repo.Name)
 
-	director := func(req *http.Request) {
+	director := func(req *http.Request, addr string) {
 		req.URL.Scheme = "http"
 		req.URL.Host = addr
 		req.URL.Path = "/exec"
@@ -612,7 +614,7 @@ func serveGitExec(w http.ResponseWriter, r *http.Request) error {
 		req.ContentLength = int64(buf.Len())
 	}
 
-	gitserver.DefaultReverseProxy.ServeHTTP(repo.Name, "POST", "exec", director, w, r)
+	gitserver.DefaultReverseProxy.ServeHTTPWithFailover(repo.Name, "POST", "exec", addrs, director, w, r)
 	return nil
 }
 
@@ -620,8 +622,44 @@ func serveGitExec(w http.ResponseWriter, r *http.Request) error {
 // gitserver for the repo.
 type gitServiceHandler struct {
 	Gitserver interface {
-		AddrForRepo(api.RepoName) string
+		AddrsForRepo(api.RepoName) []string
 	}
+
+	// Reachable reports whether the gitserver at addr accepts connections. If
+	// nil, a TCP connection to addr is attempted.
+	Reachable func(addr string) bool
+}
+
+// gitserverDialTimeout is how long we try to connect to a gitserver before
+// redirecting to the next copy of a repo.
+const gitserverDialTimeout = time.Second
+
+func (s *gitServiceHandler) reachable(addr string) bool {
+	if s.Reachable != nil {
+		return s.Reachable(addr)
+	}
+	conn, err := net.DialTimeout("tcp", addr, gitserverDialTimeout)
+	if err != nil {
+		return false
+	}
+	conn.Close()
+	return true
+}
+
+// addrForRepo returns the address of the first gitserver keeping a copy of
+// repo which accepts connections, or of the gitserver responsible for repo if
+// none does.
+func (s *gitServiceHandler) addrForRepo(repo api.RepoName) string {
+	addrs := s.Gitserver.AddrsForRepo(repo)
+	if len(addrs) == 1 {
+		return addrs[0]
+	}
+	for _, addr := range addrs {
+		if s.reachable(addr) {
+			return addr
+		}
+	}
+	return addrs[0]
 }
 
 func (s *gitServiceHandler) serveInfoRefs(w http.ResponseWriter, r *http.Request) {
@@ -637,7 +675,7 @@ func (s *gitServiceHandler) redirectToGitServer(w http.ResponseWriter, r *http.R
 
 	u := &url.URL{
 		Scheme:   "http",
-		Host:     s.Gitserver.AddrForRepo(api.RepoName(repo)),
+		Host:     s.addrForRepo(api.RepoName(repo)),
 		Path:     path.Join("/git", repo, gitPath),
 		RawQuery: r.URL.RawQuery,
 	}
@@ -54,10 +54,46 @@ func TestGitServiceHandlers(t *testing.T) {
 	}
 }
 
+func TestGitServiceHandlers_failover(t *testing.T) {
+	m := apirouter.NewInternal(mux.NewRouter())
+
+	gitService := &gitServiceHandler{
+		Gitserver: mockAddrsForRepo{"gitserver-0", "gitserver-1", "gitserver-2"},
+		Reachable: func(addr string) bool { return addr == "gitserver-1" },
+	}
+	m.Get(apirouter.GitInfoRefs).Handler(http.HandlerFunc(gitService.serveInfoRefs))
+
+	req := httptest.NewRequest("GET", "/git/foo/bar/info/refs?service=git-upload-pack", nil)
+	w := httptest.NewRecorder()
+	m.ServeHTTP(w, req)
+
+	want := "http://gitserver-1/git/foo/bar/info/refs?service=git-upload-pack"
+	if got := w.Result().Header.Get("Location"); got != want {
+		t.Errorf("mismatched location:\ngot:  %s\nwant: %s", got, want)
+	}
+
+	// If no gitserver can be reached, we redirect to the one responsible for
+	// the repo.
+	gitService.Reachable = func(string) bool { return false }
+	w = httptest.NewRecorder()
+	m.ServeHTTP(w, req)
+
+	want = "http://gitserver-0/git/foo/bar/info/refs?service=git-upload-pack"
+	if got := w.Result().Header.Get("Location"); got != want {
+		t.Errorf("mismatched location:\ngot:  %s\nwant: %s", got, want)
+	}
+}
+
 type mockAddrForRepo struct{}
 
-func (mockAddrForRepo) AddrForRepo(name api.RepoName) string {
-	return strings.ReplaceAll(string(name), "/", ".") + ".gitserver"
+func (mockAddrForRepo) AddrsForRepo(name api.RepoName) []string {
+	return []string{strings.ReplaceAll(string(name), "/", ".") + ".gitserver"}
+}
+
+type mockAddrsForRepo []string
+
+func (m mockAddrsForRepo) AddrsForRepo(api.RepoName) []string {
+	return m
 }
 
 func TestReposIndex(t *testing.T) {
@@ -0,0 +1,62 @@
+package webhookhandlers
+
+import (
+	"context"
+	"net/url"
+
+	"github.com/cockroachdb/errors"
+	gh "github.com/google/go-github/v28/github"
+	"github.com/inconshreveable/log15"
+
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/webhooks"
+	"github.com/sourcegraph/sourcegraph/internal/api"
+	"github.com/sourcegraph/sourcegraph/internal/database"
+	"github.com/sourcegraph/sourcegraph/internal/database/dbutil"
+	"github.com/sourcegraph/sourcegraph/internal/extsvc"
+	"github.com/sourcegraph/sourcegraph/internal/types"
+	"github.com/sourcegraph/sourcegraph/schema"
+)
+
+// handleGitHubRepoPushEvent handles a github push event and asks repo-updater
+// to update the pushed repo.
+func handleGitHubRepoPushEvent(db dbutil.DB) func(ctx context.Context, extSvc *types.ExternalService, payload interface{}) error {
+	return func(ctx context.Context, extSvc *types.ExternalService, payload interface{}) error {
+		e, ok := payload.(*gh.PushEvent)
+		if !ok {
+			return errors.Errorf("incorrect event type sent to github push event handler: %T", payload)
+		}
+
+		log15.Debug("handleGitHubRepoPushEvent: Got github push event", "repo", e.GetRepo().GetFullName())
+
+		serviceID, err := githubServiceID(extSvc)
+		if err != nil {
+			return err
+		}
+
+		return webhooks.EnqueueRepoUpdate(ctx, database.Repos(db), api.ExternalRepoSpec{
+			ID:          e.GetRepo().GetNodeID(),
+			ServiceType: extsvc.TypeGitHub,
+			ServiceID:   serviceID,
+		})
+	}
+}
+
+// githubServiceID returns the service ID of the repos synced by the given
+// GitHub external service.
+func githubServiceID(extSvc *types.ExternalService) (string, error) {
+	c, err := extSvc.Configuration()
+	if err != nil {
+		return "", errors.Wrap(err, "getting external service config")
+	}
+
+	con, ok := c.(*schema.GitHubConnection)
+	if !ok {
+		return "", errors.Errorf("external service %d is not a GitHub connection", extSvc.ID)
+	}
+
+	u, err := url.Parse(con.Url)
+	if err != nil {
+		return "", errors.Wrap(err, "parsing GitHub URL")
+	}
+	return extsvc.NormalizeBaseURL(u).String(), nil
+}
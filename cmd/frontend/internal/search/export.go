@@ -0,0 +1,295 @@
+package search
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cockroachdb/errors"
+
+	"github.com/sourcegraph/sourcegraph/internal/search/result"
+)
+
+// Export formats supported by the stream search handler. They are selected
+// with the "format" URL parameter.
+const (
+	exportFormatCSV   = "csv"
+	exportFormatJSONL = "jsonl"
+)
+
+// exportRow is a single row of an export. Content matches produce a row per
+// matched line, symbol matches a row per symbol and all other matches a row
+// each.
+type exportRow struct {
+	Type       string `json:"type"`
+	Repository string `json:"repository"`
+	Revision   string `json:"revision,omitempty"`
+	Commit     string `json:"commit,omitempty"`
+	Path       string `json:"path,omitempty"`
+	// Line is 1-based, and 0 if the row is not about a line.
+	Line    int    `json:"line,omitempty"`
+	Symbol  string `json:"symbol,omitempty"`
+	Preview string `json:"preview,omitempty"`
+	Author  string `json:"author,omitempty"`
+	Date    string `json:"date,omitempty"`
+}
+
+var exportColumns = []string{"type", "repository", "revision", "commit", "path", "line", "symbol", "preview", "author", "date"}
+
+func (r *exportRow) csvRecord() []string {
+	line := ""
+	if r.Line > 0 {
+		line = strconv.Itoa(r.Line)
+	}
+	record := []string{r.Type, r.Repository, r.Revision, r.Commit, r.Path, line, r.Symbol, r.Preview, r.Author, r.Date}
+	for i, cell := range record {
+		record[i] = escapeCSVFormula(cell)
+	}
+	return record
+}
+
+// escapeCSVFormula prefixes cell with a quote if it starts with a character
+// that makes spreadsheet applications interpret it as a formula, including
+// the tabs and carriage returns some of them skip before one. Previews and
+// paths come from repository content, so they must not be able to run
+// formulas when an export is opened.
+func escapeCSVFormula(cell string) string {
+	if cell != "" && strings.ContainsRune("=+-@\t\r", rune(cell[0])) {
+		return "'" + cell
+	}
+	return cell
+}
+
+// exportRows converts match to the rows of an export.
+func exportRows(match result.Match) []exportRow {
+	switch m := match.(type) {
+	case *result.FileMatch:
+		file := exportRow{
+			Repository: string(m.Repo.Name),
+			Commit:     string(m.CommitID),
+			Path:       m.Path,
+		}
+		if m.InputRev != nil {
+			file.Revision = *m.InputRev
+		}
+
+		var rows []exportRow
+		switch {
+		case len(m.Symbols) > 0:
+			for _, sym := range m.Symbols {
+				row := file
+				row.Type = "symbol"
+				row.Line = sym.Symbol.Line
+				row.Symbol = sym.Symbol.Name
+				rows = append(rows, row)
+			}
+		case len(m.LineMatches) > 0:
+			for _, lm := range m.LineMatches {
+				row := file
+				row.Type = "content"
+				row.Line = int(lm.LineNumber) + 1
+				row.Preview = lm.Preview
+				rows = append(rows, row)
+			}
+		default:
+			file.Type = "path"
+			rows = append(rows, file)
+		}
+		return rows
+
+	case *result.RepoMatch:
+		return []exportRow{{
+			Type:       "repo",
+			Repository: string(m.Name),
+			Revision:   m.Rev,
+		}}
+
+	case *result.CommitMatch:
+		return []exportRow{{
+			Type:       "commit",
+			Repository: string(m.Repo.Name),
+			Commit:     string(m.Commit.ID),
+			Preview:    m.Commit.Message.Subject(),
+			Author:     m.Commit.Author.Name,
+			Date:       m.Commit.Author.Date.UTC().Format(time.RFC3339),
+		}}
+	}
+	return nil
+}
+
+// exportWriter writes the rows of an export. Rows may be buffered until Flush
+// is called.
+type exportWriter interface {
+	Write(*exportRow) error
+	Flush() error
+}
+
+// newExportWriter returns an exportWriter for format which writes to w. It
+// sets the content headers of the response, so it must be called before
+// anything is written to w.
+func newExportWriter(w http.ResponseWriter, format string) (exportWriter, error) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		return nil, errors.New("http flushing not supported")
+	}
+
+	var ext string
+	var ew exportWriter
+	switch format {
+	case exportFormatCSV:
+		w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+		ext = "csv"
+		ew = &csvExportWriter{w: csv.NewWriter(w), flush: flusher.Flush}
+	case exportFormatJSONL:
+		w.Header().Set("Content-Type", "application/x-ndjson")
+		ext = "jsonl"
+		bw := bufio.NewWriter(w)
+		ew = &jsonlExportWriter{w: bw, enc: json.NewEncoder(bw), flush: flusher.Flush}
+	default:
+		return nil, errors.Errorf("unsupported export format %q", format)
+	}
+
+	w.Header().Set("Content-Disposition", `attachment; filename="search-results.`+ext+`"`)
+	w.Header().Set("Cache-Control", "no-cache")
+	// Errors that happen after the first rows are written are reported in a
+	// trailer, since the status code has already been sent.
+	w.Header().Set("Trailer", exportErrorTrailer)
+	// See NewWriter in streaming/http.
+	w.Header().Set("X-Accel-Buffering", "no")
+
+	return ew, nil
+}
+
+// exportErrorTrailer is the HTTP trailer set if an export is incomplete
+// because the search failed.
+const exportErrorTrailer = "X-Search-Error"
+
+type csvExportWriter struct {
+	w           *csv.Writer
+	flush       func()
+	wroteHeader bool
+}
+
+func (c *csvExportWriter) Write(row *exportRow) error {
+	if !c.wroteHeader {
+		c.wroteHeader = true
+		if err := c.w.Write(exportColumns); err != nil {
+			return err
+		}
+	}
+	return c.w.Write(row.csvRecord())
+}
+
+func (c *csvExportWriter) Flush() error {
+	// An export without results still has a header.
+	if !c.wroteHeader {
+		c.wroteHeader = true
+		if err := c.w.Write(exportColumns); err != nil {
+			return err
+		}
+	}
+	c.w.Flush()
+	if err := c.w.Error(); err != nil {
+		return err
+	}
+	c.flush()
+	return nil
+}
+
+type jsonlExportWriter struct {
+	w     *bufio.Writer
+	enc   *json.Encoder
+	flush func()
+}
+
+func (j *jsonlExportWriter) Write(row *exportRow) error {
+	// Encode terminates each value with a newline.
+	return j.enc.Encode(row)
+}
+
+func (j *jsonlExportWriter) Flush() error {
+	if err := j.w.Flush(); err != nil {
+		return err
+	}
+	j.flush()
+	return nil
+}
+
+// serveExport runs the search described by a and writes every match to w in
+// a.Format as it is found. Unlike the event stream it ignores the display
+// limit: it writes all results up to the limit of the query (count:), and
+// stops when the search times out (timeout:).
+func (h *streamHandler) serveExport(ctx context.Context, w http.ResponseWriter, a *args) error {
+	ew, err := newExportWriter(w, a.Format)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return err
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	events, inputs, results := h.startSearch(ctx, a)
+	events = batchEvents(events, 50*time.Millisecond)
+
+	remaining := inputs.MaxResults()
+	var writeErr error
+	for event := range events {
+		if writeErr != nil {
+			// Drain events so the search can shut down.
+			continue
+		}
+
+		for i, match := range event.Results {
+			if remaining <= 0 {
+				event.Results = event.Results[:i]
+				break
+			}
+			remaining = match.Limit(remaining)
+		}
+
+		repoMetadata := h.getEventRepoMetadata(ctx, event)
+		for _, match := range event.Results {
+			// See ServeHTTP: only export matches in repos the actor has
+			// access to.
+			if md, ok := repoMetadata[match.RepoName().ID]; !ok || md.Name != match.RepoName().Name {
+				continue
+			}
+			rows := exportRows(match)
+			for i := range rows {
+				if writeErr = ew.Write(&rows[i]); writeErr != nil {
+					break
+				}
+			}
+			if writeErr != nil {
+				break
+			}
+		}
+		if writeErr == nil {
+			writeErr = ew.Flush()
+		}
+		if writeErr != nil {
+			// The client went away, stop searching.
+			cancel()
+		}
+	}
+	if writeErr == nil {
+		// Also writes the CSV header if there were no results.
+		writeErr = ew.Flush()
+	}
+	if writeErr != nil {
+		return writeErr
+	}
+
+	if _, err := results(); err != nil {
+		// Header values cannot contain newlines.
+		w.Header().Set(exportErrorTrailer, strings.ReplaceAll(err.Error(), "\n", " "))
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,164 @@
+package search
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/graphqlbackend"
+	api2 "github.com/sourcegraph/sourcegraph/internal/api"
+	"github.com/sourcegraph/sourcegraph/internal/database"
+	"github.com/sourcegraph/sourcegraph/internal/database/dbutil"
+	"github.com/sourcegraph/sourcegraph/internal/search/query"
+	"github.com/sourcegraph/sourcegraph/internal/search/result"
+	"github.com/sourcegraph/sourcegraph/internal/search/run"
+	"github.com/sourcegraph/sourcegraph/internal/search/streaming"
+	"github.com/sourcegraph/sourcegraph/internal/types"
+	"github.com/sourcegraph/sourcegraph/internal/vcs/git"
+)
+
+func TestServeExport(t *testing.T) {
+	database.Mocks.Repos.GetByIDs = func(ctx context.Context, ids ...api2.RepoID) (_ []*types.Repo, err error) {
+		res := make([]*types.Repo, 0, len(ids))
+		for _, id := range ids {
+			res = append(res, &types.Repo{ID: id, Name: api2.RepoName("repo1")})
+		}
+		return res, nil
+	}
+	defer func() { database.Mocks.Repos.GetByIDs = nil }()
+
+	rev := "main"
+	repo := types.RepoName{ID: 1, Name: "repo1"}
+	matches := func() []result.Match {
+		return []result.Match{
+			&result.FileMatch{
+				File: result.File{Repo: repo, CommitID: "deadbeef", InputRev: &rev, Path: "a.go"},
+				LineMatches: []*result.LineMatch{
+					{Preview: "func a() {", LineNumber: 2, OffsetAndLengths: [][2]int32{{5, 1}}},
+					{Preview: `	b("x, y")`, LineNumber: 3, OffsetAndLengths: [][2]int32{{1, 1}}},
+				},
+			},
+			&result.CommitMatch{
+				Repo: repo,
+				Commit: git.Commit{
+					ID:      "cafe",
+					Author:  git.Signature{Name: "alice", Date: time.Date(2021, 8, 1, 12, 0, 0, 0, time.UTC)},
+					Message: "Fix a\n\nDetails",
+				},
+			},
+			// Not returned by the repos store, so not exported.
+			&result.RepoMatch{ID: 2, Name: "secret"},
+		}
+	}
+
+	cases := []struct {
+		name   string
+		query  string
+		format string
+		want   string
+	}{
+		{
+			name:   "csv",
+			query:  "a",
+			format: "csv",
+			want: `type,repository,revision,commit,path,line,symbol,preview,author,date
+content,repo1,main,deadbeef,a.go,3,,func a() {,,
+content,repo1,main,deadbeef,a.go,4,,"'	b(""x, y"")",,
+commit,repo1,,cafe,,,,Fix a,alice,2021-08-01T12:00:00Z
+`,
+		},
+		{
+			name:   "jsonl",
+			query:  "a",
+			format: "jsonl",
+			want: `{"type":"content","repository":"repo1","revision":"main","commit":"deadbeef","path":"a.go","line":3,"preview":"func a() {"}
+{"type":"content","repository":"repo1","revision":"main","commit":"deadbeef","path":"a.go","line":4,"preview":"\tb(\"x, y\")"}
+{"type":"commit","repository":"repo1","commit":"cafe","preview":"Fix a","author":"alice","date":"2021-08-01T12:00:00Z"}
+`,
+		},
+		{
+			name:   "count",
+			query:  "a count:1",
+			format: "jsonl",
+			want: `{"type":"content","repository":"repo1","revision":"main","commit":"deadbeef","path":"a.go","line":3,"preview":"func a() {"}
+`,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			mock := &mockSearchResolver{done: make(chan struct{})}
+			ts := httptest.NewServer(&streamHandler{
+				flushTickerInternal: 1 * time.Millisecond,
+				pingTickerInterval:  1 * time.Millisecond,
+				newSearchResolver: func(_ context.Context, _ dbutil.DB, args *graphqlbackend.SearchArgs) (searchResolver, error) {
+					q, err := query.Parse(tc.query, query.Literal)
+					if err != nil {
+						t.Fatal(err)
+					}
+					mock.inputs = &run.SearchInputs{Query: q}
+					go func() {
+						args.Stream.Send(streaming.SearchEvent{Results: matches()})
+						mock.Close()
+					}()
+					return mock, nil
+				}})
+			defer ts.Close()
+
+			res, err := http.Get(ts.URL + "?q=test&format=" + tc.format)
+			if err != nil {
+				t.Fatal(err)
+			}
+			b, err := io.ReadAll(res.Body)
+			res.Body.Close()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if res.StatusCode != 200 {
+				t.Fatalf("expected status 200, got %d: %s", res.StatusCode, b)
+			}
+			if diff := cmp.Diff(tc.want, string(b)); diff != "" {
+				t.Errorf("unexpected export (-want +got):\n%s", diff)
+			}
+			if got := res.Trailer.Get(exportErrorTrailer); got != "" {
+				t.Errorf("unexpected error trailer %q", got)
+			}
+		})
+	}
+}
+
+func TestExportRow_csvRecord(t *testing.T) {
+	row := exportRow{
+		Type:       "content",
+		Repository: "repo1",
+		Path:       "-a.csv",
+		Line:       1,
+		Symbol:     "\r=1+1",
+		Preview:    `=HYPERLINK("https://example.com", "click")`,
+		Author:     "@alice",
+		Date:       "+1",
+	}
+	want := []string{"content", "repo1", "", "", "'-a.csv", "1", "'\r=1+1", `'=HYPERLINK("https://example.com", "click")`, "'@alice", "'+1"}
+	if diff := cmp.Diff(want, row.csvRecord()); diff != "" {
+		t.Errorf("unexpected record (-want +got):\n%s", diff)
+	}
+}
+
+func TestServeExport_invalidFormat(t *testing.T) {
+	ts := httptest.NewServer(&streamHandler{})
+	defer ts.Close()
+
+	res, err := http.Get(ts.URL + "?q=test&format=xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+	if res.StatusCode != http.StatusBadRequest {
+		t.Errorf("expected status 400, got %d", res.StatusCode)
+	}
+}
@@ -74,6 +74,11 @@ func (h *streamHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		tr.Finish()
 	}()
 
+	if args.Format != "" {
+		err = h.serveExport(ctx, w, args)
+		return
+	}
+
 	eventWriter, err := streamhttp.NewWriter(w)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
@@ -367,6 +372,9 @@ type args struct {
 	PatternType    string
 	VersionContext string
 	Display        int
+
+	// Format is the export format, or empty for the event stream.
+	Format string
 }
 
 func parseURLQuery(q url.Values) (*args, error) {
@@ -383,6 +391,7 @@ func parseURLQuery(q url.Values) (*args, error) {
 		Version:        get("v", "V2"),
 		PatternType:    get("t", ""),
 		VersionContext: get("vc", ""),
+		Format:         get("format", ""),
 	}
 
 	if a.Query == "" {
@@ -395,6 +404,12 @@ func parseURLQuery(q url.Values) (*args, error) {
 		return nil, errors.Errorf("display must be an integer, got %q: %w", display, err)
 	}
 
+	switch a.Format {
+	case "", exportFormatCSV, exportFormatJSONL:
+	default:
+		return nil, errors.Errorf("format must be %q or %q, got %q", exportFormatCSV, exportFormatJSONL, a.Format)
+	}
+
 	return &a, nil
 }
 
@@ -428,7 +443,7 @@ func fromMatch(match result.Match, repoCache map[api.RepoID]*types.Repo) streamh
 func fromFileMatch(fm *result.FileMatch, repoCache map[api.RepoID]*types.Repo) streamhttp.EventMatch {
 	if len(fm.Symbols) > 0 {
 		return fromSymbolMatch(fm, repoCache)
-	} else if len(fm.LineMatches) > 0 {
+	} else if len(fm.LineMatches) > 0 || fm.Diff != "" {
 		return fromContentMatch(fm, repoCache)
 	}
 	return fromPathMatch(fm, repoCache)
@@ -465,6 +480,14 @@ func fromContentMatch(fm *result.FileMatch, repoCache map[api.RepoID]*types.Repo
 		})
 	}
 
+	var matchRanges []streamhttp.EventRange
+	for _, r := range fm.MatchRanges {
+		matchRanges = append(matchRanges, streamhttp.EventRange{
+			Start: streamhttp.EventLocation(r.Start),
+			End:   streamhttp.EventLocation(r.End),
+		})
+	}
+
 	var branches []string
 	if fm.InputRev != nil {
 		branches = []string{*fm.InputRev}
@@ -483,6 +506,8 @@ func fromContentMatch(fm *result.FileMatch, repoCache map[api.RepoID]*types.Repo
 		Branches:    branches,
 		Version:     string(fm.CommitID),
 		LineMatches: lineMatches,
+		MatchRanges: matchRanges,
+		Diff:        fm.Diff,
 	}
 }
 
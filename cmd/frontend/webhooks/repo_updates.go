@@ -0,0 +1,39 @@
+package webhooks
+
+import (
+	"context"
+
+	"github.com/cockroachdb/errors"
+	"github.com/inconshreveable/log15"
+
+	"github.com/sourcegraph/sourcegraph/internal/actor"
+	"github.com/sourcegraph/sourcegraph/internal/api"
+	"github.com/sourcegraph/sourcegraph/internal/database"
+	"github.com/sourcegraph/sourcegraph/internal/repoupdater"
+)
+
+// EnqueueRepoUpdate asks repo-updater to update the repository identified by
+// spec, because its code host notified us of a push to it. Pushes to
+// repositories that aren't synced to Sourcegraph are ignored.
+func EnqueueRepoUpdate(ctx context.Context, repos *database.RepoStore, spec api.ExternalRepoSpec) error {
+	// 🚨 SECURITY: we want to be able to find any private repo here, so set internal actor
+	ctx = actor.WithInternalActor(ctx)
+	rs, err := repos.List(ctx, database.ReposListOptions{
+		ExternalRepos: []api.ExternalRepoSpec{spec},
+	})
+	if err != nil {
+		return errors.Wrap(err, "listing repos")
+	}
+
+	if len(rs) == 0 {
+		log15.Debug("EnqueueRepoUpdate: ignoring push to unknown repo", "repo", spec)
+		return nil
+	}
+
+	for _, r := range rs {
+		if _, err := repoupdater.DefaultClient.EnqueueRepoUpdateFromWebhook(ctx, r.Name); err != nil {
+			return errors.Wrapf(err, "enqueuing update of repo %q", r.Name)
+		}
+	}
+	return nil
+}
@@ -8,6 +8,7 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
+	"strconv"
 	"syscall"
 	"time"
 
@@ -44,6 +45,7 @@ var (
 	syncRepoStateInterval        = env.MustGetDuration("SRC_REPOS_SYNC_STATE_INTERVAL", 10*time.Minute, "Interval between state syncs")
 	syncRepoStateBatchSize       = env.MustGetInt("SRC_REPOS_SYNC_STATE_BATCH_SIZE", 500, "Number of upserts to perform per batch")
 	syncRepoStateUpsertPerSecond = env.MustGetInt("SRC_REPOS_SYNC_STATE_UPSERT_PER_SEC", 500, "The number of upserted rows allowed per second across all gitserver instances")
+	rebalance, _                 = strconv.ParseBool(env.Get("SRC_GITSERVER_REBALANCE", "false", "Copy repositories from other gitservers instead of cloning them from the code host when the gitserver addresses change"))
 )
 
 func main() {
@@ -112,6 +114,8 @@ func main() {
 			}
 
 			switch r.ExternalRepo.ServiceType {
+			case extsvc.TypeMercurial:
+				return &server.HgRepoSyncer{}, nil
 			case extsvc.TypePerforce:
 				// Extract options from external service config
 				var c schema.PerforceConnection
@@ -155,11 +159,52 @@ func main() {
 				}
 
 				return &server.JVMPackagesSyncer{Config: &c}, nil
+			case extsvc.TypeNPMPackages:
+				var c schema.NPMPackagesConnection
+				for _, info := range r.Sources {
+					es, err := externalServiceStore.GetByID(ctx, info.ExternalServiceID())
+					if err != nil {
+						return nil, errors.Wrap(err, "get external service")
+					}
+
+					normalized, err := jsonc.Parse(es.Config)
+					if err != nil {
+						return nil, errors.Wrap(err, "normalize JSON")
+					}
+
+					if err = jsoniter.Unmarshal(normalized, &c); err != nil {
+						return nil, errors.Wrap(err, "unmarshal JSON")
+					}
+					break
+				}
+
+				return &server.NPMPackagesSyncer{Config: &c}, nil
+			case extsvc.TypeGoModules:
+				var c schema.GoModulesConnection
+				for _, info := range r.Sources {
+					es, err := externalServiceStore.GetByID(ctx, info.ExternalServiceID())
+					if err != nil {
+						return nil, errors.Wrap(err, "get external service")
+					}
+
+					normalized, err := jsonc.Parse(es.Config)
+					if err != nil {
+						return nil, errors.Wrap(err, "normalize JSON")
+					}
+
+					if err = jsoniter.Unmarshal(normalized, &c); err != nil {
+						return nil, errors.Wrap(err, "unmarshal JSON")
+					}
+					break
+				}
+
+				return &server.GoModulesSyncer{Config: &c}, nil
 			}
 			return &server.GitRepoSyncer{}, nil
 		},
-		Hostname: hostname.Get(),
-		DB:       db,
+		Hostname:  hostname.Get(),
+		Rebalance: rebalance,
+		DB:        db,
 	}
 	gitserver.RegisterMetrics()
 
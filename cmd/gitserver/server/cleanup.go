@@ -75,14 +75,15 @@ const reposStatsName = "repos-stats.json"
 
 // cleanupRepos walks the repos directory and performs maintenance tasks:
 //
-// 1. Compute the amount of space used by the repo
-// 2. Remove corrupt repos.
-// 3. Remove stale lock files.
-// 4. Ensure correct git attributes
-// 5. Scrub remote URLs
-// 6. Perform garbage collection
-// 7. Re-clone repos after a while. (simulate git gc)
-// 8. Remove repos based on disk pressure.
+// 1. Remove copies of repos this gitserver no longer needs to keep.
+// 2. Compute the amount of space used by the repo
+// 3. Remove corrupt repos.
+// 4. Remove stale lock files.
+// 5. Ensure correct git attributes
+// 6. Scrub remote URLs
+// 7. Perform garbage collection
+// 8. Re-clone repos after a while. (simulate git gc)
+// 9. Remove repos based on disk pressure.
 func (s *Server) cleanupRepos() {
 	janitorRunning.Set(1)
 	defer janitorRunning.Set(0)
@@ -94,6 +95,35 @@ func (s *Server) cleanupRepos() {
 		UpdatedAt: time.Now(),
 	}
 
+	maybeRemoveCopy := func(dir GitDir) (done bool, err error) {
+		// Without replication we keep repos of other gitservers until we run
+		// low on disk space.
+		if conf.GitServerReplicationFactor() <= 1 {
+			return false, nil
+		}
+
+		repo := s.name(dir)
+		addrs := repoAddrs(repo)
+		if s.keepsCopy(repo) {
+			return false, nil
+		}
+
+		// Don't remove what may be the last copy of repo.
+		ctx, cancel := context.WithTimeout(bCtx, peerTimeout)
+		defer cancel()
+		cloned, err := isRepoClonedOnPeer(ctx, addrs[0], repo)
+		if err != nil || !cloned {
+			return false, err
+		}
+
+		log15.Info("removing copy of repo kept by other gitservers", "repo", repo)
+		if err := s.removeRepoDirectory(dir); err != nil {
+			return true, err
+		}
+		reposRemoved.Inc()
+		return true, nil
+	}
+
 	computeStats := func(dir GitDir) (done bool, err error) {
 		stats.GitDirBytes += dirSize(dir.Path("."))
 		return false, nil
@@ -232,6 +262,9 @@ func (s *Server) cleanupRepos() {
 		Do   func(GitDir) (bool, error)
 	}
 	cleanups := []cleanupFn{
+		// With replication, a repo moves to other gitservers when the
+		// gitserver addresses change.
+		{"maybe remove copy", maybeRemoveCopy},
 		// Compute the amount of space used by the repo
 		{"compute statistics", computeStats},
 		// Do some sanity checks on the repository.
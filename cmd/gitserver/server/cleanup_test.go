@@ -3,6 +3,7 @@ package server
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"io"
 	"io/fs"
 	"log"
@@ -20,10 +21,13 @@ import (
 	"github.com/google/go-cmp/cmp"
 
 	"github.com/sourcegraph/sourcegraph/internal/api"
+	"github.com/sourcegraph/sourcegraph/internal/conf"
+	"github.com/sourcegraph/sourcegraph/internal/conf/conftypes"
 	"github.com/sourcegraph/sourcegraph/internal/database"
 	"github.com/sourcegraph/sourcegraph/internal/database/dbtesting"
 	"github.com/sourcegraph/sourcegraph/internal/gitserver/protocol"
 	"github.com/sourcegraph/sourcegraph/internal/types"
+	"github.com/sourcegraph/sourcegraph/schema"
 )
 
 const (
@@ -118,6 +122,62 @@ func TestCleanupInactive(t *testing.T) {
 	}
 }
 
+func TestCleanupCopies(t *testing.T) {
+	conf.Mock(&conf.Unified{
+		SiteConfiguration: schema.SiteConfiguration{
+			ExperimentalFeatures: &schema.ExperimentalFeatures{GitServerReplicationFactor: 2},
+		},
+		ServiceConnections: conftypes.ServiceConnections{
+			GitServers: []string{"gitserver-0", "gitserver-1", "gitserver-2"},
+		},
+	})
+	defer conf.Mock(nil)
+
+	// Primaries of the repos this gitserver doesn't keep report whether they
+	// have them.
+	primaryHas := map[api.RepoName]bool{}
+	orig := isRepoClonedOnPeer
+	isRepoClonedOnPeer = func(_ context.Context, addr string, repo api.RepoName) (bool, error) {
+		if want := repoAddrs(repo)[0]; addr != want {
+			t.Errorf("expected %s to be asked about %s, got %s", want, repo, addr)
+		}
+		return primaryHas[repo], nil
+	}
+	defer func() { isRepoClonedOnPeer = orig }()
+
+	root := t.TempDir()
+	s := &Server{ReposDir: root, Hostname: "gitserver-2"}
+	s.Handler() // Handler as a side-effect sets up Server
+
+	var kept, copied, lastCopy api.RepoName
+	for i := 0; kept == "" || copied == "" || lastCopy == ""; i++ {
+		repo := api.RepoName(fmt.Sprintf("github.com/foo/repo%d", i))
+		switch {
+		case s.keepsCopy(repo):
+			if kept == "" {
+				kept = repo
+			}
+		case copied == "":
+			copied = repo
+			primaryHas[repo] = true
+		case lastCopy == "":
+			lastCopy = repo
+		}
+	}
+	for _, repo := range []api.RepoName{kept, copied, lastCopy} {
+		runCmd(t, root, "git", "init", "--bare", filepath.Join(string(repo), ".git"))
+	}
+
+	s.cleanupRepos()
+
+	for repo, wantRemoved := range map[api.RepoName]bool{kept: false, copied: true, lastCopy: false} {
+		_, err := os.Stat(string(s.dir(repo)))
+		if removed := os.IsNotExist(err); removed != wantRemoved {
+			t.Errorf("%s: got removed=%v, want %v", repo, removed, wantRemoved)
+		}
+	}
+}
+
 // Note that the exact values (e.g. 50 commits) below are related to git's
 // internal heuristics regarding whether or not to invoke `git gc --auto`.
 //
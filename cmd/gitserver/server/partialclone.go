@@ -0,0 +1,289 @@
+package server
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/cockroachdb/errors"
+	"github.com/inconshreveable/log15"
+
+	"github.com/sourcegraph/sourcegraph/internal/api"
+	"github.com/sourcegraph/sourcegraph/internal/conf"
+	"github.com/sourcegraph/sourcegraph/internal/vcs"
+	"github.com/sourcegraph/sourcegraph/schema"
+)
+
+// Repositories matched by experimentalFeatures.gitPartialClone are cloned as
+// partial clones, which omit blobs larger than the configured limit. Git
+// fetches missing blobs from the promisor remote when they are read.
+//
+// gitserver doesn't store remote URLs in repositories, since they may contain
+// credentials. The promisor remote of a partial clone therefore has no URL,
+// and commands that may read missing blobs are passed the URL in their
+// environment instead. Git passes it on to the fetches it runs for missing
+// blobs. The URL is kept out of the command line, which is visible to other
+// processes and recorded in traces.
+
+// partialCloneRemote is the name of the promisor remote of partial clones.
+const partialCloneRemote = "origin"
+
+var partialCloneFilters = conf.Cached(func() interface{} {
+	if c := conf.Get().ExperimentalFeatures; c != nil {
+		return buildPartialCloneFilters(c.GitPartialClone)
+	}
+	return buildPartialCloneFilters(nil)
+})
+
+func buildPartialCloneFilters(c []*schema.PartialCloneMapping) map[string]string {
+	filters := map[string]string{}
+	for _, mapping := range c {
+		if mapping.BlobSizeLimit == "" {
+			continue
+		}
+		filters[mapping.DomainPath] = "blob:limit=" + mapping.BlobSizeLimit
+	}
+	return filters
+}
+
+// partialCloneFilterForRemote returns the object filter that the repository at
+// remoteURL is cloned with, or "" if it is cloned fully.
+func partialCloneFilterForRemote(remoteURL *vcs.URL) string {
+	filters := partialCloneFilters().(map[string]string)
+	return filters[path.Join(remoteURL.Host, remoteURL.Path)]
+}
+
+// setPartialClone configures the repository in dir as a partial clone whose
+// objects are filtered with filter.
+func setPartialClone(dir GitDir, filter string) error {
+	for _, kv := range [][2]string{
+		// Extensions require repository format version 1.
+		{"core.repositoryformatversion", "1"},
+		{"extensions.partialClone", partialCloneRemote},
+		{"remote." + partialCloneRemote + ".promisor", "true"},
+		{"remote." + partialCloneRemote + ".partialclonefilter", filter},
+	} {
+		if err := gitConfigSet(dir, kv[0], kv[1]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// getPartialCloneFilter returns the object filter of the repository in dir,
+// or "" if it isn't a partial clone.
+func getPartialCloneFilter(dir GitDir) (string, error) {
+	val, err := gitConfigGet(dir, "remote."+partialCloneRemote+".partialclonefilter")
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(val), nil
+}
+
+// isPartialClone returns true if the repository in dir is a partial clone. It
+// is cheaper than getPartialCloneFilter, since it only looks for the packs
+// fetched from the promisor remote.
+func isPartialClone(dir GitDir) bool {
+	matches, _ := filepath.Glob(dir.Path("objects", "pack", "*.promisor"))
+	return len(matches) > 0
+}
+
+// partialCloneFetchArgs returns the arguments of a git fetch from remoteURL
+// with the given arguments, changed to fetch the objects matching filter
+// from the promisor remote. The command must be configured with
+// setPartialCloneURL.
+func partialCloneFetchArgs(args []string, remoteURL *vcs.URL, filter string) []string {
+	out := []string{args[0]}
+	for _, arg := range args[1:] {
+		switch arg {
+		case "fetch":
+			out = append(out, arg, "--filter="+filter)
+		case remoteURL.String():
+			out = append(out, partialCloneRemote)
+		default:
+			out = append(out, arg)
+		}
+	}
+	return out
+}
+
+// setPartialCloneURL sets the URL of the promisor remote for cmd through
+// GIT_CONFIG_PARAMETERS, the variable git itself uses to pass -c options on
+// to the commands it runs. GIT_CONFIG_COUNT would be simpler, but it requires
+// git 2.31.
+func setPartialCloneURL(cmd *exec.Cmd, remoteURL *vcs.URL) {
+	if cmd.Env == nil {
+		cmd.Env = os.Environ()
+	}
+
+	param := gitConfigQuote("remote." + partialCloneRemote + ".url=" + remoteURL.String())
+	for i, env := range cmd.Env {
+		if strings.HasPrefix(env, "GIT_CONFIG_PARAMETERS=") {
+			cmd.Env[i] = env + " " + param
+			return
+		}
+	}
+	cmd.Env = append(cmd.Env, "GIT_CONFIG_PARAMETERS="+param)
+}
+
+// gitConfigQuote quotes s like git does for GIT_CONFIG_PARAMETERS: it is
+// enclosed in single quotes, and single quotes and exclamation marks in it are
+// escaped.
+func gitConfigQuote(s string) string {
+	s = strings.ReplaceAll(s, "'", `'\''`)
+	s = strings.ReplaceAll(s, "!", `'\!'`)
+	return "'" + s + "'"
+}
+
+// partialCloneURLTTL is how long the remote URL of a partial clone is cached
+// for the commands that run in it.
+const partialCloneURLTTL = time.Minute
+
+// partialCloneURL is a cached remote URL of a partial clone.
+type partialCloneURL struct {
+	url     *vcs.URL
+	expires time.Time
+}
+
+// getPartialCloneURL returns the remote URL of the partial clone of repo.
+// Every command that runs in a partial clone needs it, so it is cached for
+// partialCloneURLTTL instead of being looked up for every command.
+func (s *Server) getPartialCloneURL(ctx context.Context, repo api.RepoName) (*vcs.URL, error) {
+	now := time.Now()
+
+	s.partialCloneURLsMu.Lock()
+	cached, ok := s.partialCloneURLs[repo]
+	s.partialCloneURLsMu.Unlock()
+	if ok && now.Before(cached.expires) {
+		return cached.url, nil
+	}
+
+	remoteURL, err := s.getRemoteURL(ctx, repo)
+	if err != nil {
+		return nil, err
+	}
+
+	s.partialCloneURLsMu.Lock()
+	defer s.partialCloneURLsMu.Unlock()
+	if s.partialCloneURLs == nil {
+		s.partialCloneURLs = make(map[api.RepoName]partialCloneURL)
+	}
+	// Drop expired URLs, so that the cache only holds the partial clones in
+	// use.
+	for name, u := range s.partialCloneURLs {
+		if !now.Before(u.expires) {
+			delete(s.partialCloneURLs, name)
+		}
+	}
+	s.partialCloneURLs[repo] = partialCloneURL{url: remoteURL, expires: now.Add(partialCloneURLTTL)}
+	return remoteURL, nil
+}
+
+// configurePartialCloneCommand configures the git command cmd, which runs in
+// the partial clone of repo, to fetch missing blobs when it reads them. It
+// returns the remote URL that cmd fetches from, which must be redacted from
+// its output.
+func (s *Server) configurePartialCloneCommand(ctx context.Context, repo api.RepoName, cmd *exec.Cmd) (*vcs.URL, error) {
+	remoteURL, err := s.getPartialCloneURL(ctx, repo)
+	if err != nil {
+		return nil, err
+	}
+
+	setPartialCloneURL(cmd, remoteURL)
+	configureRemoteGitCommand(cmd, tlsExternal().(*tlsConfig))
+	return remoteURL, nil
+}
+
+// fetchMissingBlobs fetches the blobs of the given paths of treeish that are
+// missing from the partial clone of repo in a single fetch. Commands like git
+// archive would otherwise fetch each missing blob separately.
+func (s *Server) fetchMissingBlobs(ctx context.Context, repo api.RepoName, dir GitDir, treeish string, paths []string) error {
+	var trees, blobs []string
+	if len(paths) == 0 {
+		trees = []string{treeish + "^{tree}"}
+	} else {
+		cmd := exec.CommandContext(ctx, "git", append([]string{"ls-tree", treeish, "--"}, paths...)...)
+		dir.Set(cmd)
+		out, err := cmd.Output()
+		if err != nil {
+			return errors.Wrap(wrapCmdError(cmd, err), "listing paths")
+		}
+		trees, blobs = parseLsTreeObjects(out)
+	}
+
+	// Blobs are listed by ls-tree whether or not they are missing, but trees
+	// are never missing, so we only need rev-list to find the missing blobs
+	// in trees.
+	missing := blobs
+	if len(trees) > 0 {
+		cmd := exec.CommandContext(ctx, "git", append([]string{"rev-list", "--objects", "--missing=print"}, trees...)...)
+		dir.Set(cmd)
+		out, err := cmd.Output()
+		if err != nil {
+			return errors.Wrap(wrapCmdError(cmd, err), "listing missing objects")
+		}
+		missing = append(missing, parseMissingObjects(out)...)
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+
+	// These are the arguments git uses itself to fetch missing objects.
+	cmd := exec.CommandContext(ctx, "git",
+		"-c", "fetch.negotiationAlgorithm=noop",
+		"fetch", "--no-tags", "--no-write-fetch-head", "--recurse-submodules=no", "--filter=blob:none", "--stdin",
+		partialCloneRemote)
+	dir.Set(cmd)
+	cmd.Stdin = strings.NewReader(strings.Join(missing, "\n") + "\n")
+	remoteURL, err := s.configurePartialCloneCommand(ctx, repo, cmd)
+	if err != nil {
+		return err
+	}
+	if output, err := runWith(ctx, cmd, false, nil); err != nil {
+		redactor := newURLRedactor(remoteURL)
+		return errors.Errorf("failed to fetch missing blobs: %s with output %q", redactor.redact(err.Error()), redactor.redact(string(output)))
+	}
+
+	log15.Debug("fetched missing blobs of partial clone", "repo", repo, "count", len(missing))
+	return nil
+}
+
+// parseLsTreeObjects parses the output of git ls-tree and returns the IDs of
+// the listed trees and blobs.
+func parseLsTreeObjects(out []byte) (trees, blobs []string) {
+	sc := bufio.NewScanner(bytes.NewReader(out))
+	for sc.Scan() {
+		// <mode> SP <type> SP <object> TAB <file>
+		info := strings.SplitN(sc.Text(), "\t", 2)[0]
+		fields := strings.Fields(info)
+		if len(fields) != 3 {
+			continue
+		}
+		switch fields[1] {
+		case "tree":
+			trees = append(trees, fields[2])
+		case "blob":
+			blobs = append(blobs, fields[2])
+		}
+	}
+	return trees, blobs
+}
+
+// parseMissingObjects parses the output of git rev-list --missing=print and
+// returns the IDs of the missing objects.
+func parseMissingObjects(out []byte) []string {
+	var missing []string
+	sc := bufio.NewScanner(bytes.NewReader(out))
+	for sc.Scan() {
+		if line := sc.Text(); strings.HasPrefix(line, "?") {
+			missing = append(missing, line[1:])
+		}
+	}
+	return missing
+}
@@ -168,6 +168,7 @@ func (s *Server) createCommitFromPatch(ctx context.Context, req protocol.CreateC
 
 	if out, err := run(cmd, "applying patch"); err != nil {
 		log15.Error("Failed to apply patch.", "ref", ref, "output", string(out))
+		resp.Error.PatchConflict = true
 		return http.StatusInternalServerError, resp
 	}
 
@@ -0,0 +1,135 @@
+package server
+
+import (
+	"context"
+	"path"
+	"sync/atomic"
+	"time"
+
+	"github.com/inconshreveable/log15"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+
+	"github.com/sourcegraph/sourcegraph/internal/api"
+	"github.com/sourcegraph/sourcegraph/internal/conf"
+	"github.com/sourcegraph/sourcegraph/internal/gitserver"
+	"github.com/sourcegraph/sourcegraph/internal/vcs"
+)
+
+// When the gitserver addresses change, repositories are assigned to other
+// gitservers. In rebalancing mode (Server.Rebalance), a gitserver copies a
+// repository it is now responsible for from a gitserver which still has it,
+// over the same /git/ endpoint other services clone from, instead of cloning
+// it from the code host again. The copy is then updated from the code host as
+// usual.
+
+// peerTimeout is how long we wait for a peer to tell whether it has a
+// repository.
+const peerTimeout = 5 * time.Second
+
+// isRepoClonedOnPeer reports whether repo is cloned on the gitserver at addr.
+// It is a variable so tests can replace it.
+var isRepoClonedOnPeer = func(ctx context.Context, addr string, repo api.RepoName) (bool, error) {
+	return gitserver.DefaultClient.IsRepoClonedOn(ctx, addr, repo)
+}
+
+// rebalancePeer returns the remote URL of a copy of repo on another gitserver,
+// or nil if rebalancing is disabled or no other gitserver has repo. The other
+// gitservers are asked in parallel.
+func (s *Server) rebalancePeer(ctx context.Context, repo api.RepoName) *vcs.URL {
+	if !s.Rebalance {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, peerTimeout)
+	defer cancel()
+
+	var peers []string
+	for _, addr := range conf.Get().ServiceConnections.GitServers {
+		if !s.hostnameMatch(addr) {
+			peers = append(peers, addr)
+		}
+	}
+
+	found := make(chan string, len(peers))
+	for _, addr := range peers {
+		go func(addr string) {
+			cloned, err := isRepoClonedOnPeer(ctx, addr, repo)
+			if err != nil && ctx.Err() == nil {
+				log15.Warn("rebalance: checking peer for repo", "repo", repo, "peer", addr, "error", err)
+			}
+			if !cloned {
+				addr = ""
+			}
+			found <- addr
+		}(addr)
+	}
+
+	for range peers {
+		addr := <-found
+		if addr == "" {
+			continue
+		}
+
+		u, err := vcs.ParseURL("http://" + addr + path.Join("/git", string(repo)))
+		if err != nil {
+			log15.Error("rebalance: parsing peer URL", "repo", repo, "peer", addr, "error", err)
+			return nil
+		}
+		return u
+	}
+	return nil
+}
+
+// rebalance copies repos from other gitservers in the background. It is a
+// no-op if a previous call is still copying.
+func (s *Server) rebalance(repos []api.RepoName) {
+	if !atomic.CompareAndSwapInt32(&s.rebalancing, 0, 1) {
+		log15.Info("rebalance: already running, skipping", "repos", len(repos))
+		return
+	}
+
+	ctx, cancel := s.serverContext()
+	go func() {
+		defer cancel()
+		defer atomic.StoreInt32(&s.rebalancing, 0)
+		defer rebalanceQueue.Set(0)
+
+		log15.Info("rebalance: copying repos from other gitservers", "repos", len(repos))
+		rebalanceQueue.Set(float64(len(repos)))
+		for _, repo := range repos {
+			if ctx.Err() != nil {
+				return
+			}
+			rebalanceQueue.Dec()
+
+			// Only copy repos, never clone them from the code host: that is
+			// left to the repo-updater scheduler.
+			peer := s.rebalancePeer(ctx, repo)
+			if peer == nil {
+				rebalanceCounter.WithLabelValues("no_peer").Inc()
+				continue
+			}
+
+			// The clone itself runs in the background, limited by the clone
+			// limiter.
+			if _, err := s.cloneRepo(ctx, repo, &cloneOptions{Peer: peer}); err != nil {
+				log15.Warn("rebalance: copying repo", "repo", repo, "error", err)
+				rebalanceCounter.WithLabelValues("error").Inc()
+				continue
+			}
+			rebalanceCounter.WithLabelValues("started").Inc()
+		}
+	}()
+}
+
+var (
+	rebalanceQueue = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "src_gitserver_rebalance_queue",
+		Help: "The number of repositories left to copy from other gitservers after the gitserver addresses changed.",
+	})
+	rebalanceCounter = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "src_gitserver_rebalance_total",
+		Help: "Incremented each time we try to copy a repository from another gitserver after the gitserver addresses changed.",
+	}, []string{"status"})
+)
@@ -0,0 +1,103 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/sourcegraph/sourcegraph/internal/api"
+	"github.com/sourcegraph/sourcegraph/internal/conf"
+	"github.com/sourcegraph/sourcegraph/internal/conf/conftypes"
+	"github.com/sourcegraph/sourcegraph/internal/database"
+	"github.com/sourcegraph/sourcegraph/internal/database/dbtesting"
+	"github.com/sourcegraph/sourcegraph/internal/types"
+)
+
+func TestCloneRepo_rebalance(t *testing.T) {
+	ctx := context.Background()
+	repoName := api.RepoName("example.com/foo/bar")
+	db := dbtesting.GetDB(t)
+
+	dbRepo := &types.Repo{Name: repoName}
+	if err := database.Repos(db).Create(ctx, dbRepo); err != nil {
+		t.Fatal(err)
+	}
+
+	remote := t.TempDir()
+	cmd := func(name string, arg ...string) string {
+		t.Helper()
+		return runCmd(t, remote, name, arg...)
+	}
+	wantCommit := makeSingleCommitRepo(cmd)
+
+	// The peer cloned the repo from the code host before the gitserver
+	// addresses changed.
+	peer := makeTestServer(ctx, t.TempDir(), remote, db)
+	if _, err := peer.cloneRepo(ctx, repoName, &cloneOptions{Block: true}); err != nil {
+		t.Fatal(err)
+	}
+	ts := httptest.NewServer(http.StripPrefix("/git", peer.gitServiceHandler()))
+	defer ts.Close()
+	peerAddr := strings.TrimPrefix(ts.URL, "http://")
+
+	conf.Mock(&conf.Unified{
+		ServiceConnections: conftypes.ServiceConnections{
+			GitServers: []string{peerAddr, "gitserver-1:3178"},
+		},
+	})
+	defer conf.Mock(nil)
+
+	var (
+		mu      sync.Mutex
+		checked []string
+	)
+	orig := isRepoClonedOnPeer
+	isRepoClonedOnPeer = func(_ context.Context, addr string, repo api.RepoName) (bool, error) {
+		mu.Lock()
+		checked = append(checked, addr)
+		mu.Unlock()
+		return repoCloned(peer.dir(repo)), nil
+	}
+	defer func() { isRepoClonedOnPeer = orig }()
+
+	// The code host is unreachable, so the repo can only be copied from the
+	// peer.
+	s := makeTestServer(ctx, t.TempDir(), filepath.Join(t.TempDir(), "missing"), db)
+	s.Hostname = "gitserver-1"
+	s.Rebalance = true
+
+	// Cloning a repo outside of rebalancing never asks the other gitservers.
+	if _, err := s.cloneRepo(ctx, repoName, &cloneOptions{Block: true}); err == nil {
+		t.Fatal("expected clone from the code host to fail")
+	}
+	if len(checked) != 0 {
+		t.Fatalf("expected no peer to be checked, got %v", checked)
+	}
+
+	peerURL := s.rebalancePeer(ctx, repoName)
+	if peerURL == nil || peerURL.Host != peerAddr {
+		t.Fatalf("expected the peer to have the repo, got %v", peerURL)
+	}
+	if len(checked) != 1 || checked[0] != peerAddr {
+		t.Fatalf("expected only the peer to be checked, got %v", checked)
+	}
+
+	if _, err := s.cloneRepo(ctx, repoName, &cloneOptions{Block: true, Overwrite: true, Peer: peerURL}); err != nil {
+		t.Fatal(err)
+	}
+
+	dir := s.dir(repoName)
+	gotCommit := runCmd(t, filepath.Dir(string(dir)), "git", "rev-parse", "HEAD")
+	if wantCommit != gotCommit {
+		t.Fatalf("failed to copy repo from peer: got %q, want %q", gotCommit, wantCommit)
+	}
+
+	// Without a peer the repo is cloned from the code host, which fails.
+	if _, err := s.cloneRepo(ctx, repoName, &cloneOptions{Block: true, Overwrite: true}); err == nil {
+		t.Fatal("expected clone from the code host to fail")
+	}
+}
@@ -0,0 +1,102 @@
+package server
+
+import (
+	"context"
+	"time"
+
+	"github.com/cockroachdb/errors"
+	"github.com/inconshreveable/log15"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+
+	"github.com/sourcegraph/sourcegraph/internal/api"
+	"github.com/sourcegraph/sourcegraph/internal/conf"
+	"github.com/sourcegraph/sourcegraph/internal/gitserver"
+)
+
+// With experimentalFeatures.gitServerReplicationFactor greater than 1, a
+// repository is kept on several gitservers (gitserver.AddrsForRepo): the
+// primary, which is responsible for it, and its replicas. Clients fail over
+// to a replica if the primary can't be reached.
+//
+// Replicas are kept up to date by the primary: whenever it is asked to update
+// a repository, it asks the replicas to do the same, which clones the
+// repository on replicas which don't have it yet. The janitor removes copies
+// a gitserver no longer needs to keep. Only the primary records the state of
+// a repository in the database.
+
+// requestReplicaUpdate asks the gitserver at addr to update repo. It is a
+// variable so tests can replace it.
+var requestReplicaUpdate = func(ctx context.Context, addr string, repo api.RepoName, since time.Duration) error {
+	resp, err := gitserver.DefaultClient.RequestRepoUpdateOn(ctx, addr, repo, since)
+	if err != nil {
+		return err
+	}
+	if resp.Error != "" {
+		return errors.New(resp.Error)
+	}
+	return nil
+}
+
+// repoAddrs returns the addresses of the gitservers which keep a copy of repo,
+// starting with the primary. It returns nil if the gitserver addresses are
+// not known.
+func repoAddrs(repo api.RepoName) []string {
+	addrs := conf.Get().ServiceConnections.GitServers
+	if len(addrs) == 0 {
+		return nil
+	}
+	return gitserver.AddrsForRepo(repo, addrs)
+}
+
+// isPrimary reports whether s is responsible for repo. It is true if the
+// gitserver addresses are not known.
+func (s *Server) isPrimary(repo api.RepoName) bool {
+	addrs := repoAddrs(repo)
+	return len(addrs) == 0 || s.hostnameMatch(addrs[0])
+}
+
+// keepsCopy reports whether s should keep a copy of repo, either as its
+// primary or as one of its replicas.
+func (s *Server) keepsCopy(repo api.RepoName) bool {
+	addrs := repoAddrs(repo)
+	if len(addrs) == 0 {
+		return true
+	}
+	for _, addr := range addrs {
+		if s.hostnameMatch(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// updateReplicas asks the replicas of repo to update it in the background, if
+// s is the primary of repo.
+func (s *Server) updateReplicas(repo api.RepoName, since time.Duration) {
+	if conf.GitServerReplicationFactor() <= 1 {
+		return
+	}
+	addrs := repoAddrs(repo)
+	if len(addrs) <= 1 || !s.hostnameMatch(addrs[0]) {
+		return
+	}
+
+	ctx, cancel := s.serverContext()
+	go func() {
+		defer cancel()
+		for _, addr := range addrs[1:] {
+			if err := requestReplicaUpdate(ctx, addr, repo, since); err != nil {
+				log15.Warn("updating replica", "repo", repo, "replica", addr, "error", err)
+				replicaUpdateCounter.WithLabelValues("error").Inc()
+				continue
+			}
+			replicaUpdateCounter.WithLabelValues("success").Inc()
+		}
+	}()
+}
+
+var replicaUpdateCounter = promauto.NewCounterVec(prometheus.CounterOpts{
+	Name: "src_gitserver_replica_update_total",
+	Help: "Incremented each time a gitserver asks another gitserver to update its copy of a repository.",
+}, []string{"status"})
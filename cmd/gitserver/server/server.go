@@ -138,6 +138,11 @@ type Server struct {
 	// actual hostname but can also be overridden by the HOSTNAME environment variable.
 	Hostname string
 
+	// Rebalance enables copying repositories from other gitservers when the
+	// gitserver addresses change, instead of cloning them from the code host
+	// again. See rebalance.go.
+	Rebalance bool
+
 	// shared db handle
 	DB dbutil.DB
 
@@ -167,6 +172,12 @@ type Server struct {
 
 	repoUpdateLocksMu sync.Mutex // protects the map below and also updates to locks.once
 	repoUpdateLocks   map[api.RepoName]*locks
+
+	// rebalancing is 1 while repositories are copied from other gitservers.
+	rebalancing int32
+
+	partialCloneURLsMu sync.Mutex // protects the map below
+	partialCloneURLs   map[api.RepoName]partialCloneURL
 }
 
 type locks struct {
@@ -308,15 +319,15 @@ func (s *Server) Janitor(interval time.Duration) {
 
 // SyncRepoState syncs state on disk to the database for all repos and is
 // expected to run in a background goroutine. We perform a full sync if the known
-// gitserver addresses has changed since the last run. Otherwise, we only sync
-// repos that have not yet been assigned a shard.
+// gitserver addresses or their placement scheme has changed since the last run.
+// Otherwise, we only sync repos that have not yet been assigned a shard.
 func (s *Server) SyncRepoState(interval time.Duration, batchSize, perSecond int) {
 	var previousAddrs string
 	for {
 		addrs := conf.Get().ServiceConnections.GitServers
 		// We turn addrs into a string here for easy comparison and storage of previous
 		// addresses since we'd need to take a copy of the slice anyway.
-		currentAddrs := strings.Join(addrs, ",")
+		currentAddrs := conf.GitServerPlacement() + ":" + strings.Join(addrs, ",")
 		fullSync := currentAddrs != previousAddrs
 		previousAddrs = currentAddrs
 
@@ -425,6 +436,10 @@ func (s *Server) syncRepoState(addrs []string, batchSize, perSecond int, fullSyn
 		return errors.Wrap(err, "counting repos")
 	}
 
+	// Repos which were cloned on another gitserver before the addresses
+	// changed, see rebalance.go.
+	var moved []api.RepoName
+
 	var count int
 	options := database.IterateRepoGitserverStatusOptions{}
 	if !fullSync {
@@ -446,6 +461,11 @@ func (s *Server) syncRepoState(addrs []string, batchSize, perSecond int, fullSyn
 		cloned := repoCloned(dir)
 		_, cloning := s.locker.Status(dir)
 
+		if s.Rebalance && !cloned && !cloning && repo.GitserverRepo != nil &&
+			repo.ShardID != "" && repo.ShardID != s.Hostname && repo.CloneStatus == types.CloneStatusCloned {
+			moved = append(moved, repo.Name)
+		}
+
 		var shouldUpdate bool
 		if repo.GitserverRepo == nil {
 			repo.GitserverRepo = &types.GitserverRepo{
@@ -479,6 +499,10 @@ func (s *Server) syncRepoState(addrs []string, batchSize, perSecond int, fullSyn
 	// Attempt final write
 	writeBatch()
 
+	if len(moved) > 0 {
+		s.rebalance(moved)
+	}
+
 	return err
 }
 
@@ -701,6 +725,8 @@ func (s *Server) handleRepoUpdate(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	s.updateReplicas(req.Repo, req.Since)
+
 	if err := json.NewEncoder(w).Encode(resp); err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
@@ -754,6 +780,14 @@ func (s *Server) handleArchive(w http.ResponseWriter, r *http.Request) {
 	req.Args = append(req.Args, treeish, "--")
 	req.Args = append(req.Args, paths...)
 
+	// git archive fetches the missing blobs of partial clones one at a time,
+	// so we fetch all of them first.
+	if dir := s.dir(protocol.NormalizeRepo(req.Repo)); repoCloned(dir) && isPartialClone(dir) {
+		if err := s.fetchMissingBlobs(r.Context(), req.Repo, dir, treeish, paths); err != nil {
+			log15.Warn("failed to fetch missing blobs of partial clone", "repo", repo, "error", err)
+		}
+	}
+
 	s.exec(w, r, req)
 }
 
@@ -962,6 +996,16 @@ func (s *Server) exec(w http.ResponseWriter, r *http.Request, req *protocol.Exec
 	dir.Set(cmd)
 	cmd.Stdout = stdoutW
 	cmd.Stderr = stderrW
+	var redactor *urlRedactor
+	if isPartialClone(dir) {
+		// The command may read blobs which are missing from the partial clone.
+		if remoteURL, err := s.configurePartialCloneCommand(ctx, req.Repo, cmd); err != nil {
+			log15.Warn("failed to configure command for partial clone", "repo", req.Repo, "error", err)
+		} else {
+			// Errors of the fetches for missing blobs may contain the URL.
+			redactor = newURLRedactor(remoteURL)
+		}
+	}
 
 	exitStatus, execErr = runCommand(ctx, cmd)
 
@@ -972,8 +1016,14 @@ func (s *Server) exec(w http.ResponseWriter, r *http.Request, req *protocol.Exec
 	stderr := stderrBuf.String()
 	checkMaybeCorruptRepo(req.Repo, dir, stderr)
 
+	execErrString := errorString(execErr)
+	if redactor != nil {
+		stderr = redactor.redact(stderr)
+		execErrString = redactor.redact(execErrString)
+	}
+
 	// write trailer
-	w.Header().Set("X-Exec-Error", errorString(execErr))
+	w.Header().Set("X-Exec-Error", execErrString)
 	w.Header().Set("X-Exec-Exit-Status", status)
 	w.Header().Set("X-Exec-Stderr", stderr)
 }
@@ -1144,6 +1194,10 @@ func (s *Server) setLastError(ctx context.Context, name api.RepoName, error stri
 	if s.DB == nil {
 		return nil
 	}
+	if conf.GitServerReplicationFactor() > 1 && !s.isPrimary(name) {
+		// Only the primary records the state of a repo, see replication.go.
+		return nil
+	}
 	tx, err := database.Repos(s.DB).Transact(ctx)
 	if err != nil {
 		return err
@@ -1172,6 +1226,10 @@ func (s *Server) setCloneStatus(ctx context.Context, name api.RepoName, status t
 	if s.DB == nil {
 		return nil
 	}
+	if conf.GitServerReplicationFactor() > 1 && !s.isPrimary(name) {
+		// Only the primary records the state of a repo, see replication.go.
+		return nil
+	}
 	tx, err := database.Repos(s.DB).Transact(ctx)
 	if err != nil {
 		return err
@@ -1228,6 +1286,11 @@ type cloneOptions struct {
 
 	// Overwrite will overwrite the existing clone.
 	Overwrite bool
+
+	// Peer is the remote URL of a copy of the repository on another
+	// gitserver, which is copied instead of cloning the repository from the
+	// code host. See rebalance.go.
+	Peer *vcs.URL
 }
 
 // cloneRepo performs a clone operation for the given repository. It is
@@ -1257,6 +1320,15 @@ func (s *Server) cloneRepo(ctx context.Context, repo api.RepoName, opts *cloneOp
 
 	redactor := newURLRedactor(remoteURL)
 
+	// When rebalancing, copy the repository from another gitserver. It keeps
+	// the type of the repository's syncer, but the copy is a plain git
+	// repository.
+	repoType := syncer.Type()
+	if opts != nil && opts.Peer != nil {
+		log15.Info("copying repo from peer", "repo", repo, "peer", opts.Peer.Host)
+		syncer, remoteURL = &GitRepoSyncer{}, opts.Peer
+	}
+
 	// isCloneable causes a network request, so we limit the number that can
 	// run at one time. We use a separate semaphore to cloning since these
 	// checks being blocked by a few slow clones will lead to poor feedback to
@@ -1372,7 +1444,7 @@ func (s *Server) cloneRepo(ctx context.Context, repo api.RepoName, opts *cloneOp
 			return errors.Wrap(err, "failed to ensure HEAD exists")
 		}
 
-		if err := setRepositoryType(tmp, syncer.Type()); err != nil {
+		if err := setRepositoryType(tmp, repoType); err != nil {
 			return errors.Wrap(err, `git config set "sourcegraph.type"`)
 		}
 
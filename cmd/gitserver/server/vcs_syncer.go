@@ -78,12 +78,21 @@ func (s *GitRepoSyncer) CloneCommand(ctx context.Context, remoteURL *vcs.URL, tm
 		return nil, errors.Wrapf(err, "clone setup failed")
 	}
 
-	cmd, _ = s.fetchCommand(ctx, remoteURL)
+	filter := partialCloneFilterForRemote(remoteURL)
+	if filter != "" {
+		if err := setPartialClone(GitDir(tmpPath), filter); err != nil {
+			return nil, errors.Wrapf(err, "partial clone setup failed")
+		}
+	}
+
+	cmd, _ = s.fetchCommand(ctx, remoteURL, filter)
 	cmd.Dir = tmpPath
 	return cmd, nil
 }
 
-func (s *GitRepoSyncer) fetchCommand(ctx context.Context, remoteURL *vcs.URL) (cmd *exec.Cmd, configRemoteOpts bool) {
+// fetchCommand returns the command to fetch from remoteURL. If
+// partialCloneFilter is not empty, only the objects matching it are fetched.
+func (s *GitRepoSyncer) fetchCommand(ctx context.Context, remoteURL *vcs.URL, partialCloneFilter string) (cmd *exec.Cmd, configRemoteOpts bool) {
 	configRemoteOpts = true
 	if customCmd := customFetchCmd(ctx, remoteURL); customCmd != nil {
 		cmd = customCmd
@@ -106,12 +115,23 @@ func (s *GitRepoSyncer) fetchCommand(ctx context.Context, remoteURL *vcs.URL) (c
 			// Possibly deprecated refs for sourcegraph zap experiment?
 			"+refs/sourcegraph/*:refs/sourcegraph/*")
 	}
+	if partialCloneFilter != "" && configRemoteOpts {
+		cmd.Args = partialCloneFetchArgs(cmd.Args, remoteURL, partialCloneFilter)
+		setPartialCloneURL(cmd, remoteURL)
+	}
 	return cmd, configRemoteOpts
 }
 
 // Fetch tries to fetch updates of a Git repository.
 func (s *GitRepoSyncer) Fetch(ctx context.Context, remoteURL *vcs.URL, dir GitDir) error {
-	cmd, configRemoteOpts := s.fetchCommand(ctx, remoteURL)
+	// Partial clones stay partial until they are recloned, even if they are
+	// no longer configured to be.
+	filter, err := getPartialCloneFilter(dir)
+	if err != nil {
+		return err
+	}
+
+	cmd, configRemoteOpts := s.fetchCommand(ctx, remoteURL, filter)
 	dir.Set(cmd)
 	if output, err := runWith(ctx, cmd, configRemoteOpts, nil); err != nil {
 		return errors.Wrapf(err, "failed to update with output %q", newURLRedactor(remoteURL).redact(string(output)))
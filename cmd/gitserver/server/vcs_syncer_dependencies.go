@@ -0,0 +1,207 @@
+package server
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/cockroachdb/errors"
+	"github.com/inconshreveable/log15"
+
+	"github.com/sourcegraph/sourcegraph/internal/vcs"
+)
+
+// DO NOT CHANGE. This timestamp needs to be stable so that dependency repos,
+// like JVM package repos, consistently produce the same git revhash. Changing
+// this timestamp will cause links to dependency repos to return 404s because
+// Sourcegraph URLs can optionally include the git commit sha.
+const stableGitCommitDate = "Thu Apr 8 14:24:52 2021 +0200"
+
+// Dependency repos hold the versions of a package of a package manager, like
+// a Maven artifact or an npm package. Each version is a commit with the files
+// of that version, tagged with the version. The latest version is also on the
+// "latest" branch. There is no external tool that creates such a repository,
+// so the syncers of dependency repos build them with
+// cloneDependencyRepo and fetchDependencyRepo from a dependencySource.
+
+// dependency is a version of a package.
+type dependency interface {
+	// PackageManagerSyntax returns the dependency as used by its package
+	// manager. It's the message of the commit of the version.
+	PackageManagerSyntax() string
+	// GitTagFromVersion returns the name of the git tag of the version.
+	GitTagFromVersion() string
+}
+
+// dependencySource lists the versions of packages and provides their files.
+type dependencySource interface {
+	// dependencies returns the versions of the package that belongs to the
+	// given URL path, sorted by semantic versioning in descending order.
+	dependencies(ctx context.Context, repoURLPath string) ([]dependency, error)
+	// writeDependencyFiles writes the files of the given version into dir,
+	// which is the empty working tree of a git repository.
+	writeDependencyFiles(ctx context.Context, dep dependency, dir string) error
+}
+
+// cloneDependencyRepo returns the command to be executed for cloning a
+// dependency repo from remote. The actual cloning happens inside this function
+// and the returned command is a no-op. This means that the web UI can't
+// display a helpful progress bar while cloning dependency repos, but that's an
+// acceptable tradeoff we're willing to make.
+func cloneDependencyRepo(ctx context.Context, source dependencySource, remoteURL *vcs.URL, bareGitDirectory string) (*exec.Cmd, error) {
+	err := os.MkdirAll(bareGitDirectory, 0755)
+	if err != nil {
+		return nil, err
+	}
+
+	cmd := exec.CommandContext(ctx, "git", "--bare", "init")
+	if _, err := runCommandInDirectory(ctx, cmd, bareGitDirectory); err != nil {
+		return nil, err
+	}
+
+	// fetchDependencyRepo is responsible for cleaning up temporary directories.
+	if err := fetchDependencyRepo(ctx, source, remoteURL, GitDir(bareGitDirectory)); err != nil {
+		return nil, err
+	}
+
+	// no-op command to satisfy VCSSyncer interface, see docstring for more details.
+	return exec.CommandContext(ctx, "git", "--version"), nil
+}
+
+// fetchDependencyRepo adds git tags for newly added dependency versions and
+// removes git tags for deleted versions.
+func fetchDependencyRepo(ctx context.Context, source dependencySource, remoteURL *vcs.URL, dir GitDir) error {
+	dependencies, err := source.dependencies(ctx, remoteURL.Path)
+	if err != nil {
+		return err
+	}
+
+	tags := map[string]bool{}
+
+	out, err := runCommandInDirectory(ctx, exec.CommandContext(ctx, "git", "tag"), string(dir))
+	if err != nil {
+		return err
+	}
+
+	for _, line := range strings.Split(out, "\n") {
+		if len(line) == 0 {
+			continue
+		}
+		tags[line] = true
+	}
+
+	for i, dep := range dependencies {
+		if tags[dep.GitTagFromVersion()] {
+			continue
+		}
+		// gitPushDependencyTag is reponsible for cleaning up temporary directories.
+		if err := gitPushDependencyTag(ctx, source, string(dir), dep, i == 0); err != nil {
+			return errors.Wrapf(err, "error pushing dependency %q", dep.PackageManagerSyntax())
+		}
+	}
+
+	dependencyTags := make(map[string]struct{}, len(dependencies))
+	for _, dep := range dependencies {
+		dependencyTags[dep.GitTagFromVersion()] = struct{}{}
+	}
+
+	for tag := range tags {
+		if _, isDependencyTag := dependencyTags[tag]; !isDependencyTag {
+			cmd := exec.CommandContext(ctx, "git", "tag", "-d", tag)
+			if _, err := runCommandInDirectory(ctx, cmd, string(dir)); err != nil {
+				log15.Error("Failed to delete git tag", "error", err, "tag", tag)
+				continue
+			}
+		}
+	}
+
+	return nil
+}
+
+// dependencyRepoRemoteShowCommand returns the command to be executed for
+// showing the remote of a dependency repo.
+func dependencyRepoRemoteShowCommand(ctx context.Context) *exec.Cmd {
+	return exec.CommandContext(ctx, "git", "remote", "show", "./")
+}
+
+// gitPushDependencyTag pushes a git tag to the given bareGitDirectory path. The
+// tag points to a commit that adds all files of the given dependency. When
+// isLatestVersion is true, the latest branch of the bare git directory will
+// also be updated to point to the same commit as the git tag.
+func gitPushDependencyTag(ctx context.Context, source dependencySource, bareGitDirectory string, dep dependency, isLatestVersion bool) error {
+	tmpDirectory, err := ioutil.TempDir("", "dependency")
+	if err != nil {
+		return err
+	}
+	// Always clean up created temporary directories.
+	defer os.RemoveAll(tmpDirectory)
+
+	cmd := exec.CommandContext(ctx, "git", "init")
+	if _, err := runCommandInDirectory(ctx, cmd, tmpDirectory); err != nil {
+		return err
+	}
+
+	if err := source.writeDependencyFiles(ctx, dep, tmpDirectory); err != nil {
+		return err
+	}
+
+	if err := commitDependency(ctx, dep, tmpDirectory); err != nil {
+		return err
+	}
+
+	cmd = exec.CommandContext(ctx, "git", "remote", "add", "origin", bareGitDirectory)
+	if _, err := runCommandInDirectory(ctx, cmd, tmpDirectory); err != nil {
+		return err
+	}
+
+	cmd = exec.CommandContext(ctx, "git", "push", "--force", "origin", "--tags")
+	if _, err := runCommandInDirectory(ctx, cmd, tmpDirectory); err != nil {
+		return err
+	}
+
+	if isLatestVersion {
+		defaultBranch, err := runCommandInDirectory(ctx, exec.CommandContext(ctx, "git", "rev-parse", "--abbrev-ref", "HEAD"), tmpDirectory)
+		if err != nil {
+			return err
+		}
+		cmd = exec.CommandContext(ctx, "git", "push", "--force", "origin", strings.TrimSpace(defaultBranch)+":latest", dep.GitTagFromVersion())
+		if _, err := runCommandInDirectory(ctx, cmd, tmpDirectory); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// commitDependency creates a git commit in the given working directory that
+// adds all of its files and tags it with the version of the dependency.
+func commitDependency(ctx context.Context, dep dependency, workingDirectory string) error {
+	// Packages may contain .gitignore files, but we want all their files.
+	cmd := exec.CommandContext(ctx, "git", "add", "--force", ".")
+	if _, err := runCommandInDirectory(ctx, cmd, workingDirectory); err != nil {
+		return err
+	}
+
+	cmd = exec.CommandContext(ctx, "git", "commit", "--allow-empty", "-m", dep.PackageManagerSyntax(), "--date", stableGitCommitDate)
+	if _, err := runCommandInDirectory(ctx, cmd, workingDirectory); err != nil {
+		return err
+	}
+
+	cmd = exec.CommandContext(ctx, "git", "tag", "-m", dep.PackageManagerSyntax(), dep.GitTagFromVersion())
+	if _, err := runCommandInDirectory(ctx, cmd, workingDirectory); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func runCommandInDirectory(ctx context.Context, cmd *exec.Cmd, workingDirectory string) (string, error) {
+	cmd.Dir = workingDirectory
+	output, err := runWith(ctx, cmd, false, nil)
+	if err != nil {
+		return "", errors.Wrapf(err, "command %s failed with output %s", cmd.Args, string(output))
+	}
+	return string(output), nil
+}
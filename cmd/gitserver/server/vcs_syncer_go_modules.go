@@ -0,0 +1,244 @@
+package server
+
+import (
+	"archive/zip"
+	"context"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/cockroachdb/errors"
+
+	"github.com/sourcegraph/sourcegraph/internal/conf/reposource"
+	"github.com/sourcegraph/sourcegraph/internal/extsvc/gomodules/goproxy"
+	"github.com/sourcegraph/sourcegraph/internal/httpcli"
+	"github.com/sourcegraph/sourcegraph/internal/vcs"
+	"github.com/sourcegraph/sourcegraph/schema"
+)
+
+// maxGoModuleZipSize is the maximum total size of the files we extract from
+// the zip archive of a Go module. The go command rejects larger modules.
+const maxGoModuleZipSize = 500 << 20
+
+type GoModulesSyncer struct {
+	Config *schema.GoModulesConnection
+
+	// HTTPClient is used to talk to the Go module proxies. It defaults to
+	// httpcli.ExternalDoer.
+	HTTPClient httpcli.Doer
+}
+
+var _ VCSSyncer = &GoModulesSyncer{}
+
+func (s *GoModulesSyncer) GoDependencies() []string {
+	if s.Config == nil {
+		return nil
+	}
+	return s.Config.Dependencies
+}
+
+func (s *GoModulesSyncer) Type() string {
+	return "go_modules"
+}
+
+func (s *GoModulesSyncer) client() *goproxy.Client {
+	return goproxy.NewClient(s.Config, s.HTTPClient)
+}
+
+// IsCloneable checks to see if the VCS remote URL is cloneable. Any non-nil
+// error indicates there is a problem.
+func (s *GoModulesSyncer) IsCloneable(ctx context.Context, remoteURL *vcs.URL) error {
+	_, err := s.moduleDependencies(ctx, remoteURL.Path)
+	return err
+}
+
+// CloneCommand returns the command to be executed for cloning from remote.
+// Like for JVM packages, the actual cloning happens inside this method and the
+// returned command is a no-op.
+func (s *GoModulesSyncer) CloneCommand(ctx context.Context, remoteURL *vcs.URL, bareGitDirectory string) (*exec.Cmd, error) {
+	return cloneDependencyRepo(ctx, s, remoteURL, bareGitDirectory)
+}
+
+// Fetch adds git tags for newly added module versions and removes git tags for
+// deleted versions.
+func (s *GoModulesSyncer) Fetch(ctx context.Context, remoteURL *vcs.URL, dir GitDir) error {
+	return fetchDependencyRepo(ctx, s, remoteURL, dir)
+}
+
+// RemoteShowCommand returns the command to be executed for showing remote.
+func (s *GoModulesSyncer) RemoteShowCommand(ctx context.Context, remoteURL *vcs.URL) (cmd *exec.Cmd, err error) {
+	return dependencyRepoRemoteShowCommand(ctx), nil
+}
+
+func (s *GoModulesSyncer) dependencies(ctx context.Context, repoURLPath string) ([]dependency, error) {
+	goDependencies, err := s.moduleDependencies(ctx, repoURLPath)
+	if err != nil {
+		return nil, err
+	}
+	dependencies := make([]dependency, 0, len(goDependencies))
+	for i := range goDependencies {
+		dependencies = append(dependencies, &goDependencies[i])
+	}
+	return dependencies, nil
+}
+
+// moduleDependencies returns the versions of the Go module that belongs to the
+// given URL path, sorted by semantic versioning. A configured module path
+// without a version stands for all the versions the proxies list.
+func (s *GoModulesSyncer) moduleDependencies(ctx context.Context, repoURLPath string) (dependencies []reposource.GoDependency, err error) {
+	module, err := reposource.ParseGoModuleFromRepoURL(repoURLPath)
+	if err != nil {
+		return nil, err
+	}
+
+	client := s.client()
+	seen := map[string]bool{}
+	add := func(dependency reposource.GoDependency) {
+		if !seen[dependency.Version] {
+			seen[dependency.Version] = true
+			dependencies = append(dependencies, dependency)
+		}
+	}
+
+	for _, dependency := range s.GoDependencies() {
+		if !module.MatchesDependencyString(dependency) {
+			continue
+		}
+
+		if dependency == module.Path {
+			versions, err := client.ListVersions(ctx, module)
+			if err != nil {
+				return nil, err
+			}
+			for _, version := range versions {
+				add(version)
+			}
+			continue
+		}
+
+		dep, err := reposource.ParseGoDependency(dependency)
+		if err != nil {
+			return nil, err
+		}
+		if client.Exists(ctx, dep) {
+			add(dep)
+		}
+		// Silently ignore non-existent versions, like for JVM packages.
+	}
+
+	if len(dependencies) == 0 {
+		return nil, errors.Errorf("no Go module versions for URL path %s", repoURLPath)
+	}
+
+	reposource.SortGoDependencies(dependencies)
+	return dependencies, nil
+}
+
+// writeDependencyFiles writes all the files of the zip archive of the given
+// module version into workingDirectory.
+func (s *GoModulesSyncer) writeDependencyFiles(ctx context.Context, dep dependency, workingDirectory string) error {
+	dependency := *dep.(*reposource.GoDependency)
+
+	// Zip archives can't be read as a stream, so we download them to a
+	// temporary file first.
+	zipFile, err := ioutil.TempFile("", "gomod-*.zip")
+	if err != nil {
+		return err
+	}
+	// Always clean up created temporary files.
+	defer os.Remove(zipFile.Name())
+
+	err = s.downloadZip(ctx, dependency, zipFile)
+	if closeErr := zipFile.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := extractGoModuleZip(zipFile.Name(), workingDirectory, dependency); err != nil {
+		return errors.Wrapf(err, "extracting zip of %s", dependency.PackageManagerSyntax())
+	}
+	return nil
+}
+
+// downloadZip writes the zip archive of the given module version to w. It
+// fails for archives larger than maxGoModuleZipSize, which the go command
+// rejects too.
+func (s *GoModulesSyncer) downloadZip(ctx context.Context, dependency reposource.GoDependency, w io.Writer) error {
+	zipReader, err := s.client().FetchZip(ctx, dependency)
+	if err != nil {
+		return err
+	}
+	defer zipReader.Close()
+
+	n, err := io.Copy(w, io.LimitReader(zipReader, maxGoModuleZipSize+1))
+	if err != nil {
+		return err
+	}
+	if n > maxGoModuleZipSize {
+		return errors.Errorf("zip of %s is larger than %d bytes", dependency.PackageManagerSyntax(), maxGoModuleZipSize)
+	}
+	return nil
+}
+
+// extractGoModuleZip extracts the files of a Go module zip archive into dir.
+// All the files of a module zip are inside the "<module>@<version>/"
+// directory, which is stripped. See https://golang.org/ref/mod#zip-files.
+func extractGoModuleZip(zipPath, dir string, dependency reposource.GoDependency) error {
+	zipReader, err := zip.OpenReader(zipPath)
+	if err != nil {
+		return err
+	}
+	defer zipReader.Close()
+
+	prefix := dependency.PackageManagerSyntax() + "/"
+
+	var size uint64
+	for _, file := range zipReader.File {
+		if !file.Mode().IsRegular() || !strings.HasPrefix(file.Name, prefix) {
+			continue
+		}
+
+		// Never write outside of dir, or into a git directory.
+		name := path.Clean(strings.TrimPrefix(file.Name, prefix))
+		if name == ".." || strings.HasPrefix(name, "../") || strings.HasPrefix(name, "/") || strings.Contains("/"+name+"/", "/.git/") {
+			continue
+		}
+
+		size += file.UncompressedSize64
+		if size > maxGoModuleZipSize {
+			return errors.Errorf("module is larger than %d bytes", maxGoModuleZipSize)
+		}
+
+		if err := extractGoModuleFile(file, filepath.Join(dir, filepath.FromSlash(name))); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func extractGoModuleFile(file *zip.File, name string) error {
+	r, err := file.Open()
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	if err := os.MkdirAll(filepath.Dir(name), 0755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(name, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(f, r); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,157 @@
+package server
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"os/exec"
+	"path"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/sourcegraph/sourcegraph/internal/vcs"
+	"github.com/sourcegraph/sourcegraph/schema"
+)
+
+const (
+	exampleGoModuleURL       = "go/example.com/example"
+	exampleGoModule          = "example.com/example"
+	exampleGoDependency      = "example.com/example@v1.0.0"
+	exampleGoDependency2     = "example.com/example@v2.0.0+incompatible"
+	exampleGoFilePath        = "example.go"
+	exampleGoFileContents    = "package example\n"
+	exampleGoFileContents2   = "package example // v2\n"
+	exampleGoModFileContents = "module example.com/example\n"
+)
+
+// createGoModuleZip returns a zip archive with the given files, like the ones
+// served by Go module proxies.
+func createGoModuleZip(t *testing.T, files map[string]string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zipWriter := zip.NewWriter(&buf)
+	for name, contents := range files {
+		w, err := zipWriter.Create(name)
+		assert.Nil(t, err)
+		_, err = io.WriteString(w, contents)
+		assert.Nil(t, err)
+	}
+	assert.Nil(t, zipWriter.Close())
+	return buf.Bytes()
+}
+
+// goModuleProxy is a stand-in for a Go module proxy which serves the given
+// zip archives by version of the module example.com/example.
+func goModuleProxy(t *testing.T, zips map[string][]byte) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		p := strings.TrimPrefix(r.URL.Path, "/"+exampleGoModule+"/@v/")
+		if p == "list" {
+			var versions []string
+			for version := range zips {
+				versions = append(versions, version)
+			}
+			sort.Strings(versions)
+			io.WriteString(w, strings.Join(versions, "\n"))
+			return
+		}
+		if version := strings.TrimSuffix(p, ".info"); zips[version] != nil {
+			io.WriteString(w, `{"Version":"`+version+`"}`)
+			return
+		}
+		if version := strings.TrimSuffix(p, ".zip"); zips[version] != nil {
+			w.Write(zips[version])
+			return
+		}
+		http.NotFound(w, r)
+	}))
+}
+
+func (s GoModulesSyncer) runCloneCommand(t *testing.T, bareGitDirectory string, dependencies []string) {
+	url := vcs.URL{
+		URL: url.URL{Path: exampleGoModuleURL},
+	}
+	s.Config.Dependencies = dependencies
+	cmd, err := s.CloneCommand(context.Background(), &url, bareGitDirectory)
+	assert.Nil(t, err)
+	assert.Nil(t, cmd.Run())
+}
+
+func TestGoModulesCloneCommand(t *testing.T) {
+	dir, err := os.MkdirTemp("", "")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	proxy := goModuleProxy(t, map[string][]byte{
+		"v1.0.0": createGoModuleZip(t, map[string]string{
+			exampleGoDependency + "/" + exampleGoFilePath: exampleGoFileContents,
+			exampleGoDependency + "/go.mod":               exampleGoModFileContents,
+			// Files outside of the module and git directories are ignored.
+			exampleGoDependency + "/../../evil.go": "evil",
+			exampleGoDependency + "/.git/config":   "evil",
+			"other.com/other@v1.0.0/other.go":      "other",
+		}),
+		"v2.0.0+incompatible": createGoModuleZip(t, map[string]string{
+			exampleGoDependency2 + "/" + exampleGoFilePath: exampleGoFileContents2,
+		}),
+	})
+	defer proxy.Close()
+
+	s := GoModulesSyncer{
+		Config:     &schema.GoModulesConnection{Urls: []string{proxy.URL}, Dependencies: []string{}},
+		HTTPClient: http.DefaultClient,
+	}
+	bareGitDirectory := path.Join(dir, "git")
+
+	s.runCloneCommand(t, bareGitDirectory, []string{exampleGoDependency})
+	assertCommandOutput(t,
+		exec.Command("git", "tag", "--list"),
+		bareGitDirectory,
+		"v1.0.0\n",
+	)
+	assertCommandOutput(t,
+		exec.Command("git", "ls-tree", "-r", "--name-only", "v1.0.0"),
+		bareGitDirectory,
+		"example.go\ngo.mod\n",
+	)
+	assertCommandOutput(t,
+		exec.Command("git", "show", "v1.0.0:"+exampleGoFilePath),
+		bareGitDirectory,
+		exampleGoFileContents,
+	)
+
+	// Versions which don't exist in the proxy are skipped.
+	s.runCloneCommand(t, bareGitDirectory, []string{exampleGoDependency, exampleGoDependency2, exampleGoModule + "@v3.0.0"})
+	assertCommandOutput(t,
+		exec.Command("git", "tag", "--list"),
+		bareGitDirectory,
+		"v1.0.0\nv2.0.0+incompatible\n", // verify that the v2.0.0+incompatible tag got added
+	)
+	assertCommandOutput(t,
+		exec.Command("git", "show", "latest:"+exampleGoFilePath),
+		bareGitDirectory,
+		exampleGoFileContents2,
+	)
+
+	s.runCloneCommand(t, bareGitDirectory, []string{exampleGoDependency})
+	assertCommandOutput(t,
+		exec.Command("git", "tag", "--list"),
+		bareGitDirectory,
+		"v1.0.0\n", // verify that the v2.0.0+incompatible tag has been removed.
+	)
+
+	// A module without a version stands for all the versions the proxy lists.
+	s.runCloneCommand(t, bareGitDirectory, []string{exampleGoModule})
+	assertCommandOutput(t,
+		exec.Command("git", "tag", "--list"),
+		bareGitDirectory,
+		"v1.0.0\nv2.0.0+incompatible\n",
+	)
+}
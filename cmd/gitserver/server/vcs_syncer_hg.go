@@ -0,0 +1,94 @@
+package server
+
+import (
+	"context"
+	"os"
+	"os/exec"
+
+	"github.com/cockroachdb/errors"
+
+	"github.com/sourcegraph/sourcegraph/internal/vcs"
+)
+
+// HgRepoSyncer is a syncer for Mercurial repositories. Repositories are
+// converted to Git repositories by the git-remote-hg remote helper, which
+// keeps a Mercurial clone and the marks of already converted changesets
+// inside the Git directory, so that fetches only convert new changesets.
+//
+// Mercurial branches are converted to refs/heads/branches/<name>, bookmarks
+// to refs/heads/<name> and tags to refs/tags/<name>. The tip of the default
+// branch is available as master unless a bookmark of that name exists.
+type HgRepoSyncer struct{}
+
+var _ VCSSyncer = &HgRepoSyncer{}
+
+func (s *HgRepoSyncer) Type() string {
+	return "hg"
+}
+
+// IsCloneable checks to see if the Mercurial remote URL is cloneable.
+func (s *HgRepoSyncer) IsCloneable(ctx context.Context, remoteURL *vcs.URL) error {
+	args := []string{"identify", "--noninteractive", remoteURL.String()}
+	ctx, cancel := context.WithTimeout(ctx, shortGitCommandTimeout(args))
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, "hg", args...)
+	cmd.Env = append(os.Environ(), "HGPLAIN=1")
+	out, err := runWith(ctx, cmd, false, nil)
+	if err != nil {
+		if ctxerr := ctx.Err(); ctxerr != nil {
+			err = ctxerr
+		}
+		if len(out) > 0 {
+			err = errors.Errorf("%s (output follows)\n\n%s", err, newURLRedactor(remoteURL).redact(string(out)))
+		}
+		return err
+	}
+	return nil
+}
+
+// CloneCommand returns the command to be executed for cloning a Mercurial
+// repository as a Git repository.
+func (s *HgRepoSyncer) CloneCommand(ctx context.Context, remoteURL *vcs.URL, tmpPath string) (*exec.Cmd, error) {
+	if err := os.MkdirAll(tmpPath, os.ModePerm); err != nil {
+		return nil, errors.Wrapf(err, "clone failed to create tmp dir")
+	}
+
+	cmd := exec.CommandContext(ctx, "git", "init", "--bare", ".")
+	cmd.Dir = tmpPath
+	if err := cmd.Run(); err != nil {
+		return nil, errors.Wrapf(err, "clone setup failed")
+	}
+
+	cmd = exec.CommandContext(ctx, "git", hgFetchArgs(remoteURL)...)
+	cmd.Dir = tmpPath
+	return cmd, nil
+}
+
+// Fetch tries to fetch and convert new changesets of a Mercurial repository.
+func (s *HgRepoSyncer) Fetch(ctx context.Context, remoteURL *vcs.URL, dir GitDir) error {
+	cmd := exec.CommandContext(ctx, "git", hgFetchArgs(remoteURL)...)
+	dir.Set(cmd)
+	if output, err := runWith(ctx, cmd, true, nil); err != nil {
+		return errors.Wrapf(err, "failed to update with output %q", newURLRedactor(remoteURL).redact(string(output)))
+	}
+	return nil
+}
+
+// RemoteShowCommand returns the command to be executed for showing the Git
+// remote of a Mercurial repository.
+func (s *HgRepoSyncer) RemoteShowCommand(ctx context.Context, remoteURL *vcs.URL) (*exec.Cmd, error) {
+	// The remote isn't a Git repository, so we show the converted one.
+	return exec.CommandContext(ctx, "git", "remote", "show", "./"), nil
+}
+
+// hgFetchArgs returns the arguments of the git command that fetches the
+// Mercurial repository at remoteURL through git-remote-hg.
+func hgFetchArgs(remoteURL *vcs.URL) []string {
+	return []string{
+		"fetch", "--progress", "--prune", "hg::" + remoteURL.String(),
+		// Bookmarks and branches (under refs/heads/branches/)
+		"+refs/heads/*:refs/heads/*",
+		"+refs/tags/*:refs/tags/*",
+	}
+}
@@ -6,7 +6,6 @@ import (
 	"context"
 	"encoding/binary"
 	"encoding/json"
-	"io/ioutil"
 	"os"
 	"os/exec"
 	"path/filepath"
@@ -14,7 +13,6 @@ import (
 	"strings"
 
 	"github.com/cockroachdb/errors"
-	"github.com/inconshreveable/log15"
 
 	"github.com/sourcegraph/sourcegraph/internal/conf/reposource"
 	"github.com/sourcegraph/sourcegraph/internal/extsvc/jvmpackages/coursier"
@@ -22,15 +20,7 @@ import (
 	"github.com/sourcegraph/sourcegraph/schema"
 )
 
-const (
-	// DO NOT CHANGE. This timestamp needs to be stable so that JVM package
-	// repos consistently produce the same git revhash.  Changing this
-	// timestamp will cause links to JVM package repos to return 404s
-	// because Sourcegraph URLs can optionally include the git commit sha.
-	stableGitCommitDate = "Thu Apr 8 14:24:52 2021 +0200"
-
-	jvmMajorVersion0 = 44
-)
+const jvmMajorVersion0 = 44
 
 type JVMPackagesSyncer struct {
 	Config *schema.JVMPackagesConnection
@@ -72,82 +62,32 @@ func (s *JVMPackagesSyncer) IsCloneable(ctx context.Context, remoteURL *vcs.URL)
 // CloneCommand returns the command to be executed for cloning from remote.
 // There is no external tool that performs all the step for creating a JVM
 // package repository so the actual cloning happens inside this method and the
-// returned command is a no-op. This means that the web UI can't display a
-// helpful progress bar while cloning JVM package repositories, but that's an
-// acceptable tradeoff we're willing to make.
+// returned command is a no-op, see cloneDependencyRepo.
 func (s *JVMPackagesSyncer) CloneCommand(ctx context.Context, remoteURL *vcs.URL, bareGitDirectory string) (*exec.Cmd, error) {
-	err := os.MkdirAll(bareGitDirectory, 0755)
-	if err != nil {
-		return nil, err
-	}
-
-	cmd := exec.CommandContext(ctx, "git", "--bare", "init")
-	if _, err := runCommandInDirectory(ctx, cmd, bareGitDirectory); err != nil {
-		return nil, err
-	}
-
-	// The Fetch method is responsible for cleaning up temporary directories.
-	if err := s.Fetch(ctx, remoteURL, GitDir(bareGitDirectory)); err != nil {
-		return nil, err
-	}
-
-	// no-op command to satisfy VCSSyncer interface, see docstring for more details.
-	return exec.CommandContext(ctx, "git", "--version"), nil
+	return cloneDependencyRepo(ctx, s, remoteURL, bareGitDirectory)
 }
 
 // Fetch adds git tags for newly added dependency versions and removes git tags
 // for deleted deleted versions.
 func (s *JVMPackagesSyncer) Fetch(ctx context.Context, remoteURL *vcs.URL, dir GitDir) error {
-	dependencies, err := s.packageDependencies(ctx, remoteURL.Path)
-	if err != nil {
-		return err
-	}
+	return fetchDependencyRepo(ctx, s, remoteURL, dir)
+}
 
-	tags := map[string]bool{}
+// RemoteShowCommand returns the command to be executed for showing remote.
+func (s *JVMPackagesSyncer) RemoteShowCommand(ctx context.Context, remoteURL *vcs.URL) (cmd *exec.Cmd, err error) {
+	return dependencyRepoRemoteShowCommand(ctx), nil
+}
 
-	out, err := runCommandInDirectory(ctx, exec.CommandContext(ctx, "git", "tag"), string(dir))
+func (s *JVMPackagesSyncer) dependencies(ctx context.Context, repoURLPath string) ([]dependency, error) {
+	mavenDependencies, err := s.packageDependencies(ctx, repoURLPath)
 	if err != nil {
-		return err
-	}
-
-	for _, line := range strings.Split(out, "\n") {
-		if len(line) == 0 {
-			continue
-		}
-		tags[line] = true
-	}
-
-	for i, dependency := range dependencies {
-		if tags[dependency.GitTagFromVersion()] {
-			continue
-		}
-		// the gitPushDependencyTag method is reponsible for cleaning up temporary directories.
-		if err := s.gitPushDependencyTag(ctx, string(dir), dependency, i == 0); err != nil {
-			return errors.Wrapf(err, "error pushing dependency %q", dependency)
-		}
+		return nil, err
 	}
-
-	dependencyTags := make(map[string]struct{}, len(dependencies))
-	for _, dependency := range dependencies {
-		dependencyTags[dependency.GitTagFromVersion()] = struct{}{}
+	dependencies := make([]dependency, 0, len(mavenDependencies))
+	for i := range mavenDependencies {
+		dependencies = append(dependencies, &mavenDependencies[i])
 	}
-
-	for tag := range tags {
-		if _, isDependencyTag := dependencyTags[tag]; !isDependencyTag {
-			cmd := exec.CommandContext(ctx, "git", "tag", "-d", tag)
-			if _, err := runCommandInDirectory(ctx, cmd, string(dir)); err != nil {
-				log15.Error("Failed to delete git tag", "error", err, "tag", tag)
-				continue
-			}
-		}
-	}
-
-	return nil
-}
-
-// RemoteShowCommand returns the command to be executed for showing remote.
-func (s *JVMPackagesSyncer) RemoteShowCommand(ctx context.Context, remoteURL *vcs.URL) (cmd *exec.Cmd, err error) {
-	return exec.CommandContext(ctx, "git", "remote", "show", "./"), nil
+	return dependencies, nil
 }
 
 // packageDependencies returns the list of JVM dependencies that belong to the given URL path.
@@ -183,17 +123,12 @@ func (s *JVMPackagesSyncer) packageDependencies(ctx context.Context, repoUrlPath
 	return dependencies, nil
 }
 
-// gitPushDependencyTag pushes a git tag to the given bareGitDirectory path. The
-// tag points to a commit that adds all sources of given dependency. When
-// isMainBranch is true, the main branch of the bare git directory will also be
-// updated to point to the same commit as the git tag.
-func (s *JVMPackagesSyncer) gitPushDependencyTag(ctx context.Context, bareGitDirectory string, dependency reposource.MavenDependency, isLatestVersion bool) error {
-	tmpDirectory, err := ioutil.TempDir("", "maven")
-	if err != nil {
-		return err
-	}
-	// Always clean up created temporary directories.
-	defer os.RemoveAll(tmpDirectory)
+// writeDependencyFiles writes all the file contents of the sources jar of the
+// given dependency, and an lsif-java.json file, into workingDirectory. A
+// `*.jar` file works the same way as a `*.zip` file, it can even be
+// uncompressed with the `unzip` command-line tool.
+func (s *JVMPackagesSyncer) writeDependencyFiles(ctx context.Context, dep dependency, workingDirectory string) error {
+	dependency := *dep.(*reposource.MavenDependency)
 
 	sourceCodePaths, err := coursier.FetchSources(ctx, s.Config, dependency)
 	if err != nil {
@@ -204,47 +139,7 @@ func (s *JVMPackagesSyncer) gitPushDependencyTag(ctx context.Context, bareGitDir
 		return errors.Errorf("no sources for dependency %s", dependency)
 	}
 
-	sourceCodePath := sourceCodePaths[0]
-
-	cmd := exec.CommandContext(ctx, "git", "init")
-	if _, err := runCommandInDirectory(ctx, cmd, tmpDirectory); err != nil {
-		return err
-	}
-
-	err = s.commitJar(ctx, dependency, tmpDirectory, sourceCodePath, s.Config)
-	if err != nil {
-		return err
-	}
-
-	cmd = exec.CommandContext(ctx, "git", "remote", "add", "origin", bareGitDirectory)
-	if _, err := runCommandInDirectory(ctx, cmd, tmpDirectory); err != nil {
-		return err
-	}
-
-	cmd = exec.CommandContext(ctx, "git", "push", "--force", "origin", "--tags")
-	if _, err := runCommandInDirectory(ctx, cmd, tmpDirectory); err != nil {
-		return err
-	}
-
-	if isLatestVersion {
-		defaultBranch, err := runCommandInDirectory(ctx, exec.CommandContext(ctx, "git", "rev-parse", "--abbrev-ref", "HEAD"), tmpDirectory)
-		if err != nil {
-			return err
-		}
-		cmd = exec.CommandContext(ctx, "git", "push", "--force", "origin", strings.TrimSpace(defaultBranch)+":latest", dependency.GitTagFromVersion())
-		if _, err := runCommandInDirectory(ctx, cmd, tmpDirectory); err != nil {
-			return err
-		}
-	}
-
-	return nil
-}
-
-// commitJar creates a git commit in the given working directory that adds all the file contents of the given jar file.
-// A `*.jar` file works the same way as a `*.zip` file, it can even be uncompressed with the `unzip` command-line tool.
-func (s *JVMPackagesSyncer) commitJar(ctx context.Context, dependency reposource.MavenDependency,
-	workingDirectory, sourceCodeJarPath string, connection *schema.JVMPackagesConnection) error {
-	cmd := exec.CommandContext(ctx, "unzip", sourceCodeJarPath)
+	cmd := exec.CommandContext(ctx, "unzip", sourceCodePaths[0])
 	if _, err := runCommandInDirectory(ctx, cmd, workingDirectory); err != nil {
 		return err
 	}
@@ -255,7 +150,7 @@ func (s *JVMPackagesSyncer) commitJar(ctx context.Context, dependency reposource
 	}
 	defer file.Close()
 
-	jvmVersion, err := inferJVMVersionFromByteCode(ctx, connection, dependency)
+	jvmVersion, err := inferJVMVersionFromByteCode(ctx, s.Config, dependency)
 	if err != nil {
 		return err
 	}
@@ -270,26 +165,7 @@ func (s *JVMPackagesSyncer) commitJar(ctx context.Context, dependency reposource
 	}
 
 	_, err = file.Write(jsonContents)
-	if err != nil {
-		return err
-	}
-
-	cmd = exec.CommandContext(ctx, "git", "add", ".")
-	if _, err := runCommandInDirectory(ctx, cmd, workingDirectory); err != nil {
-		return err
-	}
-
-	cmd = exec.CommandContext(ctx, "git", "commit", "-m", dependency.CoursierSyntax(), "--date", stableGitCommitDate)
-	if _, err := runCommandInDirectory(ctx, cmd, workingDirectory); err != nil {
-		return err
-	}
-
-	cmd = exec.CommandContext(ctx, "git", "tag", "-m", dependency.CoursierSyntax(), dependency.GitTagFromVersion())
-	if _, err := runCommandInDirectory(ctx, cmd, workingDirectory); err != nil {
-		return err
-	}
-
-	return nil
+	return err
 }
 
 // inferJVMVersionFromByteCode returns the JVM version that was used to compile
@@ -356,15 +232,6 @@ func roundJVMVersionToNearestStableVersion(javaVersion int) int {
 	return javaVersion
 }
 
-func runCommandInDirectory(ctx context.Context, cmd *exec.Cmd, workingDirectory string) (string, error) {
-	cmd.Dir = workingDirectory
-	output, err := runWith(ctx, cmd, false, nil)
-	if err != nil {
-		return "", errors.Wrapf(err, "command %s failed with output %s", cmd.Args, string(output))
-	}
-	return string(output), nil
-}
-
 type lsifJavaJSON struct {
 	Kind         string   `json:"kind"`
 	JVM          string   `json:"jvm"`
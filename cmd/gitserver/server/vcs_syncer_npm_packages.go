@@ -0,0 +1,201 @@
+package server
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"io"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/cockroachdb/errors"
+
+	"github.com/sourcegraph/sourcegraph/internal/conf/reposource"
+	"github.com/sourcegraph/sourcegraph/internal/extsvc/npmpackages/npm"
+	"github.com/sourcegraph/sourcegraph/internal/httpcli"
+	"github.com/sourcegraph/sourcegraph/internal/vcs"
+	"github.com/sourcegraph/sourcegraph/schema"
+)
+
+// maxNPMTarballSize is the maximum total size of the files we extract from
+// the tarball of an npm package.
+const maxNPMTarballSize = 1 << 30
+
+type NPMPackagesSyncer struct {
+	Config *schema.NPMPackagesConnection
+
+	// HTTPClient is used to talk to the npm registry. It defaults to
+	// httpcli.ExternalDoer.
+	HTTPClient httpcli.Doer
+}
+
+var _ VCSSyncer = &NPMPackagesSyncer{}
+
+func (s *NPMPackagesSyncer) NPMDependencies() []string {
+	if s.Config == nil {
+		return nil
+	}
+	return s.Config.Dependencies
+}
+
+func (s *NPMPackagesSyncer) Type() string {
+	return "npm_packages"
+}
+
+func (s *NPMPackagesSyncer) client() *npm.Client {
+	return npm.NewClient(s.Config, s.HTTPClient)
+}
+
+// IsCloneable checks to see if the VCS remote URL is cloneable. Any non-nil
+// error indicates there is a problem.
+func (s *NPMPackagesSyncer) IsCloneable(ctx context.Context, remoteURL *vcs.URL) error {
+	_, err := s.packageDependencies(ctx, remoteURL.Path)
+	return err
+}
+
+// CloneCommand returns the command to be executed for cloning from remote.
+// Like for JVM packages, the actual cloning happens inside this method and the
+// returned command is a no-op.
+func (s *NPMPackagesSyncer) CloneCommand(ctx context.Context, remoteURL *vcs.URL, bareGitDirectory string) (*exec.Cmd, error) {
+	return cloneDependencyRepo(ctx, s, remoteURL, bareGitDirectory)
+}
+
+// Fetch adds git tags for newly added dependency versions and removes git tags
+// for deleted versions.
+func (s *NPMPackagesSyncer) Fetch(ctx context.Context, remoteURL *vcs.URL, dir GitDir) error {
+	return fetchDependencyRepo(ctx, s, remoteURL, dir)
+}
+
+// RemoteShowCommand returns the command to be executed for showing remote.
+func (s *NPMPackagesSyncer) RemoteShowCommand(ctx context.Context, remoteURL *vcs.URL) (cmd *exec.Cmd, err error) {
+	return dependencyRepoRemoteShowCommand(ctx), nil
+}
+
+func (s *NPMPackagesSyncer) dependencies(ctx context.Context, repoURLPath string) ([]dependency, error) {
+	npmDependencies, err := s.packageDependencies(ctx, repoURLPath)
+	if err != nil {
+		return nil, err
+	}
+	dependencies := make([]dependency, 0, len(npmDependencies))
+	for i := range npmDependencies {
+		dependencies = append(dependencies, &npmDependencies[i])
+	}
+	return dependencies, nil
+}
+
+// packageDependencies returns the list of npm dependencies that belong to the
+// given URL path, sorted by semantic versioning. A URL maps to a single npm
+// package, which may contain multiple versions (one git tag per version).
+func (s *NPMPackagesSyncer) packageDependencies(ctx context.Context, repoURLPath string) (dependencies []reposource.NPMDependency, err error) {
+	pkg, err := reposource.ParseNPMPackageFromRepoURL(repoURLPath)
+	if err != nil {
+		return nil, err
+	}
+
+	client := s.client()
+	for _, dependency := range s.NPMDependencies() {
+		if !pkg.MatchesDependencyString(dependency) {
+			continue
+		}
+		dependency, err := reposource.ParseNPMDependency(dependency)
+		if err != nil {
+			return nil, err
+		}
+
+		if client.Exists(ctx, dependency) {
+			dependencies = append(dependencies, dependency)
+		}
+		// Silently ignore non-existent dependencies because they are already
+		// logged out in the `GetRepo` method in internal/repos/npm_packages.go.
+	}
+
+	if len(dependencies) == 0 {
+		return nil, errors.Errorf("no npm dependencies for URL path %s", repoURLPath)
+	}
+
+	reposource.SortNPMDependencies(dependencies)
+	return dependencies, nil
+}
+
+// writeDependencyFiles writes all the files of the tarball of the given npm
+// package version into workingDirectory.
+func (s *NPMPackagesSyncer) writeDependencyFiles(ctx context.Context, dep dependency, workingDirectory string) error {
+	dependency := *dep.(*reposource.NPMDependency)
+
+	tarball, err := s.client().FetchTarball(ctx, dependency)
+	if err != nil {
+		return err
+	}
+	defer tarball.Close()
+
+	if err := extractNPMTarball(tarball, workingDirectory); err != nil {
+		return errors.Wrapf(err, "extracting tarball of %s", dependency.PackageManagerSyntax())
+	}
+	return nil
+}
+
+// extractNPMTarball extracts the regular files of a gzipped npm package
+// tarball into dir. The files of an npm package are all inside a single
+// top-level directory, usually "package/", which is stripped.
+func extractNPMTarball(tarball io.Reader, dir string) error {
+	gzipReader, err := gzip.NewReader(tarball)
+	if err != nil {
+		return err
+	}
+	defer gzipReader.Close()
+
+	var size int64
+	tarReader := tar.NewReader(gzipReader)
+	for {
+		header, err := tarReader.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		// Skip directories, symlinks and anything else which isn't a file.
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+
+		// Never write outside of dir, or into a git directory.
+		name := path.Clean(strings.TrimPrefix(header.Name, "/"))
+		if name == ".." || strings.HasPrefix(name, "../") || strings.Contains("/"+name+"/", "/.git/") {
+			continue
+		}
+		i := strings.Index(name, "/")
+		if i < 0 {
+			continue
+		}
+		name = name[i+1:]
+
+		size += header.Size
+		if size > maxNPMTarballSize {
+			return errors.Errorf("tarball is larger than %d bytes", maxNPMTarballSize)
+		}
+
+		if err := writeNPMFile(filepath.Join(dir, filepath.FromSlash(name)), tarReader); err != nil {
+			return err
+		}
+	}
+}
+
+func writeNPMFile(name string, r io.Reader) error {
+	if err := os.MkdirAll(filepath.Dir(name), 0755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(name, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(f, r); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
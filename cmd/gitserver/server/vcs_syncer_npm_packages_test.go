@@ -0,0 +1,159 @@
+package server
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"os/exec"
+	"path"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/sourcegraph/sourcegraph/internal/vcs"
+	"github.com/sourcegraph/sourcegraph/schema"
+)
+
+const (
+	exampleNPMPackageURL      = "npm/example/example"
+	exampleNPMDependency      = "@example/example@1.0.0"
+	exampleNPMDependency2     = "@example/example@2.0.0"
+	exampleNPMFilePath        = "index.js"
+	exampleNPMFileContents    = "module.exports = 1;\n"
+	exampleNPMFileContents2   = "module.exports = 2;\n"
+	exampleNPMPackageJSONPath = "package.json"
+)
+
+// createNPMTarball returns a gzipped tarball with the given files, inside the
+// "package/" directory like the tarballs published to npm.
+func createNPMTarball(t *testing.T, files map[string]string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	gzipWriter := gzip.NewWriter(&buf)
+	tarWriter := tar.NewWriter(gzipWriter)
+	for name, contents := range files {
+		assert.Nil(t, tarWriter.WriteHeader(&tar.Header{
+			Typeflag: tar.TypeReg,
+			Name:     name,
+			Mode:     0644,
+			Size:     int64(len(contents)),
+		}))
+		_, err := tarWriter.Write([]byte(contents))
+		assert.Nil(t, err)
+	}
+	assert.Nil(t, tarWriter.Close())
+	assert.Nil(t, gzipWriter.Close())
+	return buf.Bytes()
+}
+
+// npmRegistry is a stand-in for an npm registry which serves the given
+// tarballs by version of the package @example/example.
+func npmRegistry(t *testing.T, tarballs map[string][]byte) *httptest.Server {
+	var ts *httptest.Server
+	ts = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		p := r.URL.EscapedPath()
+		if version := strings.TrimPrefix(p, "/@example%2Fexample/"); version != p {
+			if _, ok := tarballs[version]; !ok {
+				http.NotFound(w, r)
+				return
+			}
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"name":    "@example/example",
+				"version": version,
+				"dist":    map[string]string{"tarball": ts.URL + "/tarballs/" + version + ".tgz"},
+			})
+			return
+		}
+		if version := strings.TrimSuffix(strings.TrimPrefix(p, "/tarballs/"), ".tgz"); tarballs[version] != nil {
+			w.Write(tarballs[version])
+			return
+		}
+		http.NotFound(w, r)
+	}))
+	return ts
+}
+
+func (s NPMPackagesSyncer) runCloneCommand(t *testing.T, bareGitDirectory string, dependencies []string) {
+	url := vcs.URL{
+		URL: url.URL{Path: exampleNPMPackageURL},
+	}
+	s.Config.Dependencies = dependencies
+	cmd, err := s.CloneCommand(context.Background(), &url, bareGitDirectory)
+	assert.Nil(t, err)
+	assert.Nil(t, cmd.Run())
+}
+
+func TestNPMCloneCommand(t *testing.T) {
+	dir, err := os.MkdirTemp("", "")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	registry := npmRegistry(t, map[string][]byte{
+		"1.0.0": createNPMTarball(t, map[string]string{
+			"package/" + exampleNPMFilePath:        exampleNPMFileContents,
+			"package/" + exampleNPMPackageJSONPath: `{"name":"@example/example","version":"1.0.0"}`,
+			// Files outside of the package and git directories are ignored.
+			"package/../../evil.js": "evil",
+			"package/.git/config":   "evil",
+		}),
+		"2.0.0": createNPMTarball(t, map[string]string{
+			"package/" + exampleNPMFilePath: exampleNPMFileContents2,
+		}),
+	})
+	defer registry.Close()
+
+	s := NPMPackagesSyncer{
+		Config:     &schema.NPMPackagesConnection{Registry: registry.URL, Dependencies: []string{}},
+		HTTPClient: http.DefaultClient,
+	}
+	bareGitDirectory := path.Join(dir, "git")
+
+	s.runCloneCommand(t, bareGitDirectory, []string{exampleNPMDependency})
+	assertCommandOutput(t,
+		exec.Command("git", "tag", "--list"),
+		bareGitDirectory,
+		"v1.0.0\n",
+	)
+	assertCommandOutput(t,
+		exec.Command("git", "ls-tree", "-r", "--name-only", "v1.0.0"),
+		bareGitDirectory,
+		"index.js\npackage.json\n",
+	)
+	assertCommandOutput(t,
+		exec.Command("git", "show", "v1.0.0:"+exampleNPMFilePath),
+		bareGitDirectory,
+		exampleNPMFileContents,
+	)
+
+	// Versions which don't exist in the registry are skipped.
+	s.runCloneCommand(t, bareGitDirectory, []string{exampleNPMDependency, exampleNPMDependency2, "@example/example@3.0.0"})
+	assertCommandOutput(t,
+		exec.Command("git", "tag", "--list"),
+		bareGitDirectory,
+		"v1.0.0\nv2.0.0\n", // verify that the v2.0.0 tag got added
+	)
+	assertCommandOutput(t,
+		exec.Command("git", "show", "v2.0.0:"+exampleNPMFilePath),
+		bareGitDirectory,
+		exampleNPMFileContents2,
+	)
+	assertCommandOutput(t,
+		exec.Command("git", "show", "latest:"+exampleNPMFilePath),
+		bareGitDirectory,
+		exampleNPMFileContents2,
+	)
+
+	s.runCloneCommand(t, bareGitDirectory, []string{exampleNPMDependency})
+	assertCommandOutput(t,
+		exec.Command("git", "tag", "--list"),
+		bareGitDirectory,
+		"v1.0.0\n", // verify that the v2.0.0 tag has been removed.
+	)
+}
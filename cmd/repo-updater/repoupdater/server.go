@@ -42,8 +42,12 @@ type Server struct {
 	JVMPackagesSource interface {
 		GetRepo(ctx context.Context, artifactName string) (*types.Repo, error)
 	}
+	NPMPackagesSource interface {
+		GetRepo(ctx context.Context, packagePath string) (*types.Repo, error)
+	}
 	Scheduler interface {
 		UpdateOnce(id api.RepoID, name api.RepoName)
+		UpdateFromWebhook(id api.RepoID, name api.RepoName)
 		ScheduleInfo(id api.RepoID) *protocol.RepoUpdateSchedulerInfoResult
 	}
 	GitserverClient interface {
@@ -188,7 +192,11 @@ func (s *Server) enqueueRepoUpdate(ctx context.Context, req *protocol.RepoUpdate
 
 	repo := rs[0]
 
-	s.Scheduler.UpdateOnce(repo.ID, repo.Name)
+	if req.FromWebhook {
+		s.Scheduler.UpdateFromWebhook(repo.ID, repo.Name)
+	} else {
+		s.Scheduler.UpdateOnce(repo.ID, repo.Name)
+	}
 
 	return &protocol.RepoUpdateResponse{
 		ID:   repo.ID,
@@ -454,6 +462,25 @@ func (s *Server) remoteRepoSync(ctx context.Context, codehost *extsvc.CodeHost,
 				ErrorNotFound: true,
 			}, nil
 		}
+	case extsvc.NPMPackages:
+		if s.NPMPackagesSource != nil {
+			repo, err = s.NPMPackagesSource.GetRepo(ctx, remoteName)
+			if err != nil {
+				if errcode.IsNotFound(err) {
+					return &protocol.RepoLookupResult{
+						ErrorNotFound: true,
+					}, nil
+				}
+				return nil, err
+			}
+		} else {
+			log15.Error(
+				"NPMPackagesSource is nil: doing nothing. To fix this problem, make sure that cloud_default is true for the npm packages external service type.",
+				"remoteName", remoteName)
+			return &protocol.RepoLookupResult{
+				ErrorNotFound: true,
+			}, nil
+		}
 	}
 
 	if repo.Private {
@@ -745,7 +745,8 @@ func (s *fakeRepoSource) GetRepo(context.Context, string) (*types.Repo, error) {
 
 type fakeScheduler struct{}
 
-func (s *fakeScheduler) UpdateOnce(_ api.RepoID, _ api.RepoName) {}
+func (s *fakeScheduler) UpdateOnce(_ api.RepoID, _ api.RepoName)        {}
+func (s *fakeScheduler) UpdateFromWebhook(_ api.RepoID, _ api.RepoName) {}
 func (s *fakeScheduler) ScheduleInfo(id api.RepoID) *protocol.RepoUpdateSchedulerInfoResult {
 	return &protocol.RepoUpdateSchedulerInfoResult{}
 }
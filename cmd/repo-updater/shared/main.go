@@ -165,7 +165,11 @@ func Main(enterpriseInit EnterpriseInit) {
 		src = repos.NewSourcer(cf, repos.ObservedSource(log15.Root(), m))
 	}
 
-	scheduler := repos.NewUpdateScheduler()
+	scheduler := repos.NewUpdateScheduler(store)
+	if err := scheduler.Restore(ctx); err != nil {
+		// Repos are scheduled as new repos if we can't restore their schedule.
+		log15.Error("Restoring repo update schedule", "err", err)
+	}
 	server := &repoupdater.Server{
 		Store:           store,
 		Scheduler:       scheduler,
@@ -199,6 +203,7 @@ func Main(enterpriseInit EnterpriseInit) {
 				extsvc.KindGitHub,
 				extsvc.KindGitLab,
 				extsvc.KindJVMPackages,
+				extsvc.KindNPMPackages,
 			},
 		})
 		if err != nil {
@@ -222,6 +227,8 @@ func Main(enterpriseInit EnterpriseInit) {
 				}
 			case *schema.JVMPackagesConnection:
 				server.JVMPackagesSource, err = repos.NewJVMPackagesSource(e)
+			case *schema.NPMPackagesConnection:
+				server.NPMPackagesSource, err = repos.NewNPMPackagesSource(e, cf)
 			}
 
 			if err != nil {
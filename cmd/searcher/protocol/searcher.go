@@ -123,6 +123,12 @@ type PatternInfo struct {
 	// file list in the frontend and passes it to searcher.
 	CombyRule string
 
+	// CombyRewrite is a template that rewrites the matches of a structural
+	// search. When set, searcher returns a unified diff of each file's
+	// rewritten content in FileMatch.Diff instead of line matches. It only
+	// applies when IsStructuralPat is true.
+	CombyRewrite string
+
 	// Select is the value of the the select field in the query. It is not necessary to
 	// use it since selection is done after the query completes, but exposing it can enable
 	// optimizations.
@@ -140,6 +146,9 @@ func (p *PatternInfo) String() string {
 		} else {
 			args = append(args, "comby")
 		}
+		if p.CombyRewrite != "" {
+			args = append(args, fmt.Sprintf("rewrite:%q", p.CombyRewrite))
+		}
 	}
 	if p.IsWordMatch {
 		args = append(args, "word")
@@ -202,6 +211,33 @@ type FileMatch struct {
 
 	// LimitHit is true if LineMatches may not include all LineMatches.
 	LimitHit bool
+
+	// Diff is a unified diff of the file's content after rewriting the
+	// matches of a structural search with PatternInfo.CombyRewrite. It is
+	// empty for all other searches.
+	Diff string
+
+	// MatchRanges holds the range of each match, which may span multiple
+	// lines. LineMatches holds one entry for every line of every match.
+	MatchRanges []Range
+}
+
+// Range is the range of a match in a file, from Start up to but excluding End.
+type Range struct {
+	Start Location
+	End   Location
+}
+
+// Location is a position in a file.
+type Location struct {
+	// Offset is the 0-based byte offset from the start of the file.
+	Offset int
+
+	// Line is the 0-based line number.
+	Line int
+
+	// Column is the 0-based offset in characters from the start of the line.
+	Column int
 }
 
 // LineMatch is the struct used by vscode to receive search results for a line.
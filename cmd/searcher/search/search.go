@@ -262,6 +262,9 @@ func validateParams(p *protocol.Request) error {
 	if p.IsNegated && p.IsStructuralPat {
 		return errors.New("Negated patterns are not supported for structural searches")
 	}
+	if p.CombyRewrite != "" && !p.IsStructuralPat {
+		return errors.New("Rewrite templates are only supported for structural searches")
+	}
 	return nil
 }
 
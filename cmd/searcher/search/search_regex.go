@@ -19,6 +19,7 @@ import (
 	"golang.org/x/sync/errgroup"
 
 	"github.com/sourcegraph/sourcegraph/cmd/searcher/protocol"
+	"github.com/sourcegraph/sourcegraph/internal/langmatch"
 	"github.com/sourcegraph/sourcegraph/internal/pathmatch"
 	"github.com/sourcegraph/sourcegraph/internal/store"
 	"github.com/sourcegraph/sourcegraph/internal/trace/ot"
@@ -57,6 +58,10 @@ type readerGrep struct {
 	// whether a file path matches (and should be searched).
 	matchPath pathmatch.PathMatcher
 
+	// matchLang is compiled from the languages of lang: filters and reports
+	// whether a file is written in them. It is nil if there are none.
+	matchLang *langmatch.LangMatcher
+
 	// literalSubstring is used to test if a file is worth considering for
 	// matches. literalSubstring is guaranteed to appear in any match found by
 	// re. It is the output of the longestLiteral function. It is only set if
@@ -122,10 +127,16 @@ func compile(p *protocol.PatternInfo) (*readerGrep, error) {
 		return nil, err
 	}
 
+	matchLang, err := langmatch.Compile(p.Languages)
+	if err != nil {
+		return nil, err
+	}
+
 	return &readerGrep{
 		re:               re,
 		ignoreCase:       !p.IsCaseSensitive,
 		matchPath:        matchPath,
+		matchLang:        matchLang,
 		literalSubstring: literalSubstring,
 	}, nil
 }
@@ -137,6 +148,7 @@ func (rg *readerGrep) Copy() *readerGrep {
 		re:               rg.re,
 		ignoreCase:       rg.ignoreCase,
 		matchPath:        rg.matchPath,
+		matchLang:        rg.matchLang,
 		literalSubstring: rg.literalSubstring,
 	}
 }
@@ -153,10 +165,11 @@ func (rg *readerGrep) matchString(s string) bool {
 	return rg.re.MatchString(s)
 }
 
-// Find returns a LineMatch for each line that matches rg in reader.
-// LimitHit is true if some matches may not have been included in the result.
+// Find returns a LineMatch for each line that matches rg in reader, and the
+// Range of each match. A match spanning multiple lines has a single Range but
+// a LineMatch for each of its lines.
 // NOTE: This is not safe to use concurrently.
-func (rg *readerGrep) Find(zf *store.ZipFile, f *store.SrcFile, limit int) (matches []protocol.LineMatch, err error) {
+func (rg *readerGrep) Find(zf *store.ZipFile, f *store.SrcFile, limit int) (matches []protocol.LineMatch, ranges []protocol.Range, err error) {
 	// fileMatchBuf is what we run match on, fileBuf is the original
 	// data (for Preview).
 	fileBuf := zf.DataFor(f)
@@ -183,7 +196,7 @@ func (rg *readerGrep) Find(zf *store.ZipFile, f *store.SrcFile, limit int) (matc
 	// per-line. Additionally if we have a non-empty literalSubstring, we use
 	// that to prune out files since doing bytes.Index is very fast.
 	if !bytes.Contains(fileMatchBuf, rg.literalSubstring) {
-		return nil, nil
+		return nil, nil, nil
 	}
 
 	// find limit+1 matches so we know whether we hit the limit
@@ -219,8 +232,31 @@ func (rg *readerGrep) Find(zf *store.ZipFile, f *store.SrcFile, limit int) (matc
 		lastMatchIndex = matchIndex
 		lastLineNumber = lineNumber
 		matches = appendMatches(matches, fileBuf[lineStart:lineEnd], fileMatchBuf[lineStart:lineEnd], lineNumber, start-lineStart, end-lineStart)
+		ranges = append(ranges, matchRange(fileBuf, lineStart, lineNumber, start, end))
+	}
+	return matches, ranges, nil
+}
+
+// matchRange returns the Range of the match fileBuf[start:end], which starts
+// on the line lineNumber beginning at lineStart.
+func matchRange(fileBuf []byte, lineStart, lineNumber, start, end int) protocol.Range {
+	endLineNumber := lineNumber + bytes.Count(fileBuf[start:end], []byte{'\n'})
+	endLineStart := lineStart
+	if idx := bytes.LastIndexByte(fileBuf[start:end], '\n'); idx >= 0 {
+		endLineStart = start + idx + 1
+	}
+	return protocol.Range{
+		Start: protocol.Location{
+			Offset: start,
+			Line:   lineNumber,
+			Column: utf8.RuneCount(fileBuf[lineStart:start]),
+		},
+		End: protocol.Location{
+			Offset: end,
+			Line:   endLineNumber,
+			Column: utf8.RuneCount(fileBuf[endLineStart:end]),
+		},
 	}
-	return matches, nil
 }
 
 func hydrateLineNumbers(fileBuf []byte, lastLineNumber, lastMatchIndex, lineStart int, match []int) (lineNumber, matchIndex int) {
@@ -280,10 +316,11 @@ func appendMatches(matches []protocol.LineMatch, fileBuf []byte, matchLineBuf []
 
 // FindZip is a convenience function to run Find on f.
 func (rg *readerGrep) FindZip(zf *store.ZipFile, f *store.SrcFile, limit int) (protocol.FileMatch, error) {
-	lm, err := rg.Find(zf, f, limit)
+	lm, ranges, err := rg.Find(zf, f, limit)
 	return protocol.FileMatch{
 		Path:        f.Name,
 		LineMatches: lm,
+		MatchRanges: ranges,
 		MatchCount:  len(lm),
 		LimitHit:    false,
 	}, err
@@ -337,8 +374,9 @@ func regexSearch(ctx context.Context, rg *readerGrep, zf *store.ZipFile, limit i
 	if rg.re == nil || (patternMatchesPaths && !patternMatchesContent) {
 		// Fast path for only matching file paths (or with a nil pattern, which matches all files,
 		// so is effectively matching only on file paths).
-		for _, f := range files {
-			if match := rg.matchPath.MatchPath(f.Name) && rg.matchString(f.Name); match == !isPatternNegated {
+		for i := range files {
+			f := &files[i]
+			if match := rg.matchPath.MatchPath(f.Name) && rg.matchString(f.Name) && rg.matchLang.MatchLang(f.Name, zf.DataFor(f)); match == !isPatternNegated {
 				if ctx.Err() != nil {
 					return ctx.Err()
 				}
@@ -371,8 +409,10 @@ func regexSearch(ctx context.Context, rg *readerGrep, zf *store.ZipFile, limit i
 				files = files[1:]
 				filesmu.Unlock()
 
-				// decide whether to process, record that decision
-				if !rg.matchPath.MatchPath(f.Name) {
+				// decide whether to process, record that decision. The
+				// language is detected from the content if the path is
+				// ambiguous.
+				if !rg.matchPath.MatchPath(f.Name) || !rg.matchLang.MatchLang(f.Name, zf.DataFor(f)) {
 					filesSkipped.Inc()
 					continue
 				}
@@ -597,3 +597,90 @@ func TestRegexSearch(t *testing.T) {
 		})
 	}
 }
+
+func TestMultilineMatchRanges(t *testing.T) {
+	zipData, err := testutil.CreateZip(map[string]string{
+		"a.go": "package a\n\nfunc föo() {\n\treturn\n}\n",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	zf, err := store.MockZipFile(zipData)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rg, err := compile(&protocol.PatternInfo{Pattern: `(?s)föo\(\).*?return\n`, IsRegExp: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	fileMatches, _, err := regexSearchBatch(context.Background(), rg, zf, 10, true, false, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(fileMatches) != 1 {
+		t.Fatalf("expected 1 file match, got %d", len(fileMatches))
+	}
+	fm := fileMatches[0]
+
+	wantRanges := []protocol.Range{{
+		Start: protocol.Location{Offset: 16, Line: 2, Column: 5},
+		End:   protocol.Location{Offset: 33, Line: 4, Column: 0},
+	}}
+	if !reflect.DeepEqual(fm.MatchRanges, wantRanges) {
+		t.Errorf("got ranges %+v, want %+v", fm.MatchRanges, wantRanges)
+	}
+	// Regexp search counts line matches, not match ranges.
+	if fm.MatchCount != 2 {
+		t.Errorf("got match count %d, want 2", fm.MatchCount)
+	}
+
+	wantLines := []protocol.LineMatch{
+		{Preview: "func föo() {", LineNumber: 2, OffsetAndLengths: [][2]int{{5, 8}}},
+		{Preview: "\treturn", LineNumber: 3, OffsetAndLengths: [][2]int{{0, 8}}},
+	}
+	if !reflect.DeepEqual(fm.LineMatches, wantLines) {
+		t.Errorf("got line matches %+v, want %+v", fm.LineMatches, wantLines)
+	}
+}
+
+func TestRegexSearchLanguages(t *testing.T) {
+	zipData, err := testutil.CreateZip(map[string]string{
+		"main.go": "// foo\n",
+		"objc.h":  "#import <Foundation/Foundation.h>\n@interface Foo : NSObject\n@end\n",
+		"cpp.h":   "namespace foo {\nclass Bar {};\n}\n",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	zf, err := store.MockZipFile(zipData)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range []struct {
+		languages []string
+		want      []string
+	}{
+		{languages: nil, want: []string{"cpp.h", "main.go", "objc.h"}},
+		{languages: []string{"objective-c"}, want: []string{"objc.h"}},
+		{languages: []string{"c++"}, want: []string{"cpp.h"}},
+	} {
+		rg, err := compile(&protocol.PatternInfo{Pattern: "foo", Languages: tc.languages})
+		if err != nil {
+			t.Fatal(err)
+		}
+		fileMatches, _, err := regexSearchBatch(context.Background(), rg, zf, 10, true, false, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got []string
+		for _, fm := range fileMatches {
+			got = append(got, fm.Path)
+		}
+		sort.Strings(got)
+		if !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("languages %q: got file matches %v, want %v", tc.languages, got, tc.want)
+		}
+	}
+}
@@ -7,6 +7,8 @@ import (
 	"strings"
 	"time"
 
+	"github.com/cockroachdb/errors"
+	"github.com/google/zoekt"
 	"github.com/inconshreveable/log15"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promauto"
@@ -14,6 +16,7 @@ import (
 	"github.com/sourcegraph/sourcegraph/cmd/searcher/protocol"
 	"github.com/sourcegraph/sourcegraph/internal/api"
 	"github.com/sourcegraph/sourcegraph/internal/comby"
+	"github.com/sourcegraph/sourcegraph/internal/env"
 	"github.com/sourcegraph/sourcegraph/internal/lazyregexp"
 	"github.com/sourcegraph/sourcegraph/internal/search"
 	"github.com/sourcegraph/sourcegraph/internal/store"
@@ -73,17 +76,43 @@ func highlightMultipleLines(r *comby.Match) (matches []protocol.LineMatch) {
 
 func toFileMatch(combyMatch comby.FileMatch) protocol.FileMatch {
 	var lineMatches []protocol.LineMatch
+	var ranges []protocol.Range
 	for _, r := range combyMatch.Matches {
 		lineMatches = append(lineMatches, highlightMultipleLines(&r)...)
+		ranges = append(ranges, toRange(r.Range))
 	}
 	return protocol.FileMatch{
 		Path:        combyMatch.URI,
 		LineMatches: lineMatches,
+		MatchRanges: ranges,
 		MatchCount:  len(combyMatch.Matches),
 		LimitHit:    false,
 	}
 }
 
+// toRange converts a comby range, which has 1-based lines and columns, to a
+// protocol.Range.
+func toRange(r comby.Range) protocol.Range {
+	return protocol.Range{
+		Start: protocol.Location{Offset: r.Start.Offset, Line: r.Start.Line - 1, Column: r.Start.Column - 1},
+		End:   protocol.Location{Offset: r.End.Offset, Line: r.End.Line - 1, Column: r.End.Column - 1},
+	}
+}
+
+// toDiffFileMatch converts the result of a structural rewrite to a FileMatch
+// carrying the unified diff. Each hunk of the diff counts as a match.
+func toDiffFileMatch(fileDiff comby.FileDiff) protocol.FileMatch {
+	matchCount := strings.Count("\n"+fileDiff.Diff, "\n@@ ")
+	if matchCount == 0 {
+		matchCount = 1
+	}
+	return protocol.FileMatch{
+		Path:       fileDiff.URI,
+		Diff:       fileDiff.Diff,
+		MatchCount: matchCount,
+	}
+}
+
 var isValidMatcher = lazyregexp.New(`\.(s|sh|bib|c|cs|css|dart|clj|elm|erl|ex|f|fsx|go|html|hs|java|js|json|jl|kt|tex|lisp|nim|md|ml|org|pas|php|py|re|rb|rs|rst|scala|sql|swift|tex|txt|ts)$`)
 
 func extensionToMatcher(extension string) string {
@@ -202,7 +231,22 @@ func filteredStructuralSearch(ctx context.Context, zipPath string, zipFile *stor
 		extensionHint = filepath.Ext(matchedPaths[0])
 	}
 
-	return structuralSearch(ctx, zipPath, Subset(matchedPaths), extensionHint, p.Pattern, p.CombyRule, p.Languages, repo, sender)
+	if useNativeStructuralSearch(p.CombyRule, p.CombyRewrite) {
+		matched := make(map[string]struct{}, len(matchedPaths))
+		for _, path := range matchedPaths {
+			matched[path] = struct{}{}
+		}
+		files := make([]structuralFile, 0, len(matchedPaths))
+		for i := range zipFile.Files {
+			f := &zipFile.Files[i]
+			if _, ok := matched[f.Name]; ok {
+				files = append(files, structuralFile{Name: f.Name, Content: zipFile.DataFor(f)})
+			}
+		}
+		return nativeStructuralSearch(ctx, files, extensionHint, p.Pattern, p.Languages, repo, sender)
+	}
+
+	return structuralSearch(ctx, zipPath, Subset(matchedPaths), extensionHint, p.Pattern, p.CombyRule, p.CombyRewrite, p.Languages, repo, sender)
 }
 
 // toMatcher returns the matcher that parameterizes structural search. It
@@ -240,7 +284,100 @@ type Subset []string
 
 var All UniversalSet = struct{}{}
 
-func structuralSearch(ctx context.Context, zipPath string, paths filePatterns, extensionHint, pattern, rule string, languages []string, repo api.RepoName, sender *limitedStreamCollector) error {
+// structuralSearchEngine selects the implementation of structural search.
+var structuralSearchEngine = env.Get("SEARCHER_STRUCTURAL_SEARCH_ENGINE", "auto", "structural search implementation: comby runs the comby binary, native matches in process, auto uses comby if it is installed")
+
+// useNativeStructuralSearch reports whether a structural search with the given
+// rule and rewrite template runs in process instead of with the comby binary.
+// Rules and rewrites are only supported by comby.
+func useNativeStructuralSearch(rule, rewrite string) bool {
+	if rule != "" || rewrite != "" {
+		return false
+	}
+	switch structuralSearchEngine {
+	case "native":
+		return true
+	case "comby":
+		return false
+	default:
+		return !comby.Exists()
+	}
+}
+
+// structuralFile is a file searched by nativeStructuralSearch.
+type structuralFile struct {
+	Name    string
+	Content []byte
+}
+
+// nativeStructuralSearch is like structuralSearch, but matches pattern against
+// files in process with comby.Template rather than running the comby binary.
+func nativeStructuralSearch(ctx context.Context, files []structuralFile, extensionHint, pattern string, languages []string, repo api.RepoName, sender *limitedStreamCollector) error {
+	log15.Info("native structural search", "repo", string(repo))
+
+	matcher := toMatcher(languages, extensionHint)
+	template, err := comby.Compile(pattern, matcher)
+	if err != nil {
+		return badRequestError{err.Error()}
+	}
+
+	// Files for which matching in process takes too long are matched with
+	// comby instead.
+	var fallback []zoekt.FileMatch
+	for _, f := range files {
+		matches, err := template.Matches(ctx, f.Content)
+		if err == comby.ErrMatchBudgetExceeded {
+			fallback = append(fallback, zoekt.FileMatch{FileName: f.Name, Content: f.Content})
+			continue
+		}
+		if ctx.Err() != nil {
+			return nil
+		}
+		if len(matches) == 0 {
+			continue
+		}
+		sender.Send(toFileMatch(comby.FileMatch{URI: f.Name, Matches: matches}))
+	}
+
+	if len(fallback) == 0 {
+		return nil
+	}
+	if !comby.Exists() {
+		return errors.Errorf("structural search took too long for %d files, which requires comby to be installed", len(fallback))
+	}
+	log15.Info("native structural search falling back to comby", "repo", string(repo), "files", len(fallback))
+
+	zipFile, err := os.CreateTemp("", "*.zip")
+	if err != nil {
+		return err
+	}
+	defer zipFile.Close()
+	defer os.Remove(zipFile.Name())
+
+	if err := writeZip(ctx, zipFile, fallback); err != nil {
+		return err
+	}
+
+	combyMatches, err := comby.Matches(ctx, comby.Args{
+		Input:         comby.ZipPath(zipFile.Name()),
+		Matcher:       matcher,
+		MatchTemplate: pattern,
+		MatchOnly:     true,
+		NumWorkers:    4,
+	})
+	if err != nil {
+		return err
+	}
+	for _, combyMatch := range combyMatches {
+		if ctx.Err() != nil {
+			return nil
+		}
+		sender.Send(toFileMatch(combyMatch))
+	}
+	return nil
+}
+
+func structuralSearch(ctx context.Context, zipPath string, paths filePatterns, extensionHint, pattern, rule, rewrite string, languages []string, repo api.RepoName, sender *limitedStreamCollector) error {
 	log15.Info("structural search", "repo", string(repo))
 
 	// Cap the number of forked processes to limit the size of zip contents being mapped to memory. Resolving #7133 could help to lift this restriction.
@@ -263,6 +400,21 @@ func structuralSearch(ctx context.Context, zipPath string, paths filePatterns, e
 		NumWorkers:    numWorkers,
 	}
 
+	if rewrite != "" {
+		args.RewriteTemplate = rewrite
+		fileDiffs, err := comby.Diffs(ctx, args)
+		if err != nil {
+			return err
+		}
+		for _, fileDiff := range fileDiffs {
+			if ctx.Err() != nil {
+				return nil
+			}
+			sender.Send(toDiffFileMatch(fileDiff))
+		}
+		return nil
+	}
+
 	combyMatches, err := comby.Matches(ctx, args)
 	if err != nil {
 		return err
@@ -284,6 +436,7 @@ func structuralSearchWithZoekt(ctx context.Context, p *protocol.Request, sender
 		IsRegExp:                     p.IsRegExp,
 		IsStructuralPat:              p.IsStructuralPat,
 		CombyRule:                    p.CombyRule,
+		CombyRewrite:                 p.CombyRewrite,
 		IsWordMatch:                  p.IsWordMatch,
 		IsCaseSensitive:              p.IsCaseSensitive,
 		FileMatchLimit:               int32(p.Limit),
@@ -309,6 +462,20 @@ func structuralSearchWithZoekt(ctx context.Context, p *protocol.Request, sender
 		return false, nil
 	}
 
+	var extensionHint string
+	if len(zoektMatches) > 0 {
+		filename := zoektMatches[0].FileName
+		extensionHint = filepath.Ext(filename)
+	}
+
+	if useNativeStructuralSearch(p.CombyRule, p.CombyRewrite) {
+		files := make([]structuralFile, 0, len(zoektMatches))
+		for _, m := range zoektMatches {
+			files = append(files, structuralFile{Name: m.FileName, Content: m.Content})
+		}
+		return false, nativeStructuralSearch(ctx, files, extensionHint, p.Pattern, p.Languages, p.Repo, sender)
+	}
+
 	zipFile, err := os.CreateTemp("", "*.zip")
 	if err != nil {
 		return false, err
@@ -320,13 +487,7 @@ func structuralSearchWithZoekt(ctx context.Context, p *protocol.Request, sender
 		return false, err
 	}
 
-	var extensionHint string
-	if len(zoektMatches) > 0 {
-		filename := zoektMatches[0].FileName
-		extensionHint = filepath.Ext(filename)
-	}
-
-	return false, structuralSearch(ctx, zipFile.Name(), All, extensionHint, p.Pattern, p.CombyRule, p.Languages, p.Repo, sender)
+	return false, structuralSearch(ctx, zipFile.Name(), All, extensionHint, p.Pattern, p.CombyRule, p.CombyRewrite, p.Languages, p.Repo, sender)
 }
 
 var requestTotalStructuralSearch = promauto.NewCounterVec(prometheus.CounterOpts{
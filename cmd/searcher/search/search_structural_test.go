@@ -78,7 +78,7 @@ func foo(go string) {}
 
 				ctx, cancel, sender := newLimitedStreamCollector(context.Background(), 100000000)
 				defer cancel()
-				err := structuralSearch(ctx, zf, Subset(p.IncludePatterns), "", p.Pattern, p.CombyRule, p.Languages, "repo_foo", sender)
+				err := structuralSearch(ctx, zf, Subset(p.IncludePatterns), "", p.Pattern, p.CombyRule, "", p.Languages, "repo_foo", sender)
 				if err != nil {
 					t.Fatal(err)
 				}
@@ -139,7 +139,7 @@ func foo(go.txt) {}
 		extensionHint := filepath.Ext(filename)
 		ctx, cancel, sender := newLimitedStreamCollector(context.Background(), 1000000000)
 		defer cancel()
-		err := structuralSearch(ctx, zf, All, extensionHint, "foo(:[args])", "", languages, "repo_foo", sender)
+		err := structuralSearch(ctx, zf, All, extensionHint, "foo(:[args])", "", "", languages, "repo_foo", sender)
 		if err != nil {
 			return "ERROR: " + err.Error()
 		}
@@ -340,7 +340,7 @@ func TestIncludePatterns(t *testing.T) {
 	}
 	ctx, cancel, sender := newLimitedStreamCollector(context.Background(), 1000000000)
 	defer cancel()
-	err = structuralSearch(ctx, zf, Subset(p.IncludePatterns), "", p.Pattern, p.CombyRule, p.Languages, "foo", sender)
+	err = structuralSearch(ctx, zf, Subset(p.IncludePatterns), "", p.Pattern, p.CombyRule, "", p.Languages, "foo", sender)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -384,7 +384,7 @@ func TestRule(t *testing.T) {
 
 	ctx, cancel, sender := newLimitedStreamCollector(context.Background(), 1000000000)
 	defer cancel()
-	err = structuralSearch(ctx, zf, Subset(p.IncludePatterns), "", p.Pattern, p.CombyRule, p.Languages, "repo", sender)
+	err = structuralSearch(ctx, zf, Subset(p.IncludePatterns), "", p.Pattern, p.CombyRule, "", p.Languages, "repo", sender)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -401,6 +401,12 @@ func TestRule(t *testing.T) {
 					Preview:          "func foo(success)",
 				},
 			},
+			MatchRanges: []protocol.Range{
+				{
+					Start: protocol.Location{Offset: 0, Line: 0, Column: 0},
+					End:   protocol.Location{Offset: 17, Line: 0, Column: 17},
+				},
+			},
 			MatchCount: 1,
 		},
 	}
@@ -410,6 +416,136 @@ func TestRule(t *testing.T) {
 	}
 }
 
+func TestRewrite(t *testing.T) {
+	// If we are not on CI skip the test.
+	if os.Getenv("CI") == "" {
+		t.Skip("Not on CI, skipping comby-dependent test")
+	}
+
+	input := map[string]string{
+		"file.go":  "func foo(a, b) {}\n",
+		"other.go": "func bar() {}\n",
+	}
+
+	zipData, err := testutil.CreateZip(input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	zf, cleanup, err := testutil.TempZipFileOnDisk(zipData)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cleanup()
+
+	ctx, cancel, sender := newLimitedStreamCollector(context.Background(), 1000000000)
+	defer cancel()
+	err = structuralSearch(ctx, zf, All, ".go", "foo(:[x], :[y])", "", "foo(:[y], :[x])", nil, "repo", sender)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := sender.collected
+
+	want := []protocol.FileMatch{
+		{
+			Path:       "file.go",
+			Diff:       "--- file.go\n+++ file.go\n@@ -1,1 +1,1 @@\n-func foo(a, b) {}\n+func foo(b, a) {}",
+			MatchCount: 1,
+		},
+	}
+
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Fatalf("unexpected file matches (-want +got):\n%s", diff)
+	}
+}
+
+func TestToDiffFileMatch(t *testing.T) {
+	cases := []struct {
+		diff       string
+		matchCount int
+	}{
+		{
+			diff:       "--- a.go\n+++ a.go\n@@ -1,1 +1,1 @@\n-a\n+b",
+			matchCount: 1,
+		},
+		{
+			diff:       "--- a.go\n+++ a.go\n@@ -1,1 +1,1 @@\n-a\n+b\n@@ -9,1 +9,1 @@\n-c\n+d",
+			matchCount: 2,
+		},
+		{
+			// A diff without hunk headers still counts as a match.
+			diff:       "",
+			matchCount: 1,
+		},
+	}
+
+	for _, c := range cases {
+		got := toDiffFileMatch(comby.FileDiff{URI: "a.go", Diff: c.diff})
+		want := protocol.FileMatch{Path: "a.go", Diff: c.diff, MatchCount: c.matchCount}
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Errorf("unexpected file match (-want +got):\n%s", diff)
+		}
+	}
+}
+
+func TestNativeStructuralSearch(t *testing.T) {
+	files := []structuralFile{
+		{Name: "a.go", Content: []byte("func foo(success) {} func bar(fail) {}")},
+		{Name: "b.go", Content: []byte("package b")},
+	}
+
+	ctx, cancel, sender := newLimitedStreamCollector(context.Background(), 1000000000)
+	defer cancel()
+	err := nativeStructuralSearch(ctx, files, ".go", "func :[[fn]](fail)", nil, "repo", sender)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := sender.collected
+
+	want := []protocol.FileMatch{
+		{
+			Path: "a.go",
+			LineMatches: []protocol.LineMatch{
+				{
+					LineNumber:       0,
+					OffsetAndLengths: [][2]int{{21, 14}},
+					Preview:          "func bar(fail)",
+				},
+			},
+			MatchRanges: []protocol.Range{
+				{
+					Start: protocol.Location{Offset: 21, Line: 0, Column: 21},
+					End:   protocol.Location{Offset: 35, Line: 0, Column: 35},
+				},
+			},
+			MatchCount: 1,
+		},
+	}
+
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Fatalf("unexpected file matches (-want +got):\n%s", diff)
+	}
+}
+
+func TestUseNativeStructuralSearch(t *testing.T) {
+	defer func(engine string) { structuralSearchEngine = engine }(structuralSearchEngine)
+
+	structuralSearchEngine = "native"
+	if !useNativeStructuralSearch("", "") {
+		t.Error("expected native structural search")
+	}
+	if useNativeStructuralSearch(`where :[x] == "y"`, "") {
+		t.Error("expected comby for rules")
+	}
+	if useNativeStructuralSearch("", ":[x]") {
+		t.Error("expected comby for rewrites")
+	}
+
+	structuralSearchEngine = "comby"
+	if useNativeStructuralSearch("", "") {
+		t.Error("expected comby structural search")
+	}
+}
+
 func TestStructuralLimits(t *testing.T) {
 	// If we are not on CI skip the test.
 	if os.Getenv("CI") == "" {
@@ -456,7 +592,7 @@ func bar() {
 		return func(t *testing.T) {
 			ctx, cancel, sender := newLimitedStreamCollector(context.Background(), limit)
 			defer cancel()
-			err := structuralSearch(ctx, zf, Subset(p.IncludePatterns), "", p.Pattern, p.CombyRule, p.Languages, "repo_foo", sender)
+			err := structuralSearch(ctx, zf, Subset(p.IncludePatterns), "", p.Pattern, p.CombyRule, "", p.Languages, "repo_foo", sender)
 			require.NoError(t, err)
 
 			require.Equal(t, wantCount, count(sender.collected))
@@ -599,7 +735,7 @@ func bar() {
 	t.Run("Strutural search match count", func(t *testing.T) {
 		ctx, cancel, sender := newLimitedStreamCollector(context.Background(), 1000000000)
 		defer cancel()
-		err := structuralSearch(ctx, zf, Subset(p.IncludePatterns), "", p.Pattern, p.CombyRule, p.Languages, "repo_foo", sender)
+		err := structuralSearch(ctx, zf, Subset(p.IncludePatterns), "", p.Pattern, p.CombyRule, "", p.Languages, "repo_foo", sender)
 		if err != nil {
 			t.Fatal(err)
 		}
@@ -412,6 +412,7 @@ func doSearch(u string, p *protocol.Request) ([]protocol.FileMatch, error) {
 		"IncludePatterns": p.IncludePatterns,
 		"ExcludePattern":  []string{p.ExcludePattern},
 		"CombyRule":       []string{p.CombyRule},
+		"CombyRewrite":    []string{p.CombyRewrite},
 	}
 	if p.IsRegExp {
 		form.Set("IsRegExp", "true")
@@ -38,19 +38,24 @@ func (m *limitedStreamCollector) Send(match protocol.FileMatch) {
 	m.limitHit = true
 	m.cancel()
 
-	// Can't truncate a path match
+	// Can't truncate a path match or a rewrite diff
 	if len(match.LineMatches) == 0 {
 		m.mux.Unlock()
 		return
 	}
 
-	// NOTE: this isn't strictly correct for structural search matches
-	// since a single match can be multiple lines. However, by the time we
-	// convert a structural search to a protocol.FileMatch, we lose the
-	// information required to properly limit. However, multiline matches
-	// are also not limited correctly in the frontend, so doing it correctly
-	// here won't fix that.
-	match.LineMatches = match.LineMatches[:m.remaining]
+	if len(match.MatchRanges) > 0 && len(match.MatchRanges) == match.MatchCount {
+		// Matches are counted once, even if they span multiple lines, like
+		// for structural search. Keep the line matches of every line of the
+		// kept matches.
+		match.MatchRanges = match.MatchRanges[:m.remaining]
+		match.LineMatches = truncateLineMatches(match.LineMatches, match.MatchRanges)
+	} else {
+		// Line matches are counted, like for regexp search. Keep the ranges
+		// whose lines all have been kept.
+		match.LineMatches = match.LineMatches[:m.remaining]
+		match.MatchRanges = truncateMatchRanges(match.MatchRanges, match.LineMatches)
+	}
 	match.LimitHit = true
 	match.MatchCount = m.remaining
 	m.sentCount += m.remaining
@@ -59,6 +64,43 @@ func (m *limitedStreamCollector) Send(match protocol.FileMatch) {
 	m.mux.Unlock()
 }
 
+// truncateLineMatches returns the line matches of the matches in ranges,
+// assuming lineMatches holds a LineMatch for every line of every match, in
+// the same order as ranges.
+func truncateLineMatches(lineMatches []protocol.LineMatch, ranges []protocol.Range) []protocol.LineMatch {
+	n := matchLineCount(ranges)
+	if n > len(lineMatches) {
+		n = len(lineMatches)
+	}
+	return lineMatches[:n]
+}
+
+// matchLineCount returns the number of lines of the matches in ranges.
+func matchLineCount(ranges []protocol.Range) int {
+	n := 0
+	for _, r := range ranges {
+		n += r.End.Line - r.Start.Line + 1
+		if r.End.Column == 0 && r.End.Line > r.Start.Line {
+			// The match ends with a newline, which belongs to the line
+			// before.
+			n--
+		}
+	}
+	return n
+}
+
+// truncateMatchRanges returns the ranges of the matches whose lines all are
+// in lineMatches, assuming lineMatches holds a LineMatch for every line of
+// every match, in the same order as ranges.
+func truncateMatchRanges(ranges []protocol.Range, lineMatches []protocol.LineMatch) []protocol.Range {
+	for i := range ranges {
+		if len(truncateLineMatches(lineMatches, ranges[:i+1])) < matchLineCount(ranges[:i+1]) {
+			return ranges[:i]
+		}
+	}
+	return ranges
+}
+
 func (m *limitedStreamCollector) SentCount() int {
 	m.mux.Lock()
 	defer m.mux.Unlock()
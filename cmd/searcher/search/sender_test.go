@@ -0,0 +1,81 @@
+package search
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/sourcegraph/sourcegraph/cmd/searcher/protocol"
+)
+
+func TestLimitedStreamCollectorMultilineMatches(t *testing.T) {
+	lineMatch := func(line int) protocol.LineMatch {
+		return protocol.LineMatch{LineNumber: line, OffsetAndLengths: [][2]int{{0, 1}}}
+	}
+	matchRange := func(startLine, endLine, endColumn int) protocol.Range {
+		return protocol.Range{
+			Start: protocol.Location{Line: startLine},
+			End:   protocol.Location{Line: endLine, Column: endColumn},
+		}
+	}
+
+	_, cancel, sender := newLimitedStreamCollector(context.Background(), 2)
+	defer cancel()
+	sender.Send(protocol.FileMatch{
+		Path: "a.go",
+		// A match on lines 0-1, a match ending with the newline of line 3
+		// and a match on line 5.
+		LineMatches: []protocol.LineMatch{lineMatch(0), lineMatch(1), lineMatch(3), lineMatch(5)},
+		MatchRanges: []protocol.Range{matchRange(0, 1, 3), matchRange(3, 4, 0), matchRange(5, 5, 1)},
+		MatchCount:  3,
+	})
+
+	want := []protocol.FileMatch{{
+		Path:        "a.go",
+		LineMatches: []protocol.LineMatch{lineMatch(0), lineMatch(1), lineMatch(3)},
+		MatchRanges: []protocol.Range{matchRange(0, 1, 3), matchRange(3, 4, 0)},
+		MatchCount:  2,
+		LimitHit:    true,
+	}}
+	if diff := cmp.Diff(want, sender.Collected()); diff != "" {
+		t.Errorf("unexpected collected matches (-want +got):\n%s", diff)
+	}
+	if !sender.LimitHit() {
+		t.Error("expected limit hit")
+	}
+}
+
+func TestLimitedStreamCollectorLineMatchCount(t *testing.T) {
+	lineMatch := func(line int) protocol.LineMatch {
+		return protocol.LineMatch{LineNumber: line, OffsetAndLengths: [][2]int{{0, 1}}}
+	}
+	matchRange := func(startLine, endLine, endColumn int) protocol.Range {
+		return protocol.Range{
+			Start: protocol.Location{Line: startLine},
+			End:   protocol.Location{Line: endLine, Column: endColumn},
+		}
+	}
+
+	_, cancel, sender := newLimitedStreamCollector(context.Background(), 2)
+	defer cancel()
+	// Regexp search counts line matches, so the match on lines 1-2 is cut
+	// in half by the limit and its range is dropped.
+	sender.Send(protocol.FileMatch{
+		Path:        "a.go",
+		LineMatches: []protocol.LineMatch{lineMatch(0), lineMatch(1), lineMatch(2), lineMatch(3)},
+		MatchRanges: []protocol.Range{matchRange(0, 0, 1), matchRange(1, 2, 3), matchRange(3, 3, 1)},
+		MatchCount:  4,
+	})
+
+	want := []protocol.FileMatch{{
+		Path:        "a.go",
+		LineMatches: []protocol.LineMatch{lineMatch(0), lineMatch(1)},
+		MatchRanges: []protocol.Range{matchRange(0, 0, 1)},
+		MatchCount:  2,
+		LimitHit:    true,
+	}}
+	if diff := cmp.Diff(want, sender.Collected()); diff != "" {
+		t.Errorf("unexpected collected matches (-want +got):\n%s", diff)
+	}
+}
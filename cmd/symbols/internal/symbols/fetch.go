@@ -20,7 +20,9 @@ type parseRequest struct {
 	data []byte
 }
 
-func (s *Service) fetchRepositoryArchive(ctx context.Context, repo api.RepoName, commitID api.CommitID) (<-chan parseRequest, <-chan error, error) {
+// fetchRepositoryArchive streams the files of repo@commitID to the returned
+// channel. If paths is non-empty, only those paths are fetched.
+func (s *Service) fetchRepositoryArchive(ctx context.Context, repo api.RepoName, commitID api.CommitID, paths []string) (<-chan parseRequest, <-chan error, error) {
 	fetchQueueSize.Inc()
 	s.fetchSem <- 1 // acquire concurrent fetches semaphore
 	fetchQueueSize.Dec()
@@ -30,6 +32,7 @@ func (s *Service) fetchRepositoryArchive(ctx context.Context, repo api.RepoName,
 	ext.Component.Set(span, "store")
 	span.SetTag("repo", repo)
 	span.SetTag("commit", commitID)
+	span.SetTag("paths", len(paths))
 
 	requestCh := make(chan parseRequest, s.NumParserProcesses)
 	errCh := make(chan error, 1)
@@ -60,7 +63,13 @@ func (s *Service) fetchRepositoryArchive(ctx context.Context, repo api.RepoName,
 		span.Finish()
 	}
 
-	r, err := s.FetchTar(ctx, repo, commitID)
+	var r io.ReadCloser
+	var err error
+	if len(paths) > 0 {
+		r, err = s.FetchTarPaths(ctx, repo, commitID, paths)
+	} else {
+		r, err = s.FetchTar(ctx, repo, commitID)
+	}
 	if err != nil {
 		return nil, nil, err
 	}
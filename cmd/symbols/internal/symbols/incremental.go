@@ -0,0 +1,243 @@
+package symbols
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+
+	"github.com/cockroachdb/errors"
+	"github.com/hashicorp/go-multierror"
+	"github.com/inconshreveable/log15"
+	"github.com/jmoiron/sqlx"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+
+	"github.com/sourcegraph/sourcegraph/internal/api"
+	"github.com/sourcegraph/sourcegraph/internal/diskcache"
+	"github.com/sourcegraph/sourcegraph/internal/search/result"
+)
+
+const (
+	// maxAncestorDistance is the number of first-parent ancestors of a commit
+	// that are checked for a cached database to derive from.
+	maxAncestorDistance = 100
+
+	// maxIncrementalChanges is the number of changed paths above which a
+	// database is rebuilt from scratch instead of derived from an ancestor.
+	maxIncrementalChanges = 1000
+)
+
+// Changes are the paths that differ between two commits.
+type Changes struct {
+	Added    []string
+	Modified []string
+	Deleted  []string
+}
+
+// ParseGitDiffNameStatus parses the output of
+// `git diff -z --name-status --no-renames`.
+func ParseGitDiffNameStatus(output []byte) (Changes, error) {
+	var changes Changes
+
+	output = bytes.TrimRight(output, "\x00")
+	if len(output) == 0 {
+		return changes, nil
+	}
+
+	fields := bytes.Split(output, []byte{0})
+	if len(fields)%2 != 0 {
+		return Changes{}, errors.Errorf("unbalanced git diff --name-status output %q", output)
+	}
+
+	for i := 0; i < len(fields); i += 2 {
+		status, path := string(fields[i]), string(fields[i+1])
+		if status == "" {
+			return Changes{}, errors.Errorf("missing git diff status for path %q", path)
+		}
+
+		switch status[0] {
+		case 'A':
+			changes.Added = append(changes.Added, path)
+		case 'M', 'T':
+			changes.Modified = append(changes.Modified, path)
+		case 'D':
+			changes.Deleted = append(changes.Deleted, path)
+		default:
+			return Changes{}, errors.Errorf("unrecognized git diff status %q for path %q", status, path)
+		}
+	}
+
+	return changes, nil
+}
+
+// writeSymbolsToNewDB writes the symbols of repo@commitID to the blank
+// database file dbFile. The database is derived from the database of the
+// nearest cached ancestor when possible, otherwise every file is parsed.
+func (s *Service) writeSymbolsToNewDB(ctx context.Context, dbFile string, repoName api.RepoName, commitID api.CommitID) error {
+	if s.GitDiff != nil && s.ListAncestors != nil && s.FetchTarPaths != nil {
+		ok, err := s.writeSymbolsIncrementally(ctx, dbFile, repoName, commitID)
+		if err == nil && ok {
+			incrementalBuilds.Inc()
+			return nil
+		}
+		if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+			// A full rebuild would fail the same way.
+			return err
+		}
+		if err != nil {
+			log15.Warn("Failed to index symbols incrementally, falling back to a full rebuild.", "repo", repoName, "commitID", commitID, "error", err)
+			incrementalFailures.Inc()
+		}
+
+		// Discard anything the incremental attempt wrote to dbFile.
+		if err := removeDBFile(dbFile); err != nil {
+			return err
+		}
+	}
+
+	return s.writeAllSymbolsToNewDB(ctx, dbFile, repoName, commitID)
+}
+
+// writeSymbolsIncrementally copies the database of the nearest cached ancestor
+// of commitID to dbFile and re-parses only the paths that changed since that
+// ancestor. It returns false if there is no suitable ancestor. The update is
+// rolled back on errors, but the copy is left in dbFile for the caller to
+// discard.
+func (s *Service) writeSymbolsIncrementally(ctx context.Context, dbFile string, repoName api.RepoName, commitID api.CommitID) (_ bool, err error) {
+	ancestor, ancestorDB, err := s.findCachedAncestor(ctx, repoName, commitID)
+	if err != nil || ancestorDB == nil {
+		return false, err
+	}
+	defer ancestorDB.Close()
+
+	changes, err := s.GitDiff(ctx, repoName, ancestor, commitID)
+	if err != nil {
+		return false, errors.Wrap(err, "GitDiff")
+	}
+
+	changed := make([]string, 0, len(changes.Added)+len(changes.Modified))
+	changed = append(changed, changes.Added...)
+	changed = append(changed, changes.Modified...)
+	if len(changed)+len(changes.Deleted) > maxIncrementalChanges {
+		return false, nil
+	}
+
+	if err := copyDBFile(dbFile, ancestorDB.File); err != nil {
+		return false, err
+	}
+
+	db, err := sqlx.Open("sqlite3_with_pcre", dbFile)
+	if err != nil {
+		return false, err
+	}
+	defer db.Close()
+
+	tx, err := db.Beginx()
+	if err != nil {
+		return false, err
+	}
+	defer func() {
+		if err != nil {
+			if err2 := tx.Rollback(); err2 != nil {
+				err = multierror.Append(err, err2)
+			}
+		}
+	}()
+
+	// Added paths normally have no rows yet, but deleting them too keeps
+	// the database consistent if the ancestor was indexed differently.
+	for _, paths := range [][]string{changed, changes.Deleted} {
+		for _, path := range paths {
+			if _, err := tx.Exec(`DELETE FROM symbols WHERE path = ?`, path); err != nil {
+				return false, err
+			}
+		}
+	}
+
+	if len(changed) > 0 {
+		insertStatement, err := prepareInsertSymbol(tx)
+		if err != nil {
+			return false, err
+		}
+		defer insertStatement.Close()
+
+		err = s.parseUncached(ctx, repoName, commitID, changed, func(symbol result.Symbol) error {
+			symbolInDBValue := symbolToSymbolInDB(symbol)
+			_, err := insertStatement.Exec(&symbolInDBValue)
+			return err
+		})
+		if err != nil {
+			return false, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// findCachedAncestor returns the nearest first-parent ancestor of commitID
+// whose database is in the disk cache, along with the opened database file.
+// The file is nil if no ancestor is cached.
+func (s *Service) findCachedAncestor(ctx context.Context, repoName api.RepoName, commitID api.CommitID) (api.CommitID, *diskcache.File, error) {
+	ancestors, err := s.ListAncestors(ctx, repoName, commitID, maxAncestorDistance)
+	if err != nil {
+		return "", nil, errors.Wrap(err, "ListAncestors")
+	}
+
+	for _, ancestor := range ancestors {
+		if ancestor == commitID {
+			continue
+		}
+
+		f, err := s.cache.Peek(symbolsDBCacheKey(repoName, ancestor))
+		if err == nil {
+			return ancestor, f, nil
+		}
+		if !os.IsNotExist(err) {
+			return "", nil, err
+		}
+	}
+
+	return "", nil, nil
+}
+
+// copyDBFile overwrites the file at path with the contents of src.
+func copyDBFile(path string, src io.Reader) error {
+	dst, err := os.OpenFile(path, os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.Copy(dst, src); err != nil {
+		dst.Close()
+		return err
+	}
+	return dst.Close()
+}
+
+// removeDBFile removes the database file at path along with any journal files
+// sqlite3 left next to it. Otherwise a hot journal of an aborted write would be
+// applied to the next database written to path.
+func removeDBFile(path string) error {
+	for _, suffix := range []string{"", "-journal", "-wal", "-shm"} {
+		if err := os.Remove(path + suffix); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+var (
+	incrementalBuilds = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "symbols_incremental_builds",
+		Help: "The total number of databases derived from the database of an ancestor commit.",
+	})
+	incrementalFailures = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "symbols_incremental_failures",
+		Help: "The total number of incremental builds that failed and fell back to a full rebuild.",
+	})
+)
@@ -0,0 +1,210 @@
+package symbols
+
+import (
+	"context"
+	"io"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/cockroachdb/errors"
+	"github.com/sourcegraph/go-ctags"
+
+	"github.com/sourcegraph/sourcegraph/cmd/symbols/internal/sqliteutil"
+	"github.com/sourcegraph/sourcegraph/internal/api"
+	"github.com/sourcegraph/sourcegraph/internal/search"
+	"github.com/sourcegraph/sourcegraph/internal/search/result"
+	symbolsclient "github.com/sourcegraph/sourcegraph/internal/symbols"
+)
+
+func TestParseGitDiffNameStatus(t *testing.T) {
+	tests := map[string]struct {
+		output  string
+		want    Changes
+		wantErr bool
+	}{
+		"empty": {
+			output: "",
+			want:   Changes{},
+		},
+		"all statuses": {
+			output: "A\x00new.go\x00M\x00changed.go\x00T\x00link\x00D\x00old.go\x00",
+			want: Changes{
+				Added:    []string{"new.go"},
+				Modified: []string{"changed.go", "link"},
+				Deleted:  []string{"old.go"},
+			},
+		},
+		"whitespace in path": {
+			output: "M\x00a dir/b\tc.go\x00",
+			want:   Changes{Modified: []string{"a dir/b\tc.go"}},
+		},
+		"unbalanced": {
+			output:  "M\x00a.go\x00D\x00",
+			wantErr: true,
+		},
+		"rename": {
+			output:  "R100\x00a.go\x00b.go\x00",
+			wantErr: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, err := ParseGitDiffNameStatus([]byte(test.output))
+			if test.wantErr {
+				if err == nil {
+					t.Fatal("expected error but got none")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("got %+v, want %+v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestServiceIncremental(t *testing.T) {
+	sqliteutil.MustRegisterSqlite3WithPcre()
+
+	tests := map[string]struct {
+		// fetchTarPathsErr is returned when fetching the changed paths.
+		fetchTarPathsErr error
+		wantErr          bool
+		wantFullFetches  []api.CommitID
+	}{
+		"incremental": {
+			wantFullFetches: []api.CommitID{"a"},
+		},
+		// b is rebuilt from scratch.
+		"fallback": {
+			fetchTarPathsErr: errors.New("gitserver unavailable"),
+			wantFullFetches:  []api.CommitID{"a", "b"},
+		},
+		// A rebuild would fail the same way, so b is not rebuilt.
+		"canceled": {
+			fetchTarPathsErr: context.Canceled,
+			wantErr:          true,
+			wantFullFetches:  []api.CommitID{"a"},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			tmpDir, err := os.MkdirTemp("", "")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer func() { os.RemoveAll(tmpDir) }()
+
+			commits := map[api.CommitID]map[string]string{
+				"a": {"a.js": "x", "b.js": "y"},
+				"b": {"a.js": "z", "c.js": "w"},
+			}
+
+			var fullFetches []api.CommitID
+			service := Service{
+				FetchTar: func(ctx context.Context, repo api.RepoName, commit api.CommitID) (io.ReadCloser, error) {
+					fullFetches = append(fullFetches, commit)
+					return createTar(commits[commit])
+				},
+				FetchTarPaths: func(ctx context.Context, repo api.RepoName, commit api.CommitID, paths []string) (io.ReadCloser, error) {
+					if test.fetchTarPathsErr != nil {
+						return nil, test.fetchTarPathsErr
+					}
+					files := map[string]string{}
+					for _, path := range paths {
+						files[path] = commits[commit][path]
+					}
+					return createTar(files)
+				},
+				GitDiff: func(ctx context.Context, repo api.RepoName, commitA, commitB api.CommitID) (Changes, error) {
+					return Changes{Added: []string{"c.js"}, Modified: []string{"a.js"}, Deleted: []string{"b.js"}}, nil
+				},
+				ListAncestors: func(ctx context.Context, repo api.RepoName, commit api.CommitID, n int) ([]api.CommitID, error) {
+					if commit == "b" {
+						return []api.CommitID{"a"}, nil
+					}
+					return nil, nil
+				},
+				NewParser: func() (ctags.Parser, error) {
+					return contentParser{}, nil
+				},
+				Path: tmpDir,
+			}
+
+			if err := service.Start(); err != nil {
+				t.Fatal(err)
+			}
+			server := httptest.NewServer(service.Handler())
+			defer server.Close()
+			client := symbolsclient.Client{URL: server.URL}
+
+			searchCommit := func(commit api.CommitID) ([]result.Symbol, error) {
+				res, err := client.Search(context.Background(), search.SymbolsParameters{Repo: "r", CommitID: commit, First: 10})
+				if err != nil {
+					return nil, err
+				}
+				symbols := []result.Symbol(*res)
+				sort.Slice(symbols, func(i, j int) bool { return symbols[i].Name < symbols[j].Name })
+				return symbols, nil
+			}
+
+			got, err := searchCommit("a")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if want := []result.Symbol{{Name: "x", Path: "a.js"}, {Name: "y", Path: "b.js"}}; !reflect.DeepEqual(got, want) {
+				t.Errorf("got %+v, want %+v", got, want)
+			}
+
+			got, err = searchCommit("b")
+			if test.wantErr {
+				if err == nil {
+					t.Error("expected error but got none")
+				}
+			} else if err != nil {
+				t.Fatal(err)
+			} else if want := []result.Symbol{{Name: "w", Path: "c.js"}, {Name: "z", Path: "a.js"}}; !reflect.DeepEqual(got, want) {
+				t.Errorf("got %+v, want %+v", got, want)
+			}
+
+			if !reflect.DeepEqual(fullFetches, test.wantFullFetches) {
+				t.Errorf("expected %v to be fetched in full, got %v", test.wantFullFetches, fullFetches)
+			}
+
+			// Neither a failed incremental build nor a rebuild may leave
+			// sqlite3 journals or partially written databases behind.
+			err = filepath.Walk(tmpDir, func(path string, info os.FileInfo, err error) error {
+				if err != nil {
+					return err
+				}
+				if strings.HasSuffix(path, "-journal") || strings.HasSuffix(path, ".part") {
+					t.Errorf("unexpected leftover file %s", path)
+				}
+				return nil
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
+
+// contentParser returns a single symbol per file, named after the file's
+// contents.
+type contentParser struct{}
+
+func (contentParser) Parse(name string, content []byte) ([]*ctags.Entry, error) {
+	return []*ctags.Entry{{Name: string(content), Path: name}}, nil
+}
+
+func (contentParser) Close() {}
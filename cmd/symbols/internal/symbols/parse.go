@@ -39,7 +39,9 @@ func (s *Service) startParsers() error {
 	return nil
 }
 
-func (s *Service) parseUncached(ctx context.Context, repo api.RepoName, commitID api.CommitID, callback func(symbol result.Symbol) error) (err error) {
+// parseUncached parses the symbols of repo@commitID and calls callback for
+// each of them. If paths is non-empty, only those paths are parsed.
+func (s *Service) parseUncached(ctx context.Context, repo api.RepoName, commitID api.CommitID, paths []string, callback func(symbol result.Symbol) error) (err error) {
 	span, ctx := ot.StartSpanFromContext(ctx, "parseUncached")
 	defer func() {
 		if err != nil {
@@ -65,7 +67,7 @@ func (s *Service) parseUncached(ctx context.Context, repo api.RepoName, commitID
 	}()
 
 	tr.LazyPrintf("fetch")
-	parseRequests, errChan, err := s.fetchRepositoryArchive(ctx, repo, commitID)
+	parseRequests, errChan, err := s.fetchRepositoryArchive(ctx, repo, commitID, paths)
 	tr.LazyPrintf("fetch (returned chans)")
 	if err != nil {
 		return err
@@ -98,19 +100,23 @@ func (s *Service) parseUncached(ctx context.Context, repo api.RepoName, commitID
 				wg.Done()
 				<-sem
 			}()
-			entries, parseErr := s.parse(ctx, req)
+			entries, endLines, parseErr := s.parse(ctx, req)
 			if parseErr != nil && parseErr != context.Canceled && parseErr != context.DeadlineExceeded {
 				log15.Error("Error parsing symbols.", "repo", repo, "commitID", commitID, "path", req.path, "dataSize", len(req.data), "error", parseErr)
 			}
 			if len(entries) > 0 {
 				mu.Lock()
 				defer mu.Unlock()
-				for _, e := range entries {
+				for i, e := range entries {
 					if e.Name == "" || strings.HasPrefix(e.Name, "__anon") || strings.HasPrefix(e.Parent, "__anon") || strings.HasPrefix(e.Name, "AnonymousFunction") || strings.HasPrefix(e.Parent, "AnonymousFunction") {
 						continue
 					}
+					endLine := 0
+					if i < len(endLines) {
+						endLine = endLines[i]
+					}
 					totalSymbols++
-					err = callback(entryToSymbol(e))
+					err = callback(entryToSymbol(e, endLine))
 					if err != nil {
 						log15.Error("Failed to add symbol", "symbol", e, "error", err)
 						return
@@ -126,7 +132,9 @@ func (s *Service) parseUncached(ctx context.Context, repo api.RepoName, commitID
 }
 
 // parse gets a parser from the pool and uses it to satisfy the parse request.
-func (s *Service) parse(ctx context.Context, req parseRequest) (entries []*ctags.Entry, err error) {
+// endLines holds the last line of each entry if the parser knows them, see
+// endLineParser.
+func (s *Service) parse(ctx context.Context, req parseRequest) (entries []*ctags.Entry, endLines []int, err error) {
 	parseQueueSize.Inc()
 
 	select {
@@ -135,12 +143,12 @@ func (s *Service) parse(ctx context.Context, req parseRequest) (entries []*ctags
 		if ctx.Err() == context.DeadlineExceeded {
 			parseQueueTimeouts.Inc()
 		}
-		return nil, ctx.Err()
+		return nil, nil, ctx.Err()
 	case parser, ok := <-s.parsers:
 		parseQueueSize.Dec()
 
 		if !ok {
-			return nil, nil
+			return nil, nil, nil
 		}
 
 		if parser == nil {
@@ -149,7 +157,7 @@ func (s *Service) parse(ctx context.Context, req parseRequest) (entries []*ctags
 			var err error
 			parser, err = s.NewParser()
 			if err != nil {
-				return nil, err
+				return nil, nil, err
 			}
 		}
 
@@ -173,11 +181,11 @@ func (s *Service) parse(ctx context.Context, req parseRequest) (entries []*ctags
 		}()
 		parsing.Inc()
 		defer parsing.Dec()
-		return parser.Parse(req.path, req.data)
+		return parseEndLines(parser, req.path, req.data)
 	}
 }
 
-func entryToSymbol(e *ctags.Entry) result.Symbol {
+func entryToSymbol(e *ctags.Entry, endLine int) result.Symbol {
 	return result.Symbol{
 		Name:        e.Name,
 		Path:        e.Path,
@@ -188,10 +196,30 @@ func entryToSymbol(e *ctags.Entry) result.Symbol {
 		ParentKind:  e.ParentKind,
 		Signature:   e.Signature,
 		Pattern:     e.Pattern,
+		EndLine:     endLine,
 		FileLimited: e.FileLimited,
 	}
 }
 
+// endLineParser is implemented by parsers that know where the symbols they
+// extract end. ctags.Entry has no room for that, because universal-ctags only
+// records where symbols start.
+type endLineParser interface {
+	// ParseEndLines is like Parse, but also returns the last line of each
+	// entry.
+	ParseEndLines(name string, content []byte) (entries []*ctags.Entry, endLines []int, err error)
+}
+
+// parseEndLines parses content with parser. endLines holds the last line of
+// each entry if parser is an endLineParser, and is nil otherwise.
+func parseEndLines(parser ctags.Parser, name string, content []byte) (entries []*ctags.Entry, endLines []int, err error) {
+	if p, ok := parser.(endLineParser); ok {
+		return p.ParseEndLines(name, content)
+	}
+	entries, err = parser.Parse(name, content)
+	return entries, nil, err
+}
+
 var (
 	parsing = promauto.NewGauge(prometheus.GaugeOpts{
 		Name: "symbols_parse_parsing",
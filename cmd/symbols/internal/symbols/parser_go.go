@@ -0,0 +1,221 @@
+package symbols
+
+import (
+	"bytes"
+	"go/ast"
+	"go/parser"
+	"go/printer"
+	"go/token"
+	"strconv"
+	"strings"
+
+	"github.com/cockroachdb/errors"
+	"github.com/sourcegraph/go-ctags"
+)
+
+// goParser extracts symbols from Go files with go/parser, in process. Unlike
+// ctags it reports method receivers, struct fields and interface methods with
+// their enclosing type, and full function signatures. It uses the same kinds
+// as universal-ctags, so a method is a func whose parent is its receiver.
+type goParser struct {
+	patternLengthLimit int
+}
+
+func newGoParser() (ctags.Parser, error) {
+	patternLengthLimit, err := strconv.Atoi(rawPatternLengthLimit)
+	if err != nil {
+		return nil, errors.Errorf("invalid pattern length limit: %s", rawPatternLengthLimit)
+	}
+	return &goParser{patternLengthLimit: patternLengthLimit}, nil
+}
+
+func (p *goParser) Parse(name string, content []byte) ([]*ctags.Entry, error) {
+	entries, _, err := p.ParseEndLines(name, content)
+	return entries, err
+}
+
+// ParseEndLines implements endLineParser. The end line of a symbol is the last
+// line of its declaration, such as the closing brace of a function body.
+func (p *goParser) ParseEndLines(name string, content []byte) ([]*ctags.Entry, []int, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, name, content, 0)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	lines := bytes.Split(content, []byte("\n"))
+	var (
+		entries  []*ctags.Entry
+		endLines []int
+	)
+	add := func(ident *ast.Ident, node ast.Node, kind, parent, parentKind, signature string) {
+		if ident == nil || ident.Name == "_" {
+			return
+		}
+		line := fset.Position(ident.Pos()).Line
+		endLines = append(endLines, fset.Position(node.End()).Line)
+		entries = append(entries, &ctags.Entry{
+			Name:       ident.Name,
+			Path:       name,
+			Line:       line,
+			Kind:       kind,
+			Language:   "Go",
+			Parent:     parent,
+			ParentKind: parentKind,
+			Signature:  signature,
+			Pattern:    p.pattern(lines, line),
+		})
+	}
+
+	add(file.Name, file, "package", "", "", "")
+
+	// Methods may be declared before their receiver type, so collect the
+	// kinds of all types declared in the file first.
+	typeKinds := map[string]string{}
+	for _, decl := range file.Decls {
+		if d, ok := decl.(*ast.GenDecl); ok && d.Tok == token.TYPE {
+			for _, spec := range d.Specs {
+				s := spec.(*ast.TypeSpec)
+				typeKinds[s.Name.Name] = goTypeKind(s.Type)
+			}
+		}
+	}
+
+	for _, decl := range file.Decls {
+		switch d := decl.(type) {
+		case *ast.FuncDecl:
+			signature := goSignature(fset, d.Type)
+			if d.Recv == nil || len(d.Recv.List) == 0 {
+				add(d.Name, d, "func", "", "", signature)
+				continue
+			}
+			receiver := goReceiverTypeName(d.Recv.List[0].Type)
+			receiverKind, ok := typeKinds[receiver]
+			if !ok {
+				receiverKind = "type"
+			}
+			add(d.Name, d, "func", receiver, receiverKind, signature)
+
+		case *ast.GenDecl:
+			for _, spec := range d.Specs {
+				switch s := spec.(type) {
+				case *ast.TypeSpec:
+					kind := typeKinds[s.Name.Name]
+					add(s.Name, s, kind, "", "", "")
+					switch t := s.Type.(type) {
+					case *ast.StructType:
+						for _, field := range t.Fields.List {
+							if len(field.Names) == 0 {
+								add(goEmbeddedName(field.Type), field, "anonMember", s.Name.Name, kind, "")
+							}
+							for _, fieldName := range field.Names {
+								add(fieldName, field, "member", s.Name.Name, kind, "")
+							}
+						}
+					case *ast.InterfaceType:
+						for _, method := range t.Methods.List {
+							funcType, ok := method.Type.(*ast.FuncType)
+							if !ok {
+								// Embedded interface.
+								continue
+							}
+							for _, methodName := range method.Names {
+								add(methodName, method, "methodSpec", s.Name.Name, kind, goSignature(fset, funcType))
+							}
+						}
+					}
+
+				case *ast.ValueSpec:
+					kind := "var"
+					if d.Tok == token.CONST {
+						kind = "const"
+					}
+					for _, valueName := range s.Names {
+						add(valueName, s, kind, "", "", "")
+					}
+				}
+			}
+		}
+	}
+
+	return entries, endLines, nil
+}
+
+func (p *goParser) Close() {}
+
+// pattern returns a ctags-style search pattern of the form /^...$/ for the
+// given 1-based line.
+func (p *goParser) pattern(lines [][]byte, line int) string {
+	if line < 1 || line > len(lines) {
+		return ""
+	}
+	text := strings.TrimSuffix(string(lines[line-1]), "\r")
+
+	// Like ctags, omit the end anchor of truncated patterns.
+	end := "$/"
+	if p.patternLengthLimit > 0 && len(text) > p.patternLengthLimit {
+		text = text[:p.patternLengthLimit]
+		end = "/"
+	}
+
+	text = strings.NewReplacer(`\`, `\\`, `/`, `\/`).Replace(text)
+	return "/^" + text + end
+}
+
+// goTypeKind returns the symbol kind of a type declaration with the given
+// underlying type expression.
+func goTypeKind(expr ast.Expr) string {
+	switch expr.(type) {
+	case *ast.StructType:
+		return "struct"
+	case *ast.InterfaceType:
+		return "interface"
+	default:
+		return "type"
+	}
+}
+
+// goSignature renders the parameters and results of a function type, as in
+// "(ctx context.Context, id int) (*Repo, error)".
+func goSignature(fset *token.FileSet, funcType *ast.FuncType) string {
+	var buf bytes.Buffer
+	if err := printer.Fprint(&buf, fset, &ast.FuncType{Params: funcType.Params, Results: funcType.Results}); err != nil {
+		return ""
+	}
+	return strings.TrimPrefix(buf.String(), "func")
+}
+
+// goReceiverTypeName returns the name of the type of a method receiver,
+// stripping pointers and type parameters.
+func goReceiverTypeName(expr ast.Expr) string {
+	for {
+		switch t := expr.(type) {
+		case *ast.StarExpr:
+			expr = t.X
+		case *ast.ParenExpr:
+			expr = t.X
+		case *ast.IndexExpr:
+			expr = t.X
+		case *ast.Ident:
+			return t.Name
+		default:
+			return ""
+		}
+	}
+}
+
+// goEmbeddedName returns the identifier naming an embedded struct field.
+func goEmbeddedName(expr ast.Expr) *ast.Ident {
+	for {
+		switch t := expr.(type) {
+		case *ast.StarExpr:
+			expr = t.X
+		case *ast.SelectorExpr:
+			return t.Sel
+		case *ast.Ident:
+			return t
+		default:
+			return nil
+		}
+	}
+}
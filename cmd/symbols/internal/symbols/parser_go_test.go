@@ -0,0 +1,141 @@
+package symbols
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/sourcegraph/go-ctags"
+)
+
+func TestGoParser(t *testing.T) {
+	p, err := newGoParser()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+
+	data := `package store
+
+type Store struct {
+	*Base
+	db string
+}
+
+type Getter interface {
+	Get(id int) (*Store, error)
+}
+
+func (s *Store) Get(id int) (*Store, error) { return s, nil }
+
+func New() *Store { return &Store{} }
+`
+
+	got, err := p.Parse("store/store.go", []byte(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	entry := func(name string, line int, kind, parent, parentKind, signature, pattern string) *ctags.Entry {
+		return &ctags.Entry{
+			Name:       name,
+			Path:       "store/store.go",
+			Line:       line,
+			Kind:       kind,
+			Language:   "Go",
+			Parent:     parent,
+			ParentKind: parentKind,
+			Signature:  signature,
+			Pattern:    pattern,
+		}
+	}
+	want := []*ctags.Entry{
+		entry("store", 1, "package", "", "", "", `/^package store$/`),
+		entry("Store", 3, "struct", "", "", "", `/^type Store struct {$/`),
+		entry("Base", 4, "anonMember", "Store", "struct", "", "/^\t*Base$/"),
+		entry("db", 5, "member", "Store", "struct", "", "/^\tdb string$/"),
+		entry("Getter", 8, "interface", "", "", "", `/^type Getter interface {$/`),
+		entry("Get", 9, "methodSpec", "Getter", "interface", "(id int) (*Store, error)", "/^\tGet(id int) (*Store, error)$/"),
+		entry("Get", 12, "func", "Store", "struct", "(id int) (*Store, error)", `/^func (s *Store) Get(id int) (*Store, error) { return s, nil }$/`),
+		entry("New", 14, "func", "", "", "() *Store", `/^func New() *Store { return &Store{} }$/`),
+	}
+
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("unexpected entries (-want +got):\n%s", diff)
+	}
+}
+
+func TestGoParserEndLines(t *testing.T) {
+	p := &goParser{}
+
+	data := `package app
+
+func main() {
+	run()
+}
+
+var x = 1
+`
+
+	entries, endLines, err := p.ParseEndLines("main.go", []byte(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := map[string]int{}
+	for i, e := range entries {
+		got[e.Name] = endLines[i]
+	}
+	want := map[string]int{"app": 7, "main": 5, "x": 7}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("unexpected end lines (-want +got):\n%s", diff)
+	}
+}
+
+func TestGoParserPattern(t *testing.T) {
+	p := &goParser{patternLengthLimit: 10}
+	lines := [][]byte{[]byte(`a/b\c`), []byte(`0123456789abc`)}
+
+	if got, want := p.pattern(lines, 1), `/^a\/b\\c$/`; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	if got, want := p.pattern(lines, 2), `/^0123456789/`; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestParseLanguageParsers(t *testing.T) {
+	got, err := parseLanguageParsers(" go:go-ast, ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(map[string]string{"Go": "go-ast"}, got); diff != "" {
+		t.Errorf("unexpected language parsers (-want +got):\n%s", diff)
+	}
+
+	for _, raw := range []string{"go", "notalanguage:go-ast", "go:notaparser"} {
+		if _, err := parseLanguageParsers(raw); err == nil {
+			t.Errorf("expected error for %q", raw)
+		}
+	}
+}
+
+func TestLanguageRouter(t *testing.T) {
+	fallback := mockParser{"fallback"}
+	r := &languageRouter{
+		fallback: fallback,
+		parsers:  map[string]ctags.Parser{"Go": mockParser{"routed"}},
+	}
+
+	for path, want := range map[string]string{
+		"a.go": "routed",
+		"a.js": "fallback",
+	} {
+		entries, err := r.Parse(path, []byte("x"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(entries) != 1 || entries[0].Name != want {
+			t.Errorf("%s: got %+v, want a single %q entry", path, entries, want)
+		}
+	}
+}
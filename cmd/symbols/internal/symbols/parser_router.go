@@ -0,0 +1,116 @@
+package symbols
+
+import (
+	"path"
+	"strings"
+
+	"github.com/cockroachdb/errors"
+	"github.com/go-enry/go-enry/v2"
+	"github.com/inconshreveable/log15"
+	"github.com/sourcegraph/go-ctags"
+
+	"github.com/sourcegraph/sourcegraph/internal/env"
+)
+
+var rawLanguageParsers = env.Get("SYMBOLS_LANGUAGE_PARSERS", "", "comma-separated language:parser pairs routing files of a language to an alternative symbol parser (e.g. go:go-ast)")
+
+// parserBackends are the alternative parsers that can be selected for a
+// language in SYMBOLS_LANGUAGE_PARSERS. Each of them returns entries in the
+// same shape as universal-ctags, so their symbols are stored and searched
+// like any other.
+var parserBackends = map[string]func() (ctags.Parser, error){
+	"go-ast": newGoParser,
+}
+
+// parseLanguageParsers parses a comma-separated list of language:parser pairs
+// into a map from canonical language name to parser backend name.
+func parseLanguageParsers(raw string) (map[string]string, error) {
+	languageParsers := map[string]string{}
+	for _, pair := range strings.Split(raw, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+
+		i := strings.LastIndex(pair, ":")
+		if i < 0 {
+			return nil, errors.Errorf("invalid language parser %q, expected language:parser", pair)
+		}
+
+		language, ok := enry.GetLanguageByAlias(strings.TrimSpace(pair[:i]))
+		if !ok {
+			return nil, errors.Errorf("unknown language %q in language parser %q", pair[:i], pair)
+		}
+
+		backend := strings.TrimSpace(pair[i+1:])
+		if _, ok := parserBackends[backend]; !ok {
+			return nil, errors.Errorf("unknown parser %q in language parser %q", backend, pair)
+		}
+
+		languageParsers[language] = backend
+	}
+	return languageParsers, nil
+}
+
+// languageRouter is a ctags.Parser that dispatches each file to the parser
+// configured for its language. Files in other languages, and files the
+// configured parser fails on, are parsed by the fallback parser.
+type languageRouter struct {
+	fallback ctags.Parser
+
+	// parsers maps canonical language names to parsers.
+	parsers map[string]ctags.Parser
+
+	// backends holds each distinct parser once, so they can be closed.
+	backends []ctags.Parser
+}
+
+// newLanguageRouter creates a languageRouter that starts one parser per
+// backend named in languageParsers.
+func newLanguageRouter(fallback ctags.Parser, languageParsers map[string]string) (ctags.Parser, error) {
+	r := &languageRouter{
+		fallback: fallback,
+		parsers:  make(map[string]ctags.Parser, len(languageParsers)),
+	}
+
+	started := map[string]ctags.Parser{}
+	for language, backend := range languageParsers {
+		parser, ok := started[backend]
+		if !ok {
+			var err error
+			parser, err = parserBackends[backend]()
+			if err != nil {
+				r.Close()
+				return nil, errors.Wrapf(err, "starting %s parser", backend)
+			}
+			started[backend] = parser
+			r.backends = append(r.backends, parser)
+		}
+		r.parsers[language] = parser
+	}
+
+	return r, nil
+}
+
+func (r *languageRouter) Parse(name string, content []byte) ([]*ctags.Entry, error) {
+	entries, _, err := r.ParseEndLines(name, content)
+	return entries, err
+}
+
+func (r *languageRouter) ParseEndLines(name string, content []byte) ([]*ctags.Entry, []int, error) {
+	if parser, ok := r.parsers[enry.GetLanguage(path.Base(name), content)]; ok {
+		entries, endLines, err := parseEndLines(parser, name, content)
+		if err == nil {
+			return entries, endLines, nil
+		}
+		log15.Debug("Alternative symbol parser failed, falling back to ctags.", "path", name, "error", err)
+	}
+	return parseEndLines(r.fallback, name, content)
+}
+
+func (r *languageRouter) Close() {
+	for _, parser := range r.backends {
+		parser.Close()
+	}
+	r.fallback.Close()
+}
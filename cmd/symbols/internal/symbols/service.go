@@ -23,6 +23,22 @@ type Service struct {
 	// determine if the error is a bad request (eg invalid repo).
 	FetchTar func(context.Context, api.RepoName, api.CommitID) (io.ReadCloser, error)
 
+	// FetchTarPaths is like FetchTar, but the archive only includes the given
+	// paths. It is used to fetch the changed files of a commit when indexing
+	// incrementally.
+	FetchTarPaths func(context.Context, api.RepoName, api.CommitID, []string) (io.ReadCloser, error)
+
+	// GitDiff returns the paths that changed between two commits of a
+	// repository. Together with ListAncestors and FetchTarPaths it enables
+	// incremental indexing: the database for a new commit is derived from
+	// the database of a cached ancestor, re-parsing only the changed paths.
+	// If any of the three is nil, every commit is indexed from scratch.
+	GitDiff func(ctx context.Context, repo api.RepoName, commitA, commitB api.CommitID) (Changes, error)
+
+	// ListAncestors returns up to n first-parent ancestors of a commit,
+	// nearest first.
+	ListAncestors func(ctx context.Context, repo api.RepoName, commitID api.CommitID, n int) ([]api.CommitID, error)
+
 	// MaxConcurrentFetchTar is the maximum number of concurrent calls allowed
 	// to FetchTar. It defaults to 15.
 	MaxConcurrentFetchTar int
@@ -12,6 +12,7 @@ import (
 	"os/signal"
 	"runtime"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/inconshreveable/log15"
@@ -35,6 +36,7 @@ func main() {
 		cacheDir       = env.Get("CACHE_DIR", "/tmp/symbols-cache", "directory to store cached symbols")
 		cacheSizeMB    = env.Get("SYMBOLS_CACHE_SIZE_MB", "100000", "maximum size of the disk cache in megabytes")
 		ctagsProcesses = env.Get("CTAGS_PROCESSES", strconv.Itoa(runtime.GOMAXPROCS(0)), "number of ctags child processes to run")
+		incremental    = env.Get("SYMBOLS_INCREMENTAL_INDEXING", "true", "derive the symbols of a commit from a cached ancestor commit, re-parsing only changed files")
 	)
 
 	env.Lock()
@@ -58,6 +60,13 @@ func main() {
 		NewParser: symbols.NewParser,
 		Path:      cacheDir,
 	}
+	if enabled, _ := strconv.ParseBool(incremental); enabled {
+		service.FetchTarPaths = func(ctx context.Context, repo api.RepoName, commit api.CommitID, paths []string) (io.ReadCloser, error) {
+			return gitserver.DefaultClient.Archive(ctx, repo, gitserver.ArchiveOptions{Treeish: string(commit), Format: "tar", Paths: paths})
+		}
+		service.GitDiff = gitDiff
+		service.ListAncestors = listAncestors
+	}
 	if mb, err := strconv.ParseInt(cacheSizeMB, 10, 64); err != nil {
 		log.Fatalf("Invalid SYMBOLS_CACHE_SIZE_MB: %s", err)
 	} else {
@@ -93,6 +102,35 @@ func main() {
 	}
 }
 
+// gitDiff returns the paths that changed between commitA and commitB.
+func gitDiff(ctx context.Context, repo api.RepoName, commitA, commitB api.CommitID) (symbols.Changes, error) {
+	cmd := gitserver.DefaultClient.Command("git", "diff", "-z", "--name-status", "--no-renames", string(commitA), string(commitB))
+	cmd.Repo = repo
+	out, err := cmd.Output(ctx)
+	if err != nil {
+		return symbols.Changes{}, err
+	}
+	return symbols.ParseGitDiffNameStatus(out)
+}
+
+// listAncestors returns up to n first-parent ancestors of commit, nearest
+// first.
+func listAncestors(ctx context.Context, repo api.RepoName, commit api.CommitID, n int) ([]api.CommitID, error) {
+	cmd := gitserver.DefaultClient.Command("git", "rev-list", "--first-parent", "--skip=1", "--max-count="+strconv.Itoa(n), string(commit))
+	cmd.Repo = repo
+	out, err := cmd.Output(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	lines := strings.Fields(string(out))
+	ancestors := make([]api.CommitID, 0, len(lines))
+	for _, line := range lines {
+		ancestors = append(ancestors, api.CommitID(line))
+	}
+	return ancestors, nil
+}
+
 func shutdownOnSIGINT(s *http.Server) {
 	c := make(chan os.Signal, 1)
 	signal.Notify(c, os.Interrupt)
@@ -11,6 +11,7 @@ import (
 
 	"github.com/sourcegraph/sourcegraph/internal/authz"
 	"github.com/sourcegraph/sourcegraph/internal/authz/bitbucketserver"
+	"github.com/sourcegraph/sourcegraph/internal/authz/gitea"
 	"github.com/sourcegraph/sourcegraph/internal/authz/github"
 	"github.com/sourcegraph/sourcegraph/internal/authz/gitlab"
 	"github.com/sourcegraph/sourcegraph/internal/authz/perforce"
@@ -52,6 +53,7 @@ func ProvidersFromConfig(
 			extsvc.KindGitHub,
 			extsvc.KindGitLab,
 			extsvc.KindBitbucketServer,
+			extsvc.KindGitea,
 			extsvc.KindPerforce,
 		},
 		LimitOffset: &database.LimitOffset{
@@ -63,6 +65,7 @@ func ProvidersFromConfig(
 		gitHubConns          []*types.GitHubConnection
 		gitLabConns          []*types.GitLabConnection
 		bitbucketServerConns []*types.BitbucketServerConnection
+		giteaConns           []*types.GiteaConnection
 		perforceConns        []*types.PerforceConnection
 	)
 	for {
@@ -99,6 +102,11 @@ func ProvidersFromConfig(
 					URN:                       svc.URN(),
 					BitbucketServerConnection: c,
 				})
+			case *schema.GiteaConnection:
+				giteaConns = append(giteaConns, &types.GiteaConnection{
+					URN:             svc.URN(),
+					GiteaConnection: c,
+				})
 			case *schema.PerforceConnection:
 				perforceConns = append(perforceConns, &types.PerforceConnection{
 					URN:                svc.URN(),
@@ -137,6 +145,13 @@ func ProvidersFromConfig(
 		warnings = append(warnings, bbsWarnings...)
 	}
 
+	if len(giteaConns) > 0 {
+		gtProviders, gtProblems, gtWarnings := gitea.NewAuthzProviders(giteaConns)
+		providers = append(providers, gtProviders...)
+		seriousProblems = append(seriousProblems, gtProblems...)
+		warnings = append(warnings, gtWarnings...)
+	}
+
 	if len(perforceConns) > 0 {
 		pfProviders, pfProblems, pfWarnings := perforce.NewAuthzProviders(perforceConns)
 		providers = append(providers, pfProviders...)
@@ -505,6 +505,7 @@ type fakeStore struct {
 	gitlabs          []*schema.GitLabConnection
 	githubs          []*schema.GitHubConnection
 	bitbucketServers []*schema.BitbucketServerConnection
+	giteas           []*schema.GiteaConnection
 	perforces        []*schema.PerforceConnection
 }
 
@@ -541,6 +542,13 @@ func (s fakeStore) List(ctx context.Context, opt database.ExternalServicesListOp
 					Config: mustMarshalJSONString(bbs),
 				})
 			}
+		case extsvc.KindGitea:
+			for _, g := range s.giteas {
+				svcs = append(svcs, &types.ExternalService{
+					Kind:   kind,
+					Config: mustMarshalJSONString(g),
+				})
+			}
 		case extsvc.KindPerforce:
 			for _, p := range s.perforces {
 				svcs = append(svcs, &types.ExternalService{
@@ -34,7 +34,7 @@ func Routines(ctx context.Context, batchesStore *store.Store, cf *httpcli.Factor
 
 		scheduler.NewScheduler(ctx, batchesStore),
 
-		newBulkOperationWorker(ctx, batchesStore, sourcer, metrics),
+		newBulkOperationWorker(ctx, batchesStore, gitserver.DefaultClient, sourcer, metrics),
 		newBulkOperationWorkerResetter(batchesStore, metrics),
 
 		newBatchSpecExecutionResetter(batchesStore, observationContext, metrics),
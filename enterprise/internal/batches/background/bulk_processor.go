@@ -8,6 +8,7 @@ import (
 	"github.com/inconshreveable/log15"
 
 	"github.com/sourcegraph/sourcegraph/enterprise/internal/batches/global"
+	"github.com/sourcegraph/sourcegraph/enterprise/internal/batches/reconciler"
 	"github.com/sourcegraph/sourcegraph/enterprise/internal/batches/service"
 	"github.com/sourcegraph/sourcegraph/enterprise/internal/batches/sources"
 	"github.com/sourcegraph/sourcegraph/enterprise/internal/batches/state"
@@ -33,8 +34,9 @@ func (e unknownJobTypeErr) NonRetryable() bool {
 }
 
 type bulkProcessor struct {
-	tx      *store.Store
-	sourcer sources.Sourcer
+	tx        *store.Store
+	sourcer   sources.Sourcer
+	gitClient reconciler.GitserverClient
 
 	css  sources.ChangesetSource
 	repo *types.Repo
@@ -83,6 +85,8 @@ func (b *bulkProcessor) process(ctx context.Context, job *btypes.ChangesetJob) (
 		return b.closeChangeset(ctx, job)
 	case btypes.ChangesetJobTypePublish:
 		return b.publishChangeset(ctx, job)
+	case btypes.ChangesetJobTypeRebase:
+		return b.rebaseChangeset(ctx, job)
 
 	default:
 		return &unknownJobTypeErr{jobType: string(job.JobType)}
@@ -245,3 +249,21 @@ func (b *bulkProcessor) publishChangeset(ctx context.Context, job *btypes.Change
 	}
 	return nil
 }
+
+func (b *bulkProcessor) rebaseChangeset(ctx context.Context, job *btypes.ChangesetJob) (err error) {
+	// We can only rebase changesets that we pushed ourselves.
+	if b.ch.CurrentSpecID == 0 || !b.ch.Published() {
+		return errcode.MakeNonRetryable(errors.New("cannot rebase a changeset that hasn't been published by a batch change"))
+	}
+
+	if b.ch.ExternalState != btypes.ChangesetExternalStateOpen && b.ch.ExternalState != btypes.ChangesetExternalStateDraft {
+		return errcode.MakeNonRetryable(errors.New("cannot rebase a changeset that isn't open"))
+	}
+
+	// Changesets that are currently processing should be retried at a later stage.
+	if b.ch.ReconcilerState == btypes.ReconcilerStateProcessing {
+		return errors.New("cannot rebase a changeset that is currently being processed; will retry")
+	}
+
+	return reconciler.RebaseChangeset(ctx, b.gitClient, b.sourcer, b.tx, b.ch)
+}
@@ -4,16 +4,21 @@ import (
 	"context"
 	"testing"
 
+	"github.com/cockroachdb/errors"
+
 	"github.com/sourcegraph/sourcegraph/enterprise/internal/batches/global"
 	"github.com/sourcegraph/sourcegraph/enterprise/internal/batches/sources"
 	"github.com/sourcegraph/sourcegraph/enterprise/internal/batches/store"
 	ct "github.com/sourcegraph/sourcegraph/enterprise/internal/batches/testing"
 	"github.com/sourcegraph/sourcegraph/enterprise/internal/batches/types"
 	btypes "github.com/sourcegraph/sourcegraph/enterprise/internal/batches/types"
+	"github.com/sourcegraph/sourcegraph/internal/api"
 	"github.com/sourcegraph/sourcegraph/internal/database/dbtest"
 	"github.com/sourcegraph/sourcegraph/internal/errcode"
 	"github.com/sourcegraph/sourcegraph/internal/extsvc"
 	"github.com/sourcegraph/sourcegraph/internal/extsvc/github"
+	"github.com/sourcegraph/sourcegraph/internal/extsvc/gitlab"
+	"github.com/sourcegraph/sourcegraph/internal/vcs/git"
 )
 
 func TestBulkProcessor(t *testing.T) {
@@ -344,4 +349,151 @@ func TestBulkProcessor(t *testing.T) {
 			}
 		})
 	})
+
+	t.Run("Rebase job errors", func(t *testing.T) {
+		fake := &sources.FakeChangesetSource{FakeMetadata: &github.PullRequest{}}
+		bp := &bulkProcessor{
+			tx:      bstore,
+			sourcer: sources.NewFakeSourcer(nil, fake),
+		}
+
+		for name, tc := range map[string]struct {
+			changeset     ct.TestChangesetOpts
+			wantRetryable bool
+		}{
+			"unpublished": {
+				changeset: ct.TestChangesetOpts{
+					PublicationState: btypes.ChangesetPublicationStateUnpublished,
+					ReconcilerState:  btypes.ReconcilerStateCompleted,
+				},
+				wantRetryable: false,
+			},
+			"merged": {
+				changeset: ct.TestChangesetOpts{
+					PublicationState: btypes.ChangesetPublicationStatePublished,
+					ExternalState:    btypes.ChangesetExternalStateMerged,
+					ReconcilerState:  btypes.ReconcilerStateCompleted,
+				},
+				wantRetryable: false,
+			},
+			"processing": {
+				changeset: ct.TestChangesetOpts{
+					PublicationState: btypes.ChangesetPublicationStatePublished,
+					ExternalState:    btypes.ChangesetExternalStateOpen,
+					ReconcilerState:  btypes.ReconcilerStateProcessing,
+				},
+				wantRetryable: true,
+			},
+		} {
+			t.Run(name, func(t *testing.T) {
+				tc.changeset.Repo = repo.ID
+				tc.changeset.BatchChange = batchChange.ID
+				tc.changeset.CurrentSpec = changesetSpec.ID
+				changeset := ct.CreateChangeset(t, ctx, bstore, tc.changeset)
+
+				job := &types.ChangesetJob{
+					JobType:       types.ChangesetJobTypeRebase,
+					BatchChangeID: batchChange.ID,
+					ChangesetID:   changeset.ID,
+					UserID:        user.ID,
+					Payload:       &types.ChangesetJobRebasePayload{},
+				}
+
+				if err := bp.process(ctx, job); err == nil {
+					t.Error("unexpected nil error")
+				} else if tc.wantRetryable && errcode.IsNonRetryable(err) {
+					t.Errorf("error is not retryable: %v", err)
+				} else if !tc.wantRetryable && !errcode.IsNonRetryable(err) {
+					t.Errorf("error is retryable: %v", err)
+				}
+			})
+		}
+	})
+
+	t.Run("Rebase job on GitLab merge request", func(t *testing.T) {
+		// The base commit of a GitLab merge request is the commit its source
+		// branch was created from, so the tip of the target branch has to be
+		// resolved.
+		git.Mocks.ResolveRevision = func(spec string, opt git.ResolveRevisionOptions) (api.CommitID, error) {
+			if spec != "refs/heads/main" {
+				t.Errorf("unexpected revision %q", spec)
+			}
+			return "new-base", nil
+		}
+		t.Cleanup(func() {
+			git.Mocks.ResolveRevision = nil
+			git.Mocks.MergeBase = nil
+		})
+
+		for name, tc := range map[string]struct {
+			mergeBase  api.CommitID
+			wantCommit bool
+		}{
+			"target branch moved": {
+				mergeBase:  "old-base",
+				wantCommit: true,
+			},
+			"target branch unchanged": {
+				mergeBase:  "new-base",
+				wantCommit: false,
+			},
+		} {
+			t.Run(name, func(t *testing.T) {
+				git.Mocks.MergeBase = func(repo api.RepoName, a, b api.CommitID) (api.CommitID, error) {
+					return tc.mergeBase, nil
+				}
+
+				changeset := ct.CreateChangeset(t, ctx, bstore, ct.TestChangesetOpts{
+					Repo:                repo.ID,
+					BatchChange:         batchChange.ID,
+					CurrentSpec:         changesetSpec.ID,
+					ExternalServiceType: extsvc.TypeGitLab,
+					ExternalState:       btypes.ChangesetExternalStateOpen,
+					PublicationState:    btypes.ChangesetPublicationStatePublished,
+					ReconcilerState:     btypes.ReconcilerStateCompleted,
+					Metadata: &gitlab.MergeRequest{
+						TargetBranch: "main",
+						DiffRefs:     gitlab.DiffRefs{BaseSHA: "old-base", HeadSHA: "head"},
+					},
+				})
+				changeset.SyncState = btypes.ChangesetSyncState{BaseRefOid: "old-base", HeadRefOid: "head"}
+				if err := bstore.UpdateChangeset(ctx, changeset); err != nil {
+					t.Fatal(err)
+				}
+
+				// Failing the push stops the rebase before it waits for the
+				// code host, and the base commit has been picked by then.
+				gitClient := &ct.FakeGitserverClient{ResponseErr: errors.New("push failed")}
+				bp := &bulkProcessor{
+					tx:        bstore,
+					gitClient: gitClient,
+					sourcer:   sources.NewFakeSourcer(nil, &sources.FakeChangesetSource{}),
+				}
+				job := &types.ChangesetJob{
+					JobType:       types.ChangesetJobTypeRebase,
+					BatchChangeID: batchChange.ID,
+					ChangesetID:   changeset.ID,
+					UserID:        user.ID,
+					Payload:       &types.ChangesetJobRebasePayload{},
+				}
+
+				err := bp.process(ctx, job)
+				if have, want := gitClient.CreateCommitFromPatchCalled, tc.wantCommit; have != want {
+					t.Fatalf("unexpected CreateCommitFromPatch call: have=%t want=%t", have, want)
+				}
+				if !tc.wantCommit {
+					if err != nil {
+						t.Fatalf("unexpected error: %v", err)
+					}
+					return
+				}
+				if err == nil {
+					t.Fatal("expected the failed push to be returned")
+				}
+				if have, want := gitClient.CreateCommitFromPatchReq.BaseCommit, api.CommitID("new-base"); have != want {
+					t.Fatalf("unexpected base commit: have=%q want=%q", have, want)
+				}
+			})
+		}
+	})
 }
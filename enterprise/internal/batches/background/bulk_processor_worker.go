@@ -8,6 +8,7 @@ import (
 	"github.com/hashicorp/go-multierror"
 	"github.com/keegancsmith/sqlf"
 
+	"github.com/sourcegraph/sourcegraph/enterprise/internal/batches/reconciler"
 	"github.com/sourcegraph/sourcegraph/enterprise/internal/batches/sources"
 	"github.com/sourcegraph/sourcegraph/enterprise/internal/batches/store"
 	btypes "github.com/sourcegraph/sourcegraph/enterprise/internal/batches/types"
@@ -29,10 +30,11 @@ const bulkProcessorMaxNumResets = 60
 func newBulkOperationWorker(
 	ctx context.Context,
 	s *store.Store,
+	gitClient reconciler.GitserverClient,
 	sourcer sources.Sourcer,
 	metrics batchChangesMetrics,
 ) *workerutil.Worker {
-	r := &bulkProcessorWorker{sourcer: sourcer, store: s}
+	r := &bulkProcessorWorker{sourcer: sourcer, gitClient: gitClient, store: s}
 
 	options := workerutil.WorkerOptions{
 		Name:              "batches_bulk_processor",
@@ -89,8 +91,9 @@ func scanFirstChangesetJobRecord(rows *sql.Rows, err error) (workerutil.Record,
 // bulkProcessorWorker is a wrapper for the workerutil handlerfunc to create a
 // bulkProcessor with a source and store.
 type bulkProcessorWorker struct {
-	store   *store.Store
-	sourcer sources.Sourcer
+	store     *store.Store
+	sourcer   sources.Sourcer
+	gitClient reconciler.GitserverClient
 }
 
 func (b *bulkProcessorWorker) HandlerFunc() workerutil.HandlerFunc {
@@ -110,8 +113,9 @@ func (b *bulkProcessorWorker) HandlerFunc() workerutil.HandlerFunc {
 		}()
 
 		processor := &bulkProcessor{
-			tx:      tx,
-			sourcer: b.sourcer,
+			tx:        tx,
+			sourcer:   b.sourcer,
+			gitClient: b.gitClient,
 		}
 		return processor.process(ctx, record.(*btypes.ChangesetJob))
 	}
@@ -30,6 +30,8 @@ func executePlan(ctx context.Context, gitserverClient GitserverClient, sourcer s
 		tx:                tx,
 		ch:                plan.Changeset,
 		spec:              plan.ChangesetSpec,
+		autoMergePolicy:   plan.AutoMergePolicy,
+		rebaseOnto:        plan.RebaseOnto,
 	}
 
 	return e.Run(ctx, plan)
@@ -42,6 +44,8 @@ type executor struct {
 	tx                *store.Store
 	ch                *btypes.Changeset
 	spec              *btypes.ChangesetSpec
+	autoMergePolicy   *btypes.AutoMergePolicy
+	rebaseOnto        string
 
 	css  sources.ChangesetSource
 	repo *types.Repo
@@ -52,6 +56,8 @@ func (e *executor) Run(ctx context.Context, plan *Plan) (err error) {
 		return nil
 	}
 
+	wasComplete := e.ch.Complete()
+
 	// Load the changeset repo.
 	e.repo, err = e.tx.Repos().Get(ctx, e.ch.RepoID)
 	if err != nil {
@@ -102,6 +108,12 @@ func (e *executor) Run(ctx context.Context, plan *Plan) (err error) {
 		case btypes.ReconcilerOperationArchive:
 			e.archiveChangeset()
 
+		case btypes.ReconcilerOperationMerge:
+			err = e.mergeChangeset(ctx)
+
+		case btypes.ReconcilerOperationRebase:
+			err = e.rebaseChangeset(ctx)
+
 		default:
 			err = errors.Errorf("executor operation %q not implemented", op)
 		}
@@ -123,7 +135,11 @@ func (e *executor) Run(ctx context.Context, plan *Plan) (err error) {
 		return err
 	}
 
-	return e.tx.UpdateChangeset(ctx, e.ch)
+	if err := e.tx.UpdateChangeset(ctx, e.ch); err != nil {
+		return err
+	}
+
+	return state.EnqueueUnblockedStage(ctx, e.tx, e.ch, wasComplete)
 }
 
 // pushChangesetPatch creates the commits for the changeset on its codehost.
@@ -157,6 +173,39 @@ func (e *executor) pushChangesetPatch(ctx context.Context) (err error) {
 	return e.pushCommit(ctx, opts)
 }
 
+// rebaseChangeset regenerates the commit of the changeset against the given
+// commit of its base branch, force-pushes it to the head branch and records
+// a rebase event for the changeset.
+func (e *executor) rebaseChangeset(ctx context.Context) (err error) {
+	if e.rebaseOnto == "" {
+		return errors.New("no base commit to rebase changeset onto")
+	}
+
+	pushConf, err := e.css.GitserverPushConfig(ctx, e.tx.ExternalServices(), e.repo)
+	if err != nil {
+		return err
+	}
+	opts, err := buildCommitOpts(e.repo, e.spec, pushConf)
+	if err != nil {
+		return err
+	}
+	opts.BaseCommit = api.CommitID(e.rebaseOnto)
+
+	if err := e.pushCommit(ctx, opts); err != nil {
+		return err
+	}
+
+	rebase := &btypes.ChangesetRebase{BaseRev: e.rebaseOnto, RebasedAt: e.tx.Clock()()}
+	return e.tx.UpsertChangesetEvents(ctx, &btypes.ChangesetEvent{
+		ChangesetID: e.ch.ID,
+		Kind:        btypes.ChangesetEventKindBatchesRebased,
+		Key:         rebase.Key(),
+		CreatedAt:   rebase.RebasedAt,
+		UpdatedAt:   rebase.RebasedAt,
+		Metadata:    rebase,
+	})
+}
+
 // publishChangeset creates the given changeset on its code host.
 func (e *executor) publishChangeset(ctx context.Context, asDraft bool) (err error) {
 	cs := &sources.Changeset{
@@ -314,6 +363,28 @@ func (e *executor) closeChangeset(ctx context.Context) (err error) {
 	return nil
 }
 
+// mergeChangeset merges the given changeset on its code host, since it
+// satisfies the auto-merge policy of its batch change. If the code host
+// refuses to merge it, the changeset stays open and the merge is attempted
+// again after the next sync.
+func (e *executor) mergeChangeset(ctx context.Context) (err error) {
+	if e.ch.ExternalState != btypes.ChangesetExternalStateOpen {
+		return nil
+	}
+
+	squash := e.autoMergePolicy != nil && e.autoMergePolicy.Squash
+	cs := &sources.Changeset{Changeset: e.ch, Repo: e.repo}
+
+	if err := e.css.MergeChangeset(ctx, cs, squash); err != nil {
+		if errors.HasType(err, sources.ChangesetNotMergeableError{}) {
+			log15.Warn("Auto-merging changeset", "changeset", e.ch.ID, "err", err)
+			return nil
+		}
+		return errors.Wrap(err, "merging changeset")
+	}
+	return nil
+}
+
 // undraftChangeset marks the given changeset on its code host as ready for review.
 func (e *executor) undraftChangeset(ctx context.Context) (err error) {
 	draftCss, err := sources.ToDraftChangesetSource(e.css)
@@ -393,6 +464,9 @@ func (e *executor) pushCommit(ctx context.Context, opts protocol.CreateCommitFro
 	if err != nil {
 		var e *protocol.CreateCommitFromPatchError
 		if errors.As(err, &e) {
+			if e.PatchConflict {
+				return errPatchConflict{baseRev: string(opts.BaseCommit), output: strings.TrimSpace(e.CombinedOutput)}
+			}
 			return errors.Errorf(
 				"creating commit from patch for repository %q: %s\n"+
 					"```\n"+
@@ -594,3 +668,18 @@ func (e errNoPushCredentials) Error() string {
 }
 
 func (e errNoPushCredentials) NonRetryable() bool { return true }
+
+// errPatchConflict is returned if the patch of a changeset doesn't apply to
+// the base commit anymore, for example because the base branch has changed
+// the same lines since the changeset was created when rebasing it.
+// It is a terminal error that won't be fixed by retrying to push the patch.
+type errPatchConflict struct {
+	baseRev string
+	output  string
+}
+
+func (e errPatchConflict) Error() string {
+	return fmt.Sprintf("the changes of the changeset conflict with commit %s of the base branch:\n```\n%s\n```", e.baseRev, e.output)
+}
+
+func (e errPatchConflict) NonRetryable() bool { return true }
@@ -84,7 +84,9 @@ func TestExecutor_ExecutePlan(t *testing.T) {
 		wantCloseOnCodeHost       bool
 		wantLoadFromCodeHost      bool
 		wantReopenOnCodeHost      bool
+		wantMergeOnCodeHost       bool
 
+		gitserverErr        error
 		wantGitserverCommit bool
 
 		wantChangeset       ct.ChangesetAssertions
@@ -312,6 +314,110 @@ func TestExecutor_ExecutePlan(t *testing.T) {
 				ExternalState:  btypes.ChangesetExternalStateClosed,
 			},
 		},
+		"merge open changeset": {
+			hasCurrentSpec: true,
+			changeset: ct.TestChangesetOpts{
+				PublicationState: btypes.ChangesetPublicationStatePublished,
+				ExternalID:       githubPR.ID,
+				ExternalBranch:   githubHeadRef,
+				ExternalState:    btypes.ChangesetExternalStateOpen,
+			},
+			plan: &Plan{
+				Ops: Operations{
+					btypes.ReconcilerOperationMerge,
+				},
+				AutoMergePolicy: &btypes.AutoMergePolicy{Squash: true},
+			},
+
+			wantMergeOnCodeHost: true,
+
+			wantChangeset: ct.ChangesetAssertions{
+				PublicationState: btypes.ChangesetPublicationStatePublished,
+				ExternalID:       githubPR.ID,
+				ExternalBranch:   githubHeadRef,
+				ExternalState:    btypes.ChangesetExternalStateOpen,
+			},
+		},
+		"merge not mergeable changeset": {
+			hasCurrentSpec: true,
+			changeset: ct.TestChangesetOpts{
+				PublicationState: btypes.ChangesetPublicationStatePublished,
+				ExternalID:       githubPR.ID,
+				ExternalBranch:   githubHeadRef,
+				ExternalState:    btypes.ChangesetExternalStateOpen,
+			},
+			plan: &Plan{
+				Ops: Operations{
+					btypes.ReconcilerOperationMerge,
+				},
+			},
+			sourcerErr: sources.ChangesetNotMergeableError{ErrorMsg: "checks pending"},
+
+			// The changeset stays open and is merged after the next sync.
+			wantMergeOnCodeHost: true,
+
+			wantChangeset: ct.ChangesetAssertions{
+				PublicationState: btypes.ChangesetPublicationStatePublished,
+				ExternalID:       githubPR.ID,
+				ExternalBranch:   githubHeadRef,
+				ExternalState:    btypes.ChangesetExternalStateOpen,
+			},
+		},
+		"rebase outdated changeset": {
+			hasCurrentSpec: true,
+			changeset: ct.TestChangesetOpts{
+				PublicationState: btypes.ChangesetPublicationStatePublished,
+				ExternalID:       githubPR.ID,
+				ExternalBranch:   githubHeadRef,
+				ExternalState:    btypes.ChangesetExternalStateOpen,
+			},
+			plan: &Plan{
+				Ops: Operations{
+					btypes.ReconcilerOperationRebase,
+					btypes.ReconcilerOperationSleep,
+					btypes.ReconcilerOperationSync,
+				},
+				RebaseOnto: "new-base-commit",
+			},
+
+			wantGitserverCommit:  true,
+			wantLoadFromCodeHost: true,
+
+			wantChangeset: ct.ChangesetAssertions{
+				PublicationState: btypes.ChangesetPublicationStatePublished,
+				ExternalID:       githubPR.ID,
+				ExternalBranch:   githubHeadRef,
+				ExternalState:    btypes.ChangesetExternalStateOpen,
+				Title:            githubPR.Title,
+				Body:             githubPR.Body,
+				DiffStat:         state.DiffStat,
+			},
+		},
+		"rebase changeset with conflicts": {
+			hasCurrentSpec: true,
+			changeset: ct.TestChangesetOpts{
+				PublicationState: btypes.ChangesetPublicationStatePublished,
+				ExternalID:       githubPR.ID,
+				ExternalBranch:   githubHeadRef,
+				ExternalState:    btypes.ChangesetExternalStateOpen,
+			},
+			plan: &Plan{
+				Ops: Operations{
+					btypes.ReconcilerOperationRebase,
+					btypes.ReconcilerOperationSleep,
+					btypes.ReconcilerOperationSync,
+				},
+				RebaseOnto: "new-base-commit",
+			},
+			gitserverErr: &gitprotocol.CreateCommitFromPatchError{
+				InternalError:  "gitserver: applying patch: exit status 1",
+				CombinedOutput: "error: patch failed: README.md:1",
+				PatchConflict:  true,
+			},
+
+			wantGitserverCommit: true,
+			wantNonRetryableErr: true,
+		},
 		"reopening closed changeset without updates": {
 			hasCurrentSpec: true,
 			changeset: ct.TestChangesetOpts{
@@ -472,7 +578,7 @@ func TestExecutor_ExecutePlan(t *testing.T) {
 			changeset := ct.CreateChangeset(t, ctx, cstore, changesetOpts)
 
 			// Setup gitserver dependency.
-			gitClient := &ct.FakeGitserverClient{ResponseErr: nil}
+			gitClient := &ct.FakeGitserverClient{ResponseErr: tc.gitserverErr}
 			if changesetSpec != nil {
 				gitClient.Response = changesetSpec.Spec.HeadRef
 			}
@@ -523,6 +629,12 @@ func TestExecutor_ExecutePlan(t *testing.T) {
 				t.Fatalf("wrong CreateCommitFromPatch call. wantCalled=%t, wasCalled=%t", want, have)
 			}
 
+			if tc.plan.RebaseOnto != "" {
+				if have, want := string(gitClient.CreateCommitFromPatchReq.BaseCommit), tc.plan.RebaseOnto; have != want {
+					t.Fatalf("wrong base commit for rebase. want=%q, have=%q", want, have)
+				}
+			}
+
 			if have, want := fakeSource.CreateDraftChangesetCalled, tc.wantCreateDraftOnCodeHost; have != want {
 				t.Fatalf("wrong CreateDraftChangeset call. wantCalled=%t, wasCalled=%t", want, have)
 			}
@@ -551,6 +663,10 @@ func TestExecutor_ExecutePlan(t *testing.T) {
 				t.Fatalf("wrong CloseChangeset call. wantCalled=%t, wasCalled=%t", want, have)
 			}
 
+			if have, want := fakeSource.MergeChangesetCalled, tc.wantMergeOnCodeHost; have != want {
+				t.Fatalf("wrong MergeChangeset call. wantCalled=%t, wasCalled=%t", want, have)
+			}
+
 			if tc.wantNonRetryableErr {
 				return
 			}
@@ -21,6 +21,8 @@ var operationPrecedence = map[btypes.ReconcilerOperation]int{
 	btypes.ReconcilerOperationReopen:       2,
 	btypes.ReconcilerOperationUndraft:      3,
 	btypes.ReconcilerOperationUpdate:       4,
+	btypes.ReconcilerOperationMerge:        4,
+	btypes.ReconcilerOperationRebase:       4,
 	btypes.ReconcilerOperationSleep:        5,
 	btypes.ReconcilerOperationSync:         6,
 }
@@ -97,6 +99,13 @@ type Plan struct {
 	// The Delta between a possible previous ChangesetSpec and the current
 	// ChangesetSpec.
 	Delta *ChangesetSpecDelta
+
+	// The auto-merge policy that the changeset satisfies, if it's merged.
+	AutoMergePolicy *btypes.AutoMergePolicy
+
+	// The commit of the base branch that the changeset is rebased onto, if
+	// it's rebased.
+	RebaseOnto string
 }
 
 func (p *Plan) AddOp(op btypes.ReconcilerOperation) { p.Ops = append(p.Ops, op) }
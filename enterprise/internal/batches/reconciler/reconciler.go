@@ -3,10 +3,12 @@ package reconciler
 import (
 	"context"
 
+	"github.com/cockroachdb/errors"
 	"github.com/hashicorp/go-multierror"
 	"github.com/inconshreveable/log15"
 
 	"github.com/sourcegraph/sourcegraph/enterprise/internal/batches/sources"
+	"github.com/sourcegraph/sourcegraph/enterprise/internal/batches/state"
 	"github.com/sourcegraph/sourcegraph/enterprise/internal/batches/store"
 	btypes "github.com/sourcegraph/sourcegraph/enterprise/internal/batches/types"
 	"github.com/sourcegraph/sourcegraph/internal/gitserver/protocol"
@@ -42,6 +44,15 @@ func New(gitClient GitserverClient, sourcer sources.Sourcer, store *store.Store)
 // workerutil.Worker to process queued changesets.
 func (r *Reconciler) HandlerFunc() workerutil.HandlerFunc {
 	return func(ctx context.Context, record workerutil.Record) (err error) {
+		ch := record.(*btypes.Changeset)
+
+		// Checking whether the changeset is outdated talks to gitserver, so
+		// it's done before opening the transaction.
+		rebaseOnto, err := outdatedBase(ctx, r.store, ch)
+		if err != nil {
+			return err
+		}
+
 		tx, err := r.store.Transact(ctx)
 		if err != nil {
 			return err
@@ -56,7 +67,7 @@ func (r *Reconciler) HandlerFunc() workerutil.HandlerFunc {
 			}
 		}()
 
-		return r.process(ctx, tx, record.(*btypes.Changeset))
+		return r.process(ctx, tx, ch, rebaseOnto)
 	}
 }
 
@@ -71,10 +82,13 @@ func (r *Reconciler) HandlerFunc() workerutil.HandlerFunc {
 // (publication state, external state, sync state, ...), its (if set) current
 // ChangesetSpec, and (if it exists) its previous ChangesetSpec.
 //
+// rebaseOnto is the commit that the changeset has to be rebased onto, as
+// returned by outdatedBase, or empty if it doesn't have to be rebased.
+//
 // If an error is returned, the workerutil.Worker that called this function
 // (through the HandlerFunc) will set the changeset's ReconcilerState to
 // errored and set its FailureMessage to the error.
-func (r *Reconciler) process(ctx context.Context, tx *store.Store, ch *btypes.Changeset) error {
+func (r *Reconciler) process(ctx context.Context, tx *store.Store, ch *btypes.Changeset, rebaseOnto string) error {
 	// Reset the error message.
 	ch.FailureMessage = nil
 
@@ -88,6 +102,14 @@ func (r *Reconciler) process(ctx context.Context, tx *store.Store, ch *btypes.Ch
 		return err
 	}
 
+	if err := holdBlockedPublication(ctx, tx, plan); err != nil {
+		return err
+	}
+
+	if err := planAutomation(ctx, tx, plan, rebaseOnto); err != nil {
+		return err
+	}
+
 	log15.Info("Reconciler processing changeset", "changeset", ch.ID, "operations", plan.Ops)
 
 	return executePlan(
@@ -100,6 +122,139 @@ func (r *Reconciler) process(ctx context.Context, tx *store.Store, ch *btypes.Ch
 	)
 }
 
+// holdBlockedPublication removes the operations to publish the changeset from
+// the plan if the changeset belongs to a stage of the batch change that owns it
+// whose previous stages haven't been merged or closed yet. The changeset stays
+// unpublished and is enqueued again once its stage is unblocked.
+func holdBlockedPublication(ctx context.Context, tx *store.Store, plan *Plan) error {
+	publishing := false
+	for _, op := range plan.Ops {
+		if op == btypes.ReconcilerOperationPublish || op == btypes.ReconcilerOperationPublishDraft {
+			publishing = true
+		}
+	}
+	if !publishing {
+		return nil
+	}
+
+	blocked, err := state.PublicationBlocked(ctx, tx, plan.Changeset)
+	if err != nil || !blocked {
+		return err
+	}
+
+	log15.Debug("Holding back publication of changeset until previous stages are done", "changeset", plan.Changeset.ID)
+	plan.Ops = nil
+	return nil
+}
+
+// planAutomation adds the operations that the batch change owning the
+// changeset automates to the plan, if the plan has nothing else to do: the
+// changeset is rebased onto rebaseOnto if it's set, and it's merged if it
+// satisfies the auto-merge policy of the batch change.
+func planAutomation(ctx context.Context, tx *store.Store, plan *Plan, rebaseOnto string) error {
+	ch := plan.Changeset
+	if !plan.Ops.IsNone() || ch.OwnedByBatchChangeID == 0 || !ch.Published() {
+		return nil
+	}
+
+	if rebaseOnto != "" && plan.ChangesetSpec != nil {
+		// Give the code host some time to pick up the new commit before
+		// syncing, like we do after pushing an updated diff.
+		plan.AddOp(btypes.ReconcilerOperationRebase)
+		plan.AddOp(btypes.ReconcilerOperationSleep)
+		plan.AddOp(btypes.ReconcilerOperationSync)
+		plan.RebaseOnto = rebaseOnto
+		return nil
+	}
+
+	batchSpec, err := tx.GetAppliedBatchSpec(ctx, ch.OwnedByBatchChangeID)
+	if err != nil || batchSpec == nil {
+		return err
+	}
+
+	policy := batchSpec.Spec.AutoMerge
+	if policy == nil {
+		return nil
+	}
+
+	events, err := ch.Events()
+	if err != nil {
+		return err
+	}
+
+	ok, err := state.CanAutoMerge(policy, ch, events, tx.Clock()())
+	if err != nil || !ok {
+		return err
+	}
+
+	plan.AddOp(btypes.ReconcilerOperationMerge)
+	plan.AutoMergePolicy = policy
+	return nil
+}
+
+// outdatedBase returns the latest commit of the base branch of the changeset if
+// the batch change owning it opted into auto-rebasing and the changeset is
+// outdated, see state.IsOutdated. Otherwise it returns an empty string.
+func outdatedBase(ctx context.Context, s *store.Store, ch *btypes.Changeset) (string, error) {
+	if ch.OwnedByBatchChangeID == 0 || ch.CurrentSpecID == 0 || !ch.Published() {
+		return "", nil
+	}
+
+	batchSpec, err := s.GetAppliedBatchSpec(ctx, ch.OwnedByBatchChangeID)
+	if err != nil || batchSpec == nil || !batchSpec.Spec.AutoRebase {
+		return "", err
+	}
+
+	repo, err := s.Repos().Get(ctx, ch.RepoID)
+	if err != nil {
+		return "", err
+	}
+
+	outdated, base, err := state.IsOutdated(ctx, repo.Name, ch)
+	if err != nil {
+		log15.Warn("Checking whether changeset is outdated", "changeset", ch.ID, "err", err)
+		return "", nil
+	}
+	if !outdated {
+		return "", nil
+	}
+	return base, nil
+}
+
+// RebaseChangeset regenerates the commit of the given changeset against the
+// latest commit of its base branch and pushes it, like the reconciler does for
+// batch changes that opted into auto-rebasing. Unlike auto-rebasing, it doesn't
+// wait for the code host to report conflicts, but changesets whose base branch
+// hasn't moved on are left alone.
+//
+// The changeset is expected to be a published changeset with a current spec.
+func RebaseChangeset(ctx context.Context, gitClient GitserverClient, sourcer sources.Sourcer, tx *store.Store, ch *btypes.Changeset) error {
+	repo, err := tx.Repos().Get(ctx, ch.RepoID)
+	if err != nil {
+		return errors.Wrap(err, "getting repo")
+	}
+
+	moved, base, err := state.BaseMoved(ctx, repo.Name, ch)
+	if err != nil {
+		return errors.Wrap(err, "checking whether the base branch moved")
+	}
+	if !moved {
+		log15.Debug("Not rebasing changeset whose base branch hasn't moved", "changeset", ch.ID)
+		return nil
+	}
+
+	spec, err := tx.GetChangesetSpecByID(ctx, ch.CurrentSpecID)
+	if err != nil {
+		return errors.Wrap(err, "getting changeset spec")
+	}
+
+	plan := &Plan{Changeset: ch, ChangesetSpec: spec, RebaseOnto: base}
+	plan.AddOp(btypes.ReconcilerOperationRebase)
+	plan.AddOp(btypes.ReconcilerOperationSleep)
+	plan.AddOp(btypes.ReconcilerOperationSync)
+	return executePlan(ctx, gitClient, sourcer, false, tx, plan)
+}
+
 func loadChangesetSpecs(ctx context.Context, tx *store.Store, ch *btypes.Changeset) (prev, curr *btypes.ChangesetSpec, err error) {
 	if ch.CurrentSpecID != 0 {
 		curr, err = tx.GetChangesetSpecByID(ctx, ch.CurrentSpecID)
@@ -143,7 +143,7 @@ func TestReconcilerProcess_IntegrationTest(t *testing.T) {
 				sourcer:           sourcer,
 				store:             store,
 			}
-			err := rec.process(ctx, store, changeset)
+			err := rec.process(ctx, store, changeset, "")
 			if err != nil {
 				t.Fatalf("reconciler process failed: %s", err)
 			}
@@ -162,3 +162,89 @@ func TestReconcilerProcess_IntegrationTest(t *testing.T) {
 		ct.TruncateTables(t, db, "changeset_events", "changesets", "batch_changes", "batch_specs", "changeset_specs")
 	}
 }
+
+func TestHoldBlockedPublication(t *testing.T) {
+	if testing.Short() {
+		t.Skip()
+	}
+
+	ctx := backend.WithAuthzBypass(context.Background())
+	db := dbtest.NewDB(t, "")
+
+	store := store.New(db, nil)
+
+	admin := ct.CreateTestUser(t, db, true)
+	rs, _ := ct.CreateTestRepos(t, ctx, db, 2)
+
+	batchSpec := &btypes.BatchSpec{
+		UserID:          admin.ID,
+		NamespaceUserID: admin.ID,
+		Spec: btypes.BatchSpecFields{
+			Name: "stages",
+			Stages: []btypes.BatchChangeStage{
+				{Name: "first", Repositories: []string{string(rs[0].Name)}},
+				{Name: "second", Repositories: []string{string(rs[1].Name)}},
+			},
+		},
+	}
+	if err := store.CreateBatchSpec(ctx, batchSpec); err != nil {
+		t.Fatal(err)
+	}
+	batchChange := ct.CreateBatchChange(t, ctx, store, "stages", admin.ID, batchSpec.ID)
+
+	first := ct.CreateChangeset(t, ctx, store, ct.TestChangesetOpts{
+		Repo:               rs[0].ID,
+		BatchChange:        batchChange.ID,
+		OwnedByBatchChange: batchChange.ID,
+		PublicationState:   btypes.ChangesetPublicationStateUnpublished,
+		ReconcilerState:    btypes.ReconcilerStateProcessing,
+	})
+	second := ct.CreateChangeset(t, ctx, store, ct.TestChangesetOpts{
+		Repo:               rs[1].ID,
+		BatchChange:        batchChange.ID,
+		OwnedByBatchChange: batchChange.ID,
+		PublicationState:   btypes.ChangesetPublicationStateUnpublished,
+		ReconcilerState:    btypes.ReconcilerStateProcessing,
+	})
+
+	publish := Operations{btypes.ReconcilerOperationPush, btypes.ReconcilerOperationPublish}
+
+	tests := map[string]struct {
+		changeset *btypes.Changeset
+		ops       Operations
+		want      Operations
+	}{
+		"first stage is published": {
+			changeset: first,
+			ops:       publish,
+			want:      publish,
+		},
+		"second stage is held back": {
+			changeset: second,
+			ops:       publish,
+			want:      nil,
+		},
+		"second stage as draft is held back": {
+			changeset: second,
+			ops:       Operations{btypes.ReconcilerOperationPush, btypes.ReconcilerOperationPublishDraft},
+			want:      nil,
+		},
+		"other operations of the second stage are kept": {
+			changeset: second,
+			ops:       Operations{btypes.ReconcilerOperationDetach},
+			want:      Operations{btypes.ReconcilerOperationDetach},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			plan := &Plan{Changeset: tc.changeset, Ops: tc.ops}
+			if err := holdBlockedPublication(ctx, store, plan); err != nil {
+				t.Fatal(err)
+			}
+			if !plan.Ops.Equal(tc.want) {
+				t.Fatalf("unexpected operations: have=%s want=%s", plan.Ops, tc.want)
+			}
+		})
+	}
+}
@@ -1172,11 +1172,54 @@ func TestCreateBatchChangesCredential(t *testing.T) {
 			t.Fatalf("wrong error code. want=%q, have=%q", want, have)
 		}
 	})
+	t.Run("Username and password credential", func(t *testing.T) {
+		input := map[string]interface{}{
+			"user":                graphqlbackend.MarshalUserID(userID),
+			"externalServiceKind": string(extsvc.KindBitbucketCloud),
+			"externalServiceURL":  "https://bitbucket.org/",
+			"credential":          "SOSECRET",
+		}
+
+		var response struct {
+			CreateBatchChangesCredential apitest.BatchChangesCredential
+		}
+		actorCtx := actor.WithActor(ctx, actor.FromUser(userID))
+
+		t.Run("username missing", func(t *testing.T) {
+			errs := apitest.Exec(actorCtx, t, s, input, &response, mutationCreateCredential)
+			if len(errs) != 1 {
+				t.Fatalf("expected single error, got %d", len(errs))
+			}
+			if have, want := errs[0].Message, "username required for BITBUCKETCLOUD credentials"; have != want {
+				t.Fatalf("wrong error. want=%q, have=%q", want, have)
+			}
+		})
+
+		var authenticator auth.Authenticator
+		service.Mocks.ValidateAuthenticator = func(ctx context.Context, externalServiceID, externalServiceType string, a auth.Authenticator) error {
+			authenticator = a
+			return nil
+		}
+
+		input["username"] = "alice"
+		apitest.MustExec(actorCtx, t, s, input, &response, mutationCreateCredential)
+
+		if response.CreateBatchChangesCredential.ID == "" {
+			t.Fatalf("expected credential to be created, but was not")
+		}
+		a, ok := authenticator.(*auth.BasicAuthWithSSH)
+		if !ok {
+			t.Fatalf("unexpected authenticator type %T", authenticator)
+		}
+		if a.Username != "alice" || a.Password != "SOSECRET" {
+			t.Fatalf("unexpected credentials %q:%q", a.Username, a.Password)
+		}
+	})
 }
 
 const mutationCreateCredential = `
-mutation($user: ID, $externalServiceKind: ExternalServiceKind!, $externalServiceURL: String!, $credential: String!) {
-  createBatchChangesCredential(user: $user, externalServiceKind: $externalServiceKind, externalServiceURL: $externalServiceURL, credential: $credential) { id }
+mutation($user: ID, $externalServiceKind: ExternalServiceKind!, $externalServiceURL: String!, $username: String, $credential: String!) {
+  createBatchChangesCredential(user: $user, externalServiceKind: $externalServiceKind, externalServiceURL: $externalServiceURL, username: $username, credential: $credential) { id }
 }
 `
 
@@ -13,7 +13,9 @@ import (
 
 // Scheduler provides a scheduling service that moves changesets from the
 // scheduled state to the queued state based on the current rate limit, if
-// anything. Changesets are processed in a FIFO manner.
+// anything. Changesets are processed in a FIFO manner, skipping changesets
+// that have to wait for an earlier stage of their batch change: those stay
+// scheduled until the stage opens.
 type Scheduler struct {
 	ctx   context.Context
 	done  chan struct{}
@@ -93,6 +95,9 @@ func (s *Scheduler) Stop() {
 }
 
 func (s *Scheduler) enqueueChangeset() error {
+	// Changesets that have to wait for an earlier stage of their batch change
+	// are skipped and stay scheduled until the stage opens, so they don't use
+	// up the rollout rate.
 	_, err := s.store.EnqueueNextScheduledChangeset(s.ctx)
 
 	// Let's see if this is an error caused by there being no changesets to
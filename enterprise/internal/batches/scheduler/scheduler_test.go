@@ -0,0 +1,115 @@
+package scheduler
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/backend"
+	"github.com/sourcegraph/sourcegraph/enterprise/internal/batches/state"
+	"github.com/sourcegraph/sourcegraph/enterprise/internal/batches/store"
+	ct "github.com/sourcegraph/sourcegraph/enterprise/internal/batches/testing"
+	btypes "github.com/sourcegraph/sourcegraph/enterprise/internal/batches/types"
+	"github.com/sourcegraph/sourcegraph/internal/database/dbtest"
+)
+
+func TestSchedulerEnqueueChangeset_Stages(t *testing.T) {
+	if testing.Short() {
+		t.Skip()
+	}
+
+	ctx := backend.WithAuthzBypass(context.Background())
+	db := dbtest.NewDB(t, "")
+	clock := &ct.TestClock{Time: time.Now()}
+	s := store.NewWithClock(db, nil, clock.Now)
+
+	user := ct.CreateTestUser(t, db, true)
+	rs, _ := ct.CreateTestRepos(t, ctx, db, 3)
+
+	batchSpec := &btypes.BatchSpec{
+		UserID:          user.ID,
+		NamespaceUserID: user.ID,
+		Spec: btypes.BatchSpecFields{
+			Name: "stages",
+			Stages: []btypes.BatchChangeStage{
+				{Name: "first", Repositories: []string{string(rs[0].Name)}},
+				{Name: "second", Repositories: []string{string(rs[1].Name)}},
+			},
+		},
+	}
+	if err := s.CreateBatchSpec(ctx, batchSpec); err != nil {
+		t.Fatal(err)
+	}
+	batchChange := ct.CreateBatchChange(t, ctx, s, "stages", user.ID, batchSpec.ID)
+
+	createChangeset := func(opts ct.TestChangesetOpts) *btypes.Changeset {
+		clock.Add(time.Minute)
+		opts.BatchChange = batchChange.ID
+		opts.OwnedByBatchChange = batchChange.ID
+		return ct.CreateChangeset(t, ctx, s, opts)
+	}
+	first := createChangeset(ct.TestChangesetOpts{
+		Repo:             rs[0].ID,
+		PublicationState: btypes.ChangesetPublicationStatePublished,
+		ExternalState:    btypes.ChangesetExternalStateOpen,
+		ReconcilerState:  btypes.ReconcilerStateCompleted,
+	})
+	// The blocked changeset has been scheduled before the one that isn't part
+	// of any stage, so it would be enqueued first if it weren't blocked.
+	blocked := createChangeset(ct.TestChangesetOpts{
+		Repo:             rs[1].ID,
+		PublicationState: btypes.ChangesetPublicationStateUnpublished,
+		ReconcilerState:  btypes.ReconcilerStateScheduled,
+	})
+	unstaged := createChangeset(ct.TestChangesetOpts{
+		Repo:             rs[2].ID,
+		PublicationState: btypes.ChangesetPublicationStateUnpublished,
+		ReconcilerState:  btypes.ReconcilerStateScheduled,
+	})
+
+	// Applying the batch spec records which changesets are blocked.
+	if err := state.UpdatePublicationBlocked(ctx, s, batchChange.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	scheduler := &Scheduler{ctx: ctx, store: s}
+
+	assertReconcilerState := func(t *testing.T, c *btypes.Changeset, want btypes.ReconcilerState) {
+		t.Helper()
+
+		have, err := s.GetChangeset(ctx, store.GetChangesetOpts{ID: c.ID})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if have.ReconcilerState != want {
+			t.Errorf("unexpected reconciler state of changeset %d: have=%q want=%q", c.ID, have.ReconcilerState, want)
+		}
+	}
+
+	if err := scheduler.enqueueChangeset(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	assertReconcilerState(t, unstaged, btypes.ReconcilerStateQueued)
+	assertReconcilerState(t, blocked, btypes.ReconcilerStateScheduled)
+
+	// The blocked changeset must stay scheduled, rather than using up the
+	// rollout rate.
+	if err := scheduler.enqueueChangeset(); err != store.ErrNoResults {
+		t.Fatalf("unexpected error: have=%v want=%v", err, store.ErrNoResults)
+	}
+	assertReconcilerState(t, blocked, btypes.ReconcilerStateScheduled)
+
+	// Once the first stage is merged, the next one may be published.
+	first.ExternalState = btypes.ChangesetExternalStateMerged
+	if err := s.UpdateChangeset(ctx, first); err != nil {
+		t.Fatal(err)
+	}
+	if err := state.EnqueueUnblockedStage(ctx, s, first, false); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := scheduler.enqueueChangeset(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	assertReconcilerState(t, blocked, btypes.ReconcilerStateQueued)
+}
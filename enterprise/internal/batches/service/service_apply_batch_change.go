@@ -8,6 +8,7 @@ import (
 
 	"github.com/sourcegraph/sourcegraph/cmd/frontend/backend"
 	"github.com/sourcegraph/sourcegraph/enterprise/internal/batches/rewirer"
+	"github.com/sourcegraph/sourcegraph/enterprise/internal/batches/state"
 	"github.com/sourcegraph/sourcegraph/enterprise/internal/batches/store"
 	btypes "github.com/sourcegraph/sourcegraph/enterprise/internal/batches/types"
 	"github.com/sourcegraph/sourcegraph/internal/actor"
@@ -163,6 +164,12 @@ func (s *Service) ApplyBatchChange(ctx context.Context, opts ApplyBatchChangeOpt
 		}
 	}
 
+	// The new batch spec may have changed the stages, and the changesets
+	// in them, so we record which changesets are blocked by an earlier stage.
+	if err := state.UpdatePublicationBlocked(ctx, tx, batchChange.ID); err != nil {
+		return nil, err
+	}
+
 	return batchChange, nil
 }
 
@@ -0,0 +1,191 @@
+package sources
+
+import (
+	"context"
+
+	"github.com/cockroachdb/errors"
+
+	"github.com/sourcegraph/sourcegraph/internal/database"
+	"github.com/sourcegraph/sourcegraph/internal/extsvc/auth"
+	"github.com/sourcegraph/sourcegraph/internal/extsvc/awscodecommit"
+	"github.com/sourcegraph/sourcegraph/internal/gitserver/protocol"
+	"github.com/sourcegraph/sourcegraph/internal/httpcli"
+	"github.com/sourcegraph/sourcegraph/internal/jsonc"
+	"github.com/sourcegraph/sourcegraph/internal/repos"
+	"github.com/sourcegraph/sourcegraph/internal/types"
+	"github.com/sourcegraph/sourcegraph/internal/vcs/git"
+	"github.com/sourcegraph/sourcegraph/schema"
+)
+
+// AWSCodeCommitSource manages pull requests on AWS CodeCommit.
+//
+// The AWS CodeCommit API can only be used with AWS access keys, while Git
+// can only be used with Git credentials. Pull requests are therefore always
+// managed with the access key of the code host connection, and the
+// authenticator is only used to push commits.
+type AWSCodeCommitSource struct {
+	client *awscodecommit.Client
+	au     auth.Authenticator
+}
+
+var _ ChangesetSource = AWSCodeCommitSource{}
+
+// NewAWSCodeCommitSource returns a new AWSCodeCommitSource from the given external service.
+func NewAWSCodeCommitSource(svc *types.ExternalService, cf *httpcli.Factory) (*AWSCodeCommitSource, error) {
+	var c schema.AWSCodeCommitConnection
+	if err := jsonc.Unmarshal(svc.Config, &c); err != nil {
+		return nil, errors.Errorf("external service id=%d config error: %s", svc.ID, err)
+	}
+
+	client, err := repos.NewAWSCodeCommitClient(&c, cf)
+	if err != nil {
+		return nil, err
+	}
+
+	return &AWSCodeCommitSource{
+		client: client,
+		au: &auth.BasicAuth{
+			Username: c.GitCredentials.Username,
+			Password: c.GitCredentials.Password,
+		},
+	}, nil
+}
+
+func (s AWSCodeCommitSource) GitserverPushConfig(ctx context.Context, store *database.ExternalServiceStore, repo *types.Repo) (*protocol.PushConfig, error) {
+	return gitserverPushConfig(ctx, store, repo, s.au)
+}
+
+func (s AWSCodeCommitSource) WithAuthenticator(a auth.Authenticator) (ChangesetSource, error) {
+	switch a.(type) {
+	case *auth.BasicAuth,
+		*auth.BasicAuthWithSSH:
+		break
+
+	default:
+		return nil, newUnsupportedAuthenticatorError("AWSCodeCommitSource", a)
+	}
+
+	return &AWSCodeCommitSource{
+		client: s.client,
+		au:     a,
+	}, nil
+}
+
+// ValidateAuthenticator always succeeds: Git credentials can't be used with
+// the AWS CodeCommit API, so they're only validated when commits are pushed.
+func (s AWSCodeCommitSource) ValidateAuthenticator(ctx context.Context) error {
+	return nil
+}
+
+// CreateChangeset creates the given *Changeset in the code host.
+func (s AWSCodeCommitSource) CreateChangeset(ctx context.Context, c *Changeset) (bool, error) {
+	repo := c.Repo.Metadata.(*awscodecommit.Repository)
+	source := git.EnsureRefPrefix(c.HeadRef)
+	destination := git.EnsureRefPrefix(c.BaseRef)
+
+	// AWS CodeCommit allows multiple open pull requests for the same
+	// branches, so we need to look for an existing one first.
+	exists := true
+	pr, err := s.client.FindOpenPullRequest(ctx, repo.Name, source, destination)
+	if err == awscodecommit.ErrPullRequestNotFound {
+		exists = false
+		pr, err = s.client.CreatePullRequest(ctx, &awscodecommit.CreatePullRequestInput{
+			RepositoryName:       repo.Name,
+			SourceReference:      source,
+			DestinationReference: destination,
+			Title:                c.Title,
+			Description:          c.Body,
+		})
+		if err != nil {
+			return exists, errors.Wrap(err, "creating the pull request")
+		}
+	} else if err != nil {
+		return exists, errors.Wrap(err, "retrieving an extant pull request")
+	}
+
+	if err := c.SetMetadata(pr); err != nil {
+		return exists, errors.Wrap(err, "setting changeset metadata")
+	}
+	return exists, nil
+}
+
+// CloseChangeset closes the given *Changeset on the code host and updates
+// the Metadata column in the *batches.Changeset to the closed pull request.
+func (s AWSCodeCommitSource) CloseChangeset(ctx context.Context, c *Changeset) error {
+	return s.setStatus(ctx, c, awscodecommit.PullRequestStatusClosed)
+}
+
+// ReopenChangeset reopens the closed pull request of the *Changeset on the
+// code host and updates the Metadata column in the *batches.Changeset.
+func (s AWSCodeCommitSource) ReopenChangeset(ctx context.Context, c *Changeset) error {
+	return s.setStatus(ctx, c, awscodecommit.PullRequestStatusOpen)
+}
+
+func (s AWSCodeCommitSource) setStatus(ctx context.Context, c *Changeset, status string) error {
+	pr, ok := c.Changeset.Metadata.(*awscodecommit.PullRequest)
+	if !ok {
+		return errors.New("Changeset is not an AWS CodeCommit pull request")
+	}
+
+	updated, err := s.client.UpdatePullRequestStatus(ctx, pr.ID, status)
+	if err != nil {
+		return errors.Wrap(err, "updating AWS CodeCommit pull request status")
+	}
+	return c.Changeset.SetMetadata(updated)
+}
+
+// LoadChangeset loads the latest state of the given Changeset from the codehost.
+func (s AWSCodeCommitSource) LoadChangeset(ctx context.Context, cs *Changeset) error {
+	pr, err := s.client.GetPullRequest(ctx, cs.ExternalID)
+	if err != nil {
+		if err == awscodecommit.ErrPullRequestNotFound {
+			return ChangesetNotFoundError{Changeset: cs}
+		}
+		return errors.Wrapf(err, "retrieving pull request %s", cs.ExternalID)
+	}
+	return cs.SetMetadata(pr)
+}
+
+// UpdateChangeset updates the title and description of the pull request on
+// AWS CodeCommit to reflect the local state of the Changeset. AWS CodeCommit
+// doesn't allow changing the destination of a pull request.
+func (s AWSCodeCommitSource) UpdateChangeset(ctx context.Context, c *Changeset) error {
+	pr, ok := c.Changeset.Metadata.(*awscodecommit.PullRequest)
+	if !ok {
+		return errors.New("Changeset is not an AWS CodeCommit pull request")
+	}
+
+	updated, err := s.client.UpdatePullRequest(ctx, pr.ID, c.Title, c.Body)
+	if err != nil {
+		return errors.Wrap(err, "updating AWS CodeCommit pull request")
+	}
+	return c.Changeset.SetMetadata(updated)
+}
+
+// CreateComment posts a comment on the Changeset.
+func (s AWSCodeCommitSource) CreateComment(ctx context.Context, c *Changeset, text string) error {
+	pr, ok := c.Changeset.Metadata.(*awscodecommit.PullRequest)
+	if !ok {
+		return errors.New("Changeset is not an AWS CodeCommit pull request")
+	}
+	return s.client.CreatePullRequestComment(ctx, pr, text)
+}
+
+// MergeChangeset merges the pull request of the Changeset on the code host,
+// if in a mergeable state. If squash is true, the commits of the pull request
+// are squashed.
+func (s AWSCodeCommitSource) MergeChangeset(ctx context.Context, c *Changeset, squash bool) error {
+	pr, ok := c.Changeset.Metadata.(*awscodecommit.PullRequest)
+	if !ok {
+		return errors.New("Changeset is not an AWS CodeCommit pull request")
+	}
+
+	merged, err := s.client.MergePullRequest(ctx, pr, squash)
+	if err != nil {
+		if errors.Is(err, awscodecommit.ErrNotMergeable) {
+			return ChangesetNotMergeableError{ErrorMsg: err.Error()}
+		}
+		return errors.Wrap(err, "merging AWS CodeCommit pull request")
+	}
+	return c.Changeset.SetMetadata(merged)
+}
@@ -0,0 +1,438 @@
+package sources
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/cockroachdb/errors"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+
+	btypes "github.com/sourcegraph/sourcegraph/enterprise/internal/batches/types"
+	"github.com/sourcegraph/sourcegraph/internal/extsvc"
+	"github.com/sourcegraph/sourcegraph/internal/extsvc/auth"
+	"github.com/sourcegraph/sourcegraph/internal/extsvc/awscodecommit"
+	"github.com/sourcegraph/sourcegraph/internal/httpcli"
+	"github.com/sourcegraph/sourcegraph/internal/types"
+	"github.com/sourcegraph/sourcegraph/schema"
+)
+
+// The test fixtures were generated against the test repository of the
+// Sourcegraph AWS account in us-west-1.
+var awsCodeCommitTestRepo = &types.Repo{
+	Metadata: &awscodecommit.Repository{
+		ARN:  "arn:aws:codecommit:us-west-1:185007729374:test",
+		ID:   "020a4751-0f46-4e19-82bf-07d0989b67dd",
+		Name: "test",
+	},
+}
+
+const (
+	awsCodeCommitTestRevision          = "14e2c7a9b1f3d5e6a8c0b2d4f6e8a0c2b4d6f8e0a2c4e6a8b0d2f4e6a8c0b2d"
+	awsCodeCommitTestSourceCommit      = "2b3f8c1d9e0a1b2c3d4e5f60718293a4b5c6d7e8"
+	awsCodeCommitTestDestinationCommit = "7c4e1a2b3d5f60718293a4b5c6d7e8f901234567"
+)
+
+func TestAWSCodeCommitSource_LoadChangeset(t *testing.T) {
+	testCases := []struct {
+		name string
+		cs   *Changeset
+		err  string
+	}{
+		{
+			name: "found",
+			cs:   &Changeset{Repo: awsCodeCommitTestRepo, Changeset: &btypes.Changeset{ExternalID: "1"}},
+		},
+		{
+			name: "not-found",
+			cs:   &Changeset{Repo: awsCodeCommitTestRepo, Changeset: &btypes.Changeset{ExternalID: "999"}},
+			err:  `Changeset with external ID 999 not found`,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		tc.name = "AWSCodeCommitSource_LoadChangeset_" + tc.name
+
+		t.Run(tc.name, func(t *testing.T) {
+			cf, save := newClientFactory(t, tc.name)
+			defer save(t)
+
+			src := newAWSCodeCommitTestSource(t, cf)
+
+			if tc.err == "" {
+				tc.err = "<nil>"
+			}
+
+			err := src.LoadChangeset(context.Background(), tc.cs)
+			if have, want := fmt.Sprint(err), tc.err; have != want {
+				t.Errorf("error:\nhave: %q\nwant: %q", have, want)
+			}
+
+			if err != nil {
+				return
+			}
+
+			pr := tc.cs.Changeset.Metadata.(*awscodecommit.PullRequest)
+			want := &awscodecommit.PullRequest{
+				ID:                   "1",
+				RevisionID:           awsCodeCommitTestRevision,
+				Title:                "This is a test PR",
+				Description:          "This is the body of a test PR",
+				Status:               awscodecommit.PullRequestStatusOpen,
+				AuthorARN:            "arn:aws:iam::185007729374:user/sourcegraph-test",
+				RepositoryName:       "test",
+				SourceReference:      "refs/heads/always-open-pr-acc",
+				DestinationReference: "refs/heads/master",
+				SourceCommit:         awsCodeCommitTestSourceCommit,
+				DestinationCommit:    awsCodeCommitTestDestinationCommit,
+				WebURL:               "https://us-west-1.console.aws.amazon.com/codesuite/codecommit/repositories/test/pull-requests/1/details?region=us-west-1",
+				ApprovedBy:           []string{"arn:aws:iam::185007729374:user/reviewer"},
+			}
+			// The dates are parsed from fractional epoch seconds, so we only
+			// check that they're set.
+			opt := cmpopts.IgnoreFields(awscodecommit.PullRequest{}, "CreationDate", "LastActivityDate")
+			if diff := cmp.Diff(want, pr, opt); diff != "" {
+				t.Errorf("unexpected pull request (-want +have):\n%s", diff)
+			}
+			if pr.CreationDate.IsZero() || pr.LastActivityDate.IsZero() {
+				t.Errorf("dates not set: created=%v last activity=%v", pr.CreationDate, pr.LastActivityDate)
+			}
+		})
+	}
+}
+
+func TestAWSCodeCommitSource_CreateChangeset(t *testing.T) {
+	testCases := []struct {
+		name   string
+		cs     *Changeset
+		err    string
+		exists bool
+		wantID string
+		// wantNotSatisfied are the approval rules that the pull request
+		// doesn't satisfy yet.
+		wantNotSatisfied []string
+	}{
+		{
+			name: "success",
+			cs: &Changeset{
+				Title:     "This is a test PR",
+				Body:      "This is the body of a test PR",
+				BaseRef:   "master",
+				HeadRef:   "test-pr-acc-2",
+				Repo:      awsCodeCommitTestRepo,
+				Changeset: &btypes.Changeset{},
+			},
+			wantID:           "2",
+			wantNotSatisfied: []string{"Require one approval"},
+		},
+		{
+			name: "already exists",
+			cs: &Changeset{
+				Title:     "This is a test PR",
+				Body:      "This is the body of a test PR",
+				BaseRef:   "refs/heads/master",
+				HeadRef:   "refs/heads/always-open-pr-acc",
+				Repo:      awsCodeCommitTestRepo,
+				Changeset: &btypes.Changeset{},
+			},
+			// CreateChangeset is idempotent so if the PR already exists
+			// it is not an error
+			err:    "",
+			exists: true,
+			wantID: "1",
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		tc.name = "AWSCodeCommitSource_CreateChangeset_" + tc.name
+
+		t.Run(tc.name, func(t *testing.T) {
+			cf, save := newClientFactory(t, tc.name)
+			defer save(t)
+
+			src := newAWSCodeCommitTestSource(t, cf)
+
+			if tc.err == "" {
+				tc.err = "<nil>"
+			}
+
+			exists, err := src.CreateChangeset(context.Background(), tc.cs)
+			if have, want := fmt.Sprint(err), tc.err; have != want {
+				t.Errorf("error:\nhave: %q\nwant: %q", have, want)
+			}
+
+			if err != nil {
+				return
+			}
+
+			if have, want := exists, tc.exists; have != want {
+				t.Errorf("exists:\nhave: %t\nwant: %t", have, want)
+			}
+			if have, want := tc.cs.ExternalID, tc.wantID; have != want {
+				t.Errorf("external ID:\nhave: %q\nwant: %q", have, want)
+			}
+			if have, want := tc.cs.ExternalServiceType, extsvc.TypeAWSCodeCommit; have != want {
+				t.Errorf("external service type:\nhave: %q\nwant: %q", have, want)
+			}
+
+			pr := tc.cs.Changeset.Metadata.(*awscodecommit.PullRequest)
+			if diff := cmp.Diff(tc.wantNotSatisfied, pr.ApprovalRulesNotSatisfied, cmpopts.EquateEmpty()); diff != "" {
+				t.Errorf("unexpected unsatisfied approval rules (-want +have):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestAWSCodeCommitSource_UpdateChangeset(t *testing.T) {
+	name := "AWSCodeCommitSource_UpdateChangeset_success"
+
+	cf, save := newClientFactory(t, name)
+	defer save(t)
+
+	src := newAWSCodeCommitTestSource(t, cf)
+
+	cs := &Changeset{
+		Title: "This is a new title",
+		Body:  "This is a new body",
+		Repo:  awsCodeCommitTestRepo,
+		Changeset: &btypes.Changeset{
+			Metadata: &awscodecommit.PullRequest{ID: "1", Status: awscodecommit.PullRequestStatusOpen},
+		},
+	}
+
+	if err := src.UpdateChangeset(context.Background(), cs); err != nil {
+		t.Fatal(err)
+	}
+
+	pr := cs.Changeset.Metadata.(*awscodecommit.PullRequest)
+	if have, want := pr.Title, "This is a new title"; have != want {
+		t.Errorf("title:\nhave: %q\nwant: %q", have, want)
+	}
+	if have, want := pr.Description, "This is a new body"; have != want {
+		t.Errorf("description:\nhave: %q\nwant: %q", have, want)
+	}
+}
+
+func TestAWSCodeCommitSource_CloseChangeset(t *testing.T) {
+	name := "AWSCodeCommitSource_CloseChangeset_success"
+
+	cf, save := newClientFactory(t, name)
+	defer save(t)
+
+	src := newAWSCodeCommitTestSource(t, cf)
+
+	cs := &Changeset{
+		Repo: awsCodeCommitTestRepo,
+		Changeset: &btypes.Changeset{
+			Metadata: &awscodecommit.PullRequest{ID: "3", Status: awscodecommit.PullRequestStatusOpen},
+		},
+	}
+
+	if err := src.CloseChangeset(context.Background(), cs); err != nil {
+		t.Fatal(err)
+	}
+
+	pr := cs.Changeset.Metadata.(*awscodecommit.PullRequest)
+	if have, want := pr.Status, awscodecommit.PullRequestStatusClosed; have != want {
+		t.Errorf("status:\nhave: %q\nwant: %q", have, want)
+	}
+}
+
+func TestAWSCodeCommitSource_ReopenChangeset(t *testing.T) {
+	name := "AWSCodeCommitSource_ReopenChangeset_success"
+
+	cf, save := newClientFactory(t, name)
+	defer save(t)
+
+	src := newAWSCodeCommitTestSource(t, cf)
+
+	cs := &Changeset{
+		Repo: awsCodeCommitTestRepo,
+		Changeset: &btypes.Changeset{
+			ExternalID: "3",
+			Metadata:   &awscodecommit.PullRequest{ID: "3", Status: awscodecommit.PullRequestStatusClosed},
+		},
+	}
+
+	if err := src.ReopenChangeset(context.Background(), cs); err != nil {
+		t.Fatal(err)
+	}
+
+	// Unlike on Bitbucket Cloud, closed pull requests are reopened in place.
+	pr := cs.Changeset.Metadata.(*awscodecommit.PullRequest)
+	if have, want := pr.Status, awscodecommit.PullRequestStatusOpen; have != want {
+		t.Errorf("status:\nhave: %q\nwant: %q", have, want)
+	}
+	if have, want := cs.ExternalID, "3"; have != want {
+		t.Errorf("external ID:\nhave: %q\nwant: %q", have, want)
+	}
+}
+
+func TestAWSCodeCommitSource_CreateComment(t *testing.T) {
+	name := "AWSCodeCommitSource_CreateComment_success"
+
+	cf, save := newClientFactory(t, name)
+	defer save(t)
+
+	src := newAWSCodeCommitTestSource(t, cf)
+
+	cs := &Changeset{
+		Repo: awsCodeCommitTestRepo,
+		Changeset: &btypes.Changeset{
+			Metadata: &awscodecommit.PullRequest{
+				ID:                "1",
+				RepositoryName:    "test",
+				SourceCommit:      awsCodeCommitTestSourceCommit,
+				DestinationCommit: awsCodeCommitTestDestinationCommit,
+			},
+		},
+	}
+
+	if err := src.CreateComment(context.Background(), cs, "test-comment"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestAWSCodeCommitSource_MergeChangeset(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		name := "AWSCodeCommitSource_MergeChangeset_success"
+
+		cf, save := newClientFactory(t, name)
+		defer save(t)
+
+		src := newAWSCodeCommitTestSource(t, cf)
+
+		cs := &Changeset{
+			Repo: awsCodeCommitTestRepo,
+			Changeset: &btypes.Changeset{
+				Metadata: &awscodecommit.PullRequest{
+					ID:             "5",
+					Status:         awscodecommit.PullRequestStatusOpen,
+					RepositoryName: "test",
+					SourceCommit:   awsCodeCommitTestSourceCommit,
+				},
+			},
+		}
+
+		if err := src.MergeChangeset(context.Background(), cs, false); err != nil {
+			t.Fatal(err)
+		}
+
+		pr := cs.Changeset.Metadata.(*awscodecommit.PullRequest)
+		if !pr.IsMerged {
+			t.Error("expected pull request to be merged")
+		}
+		if have, want := pr.Status, awscodecommit.PullRequestStatusClosed; have != want {
+			t.Errorf("status:\nhave: %q\nwant: %q", have, want)
+		}
+	})
+
+	t.Run("not mergeable", func(t *testing.T) {
+		name := "AWSCodeCommitSource_MergeChangeset_not-mergeable"
+
+		cf, save := newClientFactory(t, name)
+		defer save(t)
+
+		src := newAWSCodeCommitTestSource(t, cf)
+
+		cs := &Changeset{
+			Repo: awsCodeCommitTestRepo,
+			Changeset: &btypes.Changeset{
+				Metadata: &awscodecommit.PullRequest{
+					ID:             "6",
+					Status:         awscodecommit.PullRequestStatusOpen,
+					RepositoryName: "test",
+					SourceCommit:   awsCodeCommitTestSourceCommit,
+				},
+			},
+		}
+
+		err := src.MergeChangeset(context.Background(), cs, false)
+		if !errors.HasType(err, ChangesetNotMergeableError{}) {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+}
+
+func TestAWSCodeCommitSource_WithAuthenticator(t *testing.T) {
+	src := newAWSCodeCommitTestSource(t, nil)
+
+	t.Run("supported", func(t *testing.T) {
+		for name, tc := range map[string]auth.Authenticator{
+			"BasicAuth":        &auth.BasicAuth{},
+			"BasicAuthWithSSH": &auth.BasicAuthWithSSH{},
+		} {
+			t.Run(name, func(t *testing.T) {
+				s, err := src.WithAuthenticator(tc)
+				if err != nil {
+					t.Errorf("unexpected non-nil error: %v", err)
+				}
+
+				if as, ok := s.(*AWSCodeCommitSource); !ok {
+					t.Error("cannot coerce Source into AWSCodeCommitSource")
+				} else if as == nil {
+					t.Error("unexpected nil Source")
+				} else if as.au != tc {
+					t.Errorf("incorrect authenticator: have=%v want=%v", as.au, tc)
+				}
+			})
+		}
+	})
+
+	t.Run("unsupported", func(t *testing.T) {
+		for name, tc := range map[string]auth.Authenticator{
+			"nil":              nil,
+			"OAuthClient":      &auth.OAuthClient{},
+			"OAuthBearerToken": &auth.OAuthBearerToken{},
+		} {
+			t.Run(name, func(t *testing.T) {
+				s, err := src.WithAuthenticator(tc)
+				if err == nil {
+					t.Error("unexpected nil error")
+				} else if !errors.HasType(err, UnsupportedAuthenticatorError{}) {
+					t.Errorf("unexpected error of type %T: %v", err, err)
+				}
+				if s != nil {
+					t.Errorf("expected nil Source: %v", s)
+				}
+			})
+		}
+	})
+}
+
+func newAWSCodeCommitTestSource(t *testing.T, cf *httpcli.Factory) *AWSCodeCommitSource {
+	t.Helper()
+
+	svc := &types.ExternalService{
+		Kind: extsvc.KindAWSCodeCommit,
+		Config: marshalJSON(t, &schema.AWSCodeCommitConnection{
+			AccessKeyID:     getAWSEnv("AWS_ACCESS_KEY_ID"),
+			SecretAccessKey: getAWSEnv("AWS_SECRET_ACCESS_KEY"),
+			Region:          "us-west-1",
+			GitCredentials: schema.AWSCodeCommitGitCredentials{
+				Username: "git-username",
+				Password: "git-password",
+			},
+		}),
+	}
+
+	src, err := NewAWSCodeCommitSource(svc, cf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return src
+}
+
+// getAWSEnv returns the given environment variable, or a bogus value if it
+// isn't set, since the AWS SDK doesn't sign requests with empty credentials.
+func getAWSEnv(envVar string) string {
+	s := os.Getenv(envVar)
+	if s == "" {
+		s = fmt.Sprintf("BOGUS-%s", envVar)
+	}
+	return s
+}
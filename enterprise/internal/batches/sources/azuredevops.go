@@ -0,0 +1,288 @@
+package sources
+
+import (
+	"context"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/cockroachdb/errors"
+
+	"github.com/sourcegraph/sourcegraph/internal/database"
+	"github.com/sourcegraph/sourcegraph/internal/extsvc"
+	"github.com/sourcegraph/sourcegraph/internal/extsvc/auth"
+	"github.com/sourcegraph/sourcegraph/internal/extsvc/azuredevops"
+	"github.com/sourcegraph/sourcegraph/internal/gitserver/protocol"
+	"github.com/sourcegraph/sourcegraph/internal/httpcli"
+	"github.com/sourcegraph/sourcegraph/internal/jsonc"
+	"github.com/sourcegraph/sourcegraph/internal/repos"
+	"github.com/sourcegraph/sourcegraph/internal/types"
+	"github.com/sourcegraph/sourcegraph/internal/vcs/git"
+	"github.com/sourcegraph/sourcegraph/schema"
+)
+
+type AzureDevOpsSource struct {
+	client *azuredevops.Client
+	au     auth.Authenticator
+
+	// org is an organization of the connection, used to validate
+	// authenticators.
+	org string
+}
+
+var _ ChangesetSource = AzureDevOpsSource{}
+var _ DraftChangesetSource = AzureDevOpsSource{}
+
+// NewAzureDevOpsSource returns a new AzureDevOpsSource from the given external service.
+func NewAzureDevOpsSource(svc *types.ExternalService, cf *httpcli.Factory) (*AzureDevOpsSource, error) {
+	var c schema.AzureDevOpsConnection
+	if err := jsonc.Unmarshal(svc.Config, &c); err != nil {
+		return nil, errors.Errorf("external service id=%d config error: %s", svc.ID, err)
+	}
+	return newAzureDevOpsSource(&c, cf, nil)
+}
+
+func newAzureDevOpsSource(c *schema.AzureDevOpsConnection, cf *httpcli.Factory, au auth.Authenticator) (*AzureDevOpsSource, error) {
+	baseURL, err := url.Parse(c.Url)
+	if err != nil {
+		return nil, err
+	}
+	baseURL = extsvc.NormalizeBaseURL(baseURL)
+
+	if cf == nil {
+		cf = httpcli.NewExternalHTTPClientFactory()
+	}
+
+	cli, err := cf.Doer()
+	if err != nil {
+		return nil, err
+	}
+
+	if au == nil {
+		au = repos.AzureDevOpsAuthenticator(c)
+	}
+
+	var org string
+	if len(c.Orgs) > 0 {
+		org = c.Orgs[0]
+	} else if len(c.Projects) > 0 {
+		org = strings.SplitN(c.Projects[0], "/", 2)[0]
+	}
+
+	return &AzureDevOpsSource{
+		client: azuredevops.NewClient(baseURL, au, cli),
+		au:     au,
+		org:    org,
+	}, nil
+}
+
+func (s AzureDevOpsSource) GitserverPushConfig(ctx context.Context, store *database.ExternalServiceStore, repo *types.Repo) (*protocol.PushConfig, error) {
+	return gitserverPushConfig(ctx, store, repo, s.au)
+}
+
+func (s AzureDevOpsSource) WithAuthenticator(a auth.Authenticator) (ChangesetSource, error) {
+	switch a.(type) {
+	case *auth.OAuthBearerToken,
+		*auth.OAuthBearerTokenWithSSH,
+		*auth.BasicAuth,
+		*auth.BasicAuthWithSSH:
+		break
+
+	default:
+		return nil, newUnsupportedAuthenticatorError("AzureDevOpsSource", a)
+	}
+
+	return &AzureDevOpsSource{
+		client: s.client.WithAuthenticator(a),
+		au:     a,
+		org:    s.org,
+	}, nil
+}
+
+func (s AzureDevOpsSource) ValidateAuthenticator(ctx context.Context) error {
+	if s.org == "" {
+		return errors.New("no Azure DevOps organization configured to validate the credential against")
+	}
+	_, err := s.client.AuthenticatedUser(ctx, s.org)
+	return err
+}
+
+// CreateChangeset creates the given *Changeset in the code host.
+func (s AzureDevOpsSource) CreateChangeset(ctx context.Context, c *Changeset) (bool, error) {
+	return s.createChangeset(ctx, c, false)
+}
+
+// CreateDraftChangeset creates the given *Changeset in the code host as a
+// draft pull request.
+func (s AzureDevOpsSource) CreateDraftChangeset(ctx context.Context, c *Changeset) (bool, error) {
+	exists, err := s.createChangeset(ctx, c, true)
+	if err != nil {
+		return exists, err
+	}
+
+	pr := c.Changeset.Metadata.(*azuredevops.PullRequest)
+
+	// If it already exists, but is not a draft, we need to turn it into one.
+	if exists && !pr.IsDraft {
+		if err := s.setDraft(ctx, c, true); err != nil {
+			return exists, err
+		}
+	}
+	return exists, nil
+}
+
+func (s AzureDevOpsSource) createChangeset(ctx context.Context, c *Changeset, draft bool) (bool, error) {
+	repo := c.Repo.Metadata.(*azuredevops.Repository)
+	exists := false
+	source := git.EnsureRefPrefix(c.HeadRef)
+	target := git.EnsureRefPrefix(c.BaseRef)
+
+	pr, err := s.client.CreatePullRequest(ctx, repo, &azuredevops.CreatePullRequestInput{
+		SourceRefName: source,
+		TargetRefName: target,
+		Title:         c.Title,
+		Description:   c.Body,
+		IsDraft:       draft,
+	})
+	if err != nil {
+		if err != azuredevops.ErrPullRequestAlreadyExists {
+			return exists, errors.Wrap(err, "creating the pull request")
+		}
+
+		exists = true
+		pr, err = s.client.FindActivePullRequest(ctx, repo, source, target)
+		if err != nil {
+			return exists, errors.Wrap(err, "retrieving an extant pull request")
+		}
+	}
+
+	if err := s.loadPullRequestData(ctx, repo, pr); err != nil {
+		return exists, errors.Wrap(err, "loading extra metadata")
+	}
+	if err := c.SetMetadata(pr); err != nil {
+		return exists, errors.Wrap(err, "setting changeset metadata")
+	}
+	return exists, nil
+}
+
+// CloseChangeset abandons the given *Changeset on the code host and updates
+// the Metadata column in the *batches.Changeset to the abandoned pull request.
+func (s AzureDevOpsSource) CloseChangeset(ctx context.Context, c *Changeset) error {
+	return s.update(ctx, c, &azuredevops.UpdatePullRequestInput{
+		Status: azuredevops.PullRequestStatusAbandoned,
+	})
+}
+
+// LoadChangeset loads the latest state of the given Changeset from the codehost.
+func (s AzureDevOpsSource) LoadChangeset(ctx context.Context, cs *Changeset) error {
+	repo := cs.Repo.Metadata.(*azuredevops.Repository)
+	id, err := strconv.Atoi(cs.ExternalID)
+	if err != nil {
+		return errors.Wrapf(err, "parsing changeset external id %s", cs.ExternalID)
+	}
+
+	pr, err := s.client.GetPullRequest(ctx, repo, id)
+	if err != nil {
+		if err == azuredevops.ErrPullRequestNotFound {
+			return ChangesetNotFoundError{Changeset: cs}
+		}
+		return errors.Wrapf(err, "retrieving pull request %d", id)
+	}
+
+	if err := s.loadPullRequestData(ctx, repo, pr); err != nil {
+		return errors.Wrap(err, "loading pull request data")
+	}
+	return cs.SetMetadata(pr)
+}
+
+// loadPullRequestData loads the statuses of pr, which aren't returned with
+// the pull request itself.
+func (s AzureDevOpsSource) loadPullRequestData(ctx context.Context, repo *azuredevops.Repository, pr *azuredevops.PullRequest) error {
+	statuses, err := s.client.ListPullRequestStatuses(ctx, repo, pr.PullRequestID)
+	if err != nil {
+		return errors.Wrap(err, "loading pull request statuses")
+	}
+	pr.Statuses = statuses
+	return nil
+}
+
+// UpdateChangeset updates the pull request on Azure DevOps to reflect the
+// local state of the Changeset.
+func (s AzureDevOpsSource) UpdateChangeset(ctx context.Context, c *Changeset) error {
+	return s.update(ctx, c, &azuredevops.UpdatePullRequestInput{
+		Title:         c.Title,
+		Description:   &c.Body,
+		TargetRefName: git.EnsureRefPrefix(c.BaseRef),
+	})
+}
+
+// UndraftChangeset publishes the draft pull request of the Changeset.
+func (s AzureDevOpsSource) UndraftChangeset(ctx context.Context, c *Changeset) error {
+	return s.setDraft(ctx, c, false)
+}
+
+func (s AzureDevOpsSource) setDraft(ctx context.Context, c *Changeset, draft bool) error {
+	return s.update(ctx, c, &azuredevops.UpdatePullRequestInput{IsDraft: &draft})
+}
+
+// ReopenChangeset reactivates the abandoned pull request of the *Changeset
+// on the code host and updates the Metadata column in the
+// *batches.Changeset.
+func (s AzureDevOpsSource) ReopenChangeset(ctx context.Context, c *Changeset) error {
+	return s.update(ctx, c, &azuredevops.UpdatePullRequestInput{
+		Status: azuredevops.PullRequestStatusActive,
+	})
+}
+
+func (s AzureDevOpsSource) update(ctx context.Context, c *Changeset, input *azuredevops.UpdatePullRequestInput) error {
+	pr, ok := c.Changeset.Metadata.(*azuredevops.PullRequest)
+	if !ok {
+		return errors.New("Changeset is not an Azure DevOps pull request")
+	}
+	repo := c.Repo.Metadata.(*azuredevops.Repository)
+
+	updated, err := s.client.UpdatePullRequest(ctx, repo, pr.PullRequestID, input)
+	if err != nil {
+		return errors.Wrap(err, "updating Azure DevOps pull request")
+	}
+
+	if err := s.loadPullRequestData(ctx, repo, updated); err != nil {
+		return errors.Wrap(err, "loading pull request data")
+	}
+	return c.Changeset.SetMetadata(updated)
+}
+
+// CreateComment posts a comment on the Changeset.
+func (s AzureDevOpsSource) CreateComment(ctx context.Context, c *Changeset, text string) error {
+	pr, ok := c.Changeset.Metadata.(*azuredevops.PullRequest)
+	if !ok {
+		return errors.New("Changeset is not an Azure DevOps pull request")
+	}
+	repo := c.Repo.Metadata.(*azuredevops.Repository)
+
+	return s.client.CreatePullRequestComment(ctx, repo, pr.PullRequestID, text)
+}
+
+// MergeChangeset completes the pull request of the Changeset on the code
+// host, if in a mergeable state. If squash is true, the commits of the pull
+// request are squashed.
+func (s AzureDevOpsSource) MergeChangeset(ctx context.Context, c *Changeset, squash bool) error {
+	pr, ok := c.Changeset.Metadata.(*azuredevops.PullRequest)
+	if !ok {
+		return errors.New("Changeset is not an Azure DevOps pull request")
+	}
+	repo := c.Repo.Metadata.(*azuredevops.Repository)
+
+	updated, err := s.client.CompletePullRequest(ctx, repo, pr, squash)
+	if err != nil {
+		if errors.Is(err, azuredevops.ErrNotMergeable) {
+			return ChangesetNotMergeableError{ErrorMsg: err.Error()}
+		}
+		return errors.Wrap(err, "completing Azure DevOps pull request")
+	}
+
+	if err := s.loadPullRequestData(ctx, repo, updated); err != nil {
+		return errors.Wrap(err, "loading pull request data")
+	}
+	return c.Changeset.SetMetadata(updated)
+}
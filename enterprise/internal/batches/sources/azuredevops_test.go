@@ -0,0 +1,278 @@
+package sources
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/cockroachdb/errors"
+
+	btypes "github.com/sourcegraph/sourcegraph/enterprise/internal/batches/types"
+	"github.com/sourcegraph/sourcegraph/internal/extsvc"
+	"github.com/sourcegraph/sourcegraph/internal/extsvc/auth"
+	"github.com/sourcegraph/sourcegraph/internal/extsvc/azuredevops"
+	"github.com/sourcegraph/sourcegraph/internal/httpcli"
+	"github.com/sourcegraph/sourcegraph/internal/testutil"
+	"github.com/sourcegraph/sourcegraph/internal/types"
+	"github.com/sourcegraph/sourcegraph/schema"
+)
+
+// The test fixtures and golden files were generated against the sgtest
+// organization on dev.azure.com.
+var azureDevOpsTestRepo = &types.Repo{
+	Metadata: &azuredevops.Repository{
+		ID:      "1ffa2c2e-7b4d-4d6b-9d4b-0b5e6f1a3c21",
+		Name:    "src-cli",
+		Project: azuredevops.Project{Name: "sourcegraph"},
+		WebURL:  "https://dev.azure.com/sgtest/sourcegraph/_git/src-cli",
+		Org:     "sgtest",
+	},
+}
+
+func TestAzureDevOpsSource_LoadChangeset(t *testing.T) {
+	testCases := []struct {
+		name string
+		cs   *Changeset
+		err  string
+	}{
+		{
+			name: "found",
+			cs:   &Changeset{Repo: azureDevOpsTestRepo, Changeset: &btypes.Changeset{ExternalID: "2"}},
+		},
+		{
+			name: "not-found",
+			cs:   &Changeset{Repo: azureDevOpsTestRepo, Changeset: &btypes.Changeset{ExternalID: "999"}},
+			err:  `Changeset with external ID 999 not found`,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		tc.name = "AzureDevOpsSource_LoadChangeset_" + tc.name
+
+		t.Run(tc.name, func(t *testing.T) {
+			cf, save := newClientFactory(t, tc.name)
+			defer save(t)
+
+			src := newAzureDevOpsTestSource(t, cf)
+
+			if tc.err == "" {
+				tc.err = "<nil>"
+			}
+
+			err := src.LoadChangeset(context.Background(), tc.cs)
+			if have, want := fmt.Sprint(err), tc.err; have != want {
+				t.Errorf("error:\nhave: %q\nwant: %q", have, want)
+			}
+
+			if err != nil {
+				return
+			}
+
+			testutil.AssertGolden(
+				t,
+				"testdata/golden/"+tc.name,
+				update(tc.name),
+				tc.cs.Changeset.Metadata.(*azuredevops.PullRequest),
+			)
+		})
+	}
+}
+
+func TestAzureDevOpsSource_CreateChangeset(t *testing.T) {
+	testCases := []struct {
+		name   string
+		cs     *Changeset
+		err    string
+		exists bool
+	}{
+		{
+			name: "success",
+			cs: &Changeset{
+				Title:     "This is a test PR",
+				Body:      "This is the body of a test PR",
+				BaseRef:   "main",
+				HeadRef:   "test-pr-ado-2",
+				Repo:      azureDevOpsTestRepo,
+				Changeset: &btypes.Changeset{},
+			},
+		},
+		{
+			name: "already exists",
+			cs: &Changeset{
+				Title:     "This is a test PR",
+				Body:      "This is the body of a test PR",
+				BaseRef:   "refs/heads/main",
+				HeadRef:   "refs/heads/always-open-pr-ado",
+				Repo:      azureDevOpsTestRepo,
+				Changeset: &btypes.Changeset{},
+			},
+			// CreateChangeset is idempotent so if the PR already exists
+			// it is not an error
+			err:    "",
+			exists: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		tc.name = "AzureDevOpsSource_CreateChangeset_" + tc.name
+
+		t.Run(tc.name, func(t *testing.T) {
+			cf, save := newClientFactory(t, tc.name)
+			defer save(t)
+
+			src := newAzureDevOpsTestSource(t, cf)
+
+			if tc.err == "" {
+				tc.err = "<nil>"
+			}
+
+			exists, err := src.CreateChangeset(context.Background(), tc.cs)
+			if have, want := fmt.Sprint(err), tc.err; have != want {
+				t.Errorf("error:\nhave: %q\nwant: %q", have, want)
+			}
+
+			if err != nil {
+				return
+			}
+
+			if have, want := exists, tc.exists; have != want {
+				t.Errorf("exists:\nhave: %t\nwant: %t", have, want)
+			}
+
+			pr := tc.cs.Changeset.Metadata.(*azuredevops.PullRequest)
+			testutil.AssertGolden(t, "testdata/golden/"+tc.name, update(tc.name), pr)
+		})
+	}
+}
+
+func TestAzureDevOpsSource_CloseChangeset(t *testing.T) {
+	name := "AzureDevOpsSource_CloseChangeset_success"
+
+	cf, save := newClientFactory(t, name)
+	defer save(t)
+
+	src := newAzureDevOpsTestSource(t, cf)
+
+	cs := &Changeset{
+		Repo: azureDevOpsTestRepo,
+		Changeset: &btypes.Changeset{
+			Metadata: &azuredevops.PullRequest{PullRequestID: 4},
+		},
+	}
+
+	if err := src.CloseChangeset(context.Background(), cs); err != nil {
+		t.Fatal(err)
+	}
+
+	pr := cs.Changeset.Metadata.(*azuredevops.PullRequest)
+	if have, want := pr.Status, azuredevops.PullRequestStatusAbandoned; have != want {
+		t.Errorf("status:\nhave: %q\nwant: %q", have, want)
+	}
+	testutil.AssertGolden(t, "testdata/golden/"+name, update(name), pr)
+}
+
+func TestAzureDevOpsSource_CreateComment(t *testing.T) {
+	name := "AzureDevOpsSource_CreateComment_success"
+
+	cf, save := newClientFactory(t, name)
+	defer save(t)
+
+	src := newAzureDevOpsTestSource(t, cf)
+
+	cs := &Changeset{
+		Repo: azureDevOpsTestRepo,
+		Changeset: &btypes.Changeset{
+			Metadata: &azuredevops.PullRequest{PullRequestID: 2},
+		},
+	}
+
+	if err := src.CreateComment(context.Background(), cs, "test-comment"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestAzureDevOpsSource_MergeChangeset_Conflicts(t *testing.T) {
+	// Pull requests with conflicts are rejected before any request is made.
+	src := newAzureDevOpsTestSource(t, nil)
+
+	cs := &Changeset{
+		Repo: azureDevOpsTestRepo,
+		Changeset: &btypes.Changeset{
+			Metadata: &azuredevops.PullRequest{PullRequestID: 2, MergeStatus: "conflicts"},
+		},
+	}
+
+	err := src.MergeChangeset(context.Background(), cs, false)
+	if !errors.HasType(err, ChangesetNotMergeableError{}) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestAzureDevOpsSource_WithAuthenticator(t *testing.T) {
+	src := newAzureDevOpsTestSource(t, nil)
+
+	t.Run("supported", func(t *testing.T) {
+		for name, tc := range map[string]auth.Authenticator{
+			"BasicAuth":               &auth.BasicAuth{},
+			"BasicAuthWithSSH":        &auth.BasicAuthWithSSH{},
+			"OAuthBearerToken":        &auth.OAuthBearerToken{},
+			"OAuthBearerTokenWithSSH": &auth.OAuthBearerTokenWithSSH{},
+		} {
+			t.Run(name, func(t *testing.T) {
+				s, err := src.WithAuthenticator(tc)
+				if err != nil {
+					t.Errorf("unexpected non-nil error: %v", err)
+				}
+
+				if as, ok := s.(*AzureDevOpsSource); !ok {
+					t.Error("cannot coerce Source into AzureDevOpsSource")
+				} else if as == nil {
+					t.Error("unexpected nil Source")
+				} else if as.au != tc {
+					t.Errorf("incorrect authenticator: have=%v want=%v", as.au, tc)
+				}
+			})
+		}
+	})
+
+	t.Run("unsupported", func(t *testing.T) {
+		for name, tc := range map[string]auth.Authenticator{
+			"nil":         nil,
+			"OAuthClient": &auth.OAuthClient{},
+		} {
+			t.Run(name, func(t *testing.T) {
+				s, err := src.WithAuthenticator(tc)
+				if err == nil {
+					t.Error("unexpected nil error")
+				} else if !errors.HasType(err, UnsupportedAuthenticatorError{}) {
+					t.Errorf("unexpected error of type %T: %v", err, err)
+				}
+				if s != nil {
+					t.Errorf("expected nil Source: %v", s)
+				}
+			})
+		}
+	})
+}
+
+func newAzureDevOpsTestSource(t *testing.T, cf *httpcli.Factory) *AzureDevOpsSource {
+	t.Helper()
+
+	svc := &types.ExternalService{
+		Kind: extsvc.KindAzureDevOps,
+		Config: marshalJSON(t, &schema.AzureDevOpsConnection{
+			Url:   "https://dev.azure.com",
+			Token: os.Getenv("AZURE_DEVOPS_TOKEN"),
+			Orgs:  []string{"sgtest"},
+		}),
+	}
+
+	src, err := NewAzureDevOpsSource(svc, cf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return src
+}
@@ -0,0 +1,260 @@
+package sources
+
+import (
+	"context"
+	"net/url"
+	"strconv"
+
+	"github.com/cockroachdb/errors"
+
+	"github.com/sourcegraph/sourcegraph/internal/database"
+	"github.com/sourcegraph/sourcegraph/internal/extsvc"
+	"github.com/sourcegraph/sourcegraph/internal/extsvc/auth"
+	"github.com/sourcegraph/sourcegraph/internal/extsvc/bitbucketcloud"
+	"github.com/sourcegraph/sourcegraph/internal/gitserver/protocol"
+	"github.com/sourcegraph/sourcegraph/internal/httpcli"
+	"github.com/sourcegraph/sourcegraph/internal/jsonc"
+	"github.com/sourcegraph/sourcegraph/internal/types"
+	"github.com/sourcegraph/sourcegraph/internal/vcs/git"
+	"github.com/sourcegraph/sourcegraph/schema"
+)
+
+type BitbucketCloudSource struct {
+	client *bitbucketcloud.Client
+	au     auth.Authenticator
+}
+
+var _ ChangesetSource = BitbucketCloudSource{}
+
+// NewBitbucketCloudSource returns a new BitbucketCloudSource from the given external service.
+func NewBitbucketCloudSource(svc *types.ExternalService, cf *httpcli.Factory) (*BitbucketCloudSource, error) {
+	var c schema.BitbucketCloudConnection
+	if err := jsonc.Unmarshal(svc.Config, &c); err != nil {
+		return nil, errors.Errorf("external service id=%d config error: %s", svc.ID, err)
+	}
+	return newBitbucketCloudSource(&c, cf, nil)
+}
+
+func newBitbucketCloudSource(c *schema.BitbucketCloudConnection, cf *httpcli.Factory, au auth.Authenticator) (*BitbucketCloudSource, error) {
+	if c.ApiURL == "" {
+		c.ApiURL = "https://api.bitbucket.org"
+	}
+	apiURL, err := url.Parse(c.ApiURL)
+	if err != nil {
+		return nil, err
+	}
+	apiURL = extsvc.NormalizeBaseURL(apiURL)
+
+	if cf == nil {
+		cf = httpcli.NewExternalHTTPClientFactory()
+	}
+
+	cli, err := cf.Doer()
+	if err != nil {
+		return nil, err
+	}
+
+	if au == nil {
+		au = &auth.BasicAuth{Username: c.Username, Password: c.AppPassword}
+	}
+
+	return &BitbucketCloudSource{
+		client: bitbucketcloud.NewClient(apiURL, cli).WithAuthenticator(au),
+		au:     au,
+	}, nil
+}
+
+func (s BitbucketCloudSource) GitserverPushConfig(ctx context.Context, store *database.ExternalServiceStore, repo *types.Repo) (*protocol.PushConfig, error) {
+	return gitserverPushConfig(ctx, store, repo, s.au)
+}
+
+func (s BitbucketCloudSource) WithAuthenticator(a auth.Authenticator) (ChangesetSource, error) {
+	switch a.(type) {
+	case *auth.OAuthBearerToken,
+		*auth.OAuthBearerTokenWithSSH,
+		*auth.BasicAuth,
+		*auth.BasicAuthWithSSH:
+		break
+
+	default:
+		return nil, newUnsupportedAuthenticatorError("BitbucketCloudSource", a)
+	}
+
+	return &BitbucketCloudSource{
+		client: s.client.WithAuthenticator(a),
+		au:     a,
+	}, nil
+}
+
+func (s BitbucketCloudSource) ValidateAuthenticator(ctx context.Context) error {
+	_, err := s.client.CurrentUser(ctx)
+	return err
+}
+
+// CreateChangeset creates the given *Changeset in the code host.
+func (s BitbucketCloudSource) CreateChangeset(ctx context.Context, c *Changeset) (bool, error) {
+	repo := c.Repo.Metadata.(*bitbucketcloud.Repo)
+	return s.createPullRequest(ctx, c, repo, s.pullRequestInput(c))
+}
+
+// createPullRequest opens a pull request for input, unless there already is
+// an open one for its branches, and sets it as the metadata of c.
+func (s BitbucketCloudSource) createPullRequest(ctx context.Context, c *Changeset, repo *bitbucketcloud.Repo, input *bitbucketcloud.PullRequestInput) (bool, error) {
+	// Look for an open pull request for the branches first, so that we never
+	// open a second one.
+	exists := true
+	pr, err := s.client.FindOpenPullRequest(ctx, repo, input.SourceBranch, input.DestinationBranch)
+	if err == bitbucketcloud.ErrPullRequestNotFound {
+		exists = false
+		pr, err = s.client.CreatePullRequest(ctx, repo, input)
+		if err != nil {
+			return exists, errors.Wrap(err, "creating the pull request")
+		}
+	} else if err != nil {
+		return exists, errors.Wrap(err, "retrieving an extant pull request")
+	}
+
+	if err := s.setPullRequest(ctx, c, repo, pr); err != nil {
+		return exists, err
+	}
+	return exists, nil
+}
+
+// CloseChangeset declines the given *Changeset on the code host and updates
+// the Metadata column in the *batches.Changeset to the declined pull request.
+func (s BitbucketCloudSource) CloseChangeset(ctx context.Context, c *Changeset) error {
+	pr, ok := c.Changeset.Metadata.(*bitbucketcloud.PullRequest)
+	if !ok {
+		return errors.New("Changeset is not a Bitbucket Cloud pull request")
+	}
+	repo := c.Repo.Metadata.(*bitbucketcloud.Repo)
+
+	declined, err := s.client.DeclinePullRequest(ctx, repo, pr.ID)
+	if err != nil {
+		return errors.Wrap(err, "declining Bitbucket Cloud pull request")
+	}
+	return s.setPullRequest(ctx, c, repo, declined)
+}
+
+// LoadChangeset loads the latest state of the given Changeset from the codehost.
+func (s BitbucketCloudSource) LoadChangeset(ctx context.Context, cs *Changeset) error {
+	repo := cs.Repo.Metadata.(*bitbucketcloud.Repo)
+	id, err := strconv.ParseInt(cs.ExternalID, 10, 64)
+	if err != nil {
+		return errors.Wrapf(err, "parsing changeset external id %s", cs.ExternalID)
+	}
+
+	pr, err := s.client.GetPullRequest(ctx, repo, id)
+	if err != nil {
+		if err == bitbucketcloud.ErrPullRequestNotFound {
+			return ChangesetNotFoundError{Changeset: cs}
+		}
+		return errors.Wrapf(err, "retrieving pull request %d", id)
+	}
+	return s.setPullRequest(ctx, cs, repo, pr)
+}
+
+// UpdateChangeset updates the pull request on Bitbucket Cloud to reflect the
+// local state of the Changeset.
+func (s BitbucketCloudSource) UpdateChangeset(ctx context.Context, c *Changeset) error {
+	pr, ok := c.Changeset.Metadata.(*bitbucketcloud.PullRequest)
+	if !ok {
+		return errors.New("Changeset is not a Bitbucket Cloud pull request")
+	}
+	repo := c.Repo.Metadata.(*bitbucketcloud.Repo)
+
+	updated, err := s.client.UpdatePullRequest(ctx, repo, pr.ID, s.pullRequestInput(c))
+	if err != nil {
+		return errors.Wrap(err, "updating Bitbucket Cloud pull request")
+	}
+	return s.setPullRequest(ctx, c, repo, updated)
+}
+
+// ReopenChangeset opens a new pull request for the declined pull request of
+// the *Changeset, since Bitbucket Cloud can't reopen declined pull requests.
+// The new pull request is a copy of the declined one, since reopening only
+// passes the changeset itself, not its spec. The Metadata column in the
+// *batches.Changeset is updated to the new pull request.
+func (s BitbucketCloudSource) ReopenChangeset(ctx context.Context, c *Changeset) error {
+	pr, ok := c.Changeset.Metadata.(*bitbucketcloud.PullRequest)
+	if !ok {
+		return errors.New("Changeset is not a Bitbucket Cloud pull request")
+	}
+	if pr.State == bitbucketcloud.PullRequestStateOpen {
+		return nil
+	}
+	repo := c.Repo.Metadata.(*bitbucketcloud.Repo)
+
+	_, err := s.createPullRequest(ctx, c, repo, &bitbucketcloud.PullRequestInput{
+		Title:             pr.Title,
+		Description:       pr.Description,
+		SourceBranch:      pr.Source.Branch.Name,
+		DestinationBranch: pr.Destination.Branch.Name,
+	})
+	return err
+}
+
+// CreateComment posts a comment on the Changeset.
+func (s BitbucketCloudSource) CreateComment(ctx context.Context, c *Changeset, text string) error {
+	pr, ok := c.Changeset.Metadata.(*bitbucketcloud.PullRequest)
+	if !ok {
+		return errors.New("Changeset is not a Bitbucket Cloud pull request")
+	}
+	repo := c.Repo.Metadata.(*bitbucketcloud.Repo)
+
+	return s.client.CreatePullRequestComment(ctx, repo, pr.ID, text)
+}
+
+// MergeChangeset merges the pull request of the Changeset on the code host,
+// if in a mergeable state. If squash is true, the commits of the pull request
+// are squashed.
+func (s BitbucketCloudSource) MergeChangeset(ctx context.Context, c *Changeset, squash bool) error {
+	pr, ok := c.Changeset.Metadata.(*bitbucketcloud.PullRequest)
+	if !ok {
+		return errors.New("Changeset is not a Bitbucket Cloud pull request")
+	}
+	repo := c.Repo.Metadata.(*bitbucketcloud.Repo)
+
+	strategy := bitbucketcloud.MergeStrategyMergeCommit
+	if squash {
+		strategy = bitbucketcloud.MergeStrategySquash
+	}
+	if err := s.client.MergePullRequest(ctx, repo, pr.ID, strategy); err != nil {
+		if errors.Is(err, bitbucketcloud.ErrNotMergeable) {
+			return ChangesetNotMergeableError{ErrorMsg: err.Error()}
+		}
+		return errors.Wrap(err, "merging Bitbucket Cloud pull request")
+	}
+
+	// Large merges are completed asynchronously, so the response to the merge
+	// doesn't always contain the merged pull request.
+	merged, err := s.client.GetPullRequest(ctx, repo, pr.ID)
+	if err != nil {
+		return errors.Wrap(err, "retrieving merged pull request")
+	}
+	return s.setPullRequest(ctx, c, repo, merged)
+}
+
+func (s BitbucketCloudSource) pullRequestInput(c *Changeset) *bitbucketcloud.PullRequestInput {
+	return &bitbucketcloud.PullRequestInput{
+		Title:             c.Title,
+		Description:       c.Body,
+		SourceBranch:      git.AbbreviateRef(c.HeadRef),
+		DestinationBranch: git.AbbreviateRef(c.BaseRef),
+	}
+}
+
+// setPullRequest loads the build statuses of pr, which aren't returned with
+// the pull request itself, and sets it as the metadata of c.
+func (s BitbucketCloudSource) setPullRequest(ctx context.Context, c *Changeset, repo *bitbucketcloud.Repo, pr *bitbucketcloud.PullRequest) error {
+	statuses, err := s.client.PullRequestStatuses(ctx, repo, pr.ID)
+	if err != nil {
+		return errors.Wrap(err, "loading pull request statuses")
+	}
+	pr.Statuses = statuses
+
+	if err := c.Changeset.SetMetadata(pr); err != nil {
+		return errors.Wrap(err, "setting changeset metadata")
+	}
+	return nil
+}
@@ -0,0 +1,422 @@
+package sources
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/cockroachdb/errors"
+
+	btypes "github.com/sourcegraph/sourcegraph/enterprise/internal/batches/types"
+	"github.com/sourcegraph/sourcegraph/internal/extsvc"
+	"github.com/sourcegraph/sourcegraph/internal/extsvc/auth"
+	"github.com/sourcegraph/sourcegraph/internal/extsvc/bitbucketcloud"
+	"github.com/sourcegraph/sourcegraph/internal/httpcli"
+	"github.com/sourcegraph/sourcegraph/internal/types"
+	"github.com/sourcegraph/sourcegraph/schema"
+)
+
+// The test fixtures were generated against the sourcegraph-testing workspace
+// on bitbucket.org.
+var bitbucketCloudTestRepo = &types.Repo{
+	Metadata: &bitbucketcloud.Repo{
+		Slug:     "src-cli",
+		Name:     "src-cli",
+		FullName: "sourcegraph-testing/src-cli",
+	},
+}
+
+func TestBitbucketCloudSource_LoadChangeset(t *testing.T) {
+	testCases := []struct {
+		name string
+		cs   *Changeset
+		err  string
+	}{
+		{
+			name: "found",
+			cs:   &Changeset{Repo: bitbucketCloudTestRepo, Changeset: &btypes.Changeset{ExternalID: "1"}},
+		},
+		{
+			name: "not-found",
+			cs:   &Changeset{Repo: bitbucketCloudTestRepo, Changeset: &btypes.Changeset{ExternalID: "999"}},
+			err:  `Changeset with external ID 999 not found`,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		tc.name = "BitbucketCloudSource_LoadChangeset_" + tc.name
+
+		t.Run(tc.name, func(t *testing.T) {
+			cf, save := newClientFactory(t, tc.name)
+			defer save(t)
+
+			src := newBitbucketCloudTestSource(t, cf)
+
+			if tc.err == "" {
+				tc.err = "<nil>"
+			}
+
+			err := src.LoadChangeset(context.Background(), tc.cs)
+			if have, want := fmt.Sprint(err), tc.err; have != want {
+				t.Errorf("error:\nhave: %q\nwant: %q", have, want)
+			}
+
+			if err != nil {
+				return
+			}
+
+			pr := tc.cs.Changeset.Metadata.(*bitbucketcloud.PullRequest)
+			if have, want := pr.State, bitbucketcloud.PullRequestStateOpen; have != want {
+				t.Errorf("state:\nhave: %q\nwant: %q", have, want)
+			}
+			if have, want := len(pr.Statuses), 1; have != want {
+				t.Fatalf("statuses:\nhave: %d\nwant: %d", have, want)
+			}
+			if have, want := pr.Statuses[0].State, bitbucketcloud.CommitStatusStateSuccessful; have != want {
+				t.Errorf("status state:\nhave: %q\nwant: %q", have, want)
+			}
+			if have, want := tc.cs.ExternalBranch, "refs/heads/always-open-pr-bbc"; have != want {
+				t.Errorf("external branch:\nhave: %q\nwant: %q", have, want)
+			}
+		})
+	}
+}
+
+func TestBitbucketCloudSource_CreateChangeset(t *testing.T) {
+	testCases := []struct {
+		name   string
+		cs     *Changeset
+		err    string
+		exists bool
+		wantID string
+	}{
+		{
+			name: "success",
+			cs: &Changeset{
+				Title:     "This is a test PR",
+				Body:      "This is the body of a test PR",
+				BaseRef:   "refs/heads/main",
+				HeadRef:   "refs/heads/test-pr-bbc-2",
+				Repo:      bitbucketCloudTestRepo,
+				Changeset: &btypes.Changeset{},
+			},
+			wantID: "2",
+		},
+		{
+			name: "already exists",
+			cs: &Changeset{
+				Title:     "This is a test PR",
+				Body:      "This is the body of a test PR",
+				BaseRef:   "main",
+				HeadRef:   "always-open-pr-bbc",
+				Repo:      bitbucketCloudTestRepo,
+				Changeset: &btypes.Changeset{},
+			},
+			// CreateChangeset is idempotent so if the PR already exists
+			// it is not an error
+			err:    "",
+			exists: true,
+			wantID: "1",
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		tc.name = "BitbucketCloudSource_CreateChangeset_" + tc.name
+
+		t.Run(tc.name, func(t *testing.T) {
+			cf, save := newClientFactory(t, tc.name)
+			defer save(t)
+
+			src := newBitbucketCloudTestSource(t, cf)
+
+			if tc.err == "" {
+				tc.err = "<nil>"
+			}
+
+			exists, err := src.CreateChangeset(context.Background(), tc.cs)
+			if have, want := fmt.Sprint(err), tc.err; have != want {
+				t.Errorf("error:\nhave: %q\nwant: %q", have, want)
+			}
+
+			if err != nil {
+				return
+			}
+
+			if have, want := exists, tc.exists; have != want {
+				t.Errorf("exists:\nhave: %t\nwant: %t", have, want)
+			}
+			if have, want := tc.cs.ExternalID, tc.wantID; have != want {
+				t.Errorf("external ID:\nhave: %q\nwant: %q", have, want)
+			}
+			if have, want := tc.cs.ExternalServiceType, extsvc.TypeBitbucketCloud; have != want {
+				t.Errorf("external service type:\nhave: %q\nwant: %q", have, want)
+			}
+		})
+	}
+}
+
+func TestBitbucketCloudSource_UpdateChangeset(t *testing.T) {
+	name := "BitbucketCloudSource_UpdateChangeset_success"
+
+	cf, save := newClientFactory(t, name)
+	defer save(t)
+
+	src := newBitbucketCloudTestSource(t, cf)
+
+	cs := &Changeset{
+		Title:   "This is a new title",
+		Body:    "This is a new body",
+		BaseRef: "refs/heads/main",
+		HeadRef: "refs/heads/always-open-pr-bbc",
+		Repo:    bitbucketCloudTestRepo,
+		Changeset: &btypes.Changeset{
+			Metadata: &bitbucketcloud.PullRequest{ID: 1, State: bitbucketcloud.PullRequestStateOpen},
+		},
+	}
+
+	if err := src.UpdateChangeset(context.Background(), cs); err != nil {
+		t.Fatal(err)
+	}
+
+	pr := cs.Changeset.Metadata.(*bitbucketcloud.PullRequest)
+	if have, want := pr.Title, "This is a new title"; have != want {
+		t.Errorf("title:\nhave: %q\nwant: %q", have, want)
+	}
+	if have, want := pr.Description, "This is a new body"; have != want {
+		t.Errorf("description:\nhave: %q\nwant: %q", have, want)
+	}
+}
+
+func TestBitbucketCloudSource_CloseChangeset(t *testing.T) {
+	name := "BitbucketCloudSource_CloseChangeset_success"
+
+	cf, save := newClientFactory(t, name)
+	defer save(t)
+
+	src := newBitbucketCloudTestSource(t, cf)
+
+	cs := &Changeset{
+		Repo: bitbucketCloudTestRepo,
+		Changeset: &btypes.Changeset{
+			Metadata: &bitbucketcloud.PullRequest{ID: 3, State: bitbucketcloud.PullRequestStateOpen},
+		},
+	}
+
+	if err := src.CloseChangeset(context.Background(), cs); err != nil {
+		t.Fatal(err)
+	}
+
+	pr := cs.Changeset.Metadata.(*bitbucketcloud.PullRequest)
+	if have, want := pr.State, bitbucketcloud.PullRequestStateDeclined; have != want {
+		t.Errorf("state:\nhave: %q\nwant: %q", have, want)
+	}
+}
+
+func TestBitbucketCloudSource_ReopenChangeset(t *testing.T) {
+	t.Run("declined", func(t *testing.T) {
+		name := "BitbucketCloudSource_ReopenChangeset_success"
+
+		cf, save := newClientFactory(t, name)
+		defer save(t)
+
+		src := newBitbucketCloudTestSource(t, cf)
+
+		// The reconciler only passes the repo and the changeset when it
+		// reopens a changeset, so the new pull request has to be built from
+		// the declined one.
+		cs := &Changeset{
+			Repo: bitbucketCloudTestRepo,
+			Changeset: &btypes.Changeset{
+				ExternalID: "3",
+				Metadata: &bitbucketcloud.PullRequest{
+					ID:          3,
+					Title:       "This is a test PR",
+					Description: "This is the body of a test PR",
+					State:       bitbucketcloud.PullRequestStateDeclined,
+					Source:      bitbucketcloud.PullRequestEndpoint{Branch: bitbucketcloud.PullRequestBranch{Name: "test-pr-bbc-3"}},
+					Destination: bitbucketcloud.PullRequestEndpoint{Branch: bitbucketcloud.PullRequestBranch{Name: "main"}},
+				},
+			},
+		}
+
+		if err := src.ReopenChangeset(context.Background(), cs); err != nil {
+			t.Fatal(err)
+		}
+
+		// Declined pull requests can't be reopened, so a new one is opened,
+		// which changes the external ID of the changeset.
+		pr := cs.Changeset.Metadata.(*bitbucketcloud.PullRequest)
+		if have, want := pr.State, bitbucketcloud.PullRequestStateOpen; have != want {
+			t.Errorf("state:\nhave: %q\nwant: %q", have, want)
+		}
+		if have, want := cs.ExternalID, "4"; have != want {
+			t.Errorf("external ID:\nhave: %q\nwant: %q", have, want)
+		}
+		if have, want := pr.Title, "This is a test PR"; have != want {
+			t.Errorf("title:\nhave: %q\nwant: %q", have, want)
+		}
+		if have, want := pr.Source.Branch.Name, "test-pr-bbc-3"; have != want {
+			t.Errorf("source branch:\nhave: %q\nwant: %q", have, want)
+		}
+		if have, want := pr.Destination.Branch.Name, "main"; have != want {
+			t.Errorf("destination branch:\nhave: %q\nwant: %q", have, want)
+		}
+	})
+
+	t.Run("open", func(t *testing.T) {
+		// Open pull requests are left alone without making any requests.
+		src := newBitbucketCloudTestSource(t, nil)
+
+		cs := &Changeset{
+			Repo: bitbucketCloudTestRepo,
+			Changeset: &btypes.Changeset{
+				ExternalID: "1",
+				Metadata:   &bitbucketcloud.PullRequest{ID: 1, State: bitbucketcloud.PullRequestStateOpen},
+			},
+		}
+
+		if err := src.ReopenChangeset(context.Background(), cs); err != nil {
+			t.Fatal(err)
+		}
+		if have, want := cs.ExternalID, "1"; have != want {
+			t.Errorf("external ID:\nhave: %q\nwant: %q", have, want)
+		}
+	})
+}
+
+func TestBitbucketCloudSource_CreateComment(t *testing.T) {
+	name := "BitbucketCloudSource_CreateComment_success"
+
+	cf, save := newClientFactory(t, name)
+	defer save(t)
+
+	src := newBitbucketCloudTestSource(t, cf)
+
+	cs := &Changeset{
+		Repo: bitbucketCloudTestRepo,
+		Changeset: &btypes.Changeset{
+			Metadata: &bitbucketcloud.PullRequest{ID: 1},
+		},
+	}
+
+	if err := src.CreateComment(context.Background(), cs, "test-comment"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestBitbucketCloudSource_MergeChangeset(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		name := "BitbucketCloudSource_MergeChangeset_success"
+
+		cf, save := newClientFactory(t, name)
+		defer save(t)
+
+		src := newBitbucketCloudTestSource(t, cf)
+
+		cs := &Changeset{
+			Repo: bitbucketCloudTestRepo,
+			Changeset: &btypes.Changeset{
+				Metadata: &bitbucketcloud.PullRequest{ID: 5, State: bitbucketcloud.PullRequestStateOpen},
+			},
+		}
+
+		if err := src.MergeChangeset(context.Background(), cs, false); err != nil {
+			t.Fatal(err)
+		}
+
+		pr := cs.Changeset.Metadata.(*bitbucketcloud.PullRequest)
+		if have, want := pr.State, bitbucketcloud.PullRequestStateMerged; have != want {
+			t.Errorf("state:\nhave: %q\nwant: %q", have, want)
+		}
+	})
+
+	t.Run("not mergeable", func(t *testing.T) {
+		name := "BitbucketCloudSource_MergeChangeset_not-mergeable"
+
+		cf, save := newClientFactory(t, name)
+		defer save(t)
+
+		src := newBitbucketCloudTestSource(t, cf)
+
+		cs := &Changeset{
+			Repo: bitbucketCloudTestRepo,
+			Changeset: &btypes.Changeset{
+				Metadata: &bitbucketcloud.PullRequest{ID: 6, State: bitbucketcloud.PullRequestStateOpen},
+			},
+		}
+
+		err := src.MergeChangeset(context.Background(), cs, false)
+		if !errors.HasType(err, ChangesetNotMergeableError{}) {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+}
+
+func TestBitbucketCloudSource_WithAuthenticator(t *testing.T) {
+	src := newBitbucketCloudTestSource(t, nil)
+
+	t.Run("supported", func(t *testing.T) {
+		for name, tc := range map[string]auth.Authenticator{
+			"BasicAuth":               &auth.BasicAuth{},
+			"BasicAuthWithSSH":        &auth.BasicAuthWithSSH{},
+			"OAuthBearerToken":        &auth.OAuthBearerToken{},
+			"OAuthBearerTokenWithSSH": &auth.OAuthBearerTokenWithSSH{},
+		} {
+			t.Run(name, func(t *testing.T) {
+				s, err := src.WithAuthenticator(tc)
+				if err != nil {
+					t.Errorf("unexpected non-nil error: %v", err)
+				}
+
+				if bs, ok := s.(*BitbucketCloudSource); !ok {
+					t.Error("cannot coerce Source into BitbucketCloudSource")
+				} else if bs == nil {
+					t.Error("unexpected nil Source")
+				} else if bs.au != tc {
+					t.Errorf("incorrect authenticator: have=%v want=%v", bs.au, tc)
+				}
+			})
+		}
+	})
+
+	t.Run("unsupported", func(t *testing.T) {
+		for name, tc := range map[string]auth.Authenticator{
+			"nil":         nil,
+			"OAuthClient": &auth.OAuthClient{},
+		} {
+			t.Run(name, func(t *testing.T) {
+				s, err := src.WithAuthenticator(tc)
+				if err == nil {
+					t.Error("unexpected nil error")
+				} else if !errors.HasType(err, UnsupportedAuthenticatorError{}) {
+					t.Errorf("unexpected error of type %T: %v", err, err)
+				}
+				if s != nil {
+					t.Errorf("expected nil Source: %v", s)
+				}
+			})
+		}
+	})
+}
+
+func newBitbucketCloudTestSource(t *testing.T, cf *httpcli.Factory) *BitbucketCloudSource {
+	t.Helper()
+
+	svc := &types.ExternalService{
+		Kind: extsvc.KindBitbucketCloud,
+		Config: marshalJSON(t, &schema.BitbucketCloudConnection{
+			Url:         "https://bitbucket.org",
+			Username:    "sourcegraph-test",
+			AppPassword: os.Getenv("BITBUCKET_CLOUD_APP_PASSWORD"),
+		}),
+	}
+
+	src, err := NewBitbucketCloudSource(svc, cf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return src
+}
@@ -64,7 +64,9 @@ type ChangesetSource interface {
 	// UpdateChangeset can update Changesets.
 	UpdateChangeset(context.Context, *Changeset) error
 	// ReopenChangeset will reopen the Changeset on the source, if it's closed.
-	// If not, it's a noop.
+	// If not, it's a noop. Code hosts that can't reopen closed changesets,
+	// such as Bitbucket Cloud, open a new one instead, which changes the
+	// ExternalID of the Changeset.
 	ReopenChangeset(context.Context, *Changeset) error
 	// CreateComment posts a comment on the Changeset.
 	CreateComment(context.Context, *Changeset, string) error
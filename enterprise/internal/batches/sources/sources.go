@@ -265,6 +265,18 @@ func loadExternalService(ctx context.Context, s *database.ExternalServiceStore,
 			if cfg.Token != "" {
 				return e, nil
 			}
+		case *schema.AzureDevOpsConnection:
+			if cfg.Token != "" {
+				return e, nil
+			}
+		case *schema.BitbucketCloudConnection:
+			if cfg.AppPassword != "" {
+				return e, nil
+			}
+		case *schema.AWSCodeCommitConnection:
+			if cfg.SecretAccessKey != "" {
+				return e, nil
+			}
 		}
 	}
 
@@ -282,6 +294,12 @@ func buildChangesetSource(store SourcerStore, cf *httpcli.Factory, externalServi
 		return NewGitLabSource(externalService, cf)
 	case extsvc.KindBitbucketServer:
 		return NewBitbucketServerSource(externalService, cf)
+	case extsvc.KindAzureDevOps:
+		return NewAzureDevOpsSource(externalService, cf)
+	case extsvc.KindBitbucketCloud:
+		return NewBitbucketCloudSource(externalService, cf)
+	case extsvc.KindAWSCodeCommit:
+		return NewAWSCodeCommitSource(externalService, cf)
 	default:
 		return nil, errors.Errorf("unsupported external service type %q", extsvc.KindToType(externalService.Kind))
 	}
@@ -334,6 +352,17 @@ func setOAuthTokenAuth(u *vcs.URL, extSvcType, token string) error {
 	case extsvc.TypeBitbucketServer:
 		return errors.New("require username/token to push commits to BitbucketServer")
 
+	case extsvc.TypeAzureDevOps:
+		// Azure DevOps ignores the username when authenticating with a
+		// personal access token, but Git requires one.
+		u.User = url.UserPassword("git", token)
+
+	case extsvc.TypeBitbucketCloud:
+		u.User = url.UserPassword("x-token-auth", token)
+
+	case extsvc.TypeAWSCodeCommit:
+		return errors.New("require Git credentials to push commits to AWS CodeCommit")
+
 	default:
 		panic(fmt.Sprintf("setOAuthTokenAuth: invalid external service type %q", extSvcType))
 	}
@@ -347,7 +376,7 @@ func setBasicAuth(u *vcs.URL, extSvcType, username, password string) error {
 	case extsvc.TypeGitHub, extsvc.TypeGitLab:
 		return errors.New("need token to push commits to " + extSvcType)
 
-	case extsvc.TypeBitbucketServer:
+	case extsvc.TypeBitbucketServer, extsvc.TypeAzureDevOps, extsvc.TypeBitbucketCloud, extsvc.TypeAWSCodeCommit:
 		u.User = url.UserPassword(username, password)
 
 	default:
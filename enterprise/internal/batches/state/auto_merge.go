@@ -0,0 +1,127 @@
+package state
+
+import (
+	"sort"
+	"time"
+
+	btypes "github.com/sourcegraph/sourcegraph/enterprise/internal/batches/types"
+	"github.com/sourcegraph/sourcegraph/internal/extsvc/awscodecommit"
+	"github.com/sourcegraph/sourcegraph/internal/extsvc/azuredevops"
+	"github.com/sourcegraph/sourcegraph/internal/extsvc/bitbucketcloud"
+	"github.com/sourcegraph/sourcegraph/internal/extsvc/bitbucketserver"
+	"github.com/sourcegraph/sourcegraph/internal/extsvc/github"
+	"github.com/sourcegraph/sourcegraph/internal/extsvc/gitlab"
+)
+
+// CanAutoMerge reports whether the changeset satisfies the given auto-merge
+// policy at the given time: it has to be open, have passing checks if the
+// policy requires them, have no reviews requesting changes, have enough
+// approvals and one of the merge windows of the policy has to be open.
+//
+// The derived state of the changeset is expected to be up to date with the
+// given events.
+func CanAutoMerge(p *btypes.AutoMergePolicy, c *btypes.Changeset, events []*btypes.ChangesetEvent, now time.Time) (bool, error) {
+	if p == nil {
+		return false, nil
+	}
+
+	if !c.Published() || c.ExternalState != btypes.ChangesetExternalStateOpen {
+		return false, nil
+	}
+
+	if p.ChecksRequired() && c.ExternalCheckState != btypes.ChangesetCheckStatePassed {
+		return false, nil
+	}
+
+	if c.ExternalReviewState == btypes.ChangesetReviewStateChangesRequested {
+		return false, nil
+	}
+
+	if ComputeApprovals(c, events) < p.RequiredApprovals() {
+		return false, nil
+	}
+
+	windows, err := p.MergeWindows()
+	if err != nil {
+		return false, err
+	}
+	return windows.IsOpen(now.UTC()), nil
+}
+
+// ComputeApprovals returns the number of distinct users whose latest review of
+// the changeset is an approval.
+func ComputeApprovals(c *btypes.Changeset, es []*btypes.ChangesetEvent) int {
+	approvals := 0
+
+	switch m := c.Metadata.(type) {
+	case *github.PullRequest, *gitlab.MergeRequest:
+		// GitHub and GitLab don't return the reviews with the pull request, so
+		// we replay the review events to get the latest review per author.
+		events := make(ChangesetEvents, len(es))
+		copy(events, es)
+		sort.Sort(events)
+
+		lastReviewByAuthor := map[string]btypes.ChangesetReviewState{}
+		for _, e := range events {
+			switch e.Kind {
+			case btypes.ChangesetEventKindGitHubReviewed,
+				btypes.ChangesetEventKindGitHubReviewDismissed,
+				btypes.ChangesetEventKindGitLabApproved,
+				btypes.ChangesetEventKindGitLabUnapproved:
+			default:
+				continue
+			}
+
+			author := e.ReviewAuthor()
+			if author == "" {
+				continue
+			}
+
+			s, err := e.ReviewState()
+			if err != nil {
+				continue
+			}
+
+			switch s {
+			case btypes.ChangesetReviewStateApproved,
+				btypes.ChangesetReviewStateChangesRequested:
+				lastReviewByAuthor[author] = s
+			case btypes.ChangesetReviewStateDismissed:
+				delete(lastReviewByAuthor, author)
+			}
+		}
+
+		for _, s := range lastReviewByAuthor {
+			if s == btypes.ChangesetReviewStateApproved {
+				approvals++
+			}
+		}
+
+	case *bitbucketserver.PullRequest:
+		for _, r := range m.Reviewers {
+			if r.Status == "APPROVED" {
+				approvals++
+			}
+		}
+
+	case *azuredevops.PullRequest:
+		for _, r := range m.Reviewers {
+			switch r.Vote {
+			case azuredevops.VoteApproved, azuredevops.VoteApprovedWithSuggestion:
+				approvals++
+			}
+		}
+
+	case *bitbucketcloud.PullRequest:
+		for _, p := range m.Participants {
+			if p.Approved || p.State == bitbucketcloud.ParticipantStateApproved {
+				approvals++
+			}
+		}
+
+	case *awscodecommit.PullRequest:
+		approvals = len(m.ApprovedBy)
+	}
+
+	return approvals
+}
@@ -0,0 +1,203 @@
+package state
+
+import (
+	"testing"
+	"time"
+
+	btypes "github.com/sourcegraph/sourcegraph/enterprise/internal/batches/types"
+	"github.com/sourcegraph/sourcegraph/internal/extsvc/awscodecommit"
+	"github.com/sourcegraph/sourcegraph/internal/extsvc/bitbucketserver"
+	"github.com/sourcegraph/sourcegraph/internal/extsvc/github"
+)
+
+func TestComputeApprovals(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2021, 8, 11, 12, 0, 0, 0, time.UTC)
+	review := func(minutes int, login, state string) *btypes.ChangesetEvent {
+		return &btypes.ChangesetEvent{
+			Kind: btypes.ChangesetEventKindGitHubReviewed,
+			Metadata: &github.PullRequestReview{
+				Author:    github.Actor{Login: login},
+				State:     state,
+				UpdatedAt: now.Add(time.Duration(minutes) * time.Minute),
+			},
+		}
+	}
+
+	tests := []struct {
+		name      string
+		changeset *btypes.Changeset
+		events    []*btypes.ChangesetEvent
+		want      int
+	}{
+		{
+			name:      "github no reviews",
+			changeset: &btypes.Changeset{Metadata: &github.PullRequest{}},
+			want:      0,
+		},
+		{
+			name:      "github distinct approvals",
+			changeset: &btypes.Changeset{Metadata: &github.PullRequest{}},
+			events: []*btypes.ChangesetEvent{
+				review(1, "alice", "APPROVED"),
+				review(2, "bob", "APPROVED"),
+				review(3, "alice", "APPROVED"),
+			},
+			want: 2,
+		},
+		{
+			name:      "github latest review counts",
+			changeset: &btypes.Changeset{Metadata: &github.PullRequest{}},
+			events: []*btypes.ChangesetEvent{
+				review(2, "alice", "CHANGES_REQUESTED"),
+				review(1, "alice", "APPROVED"),
+				review(3, "bob", "APPROVED"),
+				review(4, "bob", "DISMISSED"),
+			},
+			want: 0,
+		},
+		{
+			name: "bitbucketserver",
+			changeset: &btypes.Changeset{Metadata: &bitbucketserver.PullRequest{
+				Reviewers: []bitbucketserver.Reviewer{
+					{Status: "APPROVED"},
+					{Status: "NEEDS_WORK"},
+					{Status: "APPROVED"},
+				},
+			}},
+			want: 2,
+		},
+		{
+			name: "awscodecommit",
+			changeset: &btypes.Changeset{Metadata: &awscodecommit.PullRequest{
+				ApprovedBy: []string{"arn:aws:iam::123456789012:user/alice"},
+			}},
+			want: 1,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if have := ComputeApprovals(tc.changeset, tc.events); have != tc.want {
+				t.Errorf("unexpected approvals: have=%d want=%d", have, tc.want)
+			}
+		})
+	}
+}
+
+func TestCanAutoMerge(t *testing.T) {
+	t.Parallel()
+
+	// 2021-08-11 is a Wednesday.
+	now := time.Date(2021, 8, 11, 12, 0, 0, 0, time.UTC)
+	intPtr := func(i int) *int { return &i }
+	boolPtr := func(b bool) *bool { return &b }
+
+	approved := &bitbucketserver.PullRequest{
+		Reviewers: []bitbucketserver.Reviewer{{Status: "APPROVED"}},
+	}
+	mergeable := func() *btypes.Changeset {
+		return &btypes.Changeset{
+			PublicationState:    btypes.ChangesetPublicationStatePublished,
+			ExternalState:       btypes.ChangesetExternalStateOpen,
+			ExternalCheckState:  btypes.ChangesetCheckStatePassed,
+			ExternalReviewState: btypes.ChangesetReviewStateApproved,
+			Metadata:            approved,
+		}
+	}
+
+	tests := []struct {
+		name      string
+		policy    *btypes.AutoMergePolicy
+		changeset func(c *btypes.Changeset)
+		want      bool
+	}{
+		{
+			name:   "no policy",
+			policy: nil,
+			want:   false,
+		},
+		{
+			name:   "default policy",
+			policy: &btypes.AutoMergePolicy{},
+			want:   true,
+		},
+		{
+			name:   "unpublished",
+			policy: &btypes.AutoMergePolicy{},
+			changeset: func(c *btypes.Changeset) {
+				c.PublicationState = btypes.ChangesetPublicationStateUnpublished
+			},
+			want: false,
+		},
+		{
+			name:   "draft",
+			policy: &btypes.AutoMergePolicy{},
+			changeset: func(c *btypes.Changeset) {
+				c.ExternalState = btypes.ChangesetExternalStateDraft
+			},
+			want: false,
+		},
+		{
+			name:   "pending checks",
+			policy: &btypes.AutoMergePolicy{},
+			changeset: func(c *btypes.Changeset) {
+				c.ExternalCheckState = btypes.ChangesetCheckStatePending
+			},
+			want: false,
+		},
+		{
+			name:   "pending checks not required",
+			policy: &btypes.AutoMergePolicy{RequireChecks: boolPtr(false)},
+			changeset: func(c *btypes.Changeset) {
+				c.ExternalCheckState = btypes.ChangesetCheckStatePending
+			},
+			want: true,
+		},
+		{
+			name:   "changes requested",
+			policy: &btypes.AutoMergePolicy{Approvals: intPtr(0)},
+			changeset: func(c *btypes.Changeset) {
+				c.ExternalReviewState = btypes.ChangesetReviewStateChangesRequested
+			},
+			want: false,
+		},
+		{
+			name:   "not enough approvals",
+			policy: &btypes.AutoMergePolicy{Approvals: intPtr(2)},
+			want:   false,
+		},
+		{
+			name: "merge window open",
+			policy: &btypes.AutoMergePolicy{Windows: []btypes.AutoMergeWindow{
+				{Days: []string{"wednesday"}, Start: "10:00", End: "14:00"},
+			}},
+			want: true,
+		},
+		{
+			name: "merge window closed",
+			policy: &btypes.AutoMergePolicy{Windows: []btypes.AutoMergeWindow{
+				{Days: []string{"thursday"}},
+			}},
+			want: false,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			c := mergeable()
+			if tc.changeset != nil {
+				tc.changeset(c)
+			}
+
+			have, err := CanAutoMerge(tc.policy, c, nil, now)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if have != tc.want {
+				t.Errorf("unexpected result: have=%v want=%v", have, tc.want)
+			}
+		})
+	}
+}
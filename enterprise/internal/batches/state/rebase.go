@@ -0,0 +1,65 @@
+package state
+
+import (
+	"context"
+
+	btypes "github.com/sourcegraph/sourcegraph/enterprise/internal/batches/types"
+	"github.com/sourcegraph/sourcegraph/internal/api"
+	"github.com/sourcegraph/sourcegraph/internal/extsvc/gitlab"
+	"github.com/sourcegraph/sourcegraph/internal/vcs/git"
+)
+
+// IsOutdated reports whether the changeset has to be rebased: its base branch
+// has moved on since its head branch was created, which means that the head
+// branch doesn't contain the latest commit of the base branch anymore, and the
+// code host reports that it conflicts with the base branch. If the code host
+// doesn't report conflicts, a moved base branch is enough. base is the latest
+// commit of the base branch, which the changeset has to be rebased onto.
+//
+// The sync state of the changeset is expected to be up to date. IsOutdated
+// talks to gitserver, so it shouldn't be called in a transaction.
+func IsOutdated(ctx context.Context, repo api.RepoName, c *btypes.Changeset) (outdated bool, base string, err error) {
+	if c.ExternalState != btypes.ChangesetExternalStateOpen {
+		return false, "", nil
+	}
+	if conflicts, ok := c.HasConflicts(); ok && !conflicts {
+		return false, "", nil
+	}
+	return BaseMoved(ctx, repo, c)
+}
+
+// BaseMoved reports whether the base branch of the changeset has moved on since
+// its head branch was created, regardless of whether the changeset conflicts
+// with it. base is the latest commit of the base branch.
+//
+// Like IsOutdated, BaseMoved talks to gitserver.
+func BaseMoved(ctx context.Context, repo api.RepoName, c *btypes.Changeset) (moved bool, base string, err error) {
+	head := c.SyncState.HeadRefOid
+	base, err = baseTip(ctx, repo, c)
+	if err != nil || base == "" || head == "" {
+		return false, "", err
+	}
+
+	mergeBase, err := git.MergeBase(ctx, repo, api.CommitID(head), api.CommitID(base))
+	if err != nil {
+		return false, "", err
+	}
+	return string(mergeBase) != base, base, nil
+}
+
+// baseTip returns the latest commit of the base branch of the changeset.
+func baseTip(ctx context.Context, repo api.RepoName, c *btypes.Changeset) (string, error) {
+	// The base commit of GitLab merge requests is the commit that the source
+	// branch was created from, not the latest commit of the target branch, so
+	// we resolve the target branch ourselves.
+	if _, ok := c.Metadata.(*gitlab.MergeRequest); !ok {
+		return c.SyncState.BaseRefOid, nil
+	}
+
+	ref, err := c.BaseRef()
+	if err != nil {
+		return "", err
+	}
+	tip, err := git.ResolveRevision(ctx, repo, ref, git.ResolveRevisionOptions{NoEnsureRevision: true})
+	return string(tip), err
+}
@@ -0,0 +1,120 @@
+package state
+
+import (
+	"context"
+	"testing"
+
+	btypes "github.com/sourcegraph/sourcegraph/enterprise/internal/batches/types"
+	"github.com/sourcegraph/sourcegraph/internal/api"
+	"github.com/sourcegraph/sourcegraph/internal/extsvc/github"
+	"github.com/sourcegraph/sourcegraph/internal/extsvc/gitlab"
+	"github.com/sourcegraph/sourcegraph/internal/vcs/git"
+)
+
+func TestIsOutdated(t *testing.T) {
+	git.Mocks.MergeBase = func(repo api.RepoName, a, b api.CommitID) (api.CommitID, error) {
+		if a != "head" {
+			t.Errorf("unexpected head %q", a)
+		}
+		// The head branch was created from "old-base".
+		return "old-base", nil
+	}
+	git.Mocks.ResolveRevision = func(spec string, opt git.ResolveRevisionOptions) (api.CommitID, error) {
+		if spec != "refs/heads/main" {
+			t.Errorf("unexpected revision %q", spec)
+		}
+		return "new-base", nil
+	}
+	t.Cleanup(func() {
+		git.Mocks.MergeBase = nil
+		git.Mocks.ResolveRevision = nil
+	})
+
+	tests := []struct {
+		name      string
+		changeset *btypes.Changeset
+		want      bool
+		wantBase  string
+	}{
+		{
+			name: "base unchanged",
+			changeset: &btypes.Changeset{
+				ExternalState: btypes.ChangesetExternalStateOpen,
+				SyncState:     btypes.ChangesetSyncState{BaseRefOid: "old-base", HeadRefOid: "head"},
+			},
+			want: false,
+		},
+		{
+			name: "base moved",
+			changeset: &btypes.Changeset{
+				ExternalState: btypes.ChangesetExternalStateOpen,
+				SyncState:     btypes.ChangesetSyncState{BaseRefOid: "new-base", HeadRefOid: "head"},
+			},
+			want:     true,
+			wantBase: "new-base",
+		},
+		{
+			name: "base moved with conflicts",
+			changeset: &btypes.Changeset{
+				ExternalState: btypes.ChangesetExternalStateOpen,
+				Metadata:      &github.PullRequest{Mergeable: "CONFLICTING"},
+				SyncState:     btypes.ChangesetSyncState{BaseRefOid: "new-base", HeadRefOid: "head"},
+			},
+			want:     true,
+			wantBase: "new-base",
+		},
+		{
+			name: "base moved without conflicts",
+			changeset: &btypes.Changeset{
+				ExternalState: btypes.ChangesetExternalStateOpen,
+				Metadata:      &github.PullRequest{Mergeable: "MERGEABLE"},
+				SyncState:     btypes.ChangesetSyncState{BaseRefOid: "new-base", HeadRefOid: "head"},
+			},
+			want: false,
+		},
+		{
+			name: "target branch of merge request moved",
+			changeset: &btypes.Changeset{
+				ExternalState: btypes.ChangesetExternalStateOpen,
+				Metadata: &gitlab.MergeRequest{
+					TargetBranch: "main",
+					MergeStatus:  "cannot_be_merged",
+					DiffRefs:     gitlab.DiffRefs{BaseSHA: "old-base", HeadSHA: "head"},
+				},
+				SyncState: btypes.ChangesetSyncState{BaseRefOid: "old-base", HeadRefOid: "head"},
+			},
+			want:     true,
+			wantBase: "new-base",
+		},
+		{
+			name: "base moved on merged changeset",
+			changeset: &btypes.Changeset{
+				ExternalState: btypes.ChangesetExternalStateMerged,
+				SyncState:     btypes.ChangesetSyncState{BaseRefOid: "new-base", HeadRefOid: "head"},
+			},
+			want: false,
+		},
+		{
+			name: "not synced",
+			changeset: &btypes.Changeset{
+				ExternalState: btypes.ChangesetExternalStateOpen,
+			},
+			want: false,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			have, base, err := IsOutdated(context.Background(), "github.com/sourcegraph/sourcegraph", tc.changeset)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if have != tc.want {
+				t.Errorf("unexpected result: have=%v want=%v", have, tc.want)
+			}
+			if have && base != tc.wantBase {
+				t.Errorf("unexpected base: have=%q want=%q", base, tc.wantBase)
+			}
+		})
+	}
+}
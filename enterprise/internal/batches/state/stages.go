@@ -0,0 +1,162 @@
+package state
+
+import (
+	"context"
+
+	"github.com/cockroachdb/errors"
+
+	"github.com/sourcegraph/sourcegraph/enterprise/internal/batches/global"
+	"github.com/sourcegraph/sourcegraph/enterprise/internal/batches/store"
+	btypes "github.com/sourcegraph/sourcegraph/enterprise/internal/batches/types"
+	"github.com/sourcegraph/sourcegraph/internal/api"
+)
+
+// ChangesetStages maps the IDs of changesets to the index of the stage of the
+// batch spec that they belong to, or -1 if they don't belong to any stage.
+type ChangesetStages map[int64]int
+
+// LoadChangesetStages returns the changesets owned by the given batch change
+// that haven't been archived or detached, along with their stages in the given
+// batch spec.
+func LoadChangesetStages(ctx context.Context, tx *store.Store, spec *btypes.BatchSpecFields, batchChangeID int64) (btypes.Changesets, ChangesetStages, error) {
+	cs, _, err := tx.ListChangesets(ctx, store.ListChangesetsOpts{
+		BatchChangeID:        batchChangeID,
+		OwnedByBatchChangeID: batchChangeID,
+	})
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "listing changesets")
+	}
+
+	repoIDs := make([]api.RepoID, 0, len(cs))
+	for _, c := range cs {
+		repoIDs = append(repoIDs, c.RepoID)
+	}
+	repos, err := tx.Repos().GetReposSetByIDs(ctx, repoIDs...)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "loading repositories")
+	}
+
+	stages := make(ChangesetStages, len(cs))
+	for _, c := range cs {
+		stages[c.ID] = -1
+		if repo, ok := repos[c.RepoID]; ok {
+			stages[c.ID] = spec.Stage(string(repo.Name))
+		}
+	}
+	return cs, stages, nil
+}
+
+// OpenStage returns the index of the first of the given number of stages that
+// still has changesets that haven't been merged or closed. Changesets in later
+// stages must not be published yet. It returns numStages if all stages are
+// done.
+func OpenStage(numStages int, cs btypes.Changesets, stages ChangesetStages) int {
+	open := numStages
+	for _, c := range cs {
+		if s := stages[c.ID]; s >= 0 && s < open && !c.Complete() {
+			open = s
+		}
+	}
+	return open
+}
+
+// PublicationBlocked reports whether the given unpublished changeset has to
+// wait for the changesets of earlier stages of the batch change that owns it to
+// be merged or closed before it may be published.
+func PublicationBlocked(ctx context.Context, tx *store.Store, c *btypes.Changeset) (bool, error) {
+	if c.OwnedByBatchChangeID == 0 || !c.Unpublished() {
+		return false, nil
+	}
+
+	// If the changeset has been detached or archived in the meantime, it's not
+	// blocked, since publishing it is moot.
+	blocked, err := BlockedChangesets(ctx, tx, c.OwnedByBatchChangeID)
+	return blocked[c.ID], err
+}
+
+// BlockedChangesets returns the IDs of the unpublished changesets owned by the
+// given batch change that have to wait for the changesets of earlier stages to
+// be merged or closed before they may be published.
+func BlockedChangesets(ctx context.Context, tx *store.Store, batchChangeID int64) (map[int64]bool, error) {
+	batchSpec, err := tx.GetAppliedBatchSpec(ctx, batchChangeID)
+	if err != nil || batchSpec == nil || len(batchSpec.Spec.Stages) == 0 {
+		return nil, err
+	}
+
+	cs, stages, err := LoadChangesetStages(ctx, tx, &batchSpec.Spec, batchChangeID)
+	if err != nil {
+		return nil, err
+	}
+
+	open := OpenStage(len(batchSpec.Spec.Stages), cs, stages)
+	blocked := make(map[int64]bool)
+	for _, c := range cs {
+		if stages[c.ID] > open && c.Unpublished() {
+			blocked[c.ID] = true
+		}
+	}
+	return blocked, nil
+}
+
+// UpdatePublicationBlocked records which changesets owned by the given batch
+// change are blocked by an earlier stage, as returned by BlockedChangesets, so
+// the scheduler can skip them without computing the stages. It must be called
+// whenever the changesets or the stages of a batch change change, or one of its
+// changesets is merged, closed or reopened.
+func UpdatePublicationBlocked(ctx context.Context, tx *store.Store, batchChangeID int64) error {
+	blocked, err := BlockedChangesets(ctx, tx, batchChangeID)
+	if err != nil {
+		return err
+	}
+
+	ids := make([]int64, 0, len(blocked))
+	for id := range blocked {
+		ids = append(ids, id)
+	}
+	return errors.Wrap(tx.SetChangesetsPublicationBlocked(ctx, batchChangeID, ids), "updating blocked changesets")
+}
+
+// EnqueueUnblockedStage updates which changesets of the batch change that owns
+// the given changeset are blocked if the changeset has just been merged,
+// closed or reopened, as told by wasComplete. If the changeset was the last
+// one of the previous stages that hadn't been merged or closed, the
+// unpublished changesets of the next stage are enqueued. It must be called by
+// everything that updates the external state of changesets.
+func EnqueueUnblockedStage(ctx context.Context, tx *store.Store, c *btypes.Changeset, wasComplete bool) error {
+	if wasComplete == c.Complete() || c.OwnedByBatchChangeID == 0 {
+		return nil
+	}
+
+	batchSpec, err := tx.GetAppliedBatchSpec(ctx, c.OwnedByBatchChangeID)
+	if err != nil || batchSpec == nil || len(batchSpec.Spec.Stages) == 0 {
+		return err
+	}
+
+	if err := UpdatePublicationBlocked(ctx, tx, c.OwnedByBatchChangeID); err != nil {
+		return err
+	}
+	if wasComplete {
+		return nil
+	}
+
+	cs, stages, err := LoadChangesetStages(ctx, tx, &batchSpec.Spec, c.OwnedByBatchChangeID)
+	if err != nil {
+		return err
+	}
+
+	stage, ok := stages[c.ID]
+	open := OpenStage(len(batchSpec.Spec.Stages), cs, stages)
+	if !ok || stage < 0 || stage >= open {
+		return nil
+	}
+
+	for _, other := range cs {
+		if stages[other.ID] != open || !other.Unpublished() || other.ReconcilerState != btypes.ReconcilerStateCompleted {
+			continue
+		}
+		if err := tx.EnqueueChangeset(ctx, other, global.DefaultReconcilerEnqueueState(), btypes.ReconcilerStateCompleted); err != nil {
+			return errors.Wrapf(err, "enqueueing changeset %d", other.ID)
+		}
+	}
+	return nil
+}
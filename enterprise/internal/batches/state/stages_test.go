@@ -0,0 +1,202 @@
+package state
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/backend"
+	"github.com/sourcegraph/sourcegraph/enterprise/internal/batches/store"
+	ct "github.com/sourcegraph/sourcegraph/enterprise/internal/batches/testing"
+	btypes "github.com/sourcegraph/sourcegraph/enterprise/internal/batches/types"
+	"github.com/sourcegraph/sourcegraph/internal/database/dbtest"
+)
+
+func TestOpenStage(t *testing.T) {
+	t.Parallel()
+
+	changeset := func(id int64, publicationState btypes.ChangesetPublicationState, externalState btypes.ChangesetExternalState) *btypes.Changeset {
+		return &btypes.Changeset{
+			ID:               id,
+			PublicationState: publicationState,
+			ExternalState:    externalState,
+		}
+	}
+	var (
+		unpublished = btypes.ChangesetPublicationStateUnpublished
+		published   = btypes.ChangesetPublicationStatePublished
+	)
+
+	tests := []struct {
+		name      string
+		numStages int
+		cs        btypes.Changesets
+		stages    ChangesetStages
+		want      int
+	}{
+		{
+			name:      "no changesets",
+			numStages: 2,
+			want:      2,
+		},
+		{
+			name:      "first stage unpublished",
+			numStages: 2,
+			cs: btypes.Changesets{
+				changeset(1, unpublished, ""),
+				changeset(2, unpublished, ""),
+			},
+			stages: ChangesetStages{1: 0, 2: 1},
+			want:   0,
+		},
+		{
+			name:      "first stage open",
+			numStages: 2,
+			cs: btypes.Changesets{
+				changeset(1, published, btypes.ChangesetExternalStateMerged),
+				changeset(2, published, btypes.ChangesetExternalStateOpen),
+				changeset(3, unpublished, ""),
+			},
+			stages: ChangesetStages{1: 0, 2: 0, 3: 1},
+			want:   0,
+		},
+		{
+			name:      "first stage merged and closed",
+			numStages: 2,
+			cs: btypes.Changesets{
+				changeset(1, published, btypes.ChangesetExternalStateMerged),
+				changeset(2, published, btypes.ChangesetExternalStateClosed),
+				changeset(3, unpublished, ""),
+			},
+			stages: ChangesetStages{1: 0, 2: 0, 3: 1},
+			want:   1,
+		},
+		{
+			name:      "changesets outside of stages",
+			numStages: 2,
+			cs: btypes.Changesets{
+				changeset(1, published, btypes.ChangesetExternalStateMerged),
+				changeset(2, published, btypes.ChangesetExternalStateOpen),
+			},
+			stages: ChangesetStages{1: 0, 2: -1},
+			want:   2,
+		},
+		{
+			name:      "empty stage",
+			numStages: 3,
+			cs: btypes.Changesets{
+				changeset(1, published, btypes.ChangesetExternalStateMerged),
+				changeset(2, published, btypes.ChangesetExternalStateDraft),
+			},
+			stages: ChangesetStages{1: 0, 2: 2},
+			want:   2,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if have := OpenStage(tc.numStages, tc.cs, tc.stages); have != tc.want {
+				t.Errorf("unexpected open stage: have=%d want=%d", have, tc.want)
+			}
+		})
+	}
+}
+
+func TestPublicationBlocked(t *testing.T) {
+	if testing.Short() {
+		t.Skip()
+	}
+
+	ctx := backend.WithAuthzBypass(context.Background())
+	db := dbtest.NewDB(t, "")
+	s := store.New(db, nil)
+
+	user := ct.CreateTestUser(t, db, true)
+	rs, _ := ct.CreateTestRepos(t, ctx, db, 3)
+
+	batchSpec := &btypes.BatchSpec{
+		UserID:          user.ID,
+		NamespaceUserID: user.ID,
+		Spec: btypes.BatchSpecFields{
+			Name: "stages",
+			Stages: []btypes.BatchChangeStage{
+				{Name: "first", Repositories: []string{string(rs[0].Name)}},
+				{Name: "second", Repositories: []string{string(rs[1].Name)}},
+			},
+		},
+	}
+	if err := s.CreateBatchSpec(ctx, batchSpec); err != nil {
+		t.Fatal(err)
+	}
+	batchChange := ct.CreateBatchChange(t, ctx, s, "stages", user.ID, batchSpec.ID)
+
+	first := ct.CreateChangeset(t, ctx, s, ct.TestChangesetOpts{
+		Repo:               rs[0].ID,
+		BatchChange:        batchChange.ID,
+		OwnedByBatchChange: batchChange.ID,
+		PublicationState:   btypes.ChangesetPublicationStatePublished,
+		ExternalState:      btypes.ChangesetExternalStateOpen,
+		ReconcilerState:    btypes.ReconcilerStateCompleted,
+	})
+	second := ct.CreateChangeset(t, ctx, s, ct.TestChangesetOpts{
+		Repo:               rs[1].ID,
+		BatchChange:        batchChange.ID,
+		OwnedByBatchChange: batchChange.ID,
+		PublicationState:   btypes.ChangesetPublicationStateUnpublished,
+		ReconcilerState:    btypes.ReconcilerStateQueued,
+	})
+	outside := ct.CreateChangeset(t, ctx, s, ct.TestChangesetOpts{
+		Repo:               rs[2].ID,
+		BatchChange:        batchChange.ID,
+		OwnedByBatchChange: batchChange.ID,
+		PublicationState:   btypes.ChangesetPublicationStateUnpublished,
+		ReconcilerState:    btypes.ReconcilerStateQueued,
+	})
+
+	assertBlocked := func(t *testing.T, want map[int64]bool) {
+		t.Helper()
+
+		have, err := BlockedChangesets(ctx, s, batchChange.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if diff := cmp.Diff(want, have); diff != "" {
+			t.Fatalf("unexpected blocked changesets (-want +have):\n%s", diff)
+		}
+
+		for _, c := range []*btypes.Changeset{first, second, outside} {
+			blocked, err := PublicationBlocked(ctx, s, c)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if blocked != want[c.ID] {
+				t.Errorf("unexpected publication blocked for changeset %d: have=%v want=%v", c.ID, blocked, want[c.ID])
+			}
+		}
+	}
+
+	t.Run("first stage open", func(t *testing.T) {
+		assertBlocked(t, map[int64]bool{second.ID: true})
+	})
+
+	t.Run("first stage merged", func(t *testing.T) {
+		first.ExternalState = btypes.ChangesetExternalStateMerged
+		if err := s.UpdateChangeset(ctx, first); err != nil {
+			t.Fatal(err)
+		}
+		assertBlocked(t, map[int64]bool{})
+	})
+
+	t.Run("batch change closed", func(t *testing.T) {
+		first.ExternalState = btypes.ChangesetExternalStateOpen
+		if err := s.UpdateChangeset(ctx, first); err != nil {
+			t.Fatal(err)
+		}
+		batchChange.ClosedAt = s.Clock()()
+		if err := s.UpdateBatchChange(ctx, batchChange); err != nil {
+			t.Fatal(err)
+		}
+		assertBlocked(t, nil)
+	})
+}
@@ -16,6 +16,9 @@ import (
 	"github.com/sourcegraph/sourcegraph/internal/api"
 	"github.com/sourcegraph/sourcegraph/internal/database"
 	"github.com/sourcegraph/sourcegraph/internal/extsvc"
+	"github.com/sourcegraph/sourcegraph/internal/extsvc/awscodecommit"
+	"github.com/sourcegraph/sourcegraph/internal/extsvc/azuredevops"
+	"github.com/sourcegraph/sourcegraph/internal/extsvc/bitbucketcloud"
 	"github.com/sourcegraph/sourcegraph/internal/extsvc/bitbucketserver"
 	"github.com/sourcegraph/sourcegraph/internal/extsvc/github"
 	"github.com/sourcegraph/sourcegraph/internal/extsvc/gitlab"
@@ -105,6 +108,16 @@ func computeCheckState(c *btypes.Changeset, events ChangesetEvents) btypes.Chang
 
 	case *gitlab.MergeRequest:
 		return computeGitLabCheckState(c.UpdatedAt, m, events)
+
+	case *azuredevops.PullRequest:
+		return computeAzureDevOpsCheckState(m)
+
+	case *bitbucketcloud.PullRequest:
+		return computeBitbucketCloudCheckState(m)
+
+	case *awscodecommit.PullRequest:
+		// AWS CodeCommit has no API for build statuses.
+		return btypes.ChangesetCheckStateUnknown
 	}
 
 	return btypes.ChangesetCheckStateUnknown
@@ -415,6 +428,71 @@ func parseGitLabPipelineStatus(status gitlab.PipelineStatus) btypes.ChangesetChe
 	}
 }
 
+func computeAzureDevOpsCheckState(pr *azuredevops.PullRequest) btypes.ChangesetCheckState {
+	// Services post a new status for each update, so only the latest status
+	// of each service counts.
+	latest := make(map[azuredevops.StatusContext]*azuredevops.PullRequestStatus)
+	for _, s := range pr.Statuses {
+		if l, ok := latest[s.Context]; !ok || s.CreationDate.After(l.CreationDate) {
+			latest[s.Context] = s
+		}
+	}
+
+	var states []btypes.ChangesetCheckState
+	for _, s := range latest {
+		// Statuses that don't apply to the pull request don't affect its
+		// check state.
+		if s.State == azuredevops.StatusStateNotApplicable {
+			continue
+		}
+		states = append(states, parseAzureDevOpsStatusState(s.State))
+	}
+	return combineCheckStates(states)
+}
+
+func parseAzureDevOpsStatusState(state string) btypes.ChangesetCheckState {
+	switch state {
+	case azuredevops.StatusStateSucceeded:
+		return btypes.ChangesetCheckStatePassed
+	case azuredevops.StatusStateFailed, azuredevops.StatusStateError:
+		return btypes.ChangesetCheckStateFailed
+	case azuredevops.StatusStatePending, azuredevops.StatusStateNotSet:
+		return btypes.ChangesetCheckStatePending
+	default:
+		return btypes.ChangesetCheckStateUnknown
+	}
+}
+
+func computeBitbucketCloudCheckState(pr *bitbucketcloud.PullRequest) btypes.ChangesetCheckState {
+	// Statuses with the same key replace each other, so only the most
+	// recently updated status of each key counts.
+	latest := make(map[string]*bitbucketcloud.CommitStatus)
+	for _, s := range pr.Statuses {
+		if l, ok := latest[s.Key]; !ok || s.UpdatedOn.After(l.UpdatedOn) {
+			latest[s.Key] = s
+		}
+	}
+
+	var states []btypes.ChangesetCheckState
+	for _, s := range latest {
+		states = append(states, parseBitbucketCloudStatusState(s.State))
+	}
+	return combineCheckStates(states)
+}
+
+func parseBitbucketCloudStatusState(state bitbucketcloud.CommitStatusState) btypes.ChangesetCheckState {
+	switch state {
+	case bitbucketcloud.CommitStatusStateSuccessful:
+		return btypes.ChangesetCheckStatePassed
+	case bitbucketcloud.CommitStatusStateFailed, bitbucketcloud.CommitStatusStateStopped:
+		return btypes.ChangesetCheckStateFailed
+	case bitbucketcloud.CommitStatusStateInProgress:
+		return btypes.ChangesetCheckStatePending
+	default:
+		return btypes.ChangesetCheckStateUnknown
+	}
+}
+
 // computeSingleChangesetExternalState of a Changeset based on the metadata.
 // It does NOT reflect the final calculated state, use `ExternalState` instead.
 func computeSingleChangesetExternalState(c *btypes.Changeset) (s btypes.ChangesetExternalState, err error) {
@@ -450,6 +528,45 @@ func computeSingleChangesetExternalState(c *btypes.Changeset) (s btypes.Changese
 		default:
 			return "", errors.Errorf("unknown GitLab merge request state: %s", m.State)
 		}
+	case *azuredevops.PullRequest:
+		switch m.Status {
+		case azuredevops.PullRequestStatusAbandoned:
+			s = btypes.ChangesetExternalStateClosed
+		case azuredevops.PullRequestStatusCompleted:
+			s = btypes.ChangesetExternalStateMerged
+		case azuredevops.PullRequestStatusActive:
+			if m.IsDraft {
+				s = btypes.ChangesetExternalStateDraft
+			} else {
+				s = btypes.ChangesetExternalStateOpen
+			}
+		default:
+			return "", errors.Errorf("unknown Azure DevOps pull request status: %s", m.Status)
+		}
+	case *bitbucketcloud.PullRequest:
+		switch m.State {
+		case bitbucketcloud.PullRequestStateDeclined, bitbucketcloud.PullRequestStateSuperseded:
+			s = btypes.ChangesetExternalStateClosed
+		case bitbucketcloud.PullRequestStateMerged:
+			s = btypes.ChangesetExternalStateMerged
+		case bitbucketcloud.PullRequestStateOpen:
+			s = btypes.ChangesetExternalStateOpen
+		default:
+			return "", errors.Errorf("unknown Bitbucket Cloud pull request state: %s", m.State)
+		}
+	case *awscodecommit.PullRequest:
+		switch m.Status {
+		case awscodecommit.PullRequestStatusClosed:
+			if m.IsMerged {
+				s = btypes.ChangesetExternalStateMerged
+			} else {
+				s = btypes.ChangesetExternalStateClosed
+			}
+		case awscodecommit.PullRequestStatusOpen:
+			s = btypes.ChangesetExternalStateOpen
+		default:
+			return "", errors.Errorf("unknown AWS CodeCommit pull request status: %s", m.Status)
+		}
 	default:
 		return "", errors.New("unknown changeset type")
 	}
@@ -507,6 +624,42 @@ func computeSingleChangesetReviewState(c *btypes.Changeset) (s btypes.ChangesetR
 		}
 		return btypes.ChangesetReviewStatePending, nil
 
+	case *azuredevops.PullRequest:
+		for _, r := range m.Reviewers {
+			switch r.Vote {
+			case azuredevops.VoteApproved, azuredevops.VoteApprovedWithSuggestion:
+				states[btypes.ChangesetReviewStateApproved] = true
+			case azuredevops.VoteWaitingForAuthor, azuredevops.VoteRejected:
+				states[btypes.ChangesetReviewStateChangesRequested] = true
+			default:
+				states[btypes.ChangesetReviewStatePending] = true
+			}
+		}
+
+	case *bitbucketcloud.PullRequest:
+		for _, p := range m.Participants {
+			if p.Role != bitbucketcloud.ParticipantRoleReviewer {
+				continue
+			}
+			switch p.State {
+			case bitbucketcloud.ParticipantStateApproved:
+				states[btypes.ChangesetReviewStateApproved] = true
+			case bitbucketcloud.ParticipantStateChangesRequested:
+				states[btypes.ChangesetReviewStateChangesRequested] = true
+			default:
+				states[btypes.ChangesetReviewStatePending] = true
+			}
+		}
+
+	case *awscodecommit.PullRequest:
+		// AWS CodeCommit has no concept of requesting changes: a pull request
+		// is approved once it has been approved and all of its approval rules
+		// are satisfied.
+		if len(m.ApprovedBy) > 0 && len(m.ApprovalRulesNotSatisfied) == 0 {
+			return btypes.ChangesetReviewStateApproved, nil
+		}
+		return btypes.ChangesetReviewStatePending, nil
+
 	default:
 		return "", errors.New("unknown changeset type")
 	}
@@ -9,6 +9,9 @@ import (
 
 	btypes "github.com/sourcegraph/sourcegraph/enterprise/internal/batches/types"
 	"github.com/sourcegraph/sourcegraph/internal/extsvc"
+	"github.com/sourcegraph/sourcegraph/internal/extsvc/awscodecommit"
+	"github.com/sourcegraph/sourcegraph/internal/extsvc/azuredevops"
+	"github.com/sourcegraph/sourcegraph/internal/extsvc/bitbucketcloud"
 	"github.com/sourcegraph/sourcegraph/internal/extsvc/bitbucketserver"
 	"github.com/sourcegraph/sourcegraph/internal/extsvc/github"
 	"github.com/sourcegraph/sourcegraph/internal/extsvc/gitlab"
@@ -406,6 +409,112 @@ func TestComputeGitLabCheckState(t *testing.T) {
 	})
 }
 
+func TestComputeAzureDevOpsCheckState(t *testing.T) {
+	t.Parallel()
+
+	status := func(name, state string, createdAt int64) *azuredevops.PullRequestStatus {
+		return &azuredevops.PullRequestStatus{
+			State:        state,
+			Context:      azuredevops.StatusContext{Name: name, Genre: "continuous-integration"},
+			CreationDate: time.Unix(createdAt, 0),
+		}
+	}
+
+	for name, tc := range map[string]struct {
+		statuses []*azuredevops.PullRequestStatus
+		want     btypes.ChangesetCheckState
+	}{
+		"no statuses": {
+			want: btypes.ChangesetCheckStateUnknown,
+		},
+		"single passed status": {
+			statuses: []*azuredevops.PullRequestStatus{status("build", "succeeded", 1)},
+			want:     btypes.ChangesetCheckStatePassed,
+		},
+		"latest status of a service wins": {
+			statuses: []*azuredevops.PullRequestStatus{
+				status("build", "failed", 1),
+				status("build", "succeeded", 2),
+			},
+			want: btypes.ChangesetCheckStatePassed,
+		},
+		"pending takes precedence over failed": {
+			statuses: []*azuredevops.PullRequestStatus{
+				status("build", "failed", 1),
+				status("lint", "pending", 1),
+			},
+			want: btypes.ChangesetCheckStatePending,
+		},
+		"not applicable statuses are ignored": {
+			statuses: []*azuredevops.PullRequestStatus{
+				status("build", "succeeded", 1),
+				status("lint", "notApplicable", 1),
+			},
+			want: btypes.ChangesetCheckStatePassed,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			have := computeAzureDevOpsCheckState(&azuredevops.PullRequest{Statuses: tc.statuses})
+			if have != tc.want {
+				t.Errorf("unexpected check state: have %s; want %s", have, tc.want)
+			}
+		})
+	}
+}
+
+func TestComputeBitbucketCloudCheckState(t *testing.T) {
+	t.Parallel()
+
+	status := func(key string, state bitbucketcloud.CommitStatusState, updatedAt int64) *bitbucketcloud.CommitStatus {
+		return &bitbucketcloud.CommitStatus{
+			Key:       key,
+			State:     state,
+			UpdatedOn: time.Unix(updatedAt, 0),
+		}
+	}
+
+	for name, tc := range map[string]struct {
+		statuses []*bitbucketcloud.CommitStatus
+		want     btypes.ChangesetCheckState
+	}{
+		"no statuses": {
+			want: btypes.ChangesetCheckStateUnknown,
+		},
+		"single passed status": {
+			statuses: []*bitbucketcloud.CommitStatus{status("build", bitbucketcloud.CommitStatusStateSuccessful, 1)},
+			want:     btypes.ChangesetCheckStatePassed,
+		},
+		"latest status of a key wins": {
+			statuses: []*bitbucketcloud.CommitStatus{
+				status("build", bitbucketcloud.CommitStatusStateSuccessful, 2),
+				status("build", bitbucketcloud.CommitStatusStateFailed, 1),
+			},
+			want: btypes.ChangesetCheckStatePassed,
+		},
+		"stopped counts as failed": {
+			statuses: []*bitbucketcloud.CommitStatus{
+				status("build", bitbucketcloud.CommitStatusStateSuccessful, 1),
+				status("lint", bitbucketcloud.CommitStatusStateStopped, 1),
+			},
+			want: btypes.ChangesetCheckStateFailed,
+		},
+		"pending takes precedence over failed": {
+			statuses: []*bitbucketcloud.CommitStatus{
+				status("build", bitbucketcloud.CommitStatusStateFailed, 1),
+				status("lint", bitbucketcloud.CommitStatusStateInProgress, 1),
+			},
+			want: btypes.ChangesetCheckStatePending,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			have := computeBitbucketCloudCheckState(&bitbucketcloud.PullRequest{Statuses: tc.statuses})
+			if have != tc.want {
+				t.Errorf("unexpected check state: have %s; want %s", have, tc.want)
+			}
+		})
+	}
+}
+
 func TestComputeReviewState(t *testing.T) {
 	t.Parallel()
 
@@ -464,6 +573,64 @@ func TestComputeReviewState(t *testing.T) {
 			},
 			want: btypes.ChangesetReviewStateChangesRequested,
 		},
+		{
+			name:      "azuredevops - no events, no reviewers",
+			changeset: azureDevOpsChangeset(daysAgo(0), azuredevops.PullRequestStatusActive),
+			history:   []changesetStatesAtTime{},
+			want:      btypes.ChangesetReviewStatePending,
+		},
+		{
+			name:      "azuredevops - no events, approved",
+			changeset: azureDevOpsChangeset(daysAgo(0), azuredevops.PullRequestStatusActive, azuredevops.VoteApproved, azuredevops.VoteNone),
+			history:   []changesetStatesAtTime{},
+			want:      btypes.ChangesetReviewStateApproved,
+		},
+		{
+			name:      "azuredevops - no events, waiting for author",
+			changeset: azureDevOpsChangeset(daysAgo(0), azuredevops.PullRequestStatusActive, azuredevops.VoteApproved, azuredevops.VoteWaitingForAuthor),
+			history:   []changesetStatesAtTime{},
+			want:      btypes.ChangesetReviewStateChangesRequested,
+		},
+		{
+			name:      "bitbucketcloud - no events, no reviewers",
+			changeset: bitbucketCloudChangeset(daysAgo(0), bitbucketcloud.PullRequestStateOpen),
+			history:   []changesetStatesAtTime{},
+			want:      btypes.ChangesetReviewStatePending,
+		},
+		{
+			name:      "bitbucketcloud - no events, approved",
+			changeset: bitbucketCloudChangeset(daysAgo(0), bitbucketcloud.PullRequestStateOpen, bitbucketcloud.ParticipantStateApproved, ""),
+			history:   []changesetStatesAtTime{},
+			want:      btypes.ChangesetReviewStateApproved,
+		},
+		{
+			name:      "bitbucketcloud - no events, changes requested",
+			changeset: bitbucketCloudChangeset(daysAgo(0), bitbucketcloud.PullRequestStateOpen, bitbucketcloud.ParticipantStateApproved, bitbucketcloud.ParticipantStateChangesRequested),
+			history:   []changesetStatesAtTime{},
+			want:      btypes.ChangesetReviewStateChangesRequested,
+		},
+		{
+			name:      "awscodecommit - no events, not approved",
+			changeset: awsCodeCommitChangeset(daysAgo(0), awscodecommit.PullRequestStatusOpen, false),
+			history:   []changesetStatesAtTime{},
+			want:      btypes.ChangesetReviewStatePending,
+		},
+		{
+			name:      "awscodecommit - no events, approved",
+			changeset: awsCodeCommitChangeset(daysAgo(0), awscodecommit.PullRequestStatusOpen, false, "arn:aws:iam::123456789012:user/alice"),
+			history:   []changesetStatesAtTime{},
+			want:      btypes.ChangesetReviewStateApproved,
+		},
+		{
+			name: "awscodecommit - no events, approval rules not satisfied",
+			changeset: func() *btypes.Changeset {
+				c := awsCodeCommitChangeset(daysAgo(0), awscodecommit.PullRequestStatusOpen, false, "arn:aws:iam::123456789012:user/alice")
+				c.Metadata.(*awscodecommit.PullRequest).ApprovalRulesNotSatisfied = []string{"two approvals"}
+				return c
+			}(),
+			history: []changesetStatesAtTime{},
+			want:    btypes.ChangesetReviewStatePending,
+		},
 		{
 			name:      "gitlab - no events, no approvals",
 			changeset: gitLabChangeset(daysAgo(0), gitlab.MergeRequestStateOpened, []*gitlab.Note{}),
@@ -699,6 +866,48 @@ func TestComputeExternalState(t *testing.T) {
 			history:   []changesetStatesAtTime{},
 			want:      btypes.ChangesetExternalStateDraft,
 		},
+		{
+			name:      "azuredevops - abandoned",
+			changeset: azureDevOpsChangeset(daysAgo(0), azuredevops.PullRequestStatusAbandoned),
+			history:   []changesetStatesAtTime{},
+			want:      btypes.ChangesetExternalStateClosed,
+		},
+		{
+			name:      "azuredevops - completed",
+			changeset: azureDevOpsChangeset(daysAgo(0), azuredevops.PullRequestStatusCompleted),
+			history:   []changesetStatesAtTime{},
+			want:      btypes.ChangesetExternalStateMerged,
+		},
+		{
+			name:      "azuredevops draft - no events",
+			changeset: setDraft(azureDevOpsChangeset(daysAgo(0), azuredevops.PullRequestStatusActive)),
+			history:   []changesetStatesAtTime{},
+			want:      btypes.ChangesetExternalStateDraft,
+		},
+		{
+			name:      "bitbucketcloud - declined",
+			changeset: bitbucketCloudChangeset(daysAgo(0), bitbucketcloud.PullRequestStateDeclined),
+			history:   []changesetStatesAtTime{},
+			want:      btypes.ChangesetExternalStateClosed,
+		},
+		{
+			name:      "bitbucketcloud - merged",
+			changeset: bitbucketCloudChangeset(daysAgo(0), bitbucketcloud.PullRequestStateMerged),
+			history:   []changesetStatesAtTime{},
+			want:      btypes.ChangesetExternalStateMerged,
+		},
+		{
+			name:      "awscodecommit - closed",
+			changeset: awsCodeCommitChangeset(daysAgo(0), awscodecommit.PullRequestStatusClosed, false),
+			history:   []changesetStatesAtTime{},
+			want:      btypes.ChangesetExternalStateClosed,
+		},
+		{
+			name:      "awscodecommit - merged",
+			changeset: awsCodeCommitChangeset(daysAgo(0), awscodecommit.PullRequestStatusClosed, true),
+			history:   []changesetStatesAtTime{},
+			want:      btypes.ChangesetExternalStateMerged,
+		},
 		{
 			name:      "gitlab draft - changeset older than events",
 			changeset: gitLabChangeset(daysAgo(10), gitlab.MergeRequestStateOpened, nil),
@@ -865,6 +1074,45 @@ func gitLabChangeset(updatedAt time.Time, state gitlab.MergeRequestState, notes
 	}
 }
 
+func azureDevOpsChangeset(updatedAt time.Time, status string, votes ...int) *btypes.Changeset {
+	pr := &azuredevops.PullRequest{Status: status}
+	for _, v := range votes {
+		pr.Reviewers = append(pr.Reviewers, &azuredevops.Reviewer{Vote: v})
+	}
+	return &btypes.Changeset{
+		ExternalServiceType: extsvc.TypeAzureDevOps,
+		UpdatedAt:           updatedAt,
+		Metadata:            pr,
+	}
+}
+
+func bitbucketCloudChangeset(updatedAt time.Time, state bitbucketcloud.PullRequestState, reviews ...bitbucketcloud.ParticipantState) *btypes.Changeset {
+	pr := &bitbucketcloud.PullRequest{State: state}
+	for _, r := range reviews {
+		pr.Participants = append(pr.Participants, bitbucketcloud.Participant{
+			Role:  bitbucketcloud.ParticipantRoleReviewer,
+			State: r,
+		})
+	}
+	return &btypes.Changeset{
+		ExternalServiceType: extsvc.TypeBitbucketCloud,
+		UpdatedAt:           updatedAt,
+		Metadata:            pr,
+	}
+}
+
+func awsCodeCommitChangeset(updatedAt time.Time, status string, merged bool, approvedBy ...string) *btypes.Changeset {
+	return &btypes.Changeset{
+		ExternalServiceType: extsvc.TypeAWSCodeCommit,
+		UpdatedAt:           updatedAt,
+		Metadata: &awscodecommit.PullRequest{
+			Status:     status,
+			IsMerged:   merged,
+			ApprovedBy: approvedBy,
+		},
+	}
+}
+
 func setDeletedAt(c *btypes.Changeset, deletedAt time.Time) *btypes.Changeset {
 	c.ExternalDeletedAt = deletedAt
 	return c
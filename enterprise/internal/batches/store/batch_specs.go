@@ -179,6 +179,24 @@ func (s *Store) GetBatchSpec(ctx context.Context, opts GetBatchSpecOpts) (*btype
 	return &c, nil
 }
 
+// GetAppliedBatchSpec returns the batch spec that is currently applied to the
+// given batch change. It returns nil if the batch change is closed.
+func (s *Store) GetAppliedBatchSpec(ctx context.Context, batchChangeID int64) (*btypes.BatchSpec, error) {
+	batchChange, err := s.GetBatchChange(ctx, GetBatchChangeOpts{ID: batchChangeID})
+	if err != nil {
+		return nil, errors.Wrap(err, "getting batch change")
+	}
+	if batchChange.Closed() {
+		return nil, nil
+	}
+
+	batchSpec, err := s.GetBatchSpec(ctx, GetBatchSpecOpts{ID: batchChange.BatchSpecID})
+	if err != nil {
+		return nil, errors.Wrap(err, "getting batch spec")
+	}
+	return batchSpec, nil
+}
+
 var getBatchSpecsQueryFmtstr = `
 -- source: enterprise/internal/batches/store/batch_specs.go:GetBatchSpec
 SELECT %s FROM batch_specs
@@ -11,6 +11,7 @@ import (
 
 	"github.com/cockroachdb/errors"
 	"github.com/keegancsmith/sqlf"
+	"github.com/lib/pq"
 
 	"github.com/sourcegraph/sourcegraph/enterprise/internal/batches/search"
 	btypes "github.com/sourcegraph/sourcegraph/enterprise/internal/batches/types"
@@ -19,6 +20,9 @@ import (
 	"github.com/sourcegraph/sourcegraph/internal/database/basestore"
 	"github.com/sourcegraph/sourcegraph/internal/database/dbutil"
 	"github.com/sourcegraph/sourcegraph/internal/extsvc"
+	"github.com/sourcegraph/sourcegraph/internal/extsvc/awscodecommit"
+	"github.com/sourcegraph/sourcegraph/internal/extsvc/azuredevops"
+	"github.com/sourcegraph/sourcegraph/internal/extsvc/bitbucketcloud"
 	"github.com/sourcegraph/sourcegraph/internal/extsvc/bitbucketserver"
 	"github.com/sourcegraph/sourcegraph/internal/extsvc/github"
 	"github.com/sourcegraph/sourcegraph/internal/extsvc/gitlab"
@@ -1078,6 +1082,12 @@ func scanChangeset(t *btypes.Changeset, s scanner) error {
 		t.Metadata = new(bitbucketserver.PullRequest)
 	case extsvc.TypeGitLab:
 		t.Metadata = new(gitlab.MergeRequest)
+	case extsvc.TypeAzureDevOps:
+		t.Metadata = new(azuredevops.PullRequest)
+	case extsvc.TypeBitbucketCloud:
+		t.Metadata = new(bitbucketcloud.PullRequest)
+	case extsvc.TypeAWSCodeCommit:
+		t.Metadata = new(awscodecommit.PullRequest)
 	default:
 		return errors.New("unknown external service type")
 	}
@@ -1170,6 +1180,10 @@ func (s *Store) GetRepoChangesetsStats(ctx context.Context, repoID api.RepoID) (
 	return &stats, nil
 }
 
+// EnqueueNextScheduledChangeset moves the changeset that has been scheduled
+// for the longest time to the queued state and returns it. Changesets whose
+// publication is blocked by an earlier stage of their batch change are skipped,
+// see SetChangesetsPublicationBlocked.
 func (s *Store) EnqueueNextScheduledChangeset(ctx context.Context) (*btypes.Changeset, error) {
 	q := sqlf.Sprintf(
 		enqueueNextScheduledChangesetFmtstr,
@@ -1198,7 +1212,7 @@ const enqueueNextScheduledChangesetFmtstr = `
 WITH c AS (
 	SELECT *
 	FROM changesets
-	WHERE reconciler_state = %s
+	WHERE reconciler_state = %s AND NOT publication_blocked
 	ORDER BY updated_at ASC
 	LIMIT 1
 )
@@ -1209,6 +1223,31 @@ WHERE c.id = changesets.id
 RETURNING %s
 `
 
+// SetChangesetsPublicationBlocked marks the changesets owned by the given batch
+// change with one of the given IDs as blocked from being published by an
+// earlier stage of the batch change, and all its other changesets as not
+// blocked.
+func (s *Store) SetChangesetsPublicationBlocked(ctx context.Context, batchChangeID int64, blocked []int64) error {
+	// A nil array would be NULL, which isn't a valid flag.
+	if blocked == nil {
+		blocked = []int64{}
+	}
+	q := sqlf.Sprintf(
+		setChangesetsPublicationBlockedFmtstr,
+		pq.Array(blocked),
+		batchChangeID,
+		pq.Array(blocked),
+	)
+	return s.Store.Exec(ctx, q)
+}
+
+const setChangesetsPublicationBlockedFmtstr = `
+-- source: enterprise/internal/batches/store/changesets.go:SetChangesetsPublicationBlocked
+UPDATE changesets
+SET publication_blocked = (id = ANY(%s))
+WHERE owned_by_batch_change_id = %s AND publication_blocked != (id = ANY(%s))
+`
+
 func (s *Store) GetChangesetPlaceInSchedulerQueue(ctx context.Context, id int64) (int, error) {
 	q := sqlf.Sprintf(
 		getChangesetPlaceInSchedulerQueueFmtstr,
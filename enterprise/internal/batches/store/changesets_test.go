@@ -2072,6 +2072,62 @@ func testStoreChangesetScheduling(t *testing.T, ctx context.Context, s *Store, c
 			t.Errorf("unexpected error: %v", err)
 		}
 	}
+
+	// Changesets whose publication is blocked stay scheduled, even if they
+	// are next in line.
+	user := ct.CreateTestUser(t, s.DB(), false)
+	spec := ct.CreateBatchSpec(t, ctx, s, "scheduling", user.ID)
+	batchChange := ct.CreateBatchChange(t, ctx, s, "scheduling", user.ID, spec.ID)
+
+	var (
+		blocked = createChangeset("blocked", time.Now(), btypes.ReconcilerStateScheduled)
+		later   = createChangeset("later", time.Now().Add(1*time.Minute), btypes.ReconcilerStateScheduled)
+	)
+	for _, cs := range []*btypes.Changeset{blocked, later} {
+		cs.OwnedByBatchChangeID = batchChange.ID
+		if err := s.UpdateChangeset(ctx, cs); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := s.SetChangesetsPublicationBlocked(ctx, batchChange.ID, []int64{blocked.ID}); err != nil {
+		t.Fatal(err)
+	}
+
+	have, err = s.EnqueueNextScheduledChangeset(ctx)
+	if err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if have == nil {
+		t.Errorf("unexpected nil changeset")
+	} else if have.ID != later.ID {
+		t.Errorf("unexpected changeset: have=%v want=%v", have, later)
+	}
+
+	if _, err = s.EnqueueNextScheduledChangeset(ctx); err != ErrNoResults {
+		t.Errorf("unexpected error: have=%v want=%v", err, ErrNoResults)
+	}
+
+	if have, err := s.GetChangesetPlaceInSchedulerQueue(ctx, blocked.ID); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	} else if want := 0; have != want {
+		t.Errorf("unexpected place: have=%d want=%d", have, want)
+	}
+
+	// Once it's unblocked, it's enqueued.
+	if err := s.SetChangesetsPublicationBlocked(ctx, batchChange.ID, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	have, err = s.EnqueueNextScheduledChangeset(ctx)
+	if err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if have == nil {
+		t.Errorf("unexpected nil changeset")
+	} else if have.ID != blocked.ID {
+		t.Errorf("unexpected changeset: have=%v want=%v", have, blocked)
+	}
 }
 
 func TestCancelQueuedBatchChangeChangesets(t *testing.T) {
@@ -11,6 +11,7 @@ import (
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promauto"
 
+	"github.com/sourcegraph/sourcegraph/enterprise/internal/batches/global"
 	"github.com/sourcegraph/sourcegraph/enterprise/internal/batches/sources"
 	"github.com/sourcegraph/sourcegraph/enterprise/internal/batches/state"
 	"github.com/sourcegraph/sourcegraph/enterprise/internal/batches/store"
@@ -44,6 +45,7 @@ type SyncStore interface {
 	UpdateChangesetCodeHostState(ctx context.Context, cs *btypes.Changeset) error
 	UpsertChangesetEvents(ctx context.Context, cs ...*btypes.ChangesetEvent) error
 	GetSiteCredential(ctx context.Context, opts store.GetSiteCredentialOpts) (*btypes.SiteCredential, error)
+	GetAppliedBatchSpec(ctx context.Context, batchChangeID int64) (*btypes.BatchSpec, error)
 	Transact(context.Context) (*store.Store, error)
 	Repos() *database.RepoStore
 	ExternalServices() *database.ExternalServiceStore
@@ -438,6 +440,8 @@ func (s *changesetSyncer) SyncChangeset(ctx context.Context, id int64) error {
 // SyncChangeset refreshes the metadata of the given changeset and
 // updates them in the database.
 func SyncChangeset(ctx context.Context, syncStore SyncStore, source sources.ChangesetSource, repo *types.Repo, c *btypes.Changeset) (err error) {
+	wasComplete := c.Complete()
+
 	repoChangeset := &sources.Changeset{Repo: repo, Changeset: c}
 	if err := source.LoadChangeset(ctx, repoChangeset); err != nil {
 		if !errors.HasType(err, sources.ChangesetNotFoundError{}) {
@@ -461,6 +465,10 @@ func SyncChangeset(ctx context.Context, syncStore SyncStore, source sources.Chan
 	}
 	state.SetDerivedState(ctx, syncStore.Repos(), c, events)
 
+	// Checking whether the changeset has to be rebased talks to gitserver, so
+	// it's done before starting the transaction.
+	rebase := rebaseDue(ctx, syncStore, repo, c)
+
 	tx, err := syncStore.Transact(ctx)
 	if err != nil {
 		return err
@@ -475,7 +483,69 @@ func SyncChangeset(ctx context.Context, syncStore SyncStore, source sources.Chan
 		return err
 	}
 
-	return tx.UpsertChangesetEvents(ctx, events...)
+	if err := tx.UpsertChangesetEvents(ctx, events...); err != nil {
+		return err
+	}
+
+	if err := state.EnqueueUnblockedStage(ctx, tx, c, wasComplete); err != nil {
+		return err
+	}
+
+	return enqueueAutomation(ctx, tx, c, events, rebase)
+}
+
+// rebaseDue reports whether the batch change that owns the changeset opted into
+// auto-rebasing and the changeset is outdated.
+func rebaseDue(ctx context.Context, syncStore SyncStore, repo *types.Repo, c *btypes.Changeset) bool {
+	if c.OwnedByBatchChangeID == 0 || c.CurrentSpecID == 0 || c.ReconcilerState != btypes.ReconcilerStateCompleted {
+		return false
+	}
+
+	batchSpec, err := syncStore.GetAppliedBatchSpec(ctx, c.OwnedByBatchChangeID)
+	if err != nil {
+		log15.Warn("Loading applied batch spec", "changeset", c.ID, "err", err)
+		return false
+	}
+	if batchSpec == nil || !batchSpec.Spec.AutoRebase {
+		return false
+	}
+
+	outdated, _, err := state.IsOutdated(ctx, repo.Name, c)
+	if err != nil {
+		log15.Warn("Checking whether changeset is outdated", "changeset", c.ID, "err", err)
+	}
+	return outdated
+}
+
+// enqueueAutomation enqueues the changeset for the reconciler if the batch
+// change that owns it automates an operation that is due now: rebasing the
+// changeset, as told by rebase, or merging it, if it satisfies the auto-merge
+// policy of the batch change.
+func enqueueAutomation(ctx context.Context, tx *store.Store, c *btypes.Changeset, events []*btypes.ChangesetEvent, rebase bool) error {
+	if c.OwnedByBatchChangeID == 0 || c.ReconcilerState != btypes.ReconcilerStateCompleted {
+		return nil
+	}
+
+	batchSpec, err := tx.GetAppliedBatchSpec(ctx, c.OwnedByBatchChangeID)
+	if err != nil || batchSpec == nil {
+		return err
+	}
+
+	due := rebase
+	if !due {
+		ok, err := state.CanAutoMerge(batchSpec.Spec.AutoMerge, c, events, tx.Clock()())
+		if err != nil {
+			// An invalid policy is rejected when the batch spec is created, so
+			// this shouldn't happen, but it mustn't fail the sync either.
+			log15.Warn("Evaluating auto-merge policy", "changeset", c.ID, "err", err)
+		}
+		due = ok
+	}
+
+	if !due {
+		return nil
+	}
+	return tx.EnqueueChangeset(ctx, c, global.DefaultReconcilerEnqueueState(), btypes.ReconcilerStateCompleted)
 }
 
 func loadChangesetSource(ctx context.Context, cf *httpcli.Factory, syncStore SyncStore, repo *types.Repo) (sources.ChangesetSource, error) {
@@ -341,6 +341,7 @@ type MockSyncStore struct {
 	upsertChangesetEvents        func(context.Context, ...*btypes.ChangesetEvent) error
 	getSiteCredential            func(ctx context.Context, opts store.GetSiteCredentialOpts) (*btypes.SiteCredential, error)
 	getExternalServiceIDs        func(ctx context.Context, opts store.GetExternalServiceIDsOpts) ([]int64, error)
+	getAppliedBatchSpec          func(ctx context.Context, batchChangeID int64) (*btypes.BatchSpec, error)
 	transact                     func(context.Context) (*store.Store, error)
 }
 
@@ -368,6 +369,10 @@ func (m MockSyncStore) GetExternalServiceIDs(ctx context.Context, opts store.Get
 	return m.getExternalServiceIDs(ctx, opts)
 }
 
+func (m MockSyncStore) GetAppliedBatchSpec(ctx context.Context, batchChangeID int64) (*btypes.BatchSpec, error) {
+	return m.getAppliedBatchSpec(ctx, batchChangeID)
+}
+
 func (m MockSyncStore) Transact(ctx context.Context) (*store.Store, error) {
 	return m.transact(ctx)
 }
@@ -3,10 +3,13 @@ package types
 import (
 	"time"
 
+	"github.com/cockroachdb/errors"
+	"github.com/gobwas/glob"
 	"github.com/sourcegraph/batch-change-utils/env"
 	"github.com/sourcegraph/batch-change-utils/overridable"
 	"github.com/sourcegraph/batch-change-utils/yaml"
 
+	"github.com/sourcegraph/sourcegraph/enterprise/internal/batches/types/scheduler/window"
 	"github.com/sourcegraph/sourcegraph/schema"
 )
 
@@ -41,7 +44,28 @@ func (cs *BatchSpec) Clone() *BatchSpec {
 // UnmarshalValidate unmarshals the RawSpec into Spec and validates it against
 // the BatchSpec schema and does additional semantic validation.
 func (cs *BatchSpec) UnmarshalValidate() error {
-	return yaml.UnmarshalValidate(schema.BatchSpecSchemaJSON, []byte(cs.RawSpec), &cs.Spec)
+	if err := yaml.UnmarshalValidate(schema.BatchSpecSchemaJSON, []byte(cs.RawSpec), &cs.Spec); err != nil {
+		return err
+	}
+
+	if cs.Spec.AutoMerge != nil {
+		if _, err := cs.Spec.AutoMerge.MergeWindows(); err != nil {
+			return errors.Wrap(err, "autoMerge")
+		}
+	}
+
+	names := make(map[string]struct{}, len(cs.Spec.Stages))
+	for _, stage := range cs.Spec.Stages {
+		if _, ok := names[stage.Name]; ok {
+			return errors.Errorf("stages: duplicate stage %q", stage.Name)
+		}
+		names[stage.Name] = struct{}{}
+
+		if _, err := stage.patterns(); err != nil {
+			return errors.Wrapf(err, "stages: %s", stage.Name)
+		}
+	}
+	return nil
 }
 
 // BatchSpecTTL specifies the TTL of BatchSpecs that haven't been applied
@@ -61,6 +85,25 @@ type BatchSpecFields struct {
 	Steps             []BatchSpecStep              `json:"steps,omitempty" yaml:"steps,omitempty"`
 	ImportChangeset   []BatchChangeImportChangeset `json:"importChangesets,omitempty" yaml:"importChangesets,omitempty"`
 	ChangesetTemplate ChangesetTemplate            `json:"changesetTemplate,omitempty" yaml:"changesetTemplate,omitempty"`
+	AutoMerge         *AutoMergePolicy             `json:"autoMerge,omitempty" yaml:"autoMerge,omitempty"`
+	AutoRebase        bool                         `json:"autoRebase,omitempty" yaml:"autoRebase,omitempty"`
+	Stages            []BatchChangeStage           `json:"stages,omitempty" yaml:"stages,omitempty"`
+}
+
+// Stage returns the index of the stage that changesets in the given repository
+// belong to, which is the first stage with a pattern matching the name of the
+// repository. It returns -1 if the repository doesn't belong to any stage.
+func (s *BatchSpecFields) Stage(repo string) int {
+	for i, stage := range s.Stages {
+		// The patterns have been validated when the batch spec was created.
+		patterns, _ := stage.patterns()
+		for _, p := range patterns {
+			if p.Match(repo) {
+				return i
+			}
+		}
+	}
+	return -1
 }
 
 type BatchSpecOn struct {
@@ -90,3 +133,73 @@ type ChangesetTemplate struct {
 type CommitTemplate struct {
 	Message string `json:"message,omitempty" yaml:"message,omitempty"`
 }
+
+// AutoMergePolicy describes when the changesets of a batch change are merged
+// automatically.
+type AutoMergePolicy struct {
+	Approvals     *int              `json:"approvals,omitempty" yaml:"approvals,omitempty"`
+	RequireChecks *bool             `json:"requireChecks,omitempty" yaml:"requireChecks,omitempty"`
+	Squash        bool              `json:"squash,omitempty" yaml:"squash,omitempty"`
+	Windows       []AutoMergeWindow `json:"windows,omitempty" yaml:"windows,omitempty"`
+}
+
+type AutoMergeWindow struct {
+	Days  []string `json:"days,omitempty" yaml:"days,omitempty"`
+	Start string   `json:"start,omitempty" yaml:"start,omitempty"`
+	End   string   `json:"end,omitempty" yaml:"end,omitempty"`
+}
+
+// RequiredApprovals returns the minimum number of approvals a changeset needs
+// to be merged, which defaults to 1.
+func (p *AutoMergePolicy) RequiredApprovals() int {
+	if p.Approvals == nil {
+		return 1
+	}
+	return *p.Approvals
+}
+
+// ChecksRequired returns whether all checks of a changeset must have passed
+// for it to be merged, which defaults to true.
+func (p *AutoMergePolicy) ChecksRequired() bool {
+	return p.RequireChecks == nil || *p.RequireChecks
+}
+
+// MergeWindows returns the windows during which changesets may be merged.
+// They use the same format as the rollout windows of the site configuration,
+// without rates.
+func (p *AutoMergePolicy) MergeWindows() (*window.Configuration, error) {
+	if len(p.Windows) == 0 {
+		return window.NewConfiguration(nil)
+	}
+
+	raw := make([]*schema.BatchChangeRolloutWindow, 0, len(p.Windows))
+	for _, w := range p.Windows {
+		raw = append(raw, &schema.BatchChangeRolloutWindow{
+			Days:  w.Days,
+			Start: w.Start,
+			End:   w.End,
+			Rate:  "unlimited",
+		})
+	}
+	return window.NewConfiguration(&raw)
+}
+
+// BatchChangeStage is a group of repositories whose changesets are published
+// together, once the changesets of all previous stages have been merged or
+// closed.
+type BatchChangeStage struct {
+	Name         string   `json:"name" yaml:"name"`
+	Repositories []string `json:"repositories" yaml:"repositories"`
+}
+
+func (s *BatchChangeStage) patterns() ([]glob.Glob, error) {
+	patterns := make([]glob.Glob, 0, len(s.Repositories))
+	for _, r := range s.Repositories {
+		p, err := glob.Compile(r, '/')
+		if err != nil {
+			return nil, errors.Wrapf(err, "compiling repository pattern %q", r)
+		}
+		patterns = append(patterns, p)
+	}
+	return patterns, nil
+}
@@ -3,6 +3,7 @@ package types
 import (
 	"fmt"
 	"testing"
+	"time"
 
 	"github.com/google/go-cmp/cmp"
 )
@@ -109,3 +110,146 @@ changesetTemplate:
 		})
 	}
 }
+
+func TestBatchSpecAutoMerge(t *testing.T) {
+	const base = `
+name: my-unique-name
+changesetTemplate:
+  title: Hello World
+  branch: hello-world
+  commit:
+    message: Append Hello World to all README.md files
+`
+
+	t.Run("defaults", func(t *testing.T) {
+		spec, err := NewBatchSpecFromRaw(base + `
+autoMerge:
+  squash: true
+`)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		p := spec.Spec.AutoMerge
+		if p == nil {
+			t.Fatal("auto-merge policy not set")
+		}
+		if have, want := p.RequiredApprovals(), 1; have != want {
+			t.Errorf("unexpected required approvals: have=%d want=%d", have, want)
+		}
+		if !p.ChecksRequired() {
+			t.Error("checks not required")
+		}
+		if !p.Squash {
+			t.Error("squash not set")
+		}
+	})
+
+	t.Run("explicit", func(t *testing.T) {
+		spec, err := NewBatchSpecFromRaw(base + `
+autoMerge:
+  approvals: 0
+  requireChecks: false
+  windows:
+  - days: [monday]
+    start: "09:00"
+    end: "17:00"
+`)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		p := spec.Spec.AutoMerge
+		if have, want := p.RequiredApprovals(), 0; have != want {
+			t.Errorf("unexpected required approvals: have=%d want=%d", have, want)
+		}
+		if p.ChecksRequired() {
+			t.Error("checks required")
+		}
+
+		windows, err := p.MergeWindows()
+		if err != nil {
+			t.Fatal(err)
+		}
+		// 2021-08-09 is a Monday.
+		if !windows.IsOpen(time.Date(2021, 8, 9, 10, 0, 0, 0, time.UTC)) {
+			t.Error("window not open on Monday morning")
+		}
+		if windows.IsOpen(time.Date(2021, 8, 10, 10, 0, 0, 0, time.UTC)) {
+			t.Error("window open on Tuesday morning")
+		}
+	})
+
+	t.Run("invalid window", func(t *testing.T) {
+		_, err := NewBatchSpecFromRaw(base + `
+autoMerge:
+  windows:
+  - start: "17:00"
+    end: "09:00"
+`)
+		if err == nil {
+			t.Fatal("unexpected nil error")
+		}
+	})
+}
+
+func TestBatchSpecStages(t *testing.T) {
+	const base = `
+name: my-unique-name
+changesetTemplate:
+  title: Hello World
+  branch: hello-world
+  commit:
+    message: Append Hello World to all README.md files
+`
+
+	t.Run("matching", func(t *testing.T) {
+		spec, err := NewBatchSpecFromRaw(base + `
+stages:
+- name: libraries
+  repositories:
+  - github.com/sourcegraph/go-*
+- name: services
+  repositories:
+  - github.com/sourcegraph/*
+`)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for repo, want := range map[string]int{
+			"github.com/sourcegraph/go-diff":     0,
+			"github.com/sourcegraph/sourcegraph": 1,
+			"github.com/sourcegraph/a/b":         -1,
+			"gitlab.com/sourcegraph/go-diff":     -1,
+		} {
+			if have := spec.Spec.Stage(repo); have != want {
+				t.Errorf("unexpected stage for %q: have=%d want=%d", repo, have, want)
+			}
+		}
+	})
+
+	t.Run("duplicate name", func(t *testing.T) {
+		_, err := NewBatchSpecFromRaw(base + `
+stages:
+- name: libraries
+  repositories: [github.com/sourcegraph/go-*]
+- name: libraries
+  repositories: [github.com/sourcegraph/*]
+`)
+		if err == nil {
+			t.Fatal("unexpected nil error")
+		}
+	})
+
+	t.Run("invalid pattern", func(t *testing.T) {
+		_, err := NewBatchSpecFromRaw(base + `
+stages:
+- name: libraries
+  repositories: ["github.com/sourcegraph/[go-*"]
+`)
+		if err == nil {
+			t.Fatal("unexpected nil error")
+		}
+	})
+}
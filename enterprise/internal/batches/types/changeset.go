@@ -11,6 +11,9 @@ import (
 
 	"github.com/sourcegraph/sourcegraph/internal/api"
 	"github.com/sourcegraph/sourcegraph/internal/extsvc"
+	"github.com/sourcegraph/sourcegraph/internal/extsvc/awscodecommit"
+	"github.com/sourcegraph/sourcegraph/internal/extsvc/azuredevops"
+	"github.com/sourcegraph/sourcegraph/internal/extsvc/bitbucketcloud"
 	"github.com/sourcegraph/sourcegraph/internal/extsvc/bitbucketserver"
 	"github.com/sourcegraph/sourcegraph/internal/extsvc/github"
 	"github.com/sourcegraph/sourcegraph/internal/extsvc/gitlab"
@@ -379,6 +382,24 @@ func (c *Changeset) SetMetadata(meta interface{}) error {
 		c.ExternalServiceType = extsvc.TypeGitLab
 		c.ExternalBranch = git.EnsureRefPrefix(pr.SourceBranch)
 		c.ExternalUpdatedAt = pr.UpdatedAt.Time
+	case *azuredevops.PullRequest:
+		c.Metadata = pr
+		c.ExternalID = strconv.Itoa(pr.PullRequestID)
+		c.ExternalServiceType = extsvc.TypeAzureDevOps
+		c.ExternalBranch = git.EnsureRefPrefix(pr.SourceRefName)
+		c.ExternalUpdatedAt = pr.LastUpdated()
+	case *bitbucketcloud.PullRequest:
+		c.Metadata = pr
+		c.ExternalID = strconv.FormatInt(pr.ID, 10)
+		c.ExternalServiceType = extsvc.TypeBitbucketCloud
+		c.ExternalBranch = git.EnsureRefPrefix(pr.Source.Branch.Name)
+		c.ExternalUpdatedAt = pr.UpdatedOn
+	case *awscodecommit.PullRequest:
+		c.Metadata = pr
+		c.ExternalID = pr.ID
+		c.ExternalServiceType = extsvc.TypeAWSCodeCommit
+		c.ExternalBranch = git.EnsureRefPrefix(pr.SourceReference)
+		c.ExternalUpdatedAt = pr.LastActivityDate
 	default:
 		return errors.New("unknown changeset type")
 	}
@@ -404,6 +425,12 @@ func (c *Changeset) Title() (string, error) {
 		return m.Title, nil
 	case *gitlab.MergeRequest:
 		return m.Title, nil
+	case *azuredevops.PullRequest:
+		return m.Title, nil
+	case *bitbucketcloud.PullRequest:
+		return m.Title, nil
+	case *awscodecommit.PullRequest:
+		return m.Title, nil
 	default:
 		return "", errors.New("unknown changeset type")
 	}
@@ -421,6 +448,12 @@ func (c *Changeset) AuthorName() (string, error) {
 		return m.Author.User.Name, nil
 	case *gitlab.MergeRequest:
 		return m.Author.Username, nil
+	case *azuredevops.PullRequest:
+		return m.CreatedBy.UniqueName, nil
+	case *bitbucketcloud.PullRequest:
+		return m.Author.Nickname, nil
+	case *awscodecommit.PullRequest:
+		return m.AuthorName(), nil
 	default:
 		return "", errors.New("unknown changeset type")
 	}
@@ -444,6 +477,16 @@ func (c *Changeset) AuthorEmail() (string, error) {
 		return m.Author.User.EmailAddress, nil
 	case *gitlab.MergeRequest:
 		return m.Author.Email, nil
+	case *azuredevops.PullRequest:
+		// The unique name of Azure Active Directory users is their email
+		// address, but it isn't one for other users.
+		if strings.Contains(m.CreatedBy.UniqueName, "@") {
+			return m.CreatedBy.UniqueName, nil
+		}
+		return "", nil
+	case *bitbucketcloud.PullRequest, *awscodecommit.PullRequest:
+		// Neither API returns the email addresses of users.
+		return "", nil
 	default:
 		return "", errors.New("unknown changeset type")
 	}
@@ -460,6 +503,12 @@ func (c *Changeset) ExternalCreatedAt() time.Time {
 		return unixMilliToTime(int64(m.CreatedDate))
 	case *gitlab.MergeRequest:
 		return m.CreatedAt.Time
+	case *azuredevops.PullRequest:
+		return m.CreationDate
+	case *bitbucketcloud.PullRequest:
+		return m.CreatedOn
+	case *awscodecommit.PullRequest:
+		return m.CreationDate
 	default:
 		return time.Time{}
 	}
@@ -474,6 +523,12 @@ func (c *Changeset) Body() (string, error) {
 		return m.Description, nil
 	case *gitlab.MergeRequest:
 		return m.Description, nil
+	case *azuredevops.PullRequest:
+		return m.Description, nil
+	case *bitbucketcloud.PullRequest:
+		return m.Description, nil
+	case *awscodecommit.PullRequest:
+		return m.Description, nil
 	default:
 		return "", errors.New("unknown changeset type")
 	}
@@ -511,6 +566,12 @@ func (c *Changeset) URL() (s string, err error) {
 		return selfLink.Href, nil
 	case *gitlab.MergeRequest:
 		return m.WebURL, nil
+	case *azuredevops.PullRequest:
+		return m.WebURL, nil
+	case *bitbucketcloud.PullRequest:
+		return m.Links.HTML.Href, nil
+	case *awscodecommit.PullRequest:
+		return m.WebURL, nil
 	default:
 		return "", errors.New("unknown changeset type")
 	}
@@ -658,6 +719,16 @@ func (c *Changeset) HeadRefOid() (string, error) {
 		return "", nil
 	case *gitlab.MergeRequest:
 		return m.DiffRefs.HeadSHA, nil
+	case *azuredevops.PullRequest:
+		if m.LastMergeSourceCommit == nil {
+			return "", nil
+		}
+		return m.LastMergeSourceCommit.CommitID, nil
+	case *bitbucketcloud.PullRequest:
+		// Bitbucket Cloud only returns abbreviated commit hashes.
+		return "", nil
+	case *awscodecommit.PullRequest:
+		return m.SourceCommit, nil
 	default:
 		return "", errors.New("unknown changeset type")
 	}
@@ -673,6 +744,12 @@ func (c *Changeset) HeadRef() (string, error) {
 		return m.FromRef.ID, nil
 	case *gitlab.MergeRequest:
 		return "refs/heads/" + m.SourceBranch, nil
+	case *azuredevops.PullRequest:
+		return m.SourceRefName, nil
+	case *bitbucketcloud.PullRequest:
+		return "refs/heads/" + m.Source.Branch.Name, nil
+	case *awscodecommit.PullRequest:
+		return m.SourceReference, nil
 	default:
 		return "", errors.New("unknown changeset type")
 	}
@@ -689,6 +766,16 @@ func (c *Changeset) BaseRefOid() (string, error) {
 		return "", nil
 	case *gitlab.MergeRequest:
 		return m.DiffRefs.BaseSHA, nil
+	case *azuredevops.PullRequest:
+		if m.LastMergeTargetCommit == nil {
+			return "", nil
+		}
+		return m.LastMergeTargetCommit.CommitID, nil
+	case *bitbucketcloud.PullRequest:
+		// Bitbucket Cloud only returns abbreviated commit hashes.
+		return "", nil
+	case *awscodecommit.PullRequest:
+		return m.DestinationCommit, nil
 	default:
 		return "", errors.New("unknown changeset type")
 	}
@@ -704,11 +791,47 @@ func (c *Changeset) BaseRef() (string, error) {
 		return m.ToRef.ID, nil
 	case *gitlab.MergeRequest:
 		return "refs/heads/" + m.TargetBranch, nil
+	case *azuredevops.PullRequest:
+		return m.TargetRefName, nil
+	case *bitbucketcloud.PullRequest:
+		return "refs/heads/" + m.Destination.Branch.Name, nil
+	case *awscodecommit.PullRequest:
+		return m.DestinationReference, nil
 	default:
 		return "", errors.New("unknown changeset type")
 	}
 }
 
+// HasConflicts reports whether the code host reports that the changeset can't
+// be merged into its base branch because of conflicts. ok is false if the code
+// host doesn't report it or hasn't checked it yet.
+func (c *Changeset) HasConflicts() (conflicts, ok bool) {
+	switch m := c.Metadata.(type) {
+	case *github.PullRequest:
+		switch m.Mergeable {
+		case "CONFLICTING":
+			return true, true
+		case "MERGEABLE":
+			return false, true
+		}
+	case *gitlab.MergeRequest:
+		switch m.MergeStatus {
+		case "cannot_be_merged":
+			return true, true
+		case "can_be_merged":
+			return false, true
+		}
+	case *azuredevops.PullRequest:
+		switch m.MergeStatus {
+		case "conflicts":
+			return true, true
+		case "succeeded":
+			return false, true
+		}
+	}
+	return false, false
+}
+
 // AttachedTo returns true if the changeset is currently attached to the batch
 // change with the given batchChangeID.
 func (c *Changeset) AttachedTo(batchChangeID int64) bool {
@@ -981,6 +1104,9 @@ func ChangesetEventKindFor(e interface{}) (ChangesetEventKind, error) {
 		return ChangesetEventKindGitLabReopened, nil
 	case *gitlab.MergeRequestMergedEvent:
 		return ChangesetEventKindGitLabMerged, nil
+
+	case *ChangesetRebase:
+		return ChangesetEventKindBatchesRebased, nil
 	}
 
 	return ChangesetEventKindInvalid, errors.Errorf("unknown changeset event kind for %T", e)
@@ -1061,6 +1187,8 @@ func NewChangesetEventMetadata(k ChangesetEventKind) (interface{}, error) {
 		case ChangesetEventKindGitLabReopened:
 			return new(gitlab.MergeRequestReopenedEvent), nil
 		}
+	case k == ChangesetEventKindBatchesRebased:
+		return new(ChangesetRebase), nil
 	}
 	return nil, errors.Errorf("unknown changeset event kind %q", k)
 }
@@ -78,9 +78,24 @@ const (
 	ChangesetEventKindGitLabMarkWorkInProgress   ChangesetEventKind = "gitlab:mark_wip"
 	ChangesetEventKindGitLabUnmarkWorkInProgress ChangesetEventKind = "gitlab:unmark_wip"
 
+	// ChangesetEventKindBatchesRebased is recorded by the reconciler, not
+	// the code host, when it regenerated the commit of a changeset against
+	// the latest commit of its base branch.
+	ChangesetEventKindBatchesRebased ChangesetEventKind = "batches:rebased"
+
 	ChangesetEventKindInvalid ChangesetEventKind = "invalid"
 )
 
+// ChangesetRebase is the metadata of a ChangesetEventKindBatchesRebased event.
+type ChangesetRebase struct {
+	// BaseRev is the commit of the base branch the changeset was rebased onto.
+	BaseRev   string
+	RebasedAt time.Time
+}
+
+// Key is a unique key identifying this event in the context of its changeset.
+func (r *ChangesetRebase) Key() string { return r.BaseRev }
+
 // A ChangesetEvent is an event that happened in the lifetime
 // and context of a Changeset.
 type ChangesetEvent struct {
@@ -240,6 +255,8 @@ func (e *ChangesetEvent) Timestamp() time.Time {
 		// fall back to the event record we created when we received the
 		// webhook.
 		t = e.CreatedAt
+	case *ChangesetRebase:
+		t = ev.RebasedAt
 	}
 
 	return t
@@ -722,6 +739,10 @@ func (e *ChangesetEvent) Update(o *ChangesetEvent) error {
 		// We always get the full event, so safe to replace it
 		*e = *o
 
+	case *ChangesetRebase:
+		o := o.Metadata.(*ChangesetRebase)
+		*e = *o
+
 	default:
 		return errors.Errorf("unknown changeset event metadata %T", e)
 	}
@@ -84,6 +84,22 @@ func (cfg *Configuration) HasRolloutWindows() bool {
 	return len(cfg.windows) != 0
 }
 
+// IsOpen returns true if any window is open at the given time, or if no
+// windows have been defined. The rates of the windows are not taken into
+// account.
+func (cfg *Configuration) IsOpen(at time.Time) bool {
+	if !cfg.HasRolloutWindows() {
+		return true
+	}
+
+	for i := range cfg.windows {
+		if cfg.windows[i].IsOpen(at) {
+			return true
+		}
+	}
+	return false
+}
+
 // Schedule returns the currently active schedule.
 func (cfg *Configuration) Schedule() *Schedule {
 	// If there are no rollout windows, then we return an unlimited schedule and
@@ -157,6 +157,59 @@ func TestConfiguration_Estimate(t *testing.T) {
 	})
 }
 
+func TestConfiguration_IsOpen(t *testing.T) {
+	// Wednesday, 2021-08-11.
+	wednesday := func(hour int) time.Time {
+		return time.Date(2021, 8, 11, hour, 0, 0, 0, time.UTC)
+	}
+
+	for name, tc := range map[string]struct {
+		cfg  *Configuration
+		at   time.Time
+		want bool
+	}{
+		"no windows": {
+			cfg:  &Configuration{},
+			at:   wednesday(3),
+			want: true,
+		},
+		"inside a window": {
+			cfg: &Configuration{windows: []Window{
+				{days: newWeekdaySet(time.Wednesday), start: timeOfDayPtr(9, 0), end: timeOfDayPtr(17, 0)},
+			}},
+			at:   wednesday(10),
+			want: true,
+		},
+		"outside of the window times": {
+			cfg: &Configuration{windows: []Window{
+				{days: newWeekdaySet(time.Wednesday), start: timeOfDayPtr(9, 0), end: timeOfDayPtr(17, 0)},
+			}},
+			at:   wednesday(18),
+			want: false,
+		},
+		"outside of the window days": {
+			cfg: &Configuration{windows: []Window{
+				{days: newWeekdaySet(time.Monday, time.Tuesday)},
+			}},
+			at:   wednesday(10),
+			want: false,
+		},
+		"zero rate windows are open": {
+			cfg: &Configuration{windows: []Window{
+				{days: newWeekdaySet(), rate: rate{n: 0}},
+			}},
+			at:   wednesday(10),
+			want: true,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			if have := tc.cfg.IsOpen(tc.at); have != tc.want {
+				t.Errorf("unexpected result: have=%v want=%v", have, tc.want)
+			}
+		})
+	}
+}
+
 func TestConfiguration_Schedule(t *testing.T) {
 	// We have other tests to test the actual implementation of scheduleAt();
 	// this is purely to ensure that we do the special case handling of not
@@ -24,6 +24,9 @@ var SupportedExternalServices = map[string]CodehostCapabilities{
 	extsvc.TypeGitHub:          {CodehostCapabilityLabels: true, CodehostCapabilityDraftChangesets: true},
 	extsvc.TypeBitbucketServer: {},
 	extsvc.TypeGitLab:          {CodehostCapabilityLabels: true, CodehostCapabilityDraftChangesets: true},
+	extsvc.TypeAzureDevOps:     {CodehostCapabilityDraftChangesets: true},
+	extsvc.TypeBitbucketCloud:  {},
+	extsvc.TypeAWSCodeCommit:   {},
 }
 
 // IsRepoSupported returns whether the given ExternalRepoSpec is supported by
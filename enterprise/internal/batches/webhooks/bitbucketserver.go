@@ -11,7 +11,9 @@ import (
 	"github.com/hashicorp/go-multierror"
 	"github.com/inconshreveable/log15"
 
+	fewebhooks "github.com/sourcegraph/sourcegraph/cmd/frontend/webhooks"
 	"github.com/sourcegraph/sourcegraph/enterprise/internal/batches/store"
+	"github.com/sourcegraph/sourcegraph/internal/api"
 	"github.com/sourcegraph/sourcegraph/internal/database"
 	"github.com/sourcegraph/sourcegraph/internal/extsvc"
 	"github.com/sourcegraph/sourcegraph/internal/extsvc/bitbucketserver"
@@ -25,7 +27,7 @@ type BitbucketServerWebhook struct {
 
 func NewBitbucketServerWebhook(store *store.Store) *BitbucketServerWebhook {
 	return &BitbucketServerWebhook{
-		Webhook: &Webhook{store, extsvc.TypeBitbucketServer},
+		Webhook: newWebhook(store, extsvc.TypeBitbucketServer),
 	}
 }
 
@@ -42,6 +44,17 @@ func (h *BitbucketServerWebhook) ServeHTTP(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
+	if push, ok := e.(*bitbucketserver.RepoPushEvent); ok {
+		if err := fewebhooks.EnqueueRepoUpdate(r.Context(), h.Store.Repos(), api.ExternalRepoSpec{
+			ID:          strconv.Itoa(push.Repository.ID),
+			ServiceType: h.ServiceType,
+			ServiceID:   externalServiceID,
+		}); err != nil {
+			respond(w, http.StatusInternalServerError, err)
+		}
+		return
+	}
+
 	prs, ev := h.convertEvent(e)
 
 	m := new(multierror.Error)
@@ -56,6 +69,12 @@ func (h *BitbucketServerWebhook) ServeHTTP(w http.ResponseWriter, r *http.Reques
 			m = multierror.Append(m, err)
 		}
 	}
+
+	if comment, ok := h.convertComment(e); ok {
+		if err := h.handleCommand(r.Context(), externalServiceID, comment); err != nil {
+			m = multierror.Append(m, err)
+		}
+	}
 	if m.ErrorOrNil() != nil {
 		respond(w, http.StatusInternalServerError, m)
 	}
@@ -147,3 +166,24 @@ func (h *BitbucketServerWebhook) convertEvent(theirs interface{}) (prs []PR, our
 
 	return
 }
+
+// convertComment returns the comment that was added to a pull request in the
+// given event, if any.
+func (h *BitbucketServerWebhook) convertComment(theirs interface{}) (prComment, bool) {
+	e, ok := theirs.(*bitbucketserver.PullRequestActivityEvent)
+	if !ok || e.Activity == nil || e.Activity.Comment == nil {
+		return prComment{}, false
+	}
+	if e.Activity.Action != bitbucketserver.CommentedActivityAction || e.Activity.CommentAction != "ADDED" {
+		return prComment{}, false
+	}
+
+	return prComment{
+		PR: PR{
+			ID:             int64(e.PullRequest.ID),
+			RepoExternalID: strconv.Itoa(e.PullRequest.FromRef.Repository.ID),
+		},
+		Body:            e.Activity.Comment.Text,
+		AuthorAccountID: int64(e.Activity.Comment.Author.ID),
+	}, true
+}
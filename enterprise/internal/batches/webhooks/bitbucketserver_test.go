@@ -22,11 +22,13 @@ import (
 	"github.com/sourcegraph/sourcegraph/enterprise/internal/batches/syncer"
 	ct "github.com/sourcegraph/sourcegraph/enterprise/internal/batches/testing"
 	btypes "github.com/sourcegraph/sourcegraph/enterprise/internal/batches/types"
+	"github.com/sourcegraph/sourcegraph/internal/api"
 	"github.com/sourcegraph/sourcegraph/internal/database"
 	"github.com/sourcegraph/sourcegraph/internal/extsvc"
 	"github.com/sourcegraph/sourcegraph/internal/httptestutil"
 	"github.com/sourcegraph/sourcegraph/internal/rcache"
 	"github.com/sourcegraph/sourcegraph/internal/repos"
+	"github.com/sourcegraph/sourcegraph/internal/repoupdater"
 	"github.com/sourcegraph/sourcegraph/internal/repoupdater/protocol"
 	"github.com/sourcegraph/sourcegraph/internal/timeutil"
 	"github.com/sourcegraph/sourcegraph/internal/types"
@@ -221,5 +223,38 @@ func testBitbucketWebhook(db *sql.DB, userID int32) func(*testing.T) {
 
 			})
 		}
+
+		t.Run("repo push", func(t *testing.T) {
+			var enqueued []api.RepoName
+			repoupdater.MockEnqueueRepoUpdateFromWebhook = func(ctx context.Context, name api.RepoName) (*protocol.RepoUpdateResponse, error) {
+				enqueued = append(enqueued, name)
+				return &protocol.RepoUpdateResponse{}, nil
+			}
+			defer func() { repoupdater.MockEnqueueRepoUpdateFromWebhook = nil }()
+
+			data := []byte(`{
+				"eventKey": "repo:refs_changed",
+				"date": "2021-06-01T10:00:00+0000",
+				"repository": {"id": ` + bitbucketRepo.ExternalRepo.ID + `, "slug": "automation-testing"},
+				"changes": [{"refId": "refs/heads/master", "fromHash": "a", "toHash": "b", "type": "UPDATE"}]
+			}`)
+			u := extsvc.WebhookURL(extsvc.TypeBitbucketServer, extSvc.ID, "https://example.com/")
+			req, err := http.NewRequest("POST", u, bytes.NewReader(data))
+			if err != nil {
+				t.Fatal(err)
+			}
+			req.Header.Set("X-Event-Key", "repo:refs_changed")
+			req.Header.Set("X-Hub-Signature", sign(t, data, []byte(secret)))
+
+			rec := httptest.NewRecorder()
+			hook.ServeHTTP(rec, req)
+			if resp := rec.Result(); resp.StatusCode != http.StatusOK {
+				t.Fatalf("Non 200 code: %v", resp.StatusCode)
+			}
+
+			if diff := cmp.Diff([]api.RepoName{bitbucketRepo.Name}, enqueued); diff != "" {
+				t.Errorf("unexpected enqueued repos (-want +have):\n%s", diff)
+			}
+		})
 	}
 }
@@ -0,0 +1,195 @@
+package webhooks
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/cockroachdb/errors"
+	"github.com/inconshreveable/log15"
+
+	"github.com/sourcegraph/sourcegraph/enterprise/internal/batches/service"
+	"github.com/sourcegraph/sourcegraph/enterprise/internal/batches/sources"
+	"github.com/sourcegraph/sourcegraph/enterprise/internal/batches/store"
+	btypes "github.com/sourcegraph/sourcegraph/enterprise/internal/batches/types"
+	"github.com/sourcegraph/sourcegraph/internal/actor"
+	"github.com/sourcegraph/sourcegraph/internal/database"
+	"github.com/sourcegraph/sourcegraph/internal/errcode"
+	"github.com/sourcegraph/sourcegraph/internal/types"
+)
+
+// commandPrefix is the prefix of the lines of pull request comments that
+// contain commands for the changeset of the pull request.
+const commandPrefix = "/sourcegraph"
+
+// commandJobTypes maps the commands that can be given in pull request comments
+// to the changeset jobs that they create.
+var commandJobTypes = map[string]btypes.ChangesetJobType{
+	"close":  btypes.ChangesetJobTypeClose,
+	"rebase": btypes.ChangesetJobTypeRebase,
+	"retry":  btypes.ChangesetJobTypeReenqueue,
+}
+
+// parseCommand returns the changeset job type for the first command in the
+// given comment. A command is a line that consists of the command prefix and
+// the name of the command, such as "/sourcegraph rebase".
+func parseCommand(comment string) (btypes.ChangesetJobType, bool) {
+	for _, line := range strings.Split(comment, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) != 2 || fields[0] != commandPrefix {
+			continue
+		}
+		if jobType, ok := commandJobTypes[strings.ToLower(fields[1])]; ok {
+			return jobType, true
+		}
+	}
+	return "", false
+}
+
+// commandName returns the name of the command that creates changeset jobs of
+// the given type.
+func commandName(jobType btypes.ChangesetJobType) string {
+	for name, t := range commandJobTypes {
+		if t == jobType {
+			return name
+		}
+	}
+	return string(jobType)
+}
+
+func commandPayload(jobType btypes.ChangesetJobType) interface{} {
+	switch jobType {
+	case btypes.ChangesetJobTypeClose:
+		return &btypes.ChangesetJobClosePayload{}
+	case btypes.ChangesetJobTypeRebase:
+		return &btypes.ChangesetJobRebasePayload{}
+	default:
+		return &btypes.ChangesetJobReenqueuePayload{}
+	}
+}
+
+// prComment is a comment on a pull request that may contain a command.
+type prComment struct {
+	PR
+	Body string
+	// AuthorAccountID is the ID of the author of the comment on the code
+	// host.
+	AuthorAccountID int64
+}
+
+// handleCommand turns the command in the given comment, if any, into a
+// changeset job for the changeset of the pull request.
+//
+// 🚨 SECURITY: The job is created on behalf of the Sourcegraph user that the
+// author of the comment is linked to through their external account, which
+// means that the user must have access to the repository on Sourcegraph and
+// must be allowed to administer a batch change of the changeset, exactly like
+// for bulk operations in the UI. Commands that can't be run, including those
+// of authors who aren't linked to a Sourcegraph user, are answered with a
+// comment that explains why.
+func (h Webhook) handleCommand(ctx context.Context, externalServiceID string, c prComment) error {
+	jobType, ok := parseCommand(c.Body)
+	if !ok {
+		return nil
+	}
+
+	r, err := h.getRepoForPR(ctx, h.Store, c.PR, externalServiceID)
+	if err != nil {
+		log15.Debug("Webhook event could not be matched to repo", "err", err)
+		return nil
+	}
+
+	cs, err := h.Store.GetChangeset(ctx, store.GetChangesetOpts{
+		RepoID:              r.ID,
+		ExternalID:          strconv.FormatInt(c.ID, 10),
+		ExternalServiceType: h.ServiceType,
+	})
+	if err != nil {
+		if err == store.ErrNoResults {
+			err = nil // Nothing to do
+		}
+		return err
+	}
+
+	batchChangeIDs := commandBatchChanges(cs)
+	if len(batchChangeIDs) == 0 {
+		return nil
+	}
+
+	accounts, err := database.ExternalAccountsWith(h.Store).List(ctx, database.ExternalAccountsListOptions{
+		ServiceType:    h.ServiceType,
+		ServiceID:      externalServiceID,
+		AccountID:      c.AuthorAccountID,
+		ExcludeExpired: true,
+	})
+	if err != nil {
+		return errors.Wrap(err, "listing external accounts")
+	}
+	if len(accounts) == 0 {
+		log15.Debug("Rejecting changeset command of author without Sourcegraph user", "changeset", cs.ID, "account", c.AuthorAccountID)
+		h.replyToCommand(ctx, r, cs, jobType, "your account isn't connected to a Sourcegraph user")
+		return nil
+	}
+	userID := accounts[0].UserID
+	userCtx := actor.WithActor(ctx, actor.FromUser(userID))
+
+	// 🚨 SECURITY: The repository is loaded again as the user, which fails if
+	// they don't have access to it.
+	if _, err := h.Store.Repos().Get(userCtx, r.ID); err != nil {
+		if !errcode.IsNotFound(err) {
+			return errors.Wrap(err, "checking repository access")
+		}
+		log15.Warn("Rejecting changeset command of user without access to the repository", "changeset", cs.ID, "user", userID)
+		h.replyToCommand(ctx, r, cs, jobType, "you don't have access to this repository on Sourcegraph")
+		return nil
+	}
+
+	svc := service.New(h.Store)
+	for _, batchChangeID := range batchChangeIDs {
+		if _, err = svc.CreateChangesetJobs(userCtx, batchChangeID, []int64{cs.ID}, jobType, commandPayload(jobType), store.ListChangesetsOpts{}); err == nil {
+			return nil
+		}
+	}
+
+	// The command can't be run, for example because the author isn't allowed
+	// to, which isn't an error of the webhook.
+	log15.Warn("Rejecting changeset command", "changeset", cs.ID, "type", jobType, "user", userID, "err", err)
+	h.replyToCommand(ctx, r, cs, jobType, "you aren't allowed to administer a batch change of this changeset")
+	return nil
+}
+
+// replyToCommand comments on the pull request of the given changeset that the
+// command for the given job type was rejected for the given reason. The comment
+// is posted with the site credential of the code host, if any. Failing to post
+// it isn't an error of the webhook.
+func (h Webhook) replyToCommand(ctx context.Context, repo *types.Repo, cs *btypes.Changeset, jobType btypes.ChangesetJobType, reason string) {
+	reply := fmt.Sprintf("Sourcegraph can't run `%s %s` because %s.", commandPrefix, commandName(jobType), reason)
+
+	css, err := h.Sourcer.ForRepo(ctx, h.Store, repo)
+	if err == nil {
+		css, err = sources.WithSiteAuthenticator(ctx, h.Store, css, repo)
+	}
+	if err == nil {
+		err = css.CreateComment(ctx, &sources.Changeset{Changeset: cs, Repo: repo}, reply)
+	}
+	if err != nil {
+		log15.Warn("Failed to reply to changeset command", "changeset", cs.ID, "err", err)
+	}
+}
+
+// commandBatchChanges returns the IDs of the batch changes that a command for
+// the given changeset may be run in, starting with the batch change that owns
+// it.
+func commandBatchChanges(c *btypes.Changeset) []int64 {
+	ids := make([]int64, 0, len(c.BatchChanges))
+	if c.OwnedByBatchChangeID != 0 {
+		ids = append(ids, c.OwnedByBatchChangeID)
+	}
+	for _, assoc := range c.BatchChanges {
+		if assoc.BatchChangeID != c.OwnedByBatchChangeID && !assoc.Detach && !assoc.IsArchived {
+			ids = append(ids, assoc.BatchChangeID)
+		}
+	}
+	return ids
+}
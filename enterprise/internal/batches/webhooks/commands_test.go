@@ -0,0 +1,164 @@
+package webhooks
+
+import (
+	"context"
+	"strconv"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/sourcegraph/sourcegraph/enterprise/internal/batches/sources"
+	"github.com/sourcegraph/sourcegraph/enterprise/internal/batches/store"
+	ct "github.com/sourcegraph/sourcegraph/enterprise/internal/batches/testing"
+	btypes "github.com/sourcegraph/sourcegraph/enterprise/internal/batches/types"
+	"github.com/sourcegraph/sourcegraph/internal/actor"
+	"github.com/sourcegraph/sourcegraph/internal/database"
+	"github.com/sourcegraph/sourcegraph/internal/database/dbtest"
+	"github.com/sourcegraph/sourcegraph/internal/extsvc"
+)
+
+func TestParseCommand(t *testing.T) {
+	for name, tc := range map[string]struct {
+		comment string
+		want    btypes.ChangesetJobType
+		wantOK  bool
+	}{
+		"no command": {
+			comment: "LGTM, thanks!",
+		},
+		"close": {
+			comment: "/sourcegraph close",
+			want:    btypes.ChangesetJobTypeClose,
+			wantOK:  true,
+		},
+		"rebase after text": {
+			comment: "The base branch has moved on.\r\n\r\n  /sourcegraph Rebase  \r\n",
+			want:    btypes.ChangesetJobTypeRebase,
+			wantOK:  true,
+		},
+		"retry": {
+			comment: "/sourcegraph retry",
+			want:    btypes.ChangesetJobTypeReenqueue,
+			wantOK:  true,
+		},
+		"unknown command": {
+			comment: "/sourcegraph merge",
+		},
+		"command within a line": {
+			comment: "Please don't /sourcegraph close",
+		},
+		"command with arguments": {
+			comment: "/sourcegraph close now",
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			have, ok := parseCommand(tc.comment)
+			if have != tc.want || ok != tc.wantOK {
+				t.Errorf("unexpected command: have=(%q, %v) want=(%q, %v)", have, ok, tc.want, tc.wantOK)
+			}
+		})
+	}
+}
+
+func TestCommandBatchChanges(t *testing.T) {
+	c := &btypes.Changeset{
+		OwnedByBatchChangeID: 2,
+		BatchChanges: []btypes.BatchChangeAssoc{
+			{BatchChangeID: 1},
+			{BatchChangeID: 2},
+			{BatchChangeID: 3, Detach: true},
+			{BatchChangeID: 4, IsArchived: true},
+			{BatchChangeID: 5},
+		},
+	}
+
+	if diff := cmp.Diff([]int64{2, 1, 5}, commandBatchChanges(c)); diff != "" {
+		t.Errorf("unexpected batch changes (-want +have):\n%s", diff)
+	}
+}
+
+func TestHandleCommand(t *testing.T) {
+	if testing.Short() {
+		t.Skip()
+	}
+
+	ctx := actor.WithInternalActor(context.Background())
+	db := dbtest.NewDB(t, "")
+	s := store.New(db, nil)
+
+	admin := ct.CreateTestUser(t, db, true)
+	member := ct.CreateTestUser(t, db, false)
+	outsider := ct.CreateTestUser(t, db, false)
+
+	rs, _ := ct.CreateTestRepos(t, ctx, db, 1)
+	repo := rs[0]
+	ct.MockRepoPermissions(t, db, member.ID, repo.ID)
+
+	// Link the users to GitHub accounts with the IDs 1, 2 and 3. The account
+	// with the ID 4 isn't linked to any user.
+	for i, user := range []int32{admin.ID, member.ID, outsider.ID} {
+		spec := extsvc.AccountSpec{
+			ServiceType: extsvc.TypeGitHub,
+			ServiceID:   repo.ExternalRepo.ServiceID,
+			AccountID:   strconv.Itoa(i + 1),
+		}
+		if err := database.ExternalAccounts(db).AssociateUserAndSave(ctx, user, spec, extsvc.AccountData{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	batchSpec := ct.CreateBatchSpec(t, ctx, s, "commands", admin.ID)
+	batchChange := ct.CreateBatchChange(t, ctx, s, "commands", admin.ID, batchSpec.ID)
+	ct.CreateChangeset(t, ctx, s, ct.TestChangesetOpts{
+		Repo:                repo.ID,
+		BatchChange:         batchChange.ID,
+		OwnedByBatchChange:  batchChange.ID,
+		ExternalServiceType: extsvc.TypeGitHub,
+		ExternalID:          "12",
+		PublicationState:    btypes.ChangesetPublicationStatePublished,
+		ExternalState:       btypes.ChangesetExternalStateOpen,
+		ReconcilerState:     btypes.ReconcilerStateCompleted,
+	})
+
+	numJobs := 0
+	for _, tc := range []struct {
+		name      string
+		accountID int64
+		wantJob   bool
+		wantReply bool
+	}{
+		{name: "author without Sourcegraph user", accountID: 4, wantReply: true},
+		{name: "user without access to the repository", accountID: 3, wantReply: true},
+		{name: "user who can't administer the batch change", accountID: 2, wantReply: true},
+		{name: "administrator of the batch change", accountID: 1, wantJob: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			source := &sources.FakeChangesetSource{}
+			h := newWebhook(s, extsvc.TypeGitHub)
+			h.Sourcer = sources.NewFakeSourcer(nil, source)
+
+			err := h.handleCommand(ctx, repo.ExternalRepo.ServiceID, prComment{
+				PR:              PR{ID: 12, RepoExternalID: repo.ExternalRepo.ID},
+				Body:            "/sourcegraph close",
+				AuthorAccountID: tc.accountID,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if tc.wantJob {
+				numJobs++
+			}
+			have, err := s.CountBulkOperations(ctx, store.CountBulkOperationsOpts{BatchChangeID: batchChange.ID})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if have != numJobs {
+				t.Errorf("unexpected number of changeset jobs: have=%d want=%d", have, numJobs)
+			}
+			if source.CreateCommentCalled != tc.wantReply {
+				t.Errorf("unexpected reply: have=%v want=%v", source.CreateCommentCalled, tc.wantReply)
+			}
+		})
+	}
+}
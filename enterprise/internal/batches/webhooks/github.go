@@ -40,7 +40,7 @@ type GitHubWebhook struct {
 }
 
 func NewGitHubWebhook(store *store.Store) *GitHubWebhook {
-	return &GitHubWebhook{&Webhook{store, extsvc.TypeGitHub}}
+	return &GitHubWebhook{newWebhook(store, extsvc.TypeGitHub)}
 }
 
 // Register registers this webhook handler to handle events with the passed webhook router
@@ -72,6 +72,17 @@ func (h *GitHubWebhook) handleGitHubWebhook(ctx context.Context, extSvc *types.E
 			m = multierror.Append(m, err)
 		}
 	}
+
+	if e, ok := payload.(*gh.IssueCommentEvent); ok && e.GetAction() == "created" && len(prs) == 1 {
+		comment := prComment{
+			PR:              prs[0],
+			Body:            e.GetComment().GetBody(),
+			AuthorAccountID: e.GetComment().GetUser().GetID(),
+		}
+		if err := h.handleCommand(ctx, externalServiceID, comment); err != nil {
+			m = multierror.Append(m, err)
+		}
+	}
 	return m.ErrorOrNil()
 }
 
@@ -10,8 +10,10 @@ import (
 	"github.com/cockroachdb/errors"
 	"github.com/inconshreveable/log15"
 
+	fewebhooks "github.com/sourcegraph/sourcegraph/cmd/frontend/webhooks"
 	"github.com/sourcegraph/sourcegraph/enterprise/internal/batches/store"
 	btypes "github.com/sourcegraph/sourcegraph/enterprise/internal/batches/types"
+	"github.com/sourcegraph/sourcegraph/internal/api"
 	"github.com/sourcegraph/sourcegraph/internal/database"
 	"github.com/sourcegraph/sourcegraph/internal/extsvc"
 	"github.com/sourcegraph/sourcegraph/internal/extsvc/gitlab"
@@ -26,7 +28,7 @@ type GitLabWebhook struct {
 }
 
 func NewGitLabWebhook(store *store.Store) *GitLabWebhook {
-	return &GitLabWebhook{&Webhook{store, extsvc.TypeGitLab}}
+	return &GitLabWebhook{newWebhook(store, extsvc.TypeGitLab)}
 }
 
 // ServeHTTP implements the http.Handler interface.
@@ -191,6 +193,25 @@ func (h *GitLabWebhook) handleEvent(ctx context.Context, extSvc *types.ExternalS
 		}
 		return nil
 
+	case *webhooks.NoteEvent:
+		// Comments on merge requests are synced as part of the merge request,
+		// so we only need to look for commands in them.
+		if e.MergeRequest == nil || e.Note.NoteableType != "MergeRequest" {
+			return nil
+		}
+		comment := prComment{
+			PR:              gitlabToPR(&e.Project, e.MergeRequest),
+			Body:            e.Note.Body,
+			AuthorAccountID: int64(e.User.ID),
+		}
+		if err := h.handleCommand(ctx, esID, comment); err != nil {
+			return &httpError{
+				code: http.StatusInternalServerError,
+				err:  errors.Wrap(err, "handling changeset command"),
+			}
+		}
+		return nil
+
 	case *webhooks.PipelineEvent:
 		if err := h.handlePipelineEvent(ctx, esID, e); err != nil && err != errPipelineMissingMergeRequest {
 			return &httpError{
@@ -199,6 +220,19 @@ func (h *GitLabWebhook) handleEvent(ctx context.Context, extSvc *types.ExternalS
 			}
 		}
 		return nil
+
+	case *webhooks.PushEvent:
+		if err := fewebhooks.EnqueueRepoUpdate(ctx, h.Store.Repos(), api.ExternalRepoSpec{
+			ID:          strconv.Itoa(e.Project.ID),
+			ServiceType: h.ServiceType,
+			ServiceID:   esID,
+		}); err != nil {
+			return &httpError{
+				code: http.StatusInternalServerError,
+				err:  err,
+			}
+		}
+		return nil
 	}
 
 	// We don't want to return a non-2XX status code and have GitLab retry the
@@ -10,6 +10,7 @@ import (
 	"strconv"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/cockroachdb/errors"
 	"github.com/google/go-cmp/cmp"
@@ -25,6 +26,7 @@ import (
 	"github.com/sourcegraph/sourcegraph/internal/extsvc/gitlab"
 	"github.com/sourcegraph/sourcegraph/internal/extsvc/gitlab/webhooks"
 	"github.com/sourcegraph/sourcegraph/internal/repoupdater"
+	"github.com/sourcegraph/sourcegraph/internal/repoupdater/protocol"
 	"github.com/sourcegraph/sourcegraph/internal/timeutil"
 	"github.com/sourcegraph/sourcegraph/internal/types"
 	"github.com/sourcegraph/sourcegraph/schema"
@@ -357,6 +359,121 @@ func testGitLabWebhook(db *sql.DB, userID int32) func(*testing.T) {
 				}
 			})
 
+			t.Run("merge request merge event unblocks the next stage", func(t *testing.T) {
+				s := gitLabTestSetup(t, db)
+				repoStore := database.ReposWith(s)
+				h := NewGitLabWebhook(s)
+				es := createGitLabExternalService(t, ctx, s.ExternalServices())
+				repo := createGitLabRepo(t, ctx, repoStore, es)
+				nextRepo := (&types.Repo{
+					Name: "gitlab.com/sourcegraph/next",
+					URI:  "gitlab.com/sourcegraph/next",
+					ExternalRepo: api.ExternalRepoSpec{
+						ID:          "456",
+						ServiceType: extsvc.TypeGitLab,
+						ServiceID:   "https://gitlab.com/",
+					},
+				}).With(types.Opt.RepoSources(es.URN()))
+				if err := repoStore.Create(ctx, nextRepo); err != nil {
+					t.Fatal(err)
+				}
+
+				spec := &btypes.BatchSpec{
+					Spec: btypes.BatchSpecFields{
+						Stages: []btypes.BatchChangeStage{
+							{Name: "first", Repositories: []string{string(repo.Name)}},
+							{Name: "next", Repositories: []string{string(nextRepo.Name)}},
+						},
+					},
+					NamespaceUserID: userID,
+					UserID:          userID,
+				}
+				if err := s.CreateBatchSpec(ctx, spec); err != nil {
+					t.Fatal(err)
+				}
+				batchChange := &btypes.BatchChange{
+					Name:             "stages",
+					InitialApplierID: userID,
+					NamespaceUserID:  userID,
+					LastApplierID:    userID,
+					LastAppliedAt:    s.Clock()(),
+					BatchSpecID:      spec.ID,
+				}
+				if err := s.CreateBatchChange(ctx, batchChange); err != nil {
+					t.Fatal(err)
+				}
+
+				changeset := &btypes.Changeset{
+					RepoID:               repo.ID,
+					ExternalID:           "1",
+					ExternalServiceType:  extsvc.TypeGitLab,
+					OwnedByBatchChangeID: batchChange.ID,
+					BatchChanges:         []btypes.BatchChangeAssoc{{BatchChangeID: batchChange.ID}},
+					PublicationState:     btypes.ChangesetPublicationStatePublished,
+					ExternalState:        btypes.ChangesetExternalStateOpen,
+					ReconcilerState:      btypes.ReconcilerStateCompleted,
+				}
+				blocked := &btypes.Changeset{
+					RepoID:               nextRepo.ID,
+					ExternalServiceType:  extsvc.TypeGitLab,
+					OwnedByBatchChangeID: batchChange.ID,
+					BatchChanges:         []btypes.BatchChangeAssoc{{BatchChangeID: batchChange.ID}},
+					PublicationState:     btypes.ChangesetPublicationStateUnpublished,
+					ReconcilerState:      btypes.ReconcilerStateCompleted,
+				}
+				for _, c := range []*btypes.Changeset{changeset, blocked} {
+					if err := s.CreateChangeset(ctx, c); err != nil {
+						t.Fatal(err)
+					}
+				}
+
+				body := ct.MarshalJSON(t, map[string]interface{}{
+					"object_kind": "merge_request",
+					"project": map[string]interface{}{
+						"id": 123,
+					},
+					"object_attributes": map[string]interface{}{
+						"iid":    1,
+						"action": "merge",
+					},
+					"changes": map[string]interface{}{
+						"updated_at": map[string]interface{}{
+							"current": s.Clock()().Add(time.Hour),
+						},
+					},
+				})
+
+				u := extsvc.WebhookURL(extsvc.TypeGitLab, es.ID, "https://example.com/")
+				req, err := http.NewRequest("POST", u, bytes.NewBufferString(body))
+				if err != nil {
+					t.Fatal(err)
+				}
+				req.Header.Add(webhooks.TokenHeaderName, "secret")
+
+				rec := httptest.NewRecorder()
+				h.ServeHTTP(rec, req)
+
+				if have, want := rec.Result().StatusCode, http.StatusNoContent; have != want {
+					t.Fatalf("unexpected status code: have %d; want %d", have, want)
+				}
+
+				merged, err := s.GetChangeset(ctx, store.GetChangesetOpts{ID: changeset.ID})
+				if err != nil {
+					t.Fatal(err)
+				}
+				if have, want := merged.ExternalState, btypes.ChangesetExternalStateMerged; have != want {
+					t.Fatalf("unexpected external state: have %q; want %q", have, want)
+				}
+
+				unblocked, err := s.GetChangeset(ctx, store.GetChangesetOpts{ID: blocked.ID})
+				if err != nil {
+					t.Fatal(err)
+				}
+				if have, want := unblocked.ReconcilerState, btypes.ReconcilerStateQueued; have != want {
+					t.Errorf("unexpected reconciler state of the next stage: have %q; want %q", have, want)
+				}
+			})
+
 			t.Run("valid pipeline events", func(t *testing.T) {
 				store := gitLabTestSetup(t, db)
 				repoStore := database.ReposWith(store)
@@ -570,6 +687,42 @@ func testGitLabWebhook(db *sql.DB, userID int32) func(*testing.T) {
 			})
 		})
 
+		t.Run("push events", func(t *testing.T) {
+			store := gitLabTestSetup(t, db)
+			h := NewGitLabWebhook(store)
+			es := createGitLabExternalService(t, ctx, store.ExternalServices())
+			repo := createGitLabRepo(t, ctx, database.ReposWith(store), es)
+
+			pid, err := strconv.Atoi(repo.ExternalRepo.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var enqueued []api.RepoName
+			repoupdater.MockEnqueueRepoUpdateFromWebhook = func(ctx context.Context, name api.RepoName) (*protocol.RepoUpdateResponse, error) {
+				enqueued = append(enqueued, name)
+				return &protocol.RepoUpdateResponse{}, nil
+			}
+			defer func() { repoupdater.MockEnqueueRepoUpdateFromWebhook = nil }()
+
+			for _, id := range []int{pid, 12345} {
+				event := &webhooks.PushEvent{
+					EventCommon: webhooks.EventCommon{
+						ObjectKind: "push",
+						Project:    gitlab.ProjectCommon{ID: id},
+					},
+				}
+				if err := h.handleEvent(ctx, es, event); err != nil {
+					t.Errorf("unexpected non-nil error: %+v", err)
+				}
+			}
+
+			// Pushes to unknown repos are ignored.
+			if diff := cmp.Diff([]api.RepoName{repo.Name}, enqueued); diff != "" {
+				t.Errorf("unexpected enqueued repos (-want +have):\n%s", diff)
+			}
+		})
+
 		t.Run("enqueueChangesetSyncFromEvent", func(t *testing.T) {
 			// Since these tests don't write to the database, we can just share
 			// the same database setup.
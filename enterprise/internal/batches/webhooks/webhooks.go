@@ -11,12 +11,14 @@ import (
 	"github.com/cockroachdb/errors"
 	"github.com/inconshreveable/log15"
 
+	"github.com/sourcegraph/sourcegraph/enterprise/internal/batches/sources"
 	"github.com/sourcegraph/sourcegraph/enterprise/internal/batches/state"
 	"github.com/sourcegraph/sourcegraph/enterprise/internal/batches/store"
 	btypes "github.com/sourcegraph/sourcegraph/enterprise/internal/batches/types"
 	"github.com/sourcegraph/sourcegraph/internal/api"
 	"github.com/sourcegraph/sourcegraph/internal/database"
 	"github.com/sourcegraph/sourcegraph/internal/extsvc"
+	"github.com/sourcegraph/sourcegraph/internal/httpcli"
 	"github.com/sourcegraph/sourcegraph/internal/types"
 	"github.com/sourcegraph/sourcegraph/schema"
 )
@@ -24,11 +26,22 @@ import (
 type Webhook struct {
 	Store *store.Store
 
+	// Sourcer is used to reply to commands in pull request comments.
+	Sourcer sources.Sourcer
+
 	// ServiceType corresponds to api.ExternalRepoSpec.ServiceType
 	// Example values: extsvc.TypeBitbucketServer, extsvc.TypeGitHub
 	ServiceType string
 }
 
+func newWebhook(store *store.Store, serviceType string) *Webhook {
+	return &Webhook{
+		Store:       store,
+		Sourcer:     sources.NewSourcer(httpcli.NewExternalHTTPClientFactory()),
+		ServiceType: serviceType,
+	}
+}
+
 type PR struct {
 	ID             int64
 	RepoExternalID string
@@ -169,12 +182,13 @@ func (h Webhook) upsertChangesetEvent(
 	events, _, err := tx.ListChangesetEvents(ctx, store.ListChangesetEventsOpts{
 		ChangesetIDs: []int64{cs.ID},
 	})
+	wasComplete := cs.Complete()
 	state.SetDerivedState(ctx, tx.Repos(), cs, events)
 	if err := tx.UpdateChangesetCodeHostState(ctx, cs); err != nil {
 		return err
 	}
 
-	return nil
+	return state.EnqueueUnblockedStage(ctx, tx, cs, wasComplete)
 }
 
 type httpError struct {
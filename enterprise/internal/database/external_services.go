@@ -4,6 +4,7 @@ import (
 	"database/sql"
 
 	"github.com/sourcegraph/sourcegraph/internal/authz/bitbucketserver"
+	"github.com/sourcegraph/sourcegraph/internal/authz/gitea"
 	"github.com/sourcegraph/sourcegraph/internal/authz/github"
 	"github.com/sourcegraph/sourcegraph/internal/authz/gitlab"
 	"github.com/sourcegraph/sourcegraph/internal/authz/perforce"
@@ -27,6 +28,9 @@ func NewExternalServicesStore(db dbutil.DB) *database.ExternalServiceStore {
 	es.BitbucketServerValidators = []func(*schema.BitbucketServerConnection) error{
 		bitbucketserver.ValidateAuthz,
 	}
+	es.GiteaValidators = []func(*schema.GiteaConnection) error{
+		gitea.ValidateAuthz,
+	}
 	es.PerforceValidators = []func(connection *schema.PerforceConnection) error{
 		perforce.ValidateAuthz,
 	}
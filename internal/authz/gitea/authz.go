@@ -0,0 +1,65 @@
+package gitea
+
+import (
+	"fmt"
+	"net/url"
+
+	"github.com/cockroachdb/errors"
+
+	"github.com/sourcegraph/sourcegraph/internal/authz"
+	"github.com/sourcegraph/sourcegraph/internal/extsvc"
+	"github.com/sourcegraph/sourcegraph/internal/extsvc/gitea"
+	"github.com/sourcegraph/sourcegraph/internal/types"
+	"github.com/sourcegraph/sourcegraph/schema"
+)
+
+// NewAuthzProviders returns the set of Gitea authz providers derived from the connections.
+// It also returns any validation problems with the config, separating these into "serious problems" and
+// "warnings". "Serious problems" are those that should make Sourcegraph set authz.allowAccessByDefault
+// to false. "Warnings" are all other validation problems.
+func NewAuthzProviders(
+	conns []*types.GiteaConnection,
+) (ps []authz.Provider, problems []string, warnings []string) {
+	for _, c := range conns {
+		p, err := newAuthzProvider(c)
+		if err != nil {
+			problems = append(problems, err.Error())
+		} else if p != nil {
+			ps = append(ps, p)
+		}
+	}
+
+	for _, p := range ps {
+		for _, problem := range p.Validate() {
+			warnings = append(warnings, fmt.Sprintf("Gitea config for %s was invalid: %s", p.ServiceID(), problem))
+		}
+	}
+
+	return ps, problems, warnings
+}
+
+func newAuthzProvider(c *types.GiteaConnection) (authz.Provider, error) {
+	if c.Authorization == nil {
+		return nil, nil
+	}
+
+	baseURL, err := url.Parse(c.Url)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Could not parse URL for Gitea instance %q", c.Url)
+	}
+	baseURL = extsvc.NormalizeBaseURL(baseURL)
+
+	switch idp := c.Authorization.IdentityProvider; idp.Type {
+	case "username":
+		return NewProvider(gitea.NewClient(baseURL, c.Token, nil), c.URN), nil
+	default:
+		return nil, errors.Errorf("No identityProvider was specified")
+	}
+}
+
+// ValidateAuthz validates the authorization fields of the given Gitea external
+// service config.
+func ValidateAuthz(c *schema.GiteaConnection) error {
+	_, err := newAuthzProvider(&types.GiteaConnection{GiteaConnection: c})
+	return err
+}
@@ -0,0 +1,252 @@
+// Package gitea contains an authorization provider for Gitea.
+package gitea
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"time"
+
+	"github.com/cockroachdb/errors"
+	otlog "github.com/opentracing/opentracing-go/log"
+
+	"github.com/sourcegraph/sourcegraph/internal/authz"
+	"github.com/sourcegraph/sourcegraph/internal/extsvc"
+	"github.com/sourcegraph/sourcegraph/internal/extsvc/gitea"
+	"github.com/sourcegraph/sourcegraph/internal/trace"
+	"github.com/sourcegraph/sourcegraph/internal/types"
+)
+
+// Provider is an implementation of AuthzProvider that provides repository permissions as
+// determined from a Gitea instance API.
+type Provider struct {
+	urn      string
+	client   *gitea.Client
+	codeHost *extsvc.CodeHost
+}
+
+var _ authz.Provider = (*Provider)(nil)
+
+// NewProvider returns a new Gitea authorization provider that uses the given
+// gitea.Client to talk to a Gitea API that is the source of truth for
+// permissions. The client must authenticate as a site admin, so that it can
+// make requests on behalf of other users. It assumes usernames of Sourcegraph
+// accounts match 1-1 with usernames of Gitea users.
+func NewProvider(cli *gitea.Client, urn string) *Provider {
+	return &Provider{
+		urn:      urn,
+		client:   cli,
+		codeHost: extsvc.NewCodeHost(cli.URL, extsvc.TypeGitea),
+	}
+}
+
+// Validate validates that the Provider has access to the Gitea API with the
+// token it was configured with, and that the token belongs to a site admin.
+func (p *Provider) Validate() []string {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	user, err := p.client.AuthenticatedUser(ctx)
+	if err != nil {
+		return []string{err.Error()}
+	}
+
+	if !user.IsAdmin {
+		return []string{"the token must belong to a Gitea site admin to enforce repository permissions"}
+	}
+
+	return nil
+}
+
+func (p *Provider) URN() string {
+	return p.urn
+}
+
+// ServiceID returns the absolute URL that identifies the Gitea instance this
+// provider is configured with.
+func (p *Provider) ServiceID() string { return p.codeHost.ServiceID }
+
+// ServiceType returns the type of this Provider, namely, "gitea".
+func (p *Provider) ServiceType() string { return p.codeHost.ServiceType }
+
+// FetchAccount satisfies the authz.Provider interface. It returns the Gitea
+// account with the same username as the given user.
+func (p *Provider) FetchAccount(ctx context.Context, user *types.User, _ []*extsvc.Account, _ []string) (acct *extsvc.Account, err error) {
+	if user == nil {
+		return nil, nil
+	}
+
+	tr, ctx := trace.New(ctx, "gitea.authz.provider.FetchAccount", "")
+	defer func() {
+		tr.LogFields(
+			otlog.String("user.name", user.Username),
+			otlog.Int32("user.id", user.ID),
+		)
+
+		if err != nil {
+			tr.SetError(err)
+		}
+
+		tr.Finish()
+	}()
+
+	giteaUser, err := p.client.GetUser(ctx, user.Username)
+	if err != nil {
+		if gitea.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	accountData, err := json.Marshal(giteaUser)
+	if err != nil {
+		return nil, err
+	}
+
+	return &extsvc.Account{
+		UserID: user.ID,
+		AccountSpec: extsvc.AccountSpec{
+			ServiceType: p.codeHost.ServiceType,
+			ServiceID:   p.codeHost.ServiceID,
+			AccountID:   strconv.FormatInt(giteaUser.ID, 10),
+		},
+		AccountData: extsvc.AccountData{
+			Data: (*json.RawMessage)(&accountData),
+		},
+	}, nil
+}
+
+// FetchUserPerms returns a list of repository IDs (on code host) that the given account
+// has read access on the code host. The repository ID has the same value as it would be
+// used as api.ExternalRepoSpec.ID. The returned list only includes private repository IDs.
+//
+// This method may return partial but valid results in case of error, and it is up to
+// callers to decide whether to discard.
+//
+// API docs: https://try.gitea.io/api/swagger#/user/userCurrentListRepos
+func (p *Provider) FetchUserPerms(ctx context.Context, account *extsvc.Account) (*authz.ExternalUserPermissions, error) {
+	switch {
+	case account == nil:
+		return nil, errors.New("no account provided")
+	case account.Data == nil:
+		return nil, errors.New("no account data provided")
+	case !extsvc.IsHostOfAccount(p.codeHost, account):
+		return nil, errors.Errorf("not a code host of the account: want %q but have %q",
+			p.codeHost.ServiceID, account.AccountSpec.ServiceID)
+	}
+
+	var user gitea.User
+	if err := json.Unmarshal(*account.Data, &user); err != nil {
+		return nil, errors.Wrap(err, "unmarshaling account data")
+	}
+
+	// The repositories of the user include those of their organizations and
+	// teams, as well as those they are a collaborator on.
+	cli := p.client.Sudo(user.Login)
+
+	var extIDs []extsvc.RepoID
+	for page := 1; page != 0; {
+		repos, next, err := cli.ListUserRepos(ctx, page)
+		if err != nil {
+			return &authz.ExternalUserPermissions{Exacts: extIDs}, err
+		}
+
+		for _, r := range repos {
+			if r.Private {
+				extIDs = append(extIDs, extsvc.RepoID(strconv.FormatInt(r.ID, 10)))
+			}
+		}
+
+		page = next
+	}
+
+	return &authz.ExternalUserPermissions{
+		Exacts: extIDs,
+	}, nil
+}
+
+// FetchUserPermsByToken is currently only required for syncing permissions for
+// GitHub and GitLab on sourcegraph.com
+func (p *Provider) FetchUserPermsByToken(ctx context.Context, token string) (*authz.ExternalUserPermissions, error) {
+	return nil, errors.New("not implemented")
+}
+
+// FetchRepoPerms returns a list of user IDs (on code host) who have read access to
+// the given repo on the code host. The user ID has the same value as it would
+// be used as extsvc.Account.AccountID. The returned list includes the owner of
+// a user repository, its collaborators and, for organization repositories, the
+// members of the teams which can access it.
+//
+// This method may return partial but valid results in case of error, and it is up to
+// callers to decide whether to discard.
+//
+// API docs: https://try.gitea.io/api/swagger#/repository/repoListCollaborators
+func (p *Provider) FetchRepoPerms(ctx context.Context, repo *extsvc.Repository) ([]extsvc.AccountID, error) {
+	switch {
+	case repo == nil:
+		return nil, errors.New("no repo provided")
+	case !extsvc.IsHostOfRepo(p.codeHost, &repo.ExternalRepoSpec):
+		return nil, errors.Errorf("not a code host of the repo: want %q but have %q",
+			p.codeHost.ServiceID, repo.ServiceID)
+	}
+
+	id, err := strconv.ParseInt(repo.ID, 10, 64)
+	if err != nil {
+		return nil, errors.Wrap(err, "parsing repository ID")
+	}
+
+	r, err := p.client.GetRepoByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if r.Owner == nil {
+		return nil, errors.Errorf("no owner returned for repository %d", id)
+	}
+	owner := r.Owner.Login
+
+	seen := make(map[int64]bool)
+	var extIDs []extsvc.AccountID
+	add := func(users []*gitea.User) {
+		for _, u := range users {
+			if !seen[u.ID] {
+				seen[u.ID] = true
+				extIDs = append(extIDs, extsvc.AccountID(strconv.FormatInt(u.ID, 10)))
+			}
+		}
+	}
+
+	_, err = p.client.GetOrg(ctx, owner)
+	switch {
+	case gitea.IsNotFound(err):
+		// A user repository: the owner can read it.
+		add([]*gitea.User{r.Owner})
+	case err != nil:
+		return extIDs, err
+	default:
+		teams, err := p.client.ListRepoTeams(ctx, owner, r.Name)
+		if err != nil {
+			return extIDs, err
+		}
+		for _, t := range teams {
+			for page := 1; page != 0; {
+				var members []*gitea.User
+				members, page, err = p.client.ListTeamMembers(ctx, t.ID, page)
+				if err != nil {
+					return extIDs, err
+				}
+				add(members)
+			}
+		}
+	}
+
+	for page := 1; page != 0; {
+		var collaborators []*gitea.User
+		collaborators, page, err = p.client.ListCollaborators(ctx, owner, r.Name, page)
+		if err != nil {
+			return extIDs, err
+		}
+		add(collaborators)
+	}
+
+	return extIDs, nil
+}
@@ -0,0 +1,153 @@
+package gitea
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/sourcegraph/sourcegraph/internal/api"
+	"github.com/sourcegraph/sourcegraph/internal/authz"
+	"github.com/sourcegraph/sourcegraph/internal/extsvc"
+	"github.com/sourcegraph/sourcegraph/internal/extsvc/gitea"
+	"github.com/sourcegraph/sourcegraph/internal/types"
+)
+
+func newTestProvider(t *testing.T, routes map[string]interface{}) *Provider {
+	t.Helper()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "token secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		key := r.URL.Path
+		if sudo := r.Header.Get("Sudo"); sudo != "" {
+			key = sudo + "@" + key
+		}
+		v, ok := routes[key]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(v)
+	}))
+	t.Cleanup(srv.Close)
+
+	u, err := url.Parse(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return NewProvider(gitea.NewClient(extsvc.NormalizeBaseURL(u), "secret", srv.Client()), "extsvc:gitea:1")
+}
+
+func TestProvider_FetchAccount(t *testing.T) {
+	p := newTestProvider(t, map[string]interface{}{
+		"/api/v1/users/alice": gitea.User{ID: 7, Login: "alice"},
+	})
+
+	acct, err := p.FetchAccount(context.Background(), &types.User{ID: 1, Username: "alice"}, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := extsvc.AccountSpec{
+		ServiceType: extsvc.TypeGitea,
+		ServiceID:   p.ServiceID(),
+		AccountID:   "7",
+	}
+	if diff := cmp.Diff(want, acct.AccountSpec); diff != "" {
+		t.Fatalf("AccountSpec mismatch (-want +got):\n%s", diff)
+	}
+
+	acct, err = p.FetchAccount(context.Background(), &types.User{ID: 2, Username: "bob"}, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if acct != nil {
+		t.Fatalf("expected no account for unknown user, got %+v", acct)
+	}
+}
+
+func TestProvider_FetchUserPerms(t *testing.T) {
+	p := newTestProvider(t, map[string]interface{}{
+		"alice@/api/v1/user/repos": []*gitea.Repository{
+			{ID: 1, Private: true},
+			{ID: 2, Private: false},
+			{ID: 3, Private: true},
+		},
+	})
+
+	data, _ := json.Marshal(gitea.User{ID: 7, Login: "alice"})
+	acct := &extsvc.Account{
+		AccountSpec: extsvc.AccountSpec{
+			ServiceType: extsvc.TypeGitea,
+			ServiceID:   p.ServiceID(),
+			AccountID:   "7",
+		},
+		AccountData: extsvc.AccountData{Data: (*json.RawMessage)(&data)},
+	}
+
+	got, err := p.FetchUserPerms(context.Background(), acct)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &authz.ExternalUserPermissions{Exacts: []extsvc.RepoID{"1", "3"}}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Fatalf("permissions mismatch (-want +got):\n%s", diff)
+	}
+
+	acct.ServiceID = "https://other.example.com/"
+	if _, err := p.FetchUserPerms(context.Background(), acct); err == nil {
+		t.Fatal("expected an error for an account of another code host")
+	}
+}
+
+func TestProvider_FetchRepoPerms(t *testing.T) {
+	p := newTestProvider(t, map[string]interface{}{
+		"/api/v1/repositories/1": gitea.Repository{ID: 1, Name: "dotfiles", Owner: &gitea.User{ID: 7, Login: "alice"}},
+		"/api/v1/repos/alice/dotfiles/collaborators": []*gitea.User{
+			{ID: 8, Login: "bob"},
+		},
+
+		"/api/v1/repositories/2": gitea.Repository{ID: 2, Name: "api", Owner: &gitea.User{ID: 100, Login: "myorg"}},
+		"/api/v1/orgs/myorg":     gitea.Organization{ID: 100, UserName: "myorg"},
+		"/api/v1/repos/myorg/api/teams": []*gitea.Team{
+			{ID: 1, Name: "Owners"},
+			{ID: 2, Name: "backend"},
+		},
+		"/api/v1/teams/1/members": []*gitea.User{{ID: 7, Login: "alice"}},
+		"/api/v1/teams/2/members": []*gitea.User{{ID: 7, Login: "alice"}, {ID: 9, Login: "carol"}},
+		"/api/v1/repos/myorg/api/collaborators": []*gitea.User{
+			{ID: 8, Login: "bob"},
+		},
+	})
+
+	for _, tc := range []struct {
+		name string
+		id   string
+		want []extsvc.AccountID
+	}{
+		{name: "user repository", id: "1", want: []extsvc.AccountID{"7", "8"}},
+		{name: "organization repository", id: "2", want: []extsvc.AccountID{"7", "9", "8"}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := p.FetchRepoPerms(context.Background(), &extsvc.Repository{
+				ExternalRepoSpec: api.ExternalRepoSpec{
+					ID:          tc.id,
+					ServiceType: extsvc.TypeGitea,
+					ServiceID:   p.ServiceID(),
+				},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Fatalf("account IDs mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -21,7 +21,8 @@ import (
 
 const combyPath = "comby"
 
-func exists() bool {
+// Exists reports whether the comby binary is installed.
+func Exists() bool {
 	_, err := exec.LookPath(combyPath)
 	return err == nil
 }
@@ -110,7 +111,7 @@ func kill(pid int) {
 }
 
 func PipeTo(ctx context.Context, args Args, w io.Writer) (err error) {
-	if !exists() {
+	if !Exists() {
 		log15.Error("comby is not installed (it could not be found on the PATH)")
 		return errors.New("comby is not installed")
 	}
@@ -176,7 +177,56 @@ func Matches(ctx context.Context, args Args) (matches []FileMatch, err error) {
 		return nil, err
 	}
 
-	scanner := bufio.NewScanner(b)
+	scanJSONLines(b, func(line []byte) error {
+		var m *FileMatch
+		if err := json.Unmarshal(line, &m); err != nil {
+			return err
+		}
+		matches = append(matches, *m)
+		return nil
+	})
+
+	if len(matches) > 0 {
+		log15.Info("comby invocation", "num_matches", strconv.Itoa(len(matches)))
+	}
+	return matches, nil
+}
+
+// Diffs returns a unified diff for every file in which comby rewrites matches
+// of args.MatchTemplate with args.RewriteTemplate.
+func Diffs(ctx context.Context, args Args) (diffs []FileDiff, err error) {
+	span, ctx := ot.StartSpanFromContext(ctx, "Comby.Diffs")
+	defer span.Finish()
+
+	b := new(bytes.Buffer)
+	w := bufio.NewWriter(b)
+
+	args.MatchOnly = false
+
+	err = PipeTo(ctx, args, w)
+	if err != nil {
+		return nil, err
+	}
+
+	scanJSONLines(b, func(line []byte) error {
+		var d *FileDiff
+		if err := json.Unmarshal(line, &d); err != nil {
+			return err
+		}
+		diffs = append(diffs, *d)
+		return nil
+	})
+
+	if len(diffs) > 0 {
+		log15.Info("comby invocation", "num_diffs", strconv.Itoa(len(diffs)))
+	}
+	return diffs, nil
+}
+
+// scanJSONLines calls decode for each line of comby's -json-lines output in
+// r. Lines that cannot be scanned or decoded are logged and skipped.
+func scanJSONLines(r io.Reader, decode func([]byte) error) {
+	scanner := bufio.NewScanner(r)
 	// increase the scanner buffer size for potentially long lines
 	scanner.Buffer(make([]byte, 100), 10*bufio.MaxScanTokenSize)
 	for scanner.Scan() {
@@ -186,17 +236,10 @@ func Matches(ctx context.Context, args Args) (matches []FileMatch, err error) {
 			log15.Warn("comby error: skipping scanner error line", "err", err.Error())
 			continue
 		}
-		var m *FileMatch
-		if err := json.Unmarshal(b, &m); err != nil {
+		if err := decode(b); err != nil {
 			// warn on decode errors and skip
 			log15.Warn("comby error: skipping unmarshaling error", "err", err.Error())
 			continue
 		}
-		matches = append(matches, *m)
-	}
-
-	if len(matches) > 0 {
-		log15.Info("comby invocation", "num_matches", strconv.Itoa(len(matches)))
 	}
-	return matches, nil
 }
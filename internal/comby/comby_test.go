@@ -5,6 +5,7 @@ import (
 	"bytes"
 	"context"
 	"os"
+	"reflect"
 	"testing"
 
 	"github.com/sourcegraph/sourcegraph/internal/testutil"
@@ -12,7 +13,7 @@ import (
 
 func TestMatchesUnmarshalling(t *testing.T) {
 	// If we are not on CI skip the test if comby is not installed.
-	if os.Getenv("CI") == "" && !exists() {
+	if os.Getenv("CI") == "" && !Exists() {
 		t.Skip("comby is not installed on the PATH. Try running 'bash <(curl -sL get.comby.dev)'.")
 	}
 
@@ -66,7 +67,7 @@ func main() {
 
 func TestMatchesInZip(t *testing.T) {
 	// If we are not on CI skip the test if comby is not installed.
-	if os.Getenv("CI") == "" && !exists() {
+	if os.Getenv("CI") == "" && !Exists() {
 		t.Skip("comby is not installed on the PATH. Try running 'bash <(curl -sL get.comby.dev)'.")
 	}
 
@@ -124,3 +125,48 @@ func main() {
 		}
 	}
 }
+
+func TestDiffs(t *testing.T) {
+	// If we are not on CI skip the test if comby is not installed.
+	if os.Getenv("CI") == "" && !Exists() {
+		t.Skip("comby is not installed on the PATH. Try running 'bash <(curl -sL get.comby.dev)'.")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	files := map[string]string{
+		"README.md": `# Hello World`,
+		"main.go": `package main
+
+func main() {
+	foo(1, 2)
+}
+`,
+	}
+
+	zipPath, cleanup, err := testutil.TempZipFromFiles(files)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cleanup()
+
+	diffs, err := Diffs(ctx, Args{
+		Input:           ZipPath(zipPath),
+		MatchTemplate:   "foo(:[a], :[b])",
+		RewriteTemplate: "foo(:[b], :[a])",
+		FilePatterns:    []string{".go"},
+		Matcher:         ".go",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []FileDiff{{
+		URI:  "main.go",
+		Diff: "--- main.go\n+++ main.go\n@@ -1,5 +1,5 @@\n package main\n \n func main() {\n-\tfoo(1, 2)\n+\tfoo(2, 1)\n }",
+	}}
+	if !reflect.DeepEqual(diffs, want) {
+		t.Errorf("got %+v, want %+v", diffs, want)
+	}
+}
@@ -0,0 +1,421 @@
+package comby
+
+import (
+	"bytes"
+	"context"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/cockroachdb/errors"
+)
+
+// Template is a match template compiled for in-process matching. It supports
+// a subset of comby's syntax: holes (:[x], :[[x]], :[x.], :[x\n], :[ x] and
+// :[x~regexp]), the ... wildcard, flexible whitespace and balanced
+// delimiters, strings and comments for the languages comby has matchers for.
+// Rules and rewrite templates are not supported and require the comby binary.
+type Template struct {
+	terms  []nativeTerm
+	syntax *syntax
+}
+
+type termKind int
+
+const (
+	termLiteral termKind = iota
+	termSpace
+	termHole
+)
+
+type holeKind int
+
+const (
+	// holeEverything matches lazily across balanced delimiters, strings and
+	// comments, but never past an unbalanced closing delimiter.
+	holeEverything holeKind = iota
+	// holeAlphanum matches one or more alphanumeric characters and underscores.
+	holeAlphanum
+	// holePunctuation matches one or more non-whitespace characters.
+	holePunctuation
+	// holeLine matches up to and including the next newline.
+	holeLine
+	// holeWhitespace matches one or more non-newline whitespace characters.
+	holeWhitespace
+	// holeRegexp matches the leftmost-first match of a regular expression.
+	holeRegexp
+)
+
+type nativeTerm struct {
+	kind termKind
+
+	// literal is the text of a termLiteral.
+	literal []byte
+
+	// name identifies a termHole. Holes with the same name must match the same
+	// content. It is empty for anonymous holes.
+	name string
+	hole holeKind
+	re   *regexp.Regexp
+}
+
+var (
+	holeAlphanumRegexp    = regexp.MustCompile(`^:\[\[(\w+)\]\]$`)
+	holeEverythingRegexp  = regexp.MustCompile(`^:\[(\w+)\]$`)
+	holePunctuationRegexp = regexp.MustCompile(`^:\[(\w+)\.\]$`)
+	holeLineRegexp        = regexp.MustCompile(`^:\[(\w+)\\n\]$`)
+	holeWhitespaceRegexp  = regexp.MustCompile(`^:\[ +(\w+)?\]$`)
+	holeRegexpRegexp      = regexp.MustCompile(`^:\[(\w+)?~(.*)\]$`)
+)
+
+// Compile compiles matchTemplate for in-process matching. The matcher is a
+// file extension such as ".go", as passed to comby's -matcher flag, which
+// selects the comment and string syntax of a language.
+func Compile(matchTemplate, matcher string) (*Template, error) {
+	matchTemplate = strings.TrimSpace(matchTemplate)
+	if matchTemplate == "" {
+		return nil, errors.New("empty match template")
+	}
+
+	var terms []nativeTerm
+	for _, term := range parseTemplate([]byte(matchTemplate)) {
+		switch t := term.(type) {
+		case Literal:
+			terms = appendLiteralTerms(terms, string(t))
+		case Hole:
+			h, err := parseHole(string(t))
+			if err != nil {
+				return nil, err
+			}
+			terms = append(terms, h)
+		}
+	}
+
+	return &Template{terms: terms, syntax: syntaxFor(matcher)}, nil
+}
+
+// appendLiteralTerms splits literal text of a template on whitespace and the
+// ... wildcard.
+func appendLiteralTerms(terms []nativeTerm, literal string) []nativeTerm {
+	for len(literal) > 0 {
+		if i := strings.IndexFunc(literal, unicode.IsSpace); i == 0 {
+			literal = strings.TrimLeftFunc(literal, unicode.IsSpace)
+			terms = append(terms, nativeTerm{kind: termSpace})
+			continue
+		}
+		if strings.HasPrefix(literal, "...") {
+			literal = literal[len("..."):]
+			terms = append(terms, nativeTerm{kind: termHole, hole: holeEverything})
+			continue
+		}
+
+		end := len(literal)
+		if i := strings.IndexFunc(literal, unicode.IsSpace); i >= 0 {
+			end = i
+		}
+		if i := strings.Index(literal[:end], "..."); i >= 0 {
+			end = i
+		}
+		terms = append(terms, nativeTerm{kind: termLiteral, literal: []byte(literal[:end])})
+		literal = literal[end:]
+	}
+	return terms
+}
+
+func parseHole(hole string) (nativeTerm, error) {
+	term := func(kind holeKind, name string) nativeTerm {
+		if name == "_" {
+			name = ""
+		}
+		return nativeTerm{kind: termHole, hole: kind, name: name}
+	}
+
+	if m := holeAlphanumRegexp.FindStringSubmatch(hole); m != nil {
+		return term(holeAlphanum, m[1]), nil
+	}
+	if m := holeEverythingRegexp.FindStringSubmatch(hole); m != nil {
+		return term(holeEverything, m[1]), nil
+	}
+	if m := holePunctuationRegexp.FindStringSubmatch(hole); m != nil {
+		return term(holePunctuation, m[1]), nil
+	}
+	if m := holeLineRegexp.FindStringSubmatch(hole); m != nil {
+		return term(holeLine, m[1]), nil
+	}
+	if m := holeWhitespaceRegexp.FindStringSubmatch(hole); m != nil {
+		return term(holeWhitespace, m[1]), nil
+	}
+	if m := holeRegexpRegexp.FindStringSubmatch(hole); m != nil {
+		re, err := regexp.Compile(`\A(?:` + m[2] + `)`)
+		if err != nil {
+			return nativeTerm{}, errors.Wrapf(err, "invalid regular expression in hole %s", hole)
+		}
+		t := term(holeRegexp, m[1])
+		t.re = re
+		return t, nil
+	}
+	return nativeTerm{}, errors.Errorf("unsupported hole syntax %s", hole)
+}
+
+// ErrMatchBudgetExceeded is returned by Template.Matches if matching a file
+// takes too many steps, which can happen for templates with several holes that
+// backtrack a lot. Such files should be matched with the comby binary instead.
+var ErrMatchBudgetExceeded = errors.New("structural match budget exceeded")
+
+// matchBudget is the number of steps that Template.Matches may take per file.
+var matchBudget = 1 << 24
+
+// ctxCheckInterval is the number of steps after which Template.Matches checks
+// whether its context is done.
+const ctxCheckInterval = 1 << 12
+
+// Matches returns the non-overlapping matches of t in content, from left to
+// right. It returns ErrMatchBudgetExceeded if matching takes too many steps,
+// or the error of ctx if it is done before matching finishes.
+func (t *Template) Matches(ctx context.Context, content []byte) ([]Match, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	// Every literal of the template must occur in content for it to match,
+	// which cheaply rules out most files.
+	for _, term := range t.terms {
+		if term.kind == termLiteral && !bytes.Contains(content, term.literal) {
+			return nil, nil
+		}
+	}
+
+	m := &nativeMatcher{
+		ctx:     ctx,
+		terms:   t.terms,
+		content: content,
+		tokens:  t.syntax.scan(content),
+	}
+
+	var matches []Match
+	var lines []int
+	for pos := 0; pos < len(content); {
+		m.bindings = m.bindings[:0]
+		end, ok := m.match(0, pos)
+		if m.err != nil {
+			return nil, m.err
+		}
+		if ok && end > pos {
+			if lines == nil {
+				lines = lineOffsets(content)
+			}
+			matches = append(matches, Match{
+				Range: Range{
+					Start: location(lines, pos),
+					End:   location(lines, end),
+				},
+				Matched: string(content[pos:end]),
+			})
+			pos = end
+			continue
+		}
+
+		// Matches only start in code or at the start of a string or comment.
+		if m.tokens.kinds[pos] == tokenOpaque {
+			pos = int(m.tokens.ends[pos])
+		} else {
+			pos++
+		}
+	}
+	return matches, nil
+}
+
+type nativeMatcher struct {
+	ctx     context.Context
+	terms   []nativeTerm
+	content []byte
+	tokens  *tokens
+
+	// bindings are the ranges of content matched by named holes so far.
+	bindings []binding
+
+	// steps is the number of calls of match so far, and err is set once they
+	// exceed matchBudget or ctx is done, which aborts matching.
+	steps int
+	err   error
+}
+
+type binding struct {
+	name       string
+	start, end int
+}
+
+// match reports whether the terms starting at ti match content at pos, and if
+// so where the match ends. Holes are tried with every extent they can take, in
+// order of preference, until the remaining terms match.
+func (m *nativeMatcher) match(ti, pos int) (int, bool) {
+	if m.step() {
+		return 0, false
+	}
+	if ti == len(m.terms) {
+		return pos, true
+	}
+
+	term := m.terms[ti]
+	switch term.kind {
+	case termLiteral:
+		if !bytes.HasPrefix(m.content[pos:], term.literal) {
+			return 0, false
+		}
+		return m.match(ti+1, pos+len(term.literal))
+
+	case termSpace:
+		end := pos
+		for end < len(m.content) && isSpace(m.content[end]) {
+			end++
+		}
+		if end == pos {
+			return 0, false
+		}
+		return m.match(ti+1, end)
+	}
+
+	n := len(m.bindings)
+	var matchEnd int
+	matched := m.eachHoleEnd(term, pos, func(end int) bool {
+		if !m.bind(term.name, pos, end) {
+			return false
+		}
+		var ok bool
+		if matchEnd, ok = m.match(ti+1, end); ok {
+			return true
+		}
+		m.bindings = m.bindings[:n]
+		// Stop trying other extents once matching has been aborted.
+		return m.err != nil
+	})
+	return matchEnd, matched && m.err == nil
+}
+
+// step counts a step of matching and reports whether matching has to be
+// aborted.
+func (m *nativeMatcher) step() bool {
+	if m.err != nil {
+		return true
+	}
+	m.steps++
+	if m.steps > matchBudget {
+		m.err = ErrMatchBudgetExceeded
+	} else if m.steps%ctxCheckInterval == 0 {
+		m.err = m.ctx.Err()
+	}
+	return m.err != nil
+}
+
+// eachHoleEnd calls try with each candidate end offset of a hole starting at
+// pos, in order of preference, until try returns true. It reports whether try
+// returned true.
+func (m *nativeMatcher) eachHoleEnd(term nativeTerm, pos int, try func(end int) bool) bool {
+	content := m.content
+	switch term.hole {
+	case holeEverything:
+		// Lazy: prefer the shortest extent.
+		for end := pos; ; {
+			if try(end) {
+				return true
+			}
+			if end == len(content) {
+				return false
+			}
+			switch m.tokens.kinds[end] {
+			case tokenOpen, tokenOpaque:
+				end = int(m.tokens.ends[end])
+			case tokenClose:
+				return false
+			default:
+				end++
+			}
+		}
+
+	case holeLine:
+		if i := bytes.IndexByte(content[pos:], '\n'); i >= 0 {
+			return try(pos + i + 1)
+		}
+		return try(len(content))
+
+	case holeRegexp:
+		if loc := term.re.FindIndex(content[pos:]); loc != nil {
+			return try(pos + loc[1])
+		}
+		return false
+	}
+
+	// Greedy: prefer the longest extent of at least one character.
+	var accept func(byte) bool
+	switch term.hole {
+	case holeAlphanum:
+		accept = isAlphanum
+	case holePunctuation:
+		accept = func(c byte) bool { return !isSpace(c) }
+	case holeWhitespace:
+		accept = func(c byte) bool { return c != '\n' && isSpace(c) }
+	}
+	end := pos
+	for end < len(content) && accept(content[end]) {
+		end++
+	}
+	for ; end > pos; end-- {
+		if try(end) {
+			return true
+		}
+	}
+	return false
+}
+
+// bind records that the named hole matched content[start:end]. It reports
+// false if the hole was already bound to different content.
+func (m *nativeMatcher) bind(name string, start, end int) bool {
+	if name == "" {
+		return true
+	}
+	for _, b := range m.bindings {
+		if b.name == name {
+			return bytes.Equal(m.content[b.start:b.end], m.content[start:end])
+		}
+	}
+	m.bindings = append(m.bindings, binding{name: name, start: start, end: end})
+	return true
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\v' || c == '\f'
+}
+
+func isAlphanum(c byte) bool {
+	return c == '_' || '0' <= c && c <= '9' || 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z'
+}
+
+// lineOffsets returns the offsets at which each line of content starts.
+func lineOffsets(content []byte) []int {
+	lines := []int{0}
+	for i, c := range content {
+		if c == '\n' {
+			lines = append(lines, i+1)
+		}
+	}
+	return lines
+}
+
+// location returns the 1-based line and column of offset, like comby does.
+func location(lines []int, offset int) Location {
+	// Find the last line starting at or before offset.
+	lo, hi := 0, len(lines)
+	for hi-lo > 1 {
+		mid := (lo + hi) / 2
+		if lines[mid] <= offset {
+			lo = mid
+		} else {
+			hi = mid
+		}
+	}
+	return Location{
+		Offset: offset,
+		Line:   lo + 1,
+		Column: offset - lines[lo] + 1,
+	}
+}
@@ -0,0 +1,260 @@
+package comby
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestNativeMatches(t *testing.T) {
+	cases := []struct {
+		name     string
+		template string
+		matcher  string
+		content  string
+		want     []string
+	}{
+		{
+			name:     "literal",
+			template: "foo",
+			content:  "foo bar foo",
+			want:     []string{"foo", "foo"},
+		},
+		{
+			name:     "hole is balanced",
+			template: "foo(:[args])",
+			content:  "foo(a, bar(b), [c]) foo()",
+			want:     []string{"foo(a, bar(b), [c])", "foo()"},
+		},
+		{
+			name:     "hole does not cross unbalanced delimiters",
+			template: "a(:[x]) b",
+			content:  "{a(1)} b",
+			want:     nil,
+		},
+		{
+			name:     "hole is lazy",
+			template: "(:[x])",
+			content:  "(1) (2)",
+			want:     []string{"(1)", "(2)"},
+		},
+		{
+			name:     "hole matches across lines",
+			template: "if :[cond] {:[body]}",
+			content:  "if x {\n\ty()\n}",
+			want:     []string{"if x {\n\ty()\n}"},
+		},
+		{
+			name:     "alphanumeric hole",
+			template: "func :[[name]](",
+			content:  "func foo_1(a) func (r *T) bar(",
+			want:     []string{"func foo_1("},
+		},
+		{
+			name:     "ellipsis",
+			template: "foo(...)",
+			content:  "foo(a, b)",
+			want:     []string{"foo(a, b)"},
+		},
+		{
+			name:     "flexible whitespace",
+			template: "a := b",
+			content:  "a :=\n\t b",
+			want:     []string{"a :=\n\t b"},
+		},
+		{
+			name:     "repeated hole must match the same content",
+			template: ":[[x]] == :[[x]]",
+			content:  "a == b; c == c",
+			want:     []string{"c == c"},
+		},
+		{
+			name:     "delimiters in strings are ignored",
+			template: "foo(:[x])",
+			matcher:  ".go",
+			content:  `foo(")") foo(')')`,
+			want:     []string{`foo(")")`, `foo(')')`},
+		},
+		{
+			name:     "delimiters in comments are ignored",
+			template: "foo(:[x])",
+			matcher:  ".go",
+			content:  "foo(a // )\n)",
+			want:     []string{"foo(a // )\n)"},
+		},
+		{
+			name:     "generic matcher has no strings",
+			template: "foo(:[x])",
+			matcher:  ".generic",
+			content:  `foo(")")`,
+			want:     []string{`foo(")`},
+		},
+		{
+			name:     "regexp hole",
+			template: "foo(:[x~[0-9]+])",
+			content:  "foo(12) foo(a)",
+			want:     []string{"foo(12)"},
+		},
+		{
+			name:     "unterminated comments and strings are code",
+			template: "foo(:[x])",
+			matcher:  ".go",
+			content:  "/* /* \" \\\" \nfoo(\")\")",
+			want:     []string{"foo(\")\")"},
+		},
+		{
+			name:     "line hole",
+			template: "// :[comment\\n]",
+			content:  "x // hello\ny",
+			want:     []string{"// hello\n"},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			tmpl, err := Compile(tc.template, tc.matcher)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			matches, err := tmpl.Matches(context.Background(), []byte(tc.content))
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, m := range matches {
+				got = append(got, m.Matched)
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("unexpected matches (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestNativeMatchesRange(t *testing.T) {
+	tmpl, err := Compile("bar(:[x])", ".go")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := tmpl.Matches(context.Background(), []byte("foo()\n  bar(\n1)"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Match{{
+		Range: Range{
+			Start: Location{Offset: 8, Line: 2, Column: 3},
+			End:   Location{Offset: 15, Line: 3, Column: 3},
+		},
+		Matched: "bar(\n1)",
+	}}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("unexpected matches (-want +got):\n%s", diff)
+	}
+}
+
+func TestNativeMatchesAborted(t *testing.T) {
+	// Every hole can take many extents, so a file without a match takes a lot
+	// of backtracking.
+	tmpl, err := Compile(":[a], :[b], :[c], :[d];", ".generic")
+	if err != nil {
+		t.Fatal(err)
+	}
+	content := []byte(";" + strings.Repeat("x, ", 200))
+
+	t.Run("budget exceeded", func(t *testing.T) {
+		defer func(budget int) { matchBudget = budget }(matchBudget)
+		matchBudget = 1000
+
+		if _, err := tmpl.Matches(context.Background(), content); err != ErrMatchBudgetExceeded {
+			t.Fatalf("unexpected error: have=%v want=%v", err, ErrMatchBudgetExceeded)
+		}
+	})
+
+	t.Run("context canceled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		if _, err := tmpl.Matches(ctx, content); err != context.Canceled {
+			t.Fatalf("unexpected error: have=%v want=%v", err, context.Canceled)
+		}
+	})
+}
+
+func TestCompileUnsupportedHole(t *testing.T) {
+	for _, template := range []string{"", "foo(:[x~(])"} {
+		if _, err := Compile(template, ".go"); err == nil {
+			t.Errorf("expected error compiling %q", template)
+		}
+	}
+}
+
+// TestNativeParity checks that the native matcher finds the same matches as
+// the comby binary.
+func TestNativeParity(t *testing.T) {
+	// If we are not on CI skip the test if comby is not installed.
+	if os.Getenv("CI") == "" && !Exists() {
+		t.Skip("comby is not installed on the PATH. Try running 'bash <(curl -sL get.comby.dev)'.")
+	}
+
+	files := map[string]string{
+		"main.go": `package main
+
+import "fmt"
+
+func main() {
+	fmt.Println("Hello (foo")
+	if err := run(a, b(c)); err != nil {
+		return
+	}
+}
+`,
+	}
+
+	dir := t.TempDir()
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, template := range []string{
+		"fmt.Println(:[x])",
+		"run(:[args])",
+		"if :[cond] {:[body]}",
+		"func :[[name]]()",
+	} {
+		t.Run(template, func(t *testing.T) {
+			want, err := Matches(context.Background(), Args{
+				Input:         DirPath(dir),
+				MatchTemplate: template,
+				Matcher:       ".go",
+				FilePatterns:  []string{".go"},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			tmpl, err := Compile(template, ".go")
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := tmpl.Matches(context.Background(), []byte(files["main.go"]))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var wantMatches []Match
+			for _, fm := range want {
+				wantMatches = append(wantMatches, fm.Matches...)
+			}
+			if diff := cmp.Diff(wantMatches, got); diff != "" {
+				t.Errorf("native matches differ from comby (-comby +native):\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,218 @@
+package comby
+
+import "bytes"
+
+// syntax describes the parts of a language's lexical structure that matter
+// for in-process matching: holes skip over strings and comments as a whole,
+// so delimiters inside them do not need to balance.
+type syntax struct {
+	lineComments  []string
+	blockComments [][2]string
+	strings       []stringSyntax
+}
+
+type stringSyntax struct {
+	delimiter string
+	// escapes is true if a backslash escapes the next character.
+	escapes bool
+	// multiline is true if the string may span lines.
+	multiline bool
+}
+
+var (
+	doubleQuoted = stringSyntax{delimiter: `"`, escapes: true}
+	singleQuoted = stringSyntax{delimiter: `'`, escapes: true}
+	backQuoted   = stringSyntax{delimiter: "`", multiline: true}
+
+	cStyleComments = syntax{
+		lineComments:  []string{"//"},
+		blockComments: [][2]string{{"/*", "*/"}},
+	}
+)
+
+func withStrings(s syntax, strings ...stringSyntax) *syntax {
+	s.strings = strings
+	return &s
+}
+
+// syntaxes maps the matchers returned by lookupMatcher in searcher, i.e. file
+// extensions, to language syntax. Matchers that are not listed, including
+// .generic, only balance delimiters.
+var syntaxes = map[string]*syntax{
+	".c":     withStrings(cStyleComments, doubleQuoted, singleQuoted),
+	".cs":    withStrings(cStyleComments, doubleQuoted, singleQuoted),
+	".css":   withStrings(syntax{blockComments: cStyleComments.blockComments}, doubleQuoted, singleQuoted),
+	".dart":  withStrings(cStyleComments, doubleQuoted, singleQuoted),
+	".go":    withStrings(cStyleComments, doubleQuoted, singleQuoted, backQuoted),
+	".java":  withStrings(cStyleComments, doubleQuoted, singleQuoted),
+	".js":    withStrings(cStyleComments, doubleQuoted, singleQuoted, backQuoted),
+	".json":  withStrings(syntax{}, doubleQuoted),
+	".kt":    withStrings(cStyleComments, doubleQuoted, singleQuoted),
+	".php":   withStrings(syntax{lineComments: []string{"//", "#"}, blockComments: cStyleComments.blockComments}, doubleQuoted, singleQuoted),
+	".rs":    withStrings(cStyleComments, doubleQuoted),
+	".scala": withStrings(cStyleComments, doubleQuoted, singleQuoted),
+	".swift": withStrings(cStyleComments, doubleQuoted),
+	".ts":    withStrings(cStyleComments, doubleQuoted, singleQuoted, backQuoted),
+	".fsx":   withStrings(syntax{lineComments: []string{"//"}, blockComments: [][2]string{{"(*", "*)"}}}, doubleQuoted),
+	".re":    withStrings(cStyleComments, doubleQuoted),
+
+	".sh":  withStrings(syntax{lineComments: []string{"#"}}, doubleQuoted, stringSyntax{delimiter: `'`, multiline: true}),
+	".s":   withStrings(syntax{lineComments: []string{"#"}}, doubleQuoted),
+	".ex":  withStrings(syntax{lineComments: []string{"#"}}, doubleQuoted, singleQuoted),
+	".jl":  withStrings(syntax{lineComments: []string{"#"}}, doubleQuoted),
+	".nim": withStrings(syntax{lineComments: []string{"#"}}, doubleQuoted),
+	".rb":  withStrings(syntax{lineComments: []string{"#"}}, doubleQuoted, singleQuoted),
+	".py": withStrings(syntax{lineComments: []string{"#"}},
+		stringSyntax{delimiter: `"""`, escapes: true, multiline: true},
+		stringSyntax{delimiter: `'''`, escapes: true, multiline: true},
+		doubleQuoted, singleQuoted),
+
+	".elm":  withStrings(syntax{lineComments: []string{"--"}, blockComments: [][2]string{{"{-", "-}"}}}, doubleQuoted),
+	".hs":   withStrings(syntax{lineComments: []string{"--"}, blockComments: [][2]string{{"{-", "-}"}}}, doubleQuoted),
+	".sql":  withStrings(syntax{lineComments: []string{"--"}, blockComments: cStyleComments.blockComments}, doubleQuoted, singleQuoted),
+	".clj":  withStrings(syntax{lineComments: []string{";"}}, doubleQuoted),
+	".lisp": withStrings(syntax{lineComments: []string{";"}}, doubleQuoted),
+	".erl":  withStrings(syntax{lineComments: []string{"%"}}, doubleQuoted),
+	".tex":  withStrings(syntax{lineComments: []string{"%"}}),
+	".f":    withStrings(syntax{lineComments: []string{"!"}}, doubleQuoted, singleQuoted),
+	".ml":   withStrings(syntax{blockComments: [][2]string{{"(*", "*)"}}}, doubleQuoted),
+	".pas":  withStrings(syntax{lineComments: []string{"//"}, blockComments: [][2]string{{"(*", "*)"}}}, singleQuoted),
+	".html": withStrings(syntax{blockComments: [][2]string{{"<!--", "-->"}}}, doubleQuoted),
+	".xml":  withStrings(syntax{blockComments: [][2]string{{"<!--", "-->"}}}, doubleQuoted),
+}
+
+func syntaxFor(matcher string) *syntax {
+	if s, ok := syntaxes[matcher]; ok {
+		return s
+	}
+	return &syntax{}
+}
+
+type tokenKind uint8
+
+const (
+	tokenOther tokenKind = iota
+	// tokenOpen is an opening delimiter with a matching closing delimiter.
+	tokenOpen
+	// tokenClose is a closing delimiter, or an opening delimiter without a
+	// matching closing delimiter. Holes never extend past it.
+	tokenClose
+	// tokenOpaque is the start of a string or comment.
+	tokenOpaque
+)
+
+// tokens is the result of scanning a file. For every byte offset it records
+// the kind of token that starts there, and for tokenOpen and tokenOpaque the
+// offset just past the end of the balanced group, string or comment.
+type tokens struct {
+	kinds []tokenKind
+	ends  []int32
+}
+
+var closers = map[byte]byte{'(': ')', '[': ']', '{': '}'}
+
+func (s *syntax) scan(content []byte) *tokens {
+	t := &tokens{
+		kinds: make([]tokenKind, len(content)),
+		ends:  make([]int32, len(content)),
+	}
+
+	o := &opaqueScanner{
+		syntax:               s,
+		content:              content,
+		unterminatedComments: make([]int, len(s.blockComments)),
+		unterminatedStrings:  make([]int, len(s.strings)),
+	}
+
+	var open []int
+	for i := 0; i < len(content); {
+		if end := o.end(i); end > i {
+			t.kinds[i] = tokenOpaque
+			t.ends[i] = int32(end)
+			i = end
+			continue
+		}
+
+		switch c := content[i]; c {
+		case '(', '[', '{':
+			open = append(open, i)
+		case ')', ']', '}':
+			t.kinds[i] = tokenClose
+			if n := len(open); n > 0 && closers[content[open[n-1]]] == c {
+				t.kinds[open[n-1]] = tokenOpen
+				t.ends[open[n-1]] = int32(i + 1)
+				open = open[:n-1]
+			}
+		}
+		i++
+	}
+	for _, i := range open {
+		t.kinds[i] = tokenClose
+	}
+	return t
+}
+
+// opaqueScanner finds the strings and comments of content for scan, which
+// calls end with increasing offsets.
+type opaqueScanner struct {
+	*syntax
+	content []byte
+
+	// unterminatedComments and unterminatedStrings record, for each block
+	// comment and string syntax, the offset up to which a comment or string
+	// starting there is known to be unterminated: looking for the end of a
+	// comment or string starting before that offset again would give up at
+	// the same place. This keeps scanning linear in the size of content.
+	unterminatedComments []int
+	unterminatedStrings  []int
+}
+
+// end returns the offset just past the string or comment starting at i, or i
+// if there is none.
+func (o *opaqueScanner) end(i int) int {
+	content := o.content
+	rest := content[i:]
+	for _, prefix := range o.lineComments {
+		if bytes.HasPrefix(rest, []byte(prefix)) {
+			if j := bytes.IndexByte(rest, '\n'); j >= 0 {
+				return i + j
+			}
+			return len(content)
+		}
+	}
+	for k, comment := range o.blockComments {
+		if bytes.HasPrefix(rest, []byte(comment[0])) {
+			if i < o.unterminatedComments[k] {
+				return i
+			}
+			if j := bytes.Index(rest[len(comment[0]):], []byte(comment[1])); j >= 0 {
+				return i + len(comment[0]) + j + len(comment[1])
+			}
+			o.unterminatedComments[k] = len(content)
+			return i
+		}
+	}
+	for k, str := range o.strings {
+		if !bytes.HasPrefix(rest, []byte(str.delimiter)) {
+			continue
+		}
+		if i < o.unterminatedStrings[k] {
+			return i
+		}
+		for j := len(str.delimiter); j < len(rest); j++ {
+			switch {
+			case str.escapes && rest[j] == '\\':
+				j++
+			case rest[j] == '\n' && !str.multiline:
+				// Unterminated string, treat the delimiter as code.
+				o.unterminatedStrings[k] = i + j
+				return i
+			case bytes.HasPrefix(rest[j:], []byte(str.delimiter)):
+				return i + j + len(str.delimiter)
+			}
+		}
+		o.unterminatedStrings[k] = len(content)
+		return i
+	}
+	return i
+}
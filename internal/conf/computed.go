@@ -321,6 +321,30 @@ func AndOrQueryEnabled() bool {
 	return val == "enabled"
 }
 
+// Placement schemes of experimentalFeatures.gitServerPlacement.
+const (
+	GitServerPlacementModulo     = "modulo"
+	GitServerPlacementRendezvous = "rendezvous"
+)
+
+// GitServerPlacement returns how repositories are assigned to gitservers. It
+// defaults to GitServerPlacementModulo.
+func GitServerPlacement() string {
+	if val := ExperimentalFeatures().GitServerPlacement; val != "" {
+		return val
+	}
+	return GitServerPlacementModulo
+}
+
+// GitServerReplicationFactor returns the number of gitservers which keep a
+// copy of each repository. It is at least 1.
+func GitServerReplicationFactor() int {
+	if val := ExperimentalFeatures().GitServerReplicationFactor; val > 1 {
+		return val
+	}
+	return 1
+}
+
 func ExperimentalFeatures() schema.ExperimentalFeatures {
 	val := Get().ExperimentalFeatures
 	if val == nil {
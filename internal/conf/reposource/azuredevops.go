@@ -0,0 +1,58 @@
+package reposource
+
+import (
+	"strings"
+
+	"github.com/sourcegraph/sourcegraph/internal/api"
+	"github.com/sourcegraph/sourcegraph/schema"
+)
+
+type AzureDevOps struct {
+	*schema.AzureDevOpsConnection
+}
+
+var _ RepoSource = AzureDevOps{}
+
+func (c AzureDevOps) CloneURLToRepoName(cloneURL string) (repoName api.RepoName, err error) {
+	parsedCloneURL, baseURL, match, err := parseURLs(cloneURL, c.Url)
+	if err != nil {
+		return "", err
+	}
+
+	var parts []string
+	switch {
+	case match && (parsedCloneURL.Scheme == "http" || parsedCloneURL.Scheme == "https"):
+		// {base}/{org}/{project}/_git/{repo}
+		path := strings.TrimPrefix(parsedCloneURL.Path, baseURL.Path)
+		parts = strings.Split(strings.Trim(path, "/"), "/")
+		if len(parts) != 4 || parts[2] != "_git" {
+			return "", nil
+		}
+		parts = []string{parts[0], parts[1], parts[3]}
+	case baseURL != nil && hostname(parsedCloneURL) == "ssh."+hostname(baseURL):
+		// Azure DevOps Services serves SSH from a separate host:
+		// git@ssh.dev.azure.com:v3/{org}/{project}/{repo}
+		parts = strings.Split(strings.Trim(parsedCloneURL.Path, "/"), "/")
+		if len(parts) != 4 || parts[0] != "v3" {
+			return "", nil
+		}
+		parts = parts[1:]
+	default:
+		return "", nil
+	}
+
+	return AzureDevOpsRepoName(c.RepositoryPathPattern, baseURL.Hostname(), parts[0], parts[1], parts[2]), nil
+}
+
+func AzureDevOpsRepoName(repositoryPathPattern, host, org, project, repo string) api.RepoName {
+	if repositoryPathPattern == "" {
+		repositoryPathPattern = "{host}/{org}/{project}/{repo}"
+	}
+
+	return api.RepoName(strings.NewReplacer(
+		"{host}", host,
+		"{org}", org,
+		"{project}", project,
+		"{repo}", repo,
+	).Replace(repositoryPathPattern))
+}
@@ -0,0 +1,125 @@
+package reposource
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/Masterminds/semver"
+
+	"github.com/sourcegraph/sourcegraph/internal/api"
+)
+
+// GoModule is a Go module, identified by its module path such as
+// "golang.org/x/net".
+type GoModule struct {
+	Path string
+}
+
+// ParseGoModule validates a module path such as "golang.org/x/net". Like the go
+// command, it requires the first path element to look like a domain name.
+func ParseGoModule(path string) (GoModule, error) {
+	elems := strings.Split(path, "/")
+	if !strings.Contains(elems[0], ".") || strings.HasPrefix(elems[0], ".") {
+		return GoModule{}, fmt.Errorf("invalid Go module path %q: the first path element must be a domain name", path)
+	}
+	for _, elem := range elems {
+		if elem == "" || elem == "." || elem == ".." || strings.ContainsAny(elem, "@\\ ") {
+			return GoModule{}, fmt.Errorf("invalid Go module path %q", path)
+		}
+	}
+	return GoModule{Path: path}, nil
+}
+
+// ParseGoModuleFromRepoURL returns the Go module of the given URL path, without
+// a leading `/`, such as "go/golang.org/x/net".
+func ParseGoModuleFromRepoURL(urlPath string) (GoModule, error) {
+	if !strings.HasPrefix(urlPath, "go/") {
+		return GoModule{}, fmt.Errorf("failed to parse a Go module from the path %s", urlPath)
+	}
+	return ParseGoModule(strings.TrimPrefix(urlPath, "go/"))
+}
+
+func (m *GoModule) MatchesDependencyString(dependency string) bool {
+	return dependency == m.Path || strings.HasPrefix(dependency, m.Path+"@")
+}
+
+func (m *GoModule) RepoName() api.RepoName {
+	return api.RepoName("go/" + m.Path)
+}
+
+func (m *GoModule) CloneURL() string {
+	cloneURL := url.URL{Path: string(m.RepoName())}
+	return cloneURL.String()
+}
+
+// GoDependency is a version of a Go module.
+type GoDependency struct {
+	GoModule
+	Version         string
+	SemanticVersion *semver.Version
+}
+
+// ParseGoDependency parses a dependency such as "golang.org/x/net@v0.1.0".
+func ParseGoDependency(dependency string) (GoDependency, error) {
+	i := strings.LastIndex(dependency, "@")
+	if i < 0 {
+		return GoDependency{}, fmt.Errorf("dependency %q must be of the form module@version", dependency)
+	}
+
+	module, err := ParseGoModule(dependency[:i])
+	if err != nil {
+		return GoDependency{}, err
+	}
+	return NewGoDependency(module, dependency[i+1:])
+}
+
+// NewGoDependency returns the given version of module. Go module versions are
+// semantic versions with a "v" prefix, such as "v1.2.3".
+func NewGoDependency(module GoModule, version string) (GoDependency, error) {
+	if !strings.HasPrefix(version, "v") || strings.ContainsAny(version, "/\\@ ") {
+		return GoDependency{}, fmt.Errorf("invalid version %q of Go module %s", version, module.Path)
+	}
+
+	// Like for Maven dependencies, we only use the semantic version for
+	// sorting, so we ignore versions which fail to parse.
+	semanticVersion, _ := semver.NewVersion(version)
+
+	return GoDependency{
+		GoModule:        module,
+		Version:         version,
+		SemanticVersion: semanticVersion,
+	}, nil
+}
+
+// PackageManagerSyntax returns the dependency as used by the go command, such
+// as "golang.org/x/net@v0.1.0".
+func (d *GoDependency) PackageManagerSyntax() string {
+	return d.Path + "@" + d.Version
+}
+
+// GitTagFromVersion returns the version itself, since Go module versions are
+// already named like git tags.
+func (d *GoDependency) GitTagFromVersion() string {
+	return d.Version
+}
+
+// SortGoDependencies sorts the dependencies by the semantic version in
+// descending order. The latest version of a dependency becomes the first
+// element of the slice.
+func SortGoDependencies(dependencies []GoDependency) {
+	sort.Slice(dependencies, func(i, j int) bool {
+		a, b := dependencies[i], dependencies[j]
+		if a.GoModule != b.GoModule {
+			return a.Path > b.Path
+		}
+		if a.SemanticVersion == nil || b.SemanticVersion == nil {
+			if a.SemanticVersion != b.SemanticVersion {
+				return a.SemanticVersion != nil
+			}
+			return a.Version > b.Version
+		}
+		return a.SemanticVersion.GreaterThan(b.SemanticVersion)
+	})
+}
@@ -0,0 +1,68 @@
+package reposource
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/sourcegraph/sourcegraph/internal/api"
+)
+
+func TestParseGoModuleFromRepoURL(t *testing.T) {
+	obtained, err := ParseGoModuleFromRepoURL("go/golang.org/x/net")
+	assert.Nil(t, err)
+	assert.Equal(t, GoModule{Path: "golang.org/x/net"}, obtained)
+	assert.Equal(t, api.RepoName("go/golang.org/x/net"), obtained.RepoName())
+	assert.True(t, obtained.MatchesDependencyString("golang.org/x/net"))
+	assert.True(t, obtained.MatchesDependencyString("golang.org/x/net@v0.1.0"))
+	assert.False(t, obtained.MatchesDependencyString("golang.org/x/network@v0.1.0"))
+
+	for _, path := range []string{"golang.org/x/net", "go/net", "go/golang.org//net", "go/golang.org/../net"} {
+		if _, err := ParseGoModuleFromRepoURL(path); err == nil {
+			t.Errorf("expected an error for %q", path)
+		}
+	}
+}
+
+func TestParseGoDependency(t *testing.T) {
+	dependency, err := ParseGoDependency("github.com/Azure/go-autorest@v14.2.0+incompatible")
+	assert.Nil(t, err)
+	assert.Equal(t, GoModule{Path: "github.com/Azure/go-autorest"}, dependency.GoModule)
+	assert.Equal(t, "v14.2.0+incompatible", dependency.GitTagFromVersion())
+	assert.Equal(t, "github.com/Azure/go-autorest@v14.2.0+incompatible", dependency.PackageManagerSyntax())
+
+	for _, value := range []string{"golang.org/x/net", "golang.org/x/net@1.0.0", "golang.org/x/net@"} {
+		if _, err := ParseGoDependency(value); err == nil {
+			t.Errorf("expected an error for %q", value)
+		}
+	}
+}
+
+func parseGoDependencyOrPanic(t *testing.T, value string) GoDependency {
+	dependency, err := ParseGoDependency(value)
+	if err != nil {
+		t.Fatalf("error=%s", err)
+	}
+	return dependency
+}
+
+func TestSortGoDependencies(t *testing.T) {
+	dependencies := []GoDependency{
+		parseGoDependencyOrPanic(t, "c.io/c@v1.2.0"),
+		parseGoDependencyOrPanic(t, "a.io/a@v1.2.0"),
+		parseGoDependencyOrPanic(t, "b.io/b@v1.2.0"),
+		parseGoDependencyOrPanic(t, "b.io/b@v1.11.0"),
+		parseGoDependencyOrPanic(t, "b.io/b@v0.0.0-20210101000000-abcdefabcdef"),
+		parseGoDependencyOrPanic(t, "b.io/b@v1.1.0"),
+	}
+	expected := []GoDependency{
+		parseGoDependencyOrPanic(t, "c.io/c@v1.2.0"),
+		parseGoDependencyOrPanic(t, "b.io/b@v1.11.0"),
+		parseGoDependencyOrPanic(t, "b.io/b@v1.2.0"),
+		parseGoDependencyOrPanic(t, "b.io/b@v1.1.0"),
+		parseGoDependencyOrPanic(t, "b.io/b@v0.0.0-20210101000000-abcdefabcdef"),
+		parseGoDependencyOrPanic(t, "a.io/a@v1.2.0"),
+	}
+	SortGoDependencies(dependencies)
+	assert.Equal(t, expected, dependencies)
+}
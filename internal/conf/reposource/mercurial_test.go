@@ -0,0 +1,48 @@
+package reposource
+
+import (
+	"testing"
+
+	"github.com/sourcegraph/sourcegraph/schema"
+)
+
+func TestMercurialCloneURLToRepoName(t *testing.T) {
+	tests := []struct {
+		conn schema.MercurialConnection
+		urls []urlToRepoNameErr
+	}{
+		{
+			conn: schema.MercurialConnection{
+				Url:   "https://hg.example.org/repos",
+				Repos: []string{"project/legacy"},
+			},
+			urls: []urlToRepoNameErr{
+				{"https://hg.example.org/repos/project/legacy", "hg.example.org/repos/project/legacy", nil},
+				{"https://hg.example.org/project/legacy", "", nil},
+				{"https://other.example.org/repos/project/legacy", "", nil},
+			},
+		},
+		{
+			conn: schema.MercurialConnection{
+				Url:                   "ssh://hg@hg.example.org:2222/",
+				RepositoryPathPattern: "hg/{repo}",
+				Repos:                 []string{"legacy"},
+			},
+			urls: []urlToRepoNameErr{
+				{"ssh://hg@hg.example.org:2222/legacy", "hg/legacy", nil},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		for _, u := range test.urls {
+			repoName, err := Mercurial{&test.conn}.CloneURLToRepoName(u.cloneURL)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if u.repoName != string(repoName) {
+				t.Errorf("expected %q but got %q for clone URL %q (connection: %+v)", u.repoName, repoName, u.cloneURL, test.conn)
+			}
+		}
+	}
+}
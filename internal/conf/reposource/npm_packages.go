@@ -0,0 +1,136 @@
+package reposource
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/Masterminds/semver"
+
+	"github.com/sourcegraph/sourcegraph/internal/api"
+)
+
+// npmPackageNameRegex matches valid npm package names, with an optional scope.
+// See https://docs.npmjs.com/cli/v7/configuring-npm/package-json#name.
+var npmPackageNameRegex = regexp.MustCompile(`^(?:@([a-z0-9-~][a-z0-9-._~]*)/)?([a-z0-9-~][a-z0-9-._~]*)$`)
+
+// NPMPackage is an npm package, which may be scoped (e.g. "@types/node") or
+// not (e.g. "lodash").
+type NPMPackage struct {
+	// Scope is the scope of the package without the leading "@", or empty if
+	// the package is not scoped.
+	Scope string
+	Name  string
+}
+
+// ParseNPMPackage parses a package name such as "@types/node" or "lodash".
+func ParseNPMPackage(name string) (NPMPackage, error) {
+	match := npmPackageNameRegex.FindStringSubmatch(name)
+	if match == nil {
+		return NPMPackage{}, fmt.Errorf("invalid npm package name %q", name)
+	}
+	return NPMPackage{Scope: match[1], Name: match[2]}, nil
+}
+
+// ParseNPMPackageFromRepoURL returns the npm package of the given URL path,
+// without a leading `/`, such as "npm/types/node" or "npm/lodash".
+func ParseNPMPackageFromRepoURL(urlPath string) (NPMPackage, error) {
+	parts := strings.Split(strings.TrimPrefix(urlPath, "npm/"), "/")
+	switch {
+	case !strings.HasPrefix(urlPath, "npm/") || len(parts) > 2:
+		return NPMPackage{}, fmt.Errorf("failed to parse an npm package from the path %s", urlPath)
+	case len(parts) == 2:
+		return ParseNPMPackage("@" + parts[0] + "/" + parts[1])
+	default:
+		return ParseNPMPackage(parts[0])
+	}
+}
+
+// PackageSyntax returns the name of the package as used by npm, such as
+// "@types/node".
+func (p *NPMPackage) PackageSyntax() string {
+	if p.Scope == "" {
+		return p.Name
+	}
+	return fmt.Sprintf("@%s/%s", p.Scope, p.Name)
+}
+
+func (p *NPMPackage) MatchesDependencyString(dependency string) bool {
+	return strings.HasPrefix(dependency, p.PackageSyntax()+"@")
+}
+
+func (p *NPMPackage) RepoName() api.RepoName {
+	if p.Scope == "" {
+		return api.RepoName("npm/" + p.Name)
+	}
+	return api.RepoName(fmt.Sprintf("npm/%s/%s", p.Scope, p.Name))
+}
+
+func (p *NPMPackage) CloneURL() string {
+	cloneURL := url.URL{Path: string(p.RepoName())}
+	return cloneURL.String()
+}
+
+// NPMDependency is a version of an npm package.
+type NPMDependency struct {
+	NPMPackage
+	Version         string
+	SemanticVersion *semver.Version
+}
+
+// ParseNPMDependency parses a dependency such as "@types/node@16.4.0" or
+// "lodash@4.17.21".
+func ParseNPMDependency(dependency string) (NPMDependency, error) {
+	i := strings.LastIndex(dependency, "@")
+	if i <= 0 || i == len(dependency)-1 {
+		return NPMDependency{}, fmt.Errorf("dependency %q must be of the form (@scope/)?name@version", dependency)
+	}
+
+	pkg, err := ParseNPMPackage(dependency[:i])
+	if err != nil {
+		return NPMDependency{}, err
+	}
+	version := dependency[i+1:]
+
+	// Like for Maven dependencies, we only use the semantic version for
+	// sorting, so we ignore versions which fail to parse.
+	semanticVersion, _ := semver.NewVersion(version)
+
+	return NPMDependency{
+		NPMPackage:      pkg,
+		Version:         version,
+		SemanticVersion: semanticVersion,
+	}, nil
+}
+
+// PackageManagerSyntax returns the dependency as used by npm, such as
+// "@types/node@16.4.0".
+func (d *NPMDependency) PackageManagerSyntax() string {
+	return d.PackageSyntax() + "@" + d.Version
+}
+
+func (d *NPMDependency) GitTagFromVersion() string {
+	return "v" + d.Version
+}
+
+// SortNPMDependencies sorts the dependencies by the semantic version in
+// descending order. The latest version of a dependency becomes the first
+// element of the slice. Versions which are not semantic versions are sorted
+// lexicographically after the semantic ones.
+func SortNPMDependencies(dependencies []NPMDependency) {
+	sort.Slice(dependencies, func(i, j int) bool {
+		a, b := dependencies[i], dependencies[j]
+		if a.NPMPackage != b.NPMPackage {
+			return a.PackageSyntax() > b.PackageSyntax()
+		}
+		if a.SemanticVersion == nil || b.SemanticVersion == nil {
+			if a.SemanticVersion != b.SemanticVersion {
+				return a.SemanticVersion != nil
+			}
+			return a.Version > b.Version
+		}
+		return a.SemanticVersion.GreaterThan(b.SemanticVersion)
+	})
+}
@@ -0,0 +1,81 @@
+package reposource
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/sourcegraph/sourcegraph/internal/api"
+)
+
+func TestParseNPMPackageFromRepoURL(t *testing.T) {
+	for _, tc := range []struct {
+		path string
+		want NPMPackage
+		name api.RepoName
+	}{
+		{path: "npm/lodash", want: NPMPackage{Name: "lodash"}, name: "npm/lodash"},
+		{path: "npm/types/node", want: NPMPackage{Scope: "types", Name: "node"}, name: "npm/types/node"},
+	} {
+		obtained, err := ParseNPMPackageFromRepoURL(tc.path)
+		assert.Nil(t, err)
+		assert.Equal(t, tc.want, obtained)
+		assert.Equal(t, tc.name, obtained.RepoName())
+	}
+
+	for _, path := range []string{"lodash", "npm/a/b/c", "npm/Upper", "maven/org.example/example"} {
+		if _, err := ParseNPMPackageFromRepoURL(path); err == nil {
+			t.Errorf("expected an error for %q", path)
+		}
+	}
+}
+
+func TestParseNPMDependency(t *testing.T) {
+	dependency, err := ParseNPMDependency("@types/node@16.4.0")
+	assert.Nil(t, err)
+	assert.Equal(t, NPMPackage{Scope: "types", Name: "node"}, dependency.NPMPackage)
+	assert.Equal(t, "16.4.0", dependency.Version)
+	assert.Equal(t, "@types/node@16.4.0", dependency.PackageManagerSyntax())
+	assert.Equal(t, "v16.4.0", dependency.GitTagFromVersion())
+	assert.True(t, dependency.MatchesDependencyString("@types/node@15.0.0"))
+	assert.False(t, dependency.MatchesDependencyString("@types/node-fetch@2.5.12"))
+
+	for _, value := range []string{"lodash", "lodash@", "@types/node", "@types@1.0.0"} {
+		if _, err := ParseNPMDependency(value); err == nil {
+			t.Errorf("expected an error for %q", value)
+		}
+	}
+}
+
+func parseNPMDependencyOrPanic(t *testing.T, value string) NPMDependency {
+	dependency, err := ParseNPMDependency(value)
+	if err != nil {
+		t.Fatalf("error=%s", err)
+	}
+	return dependency
+}
+
+func TestSortNPMDependencies(t *testing.T) {
+	dependencies := []NPMDependency{
+		parseNPMDependencyOrPanic(t, "c@1.2.0"),
+		parseNPMDependencyOrPanic(t, "a@1.2.0"),
+		parseNPMDependencyOrPanic(t, "b@1.2.0"),
+		parseNPMDependencyOrPanic(t, "b@latest"),
+		parseNPMDependencyOrPanic(t, "b@1.11.0"),
+		parseNPMDependencyOrPanic(t, "b@1.2.0-rc.11"),
+		parseNPMDependencyOrPanic(t, "b@1.2.0-rc.1"),
+		parseNPMDependencyOrPanic(t, "b@1.1.0"),
+	}
+	expected := []NPMDependency{
+		parseNPMDependencyOrPanic(t, "c@1.2.0"),
+		parseNPMDependencyOrPanic(t, "b@1.11.0"),
+		parseNPMDependencyOrPanic(t, "b@1.2.0"),
+		parseNPMDependencyOrPanic(t, "b@1.2.0-rc.11"),
+		parseNPMDependencyOrPanic(t, "b@1.2.0-rc.1"),
+		parseNPMDependencyOrPanic(t, "b@1.1.0"),
+		parseNPMDependencyOrPanic(t, "b@latest"),
+		parseNPMDependencyOrPanic(t, "a@1.2.0"),
+	}
+	SortNPMDependencies(dependencies)
+	assert.Equal(t, expected, dependencies)
+}
@@ -46,6 +46,7 @@ type ExternalServiceStore struct {
 	GitHubValidators          []func(*schema.GitHubConnection) error
 	GitLabValidators          []func(*schema.GitLabConnection, []schema.AuthProviders) error
 	BitbucketServerValidators []func(*schema.BitbucketServerConnection) error
+	GiteaValidators           []func(*schema.GiteaConnection) error
 	PerforceValidators        []func(*schema.PerforceConnection) error
 
 	key encryption.Key
@@ -60,6 +61,7 @@ func (e *ExternalServiceStore) copy() *ExternalServiceStore {
 		GitHubValidators:          e.GitHubValidators,
 		GitLabValidators:          e.GitLabValidators,
 		BitbucketServerValidators: e.BitbucketServerValidators,
+		GiteaValidators:           e.GiteaValidators,
 		PerforceValidators:        e.PerforceValidators,
 	}
 }
@@ -119,12 +121,17 @@ func (e *ExternalServiceStore) ensureStore() {
 // external services.
 var ExternalServiceKinds = map[string]ExternalServiceKind{
 	extsvc.KindAWSCodeCommit:   {CodeHost: true, JSONSchema: schema.AWSCodeCommitSchemaJSON},
+	extsvc.KindAzureDevOps:     {CodeHost: true, JSONSchema: schema.AzureDevOpsSchemaJSON},
 	extsvc.KindBitbucketCloud:  {CodeHost: true, JSONSchema: schema.BitbucketCloudSchemaJSON},
 	extsvc.KindBitbucketServer: {CodeHost: true, JSONSchema: schema.BitbucketServerSchemaJSON},
 	extsvc.KindGitHub:          {CodeHost: true, JSONSchema: schema.GitHubSchemaJSON},
 	extsvc.KindGitLab:          {CodeHost: true, JSONSchema: schema.GitLabSchemaJSON},
+	extsvc.KindGitea:           {CodeHost: true, JSONSchema: schema.GiteaSchemaJSON},
 	extsvc.KindGitolite:        {CodeHost: true, JSONSchema: schema.GitoliteSchemaJSON},
 	extsvc.KindJVMPackages:     {CodeHost: true, JSONSchema: schema.JVMPackagesSchemaJSON},
+	extsvc.KindNPMPackages:     {CodeHost: true, JSONSchema: schema.NPMPackagesSchemaJSON},
+	extsvc.KindGoModules:       {CodeHost: true, JSONSchema: schema.GoModulesSchemaJSON},
+	extsvc.KindMercurial:       {CodeHost: true, JSONSchema: schema.MercurialSchemaJSON},
 	extsvc.KindPerforce:        {CodeHost: true, JSONSchema: schema.PerforceSchemaJSON},
 	extsvc.KindPhabricator:     {CodeHost: true, JSONSchema: schema.PhabricatorSchemaJSON},
 	extsvc.KindOther:           {CodeHost: true, JSONSchema: schema.OtherExternalServiceSchemaJSON},
@@ -312,6 +319,20 @@ func (e *ExternalServiceStore) ValidateConfig(ctx context.Context, opt ValidateE
 		}
 		err = e.validateBitbucketCloudConnection(ctx, opt.ExternalServiceID, &c)
 
+	case extsvc.KindGitea:
+		var c schema.GiteaConnection
+		if err = jsoniter.Unmarshal(normalized, &c); err != nil {
+			return nil, err
+		}
+		err = e.validateGiteaConnection(ctx, opt.ExternalServiceID, &c)
+
+	case extsvc.KindAzureDevOps:
+		var c schema.AzureDevOpsConnection
+		if err = jsoniter.Unmarshal(normalized, &c); err != nil {
+			return nil, err
+		}
+		err = e.validateAzureDevOpsConnection(ctx, opt.ExternalServiceID, &c)
+
 	case extsvc.KindPerforce:
 		var c schema.PerforceConnection
 		if err = jsoniter.Unmarshal(normalized, &c); err != nil {
@@ -404,6 +425,33 @@ func (e *ExternalServiceStore) validateBitbucketCloudConnection(ctx context.Cont
 	return e.validateDuplicateRateLimits(ctx, id, extsvc.KindBitbucketCloud, c)
 }
 
+func (e *ExternalServiceStore) validateGiteaConnection(ctx context.Context, id int64, c *schema.GiteaConnection) error {
+	err := new(multierror.Error)
+	for _, validate := range e.GiteaValidators {
+		err = multierror.Append(err, validate(c))
+	}
+
+	if c.Repos == nil && c.RepositoryQuery == nil && c.Orgs == nil {
+		err = multierror.Append(err, errors.New("at least one of repositoryQuery, repos or orgs must be set"))
+	}
+
+	err = multierror.Append(err, e.validateDuplicateRateLimits(ctx, id, extsvc.KindGitea, c))
+
+	return err.ErrorOrNil()
+}
+
+func (e *ExternalServiceStore) validateAzureDevOpsConnection(ctx context.Context, id int64, c *schema.AzureDevOpsConnection) error {
+	err := new(multierror.Error)
+
+	if c.Orgs == nil && c.Projects == nil {
+		err = multierror.Append(err, errors.New("at least one of orgs or projects must be set"))
+	}
+
+	err = multierror.Append(err, e.validateDuplicateRateLimits(ctx, id, extsvc.KindAzureDevOps, c))
+
+	return err.ErrorOrNil()
+}
+
 func (e *ExternalServiceStore) validatePerforceConnection(ctx context.Context, id int64, c *schema.PerforceConnection) error {
 	err := new(multierror.Error)
 	for _, validate := range e.PerforceValidators {
@@ -0,0 +1,305 @@
+package awscodecommit
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/codecommit"
+	codecommittypes "github.com/aws/aws-sdk-go-v2/service/codecommit/types"
+	"github.com/cockroachdb/errors"
+)
+
+// PullRequest statuses.
+const (
+	PullRequestStatusOpen   = string(codecommittypes.PullRequestStatusEnumOpen)
+	PullRequestStatusClosed = string(codecommittypes.PullRequestStatusEnumClosed)
+)
+
+// ErrPullRequestNotFound is returned when a pull request doesn't exist.
+var ErrPullRequestNotFound = errors.New("AWS CodeCommit pull request not found")
+
+// ErrNotMergeable is returned by MergePullRequest when the pull request can't
+// be merged, because it has conflicts, its approval rules aren't satisfied or
+// its source branch changed since it was loaded.
+var ErrNotMergeable = errors.New("AWS CodeCommit pull request cannot be merged")
+
+// PullRequest is an AWS CodeCommit pull request.
+type PullRequest struct {
+	ID                   string    // the ID of the pull request
+	RevisionID           string    // the ID of the current revision of the pull request
+	Title                string    // the title of the pull request
+	Description          string    // the description of the pull request
+	Status               string    // the status of the pull request, OPEN or CLOSED
+	AuthorARN            string    // the ARN of the user who created the pull request
+	RepositoryName       string    // the name of the repository of the pull request
+	SourceReference      string    // the full ref of the source branch
+	DestinationReference string    // the full ref of the destination branch
+	SourceCommit         string    // the commit ID of the tip of the source branch
+	DestinationCommit    string    // the commit ID of the tip of the destination branch
+	IsMerged             bool      // whether the pull request was merged
+	CreationDate         time.Time // when the pull request was created
+	LastActivityDate     time.Time // when the pull request was last changed
+	WebURL               string    // the URL of the pull request in the AWS console
+
+	// ApprovedBy are the ARNs of the users who approved the current revision
+	// of the pull request.
+	ApprovedBy []string
+	// ApprovalRulesNotSatisfied are the names of the approval rules that the
+	// current revision of the pull request doesn't satisfy.
+	ApprovalRulesNotSatisfied []string
+}
+
+// CreatePullRequestInput is the input to CreatePullRequest.
+type CreatePullRequestInput struct {
+	RepositoryName       string
+	SourceReference      string
+	DestinationReference string
+	Title                string
+	Description          string
+}
+
+// CreatePullRequest opens a new pull request.
+func (c *Client) CreatePullRequest(ctx context.Context, input *CreatePullRequestInput) (*PullRequest, error) {
+	svc := codecommit.NewFromConfig(c.aws)
+	result, err := svc.CreatePullRequest(ctx, &codecommit.CreatePullRequestInput{
+		Title:       aws.String(input.Title),
+		Description: aws.String(input.Description),
+		Targets: []codecommittypes.Target{{
+			RepositoryName:       aws.String(input.RepositoryName),
+			SourceReference:      aws.String(input.SourceReference),
+			DestinationReference: aws.String(input.DestinationReference),
+		}},
+	})
+	if err != nil {
+		return nil, &wrappedError{err: err}
+	}
+	return c.withApprovals(ctx, svc, result.PullRequest)
+}
+
+// FindOpenPullRequest returns the open pull request in the given repository
+// from the source reference to the destination reference, or
+// ErrPullRequestNotFound if there is none.
+func (c *Client) FindOpenPullRequest(ctx context.Context, repositoryName, source, destination string) (*PullRequest, error) {
+	svc := codecommit.NewFromConfig(c.aws)
+	input := codecommit.ListPullRequestsInput{
+		RepositoryName:    aws.String(repositoryName),
+		PullRequestStatus: codecommittypes.PullRequestStatusEnumOpen,
+	}
+	for {
+		result, err := svc.ListPullRequests(ctx, &input)
+		if err != nil {
+			return nil, &wrappedError{err: err}
+		}
+
+		// The list only contains IDs, so we need to get each pull request
+		// to find out its references.
+		for _, id := range result.PullRequestIds {
+			pr, err := c.getPullRequest(ctx, svc, id)
+			if err != nil {
+				return nil, err
+			}
+			if pr.SourceReference == source && pr.DestinationReference == destination {
+				return pr, nil
+			}
+		}
+
+		if result.NextToken == nil {
+			return nil, ErrPullRequestNotFound
+		}
+		input.NextToken = result.NextToken
+	}
+}
+
+// GetPullRequest returns the pull request with the given ID.
+func (c *Client) GetPullRequest(ctx context.Context, id string) (*PullRequest, error) {
+	return c.getPullRequest(ctx, codecommit.NewFromConfig(c.aws), id)
+}
+
+func (c *Client) getPullRequest(ctx context.Context, svc *codecommit.Client, id string) (*PullRequest, error) {
+	result, err := svc.GetPullRequest(ctx, &codecommit.GetPullRequestInput{PullRequestId: aws.String(id)})
+	if err != nil {
+		if errors.HasType(err, &codecommittypes.PullRequestDoesNotExistException{}) {
+			return nil, ErrPullRequestNotFound
+		}
+		return nil, &wrappedError{err: err}
+	}
+	return c.withApprovals(ctx, svc, result.PullRequest)
+}
+
+// UpdatePullRequest updates the title and description of the pull request
+// with the given ID. The destination of a pull request can't be changed.
+func (c *Client) UpdatePullRequest(ctx context.Context, id, title, description string) (*PullRequest, error) {
+	svc := codecommit.NewFromConfig(c.aws)
+	if _, err := svc.UpdatePullRequestTitle(ctx, &codecommit.UpdatePullRequestTitleInput{
+		PullRequestId: aws.String(id),
+		Title:         aws.String(title),
+	}); err != nil {
+		return nil, &wrappedError{err: err}
+	}
+
+	result, err := svc.UpdatePullRequestDescription(ctx, &codecommit.UpdatePullRequestDescriptionInput{
+		PullRequestId: aws.String(id),
+		Description:   aws.String(description),
+	})
+	if err != nil {
+		return nil, &wrappedError{err: err}
+	}
+	return c.withApprovals(ctx, svc, result.PullRequest)
+}
+
+// UpdatePullRequestStatus closes or reopens the pull request with the given
+// ID.
+func (c *Client) UpdatePullRequestStatus(ctx context.Context, id, status string) (*PullRequest, error) {
+	svc := codecommit.NewFromConfig(c.aws)
+	result, err := svc.UpdatePullRequestStatus(ctx, &codecommit.UpdatePullRequestStatusInput{
+		PullRequestId:     aws.String(id),
+		PullRequestStatus: codecommittypes.PullRequestStatusEnum(status),
+	})
+	if err != nil {
+		return nil, &wrappedError{err: err}
+	}
+	return c.withApprovals(ctx, svc, result.PullRequest)
+}
+
+// CreatePullRequestComment posts a comment with the given text on the pull
+// request.
+func (c *Client) CreatePullRequestComment(ctx context.Context, pr *PullRequest, text string) error {
+	svc := codecommit.NewFromConfig(c.aws)
+	_, err := svc.PostCommentForPullRequest(ctx, &codecommit.PostCommentForPullRequestInput{
+		PullRequestId:  aws.String(pr.ID),
+		RepositoryName: aws.String(pr.RepositoryName),
+		BeforeCommitId: aws.String(pr.DestinationCommit),
+		AfterCommitId:  aws.String(pr.SourceCommit),
+		Content:        aws.String(text),
+	})
+	if err != nil {
+		return &wrappedError{err: err}
+	}
+	return nil
+}
+
+// MergePullRequest merges the pull request, squashing its commits if squash
+// is true. If the pull request can't be merged, an error wrapping
+// ErrNotMergeable is returned.
+func (c *Client) MergePullRequest(ctx context.Context, pr *PullRequest, squash bool) (*PullRequest, error) {
+	svc := codecommit.NewFromConfig(c.aws)
+
+	var merged *codecommittypes.PullRequest
+	var err error
+	if squash {
+		var result *codecommit.MergePullRequestBySquashOutput
+		result, err = svc.MergePullRequestBySquash(ctx, &codecommit.MergePullRequestBySquashInput{
+			PullRequestId:  aws.String(pr.ID),
+			RepositoryName: aws.String(pr.RepositoryName),
+			SourceCommitId: aws.String(pr.SourceCommit),
+		})
+		if err == nil {
+			merged = result.PullRequest
+		}
+	} else {
+		var result *codecommit.MergePullRequestByThreeWayOutput
+		result, err = svc.MergePullRequestByThreeWay(ctx, &codecommit.MergePullRequestByThreeWayInput{
+			PullRequestId:  aws.String(pr.ID),
+			RepositoryName: aws.String(pr.RepositoryName),
+			SourceCommitId: aws.String(pr.SourceCommit),
+		})
+		if err == nil {
+			merged = result.PullRequest
+		}
+	}
+	if err != nil {
+		if isNotMergeable(err) {
+			return nil, errors.Wrap(ErrNotMergeable, err.Error())
+		}
+		return nil, &wrappedError{err: err}
+	}
+	return c.withApprovals(ctx, svc, merged)
+}
+
+func isNotMergeable(err error) bool {
+	return errors.HasType(err, &codecommittypes.ManualMergeRequiredException{}) ||
+		errors.HasType(err, &codecommittypes.PullRequestApprovalRulesNotSatisfiedException{}) ||
+		errors.HasType(err, &codecommittypes.TipOfSourceReferenceIsDifferentException{})
+}
+
+// withApprovals converts pr and loads the approvals of its current revision,
+// which aren't returned with it.
+func (c *Client) withApprovals(ctx context.Context, svc *codecommit.Client, pr *codecommittypes.PullRequest) (*PullRequest, error) {
+	p := c.fromPullRequest(pr)
+
+	states, err := svc.GetPullRequestApprovalStates(ctx, &codecommit.GetPullRequestApprovalStatesInput{
+		PullRequestId: aws.String(p.ID),
+		RevisionId:    aws.String(p.RevisionID),
+	})
+	if err != nil {
+		return nil, errors.Wrap(&wrappedError{err: err}, "getting approval states")
+	}
+	for _, a := range states.Approvals {
+		if a.ApprovalState == codecommittypes.ApprovalStateApprove && a.UserArn != nil {
+			p.ApprovedBy = append(p.ApprovedBy, *a.UserArn)
+		}
+	}
+
+	evaluation, err := svc.EvaluatePullRequestApprovalRules(ctx, &codecommit.EvaluatePullRequestApprovalRulesInput{
+		PullRequestId: aws.String(p.ID),
+		RevisionId:    aws.String(p.RevisionID),
+	})
+	if err != nil {
+		return nil, errors.Wrap(&wrappedError{err: err}, "evaluating approval rules")
+	}
+	if e := evaluation.Evaluation; e != nil && !e.Overridden {
+		p.ApprovalRulesNotSatisfied = e.ApprovalRulesNotSatisfied
+	}
+
+	return p, nil
+}
+
+func (c *Client) fromPullRequest(pr *codecommittypes.PullRequest) *PullRequest {
+	p := PullRequest{
+		ID:          aws.ToString(pr.PullRequestId),
+		RevisionID:  aws.ToString(pr.RevisionId),
+		Title:       aws.ToString(pr.Title),
+		Description: aws.ToString(pr.Description),
+		Status:      string(pr.PullRequestStatus),
+		AuthorARN:   aws.ToString(pr.AuthorArn),
+	}
+	if pr.CreationDate != nil {
+		p.CreationDate = *pr.CreationDate
+	}
+	if pr.LastActivityDate != nil {
+		p.LastActivityDate = *pr.LastActivityDate
+	}
+
+	// Pull requests created by Sourcegraph always have a single target.
+	if len(pr.PullRequestTargets) > 0 {
+		t := pr.PullRequestTargets[0]
+		p.RepositoryName = aws.ToString(t.RepositoryName)
+		p.SourceReference = aws.ToString(t.SourceReference)
+		p.DestinationReference = aws.ToString(t.DestinationReference)
+		p.SourceCommit = aws.ToString(t.SourceCommit)
+		p.DestinationCommit = aws.ToString(t.DestinationCommit)
+		if t.MergeMetadata != nil {
+			p.IsMerged = t.MergeMetadata.IsMerged
+		}
+	}
+
+	p.WebURL = pullRequestWebURL(c.aws.Region, p.RepositoryName, p.ID)
+	return &p
+}
+
+func pullRequestWebURL(region, repositoryName, id string) string {
+	return fmt.Sprintf(
+		"https://%s.console.aws.amazon.com/codesuite/codecommit/repositories/%s/pull-requests/%s/details?region=%s",
+		region, url.PathEscape(repositoryName), id, region,
+	)
+}
+
+// AuthorName returns the name of the IAM user or role who created the pull
+// request, which is the last component of the author's ARN.
+func (pr *PullRequest) AuthorName() string {
+	return pr.AuthorARN[strings.LastIndex(pr.AuthorARN, "/")+1:]
+}
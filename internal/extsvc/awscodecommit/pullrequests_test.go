@@ -0,0 +1,102 @@
+package awscodecommit
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	codecommittypes "github.com/aws/aws-sdk-go-v2/service/codecommit/types"
+	"github.com/cockroachdb/errors"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestClient_fromPullRequest(t *testing.T) {
+	c := &Client{aws: aws.Config{Region: "us-west-1"}}
+	created := time.Date(2021, 11, 2, 10, 30, 58, 0, time.UTC)
+	updated := created.Add(time.Hour)
+
+	have := c.fromPullRequest(&codecommittypes.PullRequest{
+		PullRequestId:     aws.String("7"),
+		RevisionId:        aws.String("rev"),
+		Title:             aws.String("Title"),
+		Description:       aws.String("Body"),
+		PullRequestStatus: codecommittypes.PullRequestStatusEnumClosed,
+		AuthorArn:         aws.String("arn:aws:iam::185007729374:user/sourcegraph-test"),
+		CreationDate:      &created,
+		LastActivityDate:  &updated,
+		PullRequestTargets: []codecommittypes.PullRequestTarget{{
+			RepositoryName:       aws.String("my repo"),
+			SourceReference:      aws.String("refs/heads/feature"),
+			DestinationReference: aws.String("refs/heads/master"),
+			SourceCommit:         aws.String("source"),
+			DestinationCommit:    aws.String("destination"),
+			MergeMetadata:        &codecommittypes.MergeMetadata{IsMerged: true},
+		}},
+	})
+
+	want := &PullRequest{
+		ID:                   "7",
+		RevisionID:           "rev",
+		Title:                "Title",
+		Description:          "Body",
+		Status:               PullRequestStatusClosed,
+		AuthorARN:            "arn:aws:iam::185007729374:user/sourcegraph-test",
+		RepositoryName:       "my repo",
+		SourceReference:      "refs/heads/feature",
+		DestinationReference: "refs/heads/master",
+		SourceCommit:         "source",
+		DestinationCommit:    "destination",
+		IsMerged:             true,
+		CreationDate:         created,
+		LastActivityDate:     updated,
+		WebURL:               "https://us-west-1.console.aws.amazon.com/codesuite/codecommit/repositories/my%20repo/pull-requests/7/details?region=us-west-1",
+	}
+	if diff := cmp.Diff(want, have); diff != "" {
+		t.Fatalf("unexpected pull request (-want +have):\n%s", diff)
+	}
+}
+
+func TestPullRequest_AuthorName(t *testing.T) {
+	for arn, want := range map[string]string{
+		"arn:aws:iam::185007729374:user/sourcegraph-test":                "sourcegraph-test",
+		"arn:aws:sts::185007729374:assumed-role/deploy/session-name":     "session-name",
+		"arn:aws:iam::185007729374:user/division/team/sourcegraph-test2": "sourcegraph-test2",
+		"": "",
+	} {
+		if have := (&PullRequest{AuthorARN: arn}).AuthorName(); have != want {
+			t.Errorf("ARN %q: have author name %q, want %q", arn, have, want)
+		}
+	}
+}
+
+func TestIsNotMergeable(t *testing.T) {
+	for name, tc := range map[string]struct {
+		err  error
+		want bool
+	}{
+		"manual merge required": {
+			err:  &codecommittypes.ManualMergeRequiredException{},
+			want: true,
+		},
+		"approval rules not satisfied": {
+			err:  errors.Wrap(&codecommittypes.PullRequestApprovalRulesNotSatisfiedException{}, "merging"),
+			want: true,
+		},
+		"source changed": {
+			err:  &codecommittypes.TipOfSourceReferenceIsDifferentException{},
+			want: true,
+		},
+		"pull request closed": {
+			err: &codecommittypes.PullRequestAlreadyClosedException{},
+		},
+		"other error": {
+			err: errors.New("boom"),
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			if have := isNotMergeable(tc.err); have != tc.want {
+				t.Errorf("have %v, want %v", have, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,410 @@
+package azuredevops
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/cockroachdb/errors"
+	"github.com/opentracing-contrib/go-stdlib/nethttp"
+	"golang.org/x/time/rate"
+
+	"github.com/sourcegraph/sourcegraph/internal/extsvc/auth"
+	"github.com/sourcegraph/sourcegraph/internal/httpcli"
+	"github.com/sourcegraph/sourcegraph/internal/metrics"
+	"github.com/sourcegraph/sourcegraph/internal/ratelimit"
+	"github.com/sourcegraph/sourcegraph/internal/trace/ot"
+)
+
+var requestCounter = metrics.NewRequestMeter("azuredevops_requests_count", "Total number of requests sent to the Azure DevOps API.")
+
+// APIVersion is the version of the Azure DevOps REST API the client uses. It
+// is supported by Azure DevOps Services and Azure DevOps Server 2020.
+const APIVersion = "6.0"
+
+// ErrPullRequestNotFound is returned by GetPullRequest if the pull request
+// doesn't exist.
+var ErrPullRequestNotFound = errors.New("pull request not found")
+
+// ErrPullRequestAlreadyExists is returned by CreatePullRequest if an active
+// pull request for the same source and target branches already exists.
+var ErrPullRequestAlreadyExists = errors.New("pull request already exists")
+
+// ErrNotMergeable is returned by CompletePullRequest if the pull request
+// can't be completed, because it has conflicts or its branch policies aren't
+// met.
+var ErrNotMergeable = errors.New("pull request cannot be completed")
+
+// Client access Azure DevOps via the REST API.
+type Client struct {
+	// URL is the base URL of Azure DevOps, without an organization or
+	// collection, such as https://dev.azure.com/.
+	URL *url.URL
+
+	// Auth is the authenticator used to authenticate requests.
+	Auth auth.Authenticator
+
+	httpClient httpcli.Doer
+	rateLimit  *rate.Limiter
+}
+
+// NewClient returns a new Azure DevOps API client for the given base URL,
+// which authenticates requests with a. If a nil httpClient is provided,
+// httpcli.ExternalDoer will be used.
+func NewClient(baseURL *url.URL, a auth.Authenticator, httpClient httpcli.Doer) *Client {
+	if httpClient == nil {
+		httpClient = httpcli.ExternalDoer()
+	}
+
+	httpClient = requestCounter.Doer(httpClient, func(u *url.URL) string {
+		// The component of the path after _apis (/<org>/<project>/_apis/<area>/...)
+		// maps to the type of API request we are making.
+		parts := strings.Split(u.Path, "/")
+		for i, p := range parts {
+			if p == "_apis" && i+1 < len(parts) {
+				return parts[i+1]
+			}
+		}
+		return ""
+	})
+
+	return &Client{
+		URL:        baseURL,
+		Auth:       a,
+		httpClient: httpClient,
+		rateLimit:  ratelimit.DefaultRegistry.Get(baseURL.String()),
+	}
+}
+
+// WithAuthenticator returns a copy of the client which authenticates requests
+// with a.
+func (c *Client) WithAuthenticator(a auth.Authenticator) *Client {
+	cc := *c
+	cc.Auth = a
+	return &cc
+}
+
+// AuthenticatedUser returns the identity the client authenticates as in the
+// given organization.
+func (c *Client) AuthenticatedUser(ctx context.Context, org string) (*Identity, error) {
+	var data struct {
+		AuthenticatedUser Identity `json:"authenticatedUser"`
+	}
+	if _, err := c.do(ctx, "GET", org+"/_apis/connectionData", nil, nil, &data); err != nil {
+		return nil, err
+	}
+	return &data.AuthenticatedUser, nil
+}
+
+// ListProjects returns a page of the projects of the given organization, and
+// the continuation token of the next page, which is empty after the last
+// page. The first page is requested with an empty continuation token.
+func (c *Client) ListProjects(ctx context.Context, org, continuationToken string) ([]*Project, string, error) {
+	qry := url.Values{"$top": []string{"100"}}
+	if continuationToken != "" {
+		qry.Set("continuationToken", continuationToken)
+	}
+	var data struct {
+		Value []*Project `json:"value"`
+	}
+	header, err := c.do(ctx, "GET", org+"/_apis/projects", qry, nil, &data)
+	if err != nil {
+		return nil, "", err
+	}
+	return data.Value, header.Get("X-Ms-Continuationtoken"), nil
+}
+
+// ListRepositories returns the repositories of the given project.
+func (c *Client) ListRepositories(ctx context.Context, org, project string) ([]*Repository, error) {
+	var data struct {
+		Value []*Repository `json:"value"`
+	}
+	if _, err := c.do(ctx, "GET", org+"/"+project+"/_apis/git/repositories", nil, nil, &data); err != nil {
+		return nil, err
+	}
+	for _, r := range data.Value {
+		r.Org = org
+	}
+	return data.Value, nil
+}
+
+// GetRepository returns the repository of the given project with the given
+// name or ID.
+func (c *Client) GetRepository(ctx context.Context, org, project, nameOrID string) (*Repository, error) {
+	var r Repository
+	if _, err := c.do(ctx, "GET", org+"/"+project+"/_apis/git/repositories/"+nameOrID, nil, nil, &r); err != nil {
+		return nil, err
+	}
+	r.Org = org
+	return &r, nil
+}
+
+// CreatePullRequest creates a pull request in the given repository. If an
+// active pull request for the same branches exists, ErrPullRequestAlreadyExists
+// is returned.
+func (c *Client) CreatePullRequest(ctx context.Context, repo *Repository, input *CreatePullRequestInput) (*PullRequest, error) {
+	var pr PullRequest
+	if _, err := c.do(ctx, "POST", pullRequestsPath(repo), nil, input, &pr); err != nil {
+		var e *httpError
+		if errors.As(err, &e) && e.StatusCode == http.StatusConflict && e.TypeKey == "GitPullRequestExistsException" {
+			return nil, ErrPullRequestAlreadyExists
+		}
+		return nil, err
+	}
+	setPullRequestWebURL(repo, &pr)
+	return &pr, nil
+}
+
+// FindActivePullRequest returns the active pull request of the given
+// repository from sourceRef into targetRef, or ErrPullRequestNotFound if there
+// is none.
+func (c *Client) FindActivePullRequest(ctx context.Context, repo *Repository, sourceRef, targetRef string) (*PullRequest, error) {
+	qry := url.Values{
+		"searchCriteria.sourceRefName": []string{sourceRef},
+		"searchCriteria.targetRefName": []string{targetRef},
+		"searchCriteria.status":        []string{"active"},
+	}
+	var data struct {
+		Value []*PullRequest `json:"value"`
+	}
+	if _, err := c.do(ctx, "GET", pullRequestsPath(repo), qry, nil, &data); err != nil {
+		return nil, err
+	}
+	if len(data.Value) == 0 {
+		return nil, ErrPullRequestNotFound
+	}
+	pr := data.Value[0]
+	setPullRequestWebURL(repo, pr)
+	return pr, nil
+}
+
+// GetPullRequest returns the pull request of the given repository with the
+// given ID, or ErrPullRequestNotFound if it doesn't exist.
+func (c *Client) GetPullRequest(ctx context.Context, repo *Repository, id int) (*PullRequest, error) {
+	var pr PullRequest
+	if _, err := c.do(ctx, "GET", pullRequestsPath(repo)+"/"+strconv.Itoa(id), nil, nil, &pr); err != nil {
+		if IsNotFound(err) {
+			return nil, ErrPullRequestNotFound
+		}
+		return nil, err
+	}
+	setPullRequestWebURL(repo, &pr)
+	return &pr, nil
+}
+
+// UpdatePullRequest updates the pull request of the given repository with the
+// given ID and returns its new state.
+func (c *Client) UpdatePullRequest(ctx context.Context, repo *Repository, id int, input *UpdatePullRequestInput) (*PullRequest, error) {
+	var pr PullRequest
+	if _, err := c.do(ctx, "PATCH", pullRequestsPath(repo)+"/"+strconv.Itoa(id), nil, input, &pr); err != nil {
+		return nil, err
+	}
+	setPullRequestWebURL(repo, &pr)
+	return &pr, nil
+}
+
+// CompletePullRequest merges the given pull request of the given repository
+// and returns its new state. If squash is true, the commits of the pull
+// request are squashed.
+func (c *Client) CompletePullRequest(ctx context.Context, repo *Repository, pr *PullRequest, squash bool) (*PullRequest, error) {
+	if pr.MergeStatus == "conflicts" {
+		return nil, errors.Wrap(ErrNotMergeable, "the pull request has conflicts")
+	}
+
+	strategy := MergeStrategyNoFastForward
+	if squash {
+		strategy = MergeStrategySquash
+	}
+	updated, err := c.UpdatePullRequest(ctx, repo, pr.PullRequestID, &UpdatePullRequestInput{
+		Status:                PullRequestStatusCompleted,
+		LastMergeSourceCommit: pr.LastMergeSourceCommit,
+		CompletionOptions:     &CompletionOptions{MergeStrategy: strategy},
+	})
+	if err != nil {
+		var e *httpError
+		if errors.As(err, &e) && (e.StatusCode == http.StatusBadRequest || e.StatusCode == http.StatusConflict) {
+			return nil, errors.Wrap(ErrNotMergeable, e.Message)
+		}
+		return nil, err
+	}
+	// Branch policies which aren't met leave the pull request active.
+	if updated.Status != PullRequestStatusCompleted {
+		return nil, errors.Wrap(ErrNotMergeable, "the branch policies of the pull request are not met")
+	}
+	return updated, nil
+}
+
+// CreatePullRequestComment starts a comment thread with the given text on the
+// pull request of the given repository with the given ID.
+func (c *Client) CreatePullRequestComment(ctx context.Context, repo *Repository, id int, text string) error {
+	thread := map[string]interface{}{
+		"comments": []map[string]interface{}{
+			{"parentCommentId": 0, "content": text, "commentType": 1},
+		},
+		"status": 1,
+	}
+	_, err := c.do(ctx, "POST", pullRequestsPath(repo)+"/"+strconv.Itoa(id)+"/threads", nil, thread, &struct{}{})
+	return err
+}
+
+// ListPullRequestStatuses returns the statuses posted to the pull request of
+// the given repository with the given ID, such as build results.
+func (c *Client) ListPullRequestStatuses(ctx context.Context, repo *Repository, id int) ([]*PullRequestStatus, error) {
+	var data struct {
+		Value []*PullRequestStatus `json:"value"`
+	}
+	if _, err := c.do(ctx, "GET", pullRequestsPath(repo)+"/"+strconv.Itoa(id)+"/statuses", nil, nil, &data); err != nil {
+		return nil, err
+	}
+	return data.Value, nil
+}
+
+func pullRequestsPath(repo *Repository) string {
+	return repo.Org + "/" + repo.Project.Name + "/_apis/git/repositories/" + repo.ID + "/pullrequests"
+}
+
+// setPullRequestWebURL sets the WebURL of pr, which the API doesn't return.
+func setPullRequestWebURL(repo *Repository, pr *PullRequest) {
+	if repo.WebURL != "" {
+		pr.WebURL = repo.WebURL + "/pullrequest/" + strconv.Itoa(pr.PullRequestID)
+	}
+}
+
+func (c *Client) do(ctx context.Context, method, path string, qry url.Values, body, result interface{}) (http.Header, error) {
+	if qry == nil {
+		qry = url.Values{}
+	}
+	qry.Set("api-version", APIVersion)
+	u := c.URL.ResolveReference(&url.URL{Path: path, RawQuery: qry.Encode()})
+
+	var bodyReader io.Reader
+	if body != nil {
+		bs, err := json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+		bodyReader = bytes.NewReader(bs)
+	}
+
+	req, err := http.NewRequest(method, u.String(), bodyReader)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if err := c.authenticate(req); err != nil {
+		return nil, err
+	}
+
+	req, ht := nethttp.TraceRequest(ot.GetTracer(ctx),
+		req.WithContext(ctx),
+		nethttp.OperationName("Azure DevOps"),
+		nethttp.ClientTrace(false))
+	defer ht.Finish()
+
+	if err := c.rateLimit.Wait(ctx); err != nil {
+		return nil, err
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	bs, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	// Azure DevOps redirects unauthenticated requests to a sign-in page
+	// instead of failing them.
+	if resp.StatusCode == http.StatusNonAuthoritativeInfo {
+		resp.StatusCode = http.StatusUnauthorized
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 400 {
+		e := &httpError{
+			URL:        req.URL,
+			StatusCode: resp.StatusCode,
+			Body:       bs,
+		}
+		var apiErr struct {
+			Message string `json:"message"`
+			TypeKey string `json:"typeKey"`
+		}
+		if json.Unmarshal(bs, &apiErr) == nil {
+			e.Message, e.TypeKey = apiErr.Message, apiErr.TypeKey
+		}
+		return nil, errors.WithStack(e)
+	}
+
+	return resp.Header, json.Unmarshal(bs, result)
+}
+
+// authenticate authenticates req with the authenticator of the client.
+// Personal access tokens are sent with basic authentication, which is what
+// Azure DevOps expects, even if they are stored as OAuth bearer tokens.
+func (c *Client) authenticate(req *http.Request) error {
+	switch a := c.Auth.(type) {
+	case nil:
+		return nil
+	case *auth.OAuthBearerToken:
+		req.SetBasicAuth("", a.Token)
+		return nil
+	case *auth.OAuthBearerTokenWithSSH:
+		req.SetBasicAuth("", a.Token)
+		return nil
+	default:
+		return a.Authenticate(req)
+	}
+}
+
+type httpError struct {
+	StatusCode int
+	URL        *url.URL
+	Body       []byte
+
+	// Message and TypeKey are the message and type of the error returned by
+	// the API, if any.
+	Message string
+	TypeKey string
+}
+
+func (e *httpError) Error() string {
+	// The query never contains credentials, they are sent in headers.
+	if e.Message != "" {
+		return fmt.Sprintf("Azure DevOps API HTTP error: code=%d url=%q message=%q", e.StatusCode, e.URL, e.Message)
+	}
+	return fmt.Sprintf("Azure DevOps API HTTP error: code=%d url=%q body=%q", e.StatusCode, e.URL, e.Body)
+}
+
+func (e *httpError) Unauthorized() bool {
+	return e.StatusCode == http.StatusUnauthorized
+}
+
+func (e *httpError) NotFound() bool {
+	return e.StatusCode == http.StatusNotFound
+}
+
+// IsNotFound reports whether err is an Azure DevOps API error with a 404
+// status.
+func IsNotFound(err error) bool {
+	var e *httpError
+	return errors.As(err, &e) && e.NotFound()
+}
+
+// IsUnauthorized reports whether err is an Azure DevOps API error with a 401
+// status.
+func IsUnauthorized(err error) bool {
+	var e *httpError
+	return errors.As(err, &e) && e.Unauthorized()
+}
@@ -0,0 +1,159 @@
+package azuredevops
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/sourcegraph/sourcegraph/internal/extsvc/auth"
+)
+
+func newTestClient(t *testing.T, h http.HandlerFunc) *Client {
+	t.Helper()
+	srv := httptest.NewServer(h)
+	t.Cleanup(srv.Close)
+	u, err := url.Parse(srv.URL + "/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return NewClient(u, &auth.OAuthBearerToken{Token: "secret"}, srv.Client())
+}
+
+func TestClient_ListProjects(t *testing.T) {
+	cli := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if _, pass, _ := r.BasicAuth(); pass != "secret" {
+			t.Errorf("expected the token to be sent as basic auth password, got %q", pass)
+		}
+		if r.URL.Path != "/myorg/_apis/projects" || r.URL.Query().Get("api-version") != APIVersion {
+			http.NotFound(w, r)
+			return
+		}
+		switch token := r.URL.Query().Get("continuationToken"); token {
+		case "":
+			w.Header().Set("x-ms-continuationtoken", "next")
+			fmt.Fprint(w, `{"count":1,"value":[{"id":"p1","name":"a"}]}`)
+		case "next":
+			fmt.Fprint(w, `{"count":1,"value":[{"id":"p2","name":"b","visibility":"public"}]}`)
+		default:
+			t.Errorf("unexpected continuation token %q", token)
+		}
+	})
+
+	ctx := context.Background()
+	var have []*Project
+	token := ""
+	for {
+		projects, next, err := cli.ListProjects(ctx, "myorg", token)
+		if err != nil {
+			t.Fatal(err)
+		}
+		have = append(have, projects...)
+		if next == "" {
+			break
+		}
+		token = next
+	}
+
+	want := []*Project{
+		{ID: "p1", Name: "a"},
+		{ID: "p2", Name: "b", Visibility: "public"},
+	}
+	if diff := cmp.Diff(want, have); diff != "" {
+		t.Fatalf("unexpected projects (-want +have):\n%s", diff)
+	}
+}
+
+func TestClient_ListRepositories(t *testing.T) {
+	cli := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.EscapedPath() != "/myorg/my%20project/_apis/git/repositories" {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprint(w, `{"count":1,"value":[{"id":"r1","name":"repo","project":{"id":"p1","name":"my project","visibility":"private"}}]}`)
+	})
+
+	have, err := cli.ListRepositories(context.Background(), "myorg", "my project")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []*Repository{{
+		ID:      "r1",
+		Name:    "repo",
+		Project: Project{ID: "p1", Name: "my project", Visibility: "private"},
+		Org:     "myorg",
+	}}
+	if diff := cmp.Diff(want, have); diff != "" {
+		t.Fatalf("unexpected repos (-want +have):\n%s", diff)
+	}
+	if !have[0].Private() {
+		t.Error("expected repository of private project to be private")
+	}
+}
+
+func TestClient_CreatePullRequest(t *testing.T) {
+	repo := &Repository{
+		ID:      "r1",
+		Org:     "myorg",
+		Project: Project{Name: "proj"},
+		WebURL:  "https://dev.azure.com/myorg/proj/_git/repo",
+	}
+
+	t.Run("created", func(t *testing.T) {
+		cli := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != "POST" || r.URL.Path != "/myorg/proj/_apis/git/repositories/r1/pullrequests" {
+				http.NotFound(w, r)
+				return
+			}
+			body, _ := io.ReadAll(r.Body)
+			if want := `{"sourceRefName":"refs/heads/feature","targetRefName":"refs/heads/main","title":"Title","description":"Body","isDraft":true}`; string(body) != want {
+				t.Errorf("unexpected body:\nhave %s\nwant %s", body, want)
+			}
+			w.WriteHeader(http.StatusCreated)
+			fmt.Fprint(w, `{"pullRequestId":7,"status":"active","title":"Title","isDraft":true}`)
+		})
+
+		pr, err := cli.CreatePullRequest(context.Background(), repo, &CreatePullRequestInput{
+			SourceRefName: "refs/heads/feature",
+			TargetRefName: "refs/heads/main",
+			Title:         "Title",
+			Description:   "Body",
+			IsDraft:       true,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if have, want := pr.WebURL, "https://dev.azure.com/myorg/proj/_git/repo/pullrequest/7"; have != want {
+			t.Errorf("WebURL: have %q, want %q", have, want)
+		}
+	})
+
+	t.Run("already exists", func(t *testing.T) {
+		cli := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusConflict)
+			fmt.Fprint(w, `{"message":"TF401179: An active pull request for the source and target branch already exists.","typeKey":"GitPullRequestExistsException"}`)
+		})
+
+		_, err := cli.CreatePullRequest(context.Background(), repo, &CreatePullRequestInput{})
+		if err != ErrPullRequestAlreadyExists {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+}
+
+func TestClient_GetPullRequest_NotFound(t *testing.T) {
+	cli := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		fmt.Fprint(w, `{"message":"TF401180: The requested pull request was not found.","typeKey":"GitPullRequestNotFoundException"}`)
+	})
+
+	repo := &Repository{ID: "r1", Org: "myorg", Project: Project{Name: "proj"}}
+	if _, err := cli.GetPullRequest(context.Background(), repo, 7); err != ErrPullRequestNotFound {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
@@ -0,0 +1,3 @@
+// Package azuredevops implements a client for the REST API of Azure DevOps
+// Services and Azure DevOps Server.
+package azuredevops
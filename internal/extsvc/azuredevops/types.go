@@ -0,0 +1,191 @@
+package azuredevops
+
+import (
+	"net/url"
+	"time"
+
+	"github.com/sourcegraph/sourcegraph/internal/api"
+	"github.com/sourcegraph/sourcegraph/internal/extsvc"
+)
+
+// Repository is an Azure DevOps Git repository, as returned by the API. It is
+// stored as the metadata of Sourcegraph repositories.
+type Repository struct {
+	ID            string  `json:"id"`
+	Name          string  `json:"name"`
+	URL           string  `json:"url"`
+	Project       Project `json:"project"`
+	DefaultBranch string  `json:"defaultBranch,omitempty"`
+	Size          int64   `json:"size"`
+	RemoteURL     string  `json:"remoteUrl"`
+	SSHURL        string  `json:"sshUrl"`
+	WebURL        string  `json:"webUrl"`
+	IsDisabled    bool    `json:"isDisabled,omitempty"`
+
+	// Org is the organization (or collection, on Azure DevOps Server) of the
+	// repository. It isn't returned by the API but set by the client.
+	Org string `json:"org,omitempty"`
+}
+
+// Private reports whether the repository is only visible to members of its
+// project.
+func (r *Repository) Private() bool {
+	return r.Project.Visibility != "public"
+}
+
+// Project is an Azure DevOps project.
+type Project struct {
+	ID         string `json:"id"`
+	Name       string `json:"name"`
+	Visibility string `json:"visibility,omitempty"`
+}
+
+// Identity is an Azure DevOps user, as returned by the connection data API.
+type Identity struct {
+	ID                  string `json:"id"`
+	ProviderDisplayName string `json:"providerDisplayName"`
+}
+
+// IdentityRef is a reference to an Azure DevOps user.
+type IdentityRef struct {
+	ID          string `json:"id"`
+	DisplayName string `json:"displayName"`
+	UniqueName  string `json:"uniqueName"`
+}
+
+// Pull request statuses.
+const (
+	PullRequestStatusActive    = "active"
+	PullRequestStatusAbandoned = "abandoned"
+	PullRequestStatusCompleted = "completed"
+)
+
+// Reviewer votes.
+const (
+	VoteApproved               = 10
+	VoteApprovedWithSuggestion = 5
+	VoteNone                   = 0
+	VoteWaitingForAuthor       = -5
+	VoteRejected               = -10
+)
+
+// PullRequest is an Azure DevOps pull request. It is stored as the metadata
+// of changesets.
+type PullRequest struct {
+	PullRequestID         int                  `json:"pullRequestId"`
+	Status                string               `json:"status"`
+	CreatedBy             IdentityRef          `json:"createdBy"`
+	CreationDate          time.Time            `json:"creationDate"`
+	ClosedDate            time.Time            `json:"closedDate,omitempty"`
+	Title                 string               `json:"title"`
+	Description           string               `json:"description"`
+	SourceRefName         string               `json:"sourceRefName"`
+	TargetRefName         string               `json:"targetRefName"`
+	MergeStatus           string               `json:"mergeStatus,omitempty"`
+	IsDraft               bool                 `json:"isDraft"`
+	LastMergeSourceCommit *Commit              `json:"lastMergeSourceCommit,omitempty"`
+	LastMergeTargetCommit *Commit              `json:"lastMergeTargetCommit,omitempty"`
+	Reviewers             []*Reviewer          `json:"reviewers"`
+	Repository            Repository           `json:"repository"`
+	Statuses              []*PullRequestStatus `json:"statuses,omitempty"`
+
+	// WebURL is the URL of the pull request in the web UI. It isn't returned
+	// by the API but set by the client.
+	WebURL string `json:"webUrl,omitempty"`
+}
+
+// LastUpdated returns when the pull request was last updated, as far as we
+// can tell: the API doesn't return a last update time.
+func (pr *PullRequest) LastUpdated() time.Time {
+	t := pr.CreationDate
+	if pr.ClosedDate.After(t) {
+		t = pr.ClosedDate
+	}
+	for _, s := range pr.Statuses {
+		if s.CreationDate.After(t) {
+			t = s.CreationDate
+		}
+	}
+	return t
+}
+
+// Commit is a reference to a commit.
+type Commit struct {
+	CommitID string `json:"commitId"`
+}
+
+// Reviewer is a reviewer of a pull request and their vote.
+type Reviewer struct {
+	IdentityRef
+	Vote       int  `json:"vote"`
+	IsRequired bool `json:"isRequired,omitempty"`
+}
+
+// Pull request status states.
+const (
+	StatusStateSucceeded     = "succeeded"
+	StatusStatePending       = "pending"
+	StatusStateNotSet        = "notSet"
+	StatusStateNotApplicable = "notApplicable"
+	StatusStateFailed        = "failed"
+	StatusStateError         = "error"
+)
+
+// PullRequestStatus is a status posted to a pull request, such as the result
+// of a build.
+type PullRequestStatus struct {
+	ID           int           `json:"id"`
+	State        string        `json:"state"`
+	Description  string        `json:"description"`
+	Context      StatusContext `json:"context"`
+	CreationDate time.Time     `json:"creationDate"`
+	TargetURL    string        `json:"targetUrl,omitempty"`
+}
+
+// StatusContext identifies the service which posted a status.
+type StatusContext struct {
+	Name  string `json:"name"`
+	Genre string `json:"genre,omitempty"`
+}
+
+// CreatePullRequestInput is the input of CreatePullRequest.
+type CreatePullRequestInput struct {
+	SourceRefName string `json:"sourceRefName"`
+	TargetRefName string `json:"targetRefName"`
+	Title         string `json:"title"`
+	Description   string `json:"description"`
+	IsDraft       bool   `json:"isDraft"`
+}
+
+// UpdatePullRequestInput is the input of UpdatePullRequest. Only the fields
+// which are set are updated.
+type UpdatePullRequestInput struct {
+	Title                 string             `json:"title,omitempty"`
+	Description           *string            `json:"description,omitempty"`
+	Status                string             `json:"status,omitempty"`
+	TargetRefName         string             `json:"targetRefName,omitempty"`
+	IsDraft               *bool              `json:"isDraft,omitempty"`
+	LastMergeSourceCommit *Commit            `json:"lastMergeSourceCommit,omitempty"`
+	CompletionOptions     *CompletionOptions `json:"completionOptions,omitempty"`
+}
+
+// Merge strategies of CompletionOptions.
+const (
+	MergeStrategyNoFastForward = "noFastForward"
+	MergeStrategySquash        = "squash"
+)
+
+// CompletionOptions are the options used to merge a pull request.
+type CompletionOptions struct {
+	MergeStrategy      string `json:"mergeStrategy"`
+	DeleteSourceBranch bool   `json:"deleteSourceBranch"`
+}
+
+// ExternalRepoSpec returns an api.ExternalRepoSpec that refers to the specified Azure DevOps repository.
+func ExternalRepoSpec(repo *Repository, baseURL url.URL) api.ExternalRepoSpec {
+	return api.ExternalRepoSpec{
+		ID:          repo.ID,
+		ServiceType: extsvc.TypeAzureDevOps,
+		ServiceID:   extsvc.NormalizeBaseURL(&baseURL).String(),
+	}
+}
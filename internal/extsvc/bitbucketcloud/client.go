@@ -16,6 +16,7 @@ import (
 	"github.com/opentracing-contrib/go-stdlib/nethttp"
 	"golang.org/x/time/rate"
 
+	"github.com/sourcegraph/sourcegraph/internal/extsvc/auth"
 	"github.com/sourcegraph/sourcegraph/internal/httpcli"
 	"github.com/sourcegraph/sourcegraph/internal/metrics"
 	"github.com/sourcegraph/sourcegraph/internal/ratelimit"
@@ -50,6 +51,10 @@ type Client struct {
 	// The username and app password credentials for accessing the server.
 	Username, AppPassword string
 
+	// Auth is used to authenticate requests instead of Username and
+	// AppPassword, if set.
+	Auth auth.Authenticator
+
 	// RateLimit is the self-imposed rate limiter (since Bitbucket does not have a concept
 	// of rate limiting in HTTP response headers).
 	RateLimit *rate.Limiter
@@ -86,6 +91,14 @@ func NewClient(apiURL *url.URL, httpClient httpcli.Doer) *Client {
 	}
 }
 
+// WithAuthenticator returns a copy of the client which authenticates requests
+// with a instead of its username and app password.
+func (c *Client) WithAuthenticator(a auth.Authenticator) *Client {
+	cc := *c
+	cc.Auth = a
+	return &cc
+}
+
 // Repos returns a list of repositories that are fetched and populated based on given account
 // name and pagination criteria. If the account requested is a team, results will be filtered
 // down to the ones that the app password's user has access to.
@@ -195,6 +208,9 @@ func (c *Client) do(ctx context.Context, req *http.Request, result interface{})
 }
 
 func (c *Client) authenticate(req *http.Request) error {
+	if c.Auth != nil {
+		return c.Auth.Authenticate(req)
+	}
 	req.SetBasicAuth(c.Username, c.AppPassword)
 	return nil
 }
@@ -270,6 +286,20 @@ func (e *httpError) Error() string {
 	return fmt.Sprintf("Bitbucket Cloud API HTTP error: code=%d url=%q body=%q", e.StatusCode, e.URL, e.Body)
 }
 
+// message returns the error message in the body of the response, or the body
+// itself if it doesn't contain one.
+func (e *httpError) message() string {
+	var body struct {
+		Error struct {
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	if err := json.Unmarshal(e.Body, &body); err != nil || body.Error.Message == "" {
+		return string(e.Body)
+	}
+	return body.Error.Message
+}
+
 func (e *httpError) Unauthorized() bool {
 	return e.StatusCode == http.StatusUnauthorized
 }
@@ -0,0 +1,299 @@
+package bitbucketcloud
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/cockroachdb/errors"
+)
+
+// ErrPullRequestNotFound is returned when a pull request doesn't exist.
+var ErrPullRequestNotFound = errors.New("Bitbucket Cloud pull request not found")
+
+// ErrNotMergeable is returned by MergePullRequest when the pull request can't
+// be merged, for example because it has conflicts or doesn't meet the merge
+// checks of its destination branch.
+var ErrNotMergeable = errors.New("Bitbucket Cloud pull request cannot be merged")
+
+// PullRequestState is the state of a pull request.
+type PullRequestState string
+
+const (
+	PullRequestStateOpen       PullRequestState = "OPEN"
+	PullRequestStateMerged     PullRequestState = "MERGED"
+	PullRequestStateDeclined   PullRequestState = "DECLINED"
+	PullRequestStateSuperseded PullRequestState = "SUPERSEDED"
+)
+
+// ParticipantRole is the role of a participant of a pull request.
+type ParticipantRole string
+
+const (
+	ParticipantRoleParticipant ParticipantRole = "PARTICIPANT"
+	ParticipantRoleReviewer    ParticipantRole = "REVIEWER"
+)
+
+// ParticipantState is the review state of a participant of a pull request.
+// It's empty for participants that haven't reviewed the pull request.
+type ParticipantState string
+
+const (
+	ParticipantStateApproved         ParticipantState = "approved"
+	ParticipantStateChangesRequested ParticipantState = "changes_requested"
+)
+
+// CommitStatusState is the state of a build status of a commit.
+type CommitStatusState string
+
+const (
+	CommitStatusStateSuccessful CommitStatusState = "SUCCESSFUL"
+	CommitStatusStateFailed     CommitStatusState = "FAILED"
+	CommitStatusStateInProgress CommitStatusState = "INPROGRESS"
+	CommitStatusStateStopped    CommitStatusState = "STOPPED"
+)
+
+// MergeStrategy is the strategy used to merge a pull request.
+type MergeStrategy string
+
+const (
+	MergeStrategyMergeCommit MergeStrategy = "merge_commit"
+	MergeStrategySquash      MergeStrategy = "squash"
+)
+
+// Account is a Bitbucket Cloud user or team.
+type Account struct {
+	AccountID   string `json:"account_id"`
+	DisplayName string `json:"display_name"`
+	Nickname    string `json:"nickname"`
+	UUID        string `json:"uuid"`
+}
+
+// PullRequest is a Bitbucket Cloud pull request.
+type PullRequest struct {
+	ID                int64               `json:"id"`
+	Title             string              `json:"title"`
+	Description       string              `json:"description"`
+	State             PullRequestState    `json:"state"`
+	Author            Account             `json:"author"`
+	Source            PullRequestEndpoint `json:"source"`
+	Destination       PullRequestEndpoint `json:"destination"`
+	Participants      []Participant       `json:"participants"`
+	CloseSourceBranch bool                `json:"close_source_branch"`
+	CreatedOn         time.Time           `json:"created_on"`
+	UpdatedOn         time.Time           `json:"updated_on"`
+	Links             Links               `json:"links"`
+
+	// Statuses are the build statuses of the source commit of the pull
+	// request. They aren't returned with the pull request, but loaded with
+	// PullRequestStatuses.
+	Statuses []*CommitStatus `json:"statuses,omitempty"`
+}
+
+// PullRequestEndpoint is the source or destination of a pull request.
+type PullRequestEndpoint struct {
+	Branch     PullRequestBranch `json:"branch"`
+	Commit     PullRequestCommit `json:"commit"`
+	Repository Repo              `json:"repository"`
+}
+
+type PullRequestBranch struct {
+	Name string `json:"name"`
+}
+
+type PullRequestCommit struct {
+	// Hash is the abbreviated hash of the commit.
+	Hash string `json:"hash"`
+}
+
+// Participant is a user that participates in a pull request, either as a
+// reviewer or by commenting on it.
+type Participant struct {
+	User           Account          `json:"user"`
+	Role           ParticipantRole  `json:"role"`
+	Approved       bool             `json:"approved"`
+	State          ParticipantState `json:"state"`
+	ParticipatedOn time.Time        `json:"participated_on"`
+}
+
+// CommitStatus is a build status of a commit.
+type CommitStatus struct {
+	Key       string            `json:"key"`
+	Name      string            `json:"name"`
+	State     CommitStatusState `json:"state"`
+	URL       string            `json:"url"`
+	CreatedOn time.Time         `json:"created_on"`
+	UpdatedOn time.Time         `json:"updated_on"`
+}
+
+// PullRequestInput is the input to create or update a pull request.
+type PullRequestInput struct {
+	Title             string
+	Description       string
+	SourceBranch      string
+	DestinationBranch string
+}
+
+func (input *PullRequestInput) MarshalJSON() ([]byte, error) {
+	type branch struct {
+		Name string `json:"name"`
+	}
+	type endpoint struct {
+		Branch branch `json:"branch"`
+	}
+	return json.Marshal(struct {
+		Title       string   `json:"title"`
+		Description string   `json:"description"`
+		Source      endpoint `json:"source"`
+		Destination endpoint `json:"destination"`
+	}{
+		Title:       input.Title,
+		Description: input.Description,
+		Source:      endpoint{Branch: branch{Name: input.SourceBranch}},
+		Destination: endpoint{Branch: branch{Name: input.DestinationBranch}},
+	})
+}
+
+// CurrentUser returns the user the client authenticates as.
+func (c *Client) CurrentUser(ctx context.Context) (*Account, error) {
+	var user Account
+	if err := c.doJSON(ctx, "GET", "/2.0/user", nil, &user); err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// CreatePullRequest opens a new pull request in repo.
+func (c *Client) CreatePullRequest(ctx context.Context, repo *Repo, input *PullRequestInput) (*PullRequest, error) {
+	var pr PullRequest
+	if err := c.doJSON(ctx, "POST", pullRequestsPath(repo), input, &pr); err != nil {
+		return nil, err
+	}
+	return &pr, nil
+}
+
+// FindOpenPullRequest returns the open pull request in repo from the source
+// branch to the destination branch, or ErrPullRequestNotFound if there is
+// none.
+func (c *Client) FindOpenPullRequest(ctx context.Context, repo *Repo, source, destination string) (*PullRequest, error) {
+	qry := url.Values{"q": []string{fmt.Sprintf(
+		"source.branch.name = %q AND destination.branch.name = %q AND state = %q",
+		source, destination, PullRequestStateOpen,
+	)}}
+
+	var prs []*PullRequest
+	if _, err := c.page(ctx, pullRequestsPath(repo), qry, nil, &prs); err != nil {
+		return nil, err
+	}
+	if len(prs) == 0 {
+		return nil, ErrPullRequestNotFound
+	}
+	return prs[0], nil
+}
+
+// GetPullRequest returns the pull request of repo with the given ID.
+func (c *Client) GetPullRequest(ctx context.Context, repo *Repo, id int64) (*PullRequest, error) {
+	var pr PullRequest
+	if err := c.doJSON(ctx, "GET", pullRequestPath(repo, id), nil, &pr); err != nil {
+		var e *httpError
+		if errors.As(err, &e) && e.NotFound() {
+			return nil, ErrPullRequestNotFound
+		}
+		return nil, err
+	}
+	return &pr, nil
+}
+
+// UpdatePullRequest updates the title, description and destination branch of
+// the pull request of repo with the given ID.
+func (c *Client) UpdatePullRequest(ctx context.Context, repo *Repo, id int64, input *PullRequestInput) (*PullRequest, error) {
+	var pr PullRequest
+	if err := c.doJSON(ctx, "PUT", pullRequestPath(repo, id), input, &pr); err != nil {
+		return nil, err
+	}
+	return &pr, nil
+}
+
+// DeclinePullRequest declines the pull request of repo with the given ID.
+// Declined pull requests can't be reopened.
+func (c *Client) DeclinePullRequest(ctx context.Context, repo *Repo, id int64) (*PullRequest, error) {
+	var pr PullRequest
+	if err := c.doJSON(ctx, "POST", pullRequestPath(repo, id)+"/decline", nil, &pr); err != nil {
+		return nil, err
+	}
+	return &pr, nil
+}
+
+// PullRequestStatuses returns the build statuses of the source commit of the
+// pull request of repo with the given ID.
+func (c *Client) PullRequestStatuses(ctx context.Context, repo *Repo, id int64) ([]*CommitStatus, error) {
+	var all []*CommitStatus
+	next, err := c.page(ctx, pullRequestPath(repo, id)+"/statuses", nil, nil, &all)
+	for err == nil && next.HasMore() {
+		var statuses []*CommitStatus
+		next, err = c.reqPage(ctx, next.Next, &statuses)
+		all = append(all, statuses...)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return all, nil
+}
+
+// CreatePullRequestComment posts a comment with the given markdown text on
+// the pull request of repo with the given ID.
+func (c *Client) CreatePullRequestComment(ctx context.Context, repo *Repo, id int64, text string) error {
+	var comment struct {
+		Content struct {
+			Raw string `json:"raw"`
+		} `json:"content"`
+	}
+	comment.Content.Raw = text
+	return c.doJSON(ctx, "POST", pullRequestPath(repo, id)+"/comments", &comment, nil)
+}
+
+// MergePullRequest merges the pull request of repo with the given ID using
+// the given strategy. If the pull request can't be merged, an error wrapping
+// ErrNotMergeable is returned.
+func (c *Client) MergePullRequest(ctx context.Context, repo *Repo, id int64, strategy MergeStrategy) error {
+	opts := struct {
+		MergeStrategy MergeStrategy `json:"merge_strategy"`
+	}{MergeStrategy: strategy}
+
+	err := c.doJSON(ctx, "POST", pullRequestPath(repo, id)+"/merge", &opts, nil)
+	var e *httpError
+	if errors.As(err, &e) && e.StatusCode == http.StatusBadRequest {
+		return errors.Wrap(ErrNotMergeable, e.message())
+	}
+	return err
+}
+
+func pullRequestsPath(repo *Repo) string {
+	return fmt.Sprintf("/2.0/repositories/%s/pullrequests", repo.FullName)
+}
+
+func pullRequestPath(repo *Repo, id int64) string {
+	return fmt.Sprintf("%s/%d", pullRequestsPath(repo), id)
+}
+
+// doJSON sends a request with the given JSON encoded body, if not nil, and
+// decodes the response into result, if not nil.
+func (c *Client) doJSON(ctx context.Context, method, path string, body, result interface{}) error {
+	var data []byte
+	if body != nil {
+		var err error
+		if data, err = json.Marshal(body); err != nil {
+			return err
+		}
+	}
+
+	req, err := http.NewRequest(method, path, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	return c.do(ctx, req, result)
+}
@@ -0,0 +1,176 @@
+package bitbucketcloud
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/cockroachdb/errors"
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/sourcegraph/sourcegraph/internal/extsvc/auth"
+)
+
+var testRepo = &Repo{FullName: "sglocal/mux"}
+
+func newPullRequestTestClient(t *testing.T, h http.HandlerFunc) *Client {
+	t.Helper()
+	srv := httptest.NewServer(h)
+	t.Cleanup(srv.Close)
+	u, err := url.Parse(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cli := NewClient(u, srv.Client())
+	cli.Username = "user"
+	cli.AppPassword = "secret"
+	return cli
+}
+
+func TestClient_CreatePullRequest(t *testing.T) {
+	cli := newPullRequestTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "POST" || r.URL.Path != "/2.0/repositories/sglocal/mux/pullrequests" {
+			http.NotFound(w, r)
+			return
+		}
+		if user, pass, _ := r.BasicAuth(); user != "user" || pass != "secret" {
+			t.Errorf("unexpected credentials %q:%q", user, pass)
+		}
+
+		var body map[string]interface{}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Fatal(err)
+		}
+		want := map[string]interface{}{
+			"title":       "Title",
+			"description": "Body",
+			"source":      map[string]interface{}{"branch": map[string]interface{}{"name": "feature"}},
+			"destination": map[string]interface{}{"branch": map[string]interface{}{"name": "main"}},
+		}
+		if diff := cmp.Diff(want, body); diff != "" {
+			t.Errorf("unexpected body (-want +have):\n%s", diff)
+		}
+
+		w.WriteHeader(http.StatusCreated)
+		fmt.Fprint(w, `{"id":42,"title":"Title","state":"OPEN","source":{"branch":{"name":"feature"}}}`)
+	})
+
+	pr, err := cli.CreatePullRequest(context.Background(), testRepo, &PullRequestInput{
+		Title:             "Title",
+		Description:       "Body",
+		SourceBranch:      "feature",
+		DestinationBranch: "main",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pr.ID != 42 || pr.State != PullRequestStateOpen || pr.Source.Branch.Name != "feature" {
+		t.Fatalf("unexpected pull request: %+v", pr)
+	}
+}
+
+func TestClient_FindOpenPullRequest(t *testing.T) {
+	cli := newPullRequestTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		want := `source.branch.name = "feature" AND destination.branch.name = "main" AND state = "OPEN"`
+		if q := r.URL.Query().Get("q"); q != want {
+			t.Errorf("unexpected query %q", q)
+		}
+		fmt.Fprint(w, `{"values":[{"id":42}]}`)
+	})
+
+	pr, err := cli.FindOpenPullRequest(context.Background(), testRepo, "feature", "main")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pr.ID != 42 {
+		t.Fatalf("unexpected pull request ID %d", pr.ID)
+	}
+}
+
+func TestClient_GetPullRequest_NotFound(t *testing.T) {
+	cli := newPullRequestTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		http.NotFound(w, r)
+	})
+
+	if _, err := cli.GetPullRequest(context.Background(), testRepo, 42); err != ErrPullRequestNotFound {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestClient_PullRequestStatuses(t *testing.T) {
+	var srvURL string
+	cli := newPullRequestTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/2.0/repositories/sglocal/mux/pullrequests/42/statuses" {
+			http.NotFound(w, r)
+			return
+		}
+		switch r.URL.Query().Get("page") {
+		case "":
+			fmt.Fprintf(w, `{"values":[{"key":"build","state":"SUCCESSFUL"}],"next":%q}`, srvURL+r.URL.Path+"?page=2")
+		case "2":
+			fmt.Fprint(w, `{"values":[{"key":"lint","state":"FAILED"}]}`)
+		}
+	})
+	srvURL = cli.URL.String()
+
+	have, err := cli.PullRequestStatuses(context.Background(), testRepo, 42)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []*CommitStatus{
+		{Key: "build", State: CommitStatusStateSuccessful},
+		{Key: "lint", State: CommitStatusStateFailed},
+	}
+	if diff := cmp.Diff(want, have); diff != "" {
+		t.Fatalf("unexpected statuses (-want +have):\n%s", diff)
+	}
+}
+
+func TestClient_MergePullRequest(t *testing.T) {
+	cli := newPullRequestTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "POST" || r.URL.Path != "/2.0/repositories/sglocal/mux/pullrequests/42/merge" {
+			http.NotFound(w, r)
+			return
+		}
+		var opts struct {
+			MergeStrategy MergeStrategy `json:"merge_strategy"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&opts); err != nil {
+			t.Fatal(err)
+		}
+		if opts.MergeStrategy != MergeStrategySquash {
+			t.Errorf("unexpected merge strategy %q", opts.MergeStrategy)
+		}
+		w.WriteHeader(http.StatusBadRequest)
+		fmt.Fprint(w, `{"type":"error","error":{"message":"You can't merge until you resolve all merge conflicts."}}`)
+	})
+
+	err := cli.MergePullRequest(context.Background(), testRepo, 42, MergeStrategySquash)
+	if !errors.Is(err, ErrNotMergeable) {
+		t.Fatalf("expected ErrNotMergeable, got %v", err)
+	}
+	if want := "You can't merge until you resolve all merge conflicts.: Bitbucket Cloud pull request cannot be merged"; err.Error() != want {
+		t.Fatalf("unexpected error message %q", err.Error())
+	}
+}
+
+func TestClient_WithAuthenticator(t *testing.T) {
+	cli := newPullRequestTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if have, want := r.Header.Get("Authorization"), "Bearer token"; have != want {
+			t.Errorf("unexpected authorization header %q", have)
+		}
+		fmt.Fprint(w, `{"nickname":"alice"}`)
+	})
+
+	user, err := cli.WithAuthenticator(&auth.OAuthBearerToken{Token: "token"}).CurrentUser(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if user.Nickname != "alice" {
+		t.Fatalf("unexpected user %+v", user)
+	}
+}
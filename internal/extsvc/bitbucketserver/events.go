@@ -30,6 +30,9 @@ func ParseWebhookEvent(eventType string, payload []byte) (e interface{}, err err
 	case "pr:participant:status":
 		e = &PullRequestParticipantStatusEvent{}
 		return e, json.Unmarshal(payload, e)
+	case "repo:refs_changed":
+		e = &RepoPushEvent{}
+		return e, json.Unmarshal(payload, e)
 	default:
 		return nil, errors.Errorf("unknown webhook event type: %q", eventType)
 	}
@@ -60,6 +63,23 @@ func (a *ParticipantStatusEvent) Key() string {
 	return fmt.Sprintf("%s:%d:%d", a.Action, a.User.ID, a.CreatedDate)
 }
 
+// RepoPushEvent is sent when refs of a repository are changed by a push.
+type RepoPushEvent struct {
+	// The date of the event is omitted, because Bitbucket Server doesn't
+	// format it as RFC 3339.
+	Actor      User        `json:"actor"`
+	Repository Repo        `json:"repository"`
+	Changes    []RefChange `json:"changes"`
+}
+
+// RefChange is a change of a single ref in a RepoPushEvent.
+type RefChange struct {
+	RefID    string `json:"refId"`
+	FromHash string `json:"fromHash"`
+	ToHash   string `json:"toHash"`
+	Type     string `json:"type"` // ADD, DELETE or UPDATE
+}
+
 type BuildStatusEvent struct {
 	Commit       string        `json:"commit"`
 	Status       BuildStatus   `json:"status"`
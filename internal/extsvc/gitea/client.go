@@ -0,0 +1,304 @@
+package gitea
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/cockroachdb/errors"
+	"github.com/opentracing-contrib/go-stdlib/nethttp"
+	"golang.org/x/time/rate"
+
+	"github.com/sourcegraph/sourcegraph/internal/httpcli"
+	"github.com/sourcegraph/sourcegraph/internal/metrics"
+	"github.com/sourcegraph/sourcegraph/internal/ratelimit"
+	"github.com/sourcegraph/sourcegraph/internal/trace/ot"
+)
+
+var requestCounter = metrics.NewRequestMeter("gitea_requests_count", "Total number of requests sent to the Gitea API.")
+
+// PageSize is the number of items requested per page. Gitea caps it with its
+// MAX_RESPONSE_ITEMS setting, which defaults to 50.
+const PageSize = 50
+
+// Client access a Gitea instance via the REST API v1.
+type Client struct {
+	// URL is the base URL of the Gitea instance.
+	URL *url.URL
+
+	httpClient httpcli.Doer
+	token      string
+
+	// sudo is the username of the user the requests are made on behalf of,
+	// which requires a site admin token. See Sudo.
+	sudo string
+
+	rateLimit *rate.Limiter
+}
+
+// NewClient returns a new Gitea API client for the instance at baseURL, which
+// authenticates with the given access token. If a nil httpClient is provided,
+// httpcli.ExternalDoer will be used.
+func NewClient(baseURL *url.URL, token string, httpClient httpcli.Doer) *Client {
+	if httpClient == nil {
+		httpClient = httpcli.ExternalDoer()
+	}
+
+	httpClient = requestCounter.Doer(httpClient, func(u *url.URL) string {
+		// The third component of the Path (/api/v1/<category>/...) mostly
+		// maps to the type of API request we are making.
+		var category string
+		if parts := strings.SplitN(u.Path, "/", 5); len(parts) > 3 {
+			category = parts[3]
+		}
+		return category
+	})
+
+	return &Client{
+		URL:        baseURL,
+		httpClient: httpClient,
+		token:      token,
+		rateLimit:  ratelimit.DefaultRegistry.Get(baseURL.String()),
+	}
+}
+
+// Sudo returns a copy of the client which makes requests on behalf of the user
+// with the given username. The token of the client must belong to a site
+// admin.
+func (c *Client) Sudo(username string) *Client {
+	sc := *c
+	sc.sudo = username
+	return &sc
+}
+
+// AuthenticatedUser returns the user the token of the client belongs to.
+func (c *Client) AuthenticatedUser(ctx context.Context) (*User, error) {
+	var u User
+	if err := c.get(ctx, "user", nil, &u); err != nil {
+		return nil, err
+	}
+	return &u, nil
+}
+
+// GetUser returns the user with the given username.
+func (c *Client) GetUser(ctx context.Context, username string) (*User, error) {
+	var u User
+	if err := c.get(ctx, "users/"+url.PathEscape(username), nil, &u); err != nil {
+		return nil, err
+	}
+	return &u, nil
+}
+
+// GetRepo returns the repository with the given owner and name.
+func (c *Client) GetRepo(ctx context.Context, owner, name string) (*Repository, error) {
+	var r Repository
+	if err := c.get(ctx, "repos/"+url.PathEscape(owner)+"/"+url.PathEscape(name), nil, &r); err != nil {
+		return nil, err
+	}
+	return &r, nil
+}
+
+// GetRepoByID returns the repository with the given ID.
+func (c *Client) GetRepoByID(ctx context.Context, id int64) (*Repository, error) {
+	var r Repository
+	if err := c.get(ctx, "repositories/"+strconv.FormatInt(id, 10), nil, &r); err != nil {
+		return nil, err
+	}
+	return &r, nil
+}
+
+// GetOrg returns the organization with the given name.
+func (c *Client) GetOrg(ctx context.Context, name string) (*Organization, error) {
+	var o Organization
+	if err := c.get(ctx, "orgs/"+url.PathEscape(name), nil, &o); err != nil {
+		return nil, err
+	}
+	return &o, nil
+}
+
+// ListCollaborators returns a page of the users who were given access to the
+// repository with the given owner and name as collaborators, and the number
+// of the next page, which is 0 after the last page. Pages start at 1.
+func (c *Client) ListCollaborators(ctx context.Context, owner, name string, page int) (users []*User, next int, err error) {
+	next, err = c.list(ctx, "repos/"+url.PathEscape(owner)+"/"+url.PathEscape(name)+"/collaborators", nil, page, &users)
+	return users, next, err
+}
+
+// ListRepoTeams returns the teams which can access the repository with the
+// given owner and name. The repository must belong to an organization.
+func (c *Client) ListRepoTeams(ctx context.Context, owner, name string) ([]*Team, error) {
+	var teams []*Team
+	if err := c.get(ctx, "repos/"+url.PathEscape(owner)+"/"+url.PathEscape(name)+"/teams", nil, &teams); err != nil {
+		return nil, err
+	}
+	return teams, nil
+}
+
+// ListTeamMembers returns a page of the members of the team with the given
+// ID, and the number of the next page, which is 0 after the last page. Pages
+// start at 1.
+func (c *Client) ListTeamMembers(ctx context.Context, id int64, page int) (users []*User, next int, err error) {
+	next, err = c.list(ctx, "teams/"+strconv.FormatInt(id, 10)+"/members", nil, page, &users)
+	return users, next, err
+}
+
+// ListOrgRepos returns a page of the repositories of the given organization
+// which the user of the client can access, and the number of the next page,
+// which is 0 after the last page. Pages start at 1.
+func (c *Client) ListOrgRepos(ctx context.Context, org string, page int) (repos []*Repository, next int, err error) {
+	next, err = c.list(ctx, "orgs/"+url.PathEscape(org)+"/repos", nil, page, &repos)
+	return repos, next, err
+}
+
+// ListUserRepos returns a page of the repositories the user of the client
+// owns, collaborates on or can access as a member of an organization, and the
+// number of the next page, which is 0 after the last page. Pages start at 1.
+func (c *Client) ListUserRepos(ctx context.Context, page int) (repos []*Repository, next int, err error) {
+	next, err = c.list(ctx, "user/repos", nil, page, &repos)
+	return repos, next, err
+}
+
+// SearchRepos returns a page of the repositories visible to the user of the
+// client whose name or description match the given keyword, and the number of
+// the next page, which is 0 after the last page. An empty keyword matches all
+// repositories. Pages start at 1.
+func (c *Client) SearchRepos(ctx context.Context, keyword string, page int) (repos []*Repository, next int, err error) {
+	qry := url.Values{}
+	if keyword != "" {
+		qry.Set("q", keyword)
+	}
+	result := struct {
+		OK   bool          `json:"ok"`
+		Data []*Repository `json:"data"`
+	}{}
+	next, err = c.list(ctx, "repos/search", qry, page, &result)
+	if err == nil && !result.OK {
+		err = errors.Errorf("Gitea repository search for %q failed", keyword)
+	}
+	return result.Data, next, err
+}
+
+// list requests the given page of a paginated API endpoint and returns the
+// number of the next page, as found in the Link response header.
+func (c *Client) list(ctx context.Context, path string, qry url.Values, page int, result interface{}) (next int, err error) {
+	if qry == nil {
+		qry = url.Values{}
+	}
+	if page < 1 {
+		page = 1
+	}
+	qry.Set("page", strconv.Itoa(page))
+	qry.Set("limit", strconv.Itoa(PageSize))
+
+	header, err := c.do(ctx, path, qry, result)
+	if err != nil {
+		return 0, err
+	}
+	return nextPage(header.Get("Link")), nil
+}
+
+func (c *Client) get(ctx context.Context, path string, qry url.Values, result interface{}) error {
+	_, err := c.do(ctx, path, qry, result)
+	return err
+}
+
+func (c *Client) do(ctx context.Context, path string, qry url.Values, result interface{}) (http.Header, error) {
+	u := c.URL.ResolveReference(&url.URL{Path: "api/v1/" + path, RawQuery: qry.Encode()})
+	req, err := http.NewRequest("GET", u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("Authorization", "token "+c.token)
+	if c.sudo != "" {
+		req.Header.Set("Sudo", c.sudo)
+	}
+
+	req, ht := nethttp.TraceRequest(ot.GetTracer(ctx),
+		req.WithContext(ctx),
+		nethttp.OperationName("Gitea"),
+		nethttp.ClientTrace(false))
+	defer ht.Finish()
+
+	if err := c.rateLimit.Wait(ctx); err != nil {
+		return nil, err
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	bs, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 400 {
+		return nil, errors.WithStack(&httpError{
+			URL:        req.URL,
+			StatusCode: resp.StatusCode,
+			Body:       bs,
+		})
+	}
+
+	return resp.Header, json.Unmarshal(bs, result)
+}
+
+// nextPage returns the page number of the rel="next" link of the given Link
+// header, or 0 if there is none.
+func nextPage(link string) int {
+	for _, l := range strings.Split(link, ",") {
+		parts := strings.Split(l, ";")
+		if len(parts) < 2 {
+			continue
+		}
+		isNext := false
+		for _, p := range parts[1:] {
+			if strings.TrimSpace(p) == `rel="next"` {
+				isNext = true
+			}
+		}
+		if !isNext {
+			continue
+		}
+		u, err := url.Parse(strings.Trim(strings.TrimSpace(parts[0]), "<>"))
+		if err != nil {
+			return 0
+		}
+		page, _ := strconv.Atoi(u.Query().Get("page"))
+		return page
+	}
+	return 0
+}
+
+type httpError struct {
+	StatusCode int
+	URL        *url.URL
+	Body       []byte
+}
+
+func (e *httpError) Error() string {
+	// The query never contains credentials, they are sent in headers.
+	return fmt.Sprintf("Gitea API HTTP error: code=%d url=%q body=%q", e.StatusCode, e.URL, e.Body)
+}
+
+func (e *httpError) Unauthorized() bool {
+	return e.StatusCode == http.StatusUnauthorized
+}
+
+func (e *httpError) NotFound() bool {
+	return e.StatusCode == http.StatusNotFound
+}
+
+// IsNotFound reports whether err is a Gitea API error with a 404 status.
+func IsNotFound(err error) bool {
+	var e *httpError
+	return errors.As(err, &e) && e.NotFound()
+}
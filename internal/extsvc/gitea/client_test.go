@@ -0,0 +1,121 @@
+package gitea
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func newTestClient(t *testing.T, h http.HandlerFunc) *Client {
+	t.Helper()
+	srv := httptest.NewServer(h)
+	t.Cleanup(srv.Close)
+	u, err := url.Parse(srv.URL + "/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return NewClient(u, "secret", srv.Client())
+}
+
+func TestClient_ListOrgRepos(t *testing.T) {
+	cli := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if have, want := r.Header.Get("Authorization"), "token secret"; have != want {
+			t.Errorf("Authorization: have %q, want %q", have, want)
+		}
+		if r.URL.Path != "/api/v1/orgs/myorg/repos" {
+			http.NotFound(w, r)
+			return
+		}
+		switch page := r.URL.Query().Get("page"); page {
+		case "1":
+			w.Header().Set("Link", fmt.Sprintf(`<http://%[1]s/api/v1/orgs/myorg/repos?limit=50&page=2>; rel="next",<http://%[1]s/api/v1/orgs/myorg/repos?limit=50&page=2>; rel="last"`, r.Host))
+			fmt.Fprint(w, `[{"id":1,"full_name":"myorg/a"}]`)
+		case "2":
+			w.Header().Set("Link", fmt.Sprintf(`<http://%[1]s/api/v1/orgs/myorg/repos?limit=50&page=1>; rel="first",<http://%[1]s/api/v1/orgs/myorg/repos?limit=50&page=1>; rel="prev"`, r.Host))
+			fmt.Fprint(w, `[{"id":2,"full_name":"myorg/b","private":true}]`)
+		default:
+			t.Errorf("unexpected page %q", page)
+		}
+	})
+
+	ctx := context.Background()
+	var have []*Repository
+	for page := 1; page != 0; {
+		repos, next, err := cli.ListOrgRepos(ctx, "myorg", page)
+		if err != nil {
+			t.Fatal(err)
+		}
+		have = append(have, repos...)
+		page = next
+	}
+
+	want := []*Repository{
+		{ID: 1, FullName: "myorg/a"},
+		{ID: 2, FullName: "myorg/b", Private: true},
+	}
+	if diff := cmp.Diff(want, have); diff != "" {
+		t.Fatalf("unexpected repos (-want +have):\n%s", diff)
+	}
+}
+
+func TestClient_SearchRepos(t *testing.T) {
+	cli := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/repos/search" || r.URL.Query().Get("q") != "foo" {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprint(w, `{"ok":true,"data":[{"id":3,"full_name":"alice/foo"}]}`)
+	})
+
+	repos, next, err := cli.SearchRepos(context.Background(), "foo", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if next != 0 {
+		t.Errorf("expected no next page, got %d", next)
+	}
+	if diff := cmp.Diff([]*Repository{{ID: 3, FullName: "alice/foo"}}, repos); diff != "" {
+		t.Fatalf("unexpected repos (-want +have):\n%s", diff)
+	}
+}
+
+func TestClient_Sudo(t *testing.T) {
+	cli := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v1/user":
+			login := r.Header.Get("Sudo")
+			if login == "" {
+				login = "admin"
+			}
+			fmt.Fprintf(w, `{"id":1,"login":%q}`, login)
+		default:
+			http.NotFound(w, r)
+		}
+	})
+
+	ctx := context.Background()
+	for _, tc := range []struct {
+		cli  *Client
+		want string
+	}{
+		{cli: cli, want: "admin"},
+		{cli: cli.Sudo("alice"), want: "alice"},
+	} {
+		u, err := tc.cli.AuthenticatedUser(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if u.Login != tc.want {
+			t.Errorf("have user %q, want %q", u.Login, tc.want)
+		}
+	}
+
+	if _, err := cli.GetRepo(ctx, "alice", "missing"); !IsNotFound(err) {
+		t.Errorf("expected a not found error, got %v", err)
+	}
+}
@@ -0,0 +1,3 @@
+// Package gitea implements a client for the API of Gitea and of its fork
+// Forgejo.
+package gitea
@@ -0,0 +1,67 @@
+package gitea
+
+import (
+	"net/url"
+	"strconv"
+
+	"github.com/sourcegraph/sourcegraph/internal/api"
+	"github.com/sourcegraph/sourcegraph/internal/extsvc"
+)
+
+// Repository is a Gitea repository, as returned by the API. It is stored as
+// the metadata of Sourcegraph repositories.
+type Repository struct {
+	ID          int64       `json:"id"`
+	Owner       *User       `json:"owner"`
+	Name        string      `json:"name"`
+	FullName    string      `json:"full_name"`
+	Description string      `json:"description"`
+	Private     bool        `json:"private"`
+	Fork        bool        `json:"fork"`
+	Mirror      bool        `json:"mirror"`
+	Archived    bool        `json:"archived"`
+	Empty       bool        `json:"empty"`
+	Stars       int         `json:"stars_count"`
+	HTMLURL     string      `json:"html_url"`
+	CloneURL    string      `json:"clone_url"`
+	SSHURL      string      `json:"ssh_url"`
+	Permissions Permissions `json:"permissions"`
+}
+
+// Permissions are the permissions of the user of a client on a repository.
+type Permissions struct {
+	Admin bool `json:"admin"`
+	Push  bool `json:"push"`
+	Pull  bool `json:"pull"`
+}
+
+// User is a Gitea user or organization.
+type User struct {
+	ID       int64  `json:"id"`
+	Login    string `json:"login"`
+	FullName string `json:"full_name"`
+	Email    string `json:"email"`
+	IsAdmin  bool   `json:"is_admin"`
+}
+
+// Organization is a Gitea organization.
+type Organization struct {
+	ID       int64  `json:"id"`
+	UserName string `json:"username"`
+}
+
+// Team is a team of a Gitea organization.
+type Team struct {
+	ID         int64  `json:"id"`
+	Name       string `json:"name"`
+	Permission string `json:"permission"`
+}
+
+// ExternalRepoSpec returns an api.ExternalRepoSpec that refers to the specified Gitea repository.
+func ExternalRepoSpec(repo *Repository, baseURL url.URL) api.ExternalRepoSpec {
+	return api.ExternalRepoSpec{
+		ID:          strconv.FormatInt(repo.ID, 10),
+		ServiceType: extsvc.TypeGitea,
+		ServiceID:   extsvc.NormalizeBaseURL(&baseURL).String(),
+	}
+}
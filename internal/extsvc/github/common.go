@@ -161,6 +161,7 @@ type PullRequest struct {
 	BaseRefOid    string
 	HeadRefName   string
 	BaseRefName   string
+	Mergeable     string
 	Number        int64
 	Author        Actor
 	Participants  []Actor
@@ -1382,6 +1383,7 @@ fragment pr on PullRequest {
   baseRefOid
   headRefName
   baseRefName
+  mergeable
   %s
   author {
     ...actor
@@ -25,6 +25,32 @@ type PipelineEvent struct {
 	MergeRequest *gitlab.MergeRequest `json:"merge_request"`
 }
 
+// PushEvent is sent when commits or tags are pushed to a project.
+type PushEvent struct {
+	EventCommon
+
+	Ref          string `json:"ref"`
+	Before       string `json:"before"`
+	After        string `json:"after"`
+	UserUsername string `json:"user_username"`
+}
+
+// NoteEvent is sent when a comment is added to a commit, merge request, issue
+// or code snippet. MergeRequest is only set for comments on merge requests.
+type NoteEvent struct {
+	EventCommon
+
+	User         gitlab.User          `json:"user"`
+	Note         Note                 `json:"object_attributes"`
+	MergeRequest *gitlab.MergeRequest `json:"merge_request"`
+}
+
+type Note struct {
+	ID           gitlab.ID `json:"id"`
+	Body         string    `json:"note"`
+	NoteableType string    `json:"noteable_type"`
+}
+
 var ErrObjectKindUnknown = errors.New("unknown object kind")
 
 type downcaster interface {
@@ -32,7 +58,7 @@ type downcaster interface {
 }
 
 // UnmarshalEvent unmarshals the given JSON into an event type. Possible return
-// types are *MergeRequestEvent.
+// types are *MergeRequestEvent, *NoteEvent, *PipelineEvent and *PushEvent.
 //
 // Errors caused by a valid payload being of an unknown type may be
 // distinguished from other errors by checking for ErrObjectKindUnknown in the
@@ -58,8 +84,12 @@ func UnmarshalEvent(data []byte) (interface{}, error) {
 	switch event.ObjectKind {
 	case "merge_request":
 		typedEvent = &mergeRequestEvent{}
+	case "note":
+		typedEvent = &NoteEvent{}
 	case "pipeline":
 		typedEvent = &PipelineEvent{}
+	case "push", "tag_push":
+		typedEvent = &PushEvent{}
 	default:
 		return nil, errors.Wrapf(ErrObjectKindUnknown, "kind: %s", event.ObjectKind)
 	}
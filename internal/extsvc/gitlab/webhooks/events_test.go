@@ -110,4 +110,69 @@ func TestUnmarshalEvent(t *testing.T) {
 			t.Errorf("unexpected IID: have %d; want %d", pe.Pipeline.ID, want)
 		}
 	})
+
+	t.Run("valid note", func(t *testing.T) {
+		event, err := UnmarshalEvent([]byte(`
+			{
+				"object_kind": "note",
+				"user": {
+					"id": 7,
+					"username": "alice"
+				},
+				"object_attributes": {
+					"id": 1244,
+					"note": "/sourcegraph rebase",
+					"noteable_type": "MergeRequest"
+				},
+				"merge_request": {
+					"iid": 42
+				}
+			}
+		`))
+		if event == nil {
+			t.Error("unexpected nil event")
+		}
+		if err != nil {
+			t.Errorf("unexpected error: %+v", err)
+		}
+
+		ne := event.(*NoteEvent)
+		if want := "/sourcegraph rebase"; ne.Note.Body != want {
+			t.Errorf("unexpected note: have %q; want %q", ne.Note.Body, want)
+		}
+		if want := int32(7); ne.User.ID != want {
+			t.Errorf("unexpected user ID: have %d; want %d", ne.User.ID, want)
+		}
+		if want := gitlab.ID(42); ne.MergeRequest == nil || ne.MergeRequest.IID != want {
+			t.Errorf("unexpected merge request: %+v", ne.MergeRequest)
+		}
+	})
+
+	t.Run("valid push", func(t *testing.T) {
+		for _, kind := range []string{"push", "tag_push"} {
+			event, err := UnmarshalEvent([]byte(`
+				{
+					"object_kind": "` + kind + `",
+					"ref": "refs/heads/main",
+					"project": {
+						"id": 42
+					}
+				}
+			`))
+			if event == nil {
+				t.Error("unexpected nil event")
+			}
+			if err != nil {
+				t.Errorf("unexpected error: %+v", err)
+			}
+
+			pe := event.(*PushEvent)
+			if want := 42; pe.Project.ID != want {
+				t.Errorf("unexpected project ID: have %d; want %d", pe.Project.ID, want)
+			}
+			if want := "refs/heads/main"; pe.Ref != want {
+				t.Errorf("unexpected ref: have %q; want %q", pe.Ref, want)
+			}
+		}
+	})
 }
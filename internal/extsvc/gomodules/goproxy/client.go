@@ -0,0 +1,152 @@
+package goproxy
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/cockroachdb/errors"
+	"golang.org/x/mod/module"
+
+	"github.com/sourcegraph/sourcegraph/internal/conf/reposource"
+	"github.com/sourcegraph/sourcegraph/internal/httpcli"
+	"github.com/sourcegraph/sourcegraph/internal/ratelimit"
+	"github.com/sourcegraph/sourcegraph/schema"
+)
+
+// Client talks to Go module proxies, see
+// https://golang.org/ref/mod#goproxy-protocol.
+type Client struct {
+	urls       []string
+	httpClient httpcli.Doer
+}
+
+// NewClient returns a client for the proxies of the given connection. If a nil
+// httpClient is provided, httpcli.ExternalDoer() will be used.
+func NewClient(config *schema.GoModulesConnection, httpClient httpcli.Doer) *Client {
+	if httpClient == nil {
+		httpClient = httpcli.ExternalDoer()
+	}
+	urls := make([]string, 0, len(config.Urls))
+	for _, u := range config.Urls {
+		urls = append(urls, strings.TrimSuffix(u, "/"))
+	}
+	return &Client{urls: urls, httpClient: httpClient}
+}
+
+// ListVersions returns the released versions of mod, sorted by semantic
+// version in descending order. Pseudo-versions are not listed.
+func (c *Client) ListVersions(ctx context.Context, mod reposource.GoModule) ([]reposource.GoDependency, error) {
+	escapedPath, err := module.EscapePath(mod.Path)
+	if err != nil {
+		return nil, err
+	}
+	body, err := c.getPath(ctx, escapedPath+"/@v/list")
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+
+	list, err := io.ReadAll(body)
+	if err != nil {
+		return nil, err
+	}
+
+	var dependencies []reposource.GoDependency
+	for _, version := range strings.Fields(string(list)) {
+		dependency, err := reposource.NewGoDependency(mod, version)
+		if err != nil {
+			return nil, err
+		}
+		dependencies = append(dependencies, dependency)
+	}
+	reposource.SortGoDependencies(dependencies)
+	return dependencies, nil
+}
+
+// Exists reports whether the given version of a module is available.
+func (c *Client) Exists(ctx context.Context, dependency reposource.GoDependency) bool {
+	body, err := c.get(ctx, dependency, ".info")
+	if err != nil {
+		return false
+	}
+	body.Close()
+	return true
+}
+
+// FetchZip returns the zip archive of the given version of a module. The
+// caller must close it.
+func (c *Client) FetchZip(ctx context.Context, dependency reposource.GoDependency) (io.ReadCloser, error) {
+	return c.get(ctx, dependency, ".zip")
+}
+
+func (c *Client) get(ctx context.Context, dependency reposource.GoDependency, suffix string) (io.ReadCloser, error) {
+	escapedPath, err := module.EscapePath(dependency.Path)
+	if err != nil {
+		return nil, err
+	}
+	escapedVersion, err := module.EscapeVersion(dependency.Version)
+	if err != nil {
+		return nil, err
+	}
+	return c.getPath(ctx, escapedPath+"/@v/"+escapedVersion+suffix)
+}
+
+// getPath requests path from each proxy in turn, moving on to the next one if
+// a proxy doesn't have it, like the go command does.
+func (c *Client) getPath(ctx context.Context, path string) (body io.ReadCloser, err error) {
+	if len(c.urls) == 0 {
+		return nil, errors.New("no Go module proxy URLs configured")
+	}
+	for _, baseURL := range c.urls {
+		body, err = c.getURL(ctx, baseURL, baseURL+"/"+path)
+		if e, ok := err.(*HTTPError); ok && e.NotFound() {
+			continue
+		}
+		return body, err
+	}
+	return nil, err
+}
+
+func (c *Client) getURL(ctx context.Context, baseURL, u string) (io.ReadCloser, error) {
+	if err := ratelimit.DefaultRegistry.Get(baseURL).Wait(ctx); err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return nil, &HTTPError{Path: req.URL.Path, StatusCode: resp.StatusCode, Body: body}
+	}
+	return resp.Body, nil
+}
+
+// HTTPError is returned when a proxy responds with an unexpected status.
+type HTTPError struct {
+	// Path is the path of the request URL. We don't keep the full URL, which
+	// may contain credentials.
+	Path       string
+	StatusCode int
+	Body       []byte
+}
+
+func (e *HTTPError) Error() string {
+	return fmt.Sprintf("Go module proxy: unexpected status %d for %s: %s", e.StatusCode, e.Path, e.Body)
+}
+
+// NotFound reports whether the module or version does not exist. Proxies
+// respond with 410 Gone for modules they refuse to serve.
+func (e *HTTPError) NotFound() bool {
+	return e.StatusCode == http.StatusNotFound || e.StatusCode == http.StatusGone
+}
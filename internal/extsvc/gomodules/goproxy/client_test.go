@@ -0,0 +1,71 @@
+package goproxy
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/sourcegraph/sourcegraph/internal/conf/reposource"
+	"github.com/sourcegraph/sourcegraph/schema"
+)
+
+func TestClient(t *testing.T) {
+	// The first proxy doesn't have anything, so every request falls back to
+	// the second one.
+	empty := httptest.NewServer(http.NotFoundHandler())
+	defer empty.Close()
+
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/github.com/!azure/go-autorest/@v/list":
+			io.WriteString(w, "v14.1.0+incompatible\nv14.2.0+incompatible\n")
+		case "/github.com/!azure/go-autorest/@v/v14.2.0+incompatible.info":
+			io.WriteString(w, `{"Version":"v14.2.0+incompatible"}`)
+		case "/github.com/!azure/go-autorest/@v/v14.2.0+incompatible.zip":
+			io.WriteString(w, "zip")
+		default:
+			http.Error(w, "gone", http.StatusGone)
+		}
+	}))
+	defer proxy.Close()
+
+	ctx := context.Background()
+	cli := NewClient(&schema.GoModulesConnection{Urls: []string{empty.URL, proxy.URL + "/"}}, http.DefaultClient)
+
+	module, err := reposource.ParseGoModule("github.com/Azure/go-autorest")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	versions, err := cli.ListVersions(ctx, module)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, v := range versions {
+		got = append(got, v.Version)
+	}
+	if diff := cmp.Diff([]string{"v14.2.0+incompatible", "v14.1.0+incompatible"}, got); diff != "" {
+		t.Fatalf("unexpected versions (-want +got):\n%s", diff)
+	}
+
+	if !cli.Exists(ctx, versions[0]) {
+		t.Fatalf("expected %s to exist", versions[0].PackageManagerSyntax())
+	}
+	if cli.Exists(ctx, versions[1]) {
+		t.Fatalf("expected %s not to exist", versions[1].PackageManagerSyntax())
+	}
+
+	zip, err := cli.FetchZip(ctx, versions[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer zip.Close()
+	if b, err := io.ReadAll(zip); err != nil || string(b) != "zip" {
+		t.Fatalf("unexpected zip %q, err=%v", b, err)
+	}
+}
@@ -0,0 +1,7 @@
+package gomodules
+
+import "github.com/sourcegraph/sourcegraph/internal/conf/reposource"
+
+type Metadata struct {
+	Module reposource.GoModule
+}
@@ -0,0 +1,8 @@
+package mercurial
+
+// Repo contains information of a Mercurial repository.
+type Repo struct {
+	// Path is the path of the repository relative to the URL of the
+	// connection.
+	Path string `json:"path"`
+}
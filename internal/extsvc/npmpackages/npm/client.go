@@ -0,0 +1,132 @@
+package npm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/cockroachdb/errors"
+	"golang.org/x/time/rate"
+
+	"github.com/sourcegraph/sourcegraph/internal/conf/reposource"
+	"github.com/sourcegraph/sourcegraph/internal/httpcli"
+	"github.com/sourcegraph/sourcegraph/internal/ratelimit"
+	"github.com/sourcegraph/sourcegraph/schema"
+)
+
+// Client talks to an npm registry, see
+// https://github.com/npm/registry/blob/master/docs/REGISTRY-API.md.
+type Client struct {
+	registryURL string
+	credentials string
+	httpClient  httpcli.Doer
+	limiter     *rate.Limiter
+}
+
+// NewClient returns a client for the registry of the given connection. If a
+// nil httpClient is provided, httpcli.ExternalDoer() will be used.
+func NewClient(config *schema.NPMPackagesConnection, httpClient httpcli.Doer) *Client {
+	if httpClient == nil {
+		httpClient = httpcli.ExternalDoer()
+	}
+	return &Client{
+		registryURL: strings.TrimSuffix(config.Registry, "/"),
+		credentials: config.Credentials,
+		httpClient:  httpClient,
+		limiter:     ratelimit.DefaultRegistry.Get(config.Registry),
+	}
+}
+
+// PackageVersion is the metadata the registry returns for a version of a
+// package. We only decode the fields we use.
+type PackageVersion struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+	Dist    struct {
+		Tarball string `json:"tarball"`
+	} `json:"dist"`
+}
+
+// Version returns the metadata of the given version of a package.
+func (c *Client) Version(ctx context.Context, dependency reposource.NPMDependency) (*PackageVersion, error) {
+	// Scoped package names must be escaped as a single path segment, e.g.
+	// "@types%2Fnode".
+	u := fmt.Sprintf("%s/%s/%s", c.registryURL, url.PathEscape(dependency.PackageSyntax()), url.PathEscape(dependency.Version))
+	body, err := c.get(ctx, u)
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+
+	var v PackageVersion
+	if err := json.NewDecoder(body).Decode(&v); err != nil {
+		return nil, errors.Wrapf(err, "decoding metadata of npm dependency %s", dependency.PackageManagerSyntax())
+	}
+	if v.Dist.Tarball == "" {
+		return nil, errors.Errorf("no tarball for npm dependency %s", dependency.PackageManagerSyntax())
+	}
+	return &v, nil
+}
+
+// Exists reports whether the given version of a package is published.
+func (c *Client) Exists(ctx context.Context, dependency reposource.NPMDependency) bool {
+	_, err := c.Version(ctx, dependency)
+	return err == nil
+}
+
+// FetchTarball returns the gzipped tarball of the given version of a package.
+// The caller must close it.
+func (c *Client) FetchTarball(ctx context.Context, dependency reposource.NPMDependency) (io.ReadCloser, error) {
+	v, err := c.Version(ctx, dependency)
+	if err != nil {
+		return nil, err
+	}
+	return c.get(ctx, v.Dist.Tarball)
+}
+
+func (c *Client) get(ctx context.Context, u string) (io.ReadCloser, error) {
+	if err := c.limiter.Wait(ctx); err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	// Tarballs may be served from another host, which must not see our
+	// credentials.
+	if c.credentials != "" && strings.HasPrefix(u, c.registryURL+"/") {
+		req.Header.Set("Authorization", "Bearer "+c.credentials)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return nil, &HTTPError{URL: u, StatusCode: resp.StatusCode, Body: body}
+	}
+	return resp.Body, nil
+}
+
+// HTTPError is returned when the registry responds with an unexpected status.
+type HTTPError struct {
+	URL        string
+	StatusCode int
+	Body       []byte
+}
+
+func (e *HTTPError) Error() string {
+	return fmt.Sprintf("npm registry: unexpected status %d from %s: %s", e.StatusCode, e.URL, e.Body)
+}
+
+// NotFound reports whether the package or version does not exist.
+func (e *HTTPError) NotFound() bool {
+	return e.StatusCode == http.StatusNotFound
+}
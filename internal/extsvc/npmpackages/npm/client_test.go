@@ -0,0 +1,57 @@
+package npm
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/sourcegraph/sourcegraph/internal/conf/reposource"
+	"github.com/sourcegraph/sourcegraph/internal/errcode"
+	"github.com/sourcegraph/sourcegraph/schema"
+)
+
+func TestClient(t *testing.T) {
+	var registryURL string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("Authorization"); got != "Bearer secret" {
+			t.Errorf("unexpected Authorization header %q", got)
+		}
+		switch r.URL.EscapedPath() {
+		case "/@types%2Fnode/16.4.0":
+			io.WriteString(w, `{"name":"@types/node","version":"16.4.0","dist":{"tarball":"`+registryURL+`/@types/node/-/node-16.4.0.tgz"}}`)
+		case "/@types/node/-/node-16.4.0.tgz":
+			io.WriteString(w, "tarball")
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer ts.Close()
+	registryURL = ts.URL
+
+	ctx := context.Background()
+	cli := NewClient(&schema.NPMPackagesConnection{Registry: ts.URL + "/", Credentials: "secret"}, http.DefaultClient)
+
+	dependency, err := reposource.ParseNPMDependency("@types/node@16.4.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !cli.Exists(ctx, dependency) {
+		t.Fatalf("expected %s to exist", dependency.PackageManagerSyntax())
+	}
+
+	tarball, err := cli.FetchTarball(ctx, dependency)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tarball.Close()
+	if got, err := io.ReadAll(tarball); err != nil || string(got) != "tarball" {
+		t.Fatalf("unexpected tarball %q, err=%v", got, err)
+	}
+
+	dependency.Version = "0.0.0"
+	if _, err := cli.Version(ctx, dependency); !errcode.IsNotFound(err) {
+		t.Fatalf("expected a not found error, got %v", err)
+	}
+}
@@ -75,14 +75,19 @@ const (
 	// in preference to the Type values below.
 
 	KindAWSCodeCommit   = "AWSCODECOMMIT"
+	KindAzureDevOps     = "AZUREDEVOPS"
 	KindBitbucketServer = "BITBUCKETSERVER"
 	KindBitbucketCloud  = "BITBUCKETCLOUD"
 	KindGitHub          = "GITHUB"
 	KindGitLab          = "GITLAB"
+	KindGitea           = "GITEA"
 	KindGitolite        = "GITOLITE"
+	KindMercurial       = "MERCURIAL"
 	KindPerforce        = "PERFORCE"
 	KindPhabricator     = "PHABRICATOR"
 	KindJVMPackages     = "JVMPACKAGES"
+	KindNPMPackages     = "NPMPACKAGES"
+	KindGoModules       = "GOMODULES"
 	KindOther           = "OTHER"
 )
 
@@ -94,6 +99,11 @@ const (
 	// suffix (e.g., "arn:aws:codecommit:us-west-1:123456789:").
 	TypeAWSCodeCommit = "awscodecommit"
 
+	// TypeAzureDevOps is the (api.ExternalRepoSpec).ServiceType value for Azure DevOps repositories.
+	// The ServiceID value is the base URL to Azure DevOps (https://dev.azure.com or the Azure
+	// DevOps Server URL).
+	TypeAzureDevOps = "azuredevops"
+
 	// TypeBitbucketServer is the (api.ExternalRepoSpec).ServiceType value for Bitbucket Server projects. The
 	// ServiceID value is the base URL to the Bitbucket Server instance.
 	TypeBitbucketServer = "bitbucketServer"
@@ -110,9 +120,17 @@ const (
 	// value is the base URL to the GitLab instance (https://gitlab.com or self-hosted GitLab URL).
 	TypeGitLab = "gitlab"
 
+	// TypeGitea is the (api.ExternalRepoSpec).ServiceType value for Gitea repositories. The ServiceID
+	// value is the base URL to the Gitea instance.
+	TypeGitea = "gitea"
+
 	// TypeGitolite is the (api.ExternalRepoSpec).ServiceType value for Gitolite projects.
 	TypeGitolite = "gitolite"
 
+	// TypeMercurial is the (api.ExternalRepoSpec).ServiceType value for Mercurial repositories. The
+	// ServiceID value is the base URL of the Mercurial repositories.
+	TypeMercurial = "mercurial"
+
 	// TypePerforce is the (api.ExternalRepoSpec).ServiceType value for Perforce projects.
 	TypePerforce = "perforce"
 
@@ -122,6 +140,12 @@ const (
 	// TypeJVMPackages is the (api.ExternalRepoSpec).ServiceType value for Maven packages (Java/JVM ecosystem libraries).
 	TypeJVMPackages = "jvmPackages"
 
+	// TypeNPMPackages is the (api.ExternalRepoSpec).ServiceType value for npm packages (JavaScript/TypeScript ecosystem libraries).
+	TypeNPMPackages = "npmPackages"
+
+	// TypeGoModules is the (api.ExternalRepoSpec).ServiceType value for Go modules.
+	TypeGoModules = "goModules"
+
 	// TypeOther is the (api.ExternalRepoSpec).ServiceType value for other projects.
 	TypeOther = "other"
 
@@ -134,6 +158,8 @@ func KindToType(kind string) string {
 	switch kind {
 	case KindAWSCodeCommit:
 		return TypeAWSCodeCommit
+	case KindAzureDevOps:
+		return TypeAzureDevOps
 	case KindBitbucketServer:
 		return TypeBitbucketServer
 	case KindBitbucketCloud:
@@ -142,14 +168,22 @@ func KindToType(kind string) string {
 		return TypeGitHub
 	case KindGitLab:
 		return TypeGitLab
+	case KindGitea:
+		return TypeGitea
 	case KindGitolite:
 		return TypeGitolite
 	case KindPhabricator:
 		return TypePhabricator
+	case KindMercurial:
+		return TypeMercurial
 	case KindPerforce:
 		return TypePerforce
 	case KindJVMPackages:
 		return TypeJVMPackages
+	case KindNPMPackages:
+		return TypeNPMPackages
+	case KindGoModules:
+		return TypeGoModules
 	case KindOther:
 		return TypeOther
 	default:
@@ -163,6 +197,8 @@ func TypeToKind(t string) string {
 	switch t {
 	case TypeAWSCodeCommit:
 		return KindAWSCodeCommit
+	case TypeAzureDevOps:
+		return KindAzureDevOps
 	case TypeBitbucketServer:
 		return KindBitbucketServer
 	case TypeBitbucketCloud:
@@ -171,14 +207,22 @@ func TypeToKind(t string) string {
 		return KindGitHub
 	case TypeGitLab:
 		return KindGitLab
+	case TypeGitea:
+		return KindGitea
 	case TypeGitolite:
 		return KindGitolite
+	case TypeMercurial:
+		return KindMercurial
 	case TypePerforce:
 		return KindPerforce
 	case TypePhabricator:
 		return KindPhabricator
 	case TypeJVMPackages:
 		return KindJVMPackages
+	case TypeNPMPackages:
+		return KindNPMPackages
+	case TypeGoModules:
+		return KindGoModules
 	case TypeOther:
 		return KindOther
 	default:
@@ -191,6 +235,8 @@ var (
 	bbsLower = strings.ToLower(TypeBitbucketServer)
 	bbcLower = strings.ToLower(TypeBitbucketCloud)
 	jvmLower = strings.ToLower(TypeJVMPackages)
+	npmLower = strings.ToLower(TypeNPMPackages)
+	goLower  = strings.ToLower(TypeGoModules)
 )
 
 // ParseServiceType will return a ServiceType constant after doing a case insensitive match on s.
@@ -199,6 +245,8 @@ func ParseServiceType(s string) (string, bool) {
 	switch strings.ToLower(s) {
 	case TypeAWSCodeCommit:
 		return TypeAWSCodeCommit, true
+	case TypeAzureDevOps:
+		return TypeAzureDevOps, true
 	case bbsLower:
 		return TypeBitbucketServer, true
 	case bbcLower:
@@ -207,14 +255,22 @@ func ParseServiceType(s string) (string, bool) {
 		return TypeGitHub, true
 	case TypeGitLab:
 		return TypeGitLab, true
+	case TypeGitea:
+		return TypeGitea, true
 	case TypeGitolite:
 		return TypeGitolite, true
+	case TypeMercurial:
+		return TypeMercurial, true
 	case TypePerforce:
 		return TypePerforce, true
 	case TypePhabricator:
 		return TypePhabricator, true
 	case jvmLower:
 		return TypeJVMPackages, true
+	case npmLower:
+		return TypeNPMPackages, true
+	case goLower:
+		return TypeGoModules, true
 	case TypeOther:
 		return TypeOther, true
 	default:
@@ -228,6 +284,8 @@ func ParseServiceKind(s string) (string, bool) {
 	switch strings.ToUpper(s) {
 	case KindAWSCodeCommit:
 		return KindAWSCodeCommit, true
+	case KindAzureDevOps:
+		return KindAzureDevOps, true
 	case KindBitbucketServer:
 		return KindBitbucketServer, true
 	case KindBitbucketCloud:
@@ -236,14 +294,22 @@ func ParseServiceKind(s string) (string, bool) {
 		return KindGitHub, true
 	case KindGitLab:
 		return KindGitLab, true
+	case KindGitea:
+		return KindGitea, true
 	case KindGitolite:
 		return KindGitolite, true
+	case KindMercurial:
+		return KindMercurial, true
 	case KindPerforce:
 		return KindPerforce, true
 	case KindPhabricator:
 		return KindPhabricator, true
 	case KindJVMPackages:
 		return KindJVMPackages, true
+	case KindNPMPackages:
+		return KindNPMPackages, true
+	case KindGoModules:
+		return KindGoModules, true
 	case KindOther:
 		return KindOther, true
 	default:
@@ -269,6 +335,8 @@ func ParseConfig(kind, config string) (cfg interface{}, _ error) {
 	switch strings.ToUpper(kind) {
 	case KindAWSCodeCommit:
 		cfg = &schema.AWSCodeCommitConnection{}
+	case KindAzureDevOps:
+		cfg = &schema.AzureDevOpsConnection{}
 	case KindBitbucketServer:
 		cfg = &schema.BitbucketServerConnection{}
 	case KindBitbucketCloud:
@@ -277,14 +345,22 @@ func ParseConfig(kind, config string) (cfg interface{}, _ error) {
 		cfg = &schema.GitHubConnection{}
 	case KindGitLab:
 		cfg = &schema.GitLabConnection{}
+	case KindGitea:
+		cfg = &schema.GiteaConnection{}
 	case KindGitolite:
 		cfg = &schema.GitoliteConnection{}
+	case KindMercurial:
+		cfg = &schema.MercurialConnection{}
 	case KindPerforce:
 		cfg = &schema.PerforceConnection{}
 	case KindPhabricator:
 		cfg = &schema.PhabricatorConnection{}
 	case KindJVMPackages:
 		cfg = &schema.JVMPackagesConnection{}
+	case KindNPMPackages:
+		cfg = &schema.NPMPackagesConnection{}
+	case KindGoModules:
+		cfg = &schema.GoModulesConnection{}
 	case KindOther:
 		cfg = &schema.OtherExternalServiceConnection{}
 	default:
@@ -324,6 +400,10 @@ func ExtractToken(config string, kind string) (string, error) {
 		return c.Token, nil
 	case *schema.BitbucketServerConnection:
 		return c.Token, nil
+	case *schema.GiteaConnection:
+		return c.Token, nil
+	case *schema.AzureDevOpsConnection:
+		return c.Token, nil
 	case *schema.PhabricatorConnection:
 		return c.Token, nil
 	default:
@@ -396,6 +476,23 @@ func GetLimitFromConfig(kind string, config interface{}) (rlc RateLimitConfig, e
 			rlc.IsDefault = false
 		}
 		rlc.BaseURL = c.Url
+	case *schema.GiteaConnection:
+		// 2/s is the default limit we enforce, Gitea doesn't rate limit by default
+		rlc.Limit = rate.Limit(2)
+		if c != nil && c.RateLimit != nil {
+			rlc.Limit = limitOrInf(c.RateLimit.Enabled, c.RateLimit.RequestsPerHour)
+			rlc.IsDefault = false
+		}
+		rlc.BaseURL = c.Url
+	case *schema.AzureDevOpsConnection:
+		// Azure DevOps throttles by resource consumption rather than by
+		// request count, 2/s keeps us well below its limits.
+		rlc.Limit = defaultRateLimit
+		if c != nil && c.RateLimit != nil {
+			rlc.Limit = limitOrInf(c.RateLimit.Enabled, c.RateLimit.RequestsPerHour)
+			rlc.IsDefault = false
+		}
+		rlc.BaseURL = c.Url
 	case *schema.PerforceConnection:
 		rlc.Limit = rate.Limit(5000.0 / 3600.0)
 		if c != nil && c.RateLimit != nil {
@@ -410,6 +507,22 @@ func GetLimitFromConfig(kind string, config interface{}) (rlc RateLimitConfig, e
 			rlc.IsDefault = false
 		}
 		rlc.BaseURL = "maven"
+	case *schema.NPMPackagesConnection:
+		rlc.Limit = defaultRateLimit
+		if c != nil && c.RateLimit != nil {
+			rlc.Limit = limitOrInf(c.RateLimit.Enabled, c.RateLimit.RequestsPerHour)
+			rlc.IsDefault = false
+		}
+		rlc.BaseURL = c.Registry
+	case *schema.GoModulesConnection:
+		rlc.Limit = defaultRateLimit
+		if c != nil && c.RateLimit != nil {
+			rlc.Limit = limitOrInf(c.RateLimit.Enabled, c.RateLimit.RequestsPerHour)
+			rlc.IsDefault = false
+		}
+		if len(c.Urls) > 0 {
+			rlc.BaseURL = c.Urls[0]
+		}
 	default:
 		return rlc, ErrRateLimitUnsupported{codehostKind: kind}
 	}
@@ -492,10 +605,16 @@ func UniqueCodeHostIdentifier(kind, config string) (string, error) {
 		rawURL = c.Url
 	case *schema.BitbucketCloudConnection:
 		rawURL = c.Url
+	case *schema.GiteaConnection:
+		rawURL = c.Url
+	case *schema.AzureDevOpsConnection:
+		rawURL = c.Url
 	case *schema.PhabricatorConnection:
 		rawURL = c.Url
 	case *schema.OtherExternalServiceConnection:
 		rawURL = c.Url
+	case *schema.MercurialConnection:
+		rawURL = c.Url
 	case *schema.GitoliteConnection:
 		rawURL = c.Host
 	case *schema.AWSCodeCommitConnection:
@@ -507,6 +626,13 @@ func UniqueCodeHostIdentifier(kind, config string) (string, error) {
 		return c.P4Port, nil
 	case *schema.JVMPackagesConnection:
 		return KindJVMPackages, nil
+	case *schema.NPMPackagesConnection:
+		rawURL = c.Registry
+	case *schema.GoModulesConnection:
+		// The first proxy is the one most requests go to.
+		if len(c.Urls) > 0 {
+			rawURL = c.Urls[0]
+		}
 	default:
 		return "", errors.Errorf("unknown external service kind: %s", kind)
 	}
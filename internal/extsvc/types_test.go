@@ -27,6 +27,16 @@ func TestExtractToken(t *testing.T) {
 			kind:   KindBitbucketServer,
 			want:   "deadbeef",
 		},
+		{
+			config: `{"token": "deadbeef"}`,
+			kind:   KindGitea,
+			want:   "deadbeef",
+		},
+		{
+			config: `{"token": "deadbeef"}`,
+			kind:   KindAzureDevOps,
+			want:   "deadbeef",
+		},
 		{
 			config: `{"token": "deadbeef"}`,
 			kind:   KindPhabricator,
@@ -108,6 +118,30 @@ func TestExtractRateLimitConfig(t *testing.T) {
 				IsDefault:   true,
 			},
 		},
+		{
+			name:        "Gitea default",
+			config:      `{"url": "https://example.com/"}`,
+			kind:        KindGitea,
+			displayName: "Gitea 1",
+			want: RateLimitConfig{
+				BaseURL:     "https://example.com/",
+				DisplayName: "Gitea 1",
+				Limit:       2.0,
+				IsDefault:   true,
+			},
+		},
+		{
+			name:        "Azure DevOps non-default",
+			config:      `{"url": "https://dev.azure.com/", "rateLimit": {"enabled": true, "requestsPerHour": 3600}}`,
+			kind:        KindAzureDevOps,
+			displayName: "Azure DevOps 1",
+			want: RateLimitConfig{
+				BaseURL:     "https://dev.azure.com/",
+				DisplayName: "Azure DevOps 1",
+				Limit:       1.0,
+				IsDefault:   false,
+			},
+		},
 		{
 			name:        "GitLab non-default",
 			config:      `{"url": "https://example.com/", "rateLimit": {"enabled": true, "requestsPerHour": 3600}}`,
@@ -304,6 +338,18 @@ func TestUniqueCodeHostIdentifier(t *testing.T) {
 			want:   "https://bitbucket.org/",
 		},
 
+		{
+			kind:   KindGitea,
+			config: `{"url": "https://Gitea.example.com"}`,
+			want:   "https://gitea.example.com/",
+		},
+
+		{
+			kind:   KindAzureDevOps,
+			config: `{"url": "https://dev.azure.com"}`,
+			want:   "https://dev.azure.com/",
+		},
+
 		{
 			kind:   KindGitolite,
 			config: `{"host": "ssh://git@gitolite.example.com:2222/"}`,
@@ -314,6 +360,11 @@ func TestUniqueCodeHostIdentifier(t *testing.T) {
 			config: `{"host": "git@gitolite.example.com"}`,
 			want:   "git@gitolite.example.com/",
 		},
+		{
+			kind:   KindMercurial,
+			config: `{"url": "https://hg.example.com/repos"}`,
+			want:   "https://hg.example.com/repos/",
+		},
 		{
 			kind:   KindPerforce,
 			config: `{"p4.port": "ssl:111.222.333.444:1666"}`,
@@ -8,13 +8,16 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"net"
 	"net/http"
 	"net/url"
 	"os"
 	"path/filepath"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
+	"syscall"
 	"time"
 
 	"github.com/cockroachdb/errors"
@@ -22,6 +25,7 @@ import (
 	"github.com/inconshreveable/log15"
 	"github.com/neelance/parallel"
 	"github.com/opentracing-contrib/go-stdlib/nethttp"
+	"github.com/opentracing/opentracing-go"
 	"github.com/opentracing/opentracing-go/ext"
 	otlog "github.com/opentracing/opentracing-go/log"
 	"github.com/prometheus/client_golang/prometheus"
@@ -116,11 +120,101 @@ func AddrForRepo(repo api.RepoName, addrs []string) string {
 }
 
 // addrForKey returns the gitserver address to use for the given string key,
-// which is hashed for sharding purposes.
+// which is hashed for sharding purposes. The placement scheme is configured
+// with experimentalFeatures.gitServerPlacement.
 func addrForKey(key string, addrs []string) string {
+	if conf.GitServerPlacement() == conf.GitServerPlacementRendezvous {
+		return rendezvousAddrForKey(key, addrs)
+	}
+	return moduloAddrForKey(key, addrs)
+}
+
+// AddrsForRepo returns the gitserver addresses which keep a copy of the given
+// repo name, starting with AddrForRepo. It returns more than one address if
+// experimentalFeatures.gitServerReplicationFactor is greater than 1. It should
+// never be called with an empty slice.
+func AddrsForRepo(repo api.RepoName, addrs []string) []string {
+	repo = protocol.NormalizeRepo(repo) // in case the caller didn't already normalize it
+	return addrsForKey(string(repo), addrs, conf.GitServerReplicationFactor())
+}
+
+// AddrsForRepo returns the gitserver addresses which keep a copy of the given
+// repo name, starting with the one responsible for it.
+func (c *Client) AddrsForRepo(repo api.RepoName) []string {
+	addrs := c.Addrs()
+	if len(addrs) == 0 {
+		panic("unexpected state: no gitserver addresses")
+	}
+	return AddrsForRepo(repo, addrs)
+}
+
+// addrsForKey returns the n gitserver addresses to use for the given string
+// key, starting with addrForKey(key, addrs).
+func addrsForKey(key string, addrs []string, n int) []string {
+	if n > len(addrs) {
+		n = len(addrs)
+	}
+	if n <= 1 {
+		return []string{addrForKey(key, addrs)}
+	}
+
+	if conf.GitServerPlacement() == conf.GitServerPlacementRendezvous {
+		// The replicas are the addresses with the next highest scores.
+		sorted := append([]string(nil), addrs...)
+		sort.Slice(sorted, func(i, j int) bool {
+			return rendezvousLess(key, sorted[j], sorted[i])
+		})
+		return sorted[:n]
+	}
+
+	// The replicas are the addresses following the one responsible for key.
+	i := moduloIndex(key, len(addrs))
+	replicas := make([]string, 0, n)
+	for j := 0; j < n; j++ {
+		replicas = append(replicas, addrs[(i+j)%len(addrs)])
+	}
+	return replicas
+}
+
+// moduloAddrForKey places key by hashing it modulo the number of addresses.
+// Adding or removing an address moves almost every key.
+func moduloAddrForKey(key string, addrs []string) string {
+	return addrs[moduloIndex(key, len(addrs))]
+}
+
+func moduloIndex(key string, n int) int {
 	sum := md5.Sum([]byte(key))
-	serverIndex := binary.BigEndian.Uint64(sum[:]) % uint64(len(addrs))
-	return addrs[serverIndex]
+	return int(binary.BigEndian.Uint64(sum[:]) % uint64(n))
+}
+
+// rendezvousAddrForKey places key with rendezvous (highest random weight)
+// hashing: every address is scored by hashing it together with key, and the
+// address with the highest score wins. Adding an address only moves the keys
+// which it now scores highest for, and removing an address only moves the
+// keys which were placed on it. The order of addrs does not matter.
+func rendezvousAddrForKey(key string, addrs []string) string {
+	best := addrs[0]
+	for _, addr := range addrs[1:] {
+		if rendezvousLess(key, best, addr) {
+			best = addr
+		}
+	}
+	return best
+}
+
+// rendezvousLess reports whether a scores lower than b for key. Ties, which
+// are very unlikely, are broken by address.
+func rendezvousLess(key, a, b string) bool {
+	sa, sb := rendezvousScore(key, a), rendezvousScore(key, b)
+	if sa != sb {
+		return sa < sb
+	}
+	return a > b
+}
+
+func rendezvousScore(key, addr string) uint64 {
+	sum := md5.Sum([]byte(addr + "\x00" + key))
+	return binary.BigEndian.Uint64(sum[:])
 }
 
 // ArchiveOptions contains options for the Archive func.
@@ -333,6 +427,11 @@ var deadlineExceededCounter = promauto.NewCounter(prometheus.CounterOpts{
 	Help: "Times that Client.sendExec() returned context.DeadlineExceeded",
 })
 
+var failoverCounter = promauto.NewCounter(prometheus.CounterOpts{
+	Name: "src_gitserver_client_failover",
+	Help: "Times that a request was sent to another copy of a repository because its gitserver could not be reached",
+})
+
 // Cmd represents a command to be executed remotely.
 type Cmd struct {
 	client *Client
@@ -608,6 +707,25 @@ func (c *Client) RequestRepoUpdate(ctx context.Context, repo api.RepoName, since
 	if err != nil {
 		return nil, err
 	}
+	return readRepoUpdateResponse(resp)
+}
+
+// RequestRepoUpdateOn is like RequestRepoUpdate, but asks the gitserver at
+// addr, which may be a replica of repo rather than the gitserver responsible
+// for it.
+func (c *Client) RequestRepoUpdateOn(ctx context.Context, addr string, repo api.RepoName, since time.Duration) (*protocol.RepoUpdateResponse, error) {
+	req := &protocol.RepoUpdateRequest{
+		Repo:  repo,
+		Since: since,
+	}
+	resp, err := c.httpPostOn(ctx, addr, repo, "repo-update", req)
+	if err != nil {
+		return nil, err
+	}
+	return readRepoUpdateResponse(resp)
+}
+
+func readRepoUpdateResponse(resp *http.Response) (*protocol.RepoUpdateResponse, error) {
 	defer resp.Body.Close()
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(io.LimitReader(resp.Body, 200))
@@ -615,7 +733,7 @@ func (c *Client) RequestRepoUpdate(ctx context.Context, repo api.RepoName, since
 	}
 
 	var info *protocol.RepoUpdateResponse
-	err = json.NewDecoder(resp.Body).Decode(&info)
+	err := json.NewDecoder(resp.Body).Decode(&info)
 	return info, err
 }
 
@@ -695,6 +813,29 @@ func (c *Client) IsRepoCloned(ctx context.Context, repo api.RepoName) (bool, err
 	return cloned, nil
 }
 
+// IsRepoClonedOn reports whether repo is cloned on the gitserver at addr, even
+// if that gitserver is not responsible for repo. It is used to find where a
+// repository was cloned before the gitserver addresses changed.
+func (c *Client) IsRepoClonedOn(ctx context.Context, addr string, repo api.RepoName) (bool, error) {
+	req := &protocol.IsRepoClonedRequest{
+		Repo: repo,
+	}
+	resp, err := c.httpPostOn(ctx, addr, repo, "is-repo-cloned", req)
+	if err != nil {
+		return false, err
+	}
+	// no need to defer, we aren't using the body.
+	resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return true, nil
+	case http.StatusNotFound:
+		return false, nil
+	default:
+		return false, errors.Errorf("is-repo-cloned on %s: http status %d", addr, resp.StatusCode)
+	}
+}
+
 func (c *Client) RepoCloneProgress(ctx context.Context, repos ...api.RepoName) (*protocol.RepoCloneProgressResponse, error) {
 	numPossibleShards := len(c.Addrs())
 	shards := make(map[string]*protocol.RepoCloneProgressRequest, (len(repos)/numPossibleShards)*2) // 2x because it may not be a perfect division
@@ -906,12 +1047,44 @@ func (c *Client) httpPost(ctx context.Context, repo api.RepoName, op string, pay
 	return c.do(ctx, repo, "POST", op, payload)
 }
 
+// httpPostOn is like httpPost, but sends the request to the gitserver at addr
+// only. It never fails over to another copy of repo, since callers use it to
+// learn about the copy on that specific gitserver.
+func (c *Client) httpPostOn(ctx context.Context, addr string, repo api.RepoName, op string, payload interface{}) (resp *http.Response, err error) {
+	return c.send(ctx, repo, "POST", []string{"http://" + addr + "/" + op}, payload)
+}
+
 // do performs a request to a gitserver, sharding based on the given
 // repo name (the repo name is otherwise not used).
 func (c *Client) do(ctx context.Context, repo api.RepoName, method, op string, payload interface{}) (resp *http.Response, err error) {
+	// A request about a repo can be sent to any gitserver which keeps a copy
+	// of it. We only move on to the next copy if the gitserver could not
+	// handle the request.
+	uris := []string{op}
+	if !strings.HasPrefix(op, "http") {
+		uris = uris[:0]
+		for _, addr := range c.AddrsForRepo(repo) {
+			uris = append(uris, "http://"+addr+"/"+op)
+		}
+	} else if u, err := url.Parse(op); err == nil && len(c.Addrs()) > 0 {
+		// Requests for a specific gitserver only fail over if it is the one
+		// responsible for repo.
+		if addrs := c.AddrsForRepo(repo); u.Host == addrs[0] {
+			for _, addr := range addrs[1:] {
+				u.Host = addr
+				uris = append(uris, u.String())
+			}
+		}
+	}
+	return c.send(ctx, repo, method, uris, payload)
+}
+
+// send sends the request to each of uris in turn until one of them handles
+// it.
+func (c *Client) send(ctx context.Context, repo api.RepoName, method string, uris []string, payload interface{}) (resp *http.Response, err error) {
 	span, ctx := ot.StartSpanFromContext(ctx, "Client.do")
 	defer func() {
-		span.LogKV("repo", string(repo), "method", method, "op", op)
+		span.LogKV("repo", string(repo), "method", method)
 		if err != nil {
 			ext.Error.Set(span, true)
 			span.SetTag("err", err.Error())
@@ -924,11 +1097,30 @@ func (c *Client) do(ctx context.Context, repo api.RepoName, method, op string, p
 		return nil, err
 	}
 
-	uri := op
-	if !strings.HasPrefix(op, "http") {
-		uri = "http://" + c.AddrForRepo(repo) + "/" + op
+	if c.HTTPLimiter != nil {
+		c.HTTPLimiter.Acquire()
+		defer c.HTTPLimiter.Release()
+		span.LogKV("event", "Acquired HTTP limiter")
+	}
+
+	for i, uri := range uris {
+		if i > 0 {
+			span.LogKV("event", "failover", "uri", uri)
+			failoverCounter.Inc()
+		}
+
+		resp, err = c.sendOnce(ctx, span.Tracer(), method, uri, reqBody)
+		if ctx.Err() != nil || i == len(uris)-1 || !shouldFailover(resp, err) {
+			return resp, err
+		}
+		if resp != nil {
+			resp.Body.Close()
+		}
 	}
+	return resp, err
+}
 
+func (c *Client) sendOnce(ctx context.Context, tracer opentracing.Tracer, method, uri string, reqBody []byte) (*http.Response, error) {
 	req, err := http.NewRequest(method, uri, bytes.NewReader(reqBody))
 	if err != nil {
 		return nil, err
@@ -939,13 +1131,7 @@ func (c *Client) do(ctx context.Context, repo api.RepoName, method, op string, p
 	req.Header.Set("X-Sourcegraph-Actor", userFromContext(ctx))
 	req = req.WithContext(ctx)
 
-	if c.HTTPLimiter != nil {
-		c.HTTPLimiter.Acquire()
-		defer c.HTTPLimiter.Release()
-		span.LogKV("event", "Acquired HTTP limiter")
-	}
-
-	req, ht := nethttp.TraceRequest(span.Tracer(), req,
+	req, ht := nethttp.TraceRequest(tracer, req,
 		nethttp.OperationName("Gitserver Client"),
 		nethttp.ClientTrace(false))
 	defer ht.Finish()
@@ -953,6 +1139,28 @@ func (c *Client) do(ctx context.Context, repo api.RepoName, method, op string, p
 	return c.HTTPClient.Do(req)
 }
 
+// shouldFailover reports whether the request never reached a gitserver,
+// either because we could not connect to it or because it (or a proxy in
+// front of it) is unavailable. Requests like create-commit-from-patch aren't
+// idempotent, so we don't fail over once a gitserver may have started to
+// handle a request, even if it drops the connection afterwards.
+func shouldFailover(resp *http.Response, err error) bool {
+	if err != nil {
+		return isConnectionError(err)
+	}
+	return resp.StatusCode == http.StatusBadGateway || resp.StatusCode == http.StatusServiceUnavailable
+}
+
+// isConnectionError reports whether err means we could not connect to a
+// gitserver.
+func isConnectionError(err error) bool {
+	if errors.Is(err, syscall.ECONNREFUSED) {
+		return true
+	}
+	var opErr *net.OpError
+	return errors.As(err, &opErr) && opErr.Op == "dial"
+}
+
 func userFromContext(ctx context.Context) string {
 	a := actor.FromContext(ctx)
 	if a == nil {
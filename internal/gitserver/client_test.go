@@ -7,6 +7,7 @@ import (
 	"encoding/base64"
 	"fmt"
 	"io"
+	"net"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
@@ -14,6 +15,7 @@ import (
 	"os/exec"
 	"path/filepath"
 	"sort"
+	"strings"
 	"testing"
 
 	"github.com/cockroachdb/errors"
@@ -22,8 +24,10 @@ import (
 
 	"github.com/sourcegraph/sourcegraph/cmd/gitserver/server"
 	"github.com/sourcegraph/sourcegraph/internal/api"
+	"github.com/sourcegraph/sourcegraph/internal/conf"
 	"github.com/sourcegraph/sourcegraph/internal/gitserver"
 	"github.com/sourcegraph/sourcegraph/internal/httpcli"
+	"github.com/sourcegraph/sourcegraph/schema"
 )
 
 func TestClient_ListCloned(t *testing.T) {
@@ -287,6 +291,190 @@ func TestAddrForRepo(t *testing.T) {
 	}
 }
 
+func TestAddrForRepo_rendezvous(t *testing.T) {
+	conf.Mock(&conf.Unified{SiteConfiguration: schema.SiteConfiguration{
+		ExperimentalFeatures: &schema.ExperimentalFeatures{GitServerPlacement: conf.GitServerPlacementRendezvous},
+	}})
+	defer conf.Mock(nil)
+
+	addrs := []string{"gitserver-0", "gitserver-1", "gitserver-2"}
+	grown := append(addrs[:len(addrs):len(addrs)], "gitserver-3")
+	shrunk := []string{"gitserver-0", "gitserver-2"}
+
+	counts := map[string]int{}
+	for i := 0; i < 10000; i++ {
+		repo := api.RepoName(fmt.Sprintf("github.com/foo/repo%d", i))
+		addr := gitserver.AddrForRepo(repo, addrs)
+		counts[addr]++
+
+		// Adding a gitserver only moves repos to it.
+		if got := gitserver.AddrForRepo(repo, grown); got != addr && got != "gitserver-3" {
+			t.Fatalf("%s moved from %s to %s after adding gitserver-3", repo, addr, got)
+		}
+
+		// Removing a gitserver only moves its repos.
+		if got := gitserver.AddrForRepo(repo, shrunk); got != addr && addr != "gitserver-1" {
+			t.Fatalf("%s moved from %s to %s after removing gitserver-1", repo, addr, got)
+		}
+
+		// The order of the addresses doesn't matter.
+		if got := gitserver.AddrForRepo(repo, []string{"gitserver-2", "gitserver-0", "gitserver-1"}); got != addr {
+			t.Fatalf("%s moved from %s to %s after reordering gitservers", repo, addr, got)
+		}
+	}
+
+	for _, addr := range addrs {
+		// Each gitserver should get about a third of the repos.
+		if counts[addr] < 3000 || counts[addr] > 3700 {
+			t.Errorf("unbalanced placement: %v", counts)
+			break
+		}
+	}
+}
+
+func TestAddrsForRepo(t *testing.T) {
+	addrs := []string{"gitserver-0", "gitserver-1", "gitserver-2", "gitserver-3"}
+
+	for _, placement := range []string{conf.GitServerPlacementModulo, conf.GitServerPlacementRendezvous} {
+		t.Run(placement, func(t *testing.T) {
+			conf.Mock(&conf.Unified{SiteConfiguration: schema.SiteConfiguration{
+				ExperimentalFeatures: &schema.ExperimentalFeatures{
+					GitServerPlacement:         placement,
+					GitServerReplicationFactor: 3,
+				},
+			}})
+			defer conf.Mock(nil)
+
+			for i := 0; i < 100; i++ {
+				repo := api.RepoName(fmt.Sprintf("github.com/foo/repo%d", i))
+				got := gitserver.AddrsForRepo(repo, addrs)
+				if len(got) != 3 {
+					t.Fatalf("expected 3 addresses for %s, got %v", repo, got)
+				}
+				if want := gitserver.AddrForRepo(repo, addrs); got[0] != want {
+					t.Fatalf("expected %s to be the primary of %s, got %v", want, repo, got)
+				}
+				seen := map[string]bool{}
+				for _, addr := range got {
+					if seen[addr] {
+						t.Fatalf("duplicate address for %s: %v", repo, got)
+					}
+					seen[addr] = true
+				}
+			}
+
+			// The replication factor is capped by the number of gitservers.
+			if got := gitserver.AddrsForRepo("repo1", addrs[:2]); len(got) != 2 {
+				t.Fatalf("expected 2 addresses, got %v", got)
+			}
+		})
+	}
+
+	t.Run("no replication", func(t *testing.T) {
+		got := gitserver.AddrsForRepo("repo1", addrs)
+		if want := []string{gitserver.AddrForRepo("repo1", addrs)}; !cmp.Equal(want, got) {
+			t.Fatalf("want %v, got %v", want, got)
+		}
+	})
+}
+
+func TestClient_failover(t *testing.T) {
+	conf.Mock(&conf.Unified{SiteConfiguration: schema.SiteConfiguration{
+		ExperimentalFeatures: &schema.ExperimentalFeatures{GitServerReplicationFactor: 2},
+	}})
+	defer conf.Mock(nil)
+
+	var called int
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called++
+		if r.URL.Path != "/is-repo-cloned" {
+			t.Errorf("unexpected request %s", r.URL)
+		}
+	}))
+	defer ts.Close()
+
+	// Nothing listens on the address of a closed server.
+	dead := httptest.NewServer(http.NotFoundHandler())
+	dead.Close()
+
+	addrs := []string{
+		strings.TrimPrefix(dead.URL, "http://"),
+		strings.TrimPrefix(ts.URL, "http://"),
+	}
+	cli := &gitserver.Client{
+		Addrs:      func() []string { return addrs },
+		HTTPClient: http.DefaultClient,
+	}
+
+	// Find a repo the unreachable gitserver is responsible for.
+	var repo api.RepoName
+	for i := 0; ; i++ {
+		repo = api.RepoName(fmt.Sprintf("github.com/foo/repo%d", i))
+		if cli.AddrForRepo(repo) == addrs[0] {
+			break
+		}
+	}
+
+	cloned, err := cli.IsRepoCloned(context.Background(), repo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !cloned || called != 1 {
+		t.Fatalf("expected the replica to answer, got cloned=%v called=%d", cloned, called)
+	}
+
+	// Requests for a specific gitserver must not be answered by another one.
+	if _, err := cli.IsRepoClonedOn(context.Background(), addrs[0], repo); err == nil || called != 1 {
+		t.Fatalf("expected an error without failover, got err=%v called=%d", err, called)
+	}
+
+	// Unavailable gitservers are skipped too.
+	unavailable := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer unavailable.Close()
+	addrs[0] = strings.TrimPrefix(unavailable.URL, "http://")
+	for i := 0; ; i++ {
+		repo = api.RepoName(fmt.Sprintf("github.com/foo/repo%d", i))
+		if cli.AddrForRepo(repo) == addrs[0] {
+			break
+		}
+	}
+	if cloned, err := cli.IsRepoCloned(context.Background(), repo); err != nil || !cloned || called != 2 {
+		t.Fatalf("expected the replica to answer, got cloned=%v err=%v called=%d", cloned, err, called)
+	}
+
+	// Requests may have been handled when a gitserver resets the connection,
+	// so they aren't sent to another one.
+	reset := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, _, err := w.(http.Hijacker).Hijack()
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		_ = conn.(*net.TCPConn).SetLinger(0)
+		conn.Close()
+	}))
+	defer reset.Close()
+	addrs[0] = strings.TrimPrefix(reset.URL, "http://")
+	for i := 0; ; i++ {
+		repo = api.RepoName(fmt.Sprintf("github.com/foo/repo%d", i))
+		if cli.AddrForRepo(repo) == addrs[0] {
+			break
+		}
+	}
+	if _, err := cli.IsRepoCloned(context.Background(), repo); err == nil || called != 2 {
+		t.Fatalf("expected an error without failover, got err=%v called=%d", err, called)
+	}
+
+	// Without replication there is nothing to fail over to.
+	addrs[0] = strings.TrimPrefix(dead.URL, "http://")
+	conf.Mock(nil)
+	if _, err := cli.IsRepoCloned(context.Background(), repo); err == nil {
+		t.Fatal("expected an error")
+	}
+}
+
 func TestClient_P4Exec(t *testing.T) {
 	root, err := os.MkdirTemp("", t.Name())
 	if err != nil {
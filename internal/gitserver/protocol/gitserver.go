@@ -249,6 +249,10 @@ type CreateCommitFromPatchError struct {
 	Command string
 	// CombinedOutput is the combined stderr and stdout from running the command
 	CombinedOutput string
+
+	// PatchConflict is true if the error occurred because the patch doesn't
+	// apply to the base commit.
+	PatchConflict bool
 }
 
 // Error returns a detailed error conforming to the error interface
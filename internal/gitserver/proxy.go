@@ -4,6 +4,7 @@ import (
 	"net/http"
 	"net/http/httputil"
 
+	"github.com/cockroachdb/errors"
 	"github.com/neelance/parallel"
 
 	"github.com/sourcegraph/sourcegraph/internal/api"
@@ -54,3 +55,61 @@ func (p *ReverseProxy) ServeHTTP(repo api.RepoName, method, op string, director
 
 	proxy.ServeHTTP(res, req)
 }
+
+// errUnavailable is returned by the response modifier of ServeHTTPWithFailover
+// when a gitserver (or a proxy in front of it) is unavailable.
+var errUnavailable = errors.New("gitserver unavailable")
+
+// ServeHTTPWithFailover is like ServeHTTP, but tries each of the given gitserver
+// addresses in order until one of them can handle the request. The director
+// must rewrite the request to the given address. addrs should be obtained via a
+// gitserver client's AddrsForRepo method, and the director must be able to
+// send the request body more than once.
+func (p *ReverseProxy) ServeHTTPWithFailover(repo api.RepoName, method, op string, addrs []string, director func(req *http.Request, addr string), res http.ResponseWriter, req *http.Request) {
+	span, _ := ot.StartSpanFromContext(req.Context(), "ReverseProxy.ServeHTTPWithFailover")
+	defer func() {
+		span.LogKV("repo", string(repo), "method", method, "op", op)
+		span.Finish()
+	}()
+
+	if p.HTTPLimiter != nil {
+		p.HTTPLimiter.Acquire()
+		defer p.HTTPLimiter.Release()
+		span.LogKV("event", "Acquired HTTP limiter")
+	}
+
+	for i, addr := range addrs {
+		addr := addr
+		proxy := &httputil.ReverseProxy{
+			Director:  func(req *http.Request) { director(req, addr) },
+			Transport: p.Transport,
+		}
+
+		// Nothing has been written to res yet if we could not connect to the
+		// gitserver or it is unavailable, so the next copy of the repo can
+		// still handle the request.
+		var failover bool
+		if i < len(addrs)-1 {
+			proxy.ModifyResponse = func(resp *http.Response) error {
+				if resp.StatusCode == http.StatusBadGateway || resp.StatusCode == http.StatusServiceUnavailable {
+					return errUnavailable
+				}
+				return nil
+			}
+			proxy.ErrorHandler = func(res http.ResponseWriter, req *http.Request, err error) {
+				if err == errUnavailable || (isConnectionError(err) && req.Context().Err() == nil) {
+					failover = true
+					return
+				}
+				res.WriteHeader(http.StatusBadGateway)
+			}
+		}
+
+		proxy.ServeHTTP(res, req)
+		if !failover {
+			return
+		}
+		span.LogKV("event", "failover", "addr", addr)
+		failoverCounter.Inc()
+	}
+}
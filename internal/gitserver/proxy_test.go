@@ -0,0 +1,59 @@
+package gitserver_test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/sourcegraph/sourcegraph/internal/gitserver"
+)
+
+func TestReverseProxy_ServeHTTPWithFailover(t *testing.T) {
+	ok := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		w.Write(body)
+	}))
+	defer ok.Close()
+
+	unavailable := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer unavailable.Close()
+
+	// Nothing listens on the address of a closed server.
+	dead := httptest.NewServer(http.NotFoundHandler())
+	dead.Close()
+
+	addr := func(s *httptest.Server) string { return strings.TrimPrefix(s.URL, "http://") }
+
+	for name, tc := range map[string]struct {
+		addrs      []string
+		wantStatus int
+	}{
+		"first reachable":    {addrs: []string{addr(ok), addr(dead)}, wantStatus: http.StatusOK},
+		"first dead":         {addrs: []string{addr(dead), addr(unavailable), addr(ok)}, wantStatus: http.StatusOK},
+		"none available":     {addrs: []string{addr(dead), addr(unavailable)}, wantStatus: http.StatusServiceUnavailable},
+		"single unreachable": {addrs: []string{addr(dead)}, wantStatus: http.StatusBadGateway},
+	} {
+		t.Run(name, func(t *testing.T) {
+			director := func(req *http.Request, addr string) {
+				req.URL.Scheme = "http"
+				req.URL.Host = addr
+				req.Body = io.NopCloser(strings.NewReader("payload"))
+			}
+
+			p := gitserver.NewReverseProxy(http.DefaultTransport, nil)
+			w := httptest.NewRecorder()
+			p.ServeHTTPWithFailover("repo", "POST", "exec", tc.addrs, director, w, httptest.NewRequest("POST", "/exec", nil))
+
+			if w.Code != tc.wantStatus {
+				t.Fatalf("unexpected status: have=%d want=%d", w.Code, tc.wantStatus)
+			}
+			if tc.wantStatus == http.StatusOK && w.Body.String() != "payload" {
+				t.Fatalf("unexpected body %q", w.Body.String())
+			}
+		})
+	}
+}
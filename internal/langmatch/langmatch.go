@@ -0,0 +1,115 @@
+// Package langmatch provides helpers for matching files against the
+// languages of lang: filters.
+package langmatch
+
+import (
+	"path"
+	"strings"
+
+	"github.com/cockroachdb/errors"
+	"github.com/go-enry/go-enry/v2"
+
+	// Registers the inventory's language tweaks (e.g. .tsx is TypeScript) so
+	// that languages are detected the same way everywhere.
+	_ "github.com/sourcegraph/sourcegraph/internal/inventory"
+)
+
+// LangMatcher reports whether a file is written in all of a set of languages.
+// Languages are detected from the file name, and from the file content when
+// the name is ambiguous (e.g. a .h file can be C, C++ or Objective-C).
+type LangMatcher struct {
+	languages []string
+}
+
+// Compile returns a LangMatcher for languages, which are names or aliases
+// known to enry, such as the values of lang: filters. It returns nil if
+// languages is empty, which matches every file.
+func Compile(languages []string) (*LangMatcher, error) {
+	if len(languages) == 0 {
+		return nil, nil
+	}
+	m := &LangMatcher{languages: make([]string, 0, len(languages))}
+	for _, alias := range languages {
+		lang, ok := enry.GetLanguageByAlias(alias)
+		if !ok {
+			return nil, errors.Errorf("unknown language %q", alias)
+		}
+		m.languages = append(m.languages, lang)
+	}
+	return m, nil
+}
+
+// MatchLang reports whether the file at name with content is written in the
+// languages of m. content may be a prefix or an excerpt of the file. If
+// content is empty and name is ambiguous, the file matches if it could be
+// written in the languages of m.
+//
+// Callers only ask about files whose names matched the include patterns of
+// the lang: filters, so files enry can't classify from their name match too.
+func (m *LangMatcher) MatchLang(name string, content []byte) bool {
+	if m == nil {
+		return true
+	}
+
+	candidates := Candidates(name)
+	if len(candidates) == 0 {
+		return true
+	}
+	if len(candidates) > 1 && len(content) > 0 {
+		candidates = breakTie(path.Base(name), content, candidates)
+	}
+
+	for _, lang := range m.languages {
+		if !contains(candidates, lang) {
+			return false
+		}
+	}
+	return true
+}
+
+// String returns the languages of m.
+func (m *LangMatcher) String() string {
+	if m == nil {
+		return ""
+	}
+	return strings.Join(m.languages, ",")
+}
+
+// Candidates returns the languages the file at name may be written in, judging
+// from its name only.
+func Candidates(name string) []string {
+	base := path.Base(name)
+	if langs := enry.GetLanguagesByFilename(base, nil, nil); len(langs) > 0 {
+		return langs
+	}
+	return enry.GetLanguagesByExtension(base, nil, nil)
+}
+
+// breakTie returns the language among candidates that content is most likely
+// written in. It never returns a language which isn't a candidate.
+func breakTie(base string, content []byte, candidates []string) []string {
+	// The heuristics ignore the candidates they are passed, so filter their
+	// results ourselves.
+	var langs []string
+	for _, lang := range enry.GetLanguagesByContent(base, content, candidates) {
+		if contains(candidates, lang) {
+			langs = append(langs, lang)
+		}
+	}
+	if len(langs) == 1 {
+		return langs
+	}
+	if ranked := enry.GetLanguagesByClassifier(base, content, candidates); len(ranked) > 0 {
+		return ranked[:1]
+	}
+	return candidates
+}
+
+func contains(langs []string, lang string) bool {
+	for _, l := range langs {
+		if l == lang {
+			return true
+		}
+	}
+	return false
+}
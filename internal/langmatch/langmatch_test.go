@@ -0,0 +1,58 @@
+package langmatch
+
+import "testing"
+
+func TestMatchLang(t *testing.T) {
+	const (
+		objectiveC = "#import <Foundation/Foundation.h>\n@interface Foo : NSObject\n@end\n"
+		cpp        = "namespace foo {\nclass Bar {};\n}\n"
+	)
+
+	cases := []struct {
+		languages []string
+		name      string
+		content   string
+		want      bool
+	}{
+		{languages: nil, name: "a.py", want: true},
+		{languages: []string{"go"}, name: "cmd/main.go", want: true},
+		{languages: []string{"go"}, name: "main.py", want: false},
+		{languages: []string{"golang"}, name: "main.go", want: true},
+		{languages: []string{"typescript"}, name: "app.tsx", want: true},
+		{languages: []string{"dockerfile"}, name: "build/Dockerfile", want: true},
+
+		// Ambiguous extensions are resolved with the content.
+		{languages: []string{"objective-c"}, name: "foo.h", content: objectiveC, want: true},
+		{languages: []string{"c"}, name: "foo.h", content: objectiveC, want: false},
+		{languages: []string{"c++"}, name: "foo.h", content: cpp, want: true},
+		{languages: []string{"objective-c"}, name: "foo.h", content: cpp, want: false},
+
+		// Files enry doesn't know matched the include pattern already.
+		{languages: []string{"go"}, name: "main.sgtest", want: true},
+		{languages: []string{"go"}, name: "main.sgtest", content: cpp, want: true},
+
+		// Without content, any candidate language matches.
+		{languages: []string{"c"}, name: "foo.h", want: true},
+		{languages: []string{"objective-c"}, name: "foo.h", want: true},
+	}
+
+	for _, tc := range cases {
+		m, err := Compile(tc.languages)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var content []byte
+		if tc.content != "" {
+			content = []byte(tc.content)
+		}
+		if got := m.MatchLang(tc.name, content); got != tc.want {
+			t.Errorf("Compile(%q).MatchLang(%q, %q) = %t, want %t", tc.languages, tc.name, tc.content, got, tc.want)
+		}
+	}
+}
+
+func TestCompileUnknownLanguage(t *testing.T) {
+	if _, err := Compile([]string{"notalanguage"}); err == nil {
+		t.Error("expected an error for an unknown language")
+	}
+}
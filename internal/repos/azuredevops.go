@@ -0,0 +1,226 @@
+package repos
+
+import (
+	"context"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/cockroachdb/errors"
+	"github.com/inconshreveable/log15"
+
+	"github.com/sourcegraph/sourcegraph/internal/conf/reposource"
+	"github.com/sourcegraph/sourcegraph/internal/extsvc"
+	"github.com/sourcegraph/sourcegraph/internal/extsvc/auth"
+	"github.com/sourcegraph/sourcegraph/internal/extsvc/azuredevops"
+	"github.com/sourcegraph/sourcegraph/internal/httpcli"
+	"github.com/sourcegraph/sourcegraph/internal/jsonc"
+	"github.com/sourcegraph/sourcegraph/internal/types"
+	"github.com/sourcegraph/sourcegraph/schema"
+)
+
+// An AzureDevOpsSource yields repositories from a single Azure DevOps
+// connection configured in Sourcegraph via the external services
+// configuration.
+type AzureDevOpsSource struct {
+	svc     *types.ExternalService
+	config  *schema.AzureDevOpsConnection
+	exclude excludeFunc
+	baseURL *url.URL
+	client  *azuredevops.Client
+}
+
+var _ Source = &AzureDevOpsSource{}
+
+// NewAzureDevOpsSource returns a new AzureDevOpsSource from the given external
+// service.
+func NewAzureDevOpsSource(svc *types.ExternalService, cf *httpcli.Factory) (*AzureDevOpsSource, error) {
+	var c schema.AzureDevOpsConnection
+	if err := jsonc.Unmarshal(svc.Config, &c); err != nil {
+		return nil, errors.Errorf("external service id=%d config error: %s", svc.ID, err)
+	}
+	return newAzureDevOpsSource(svc, &c, cf)
+}
+
+func newAzureDevOpsSource(svc *types.ExternalService, c *schema.AzureDevOpsConnection, cf *httpcli.Factory) (*AzureDevOpsSource, error) {
+	baseURL, err := url.Parse(c.Url)
+	if err != nil {
+		return nil, err
+	}
+	baseURL = extsvc.NormalizeBaseURL(baseURL)
+
+	if cf == nil {
+		cf = httpcli.NewExternalHTTPClientFactory()
+	}
+
+	cli, err := cf.Doer()
+	if err != nil {
+		return nil, err
+	}
+
+	var eb excludeBuilder
+	for _, r := range c.Exclude {
+		eb.Exact(r.Name)
+		eb.Exact(r.Id)
+		eb.Pattern(r.Pattern)
+	}
+	exclude, err := eb.Build()
+	if err != nil {
+		return nil, err
+	}
+
+	return &AzureDevOpsSource{
+		svc:     svc,
+		config:  c,
+		exclude: exclude,
+		baseURL: baseURL,
+		client:  azuredevops.NewClient(baseURL, AzureDevOpsAuthenticator(c), cli),
+	}, nil
+}
+
+// AzureDevOpsAuthenticator returns the authenticator for the token of the
+// given connection.
+func AzureDevOpsAuthenticator(c *schema.AzureDevOpsConnection) auth.Authenticator {
+	if c.Username != "" {
+		return &auth.BasicAuth{Username: c.Username, Password: c.Token}
+	}
+	return &auth.OAuthBearerToken{Token: c.Token}
+}
+
+// ListRepos returns all Azure DevOps repositories accessible to all connections
+// configured in Sourcegraph via the external services configuration.
+func (s AzureDevOpsSource) ListRepos(ctx context.Context, results chan SourceResult) {
+	s.listAllRepos(ctx, results)
+}
+
+// GetRepo returns the Azure DevOps repository with the given
+// "org/project/repo" name.
+func (s AzureDevOpsSource) GetRepo(ctx context.Context, name string) (*types.Repo, error) {
+	parts := strings.Split(name, "/")
+	if len(parts) != 3 || parts[0] == "" || parts[1] == "" || parts[2] == "" {
+		return nil, errors.Errorf("invalid Azure DevOps repository %q: expected org/project/repo", name)
+	}
+
+	repo, err := s.client.GetRepository(ctx, parts[0], parts[1], parts[2])
+	if err != nil {
+		return nil, err
+	}
+
+	return s.makeRepo(repo), nil
+}
+
+// ExternalServices returns a singleton slice containing the external service.
+func (s AzureDevOpsSource) ExternalServices() types.ExternalServices {
+	return types.ExternalServices{s.svc}
+}
+
+func (s AzureDevOpsSource) makeRepo(repo *azuredevops.Repository) *types.Repo {
+	urn := s.svc.URN()
+	return &types.Repo{
+		Name: reposource.AzureDevOpsRepoName(
+			s.config.RepositoryPathPattern,
+			s.baseURL.Hostname(),
+			repo.Org,
+			repo.Project.Name,
+			repo.Name,
+		),
+		URI: string(reposource.AzureDevOpsRepoName(
+			"",
+			s.baseURL.Hostname(),
+			repo.Org,
+			repo.Project.Name,
+			repo.Name,
+		)),
+		ExternalRepo: azuredevops.ExternalRepoSpec(repo, *s.baseURL),
+		Private:      repo.Private(),
+		Sources: map[string]*types.SourceInfo{
+			urn: {
+				ID:       urn,
+				CloneURL: s.remoteURL(repo),
+			},
+		},
+		Metadata: repo,
+	}
+}
+
+// remoteURL returns the repository's Git remote URL
+//
+// note: this used to contain credentials but that is no longer the case
+// if you need to get an authenticated clone url use repos.CloneURL
+func (s *AzureDevOpsSource) remoteURL(repo *azuredevops.Repository) string {
+	if s.config.GitURLType == "ssh" {
+		return repo.SSHURL // SSH authentication must be provided out-of-band
+	}
+	return repo.RemoteURL
+}
+
+func (s *AzureDevOpsSource) excludes(r *azuredevops.Repository) bool {
+	return s.exclude(r.Org+"/"+r.Project.Name+"/"+r.Name) || s.exclude(r.ID)
+}
+
+func (s *AzureDevOpsSource) listAllRepos(ctx context.Context, results chan SourceResult) {
+	seen := make(map[string]bool)
+
+	// listProject sends the repositories of the given project to results. It
+	// returns false if ctx is done.
+	listProject := func(item, org, project string) bool {
+		if err := ctx.Err(); err != nil {
+			results <- SourceResult{Source: s, Err: err}
+			return false
+		}
+
+		repos, err := s.client.ListRepositories(ctx, org, project)
+		if err != nil {
+			if azuredevops.IsNotFound(err) {
+				log15.Warn("skipping missing azuredevops project:", "item", item, "project", org+"/"+project, "err", err)
+				return true
+			}
+			results <- SourceResult{Source: s, Err: errors.Wrapf(err, "%s: project=%q", item, project)}
+			return true
+		}
+
+		for _, repo := range repos {
+			// Repositories without a default branch are empty and can't
+			// be cloned.
+			if repo.IsDisabled || repo.DefaultBranch == "" {
+				continue
+			}
+
+			if !seen[repo.ID] && !s.excludes(repo) {
+				results <- SourceResult{Source: s, Repo: s.makeRepo(repo)}
+				seen[repo.ID] = true
+			}
+		}
+		return true
+	}
+
+	for _, p := range s.config.Projects {
+		item := "azuredevops.projects: item=" + strconv.Quote(p)
+		parts := strings.SplitN(p, "/", 2)
+		if len(parts) != 2 {
+			results <- SourceResult{Source: s, Err: errors.Errorf("%s: expected org/project", item)}
+			continue
+		}
+		if !listProject(item, parts[0], parts[1]) {
+			return
+		}
+	}
+
+	for _, org := range s.config.Orgs {
+		item := "azuredevops.orgs: item=" + strconv.Quote(org)
+		for token, first := "", true; first || token != ""; first = false {
+			projects, next, err := s.client.ListProjects(ctx, org, token)
+			if err != nil {
+				results <- SourceResult{Source: s, Err: errors.Wrap(err, item)}
+				break
+			}
+
+			for _, p := range projects {
+				if !listProject(item, org, p.Name) {
+					return
+				}
+			}
+			token = next
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package repos
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/sourcegraph/sourcegraph/internal/extsvc"
+	"github.com/sourcegraph/sourcegraph/internal/types"
+	"github.com/sourcegraph/sourcegraph/schema"
+)
+
+func TestAzureDevOpsSource_ListRepos(t *testing.T) {
+	conf := &schema.AzureDevOpsConnection{
+		Url:      "https://dev.azure.com",
+		Token:    "secret",
+		Orgs:     []string{"sgtest"},
+		Projects: []string{"sgtest/sourcegraph"},
+		Exclude: []*schema.ExcludedAzureDevOpsRepo{
+			{Name: "sgtest/sourcegraph/jsonrpc2"},
+		},
+	}
+
+	cf, save := newClientFactory(t, "AZUREDEVOPS-LIST-REPOS/found")
+	defer save(t)
+
+	svc := &types.ExternalService{
+		Kind:   extsvc.KindAzureDevOps,
+		Config: marshalJSON(t, conf),
+	}
+
+	src, err := newAzureDevOpsSource(svc, conf, cf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	repos, err := listAll(context.Background(), src)
+	if have, want := fmt.Sprint(err), "<nil>"; have != want {
+		t.Errorf("error:\nhave: %q\nwant: %q", have, want)
+	}
+
+	type repo struct {
+		Name     string
+		Private  bool
+		CloneURL string
+	}
+	var have []repo
+	for _, r := range repos {
+		have = append(have, repo{
+			Name:     string(r.Name),
+			Private:  r.Private,
+			CloneURL: r.Sources[svc.URN()].CloneURL,
+		})
+	}
+	sort.Slice(have, func(i, j int) bool { return have[i].Name < have[j].Name })
+
+	// Empty, disabled and excluded repositories are skipped, and repositories
+	// of projects which are also part of a listed organization are only
+	// yielded once.
+	want := []repo{
+		{
+			Name:     "dev.azure.com/sgtest/internal/secrets",
+			Private:  true,
+			CloneURL: "https://sgtest@dev.azure.com/sgtest/internal/_git/secrets",
+		},
+		{
+			Name:     "dev.azure.com/sgtest/sourcegraph/src-cli",
+			CloneURL: "https://sgtest@dev.azure.com/sgtest/sourcegraph/_git/src-cli",
+		},
+	}
+	if diff := cmp.Diff(want, have); diff != "" {
+		t.Fatalf("unexpected repos (-want +have):\n%s", diff)
+	}
+}
@@ -0,0 +1,278 @@
+package repos
+
+import (
+	"context"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/cockroachdb/errors"
+	"github.com/inconshreveable/log15"
+
+	"github.com/sourcegraph/sourcegraph/internal/conf/reposource"
+	"github.com/sourcegraph/sourcegraph/internal/extsvc"
+	"github.com/sourcegraph/sourcegraph/internal/extsvc/gitea"
+	"github.com/sourcegraph/sourcegraph/internal/httpcli"
+	"github.com/sourcegraph/sourcegraph/internal/jsonc"
+	"github.com/sourcegraph/sourcegraph/internal/types"
+	"github.com/sourcegraph/sourcegraph/schema"
+)
+
+// A GiteaSource yields repositories from a single Gitea connection configured
+// in Sourcegraph via the external services configuration.
+type GiteaSource struct {
+	svc     *types.ExternalService
+	config  *schema.GiteaConnection
+	exclude excludeFunc
+	baseURL *url.URL
+	client  *gitea.Client
+}
+
+var _ Source = &GiteaSource{}
+
+// NewGiteaSource returns a new GiteaSource from the given external service.
+func NewGiteaSource(svc *types.ExternalService, cf *httpcli.Factory) (*GiteaSource, error) {
+	var c schema.GiteaConnection
+	if err := jsonc.Unmarshal(svc.Config, &c); err != nil {
+		return nil, errors.Errorf("external service id=%d config error: %s", svc.ID, err)
+	}
+	return newGiteaSource(svc, &c, cf)
+}
+
+func newGiteaSource(svc *types.ExternalService, c *schema.GiteaConnection, cf *httpcli.Factory) (*GiteaSource, error) {
+	baseURL, err := url.Parse(c.Url)
+	if err != nil {
+		return nil, err
+	}
+	baseURL = extsvc.NormalizeBaseURL(baseURL)
+
+	if cf == nil {
+		cf = httpcli.NewExternalHTTPClientFactory()
+	}
+
+	cli, err := cf.Doer()
+	if err != nil {
+		return nil, err
+	}
+
+	var eb excludeBuilder
+	for _, r := range c.Exclude {
+		eb.Exact(r.Name)
+		eb.Exact(strconv.Itoa(r.Id))
+		eb.Pattern(r.Pattern)
+	}
+	exclude, err := eb.Build()
+	if err != nil {
+		return nil, err
+	}
+
+	return &GiteaSource{
+		svc:     svc,
+		config:  c,
+		exclude: exclude,
+		baseURL: baseURL,
+		client:  gitea.NewClient(baseURL, c.Token, cli),
+	}, nil
+}
+
+// ListRepos returns all Gitea repositories accessible to all connections configured
+// in Sourcegraph via the external services configuration.
+func (s GiteaSource) ListRepos(ctx context.Context, results chan SourceResult) {
+	s.listAllRepos(ctx, results)
+}
+
+// GetRepo returns the Gitea repository with the given "owner/name".
+func (s GiteaSource) GetRepo(ctx context.Context, nameWithOwner string) (*types.Repo, error) {
+	owner, name, err := splitGiteaNameWithOwner(nameWithOwner)
+	if err != nil {
+		return nil, err
+	}
+
+	repo, err := s.client.GetRepo(ctx, owner, name)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.makeRepo(repo), nil
+}
+
+// ExternalServices returns a singleton slice containing the external service.
+func (s GiteaSource) ExternalServices() types.ExternalServices {
+	return types.ExternalServices{s.svc}
+}
+
+func (s GiteaSource) makeRepo(repo *gitea.Repository) *types.Repo {
+	urn := s.svc.URN()
+	return &types.Repo{
+		Name: reposource.GiteaRepoName(
+			s.config.RepositoryPathPattern,
+			s.baseURL.Hostname(),
+			repo.FullName,
+		),
+		URI: string(reposource.GiteaRepoName(
+			"",
+			s.baseURL.Hostname(),
+			repo.FullName,
+		)),
+		ExternalRepo: gitea.ExternalRepoSpec(repo, *s.baseURL),
+		Description:  repo.Description,
+		Fork:         repo.Fork,
+		Archived:     repo.Archived,
+		Stars:        repo.Stars,
+		Private:      repo.Private,
+		Sources: map[string]*types.SourceInfo{
+			urn: {
+				ID:       urn,
+				CloneURL: s.remoteURL(repo),
+			},
+		},
+		Metadata: repo,
+	}
+}
+
+// remoteURL returns the repository's Git remote URL
+//
+// note: this used to contain credentials but that is no longer the case
+// if you need to get an authenticated clone url use repos.CloneURL
+func (s *GiteaSource) remoteURL(repo *gitea.Repository) string {
+	if s.config.GitURLType == "ssh" {
+		return repo.SSHURL // SSH authentication must be provided out-of-band
+	}
+	return repo.CloneURL
+}
+
+func (s *GiteaSource) excludes(r *gitea.Repository) bool {
+	return s.exclude(r.FullName) || s.exclude(strconv.FormatInt(r.ID, 10))
+}
+
+func (s *GiteaSource) listAllRepos(ctx context.Context, results chan SourceResult) {
+	type batch struct {
+		repos []*gitea.Repository
+		err   error
+	}
+
+	ch := make(chan batch)
+
+	var wg sync.WaitGroup
+
+	// List the repositories listed explicitly.
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+
+		// Admins normally add to end of lists, so end of list most likely has
+		// new repos => stream them first.
+		for i := len(s.config.Repos) - 1; i >= 0; i-- {
+			nameWithOwner := s.config.Repos[i]
+			owner, name, err := splitGiteaNameWithOwner(nameWithOwner)
+			if err != nil {
+				ch <- batch{err: err}
+				continue
+			}
+
+			repo, err := s.client.GetRepo(ctx, owner, name)
+			if err != nil {
+				if gitea.IsNotFound(err) {
+					log15.Warn("skipping missing gitea.repos entry:", "name", nameWithOwner, "err", err)
+					continue
+				}
+				ch <- batch{err: errors.Wrapf(err, "gitea.repos: item=%q", nameWithOwner)}
+				continue
+			}
+
+			ch <- batch{repos: []*gitea.Repository{repo}}
+		}
+	}()
+
+	// list pages through the results of the given list method.
+	list := func(item string, listPage func(page int) ([]*gitea.Repository, int, error)) {
+		for page := 1; page != 0; {
+			if err := ctx.Err(); err != nil {
+				ch <- batch{err: err}
+				return
+			}
+
+			repos, next, err := listPage(page)
+			if err != nil {
+				ch <- batch{err: errors.Wrapf(err, "%s: page=%d", item, page)}
+				return
+			}
+
+			ch <- batch{repos: repos}
+			page = next
+		}
+	}
+
+	// List the repositories of the organizations.
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+
+		for _, org := range s.config.Orgs {
+			org := org
+			list("gitea.orgs: item="+strconv.Quote(org), func(page int) ([]*gitea.Repository, int, error) {
+				return s.client.ListOrgRepos(ctx, org, page)
+			})
+		}
+	}()
+
+	// List the repositories matching the repository queries.
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+
+		for _, query := range s.config.RepositoryQuery {
+			query := query
+			item := "gitea.repositoryQuery: item=" + strconv.Quote(query)
+			switch query {
+			case "none":
+			case "affiliated":
+				list(item, func(page int) ([]*gitea.Repository, int, error) {
+					return s.client.ListUserRepos(ctx, page)
+				})
+			case "all":
+				list(item, func(page int) ([]*gitea.Repository, int, error) {
+					return s.client.SearchRepos(ctx, "", page)
+				})
+			default:
+				list(item, func(page int) ([]*gitea.Repository, int, error) {
+					return s.client.SearchRepos(ctx, query, page)
+				})
+			}
+		}
+	}()
+
+	go func() {
+		wg.Wait()
+		close(ch)
+	}()
+
+	seen := make(map[int64]bool)
+	for b := range ch {
+		if b.err != nil {
+			results <- SourceResult{Source: s, Err: b.err}
+			continue
+		}
+
+		for _, repo := range b.repos {
+			// Empty repositories can't be cloned.
+			if repo.Empty {
+				continue
+			}
+
+			if !seen[repo.ID] && !s.excludes(repo) {
+				results <- SourceResult{Source: s, Repo: s.makeRepo(repo)}
+				seen[repo.ID] = true
+			}
+		}
+	}
+}
+
+func splitGiteaNameWithOwner(nameWithOwner string) (owner, name string, err error) {
+	parts := strings.Split(nameWithOwner, "/")
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return "", "", errors.Errorf("invalid Gitea repository %q: expected owner/name", nameWithOwner)
+	}
+	return parts[0], parts[1], nil
+}
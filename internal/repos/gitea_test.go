@@ -0,0 +1,76 @@
+package repos
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/sourcegraph/sourcegraph/internal/extsvc"
+	"github.com/sourcegraph/sourcegraph/internal/types"
+	"github.com/sourcegraph/sourcegraph/schema"
+)
+
+func TestGiteaSource_ListRepos(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		repo := func(id int, fullName string, extra string) string {
+			return fmt.Sprintf(`{"id":%d,"full_name":%q,"clone_url":"http://%s/%s.git"%s}`, id, fullName, r.Host, fullName, extra)
+		}
+		switch r.URL.Path {
+		case "/api/v1/repos/alice/dotfiles":
+			fmt.Fprint(w, repo(1, "alice/dotfiles", ""))
+		case "/api/v1/orgs/myorg/repos":
+			fmt.Fprintf(w, "[%s,%s,%s]",
+				repo(2, "myorg/api", `,"private":true`),
+				repo(3, "myorg/empty", `,"empty":true`),
+				repo(4, "myorg/old", ""),
+			)
+		case "/api/v1/repos/search":
+			fmt.Fprintf(w, `{"ok":true,"data":[%s,%s]}`, repo(1, "alice/dotfiles", ""), repo(5, "bob/web", ""))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	svc := &types.ExternalService{
+		Kind: extsvc.KindGitea,
+		Config: marshalJSON(t, &schema.GiteaConnection{
+			Url:             srv.URL,
+			Token:           "secret",
+			Repos:           []string{"alice/dotfiles", "alice/missing"},
+			Orgs:            []string{"myorg"},
+			RepositoryQuery: []string{"web"},
+			Exclude:         []*schema.ExcludedGiteaRepo{{Id: 4}},
+		}),
+	}
+
+	src, err := NewGiteaSource(svc, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	repos, err := listAll(context.Background(), src)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var have []string
+	for _, r := range repos {
+		have = append(have, string(r.Name))
+	}
+	sort.Strings(have)
+
+	want := []string{
+		"127.0.0.1/alice/dotfiles",
+		"127.0.0.1/bob/web",
+		"127.0.0.1/myorg/api",
+	}
+	if diff := cmp.Diff(want, have); diff != "" {
+		t.Fatalf("unexpected repos (-want +have):\n%s", diff)
+	}
+}
@@ -0,0 +1,97 @@
+package repos
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/sourcegraph/sourcegraph/internal/api"
+	"github.com/sourcegraph/sourcegraph/internal/conf/reposource"
+	"github.com/sourcegraph/sourcegraph/internal/extsvc"
+	"github.com/sourcegraph/sourcegraph/internal/extsvc/gomodules"
+	"github.com/sourcegraph/sourcegraph/internal/jsonc"
+	"github.com/sourcegraph/sourcegraph/internal/types"
+	"github.com/sourcegraph/sourcegraph/schema"
+)
+
+// A GoModulesSource creates git repositories from the zip archives of Go
+// modules served by Go module proxies.
+type GoModulesSource struct {
+	svc    *types.ExternalService
+	config *schema.GoModulesConnection
+}
+
+// NewGoModulesSource returns a new GoModulesSource from the given external
+// service.
+func NewGoModulesSource(svc *types.ExternalService) (*GoModulesSource, error) {
+	var c schema.GoModulesConnection
+	if err := jsonc.Unmarshal(svc.Config, &c); err != nil {
+		return nil, fmt.Errorf("external service id=%d config error: %s", svc.ID, err)
+	}
+	return &GoModulesSource{svc: svc, config: &c}, nil
+}
+
+// ListRepos returns all Go modules accessible to all connections configured
+// in Sourcegraph via the external services configuration.
+func (s *GoModulesSource) ListRepos(ctx context.Context, results chan SourceResult) {
+	modules, err := GoModules(*s.config)
+	if err != nil {
+		results <- SourceResult{Err: err}
+		return
+	}
+	for _, module := range modules {
+		results <- SourceResult{
+			Source: s,
+			Repo:   s.makeRepo(module),
+		}
+	}
+}
+
+func (s *GoModulesSource) makeRepo(module reposource.GoModule) *types.Repo {
+	urn := s.svc.URN()
+	return &types.Repo{
+		Name: module.RepoName(),
+		URI:  string(module.RepoName()),
+		ExternalRepo: api.ExternalRepoSpec{
+			ID:          string(module.RepoName()),
+			ServiceID:   extsvc.TypeGoModules,
+			ServiceType: extsvc.TypeGoModules,
+		},
+		Private: false,
+		Sources: map[string]*types.SourceInfo{
+			urn: {
+				ID:       urn,
+				CloneURL: module.CloneURL(),
+			},
+		},
+		Metadata: &gomodules.Metadata{
+			Module: module,
+		},
+	}
+}
+
+// ExternalServices returns a singleton slice containing the external service.
+func (s *GoModulesSource) ExternalServices() types.ExternalServices {
+	return types.ExternalServices{s.svc}
+}
+
+// GoModules returns the modules of the dependencies of the given connection.
+// A dependency is either a module path, or a module path and a version.
+func GoModules(connection schema.GoModulesConnection) ([]reposource.GoModule, error) {
+	isAdded := make(map[reposource.GoModule]bool)
+	modules := []reposource.GoModule{}
+	for _, dep := range connection.Dependencies {
+		if i := strings.LastIndex(dep, "@"); i >= 0 {
+			dep = dep[:i]
+		}
+		module, err := reposource.ParseGoModule(dep)
+		if err != nil {
+			return nil, err
+		}
+		if !isAdded[module] {
+			modules = append(modules, module)
+		}
+		isAdded[module] = true
+	}
+	return modules, nil
+}
@@ -0,0 +1,117 @@
+package repos
+
+import (
+	"context"
+	"net/url"
+	"strings"
+
+	"github.com/cockroachdb/errors"
+
+	"github.com/sourcegraph/sourcegraph/internal/api"
+	"github.com/sourcegraph/sourcegraph/internal/conf/reposource"
+	"github.com/sourcegraph/sourcegraph/internal/extsvc"
+	"github.com/sourcegraph/sourcegraph/internal/extsvc/mercurial"
+	"github.com/sourcegraph/sourcegraph/internal/jsonc"
+	"github.com/sourcegraph/sourcegraph/internal/types"
+	"github.com/sourcegraph/sourcegraph/schema"
+)
+
+// A MercurialSource yields repositories from a single Mercurial connection
+// configured in Sourcegraph via the external services configuration. The
+// repositories are converted to Git repositories by gitserver.
+type MercurialSource struct {
+	svc    *types.ExternalService
+	config *schema.MercurialConnection
+	base   *url.URL
+}
+
+// NewMercurialSource returns a new MercurialSource from the given external
+// service.
+func NewMercurialSource(svc *types.ExternalService) (*MercurialSource, error) {
+	var c schema.MercurialConnection
+	if err := jsonc.Unmarshal(svc.Config, &c); err != nil {
+		return nil, errors.Errorf("external service id=%d config error: %s", svc.ID, err)
+	}
+	return newMercurialSource(svc, &c)
+}
+
+func newMercurialSource(svc *types.ExternalService, c *schema.MercurialConnection) (*MercurialSource, error) {
+	base, err := url.Parse(c.Url)
+	if err != nil {
+		return nil, errors.Wrap(err, "parsing Mercurial URL")
+	}
+	return &MercurialSource{
+		svc:    svc,
+		config: c,
+		base:   base,
+	}, nil
+}
+
+// ListRepos returns all Mercurial repositories configured in the connection.
+func (s MercurialSource) ListRepos(ctx context.Context, results chan SourceResult) {
+	for _, path := range s.config.Repos {
+		repo, err := s.makeRepo(path)
+		if err != nil {
+			results <- SourceResult{Source: s, Err: err}
+			continue
+		}
+		results <- SourceResult{Source: s, Repo: repo}
+	}
+}
+
+func (s MercurialSource) makeRepo(path string) (*types.Repo, error) {
+	path = strings.Trim(path, "/")
+	cloneURL, err := mercurialRepoURL(s.base, path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "parsing Mercurial repository path %q", path)
+	}
+
+	repoSource := reposource.Mercurial{MercurialConnection: s.config}
+	name, err := repoSource.CloneURLToRepoName(cloneURL.String())
+	if err != nil {
+		return nil, err
+	}
+	uri, err := repoSource.CloneURLToRepoURI(cloneURL.String())
+	if err != nil {
+		return nil, err
+	}
+
+	serviceID := *s.base
+	serviceID.User = nil
+	urn := s.svc.URN()
+
+	return &types.Repo{
+		Name: name,
+		URI:  uri,
+		ExternalRepo: api.ExternalRepoSpec{
+			ID:          path,
+			ServiceType: extsvc.TypeMercurial,
+			ServiceID:   extsvc.NormalizeBaseURL(&serviceID).String(),
+		},
+		Sources: map[string]*types.SourceInfo{
+			urn: {
+				ID:       urn,
+				CloneURL: cloneURL.String(),
+			},
+		},
+		Metadata: &mercurial.Repo{
+			Path: path,
+		},
+	}, nil
+}
+
+// mercurialRepoURL returns the URL of the repository at the given path
+// relative to base. Unlike url.URL.Parse, the last segment of base is kept
+// if it doesn't end with a slash.
+func mercurialRepoURL(base *url.URL, path string) (*url.URL, error) {
+	dir := *base
+	if !strings.HasSuffix(dir.Path, "/") {
+		dir.Path += "/"
+	}
+	return dir.Parse(strings.TrimPrefix(path, "/"))
+}
+
+// ExternalServices returns a singleton slice containing the external service.
+func (s MercurialSource) ExternalServices() types.ExternalServices {
+	return types.ExternalServices{s.svc}
+}
@@ -0,0 +1,80 @@
+package repos
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/sourcegraph/sourcegraph/internal/api"
+	"github.com/sourcegraph/sourcegraph/internal/extsvc"
+	"github.com/sourcegraph/sourcegraph/internal/extsvc/mercurial"
+	"github.com/sourcegraph/sourcegraph/internal/types"
+	"github.com/sourcegraph/sourcegraph/schema"
+)
+
+func TestMercurialSource_ListRepos(t *testing.T) {
+	conf := &schema.MercurialConnection{
+		Url:      "https://hg.example.com/repos",
+		Username: "admin",
+		Password: "secret",
+		Repos:    []string{"legacy", "/project/tools/"},
+	}
+	svc := &types.ExternalService{
+		ID:     1,
+		Kind:   extsvc.KindMercurial,
+		Config: marshalJSON(t, conf),
+	}
+
+	src, err := newMercurialSource(svc, conf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	repos, err := listAll(context.Background(), src)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	type repo struct {
+		Name     api.RepoName
+		External api.ExternalRepoSpec
+		CloneURL string
+		Metadata interface{}
+	}
+	var have []repo
+	for _, r := range repos {
+		have = append(have, repo{
+			Name:     r.Name,
+			External: r.ExternalRepo,
+			CloneURL: r.Sources[svc.URN()].CloneURL,
+			Metadata: r.Metadata,
+		})
+	}
+
+	want := []repo{
+		{
+			Name: "hg.example.com/repos/legacy",
+			External: api.ExternalRepoSpec{
+				ID:          "legacy",
+				ServiceType: extsvc.TypeMercurial,
+				ServiceID:   "https://hg.example.com/repos/",
+			},
+			CloneURL: "https://hg.example.com/repos/legacy",
+			Metadata: &mercurial.Repo{Path: "legacy"},
+		},
+		{
+			Name: "hg.example.com/repos/project/tools",
+			External: api.ExternalRepoSpec{
+				ID:          "project/tools",
+				ServiceType: extsvc.TypeMercurial,
+				ServiceID:   "https://hg.example.com/repos/",
+			},
+			CloneURL: "https://hg.example.com/repos/project/tools",
+			Metadata: &mercurial.Repo{Path: "project/tools"},
+		},
+	}
+	if diff := cmp.Diff(want, have); diff != "" {
+		t.Fatalf("mismatch (-want +have):\n%s", diff)
+	}
+}
@@ -74,6 +74,11 @@ var (
 		Help: "Incremented each time the scheduler updates a repository due to user traffic.",
 	})
 
+	schedWebhookFetch = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "src_repoupdater_sched_webhook_fetch",
+		Help: "Incremented each time the scheduler updates a repository due to a code host push webhook.",
+	})
+
 	schedKnownRepos = promauto.NewGauge(prometheus.GaugeOpts{
 		Name: "src_repoupdater_sched_known_repos",
 		Help: "The number of repositories that are managed by the scheduler.",
@@ -83,6 +88,11 @@ var (
 		Name: "src_repoupdater_sched_update_queue_length",
 		Help: "The number of repositories that are currently queued for update",
 	})
+
+	schedPersistError = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "src_repoupdater_sched_persist_error",
+		Help: "Incremented each time we encounter an error persisting the update schedule.",
+	})
 )
 
 func MustRegisterMetrics(db dbutil.DB, sourcegraphDotCom bool) {
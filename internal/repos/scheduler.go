@@ -8,6 +8,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/cockroachdb/errors"
 	"github.com/inconshreveable/log15"
 
 	"github.com/sourcegraph/sourcegraph/internal/api"
@@ -59,6 +60,9 @@ func RunScheduler(ctx context.Context, scheduler *updateScheduler) {
 		ctx2, stop = context.WithCancel(ctx)
 
 		go scheduler.runUpdateLoop(ctx2)
+		if scheduler.store != nil {
+			go scheduler.runPersistLoop(ctx2)
+		}
 		if want.autoGitUpdatesEnabled {
 			go scheduler.runScheduleLoop(ctx2)
 		}
@@ -83,6 +87,18 @@ const (
 
 	// maxDelay is the maximum amount of time between scheduled updates for a single repository.
 	maxDelay = 8 * time.Hour
+
+	// persistInterval is the amount of time between writes of the schedule to the store.
+	persistInterval = 10 * time.Second
+
+	// webhookInterval is the amount of time between scheduled updates for a
+	// repository whose code host notifies us of pushes via webhooks. Polling
+	// is only a fallback for missed webhooks in that case.
+	webhookInterval = maxDelay
+
+	// webhookTTL is how long a repository is considered to receive webhooks
+	// after the last one was received.
+	webhookTTL = 24 * time.Hour
 )
 
 // updateScheduler schedules repo update (or clone) requests to gitserver.
@@ -103,9 +119,22 @@ const (
 //
 // A worker continuously dequeues repos and sends updates to gitserver, but its concurrency
 // is limited by the gitMaxConcurrentClones site configuration.
+//
+// If the scheduler has a store, the schedule is periodically persisted to it. The
+// persisted schedule is restored on startup, so that repos keep their intervals
+// across restarts instead of all being fetched at once.
 type updateScheduler struct {
 	updateQueue *updateQueue
 	schedule    *schedule
+	store       scheduleStore
+}
+
+// A scheduleStore persists the schedule of an updateScheduler. It is
+// implemented by Store.
+type scheduleStore interface {
+	ListRepoUpdateSchedules(ctx context.Context) ([]*RepoUpdateSchedule, error)
+	UpsertRepoUpdateSchedules(ctx context.Context, schedules []*RepoUpdateSchedule) error
+	DeleteRepoUpdateSchedules(ctx context.Context, ids []api.RepoID) error
 }
 
 // A configuredRepo represents the configuration data for a given repo from
@@ -121,18 +150,100 @@ type configuredRepo struct {
 // non-blocking sends.
 const notifyChanBuffer = 1
 
-// NewUpdateScheduler returns a new scheduler.
-func NewUpdateScheduler() *updateScheduler {
-	return &updateScheduler{
+// NewUpdateScheduler returns a new scheduler. The schedule is persisted to the
+// given store, unless it is nil.
+func NewUpdateScheduler(store scheduleStore) *updateScheduler {
+	s := &updateScheduler{
 		updateQueue: &updateQueue{
 			index:         make(map[api.RepoID]*repoUpdate),
 			notifyEnqueue: make(chan struct{}, notifyChanBuffer),
 		},
 		schedule: &schedule{
-			index:  make(map[api.RepoID]*scheduledRepoUpdate),
-			wakeup: make(chan struct{}, notifyChanBuffer),
+			index:    make(map[api.RepoID]*scheduledRepoUpdate),
+			restored: make(map[api.RepoID]*RepoUpdateSchedule),
+			wakeup:   make(chan struct{}, notifyChanBuffer),
 		},
+		store: store,
+	}
+	if store != nil {
+		s.schedule.changed = make(map[api.RepoID]struct{})
+	}
+	return s
+}
+
+// Restore loads the persisted schedule from the store. Repos that are added
+// to the scheduler afterwards resume their persisted schedule instead of
+// being scheduled as new repos. It should be called before any repos are
+// added to the scheduler.
+func (s *updateScheduler) Restore(ctx context.Context) error {
+	if s.store == nil {
+		return nil
+	}
+
+	schedules, err := s.store.ListRepoUpdateSchedules(ctx)
+	if err != nil {
+		return errors.Wrap(err, "listing repo update schedules")
+	}
+
+	s.schedule.mu.Lock()
+	defer s.schedule.mu.Unlock()
+
+	for _, sched := range schedules {
+		s.schedule.restored[sched.RepoID] = sched
+	}
+	return nil
+}
+
+// runPersistLoop periodically writes the changes of the schedule to the store.
+func (s *updateScheduler) runPersistLoop(ctx context.Context) {
+	for {
+		select {
+		case <-time.After(persistInterval):
+		case <-ctx.Done():
+			return
+		}
+
+		if err := s.persist(ctx); err != nil {
+			schedPersistError.Inc()
+			log15.Warn("error persisting repo update schedule", "err", err)
+		}
+	}
+}
+
+// persist writes the schedule of all repos whose schedule changed since the
+// last call to the store. Repos that were removed from the schedule are
+// deleted from the store.
+func (s *updateScheduler) persist(ctx context.Context) error {
+	var (
+		upserts []*RepoUpdateSchedule
+		deletes []api.RepoID
+	)
+
+	s.schedule.mu.Lock()
+	changed := s.schedule.changed
+	s.schedule.changed = make(map[api.RepoID]struct{}, len(changed))
+	for id := range changed {
+		if update := s.schedule.index[id]; update != nil {
+			upserts = append(upserts, update.state())
+		} else {
+			deletes = append(deletes, id)
+		}
+	}
+	s.schedule.mu.Unlock()
+
+	err := s.store.UpsertRepoUpdateSchedules(ctx, upserts)
+	if err == nil {
+		err = s.store.DeleteRepoUpdateSchedules(ctx, deletes)
+	}
+	if err != nil {
+		// Try again on the next call.
+		s.schedule.mu.Lock()
+		for id := range changed {
+			s.schedule.markChanged(id)
+		}
+		s.schedule.mu.Unlock()
 	}
+	return err
 }
 
 // runScheduleLoop starts the loop that schedules updates by enqueuing them into the updateQueue.
@@ -165,6 +276,7 @@ func (s *updateScheduler) runSchedule() {
 		s.updateQueue.enqueue(repoUpdate.Repo, priorityLow)
 		repoUpdate.Due = timeNow().Add(repoUpdate.Interval)
 		heap.Fix(s.schedule, 0)
+		s.schedule.markChanged(repoUpdate.Repo.ID)
 	}
 }
 
@@ -202,8 +314,13 @@ func (s *updateScheduler) runUpdateLoop(ctx context.Context) {
 					schedError.Inc()
 					log15.Warn("error requesting repo update", "uri", repo.Name, "err", err)
 				}
+				s.schedule.setLastResult(repo, resp, err)
 				if interval := getCustomInterval(conf.Get(), string(repo.Name)); interval > 0 {
 					s.schedule.updateInterval(repo, interval)
+				} else if s.schedule.receivesWebhooks(repo) {
+					// Pushes to the repo trigger updates, so we only poll to
+					// catch up on webhooks that we missed.
+					s.schedule.updateInterval(repo, webhookInterval)
 				} else if err != nil {
 					// On error we will double the current interval so that we back off and don't
 					// get stuck with problematic repos with low intervals.
@@ -376,6 +493,19 @@ func (s *updateScheduler) UpdateOnce(id api.RepoID, name api.RepoName) {
 	s.updateQueue.enqueue(repo, priorityHigh)
 }
 
+// UpdateFromWebhook causes a single update of the given repository, because
+// its code host notified us of a push to it. The repo is polled less
+// frequently for as long as it keeps receiving webhooks.
+func (s *updateScheduler) UpdateFromWebhook(id api.RepoID, name api.RepoName) {
+	repo := configuredRepo{
+		ID:   id,
+		Name: name,
+	}
+	schedWebhookFetch.Inc()
+	s.schedule.webhookReceived(repo)
+	s.updateQueue.enqueue(repo, priorityHigh)
+}
+
 // DebugDump returns the state of the update scheduler for debugging.
 func (s *updateScheduler) DebugDump(ctx context.Context, db dbutil.DB) interface{} {
 	data := struct {
@@ -445,6 +575,11 @@ func (s *updateScheduler) ScheduleInfo(id api.RepoID) *protocol.RepoUpdateSchedu
 			Total:           len(s.schedule.index),
 			IntervalSeconds: int(update.Interval / time.Second),
 			Due:             update.Due,
+			LastError:       update.LastError,
+		}
+		if !update.LastUpdatedAt.IsZero() {
+			lastUpdatedAt := update.LastUpdatedAt
+			result.Schedule.LastUpdatedAt = &lastUpdatedAt
 		}
 	}
 	s.schedule.mu.Unlock()
@@ -652,6 +787,14 @@ type schedule struct {
 	heap  []*scheduledRepoUpdate // min heap of scheduledRepoUpdates based on their due time.
 	index map[api.RepoID]*scheduledRepoUpdate
 
+	// restored holds the schedule of repos that was restored from the store
+	// or kept across a reset, until the repos are added to the schedule again.
+	restored map[api.RepoID]*RepoUpdateSchedule
+
+	// changed is the set of repos whose schedule changed since it was last
+	// persisted. It is nil if the schedule isn't persisted.
+	changed map[api.RepoID]struct{}
+
 	// timer sends a value on the wakeup channel when it is time
 	timer  *time.Timer
 	wakeup chan struct{}
@@ -659,10 +802,55 @@ type schedule struct {
 
 // scheduledRepoUpdate is the update schedule for a single repo.
 type scheduledRepoUpdate struct {
-	Repo     configuredRepo // the repo to update
-	Interval time.Duration  // how regularly the repo is updated
-	Due      time.Time      // the next time that the repo will be enqueued for a update
-	Index    int            `json:"-"` // the index in the heap
+	Repo          configuredRepo // the repo to update
+	Interval      time.Duration  // how regularly the repo is updated
+	Due           time.Time      // the next time that the repo will be enqueued for a update
+	LastUpdatedAt time.Time      // the last time that an update of the repo finished
+	LastError     string         // the error of the last update, if it failed
+	LastWebhookAt time.Time      // the last time that a push webhook was received for the repo
+	Index         int            `json:"-"` // the index in the heap
+}
+
+// state returns the state of the update that is persisted.
+func (u *scheduledRepoUpdate) state() *RepoUpdateSchedule {
+	return &RepoUpdateSchedule{
+		RepoID:        u.Repo.ID,
+		Interval:      u.Interval,
+		Due:           u.Due,
+		LastUpdatedAt: u.LastUpdatedAt,
+		LastError:     u.LastError,
+		LastWebhookAt: u.LastWebhookAt,
+	}
+}
+
+// newUpdate returns the update of a repo that is added to the schedule. The
+// repo resumes its restored schedule if there is one, otherwise it is due at
+// the given time.
+// The caller must hold the lock on s.mu.
+func (s *schedule) newUpdate(repo configuredRepo, due time.Time) *scheduledRepoUpdate {
+	update := &scheduledRepoUpdate{
+		Repo:     repo,
+		Interval: minDelay,
+		Due:      due,
+	}
+	if restored, ok := s.restored[repo.ID]; ok {
+		delete(s.restored, repo.ID)
+		update.Interval = restored.Interval
+		update.Due = restored.Due
+		update.LastUpdatedAt = restored.LastUpdatedAt
+		update.LastError = restored.LastError
+		update.LastWebhookAt = restored.LastWebhookAt
+	}
+	s.markChanged(repo.ID)
+	return update
+}
+
+// markChanged marks the schedule of the repo for being persisted.
+// The caller must hold the lock on s.mu.
+func (s *schedule) markChanged(id api.RepoID) {
+	if s.changed != nil {
+		s.changed[id] = struct{}{}
+	}
 }
 
 // upsert inserts or updates a repo in the schedule.
@@ -679,11 +867,7 @@ func (s *schedule) upsert(repo configuredRepo) (updated bool) {
 		return true
 	}
 
-	heap.Push(s, &scheduledRepoUpdate{
-		Repo:     repo,
-		Interval: minDelay,
-		Due:      timeNow().Add(minDelay),
-	})
+	heap.Push(s, s.newUpdate(repo, timeNow().Add(minDelay)))
 
 	s.rescheduleTimer()
 
@@ -716,6 +900,7 @@ func (s *schedule) prioritiseUncloned(names []string) {
 		if repoUpdate.Due.After(notClonedDue) {
 			repoUpdate.Due = notClonedDue
 			heap.Fix(s, repoUpdate.Index)
+			s.markChanged(repoUpdate.Repo.ID)
 			rescheduleTimer = true
 		}
 	}
@@ -751,11 +936,7 @@ func (s *schedule) insertNew(repos []types.RepoName) {
 		if update := s.index[repo.ID]; update != nil {
 			continue
 		}
-		heap.Push(s, &scheduledRepoUpdate{
-			Repo:     repo,
-			Interval: minDelay,
-			Due:      due,
-		})
+		heap.Push(s, s.newUpdate(repo, due))
 		rescheduleTimer = true
 	}
 
@@ -784,11 +965,59 @@ func (s *schedule) updateInterval(repo configuredRepo, interval time.Duration) {
 		update.Due = timeNow().Add(update.Interval)
 		log15.Debug("updated repo", "repo", repo.Name, "due", update.Due.Sub(timeNow()))
 		heap.Fix(s, update.Index)
+		s.markChanged(repo.ID)
 		s.rescheduleTimer()
 	}
 	s.mu.Unlock()
 }
 
+// setLastResult records the result of the last update of a repo in the schedule.
+// It does nothing if the repo is not in the schedule.
+func (s *schedule) setLastResult(repo configuredRepo, resp *gitserverprotocol.RepoUpdateResponse, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	update := s.index[repo.ID]
+	if update == nil {
+		return
+	}
+
+	update.LastUpdatedAt = timeNow()
+	switch {
+	case err != nil:
+		update.LastError = err.Error()
+	case resp != nil:
+		update.LastError = resp.Error
+	default:
+		update.LastError = ""
+	}
+	s.markChanged(repo.ID)
+}
+
+// webhookReceived records that a push webhook was received for a repo in the
+// schedule. It is persisted with the schedule, so that the repo keeps being
+// treated as receiving webhooks after a restart. It does nothing if the repo
+// is not in the schedule.
+func (s *schedule) webhookReceived(repo configuredRepo) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if update := s.index[repo.ID]; update != nil {
+		update.LastWebhookAt = timeNow()
+		s.markChanged(repo.ID)
+	}
+}
+
+// receivesWebhooks returns true if a push webhook was received for the repo
+// within webhookTTL.
+func (s *schedule) receivesWebhooks(repo configuredRepo) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	update := s.index[repo.ID]
+	return update != nil && !update.LastWebhookAt.IsZero() && timeNow().Sub(update.LastWebhookAt) < webhookTTL
+}
+
 // getCurrentInterval gets the current interval for the supplied repo and a bool
 // indicating whether it was found.
 func (s *schedule) getCurrentInterval(repo configuredRepo) (time.Duration, bool) {
@@ -811,11 +1040,17 @@ func (s *schedule) remove(repo configuredRepo) (removed bool) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
+	if _, ok := s.restored[repo.ID]; ok {
+		delete(s.restored, repo.ID)
+		s.markChanged(repo.ID)
+	}
+
 	update := s.index[repo.ID]
 	if update == nil {
 		return false
 	}
 
+	s.markChanged(repo.ID)
 	reschedule := update.Index == 0
 	if heap.Remove(s, update.Index); reschedule {
 		s.rescheduleTimer()
@@ -844,6 +1079,15 @@ func (s *schedule) reset() {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
+	// Keep the schedule of the repos, so that they resume it when they are
+	// added to the schedule again.
+	for id, update := range s.index {
+		s.restored[id] = update.state()
+	}
+	if s.changed != nil {
+		s.changed = map[api.RepoID]struct{}{}
+	}
+
 	s.heap = s.heap[:0]
 	s.index = map[api.RepoID]*scheduledRepoUpdate{}
 	s.wakeup = make(chan struct{}, notifyChanBuffer)
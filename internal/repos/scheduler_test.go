@@ -7,6 +7,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/cockroachdb/errors"
 	"github.com/davecgh/go-spew/spew"
 	"github.com/google/go-cmp/cmp"
 
@@ -14,6 +15,7 @@ import (
 	"github.com/sourcegraph/sourcegraph/internal/conf"
 	gitserverprotocol "github.com/sourcegraph/sourcegraph/internal/gitserver/protocol"
 	"github.com/sourcegraph/sourcegraph/internal/mutablelimiter"
+	"github.com/sourcegraph/sourcegraph/internal/repoupdater/protocol"
 	"github.com/sourcegraph/sourcegraph/internal/types"
 	"github.com/sourcegraph/sourcegraph/schema"
 )
@@ -274,7 +276,7 @@ func TestUpdateQueue_enqueue(t *testing.T) {
 			r, stop := startRecording()
 			defer stop()
 
-			s := NewUpdateScheduler()
+			s := NewUpdateScheduler(nil)
 
 			for _, call := range test.calls {
 				s.updateQueue.enqueue(call.repo, call.priority)
@@ -440,7 +442,7 @@ func TestUpdateQueue_remove(t *testing.T) {
 			r, stop := startRecording()
 			defer stop()
 
-			s := NewUpdateScheduler()
+			s := NewUpdateScheduler(nil)
 			setupInitialQueue(s, test.initialQueue)
 
 			// Perform the removals.
@@ -512,7 +514,7 @@ func TestUpdateQueue_acquireNext(t *testing.T) {
 			r, stop := startRecording()
 			defer stop()
 
-			s := NewUpdateScheduler()
+			s := NewUpdateScheduler(nil)
 			setupInitialQueue(s, test.initialQueue)
 
 			// Test aquireNext.
@@ -642,7 +644,7 @@ func Test_updateScheduler_UpdateFromDiff(t *testing.T) {
 			_, stop := startRecording()
 			defer stop()
 
-			s := NewUpdateScheduler()
+			s := NewUpdateScheduler(nil)
 			setupInitialSchedule(s, test.initialSchedule)
 			setupInitialQueue(s, test.initialQueue)
 
@@ -788,7 +790,7 @@ func TestSchedule_upsert(t *testing.T) {
 			r, stop := startRecording()
 			defer stop()
 
-			s := NewUpdateScheduler()
+			s := NewUpdateScheduler(nil)
 			setupInitialSchedule(s, test.initialSchedule)
 
 			for _, call := range test.upsertCalls {
@@ -810,7 +812,7 @@ func TestUpdateQueue_PrioritiseUncloned(t *testing.T) {
 	_, stop := startRecording()
 	defer stop()
 
-	s := NewUpdateScheduler()
+	s := NewUpdateScheduler(nil)
 
 	assertFront := func(name api.RepoName) {
 		t.Helper()
@@ -843,7 +845,7 @@ func TestScheduleInsertNew(t *testing.T) {
 	_, stop := startRecording()
 	defer stop()
 
-	s := NewUpdateScheduler()
+	s := NewUpdateScheduler(nil)
 
 	assertFront := func(name api.RepoName) {
 		t.Helper()
@@ -1028,7 +1030,7 @@ func TestSchedule_updateInterval(t *testing.T) {
 			r, stop := startRecording()
 			defer stop()
 
-			s := NewUpdateScheduler()
+			s := NewUpdateScheduler(nil)
 			setupInitialSchedule(s, test.initialSchedule)
 
 			for _, call := range test.updateCalls {
@@ -1126,7 +1128,7 @@ func TestSchedule_remove(t *testing.T) {
 			r, stop := startRecording()
 			defer stop()
 
-			s := NewUpdateScheduler()
+			s := NewUpdateScheduler(nil)
 			setupInitialSchedule(s, test.initialSchedule)
 
 			for _, call := range test.removeCalls {
@@ -1288,7 +1290,7 @@ func TestUpdateScheduler_runSchedule(t *testing.T) {
 			r, stop := startRecording()
 			defer stop()
 
-			s := NewUpdateScheduler()
+			s := NewUpdateScheduler(nil)
 
 			setupInitialSchedule(s, test.initialSchedule)
 
@@ -1376,7 +1378,59 @@ func TestUpdateScheduler_runUpdateLoop(t *testing.T) {
 				},
 			},
 			finalSchedule: []*scheduledRepoUpdate{
-				{Repo: a, Interval: time.Minute, Due: defaultTime.Add(time.Minute)},
+				{Repo: a, Interval: time.Minute, Due: defaultTime.Add(time.Minute), LastUpdatedAt: defaultTime},
+			},
+			timeAfterFuncDelays: []time.Duration{time.Minute},
+			expectedNotifications: func(s *updateScheduler) []chan struct{} {
+				return []chan struct{}{s.schedule.wakeup}
+			},
+		},
+		{
+			name:                   "schedule of repo receiving webhooks updated",
+			gitMaxConcurrentClones: 1,
+			initialSchedule: []*scheduledRepoUpdate{
+				{Repo: a, Interval: time.Hour, Due: defaultTime.Add(time.Hour), LastWebhookAt: defaultTime.Add(-time.Hour)},
+			},
+			initialQueue: []*repoUpdate{
+				{Repo: a, Seq: 1},
+			},
+			mockRequestRepoUpdates: []*mockRequestRepoUpdate{
+				{
+					repo: a,
+					resp: &gitserverprotocol.RepoUpdateResponse{
+						LastFetched: timePtr(defaultTime.Add(2 * time.Minute)),
+						LastChanged: timePtr(defaultTime),
+					},
+				},
+			},
+			finalSchedule: []*scheduledRepoUpdate{
+				{Repo: a, Interval: webhookInterval, Due: defaultTime.Add(webhookInterval), LastUpdatedAt: defaultTime, LastWebhookAt: defaultTime.Add(-time.Hour)},
+			},
+			timeAfterFuncDelays: []time.Duration{webhookInterval},
+			expectedNotifications: func(s *updateScheduler) []chan struct{} {
+				return []chan struct{}{s.schedule.wakeup}
+			},
+		},
+		{
+			name:                   "schedule of repo no longer receiving webhooks updated",
+			gitMaxConcurrentClones: 1,
+			initialSchedule: []*scheduledRepoUpdate{
+				{Repo: a, Interval: webhookInterval, Due: defaultTime.Add(webhookInterval), LastWebhookAt: defaultTime.Add(-webhookTTL)},
+			},
+			initialQueue: []*repoUpdate{
+				{Repo: a, Seq: 1},
+			},
+			mockRequestRepoUpdates: []*mockRequestRepoUpdate{
+				{
+					repo: a,
+					resp: &gitserverprotocol.RepoUpdateResponse{
+						LastFetched: timePtr(defaultTime.Add(2 * time.Minute)),
+						LastChanged: timePtr(defaultTime),
+					},
+				},
+			},
+			finalSchedule: []*scheduledRepoUpdate{
+				{Repo: a, Interval: time.Minute, Due: defaultTime.Add(time.Minute), LastUpdatedAt: defaultTime, LastWebhookAt: defaultTime.Add(-webhookTTL)},
 			},
 			timeAfterFuncDelays: []time.Duration{time.Minute},
 			expectedNotifications: func(s *updateScheduler) []chan struct{} {
@@ -1419,7 +1473,7 @@ func TestUpdateScheduler_runUpdateLoop(t *testing.T) {
 			}
 			defer func() { requestRepoUpdate = nil }()
 
-			s := NewUpdateScheduler()
+			s := NewUpdateScheduler(nil)
 
 			// unbuffer the channel
 			s.updateQueue.notifyEnqueue = make(chan struct{})
@@ -1460,6 +1514,50 @@ func TestUpdateScheduler_runUpdateLoop(t *testing.T) {
 	}
 }
 
+func TestUpdateScheduler_UpdateFromWebhook(t *testing.T) {
+	a := configuredRepo{ID: 1, Name: "a"}
+	b := configuredRepo{ID: 2, Name: "b"}
+
+	r, stop := startRecording()
+	defer stop()
+
+	s := NewUpdateScheduler(nil)
+	setupInitialSchedule(s, []*scheduledRepoUpdate{
+		{Repo: a, Interval: time.Hour, Due: defaultTime.Add(time.Hour)},
+	})
+
+	if s.schedule.receivesWebhooks(a) {
+		t.Fatal("expected repo not to receive webhooks before the first one")
+	}
+
+	s.UpdateFromWebhook(a.ID, a.Name)
+	// Repos that aren't in the schedule are still updated.
+	s.UpdateFromWebhook(b.ID, b.Name)
+
+	if !s.schedule.receivesWebhooks(a) {
+		t.Fatal("expected repo to receive webhooks")
+	}
+	if s.schedule.receivesWebhooks(b) {
+		t.Fatal("expected repo that isn't in the schedule not to receive webhooks")
+	}
+
+	mockTime(defaultTime.Add(webhookTTL))
+	if s.schedule.receivesWebhooks(a) {
+		t.Fatal("expected repo not to receive webhooks after webhookTTL")
+	}
+
+	verifySchedule(t, s, []*scheduledRepoUpdate{
+		{Repo: a, Interval: time.Hour, Due: defaultTime.Add(time.Hour), LastWebhookAt: defaultTime},
+	})
+	verifyQueue(t, s, []*repoUpdate{
+		{Repo: a, Priority: priorityHigh, Seq: 1},
+		{Repo: b, Priority: priorityHigh, Seq: 2},
+	})
+	verifyRecording(t, s, nil, func(s *updateScheduler) []chan struct{} {
+		return []chan struct{}{s.updateQueue.notifyEnqueue, s.updateQueue.notifyEnqueue}
+	}, r)
+}
+
 func verifyRecording(t *testing.T, s *updateScheduler, timeAfterFuncDelays []time.Duration, expectedNotifications func(s *updateScheduler) []chan struct{}, r *recording) {
 	if !reflect.DeepEqual(timeAfterFuncDelays, r.timeAfterFuncDelays) {
 		t.Fatalf("\nexpected timeAfterFuncDelays\n%s\ngot\n%s", spew.Sdump(timeAfterFuncDelays), spew.Sdump(r.timeAfterFuncDelays))
@@ -1594,3 +1692,163 @@ func TestGetCustomInterval(t *testing.T) {
 		})
 	}
 }
+
+// fakeScheduleStore is an in-memory scheduleStore.
+type fakeScheduleStore struct {
+	schedules map[api.RepoID]*RepoUpdateSchedule
+	err       error
+}
+
+func (s *fakeScheduleStore) ListRepoUpdateSchedules(ctx context.Context) ([]*RepoUpdateSchedule, error) {
+	var schedules []*RepoUpdateSchedule
+	for _, sched := range s.schedules {
+		schedules = append(schedules, sched)
+	}
+	return schedules, s.err
+}
+
+func (s *fakeScheduleStore) UpsertRepoUpdateSchedules(ctx context.Context, schedules []*RepoUpdateSchedule) error {
+	if s.err != nil {
+		return s.err
+	}
+	for _, sched := range schedules {
+		s.schedules[sched.RepoID] = sched
+	}
+	return nil
+}
+
+func (s *fakeScheduleStore) DeleteRepoUpdateSchedules(ctx context.Context, ids []api.RepoID) error {
+	if s.err != nil {
+		return s.err
+	}
+	for _, id := range ids {
+		delete(s.schedules, id)
+	}
+	return nil
+}
+
+func TestUpdateScheduler_persist(t *testing.T) {
+	a := configuredRepo{ID: 1, Name: "a"}
+	b := configuredRepo{ID: 2, Name: "b"}
+
+	_, stop := startRecording()
+	defer stop()
+
+	ctx := context.Background()
+	store := &fakeScheduleStore{schedules: map[api.RepoID]*RepoUpdateSchedule{}}
+	s := NewUpdateScheduler(store)
+
+	assertPersisted := func(t *testing.T, want map[api.RepoID]*RepoUpdateSchedule) {
+		t.Helper()
+		if diff := cmp.Diff(want, store.schedules); diff != "" {
+			t.Fatalf("persisted schedule mismatch (-want +got):\n%s", diff)
+		}
+	}
+
+	s.schedule.upsert(a)
+	s.schedule.upsert(b)
+	if err := s.persist(ctx); err != nil {
+		t.Fatal(err)
+	}
+	assertPersisted(t, map[api.RepoID]*RepoUpdateSchedule{
+		a.ID: {RepoID: a.ID, Interval: minDelay, Due: defaultTime.Add(minDelay)},
+		b.ID: {RepoID: b.ID, Interval: minDelay, Due: defaultTime.Add(minDelay)},
+	})
+
+	s.schedule.updateInterval(a, time.Hour)
+	s.schedule.setLastResult(a, &gitserverprotocol.RepoUpdateResponse{Error: "fetch failed"}, nil)
+	s.schedule.webhookReceived(a)
+	s.schedule.remove(b)
+	if err := s.persist(ctx); err != nil {
+		t.Fatal(err)
+	}
+	want := map[api.RepoID]*RepoUpdateSchedule{
+		a.ID: {
+			RepoID:        a.ID,
+			Interval:      time.Hour,
+			Due:           defaultTime.Add(time.Hour),
+			LastUpdatedAt: defaultTime,
+			LastError:     "fetch failed",
+			LastWebhookAt: defaultTime,
+		},
+	}
+	assertPersisted(t, want)
+
+	// Changes are persisted with the next call if persisting fails.
+	store.err = errors.New("boom")
+	s.schedule.updateInterval(a, 2*time.Hour)
+	if err := s.persist(ctx); err == nil {
+		t.Fatal("expected error")
+	}
+	assertPersisted(t, want)
+
+	store.err = nil
+	if err := s.persist(ctx); err != nil {
+		t.Fatal(err)
+	}
+	want[a.ID].Interval = 2 * time.Hour
+	want[a.ID].Due = defaultTime.Add(2 * time.Hour)
+	assertPersisted(t, want)
+}
+
+func TestUpdateScheduler_Restore(t *testing.T) {
+	a := types.RepoName{ID: 1, Name: "a"}
+	b := types.RepoName{ID: 2, Name: "b"}
+
+	_, stop := startRecording()
+	defer stop()
+
+	store := &fakeScheduleStore{schedules: map[api.RepoID]*RepoUpdateSchedule{
+		a.ID: {
+			RepoID:        a.ID,
+			Interval:      time.Hour,
+			Due:           defaultTime.Add(30 * time.Minute),
+			LastUpdatedAt: defaultTime.Add(-30 * time.Minute),
+			LastError:     "fetch failed",
+			LastWebhookAt: defaultTime.Add(-time.Hour),
+		},
+	}}
+	s := NewUpdateScheduler(store)
+	if err := s.Restore(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []*scheduledRepoUpdate{
+		{Repo: configuredRepo{ID: b.ID, Name: b.Name}, Interval: minDelay, Due: defaultTime.Add(minDelay)},
+		{
+			Repo:          configuredRepo{ID: a.ID, Name: a.Name},
+			Interval:      time.Hour,
+			Due:           defaultTime.Add(30 * time.Minute),
+			LastUpdatedAt: defaultTime.Add(-30 * time.Minute),
+			LastError:     "fetch failed",
+			LastWebhookAt: defaultTime.Add(-time.Hour),
+		},
+	}
+
+	s.schedule.insertNew([]types.RepoName{a, b})
+
+	// Repos that received webhooks before the restart are still treated as
+	// receiving them.
+	if !s.schedule.receivesWebhooks(configuredRepo{ID: a.ID, Name: a.Name}) {
+		t.Fatal("expected restored repo to receive webhooks")
+	}
+
+	info := s.ScheduleInfo(a.ID)
+	wantInfo := &protocol.RepoScheduleState{
+		Index:           1,
+		Total:           2,
+		IntervalSeconds: 3600,
+		Due:             defaultTime.Add(30 * time.Minute),
+		LastUpdatedAt:   timePtr(defaultTime.Add(-30 * time.Minute)),
+		LastError:       "fetch failed",
+	}
+	if diff := cmp.Diff(wantInfo, info.Schedule); diff != "" {
+		t.Fatalf("schedule info mismatch (-want +got):\n%s", diff)
+	}
+
+	// Repos resume their schedule when they are added again after a reset.
+	s.schedule.reset()
+	s.schedule.insertNew([]types.RepoName{a, b})
+
+	verifySchedule(t, s, want)
+}
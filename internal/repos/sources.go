@@ -60,6 +60,8 @@ func NewSource(svc *types.ExternalService, cf *httpcli.Factory) (Source, error)
 		return NewGithubSource(svc, cf)
 	case extsvc.KindGitLab:
 		return NewGitLabSource(svc, cf)
+	case extsvc.KindGitea:
+		return NewGiteaSource(svc, cf)
 	case extsvc.KindBitbucketServer:
 		return NewBitbucketServerSource(svc, cf)
 	case extsvc.KindBitbucketCloud:
@@ -70,10 +72,18 @@ func NewSource(svc *types.ExternalService, cf *httpcli.Factory) (Source, error)
 		return NewPhabricatorSource(svc, cf)
 	case extsvc.KindAWSCodeCommit:
 		return NewAWSCodeCommitSource(svc, cf)
+	case extsvc.KindAzureDevOps:
+		return NewAzureDevOpsSource(svc, cf)
+	case extsvc.KindMercurial:
+		return NewMercurialSource(svc)
 	case extsvc.KindPerforce:
 		return NewPerforceSource(svc)
 	case extsvc.KindJVMPackages:
 		return NewJVMPackagesSource(svc)
+	case extsvc.KindNPMPackages:
+		return NewNPMPackagesSource(svc, cf)
+	case extsvc.KindGoModules:
+		return NewGoModulesSource(svc)
 	case extsvc.KindOther:
 		return NewOtherSource(svc, cf)
 	default:
@@ -584,6 +584,137 @@ func scanJobs(rows *sql.Rows) ([]SyncJob, error) {
 	return jobs, nil
 }
 
+// RepoUpdateSchedule is the persisted state of the update schedule of a
+// single repository. See updateScheduler for how the schedule is computed.
+type RepoUpdateSchedule struct {
+	RepoID        api.RepoID
+	Interval      time.Duration
+	Due           time.Time
+	LastUpdatedAt time.Time // zero if the repo hasn't been updated yet
+	LastError     string    // empty if the last update succeeded
+	LastWebhookAt time.Time // zero if no push webhook was received for the repo
+}
+
+// ListRepoUpdateSchedules returns the persisted update schedules of all repos
+// that are not deleted.
+func (s *Store) ListRepoUpdateSchedules(ctx context.Context) ([]*RepoUpdateSchedule, error) {
+	rows, err := s.Query(ctx, sqlf.Sprintf(listRepoUpdateSchedulesQuery))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var schedules []*RepoUpdateSchedule
+	for rows.Next() {
+		var (
+			sched           RepoUpdateSchedule
+			intervalSeconds int
+		)
+		if err := rows.Scan(
+			&sched.RepoID,
+			&intervalSeconds,
+			&sched.Due,
+			&dbutil.NullTime{Time: &sched.LastUpdatedAt},
+			&dbutil.NullString{S: &sched.LastError},
+			&dbutil.NullTime{Time: &sched.LastWebhookAt},
+		); err != nil {
+			return nil, err
+		}
+		sched.Interval = time.Duration(intervalSeconds) * time.Second
+		schedules = append(schedules, &sched)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return schedules, nil
+}
+
+const listRepoUpdateSchedulesQuery = `
+SELECT
+	s.repo_id,
+	s.interval_seconds,
+	s.due_at,
+	s.last_updated_at,
+	s.last_error,
+	s.last_webhook_at
+FROM repo_update_schedule s
+JOIN repo ON repo.id = s.repo_id
+WHERE repo.deleted_at IS NULL
+`
+
+// repoUpdateSchedulesBatchSize is the maximum number of schedules written in
+// a single query, which keeps us well below the Postgres parameter limit.
+const repoUpdateSchedulesBatchSize = 1000
+
+// UpsertRepoUpdateSchedules persists the given update schedules, replacing
+// the previously persisted schedules of the same repos. Schedules of repos
+// that no longer exist are skipped.
+func (s *Store) UpsertRepoUpdateSchedules(ctx context.Context, schedules []*RepoUpdateSchedule) error {
+	for len(schedules) > 0 {
+		batch := schedules
+		if len(batch) > repoUpdateSchedulesBatchSize {
+			batch = batch[:repoUpdateSchedulesBatchSize]
+		}
+		schedules = schedules[len(batch):]
+
+		values := make([]*sqlf.Query, 0, len(batch))
+		for _, sched := range batch {
+			values = append(values, sqlf.Sprintf(
+				"(%s::integer, %s::integer, %s::timestamptz, %s::timestamptz, %s::text, %s::timestamptz)",
+				sched.RepoID,
+				int(sched.Interval/time.Second),
+				sched.Due,
+				nullTimeColumn(sched.LastUpdatedAt),
+				dbutil.NewNullString(sched.LastError),
+				nullTimeColumn(sched.LastWebhookAt),
+			))
+		}
+
+		q := sqlf.Sprintf(upsertRepoUpdateSchedulesQueryFmtstr, sqlf.Join(values, ",\n"))
+		if err := s.Exec(ctx, q); err != nil {
+			return errors.Wrap(err, "upserting repo update schedules")
+		}
+	}
+	return nil
+}
+
+const upsertRepoUpdateSchedulesQueryFmtstr = `
+INSERT INTO repo_update_schedule (repo_id, interval_seconds, due_at, last_updated_at, last_error, last_webhook_at)
+SELECT v.repo_id, v.interval_seconds, v.due_at, v.last_updated_at, v.last_error, v.last_webhook_at
+FROM (VALUES %s) AS v (repo_id, interval_seconds, due_at, last_updated_at, last_error, last_webhook_at)
+JOIN repo ON repo.id = v.repo_id
+ON CONFLICT (repo_id) DO UPDATE SET
+	interval_seconds = excluded.interval_seconds,
+	due_at = excluded.due_at,
+	last_updated_at = excluded.last_updated_at,
+	last_error = excluded.last_error,
+	last_webhook_at = excluded.last_webhook_at,
+	updated_at = now()
+`
+
+// DeleteRepoUpdateSchedules deletes the persisted update schedules of the
+// given repos.
+func (s *Store) DeleteRepoUpdateSchedules(ctx context.Context, ids []api.RepoID) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	set := make(pq.Int64Array, 0, len(ids))
+	for _, id := range ids {
+		set = append(set, int64(id))
+	}
+
+	return s.Exec(ctx, sqlf.Sprintf(`DELETE FROM repo_update_schedule WHERE repo_id = ANY(%s)`, set))
+}
+
+func nullTimeColumn(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
+
 func metadataColumn(metadata interface{}) (msg json.RawMessage, err error) {
 	switch m := metadata.(type) {
 	case nil:
@@ -261,6 +261,71 @@ func testStoreEnqueueSingleSyncJob(store *repos.Store) func(*testing.T) {
 	}
 }
 
+func testStoreRepoUpdateSchedules(store *repos.Store) func(*testing.T) {
+	return func(t *testing.T) {
+		ctx := context.Background()
+		now := time.Now().Truncate(time.Microsecond)
+
+		transact(ctx, store, func(t testing.TB, tx *repos.Store) {
+			stored := mkRepos(3, &types.Repo{
+				Name: "github.com/foo/bar",
+				ExternalRepo: api.ExternalRepoSpec{
+					ID:          "bar",
+					ServiceType: extsvc.TypeGitHub,
+					ServiceID:   "https://github.com/",
+				},
+			})
+			if err := tx.RepoStore.Create(ctx, stored...); err != nil {
+				t.Fatal(err)
+			}
+
+			schedules := []*repos.RepoUpdateSchedule{
+				{RepoID: stored[0].ID, Interval: time.Minute, Due: now.Add(time.Minute)},
+				{RepoID: stored[1].ID, Interval: time.Hour, Due: now.Add(time.Hour), LastUpdatedAt: now, LastError: "boom"},
+				{RepoID: stored[2].ID, Interval: time.Hour, Due: now.Add(time.Hour), LastUpdatedAt: now, LastWebhookAt: now},
+				// Schedules of repos that don't exist are skipped.
+				{RepoID: 9999, Interval: time.Hour, Due: now},
+			}
+			if err := tx.UpsertRepoUpdateSchedules(ctx, schedules); err != nil {
+				t.Fatal(err)
+			}
+
+			assertSchedules := func(t testing.TB, want []*repos.RepoUpdateSchedule) {
+				t.Helper()
+
+				have, err := tx.ListRepoUpdateSchedules(ctx)
+				if err != nil {
+					t.Fatal(err)
+				}
+				sort.Slice(have, func(i, j int) bool { return have[i].RepoID < have[j].RepoID })
+				if diff := cmp.Diff(want, have); diff != "" {
+					t.Fatalf("schedules mismatch (-want +have):\n%s", diff)
+				}
+			}
+			assertSchedules(t, schedules[:3])
+
+			// Upserting replaces the persisted schedule.
+			schedules[1].Interval = 2 * time.Hour
+			schedules[1].LastError = ""
+			if err := tx.UpsertRepoUpdateSchedules(ctx, schedules[1:2]); err != nil {
+				t.Fatal(err)
+			}
+			assertSchedules(t, schedules[:3])
+
+			// Schedules of deleted repos aren't listed.
+			if err := tx.RepoStore.Delete(ctx, stored[2].ID); err != nil {
+				t.Fatal(err)
+			}
+			assertSchedules(t, schedules[:2])
+
+			if err := tx.DeleteRepoUpdateSchedules(ctx, []api.RepoID{stored[0].ID}); err != nil {
+				t.Fatal(err)
+			}
+			assertSchedules(t, schedules[1:2])
+		})(t)
+	}
+}
+
 func mkRepos(n int, base ...*types.Repo) types.Repos {
 	if len(base) == 0 {
 		return nil
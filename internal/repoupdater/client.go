@@ -140,10 +140,30 @@ func (c *Client) EnqueueRepoUpdate(ctx context.Context, repo api.RepoName) (*pro
 		return MockEnqueueRepoUpdate(ctx, repo)
 	}
 
-	req := &protocol.RepoUpdateRequest{
+	return c.enqueueRepoUpdate(ctx, &protocol.RepoUpdateRequest{
 		Repo: repo,
+	})
+}
+
+// MockEnqueueRepoUpdateFromWebhook mocks (*Client).EnqueueRepoUpdateFromWebhook for tests.
+var MockEnqueueRepoUpdateFromWebhook func(ctx context.Context, repo api.RepoName) (*protocol.RepoUpdateResponse, error)
+
+// EnqueueRepoUpdateFromWebhook requests that the named repository be updated
+// in the near future, because the code host notified us of a push to it. The
+// scheduler polls repositories that receive push webhooks less frequently. It
+// does not wait for the update.
+func (c *Client) EnqueueRepoUpdateFromWebhook(ctx context.Context, repo api.RepoName) (*protocol.RepoUpdateResponse, error) {
+	if MockEnqueueRepoUpdateFromWebhook != nil {
+		return MockEnqueueRepoUpdateFromWebhook(ctx, repo)
 	}
 
+	return c.enqueueRepoUpdate(ctx, &protocol.RepoUpdateRequest{
+		Repo:        repo,
+		FromWebhook: true,
+	})
+}
+
+func (c *Client) enqueueRepoUpdate(ctx context.Context, req *protocol.RepoUpdateRequest) (*protocol.RepoUpdateResponse, error) {
 	resp, err := c.httpPost(ctx, "enqueue-repo-update", req)
 	if err != nil {
 		return nil, err
@@ -157,7 +177,7 @@ func (c *Client) EnqueueRepoUpdate(ctx context.Context, repo api.RepoName) (*pro
 
 	var res protocol.RepoUpdateResponse
 	if resp.StatusCode == http.StatusNotFound {
-		return nil, &repoNotFoundError{string(repo), string(bs)}
+		return nil, &repoNotFoundError{string(req.Repo), string(bs)}
 	} else if resp.StatusCode < 200 || resp.StatusCode >= 400 {
 		return nil, errors.New(string(bs))
 	} else if err = json.Unmarshal(bs, &res); err != nil {
@@ -26,6 +26,11 @@ type RepoScheduleState struct {
 	Total           int
 	IntervalSeconds int
 	Due             time.Time
+	// LastUpdatedAt is the time the last scheduled update of the repo
+	// finished, or nil if the repo hasn't been updated yet.
+	LastUpdatedAt *time.Time `json:",omitempty"`
+	// LastError is the error of the last update, if it failed.
+	LastError string `json:",omitempty"`
 }
 
 type RepoQueueState struct {
@@ -126,9 +131,16 @@ type RepoLinks struct {
 // RepoUpdateRequest is a request to update the contents of a given repo, or clone it if it doesn't exist.
 type RepoUpdateRequest struct {
 	Repo api.RepoName `json:"repo"`
+
+	// FromWebhook is true if the update was requested because the code host
+	// notified us of a push to the repo.
+	FromWebhook bool `json:"fromWebhook,omitempty"`
 }
 
 func (a *RepoUpdateRequest) String() string {
+	if a.FromWebhook {
+		return fmt.Sprintf("RepoUpdateRequest{%s, webhook}", a.Repo)
+	}
 	return fmt.Sprintf("RepoUpdateRequest{%s}", a.Repo)
 }
 
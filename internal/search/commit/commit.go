@@ -73,6 +73,7 @@ func ResolveCommitParameters(ctx context.Context, tp *search.TextParameters) (*s
 		ExcludePattern:               old.ExcludePattern,
 		PathPatternsAreRegExps:       true,
 		PathPatternsAreCaseSensitive: old.PathPatternsAreCaseSensitive,
+		Languages:                    old.Languages,
 	}
 	repos, err := tp.RepoPromise.Get(ctx)
 	if err != nil {
@@ -202,6 +203,7 @@ func commitParametersToDiffParameters(ctx context.Context, db dbutil.DB, op *sea
 				ExcludePattern:  op.PatternInfo.ExcludePattern,
 				IsCaseSensitive: op.PatternInfo.PathPatternsAreCaseSensitive,
 				IsRegExp:        op.PatternInfo.PathPatternsAreRegExps,
+				Languages:       op.PatternInfo.Languages,
 			},
 			Diff:              op.Diff,
 			OnlyMatchingHunks: true,
@@ -29,11 +29,12 @@ const (
 	FieldMessage   = "message"
 
 	// Temporary experimental fields:
-	FieldIndex     = "index"
-	FieldCount     = "count" // Searches that specify `count:` will fetch at least that number of results, or the full result set
-	FieldTimeout   = "timeout"
-	FieldCombyRule = "rule"
-	FieldSelect    = "select"
+	FieldIndex        = "index"
+	FieldCount        = "count" // Searches that specify `count:` will fetch at least that number of results, or the full result set
+	FieldTimeout      = "timeout"
+	FieldCombyRule    = "rule"
+	FieldCombyRewrite = "rewrite" // Structural searches that specify `rewrite:` return diffs of the rewritten matches
+	FieldSelect       = "select"
 )
 
 var allFields = map[string]struct{}{
@@ -69,6 +70,7 @@ var allFields = map[string]struct{}{
 	FieldCount:              empty,
 	FieldTimeout:            empty,
 	FieldCombyRule:          empty,
+	FieldCombyRewrite:       empty,
 	FieldRev:                empty,
 	"revision":              empty,
 	FieldSelect:             empty,
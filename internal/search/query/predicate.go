@@ -5,6 +5,8 @@ import (
 	"strings"
 
 	"github.com/cockroachdb/errors"
+
+	"github.com/sourcegraph/sourcegraph/internal/search/filter"
 )
 
 type Predicate interface {
@@ -31,10 +33,12 @@ var DefaultPredicateRegistry = predicateRegistry{
 		"contains.file":         func() Predicate { return &RepoContainsFilePredicate{} },
 		"contains.content":      func() Predicate { return &RepoContainsContentPredicate{} },
 		"contains.commit.after": func() Predicate { return &RepoContainsCommitAfterPredicate{} },
+		"contains.symbol":       func() Predicate { return &RepoContainsSymbolPredicate{} },
 	},
 	FieldFile: {
 		"contains.content": func() Predicate { return &FileContainsContentPredicate{} },
 		"contains":         func() Predicate { return &FileContainsContentPredicate{} },
+		"contains.symbol":  func() Predicate { return &FileContainsSymbolPredicate{} },
 	},
 }
 
@@ -278,6 +282,133 @@ func (f *FileContainsContentPredicate) Plan(parent Basic) (Plan, error) {
 	return ToPlan(Dnf(nodes))
 }
 
+/* repo:contains.symbol(...) and file:contains.symbol(...) */
+
+// ContainsSymbolParams are the parameters shared by the symbol containment
+// predicates. Pattern is a regular expression matched against symbol names,
+// and Kind optionally restricts matches to a symbol kind, as in
+// `contains.symbol(newClient kind:function)`.
+type ContainsSymbolParams struct {
+	Pattern string
+	Kind    string
+}
+
+func (c *ContainsSymbolParams) parseParams(params string) error {
+	nodes, err := Parse(params, SearchTypeRegex)
+	if err != nil {
+		return err
+	}
+
+	for _, node := range nodes {
+		if err := c.parseNode(node); err != nil {
+			return err
+		}
+	}
+
+	if c.Pattern == "" {
+		return errors.New("contains.symbol argument should specify a symbol name")
+	}
+	if _, err := regexp.Compile(c.Pattern); err != nil {
+		return errors.Errorf("contains.symbol argument: %w", err)
+	}
+	return nil
+}
+
+func (c *ContainsSymbolParams) parseNode(n Node) error {
+	switch v := n.(type) {
+	case Parameter:
+		if v.Negated {
+			return errors.New("predicates do not currently support negated values")
+		}
+		if strings.ToLower(v.Field) != "kind" {
+			return errors.Errorf("unsupported option %q", v.Field)
+		}
+		if c.Kind != "" {
+			return errors.New("cannot specify kind multiple times")
+		}
+		if _, err := filter.SelectPathFromString(filter.Symbol + "." + v.Value); err != nil {
+			return errors.Errorf("invalid symbol kind %q", v.Value)
+		}
+		c.Kind = v.Value
+	case Pattern:
+		if c.Pattern != "" {
+			return errors.New("cannot specify symbol name multiple times")
+		}
+		c.Pattern = v.Value
+	case Operator:
+		if v.Kind == Or {
+			return errors.New("predicates do not currently support 'or' queries")
+		}
+		for _, operand := range v.Operands {
+			if err := c.parseNode(operand); err != nil {
+				return err
+			}
+		}
+	default:
+		return errors.Errorf("unsupported node type %T", n)
+	}
+	return nil
+}
+
+// plan returns a symbol search scoped to the repos of parent. The selected
+// symbol kind ensures only files defining a matching symbol are returned.
+func (c *ContainsSymbolParams) plan(parent Basic) (Plan, error) {
+	selectValue := filter.Symbol
+	if c.Kind != "" {
+		selectValue += "." + c.Kind
+	}
+
+	nodes := make([]Node, 0, 4)
+	nodes = append(nodes, Parameter{
+		Field: FieldCount,
+		Value: "99999",
+	}, Parameter{
+		Field: FieldType,
+		Value: "symbol",
+	}, Parameter{
+		Field: FieldSelect,
+		Value: selectValue,
+	}, Pattern{
+		Value:      c.Pattern,
+		Annotation: Annotation{Labels: Regexp},
+	})
+
+	nodes = append(nodes, nonPredicateRepos(parent)...)
+	return ToPlan(Dnf(nodes))
+}
+
+// RepoContainsSymbolPredicate represents the `repo:contains.symbol()`
+// predicate, which filters to repos that define a matching symbol.
+type RepoContainsSymbolPredicate struct {
+	ContainsSymbolParams
+}
+
+func (f *RepoContainsSymbolPredicate) ParseParams(params string) error {
+	return f.parseParams(params)
+}
+
+func (f *RepoContainsSymbolPredicate) Field() string { return FieldRepo }
+func (f *RepoContainsSymbolPredicate) Name() string  { return "contains.symbol" }
+func (f *RepoContainsSymbolPredicate) Plan(parent Basic) (Plan, error) {
+	return f.plan(parent)
+}
+
+// FileContainsSymbolPredicate represents the `file:contains.symbol()`
+// predicate, which filters to files that define a matching symbol.
+type FileContainsSymbolPredicate struct {
+	ContainsSymbolParams
+}
+
+func (f *FileContainsSymbolPredicate) ParseParams(params string) error {
+	return f.parseParams(params)
+}
+
+func (f *FileContainsSymbolPredicate) Field() string { return FieldFile }
+func (f *FileContainsSymbolPredicate) Name() string  { return "contains.symbol" }
+func (f *FileContainsSymbolPredicate) Plan(parent Basic) (Plan, error) {
+	return f.plan(parent)
+}
+
 // nonPredicateRepos returns the repo nodes in a query that aren't predicates,
 // respecting parameters that determine repo results.
 func nonPredicateRepos(q Basic) []Node {
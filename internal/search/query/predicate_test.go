@@ -55,6 +55,88 @@ func TestRepoContainsPredicate(t *testing.T) {
 	})
 }
 
+func TestContainsSymbolPredicate(t *testing.T) {
+	t.Run("ParseParams", func(t *testing.T) {
+		type test struct {
+			name     string
+			params   string
+			expected *FileContainsSymbolPredicate
+		}
+
+		valid := []test{
+			{`name`, `newClient`, &FileContainsSymbolPredicate{ContainsSymbolParams{Pattern: "newClient"}}},
+			{`name regex`, `^new.*Client$`, &FileContainsSymbolPredicate{ContainsSymbolParams{Pattern: "^new.*Client$"}}},
+			{`name and kind`, `newClient kind:function`, &FileContainsSymbolPredicate{ContainsSymbolParams{Pattern: "newClient", Kind: "function"}}},
+			{`kind and name`, `kind:class Client`, &FileContainsSymbolPredicate{ContainsSymbolParams{Pattern: "Client", Kind: "class"}}},
+		}
+
+		for _, tc := range valid {
+			t.Run(tc.name, func(t *testing.T) {
+				p := &FileContainsSymbolPredicate{}
+				err := p.ParseParams(tc.params)
+				if err != nil {
+					t.Fatalf("unexpected error: %s", err)
+				}
+
+				if !reflect.DeepEqual(tc.expected, p) {
+					t.Fatalf("expected %#v, got %#v", tc.expected, p)
+				}
+			})
+		}
+
+		invalid := []test{
+			{`empty`, ``, nil},
+			{`only kind`, `kind:function`, nil},
+			{`unknown kind`, `foo kind:gadget`, nil},
+			{`negated kind`, `foo -kind:function`, nil},
+			{`multiple kinds`, `foo kind:function kind:class`, nil},
+			{`unsupported syntax`, `foo abc:test`, nil},
+			{`or`, `foo or bar`, nil},
+		}
+
+		for _, tc := range invalid {
+			t.Run(tc.name, func(t *testing.T) {
+				p := &FileContainsSymbolPredicate{}
+				err := p.ParseParams(tc.params)
+				if err == nil {
+					t.Fatal("expected error but got none")
+				}
+			})
+		}
+	})
+
+	t.Run("Plan", func(t *testing.T) {
+		parent, err := ParseRegexp(`repo:foo file:contains.symbol(newClient kind:function)`)
+		if err != nil {
+			t.Fatal(err)
+		}
+		basic, err := ToBasicQuery(parent)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		p := &FileContainsSymbolPredicate{ContainsSymbolParams{Pattern: "newClient", Kind: "function"}}
+		plan, err := p.Plan(basic)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(plan) != 1 {
+			t.Fatalf("expected a single query in plan, got %d", len(plan))
+		}
+		tree := plan[0].ToParseTree()
+		for field, want := range map[string]string{
+			FieldType:   "symbol",
+			FieldSelect: "symbol.function",
+			FieldRepo:   "foo",
+		} {
+			if got, _ := tree.StringValue(field); got != want {
+				t.Errorf("expected %s:%s, got %s:%s", field, want, field, got)
+			}
+		}
+	})
+}
+
 func TestParseAsPredicate(t *testing.T) {
 	tests := []struct {
 		input  string
@@ -412,7 +412,8 @@ func (q Q) valueToTypedValue(field, value string, label labels) []*Value {
 		FieldIndex,
 		FieldCount,
 		FieldTimeout,
-		FieldCombyRule:
+		FieldCombyRule,
+		FieldCombyRewrite:
 		return []*Value{{String: &value}}
 	}
 	return []*Value{{String: &value}}
@@ -326,7 +326,8 @@ func validateField(field, value string, negated bool, seen map[string]struct{})
 		FieldCount:
 		return satisfies(isSingular, isNumber, isNotNegated)
 	case
-		FieldCombyRule:
+		FieldCombyRule,
+		FieldCombyRewrite:
 		return satisfies(isSingular, isNotNegated)
 	case
 		FieldTimeout:
@@ -421,6 +422,25 @@ func validateTypeStructural(nodes []Node) error {
 	return nil
 }
 
+func validateRewrite(nodes []Node) error {
+	seenStructural := false
+	seenRewrite := false
+	VisitParameter(nodes, func(field, _ string, _ bool, _ Annotation) {
+		if field == FieldCombyRewrite {
+			seenRewrite = true
+		}
+	})
+	VisitPattern(nodes, func(_ string, _ bool, annotation Annotation) {
+		if annotation.Labels.IsSet(Structural) {
+			seenStructural = true
+		}
+	})
+	if seenRewrite && !seenStructural {
+		return errors.Errorf("your query contains the field '%s', which requires patterntype:structural in the query", FieldCombyRewrite)
+	}
+	return nil
+}
+
 // validatePredicates validates predicate parameters with respect to their validation logic.
 func validatePredicates(nodes []Node) error {
 	var err error
@@ -532,6 +552,7 @@ func validate(nodes []Node) error {
 		validateCommitParameters,
 		validatePredicates,
 		validateTypeStructural,
+		validateRewrite,
 	)
 }
 
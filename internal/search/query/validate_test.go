@@ -109,6 +109,10 @@ func TestAndOrQuery_Validation(t *testing.T) {
 			want:       "this structural search query specifies `type:` and is not supported. Structural search syntax only applies to searching file contents and is not currently supported for diff searches",
 			searchType: SearchTypeStructural,
 		},
+		{
+			input: "foo(:[x]) rewrite:bar(:[x])",
+			want:  "your query contains the field 'rewrite', which requires patterntype:structural in the query",
+		},
 	}
 	for _, c := range cases {
 		t.Run("validate and/or query", func(t *testing.T) {
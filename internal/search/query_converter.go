@@ -151,6 +151,7 @@ func ToTextPatternInfo(q query.Basic, p Protocol, transform query.BasicPass) *Te
 		Languages:                    langInclude,
 		PathPatternsAreCaseSensitive: q.IsCaseSensitive(),
 		CombyRule:                    q.FindValue(query.FieldCombyRule),
+		CombyRewrite:                 q.FindValue(query.FieldCombyRewrite),
 		Index:                        q.Index(),
 		Select:                       selector,
 	}
@@ -46,6 +46,15 @@ type FileMatch struct {
 	LineMatches []*LineMatch
 	Symbols     []*SymbolMatch `json:"-"`
 
+	// MatchRanges are the ranges of the matches in LineMatches. A match that
+	// spans multiple lines has a LineMatch for each line but a single range.
+	// It is only set for matches that report ranges.
+	MatchRanges []Range
+
+	// Diff is a unified diff of the file's content after rewriting the
+	// matches of a structural search with a `rewrite:` template.
+	Diff string
+
 	LimitHit bool
 }
 
@@ -75,6 +84,7 @@ func (fm *FileMatch) Select(selectPath filter.SelectPath) Match {
 		}
 	case filter.File:
 		fm.LineMatches = nil
+		fm.MatchRanges = nil
 		fm.Symbols = nil
 		if len(selectPath) > 1 && selectPath[1] == "directory" {
 			fm.Path = path.Clean(path.Dir(fm.Path)) + "/" // Add trailing slash for clarity.
@@ -83,6 +93,7 @@ func (fm *FileMatch) Select(selectPath filter.SelectPath) Match {
 	case filter.Symbol:
 		if len(fm.Symbols) > 0 {
 			fm.LineMatches = nil // Only return symbol match if symbols exist
+			fm.MatchRanges = nil
 			if len(selectPath) > 1 {
 				filteredSymbols := SelectSymbolKind(fm.Symbols, selectPath[1])
 				if len(filteredSymbols) == 0 {
@@ -110,7 +121,11 @@ func (fm *FileMatch) Select(selectPath filter.SelectPath) Match {
 // counts and limit.
 func (fm *FileMatch) AppendMatches(src *FileMatch) {
 	fm.LineMatches = append(fm.LineMatches, src.LineMatches...)
+	fm.MatchRanges = append(fm.MatchRanges, src.MatchRanges...)
 	fm.Symbols = append(fm.Symbols, src.Symbols...)
+	if fm.Diff == "" {
+		fm.Diff = src.Diff
+	}
 	fm.LimitHit = fm.LimitHit || src.LimitHit
 }
 
@@ -132,6 +147,7 @@ func (fm *FileMatch) Limit(limit int) int {
 			fm.Symbols = nil
 			fm.LineMatches = fm.LineMatches[:i+1]
 			m.OffsetAndLengths = m.OffsetAndLengths[:limit]
+			fm.limitMatchRanges(m.LineNumber, m.OffsetAndLengths[limit-1][0])
 			return 0
 		}
 		limit = after
@@ -141,6 +157,20 @@ func (fm *FileMatch) Limit(limit int) int {
 	return 0
 }
 
+// limitMatchRanges drops the match ranges that start after the last match
+// kept by Limit, which starts at the given line and column. A range starts
+// where the first line match of its match does, so this keeps exactly the
+// ranges of the kept line matches.
+func (fm *FileMatch) limitMatchRanges(lineNumber, column int32) {
+	kept := fm.MatchRanges[:0]
+	for _, r := range fm.MatchRanges {
+		if r.Start.Line < lineNumber || (r.Start.Line == lineNumber && r.Start.Column <= column) {
+			kept = append(kept, r)
+		}
+	}
+	fm.MatchRanges = kept
+}
+
 func (fm *FileMatch) Key() Key {
 	return Key{
 		TypeRank: rankFileMatch,
@@ -156,3 +186,18 @@ type LineMatch struct {
 	OffsetAndLengths [][2]int32
 	LineNumber       int32
 }
+
+// Range is the range of a match in a file, from Start up to but excluding End.
+type Range struct {
+	Start Location
+	End   Location
+}
+
+// Location is a position in a file. Offset is in bytes from the start of the
+// file, Line is 0-based and Column is the 0-based offset in characters from
+// the start of the line.
+type Location struct {
+	Offset int32
+	Line   int32
+	Column int32
+}
@@ -0,0 +1,84 @@
+package result
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestFileMatch_Limit(t *testing.T) {
+	rng := func(startLine, startColumn, endLine, endColumn int32) Range {
+		return Range{
+			Start: Location{Line: startLine, Column: startColumn},
+			End:   Location{Line: endLine, Column: endColumn},
+		}
+	}
+
+	// Three matches: "foo" on line 0, "bar\nbaz" spanning lines 1 and 2, and
+	// "qux" right after it on line 2.
+	newFileMatch := func() *FileMatch {
+		return &FileMatch{
+			LineMatches: []*LineMatch{
+				{LineNumber: 0, OffsetAndLengths: [][2]int32{{4, 3}}},
+				{LineNumber: 1, OffsetAndLengths: [][2]int32{{2, 3}}},
+				{LineNumber: 2, OffsetAndLengths: [][2]int32{{0, 3}, {5, 3}}},
+			},
+			MatchRanges: []Range{
+				rng(0, 4, 0, 7),
+				rng(1, 2, 2, 3),
+				rng(2, 5, 2, 8),
+			},
+		}
+	}
+
+	tests := []struct {
+		limit           int
+		wantLineMatches []*LineMatch
+		wantRanges      []Range
+	}{
+		{
+			limit: 1,
+			wantLineMatches: []*LineMatch{
+				{LineNumber: 0, OffsetAndLengths: [][2]int32{{4, 3}}},
+			},
+			wantRanges: []Range{rng(0, 4, 0, 7)},
+		},
+		{
+			// The match spanning lines 1 and 2 is cut after its first line,
+			// but its range is kept.
+			limit: 2,
+			wantLineMatches: []*LineMatch{
+				{LineNumber: 0, OffsetAndLengths: [][2]int32{{4, 3}}},
+				{LineNumber: 1, OffsetAndLengths: [][2]int32{{2, 3}}},
+			},
+			wantRanges: []Range{rng(0, 4, 0, 7), rng(1, 2, 2, 3)},
+		},
+		{
+			// Both the line matches and the ranges are truncated within line
+			// 2, so the range of "qux" is dropped along with its line match.
+			limit: 3,
+			wantLineMatches: []*LineMatch{
+				{LineNumber: 0, OffsetAndLengths: [][2]int32{{4, 3}}},
+				{LineNumber: 1, OffsetAndLengths: [][2]int32{{2, 3}}},
+				{LineNumber: 2, OffsetAndLengths: [][2]int32{{0, 3}}},
+			},
+			wantRanges: []Range{rng(0, 4, 0, 7), rng(1, 2, 2, 3)},
+		},
+		{
+			limit:           4,
+			wantLineMatches: newFileMatch().LineMatches,
+			wantRanges:      newFileMatch().MatchRanges,
+		},
+	}
+
+	for _, tc := range tests {
+		fm := newFileMatch()
+		fm.Limit(tc.limit)
+		if diff := cmp.Diff(tc.wantLineMatches, fm.LineMatches); diff != "" {
+			t.Errorf("limit %d: unexpected line matches (-want +got):\n%s", tc.limit, diff)
+		}
+		if diff := cmp.Diff(tc.wantRanges, fm.MatchRanges); diff != "" {
+			t.Errorf("limit %d: unexpected match ranges (-want +got):\n%s", tc.limit, diff)
+		}
+	}
+}
@@ -26,6 +26,10 @@ type Symbol struct {
 	Signature  string
 	Pattern    string
 
+	// EndLine is the last line of the symbol, or 0 if the parser that
+	// extracted it only records where symbols start, like ctags does.
+	EndLine int
+
 	FileLimited bool
 }
 
@@ -65,6 +65,7 @@ func Search(ctx context.Context, searcherURLs *endpoint.Map, repo api.RepoName,
 		"FetchTimeout":    []string{fetchTimeout.String()},
 		"Languages":       p.Languages,
 		"CombyRule":       []string{p.CombyRule},
+		"CombyRewrite":    []string{p.CombyRewrite},
 
 		"PathPatternsAreRegExps": []string{"true"},
 		"IndexerEndpoints":       indexerEndpoints,
@@ -25,6 +25,14 @@ type EventContentMatch struct {
 	Version    string   `json:"version,omitempty"`
 
 	LineMatches []EventLineMatch `json:"lineMatches"`
+
+	// MatchRanges are the ranges of the matches in LineMatches. A match that
+	// spans multiple lines has a single range.
+	MatchRanges []EventRange `json:"matchRanges,omitempty"`
+
+	// Diff is a unified diff of the file's content after rewriting the
+	// matches of a structural search with a `rewrite:` template.
+	Diff string `json:"diff,omitempty"`
 }
 
 func (e *EventContentMatch) eventMatch() {}
@@ -53,6 +61,20 @@ type EventLineMatch struct {
 	OffsetAndLengths [][2]int32 `json:"offsetAndLengths"`
 }
 
+// EventRange is the range of a match, from Start up to but excluding End.
+type EventRange struct {
+	Start EventLocation `json:"start"`
+	End   EventLocation `json:"end"`
+}
+
+// EventLocation is a position in a file. Line is 0-based and Column is the
+// 0-based offset in characters from the start of the line.
+type EventLocation struct {
+	Offset int32 `json:"offset"`
+	Line   int32 `json:"line"`
+	Column int32 `json:"column"`
+}
+
 // EventRepoMatch is a subset of zoekt.FileMatch for our Event API.
 type EventRepoMatch struct {
 	// Type is always RepoMatchType. Included here for marshalling.
@@ -0,0 +1,154 @@
+package symbol
+
+import (
+	"context"
+	"regexp"
+	"sort"
+	"sync"
+
+	"github.com/sourcegraph/go-lsp"
+	"golang.org/x/sync/semaphore"
+
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/backend"
+	"github.com/sourcegraph/sourcegraph/internal/conf"
+	"github.com/sourcegraph/sourcegraph/internal/goroutine"
+	"github.com/sourcegraph/sourcegraph/internal/search"
+	"github.com/sourcegraph/sourcegraph/internal/search/result"
+	"github.com/sourcegraph/sourcegraph/internal/search/streaming"
+)
+
+// scopeKinds are the symbol kinds that can enclose a line of code.
+var scopeKinds = map[lsp.SymbolKind]struct{}{
+	lsp.SKModule:      {},
+	lsp.SKNamespace:   {},
+	lsp.SKClass:       {},
+	lsp.SKMethod:      {},
+	lsp.SKConstructor: {},
+	lsp.SKEnum:        {},
+	lsp.SKInterface:   {},
+	lsp.SKFunction:    {},
+	lsp.SKStruct:      {},
+}
+
+// MockListFileSymbols mocks listing the symbols of a file in
+// ResolveEnclosingSymbols.
+var MockListFileSymbols func(ctx context.Context, file *result.File) (result.Symbols, error)
+
+// ResolveEnclosingSymbols sets the symbols of content matches to the symbols
+// that enclose their line matches, so that select:symbol can be applied to
+// them. File matches that already have symbols are left as is. A line is
+// attributed to the symbol defined on it, or else to the innermost scope (e.g.
+// function or class) that starts above it and ends below it. Only some parsers
+// of the symbols service record where symbols end; ctags doesn't, so lines of
+// files parsed by ctags are only attributed to symbols defined on them.
+//
+// Files whose symbols cannot be listed get no symbols, and so are dropped by
+// select:symbol.
+func ResolveEnclosingSymbols(ctx context.Context, matches []result.Match) {
+	resolveEnclosingSymbols(ctx, matches, semaphore.NewWeighted(int64(conf.SearchSymbolsParallelism())))
+}
+
+// resolveEnclosingSymbols is ResolveEnclosingSymbols, with the number of
+// files whose symbols are listed concurrently limited by sem.
+func resolveEnclosingSymbols(ctx context.Context, matches []result.Match, sem *semaphore.Weighted) {
+	var wg sync.WaitGroup
+	for _, match := range matches {
+		fm, ok := match.(*result.FileMatch)
+		if !ok || len(fm.Symbols) > 0 || len(fm.LineMatches) == 0 {
+			continue
+		}
+		if err := sem.Acquire(ctx, 1); err != nil {
+			break
+		}
+
+		wg.Add(1)
+		goroutine.Go(func() {
+			defer wg.Done()
+			defer sem.Release(1)
+
+			// Errors are not fatal, they only mean the match has no
+			// symbols.
+			symbols, err := listFileSymbols(ctx, &fm.File)
+			if err != nil {
+				return
+			}
+			fm.Symbols = enclosingSymbols(&fm.File, symbols, fm.LineMatches)
+		})
+	}
+	wg.Wait()
+}
+
+// WithEnclosingSymbols returns a child Stream of parent that calls
+// ResolveEnclosingSymbols on the results of each event. Events may be sent
+// concurrently. Their symbols are listed within a shared limit, so that
+// concurrent searches do not multiply the symbols parallelism, but an event
+// never waits for the symbols of another one.
+func WithEnclosingSymbols(ctx context.Context, parent streaming.Sender) streaming.Sender {
+	sem := semaphore.NewWeighted(int64(conf.SearchSymbolsParallelism()))
+	return streaming.StreamFunc(func(e streaming.SearchEvent) {
+		resolveEnclosingSymbols(ctx, e.Results, sem)
+		parent.Send(e)
+	})
+}
+
+func listFileSymbols(ctx context.Context, file *result.File) (result.Symbols, error) {
+	if MockListFileSymbols != nil {
+		return MockListFileSymbols(ctx, file)
+	}
+	return backend.Symbols.ListTags(ctx, search.SymbolsParameters{
+		Repo:     file.Repo.Name,
+		CommitID: file.CommitID,
+		// An exact path pattern lets the symbols service use its index on
+		// paths.
+		IncludePatterns: []string{"^" + regexp.QuoteMeta(file.Path) + "$"},
+		IsCaseSensitive: true,
+		First:           -1, // The symbols service caps this at its maximum.
+	})
+}
+
+// enclosingSymbols returns the symbols that enclose lineMatches, in order of
+// appearance and without duplicates.
+func enclosingSymbols(file *result.File, symbols result.Symbols, lineMatches []*result.LineMatch) []*result.SymbolMatch {
+	sort.SliceStable(symbols, func(i, j int) bool { return symbols[i].Line < symbols[j].Line })
+
+	seen := make(map[int]struct{})
+	var enclosing []int
+	for _, lm := range lineMatches {
+		// Symbol lines are 1-based, line matches are 0-based.
+		line := int(lm.LineNumber) + 1
+
+		// Index of the first symbol after line.
+		after := sort.Search(len(symbols), func(i int) bool { return symbols[i].Line > line })
+
+		found := -1
+		if after > 0 && symbols[after-1].Line == line {
+			found = after - 1
+		} else {
+			// Symbols are sorted by start line, so the first scope above
+			// line that hasn't ended before it is the innermost one.
+			for i := after - 1; i >= 0; i-- {
+				if _, ok := scopeKinds[symbols[i].LSPKind()]; ok && symbols[i].EndLine >= line {
+					found = i
+					break
+				}
+			}
+		}
+		if found < 0 {
+			continue
+		}
+		if _, ok := seen[found]; !ok {
+			seen[found] = struct{}{}
+			enclosing = append(enclosing, found)
+		}
+	}
+
+	sort.Ints(enclosing)
+	matches := make([]*result.SymbolMatch, 0, len(enclosing))
+	for _, i := range enclosing {
+		matches = append(matches, &result.SymbolMatch{
+			Symbol: symbols[i],
+			File:   file,
+		})
+	}
+	return matches
+}
@@ -0,0 +1,154 @@
+package symbol
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/cockroachdb/errors"
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/sourcegraph/sourcegraph/internal/conf"
+	"github.com/sourcegraph/sourcegraph/internal/search/filter"
+	"github.com/sourcegraph/sourcegraph/internal/search/result"
+	"github.com/sourcegraph/sourcegraph/internal/search/streaming"
+	"github.com/sourcegraph/sourcegraph/internal/types"
+)
+
+func TestResolveEnclosingSymbols(t *testing.T) {
+	conf.Mock(&conf.Unified{})
+	defer conf.Mock(nil)
+
+	MockListFileSymbols = func(ctx context.Context, file *result.File) (result.Symbols, error) {
+		switch file.Path {
+		case "broken.go":
+			return nil, errors.New("boom")
+		case "ctags.go":
+			// Parsed by ctags, which doesn't record where symbols end.
+			return result.Symbols{{Name: "f", Kind: "func", Line: 1}}, nil
+		}
+		// Deliberately out of order.
+		return result.Symbols{
+			{Name: "b", Kind: "func", Line: 10, EndLine: 12},
+			{Name: "Server", Kind: "struct", Line: 1, EndLine: 3},
+			{Name: "addr", Kind: "member", Line: 2, EndLine: 2},
+			{Name: "a", Kind: "method", Line: 5, EndLine: 7},
+			{Name: "limit", Kind: "const", Line: 9, EndLine: 9},
+		}, nil
+	}
+	defer func() { MockListFileSymbols = nil }()
+
+	lineMatches := func(lines ...int32) []*result.LineMatch {
+		var lms []*result.LineMatch
+		for _, line := range lines {
+			lms = append(lms, &result.LineMatch{LineNumber: line, OffsetAndLengths: [][2]int32{{0, 1}}})
+		}
+		return lms
+	}
+	repo := types.RepoName{Name: "repo"}
+
+	content := &result.FileMatch{
+		File: result.File{Repo: repo, Path: "server.go"},
+		// 0-based lines: within a, within a, after a, on limit, within b.
+		LineMatches: lineMatches(5, 6, 7, 8, 11),
+	}
+	ctags := &result.FileMatch{
+		File: result.File{Repo: repo, Path: "ctags.go"},
+		// 0-based line below f.
+		LineMatches: lineMatches(1),
+	}
+	broken := &result.FileMatch{
+		File:        result.File{Repo: repo, Path: "broken.go"},
+		LineMatches: lineMatches(0),
+	}
+	symbolMatch := &result.SymbolMatch{Symbol: result.Symbol{Name: "x", Kind: "func"}}
+	symbols := &result.FileMatch{
+		File:    result.File{Repo: repo, Path: "symbols.go"},
+		Symbols: []*result.SymbolMatch{symbolMatch},
+	}
+
+	ResolveEnclosingSymbols(context.Background(), []result.Match{content, ctags, broken, symbols, &result.RepoMatch{Name: "repo"}})
+
+	var got []string
+	for _, s := range content.Symbols {
+		got = append(got, s.Symbol.Name)
+	}
+	if diff := cmp.Diff([]string{"a", "limit", "b"}, got); diff != "" {
+		t.Errorf("unexpected enclosing symbols (-want +got):\n%s", diff)
+	}
+	// Lines below a symbol whose end isn't known are not attributed to it.
+	if len(ctags.Symbols) != 0 {
+		t.Errorf("expected no enclosing symbols for lines below a symbol without an end line, got %d", len(ctags.Symbols))
+	}
+	if content.Symbols[0].File != &content.File {
+		t.Error("expected enclosing symbols to refer to the file match")
+	}
+	if len(broken.Symbols) != 0 {
+		t.Errorf("expected no symbols for a file whose symbols cannot be listed, got %d", len(broken.Symbols))
+	}
+	if len(symbols.Symbols) != 1 || symbols.Symbols[0] != symbolMatch {
+		t.Error("expected symbol matches to be left as is")
+	}
+
+	// select:symbol.function keeps only the function.
+	selected := content.Select(filter.SelectPath{filter.Symbol, "function"})
+	if selected == nil {
+		t.Fatal("expected the file match to be selected")
+	}
+	fm := selected.(*result.FileMatch)
+	if len(fm.Symbols) != 1 || fm.Symbols[0].Symbol.Name != "b" || len(fm.LineMatches) != 0 {
+		t.Errorf("unexpected selection %+v", fm)
+	}
+}
+
+func TestWithEnclosingSymbols(t *testing.T) {
+	conf.Mock(&conf.Unified{})
+	defer conf.Mock(nil)
+
+	// The symbols of slow.go can only be listed once unblock is closed.
+	unblock := make(chan struct{})
+	MockListFileSymbols = func(ctx context.Context, file *result.File) (result.Symbols, error) {
+		if file.Path == "slow.go" {
+			<-unblock
+		}
+		return result.Symbols{{Name: "f", Kind: "func", Line: 1}}, nil
+	}
+	defer func() { MockListFileSymbols = nil }()
+
+	fileMatch := func(path string) *result.FileMatch {
+		return &result.FileMatch{
+			File:        result.File{Repo: types.RepoName{Name: "repo"}, Path: path},
+			LineMatches: []*result.LineMatch{{LineNumber: 0, OffsetAndLengths: [][2]int32{{0, 1}}}},
+		}
+	}
+
+	sent := make(chan *result.FileMatch, 2)
+	stream := WithEnclosingSymbols(context.Background(), streaming.StreamFunc(func(e streaming.SearchEvent) {
+		for _, m := range e.Results {
+			sent <- m.(*result.FileMatch)
+		}
+	}))
+
+	slow := fileMatch("slow.go")
+	go stream.Send(streaming.SearchEvent{Results: []result.Match{slow}})
+
+	// An event doesn't wait for the symbols of another one.
+	fast := fileMatch("fast.go")
+	go stream.Send(streaming.SearchEvent{Results: []result.Match{fast}})
+	select {
+	case fm := <-sent:
+		if fm != fast {
+			t.Fatalf("expected %s to be sent first, got %s", fast.Path, fm.Path)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("event was blocked by a concurrent event")
+	}
+	if len(fast.Symbols) != 1 {
+		t.Errorf("expected 1 enclosing symbol, got %d", len(fast.Symbols))
+	}
+
+	close(unblock)
+	if fm := <-sent; fm != slow || len(fm.Symbols) != 1 {
+		t.Errorf("unexpected match %+v", fm)
+	}
+}
@@ -52,6 +52,10 @@ type CommitPatternInfo struct {
 
 	PathPatternsAreRegExps       bool
 	PathPatternsAreCaseSensitive bool
+
+	// Languages is the languages passed via the lang filters (e.g.,
+	// "lang:c"). Changes are only included for files in these languages.
+	Languages []string
 }
 
 type SymbolsParameters struct {
@@ -180,6 +184,7 @@ type TextPatternInfo struct {
 	IsRegExp        bool
 	IsStructuralPat bool
 	CombyRule       string
+	CombyRewrite    string
 	IsWordMatch     bool
 	IsCaseSensitive bool
 	FileMatchLimit  int32
@@ -213,6 +218,9 @@ func (p *TextPatternInfo) String() string {
 		} else {
 			args = append(args, "comby")
 		}
+		if p.CombyRewrite != "" {
+			args = append(args, fmt.Sprintf("rewrite:%q", p.CombyRewrite))
+		}
 	}
 	if p.IsWordMatch {
 		args = append(args, "word")
@@ -177,6 +177,14 @@ func searchFilesInRepo(ctx context.Context, searcherURLs *endpoint.Map, repo typ
 			})
 		}
 
+		var matchRanges []result.Range
+		for _, r := range fm.MatchRanges {
+			matchRanges = append(matchRanges, result.Range{
+				Start: result.Location{Offset: int32(r.Start.Offset), Line: int32(r.Start.Line), Column: int32(r.Start.Column)},
+				End:   result.Location{Offset: int32(r.End.Offset), Line: int32(r.End.Line), Column: int32(r.End.Column)},
+			})
+		}
+
 		matches = append(matches, &result.FileMatch{
 			File: result.File{
 				Path:     fm.Path,
@@ -185,6 +193,8 @@ func searchFilesInRepo(ctx context.Context, searcherURLs *endpoint.Map, repo typ
 				InputRev: &rev,
 			},
 			LineMatches: lineMatches,
+			MatchRanges: matchRanges,
+			Diff:        fm.Diff,
 			LimitHit:    fm.LimitHit,
 		})
 	}
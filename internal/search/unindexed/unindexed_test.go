@@ -305,6 +305,39 @@ func TestRepoShouldBeSearched(t *testing.T) {
 	}
 }
 
+func TestSearchFilesInRepo_rewrite(t *testing.T) {
+	git.Mocks.ResolveRevision = func(spec string, opt git.ResolveRevisionOptions) (api.CommitID, error) {
+		return "1a2b3c", nil
+	}
+	defer func() { git.Mocks.ResolveRevision = nil }()
+
+	diff := "--- main.go\n+++ main.go\n@@ -1,1 +1,1 @@\n-foo(a, b)\n+foo(b, a)"
+	searcher.MockSearch = func(ctx context.Context, repo api.RepoName, commit api.CommitID, p *search.TextPatternInfo, fetchTimeout time.Duration) (matches []*protocol.FileMatch, limitHit bool, err error) {
+		if p.CombyRewrite != "foo(:[y], :[x])" {
+			return nil, false, errors.Errorf("unexpected rewrite template %q", p.CombyRewrite)
+		}
+		return []*protocol.FileMatch{{Path: "main.go", Diff: diff, MatchCount: 1}}, false, nil
+	}
+	defer func() { searcher.MockSearch = nil }()
+
+	info := &search.TextPatternInfo{
+		FileMatchLimit: search.DefaultMaxSearchResults,
+		Pattern:        "foo(:[x], :[y])",
+		CombyRewrite:   "foo(:[y], :[x])",
+	}
+	repo := mkRepos("foo/one")[0]
+	matches, _, err := searchFilesInRepo(context.Background(), endpoint.Static("test"), repo, repo.Name, "", false, info, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(matches) != 1 {
+		t.Fatalf("expected 1 match, got %d", len(matches))
+	}
+	if have := matches[0].(*result.FileMatch).Diff; have != diff {
+		t.Errorf("unexpected diff: have=%q want=%q", have, diff)
+	}
+}
+
 func makeRepositoryRevisions(repos ...string) []*search.RepositoryRevisions {
 	r := make([]*search.RepositoryRevisions, len(repos))
 	for i, repospec := range repos {
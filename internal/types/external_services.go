@@ -16,6 +16,12 @@ type GitHubConnection struct {
 	*schema.GitHubConnection
 }
 
+type GiteaConnection struct {
+	// The unique resource identifier of the external service.
+	URN string
+	*schema.GiteaConnection
+}
+
 type GitLabConnection struct {
 	// The unique resource identifier of the external service.
 	URN string
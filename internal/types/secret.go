@@ -57,12 +57,23 @@ func (e *ExternalService) RedactConfigSecrets() error {
 		newCfg, err = redactField(e.Config, fields...)
 	case *schema.BitbucketCloudConnection:
 		newCfg, err = redactField(e.Config, "appPassword")
+	case *schema.GiteaConnection:
+		newCfg, err = redactField(e.Config, "token")
+	case *schema.AzureDevOpsConnection:
+		newCfg, err = redactField(e.Config, "token")
 	case *schema.AWSCodeCommitConnection:
 		newCfg, err = redactField(e.Config, "secretAccessKey")
 	case *schema.PhabricatorConnection:
 		newCfg, err = redactField(e.Config, "token")
 	case *schema.PerforceConnection:
 		newCfg, err = redactField(e.Config, "p4.passwd")
+	case *schema.MercurialConnection:
+		// Repositories cloned over SSH don't need a password
+		var fields []string
+		if cfg.Password != "" {
+			fields = append(fields, "password")
+		}
+		newCfg, err = redactField(e.Config, fields...)
 	case *schema.GitoliteConnection:
 		// Gitolite has no secret fields
 		newCfg, err = redactField(e.Config)
@@ -70,6 +81,15 @@ func (e *ExternalService) RedactConfigSecrets() error {
 		newCfg, err = redactField(e.Config, "url")
 	case *schema.JVMPackagesConnection:
 		newCfg, err = e.Config, nil
+	case *schema.GoModulesConnection:
+		newCfg, err = e.Config, nil
+	case *schema.NPMPackagesConnection:
+		// Public registries don't need credentials
+		var fields []string
+		if cfg.Credentials != "" {
+			fields = append(fields, "credentials")
+		}
+		newCfg, err = redactField(e.Config, fields...)
 	default:
 		// return an error here, it's safer to fail than to incorrectly return unsafe data.
 		err = errors.Errorf("RedactExternalServiceConfig: kind %q not implemented", e.Kind)
@@ -134,12 +154,23 @@ func (e *ExternalService) UnredactConfig(old *ExternalService) error {
 		unredacted, err = unredactField(old.Config, e.Config, &cfg, fields...)
 	case *schema.BitbucketCloudConnection:
 		unredacted, err = unredactField(old.Config, e.Config, &cfg, jsonStringField{"appPassword", &cfg.AppPassword})
+	case *schema.GiteaConnection:
+		unredacted, err = unredactField(old.Config, e.Config, &cfg, jsonStringField{"token", &cfg.Token})
+	case *schema.AzureDevOpsConnection:
+		unredacted, err = unredactField(old.Config, e.Config, &cfg, jsonStringField{"token", &cfg.Token})
 	case *schema.AWSCodeCommitConnection:
 		unredacted, err = unredactField(old.Config, e.Config, &cfg, jsonStringField{"secretAccessKey", &cfg.SecretAccessKey})
 	case *schema.PhabricatorConnection:
 		unredacted, err = unredactField(old.Config, e.Config, &cfg, jsonStringField{"token", &cfg.Token})
 	case *schema.PerforceConnection:
 		unredacted, err = unredactField(old.Config, e.Config, &cfg, jsonStringField{"p4.passwd", &cfg.P4Passwd})
+	case *schema.MercurialConnection:
+		// Repositories cloned over SSH don't need a password
+		var fields []jsonStringField
+		if cfg.Password != "" {
+			fields = append(fields, jsonStringField{"password", &cfg.Password})
+		}
+		unredacted, err = unredactField(old.Config, e.Config, &cfg, fields...)
 	case *schema.GitoliteConnection:
 		// no secret fields?
 		unredacted, err = unredactField(old.Config, e.Config, &cfg)
@@ -147,6 +178,15 @@ func (e *ExternalService) UnredactConfig(old *ExternalService) error {
 		unredacted, err = unredactField(old.Config, e.Config, &cfg, jsonStringField{"url", &cfg.Url})
 	case *schema.JVMPackagesConnection:
 		unredacted, err = e.Config, nil
+	case *schema.GoModulesConnection:
+		unredacted, err = e.Config, nil
+	case *schema.NPMPackagesConnection:
+		// Public registries don't need credentials
+		var fields []jsonStringField
+		if cfg.Credentials != "" {
+			fields = append(fields, jsonStringField{"credentials", &cfg.Credentials})
+		}
+		unredacted, err = unredactField(old.Config, e.Config, &cfg, fields...)
 	default:
 		// return an error here, it's safer to fail than to incorrectly return unsafe data.
 		err = errors.Errorf("UnRedactExternalServiceConfig: kind %q not implemented", e.Kind)
@@ -32,6 +32,14 @@ func TestRoundTripRedactExternalServiceConfig(t *testing.T) {
 		Token: someSecret,
 		Url:   "https://bitbucket.com",
 	}
+	giteaConfig := schema.GiteaConnection{
+		Token: someSecret,
+		Url:   "https://gitea.example.com",
+	}
+	azureDevOpsConfig := schema.AzureDevOpsConnection{
+		Token: someSecret,
+		Url:   "https://dev.azure.com",
+	}
 	awsCodeCommitConfig := schema.AWSCodeCommitConnection{
 		SecretAccessKey: someSecret,
 		Region:          "us-east-9000z",
@@ -47,6 +55,11 @@ func TestRoundTripRedactExternalServiceConfig(t *testing.T) {
 		P4Passwd: someSecret,
 		P4User:   "admin",
 	}
+	mercurialConfig := schema.MercurialConnection{
+		Url:      "https://hg.example.com",
+		Username: "admin",
+		Password: someSecret,
+	}
 	otherConfig := schema.OtherExternalServiceConnection{
 		Url:                   someSecret,
 		RepositoryPathPattern: "foo",
@@ -88,6 +101,18 @@ func TestRoundTripRedactExternalServiceConfig(t *testing.T) {
 			editField:   &bitbucketServerConfigWithToken.Url,
 			secretField: &bitbucketServerConfigWithToken.Token,
 		},
+		{
+			kind:        extsvc.KindGitea,
+			config:      &giteaConfig,
+			editField:   &giteaConfig.Url,
+			secretField: &giteaConfig.Token,
+		},
+		{
+			kind:        extsvc.KindAzureDevOps,
+			config:      &azureDevOpsConfig,
+			editField:   &azureDevOpsConfig.Url,
+			secretField: &azureDevOpsConfig.Token,
+		},
 		{
 			kind:        extsvc.KindAWSCodeCommit,
 			config:      &awsCodeCommitConfig,
@@ -111,6 +136,12 @@ func TestRoundTripRedactExternalServiceConfig(t *testing.T) {
 			editField:   &perforceConfig.P4User,
 			secretField: &perforceConfig.P4Passwd,
 		},
+		{
+			kind:        extsvc.KindMercurial,
+			config:      &mercurialConfig,
+			editField:   &mercurialConfig.Username,
+			secretField: &mercurialConfig.Password,
+		},
 		{
 			kind:        extsvc.KindOther,
 			config:      &otherConfig,
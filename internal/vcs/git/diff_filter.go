@@ -12,6 +12,7 @@ import (
 	"github.com/cockroachdb/errors"
 	"github.com/sourcegraph/go-diff/diff"
 
+	"github.com/sourcegraph/sourcegraph/internal/langmatch"
 	"github.com/sourcegraph/sourcegraph/internal/pathmatch"
 )
 
@@ -24,8 +25,9 @@ func compilePathMatcher(options PathOptions) (pathmatch.PathMatcher, error) {
 }
 
 // filterAndHighlightDiff returns the raw diff with query matches highlighted
-// and only hunks that satisfy the query (if onlyMatchingHunks) and path matcher.
-func filterAndHighlightDiff(rawDiff []byte, query *regexp.Regexp, onlyMatchingHunks bool, pathMatcher pathmatch.PathMatcher) (_ []byte, _ []Highlight, err error) {
+// and only hunks that satisfy the query (if onlyMatchingHunks), path matcher
+// and language matcher. langMatcher may be nil.
+func filterAndHighlightDiff(rawDiff []byte, query *regexp.Regexp, onlyMatchingHunks bool, pathMatcher pathmatch.PathMatcher, langMatcher *langmatch.LangMatcher) (_ []byte, _ []Highlight, err error) {
 	// go-diff has been known to panic. Until we are sure it has been written
 	// to avoid panics, we protect calles from the panic. eg
 	// https://github.com/sourcegraph/go-diff/issues/54
@@ -63,6 +65,13 @@ func filterAndHighlightDiff(rawDiff []byte, query *regexp.Regexp, onlyMatchingHu
 			continue
 		}
 
+		// Exclude files in other languages. The hunks are only an excerpt of
+		// the file, but usually enough to tell apart languages that share an
+		// extension.
+		if langMatcher != nil && !langMatcher.MatchLang(diffFileName(fileDiff), hunksContent(fileDiff.Hunks)) {
+			continue
+		}
+
 		// TODO(sqs): preserve the "no newline" message. We clear it out because our truncateLongLines
 		// and splitHunkMatches funcs don't properly adjust its offset as they modify hunk.Body. If
 		// the OrigNoNewlineAt points to an out-of-bounds offset, a panic will occur.
@@ -138,6 +147,28 @@ func filterAndHighlightDiff(rawDiff []byte, query *regexp.Regexp, onlyMatchingHu
 	return rawDiff, highlights, nil
 }
 
+// diffFileName returns the name of the file after the change, or before the
+// change if it was deleted.
+func diffFileName(fileDiff *diff.FileDiff) string {
+	if fileDiff.NewName == "/dev/null" {
+		return fileDiff.OrigName
+	}
+	return fileDiff.NewName
+}
+
+// hunksContent returns the lines of hunks without their line status.
+func hunksContent(hunks []*diff.Hunk) []byte {
+	var content []byte
+	for _, hunk := range hunks {
+		for _, line := range bytes.SplitAfter(hunk.Body, []byte("\n")) {
+			if len(line) > 0 {
+				content = append(content, line[1:]...)
+			}
+		}
+	}
+	return content
+}
+
 func truncateLongLines(data []byte, maxCharsPerLine int) []byte {
 	// We reuse data's storage to avoid allocation.
 
@@ -8,6 +8,8 @@ import (
 	"testing"
 
 	"github.com/sourcegraph/go-diff/diff"
+
+	"github.com/sourcegraph/sourcegraph/internal/langmatch"
 )
 
 func TestFilterAndHighlightDiff(t *testing.T) {
@@ -86,7 +88,7 @@ index a29bdeb434d874c9b1d8969c40c42161b03fafdc..c0d0fb45c382919737f8d0c20aaf57cf
 			if err != nil {
 				t.Fatal(err)
 			}
-			rawDiff, highlights, err := filterAndHighlightDiff([]byte(test.rawDiff), query, true, pathMatcher)
+			rawDiff, highlights, err := filterAndHighlightDiff([]byte(test.rawDiff), query, true, pathMatcher, nil)
 			if err != nil {
 				t.Fatal(err)
 			}
@@ -100,6 +102,57 @@ index a29bdeb434d874c9b1d8969c40c42161b03fafdc..c0d0fb45c382919737f8d0c20aaf57cf
 	}
 }
 
+func TestFilterAndHighlightDiffLanguages(t *testing.T) {
+	const rawDiff = `diff --git a.h a.h
+index a29bdeb434d874c9b1d8969c40c42161b03fafdc..c0d0fb45c382919737f8d0c20aaf57cf89b74af8 100644
+--- a.h
++++ a.h
+@@ -1,1 +1,2 @@
+ @interface Foo : NSObject
++- (void)foo;
+diff --git b.h b.h
+index a29bdeb434d874c9b1d8969c40c42161b03fafdc..c0d0fb45c382919737f8d0c20aaf57cf89b74af8 100644
+--- b.h
++++ b.h
+@@ -1,1 +1,2 @@
+ namespace foo {
++class Bar {};
+`
+
+	pathMatcher, err := compilePathMatcher(PathOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tc := range []struct {
+		languages []string
+		want      []string
+	}{
+		{languages: []string{"objective-c"}, want: []string{"a.h"}},
+		{languages: []string{"c++"}, want: []string{"b.h"}},
+		{languages: []string{"go"}, want: nil},
+	} {
+		langMatcher, err := langmatch.Compile(tc.languages)
+		if err != nil {
+			t.Fatal(err)
+		}
+		filtered, _, err := filterAndHighlightDiff([]byte(rawDiff), nil, false, pathMatcher, langMatcher)
+		if err != nil {
+			t.Fatal(err)
+		}
+		fileDiffs, err := diff.ParseMultiFileDiff(filtered)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got []string
+		for _, fd := range fileDiffs {
+			got = append(got, fd.NewName)
+		}
+		if !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("languages %q: got files %v, want %v", tc.languages, got, tc.want)
+		}
+	}
+}
+
 func TestSplitHunkMatches(t *testing.T) {
 	tests := []struct {
 		hunks             string
@@ -16,6 +16,7 @@ import (
 
 	"github.com/sourcegraph/sourcegraph/internal/api"
 	"github.com/sourcegraph/sourcegraph/internal/gitserver"
+	"github.com/sourcegraph/sourcegraph/internal/langmatch"
 	"github.com/sourcegraph/sourcegraph/internal/pathmatch"
 	"github.com/sourcegraph/sourcegraph/internal/trace"
 )
@@ -34,6 +35,7 @@ type PathOptions struct {
 	ExcludePattern  string   // exclude paths matching any of these patterns
 	IsRegExp        bool     // whether the pattern is a regexp (if false, treated as exact string)
 	IsCaseSensitive bool     // whether the pattern should be matched case-sensitively
+	Languages       []string // include only files in all of these languages (lang: values)
 }
 
 // CompilePathMatcher compiles the path options into a PathMatcher.
@@ -354,6 +356,10 @@ func rawShowSearch(ctx context.Context, repo api.RepoName, opt RawLogDiffSearchO
 	if err != nil {
 		return nil, false, err
 	}
+	langMatcher, err := langmatch.Compile(opt.Paths.Languages)
+	if err != nil {
+		return nil, false, err
+	}
 
 	// Now fetch the full commit data for all of the commits.
 	commitOIDs := make([]string, len(onelineCommits))
@@ -368,7 +374,7 @@ func rawShowSearch(ctx context.Context, repo api.RepoName, opt RawLogDiffSearchO
 	// Need --patch (TODO(sqs): or just --raw, which is smaller) if we are filtering by file paths,
 	// because we post-filter by path since we need to support regexps. Just the commit message
 	// alone would be insufficient for our post-filtering.
-	hasPathFilters := opt.Paths.ExcludePattern != "" || len(opt.Paths.IncludePatterns) > 0
+	hasPathFilters := opt.Paths.ExcludePattern != "" || len(opt.Paths.IncludePatterns) > 0 || len(opt.Paths.Languages) > 0
 	if hasPathFilters {
 		showArgs = append(showArgs, "--patch")
 	}
@@ -441,7 +447,7 @@ func rawShowSearch(ctx context.Context, repo api.RepoName, opt RawLogDiffSearchO
 			}
 
 			var err error
-			rawDiff, result.DiffHighlights, err = filterAndHighlightDiff(rawDiff, query, opt.OnlyMatchingHunks, pathMatcher)
+			rawDiff, result.DiffHighlights, err = filterAndHighlightDiff(rawDiff, query, opt.OnlyMatchingHunks, pathMatcher, langMatcher)
 			if err != nil {
 				return nil, false, err
 			}
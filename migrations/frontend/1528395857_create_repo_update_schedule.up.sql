@@ -0,0 +1,13 @@
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS repo_update_schedule
+(
+    repo_id int REFERENCES repo(id) ON DELETE CASCADE PRIMARY KEY,
+    interval_seconds int NOT NULL,
+    due_at timestamp WITH TIME ZONE NOT NULL,
+    last_updated_at timestamp WITH TIME ZONE,
+    last_error text,
+    updated_at timestamp WITH TIME ZONE default now() not null
+);
+
+COMMIT;
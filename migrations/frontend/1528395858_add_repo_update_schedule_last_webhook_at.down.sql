@@ -0,0 +1,5 @@
+BEGIN;
+
+ALTER TABLE IF EXISTS repo_update_schedule DROP COLUMN IF EXISTS last_webhook_at;
+
+COMMIT;
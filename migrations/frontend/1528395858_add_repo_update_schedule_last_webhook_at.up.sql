@@ -0,0 +1,5 @@
+BEGIN;
+
+ALTER TABLE IF EXISTS repo_update_schedule ADD COLUMN IF NOT EXISTS last_webhook_at timestamp WITH TIME ZONE;
+
+COMMIT;
@@ -0,0 +1,5 @@
+BEGIN;
+
+ALTER TABLE IF EXISTS changesets ADD COLUMN IF NOT EXISTS publication_blocked boolean DEFAULT false NOT NULL;
+
+COMMIT;
@@ -6,6 +6,10 @@ import _ "embed"
 //go:embed aws_codecommit.schema.json
 var AWSCodeCommitSchemaJSON string
 
+// AzureDevOpsSchemaJSON is the content of the file "azuredevops.schema.json".
+//go:embed azuredevops.schema.json
+var AzureDevOpsSchemaJSON string
+
 // BatchSpecSchemaJSON is the content of the file "batch_spec.schema.json".
 //go:embed batch_spec.schema.json
 var BatchSpecSchemaJSON string
@@ -30,14 +34,30 @@ var GitHubSchemaJSON string
 //go:embed gitlab.schema.json
 var GitLabSchemaJSON string
 
+// GiteaSchemaJSON is the content of the file "gitea.schema.json".
+//go:embed gitea.schema.json
+var GiteaSchemaJSON string
+
 // GitoliteSchemaJSON is the content of the file "gitolite.schema.json".
 //go:embed gitolite.schema.json
 var GitoliteSchemaJSON string
 
+// GoModulesSchemaJSON is the content of the file "go-modules.schema.json".
+//go:embed go-modules.schema.json
+var GoModulesSchemaJSON string
+
 // JVMPackagesSchemaJSON is the content of the file "jvm-packages.schema.json".
 //go:embed jvm-packages.schema.json
 var JVMPackagesSchemaJSON string
 
+// MercurialSchemaJSON is the content of the file "mercurial.schema.json".
+//go:embed mercurial.schema.json
+var MercurialSchemaJSON string
+
+// NPMPackagesSchemaJSON is the content of the file "npm-packages.schema.json".
+//go:embed npm-packages.schema.json
+var NPMPackagesSchemaJSON string
+
 // OtherExternalServiceSchemaJSON is the content of the file "other_external_service.schema.json".
 //go:embed other_external_service.schema.json
 var OtherExternalServiceSchemaJSON string